@@ -1,16 +1,21 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/cli"
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/metrics"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/mux"
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/ui"
 )
 
@@ -26,7 +31,9 @@ func main() {
 	listFlag := flag.Bool("l", false, "List and select from saved SSH connections")
 	initFlag := flag.Bool("i", false, "Initialize SSH config and perform first-time migration")
 	connectFlag := flag.String("c", "", "Connect directly to a saved connection by ID using golang SSH client")
+	fileFlag := flag.String("f", "", "Declarative YAML file to reconcile connections against on startup")
 	versionFlag := flag.Bool("v", false, "Show version information")
+	debugBundleFlag := flag.Bool("debug-bundle", false, "Write a redacted diagnostic archive for bug reports")
 	helpFlag := flag.Bool("h", false, "Show help")
 	flag.Parse()
 
@@ -42,8 +49,23 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *fileFlag != "" {
+		path, err := filepath.Abs(config.ExpandPath(*fileFlag))
+		if err != nil {
+			log.Fatalf("Invalid declarative file path: %v", err)
+		}
+		config.DeclarativeFilePath = path
+	}
+
+	settings := loadSettings()
+	config.SetCurrentSettings(settings)
+
 	logfilePath := os.Getenv("SSH_X_TERM_LOG")
-	if logfilePath == "" {
+	if logfilePath == "" && !settings.LogEnabled {
+		log.SetOutput(io.Discard)
+	} else if logfilePath == "" && settings.LogFile != "" {
+		logfilePath = config.ExpandPath(settings.LogFile)
+	} else if logfilePath == "" {
 		homeDir, err := os.UserHomeDir()
 		if err != nil {
 			log.Fatalf("Unable to get user home directory: %v", err)
@@ -55,15 +77,54 @@ func main() {
 		logfilePath = filepath.Join(configDir, "sxt.log")
 	}
 
-	logCloser, err := tea.LogToFile(logfilePath, "")
-	if err != nil {
-		log.Fatalf("Unable to set Bubble Tea log file: %v", err)
+	if logfilePath != "" {
+		logCloser, err := tea.LogToFile(logfilePath, "")
+		if err != nil {
+			log.Fatalf("Unable to set Bubble Tea log file: %v", err)
+		}
+		config.LogFilePath = logfilePath
+		// Passwords and keys can show up in errors and command lines
+		log.SetOutput(config.NewRedactingWriter(logCloser))
+		defer func() {
+			if logCloser != nil {
+				logCloser.Close()
+			}
+		}()
+	}
+
+	if *debugBundleFlag {
+		path := config.DefaultDebugBundlePath()
+		if err := config.WriteDebugBundle(path, fmt.Sprintf("%s (built on %s)", Version, BuildDate), logfilePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing debug bundle: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Debug bundle written to %s\n", path)
+		fmt.Println("Secrets are redacted, but please look it over before attaching it to an issue.")
+		return
 	}
-	defer func() {
-		if logCloser != nil {
-			logCloser.Close()
+
+	// Handle non-interactive subcommands (sxt list, sxt scp, ...). A bare
+	// ssh:// URI, as passed by the system's link handler, means connect.
+	name, args := flag.Arg(0), flag.Args()
+	if cli.IsSSHURI(name) {
+		name, args = "connect", append([]string{"connect"}, args...)
+	}
+	if command, ok := cli.Commands[name]; ok {
+		if !isInitialized() {
+			fmt.Fprintln(os.Stderr, "Error: SSH-X-Term not initialized.")
+			fmt.Fprintln(os.Stderr, "Please run 'sxt -i' first to initialize and migrate your configuration.")
+			os.Exit(1)
+		}
+		if err := command(args[1:]); err != nil {
+			var exitErr *cli.ExitStatusError
+			if errors.As(err, &exitErr) {
+				os.Exit(exitErr.Status)
+			}
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
 		}
-	}()
+		return
+	}
 
 	// Handle -i flag for initialization
 	if *initFlag {
@@ -95,7 +156,11 @@ func main() {
 		return
 	}
 
-	if os.Getenv("TMUX") == "" {
+	// Without a multiplexer to open connections in, sxt starts itself
+	// inside a new tmux session
+	config.ActiveMultiplexer = mux.Detect(settings.Multiplexer)
+	wantTmux := settings.Multiplexer == mux.Tmux || settings.Multiplexer == "auto" && config.ActiveMultiplexer == ""
+	if wantTmux && os.Getenv("TMUX") == "" {
 		log.Println("Not in tmux session. Attempting to launch inside tmux...")
 
 		execPath, err := os.Executable()
@@ -113,24 +178,30 @@ func main() {
 
 		if err := cmd.Run(); err != nil {
 			log.Printf("Failed to start tmux session: %v\nFalling back to normal execution...\n", err)
-			config.IsTmuxAvailable = false
-			runApp()
+			config.ActiveMultiplexer = ""
+			runApp(settings)
 			return
 		}
 		return
 	}
 
-	config.IsTmuxAvailable = true
-	runApp()
+	runApp(settings)
 }
 
-func runApp() {
+func runApp(settings config.Settings) {
 	// Check and migrate from old JSON config if needed
 	if err := config.CheckAndMigrate(); err != nil {
 		log.Printf("Warning: migration failed: %v\n", err)
 		// Continue anyway - user can manually migrate
 	}
 
+	stopMetrics, err := metrics.Start(settings.MetricsAddress, config.ExpandPath(settings.StatsFile),
+		time.Duration(settings.StatsIntervalSeconds)*time.Second)
+	if err != nil {
+		log.Printf("Warning: %v\n", err)
+		stopMetrics = func() {}
+	}
+
 	// Create UI model
 	model := ui.NewModel()
 
@@ -142,26 +213,44 @@ func runApp() {
 	)
 
 	// Run the program
-	if _, err := p.Run(); err != nil {
+	_, err = p.Run()
+	model.RemoveTempKeyFiles()
+	stopMetrics()
+	if err != nil {
 		log.Printf("Error running program: %v\n", err)
+		if errors.Is(err, tea.ErrProgramPanic) {
+			fmt.Fprintln(os.Stderr, "sxt crashed. Please run 'sxt --debug-bundle' and attach the archive to a bug report.")
+		}
 		os.Exit(1)
 	}
 }
 
-func runQuickConnect() {
-	// Load SSH config directly
-	sshConfigManager, err := config.NewSSHConfigManager()
+// loadSettings reads settings.toml, falling back to the defaults when it
+// cannot be used
+func loadSettings() config.Settings {
+	path, err := config.DefaultSettingsPath()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading SSH config: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Warning: unable to locate settings: %v\n", err)
+		return config.DefaultSettings()
+	}
+	settings, err := config.LoadSettings(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; using default settings\n", err)
+		settings = config.DefaultSettings()
+		settings.Path = path
 	}
+	return settings
+}
 
-	if err := sshConfigManager.Load(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading connections: %v\n", err)
+func runQuickConnect() {
+	// Load the default storage directly
+	storage, err := cli.LoadStorage()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	connections := sshConfigManager.ListConnections()
+	connections := config.ExpandHostRanges(storage.ListConnections())
 	if len(connections) == 0 {
 		fmt.Println("No saved connections found.")
 		os.Exit(0)
@@ -186,10 +275,18 @@ func runQuickConnect() {
 		fmt.Println("Connection canceled.")
 		os.Exit(0)
 	}
+	if err := cli.ConfirmProduction(*choice, false); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if err := config.RecordConnection(storage, choice.ID, time.Now()); err != nil {
+		log.Printf("Failed to record connection %s: %v", choice.ID, err)
+	}
 
 	// Connect directly using native SSH client
 	fmt.Printf("Connecting to %s...\n", choice.Name)
-	if err := cli.ConnectDirect(*choice); err != nil {
+	if err := cli.ConnectDirect(storage, *choice); err != nil {
 		fmt.Fprintf(os.Stderr, "Connection failed: %v\n", err)
 		os.Exit(1)
 	}
@@ -247,25 +344,20 @@ func runInitialization() {
 }
 
 func runDirectConnect(connectionID string) {
-	// Load SSH config
-	sshConfigManager, err := config.NewSSHConfigManager()
+	// Load the default storage
+	storage, err := cli.LoadStorage()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading SSH config: %v\n", err)
-		os.Exit(1)
-	}
-
-	if err := sshConfigManager.Load(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading connections: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Get the connection by ID
-	conn, found := sshConfigManager.GetConnection(connectionID)
+	conn, found := storage.GetConnection(connectionID)
 	if !found {
 		fmt.Fprintf(os.Stderr, "Error: Connection with ID '%s' not found.\n", connectionID)
 		fmt.Fprintln(os.Stderr, "\nAvailable connections:")
 
-		connections := sshConfigManager.ListConnections()
+		connections := storage.ListConnections()
 		if len(connections) == 0 {
 			fmt.Fprintln(os.Stderr, "  (none)")
 		} else {
@@ -275,10 +367,18 @@ func runDirectConnect(connectionID string) {
 		}
 		os.Exit(1)
 	}
+	if err := cli.ConfirmProduction(conn, false); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if err := config.RecordConnection(storage, conn.ID, time.Now()); err != nil {
+		log.Printf("Failed to record connection %s: %v", conn.ID, err)
+	}
 
 	// Connect using golang SSH client (cli.ConnectDirect uses ssh.ConnectInteractive)
 	fmt.Printf("Connecting to %s...\n", conn.Name)
-	if err := cli.ConnectDirect(conn); err != nil {
+	if err := cli.ConnectDirect(storage, conn); err != nil {
 		fmt.Fprintf(os.Stderr, "Connection failed: %v\n", err)
 		os.Exit(1)
 	}
@@ -289,6 +389,7 @@ func printHelp() {
 	fmt.Println()
 	fmt.Println("Usage:")
 	fmt.Printf("  %s [flags]\n", os.Args[0])
+	fmt.Printf("  %s <command> [args]\n", os.Args[0])
 	fmt.Println()
 	fmt.Println("Flags:")
 	fmt.Println("  -h           Show this help message")
@@ -296,6 +397,13 @@ func printHelp() {
 	fmt.Println("  -i           Initialize SSH config and perform first-time migration")
 	fmt.Println("  -l           List and select from saved SSH connections")
 	fmt.Println("  -c <id>      Connect directly to a saved connection by ID")
+	fmt.Println("  -f <file>    Reconcile connections with a declarative YAML file on startup")
+	fmt.Println("               (default: $SXT_CONNECTIONS_FILE or ~/.config/ssh-x-term/connections.yaml)")
+	fmt.Println("  --debug-bundle")
+	fmt.Println("               Write a redacted zip of version info, settings and the log")
+	fmt.Println()
+	fmt.Println("Commands (use the local SSH config backend, no TUI):")
+	fmt.Println(cli.CommandUsage)
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  sxt              Start the interactive TUI")
@@ -304,6 +412,14 @@ func printHelp() {
 	fmt.Println("  sxt -i           Initialize configuration")
 	fmt.Println("  sxt -l           Quick connect mode")
 	fmt.Println("  sxt -c myserver  Connect to 'myserver'")
+	fmt.Println("  sxt -f infra/connections.yaml")
+	fmt.Println("                   Sync the team inventory, then start the TUI")
+	fmt.Println("  sxt connect prod-db")
+	fmt.Println("  sxt ssh://deploy@web.example.com:2222")
+	fmt.Println("  sxt exec web-1 uptime")
+	fmt.Println("  sxt scp -r web-1:/var/log/nginx ./logs")
+	fmt.Println("  echo \"$PASS\" | sxt add --name db --host 10.0.0.5 --user admin --password-stdin")
+	fmt.Println("  sxt export --format yaml -o backup.yaml")
 	fmt.Println()
 	fmt.Println("For more information, visit: https://github.com/eugeniofciuvasile/ssh-x-term")
 }
@@ -0,0 +1,514 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+// Commands are the non-interactive subcommands, for use in scripts and CI.
+// They work against the default_storage backend, see LoadStorage, like -l
+// and -c.
+var Commands = map[string]func(args []string) error{
+	"list":    runList,
+	"connect": runConnect,
+	"exec":    runExec,
+	"scp":     runSCP,
+	"add":     runAdd,
+	"remove":  runRemove,
+	"export":  runExport,
+
+	"register-uri": runRegisterURI,
+}
+
+// ExitStatusError carries a remote command's exit status so the process
+// can exit with it
+type ExitStatusError struct {
+	Status int
+}
+
+func (e *ExitStatusError) Error() string {
+	return fmt.Sprintf("remote command exited with status %d", e.Status)
+}
+
+// CommandUsage describes the subcommands for the -h output
+const CommandUsage = `  list [--json]                 List saved connections
+  connect [--yes] <name|id|target>
+                                Open an interactive session; a target is
+                                [user@]host[:port] or ssh://user@host:port
+  <ssh://user@host:port>        Same as connect, for links opened by the browser
+  exec [--yes] <name|id> <command...>
+                                Run a command and exit with its status
+  scp [-r] [-l KB/s] [--yes] <src> <dst>
+                                Copy files; prefix the remote side with <name|id>:
+                                --yes skips typing a production host's name
+  add --name N --host H [--port P] [--user U] [--key FILE] [--password-stdin]
+                                Save a new connection
+  remove <name|id>              Delete a connection
+  export [--format json|yaml|ssh_config] [--secrets] [-o FILE] [name|id...]
+                                Export all or the named connections
+  register-uri [--remove]       Open ssh:// links with sxt (Linux, Windows)`
+
+// findConnection resolves a connection by ID, then by case-insensitive
+// name, including its keyring secrets
+func findConnection(storage config.Storage, ref string) (config.SSHConnection, error) {
+	if conn, ok := storage.GetConnection(ref); ok {
+		return conn, nil
+	}
+	// <id>#<host> picks one host of a host range template
+	if id, host, ok := strings.Cut(ref, "#"); ok {
+		if conn, ok := storage.GetConnection(id); ok {
+			if instance, ok := config.ResolveInstance(conn, host); ok {
+				return instance, nil
+			}
+			return config.SSHConnection{}, fmt.Errorf("%s is not a host of %s", host, conn.Name)
+		}
+	}
+	var matches []config.SSHConnection
+	for _, conn := range config.ExpandHostRanges(storage.ListConnections()) {
+		if strings.EqualFold(conn.Name, ref) {
+			matches = append(matches, conn)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return config.SSHConnection{}, fmt.Errorf("no connection named %q", ref)
+	case 1:
+		conn, _ := storage.GetConnection(matches[0].ID)
+		if matches[0].HostRange != "" {
+			conn, _ = config.ResolveInstance(conn, matches[0].Host)
+		}
+		return conn, nil
+	}
+	return config.SSHConnection{}, fmt.Errorf("%d connections are named %q, use the ID instead", len(matches), ref)
+}
+
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print connections as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	storage, err := LoadStorage()
+	if err != nil {
+		return err
+	}
+	connections := storage.ListConnections()
+
+	if *asJSON {
+		type listedConnection struct {
+			ID          string `json:"id"`
+			Name        string `json:"name"`
+			Host        string `json:"host"`
+			Port        int    `json:"port"`
+			Username    string `json:"username"`
+			UsePassword bool   `json:"use_password"`
+			KeyFile     string `json:"key_file,omitempty"`
+		}
+		listed := make([]listedConnection, 0, len(connections))
+		for _, c := range connections {
+			listed = append(listed, listedConnection{c.ID, c.Name, c.Host, c.Port, c.Username, c.UsePassword, c.KeyFile})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(listed)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tTARGET\tAUTH")
+	for _, c := range connections {
+		auth := "key"
+		if c.UsePassword {
+			auth = "password"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s@%s:%d\t%s\n", c.ID, c.Name, c.Username, c.Host, c.Port, auth)
+	}
+	return w.Flush()
+}
+
+// ConfirmProduction asks for the host name of a production connection to
+// be typed, as the connection list does. Without a terminal to ask on it
+// refuses, unless yes is set.
+func ConfirmProduction(conn config.SSHConnection, yes bool) error {
+	if conn.DangerLevel != config.DangerProduction || yes {
+		return nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return fmt.Errorf("%s is a production host: pass --yes to confirm without a terminal", conn.Name)
+	}
+	fmt.Fprintf(os.Stderr, "%s is a production host. Type %s to continue: ", conn.Name, conn.Host)
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if strings.TrimSpace(line) != conn.Host {
+		return fmt.Errorf("%q is not %s, canceled", strings.TrimSpace(line), conn.Host)
+	}
+	return nil
+}
+
+// yesFlag adds --yes and -y, which confirm production hosts up front
+func yesFlag(fs *flag.FlagSet) *bool {
+	yes := fs.Bool("yes", false, "do not ask to type the host name of production hosts")
+	fs.BoolVar(yes, "y", false, "shorthand for --yes")
+	return yes
+}
+
+func runConnect(args []string) error {
+	fs := flag.NewFlagSet("connect", flag.ContinueOnError)
+	yes := yesFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: sxt connect [--yes] <name|id|[user@]host[:port]|ssh://user@host:port>")
+	}
+	storage, err := LoadStorage()
+	if err != nil {
+		return err
+	}
+	conn, err := resolveTarget(storage, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := ConfirmProduction(conn, *yes); err != nil {
+		return err
+	}
+	if conn.ID != "" {
+		if err := config.RecordConnection(storage, conn.ID, time.Now()); err != nil {
+			log.Printf("Failed to record connection %s: %v", conn.ID, err)
+		}
+	}
+	fmt.Printf("Connecting to %s...\n", conn.Name)
+	return ConnectDirect(storage, conn)
+}
+
+// IsSSHURI reports whether arg is an ssh:// link rather than a subcommand
+func IsSSHURI(arg string) bool {
+	return strings.HasPrefix(strings.ToLower(arg), "ssh://")
+}
+
+// resolveTarget finds a saved connection by ID or name, or else reads ref
+// as user@host:port or an ssh:// URI. Targets matching a saved connection's
+// host, port and user use that connection and its credentials.
+func resolveTarget(storage config.Storage, ref string) (config.SSHConnection, error) {
+	if !IsSSHURI(ref) {
+		conn, err := findConnection(storage, ref)
+		if err == nil || !strings.Contains(ref, "@") {
+			return conn, err
+		}
+	}
+	target, err := config.ParseTarget(ref)
+	if err != nil {
+		return config.SSHConnection{}, err
+	}
+	for _, saved := range config.ExpandHostRanges(storage.ListConnections()) {
+		if strings.EqualFold(saved.Host, target.Host) && saved.Port == target.Port && saved.Username == target.Username {
+			conn, _ := storage.GetConnection(saved.ID)
+			if saved.HostRange != "" {
+				conn, _ = config.ResolveInstance(conn, saved.Host)
+			}
+			return conn, nil
+		}
+	}
+	return target, nil
+}
+
+func runExec(args []string) error {
+	fs := flag.NewFlagSet("exec", flag.ContinueOnError)
+	yes := yesFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	if len(args) < 2 {
+		return errors.New("usage: sxt exec [--yes] <name|id> <command...>")
+	}
+	storage, err := LoadStorage()
+	if err != nil {
+		return err
+	}
+	conn, err := findConnection(storage, args[0])
+	if err != nil {
+		return err
+	}
+	if err := ConfirmProduction(conn, *yes); err != nil {
+		return err
+	}
+	conn, cleanup, err := withKeyFile(storage, conn)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	touchNotice(conn)
+	client, err := ssh.NewClient(conn)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	result, err := client.Run(strings.Join(args[1:], " "))
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(os.Stdout, result.Stdout)
+	fmt.Fprint(os.Stderr, result.Stderr)
+	if result.ExitStatus != 0 {
+		return &ExitStatusError{Status: result.ExitStatus}
+	}
+	return nil
+}
+
+// touchNotice tells the user to confirm a security key login on the device
+func touchNotice(conn config.SSHConnection) {
+	if ssh.UsesSecurityKey(conn) {
+		fmt.Fprintf(os.Stderr, "%s (%s)\n", ssh.SecurityKeyNotice, conn.KeyFile)
+	}
+}
+
+// remoteSpec is one side of an scp copy
+type remoteSpec struct {
+	conn *config.SSHConnection // nil for a local path
+	path string
+}
+
+// parseSpec splits name:path when name is a saved connection, so local
+// paths containing a colon (e.g. C:\files) are left alone
+func parseSpec(storage config.Storage, arg string) remoteSpec {
+	if name, p, ok := strings.Cut(arg, ":"); ok && name != "" {
+		if conn, err := findConnection(storage, name); err == nil {
+			return remoteSpec{conn: &conn, path: p}
+		}
+	}
+	return remoteSpec{path: config.ExpandPath(arg)}
+}
+
+func runSCP(args []string) error {
+	fs := flag.NewFlagSet("scp", flag.ContinueOnError)
+	recursive := fs.Bool("r", false, "copy directories recursively")
+	limit := fs.Int("l", ssh.DefaultRateLimit(), "limit bandwidth in KB/s, 0 for unlimited (default $"+ssh.RateLimitEnv+")")
+	yes := yesFlag(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("usage: sxt scp [-r] [-l KB/s] [--yes] <src> <dst>")
+	}
+
+	storage, err := LoadStorage()
+	if err != nil {
+		return err
+	}
+	src, dst := parseSpec(storage, fs.Arg(0)), parseSpec(storage, fs.Arg(1))
+	if (src.conn == nil) == (dst.conn == nil) {
+		return errors.New("exactly one of source and destination must be <name|id>:<path>")
+	}
+
+	remote := src
+	if dst.conn != nil {
+		remote = dst
+		if remote.conn.ViewOnly {
+			return fmt.Errorf("%s is view-only: uploads are refused", remote.conn.Name)
+		}
+	}
+	if err := ConfirmProduction(*remote.conn, *yes); err != nil {
+		return err
+	}
+	conn, cleanup, err := withKeyFile(storage, *remote.conn)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	touchNotice(conn)
+	client, err := ssh.NewSFTPClient(conn)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	client = client.WithRateLimit(*limit)
+
+	if remote.path == "" || !path.IsAbs(remote.path) {
+		wd, err := client.GetWorkingDir()
+		if err != nil {
+			return err
+		}
+		remote.path = path.Join(wd, remote.path)
+	}
+
+	if src.conn != nil {
+		return download(client, remote.path, dst.path, *recursive)
+	}
+	return upload(client, src.path, remote.path, *recursive)
+}
+
+func download(client *ssh.SFTPClient, remotePath, localPath string, recursive bool) error {
+	isDir, err := client.IsDir(remotePath)
+	if err != nil {
+		return err
+	}
+	if isDir && !recursive {
+		return fmt.Errorf("%s is a directory (use -r)", remotePath)
+	}
+	if info, err := os.Stat(localPath); err == nil && info.IsDir() {
+		localPath = filepath.Join(localPath, path.Base(remotePath))
+	}
+	return client.DownloadFile(remotePath, localPath)
+}
+
+func upload(client *ssh.SFTPClient, localPath, remotePath string, recursive bool) error {
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() && !recursive {
+		return fmt.Errorf("%s is a directory (use -r)", localPath)
+	}
+	if isDir, err := client.IsDir(remotePath); err == nil && isDir {
+		remotePath = path.Join(remotePath, filepath.Base(localPath))
+	}
+	return client.UploadFile(localPath, remotePath)
+}
+
+func runAdd(args []string) error {
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	name := fs.String("name", "", "connection name (required)")
+	host := fs.String("host", "", "host name or address (required)")
+	port := fs.Int("port", 22, "SSH port")
+	user := fs.String("user", "", "user name (default: current user)")
+	keyFile := fs.String("key", "", "private key file")
+	passwordStdin := fs.Bool("password-stdin", false, "read the password from stdin and use password auth")
+	notes := fs.String("notes", "", "free-form notes")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" || *host == "" {
+		return errors.New("usage: sxt add --name <name> --host <host> [flags]")
+	}
+
+	conn := config.SSHConnection{
+		Name:     *name,
+		Host:     *host,
+		Port:     *port,
+		Username: *user,
+		KeyFile:  *keyFile,
+		Notes:    *notes,
+	}
+	if conn.Username == "" {
+		conn.Username = os.Getenv("USER")
+	}
+	if *passwordStdin {
+		password, err := readPassword(os.Stdin)
+		if err != nil {
+			return err
+		}
+		conn.UsePassword = true
+		conn.Password = password
+	}
+
+	storage, err := LoadStorage()
+	if err != nil {
+		return err
+	}
+	if _, err := findConnection(storage, conn.Name); err == nil {
+		return fmt.Errorf("a connection named %q already exists", conn.Name)
+	}
+	if err := storage.AddConnection(conn); err != nil {
+		return err
+	}
+	fmt.Printf("Added %s\n", conn.Name)
+	return nil
+}
+
+// readPassword reads a single line from r without its line ending
+func readPassword(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", errors.New("no password on stdin")
+	}
+	return line, nil
+}
+
+func runRemove(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: sxt remove <name|id>")
+	}
+	storage, err := LoadStorage()
+	if err != nil {
+		return err
+	}
+	conn, err := findConnection(storage, args[0])
+	if err != nil {
+		return err
+	}
+	if conn.ViewOnly {
+		return fmt.Errorf("%s is view-only", conn.Name)
+	}
+	if err := storage.DeleteConnection(conn.ID); err != nil {
+		return err
+	}
+	fmt.Printf("Removed %s\n", conn.Name)
+	return nil
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	formatName := fs.String("format", "json", "json, yaml or ssh_config")
+	secrets := fs.Bool("secrets", false, "include passwords and passphrases")
+	output := fs.String("o", "", "write to FILE instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	format, err := config.ParseExportFormat(*formatName)
+	if err != nil {
+		return err
+	}
+	if *secrets && !format.SupportsSecrets() {
+		return fmt.Errorf("%s exports cannot contain secrets", format)
+	}
+
+	storage, err := LoadStorage()
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, ref := range fs.Args() {
+		conn, err := findConnection(storage, ref)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, conn.ID)
+	}
+	conns := config.ConnectionsForExport(storage, ids, *secrets)
+
+	if *output != "" {
+		if err := config.ExportToFile(*output, conns, format, *secrets); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Exported %d connections to %s\n", len(conns), *output)
+		return nil
+	}
+	data, err := config.ExportConnections(conns, format, *secrets)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
@@ -11,10 +11,17 @@ import (
 const keyringService = "ssh-x-term"
 
 // ConnectDirect opens a direct SSH connection using the golang SSH client
-// This replaces external tools like passh, plink, and native ssh command
-func ConnectDirect(conn config.SSHConnection) error {
+// This replaces external tools like passh, plink, and native ssh command.
+// A key kept in storage with conn is used from a temporary file.
+func ConnectDirect(storage config.Storage, conn config.SSHConnection) error {
 	log.Printf("Direct SSH connection to %s@%s:%d", conn.Username, conn.Host, conn.Port)
 
+	conn, cleanup, err := withKeyFile(storage, conn)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
 	// Use golang SSH client for interactive session
 	if err := ssh.ConnectInteractive(conn); err != nil {
 		return fmt.Errorf("SSH connection failed: %w", err)
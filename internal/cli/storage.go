@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// storageOpener opens a backend for the subcommands. Nothing is asked for:
+// what the backend needs comes from the environment, or from what the TUI
+// cached, as with the Bitwarden session.
+type storageOpener func() (config.Storage, error)
+
+// storageOpeners open the backends registered with
+// config.RegisterStorageBackend, by name
+var storageOpeners = map[string]storageOpener{}
+
+// registerStorageOpener sets how the backend named name is opened
+func registerStorageOpener(name string, open storageOpener) {
+	storageOpeners[name] = open
+}
+
+func init() {
+	registerStorageOpener("local", openLocalStorage)
+	registerStorageOpener("bitwarden", openBitwardenStorage)
+	registerStorageOpener("vault", openVaultStorage)
+	registerStorageOpener("keepassxc", openKeePassXCStorage)
+}
+
+// LoadStorage opens the default_storage backend, local storage when it is
+// unset, and loads its connections
+func LoadStorage() (config.Storage, error) {
+	name := config.CurrentSettings().DefaultStorage
+	if name == "" {
+		name = "local"
+	}
+	open, ok := storageOpeners[name]
+	if !ok {
+		return nil, fmt.Errorf("the %s storage can't be used from the command line", name)
+	}
+	storage, err := open()
+	if err != nil {
+		return nil, err
+	}
+	if err := storage.Load(); err != nil {
+		return nil, fmt.Errorf("failed to load connections: %w", err)
+	}
+	return storage, nil
+}
+
+func openLocalStorage() (config.Storage, error) {
+	manager, err := config.NewSSHConfigManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load SSH config: %w", err)
+	}
+	return manager, nil
+}
+
+// openBitwardenStorage uses the session of BW_SESSION, or the one the TUI
+// cached in the keyring (bitwarden.session_ttl_minutes)
+func openBitwardenStorage() (config.Storage, error) {
+	bwm, err := config.NewBitwardenManager(&config.BitwardenConfig{})
+	if err != nil {
+		return nil, err
+	}
+	_, unlocked, err := bwm.Status()
+	if err != nil {
+		return nil, err
+	}
+	if !unlocked {
+		return nil, errors.New("the Bitwarden vault is locked: set BW_SESSION, or unlock it in sxt with bitwarden.session_ttl_minutes set")
+	}
+	return bwm, nil
+}
+
+// openVaultStorage logs in with VAULT_TOKEN to VAULT_ADDR, as the Vault
+// form is prefilled
+func openVaultStorage() (config.Storage, error) {
+	if os.Getenv("VAULT_ADDR") == "" || os.Getenv("VAULT_TOKEN") == "" {
+		return nil, errors.New("the vault storage needs VAULT_ADDR and VAULT_TOKEN")
+	}
+	vm, err := config.NewVaultManager(&config.VaultConfig{})
+	if err != nil {
+		return nil, err
+	}
+	if err := vm.Login(); err != nil {
+		return nil, err
+	}
+	return vm, nil
+}
+
+// openKeePassXCStorage opens the database at KEEPASSXC_DATABASE with
+// KEEPASSXC_PASSWORD and/or KEEPASSXC_KEY_FILE
+func openKeePassXCStorage() (config.Storage, error) {
+	cfg := &config.KeePassXCConfig{
+		DatabasePath: os.Getenv("KEEPASSXC_DATABASE"),
+		KeyFile:      os.Getenv("KEEPASSXC_KEY_FILE"),
+		Password:     os.Getenv("KEEPASSXC_PASSWORD"),
+	}
+	if cfg.DatabasePath == "" || (cfg.Password == "" && cfg.KeyFile == "") {
+		return nil, errors.New("the keepassxc storage needs KEEPASSXC_DATABASE, and KEEPASSXC_PASSWORD and/or KEEPASSXC_KEY_FILE")
+	}
+	kpm, err := config.NewKeePassXCManager(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return kpm, nil
+}
+
+// withKeyFile fetches the key storage keeps with conn, if any, to a
+// temporary file conn then points to. The returned func removes it.
+func withKeyFile(storage config.Storage, conn config.SSHConnection) (config.SSHConnection, func(), error) {
+	store, ok := storage.(config.KeyAttachmentStorage)
+	if !ok || conn.UsePassword || conn.KeyAttachment == "" || !storage.Capabilities().Attachments {
+		return conn, func() {}, nil
+	}
+	keyPath, err := store.DownloadKeyAttachment(conn)
+	if err != nil {
+		return conn, func() {}, err
+	}
+	conn.KeyFile = keyPath
+	return conn, func() {
+		if err := os.Remove(keyPath); err != nil {
+			log.Printf("Failed to remove temporary key file %s: %v", keyPath, err)
+		}
+	}, nil
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// desktopFileName is the entry that makes sxt the ssh:// handler on
+// freedesktop systems
+const desktopFileName = "sxt-ssh-handler.desktop"
+
+// runRegisterURI makes sxt the handler for ssh:// links, or removes it
+func runRegisterURI(args []string) error {
+	fs := flag.NewFlagSet("register-uri", flag.ContinueOnError)
+	remove := fs.Bool("remove", false, "stop handling ssh:// links")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	execPath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate sxt: %w", err)
+	}
+
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return registerDesktopHandler(execPath, *remove)
+	case "windows":
+		return registerWindowsHandler(execPath, *remove)
+	}
+	return fmt.Errorf("registering a URI handler is not supported on %s; "+
+		"point your system's ssh:// handler at %q with the URI as its argument", runtime.GOOS, execPath)
+}
+
+// registerDesktopHandler installs a desktop entry for x-scheme-handler/ssh
+// that runs sxt in the user's terminal emulator
+func registerDesktopHandler(execPath string, remove bool) error {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	dir := filepath.Join(dataHome, "applications")
+	path := filepath.Join(dir, desktopFileName)
+
+	if remove {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		fmt.Printf("Removed %s\n", path)
+		return nil
+	}
+
+	entry := fmt.Sprintf(`[Desktop Entry]
+Type=Application
+Name=SSH-X-Term
+Comment=Open ssh:// links in SSH-X-Term
+Exec="%s" %%u
+Terminal=true
+NoDisplay=true
+MimeType=x-scheme-handler/ssh;
+`, execPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(entry), 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\n", path)
+
+	if out, err := exec.Command("xdg-mime", "default", desktopFileName, "x-scheme-handler/ssh").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set the default ssh:// handler (is xdg-utils installed?): %v %s", err, out)
+	}
+	fmt.Println("ssh:// links now open in sxt")
+	return nil
+}
+
+// registerWindowsHandler adds the ssh URL protocol for the current user
+func registerWindowsHandler(execPath string, remove bool) error {
+	const key = `HKCU\Software\Classes\ssh`
+	var commands [][]string
+	if remove {
+		commands = [][]string{{"delete", key, "/f"}}
+	} else {
+		commands = [][]string{
+			{"add", key, "/ve", "/d", "URL:SSH Protocol", "/f"},
+			{"add", key, "/v", "URL Protocol", "/d", "", "/f"},
+			{"add", key + `\shell\open\command`, "/ve", "/d", fmt.Sprintf(`"%s" "%%1"`, execPath), "/f"},
+		}
+	}
+	for _, args := range commands {
+		if out, err := exec.Command("reg", args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("reg %s failed: %v %s", args[0], err, out)
+		}
+	}
+	if remove {
+		fmt.Println("sxt no longer handles ssh:// links")
+	} else {
+		fmt.Println("ssh:// links now open in sxt")
+	}
+	return nil
+}
@@ -12,9 +12,38 @@ import (
 	"sync"
 )
 
+// BitwardenLoginMethod selects how the BitwardenManager logs in to bw
+type BitwardenLoginMethod int
+
+const (
+	BitwardenLoginPassword BitwardenLoginMethod = iota
+	BitwardenLoginAPIKey
+	BitwardenLoginSSO
+)
+
+// BitwardenLoginMethods lists the login methods in the order the setup
+// form cycles through them
+var BitwardenLoginMethods = []BitwardenLoginMethod{BitwardenLoginPassword, BitwardenLoginAPIKey, BitwardenLoginSSO}
+
+func (m BitwardenLoginMethod) String() string {
+	switch m {
+	case BitwardenLoginAPIKey:
+		return "API Key"
+	case BitwardenLoginSSO:
+		return "SSO"
+	default:
+		return "Email & Password"
+	}
+}
+
 type BitwardenConfig struct {
-	ServerURL string
-	Email     string
+	ServerURL   string
+	Email       string
+	LoginMethod BitwardenLoginMethod
+	// ClientID and ClientSecret are the API key (BW_CLIENTID and
+	// BW_CLIENTSECRET) of a user or machine account
+	ClientID     string
+	ClientSecret string
 }
 
 type BitwardenManager struct {
@@ -23,10 +52,13 @@ type BitwardenManager struct {
 	authed             bool
 	vaultMutex         sync.Mutex
 	items              map[string]SSHConnection
+	revisions          map[string]string // revisionDate of each loaded item
 	organizations      []Organization
 	collections        []Collection
 	personalVault      bool
 	selectedCollection *Collection
+	folders            []Folder
+	selectedFolder     *Folder // nil lists the whole personal vault
 }
 
 func NewBitwardenManager(cfg *BitwardenConfig) (*BitwardenManager, error) {
@@ -47,6 +79,9 @@ func checkBwCLI() error {
 	return nil
 }
 
+// Login logs in with the configured method. Password and otp are only used
+// by email and password logins; API key and SSO logins leave the vault
+// locked until Unlock is called with the master password.
 func (bwm *BitwardenManager) Login(password, otp string) error {
 	if err := checkBwCLI(); err != nil {
 		log.Print("Bitwarden CLI check failed during login")
@@ -63,6 +98,14 @@ func (bwm *BitwardenManager) Login(password, otp string) error {
 		}
 	}
 
+	switch bwm.cfg.LoginMethod {
+	case BitwardenLoginAPIKey:
+		return bwm.loginAPIKey()
+	case BitwardenLoginSSO:
+		return bwm.loginSSO()
+	}
+
+	RegisterSecret(password)
 	args := []string{"login", bwm.cfg.Email, password, "--raw"}
 	if otp != "" {
 		args = append(args, "--code", otp)
@@ -78,6 +121,40 @@ func (bwm *BitwardenManager) Login(password, otp string) error {
 	}
 	bwm.session = strings.TrimSpace(out.String())
 	bwm.authed = true
+	bwm.cacheSession()
+	return nil
+}
+
+// loginAPIKey logs in with the client_credentials of an API key, which bw
+// reads from BW_CLIENTID and BW_CLIENTSECRET
+func (bwm *BitwardenManager) loginAPIKey() error {
+	cmd := exec.Command("bw", "login", "--apikey")
+	cmd.Env = append(os.Environ(),
+		"BW_CLIENTID="+bwm.cfg.ClientID,
+		"BW_CLIENTSECRET="+bwm.cfg.ClientSecret,
+		"BW_NOINTERACTION=true",
+	)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		log.Printf("Bitwarden API key login failed: %s", stderr.String())
+		return errors.New("Bitwarden API key login failed: " + stderr.String())
+	}
+	return nil
+}
+
+// loginSSO logs in through the organization's identity provider. bw opens
+// the browser and waits for it to redirect back to a local port.
+func (bwm *BitwardenManager) loginSSO() error {
+	cmd := exec.Command("bw", "login", "--sso")
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		log.Printf("Bitwarden SSO login failed: %s", stderr.String())
+		return errors.New("Bitwarden SSO login failed: " + stderr.String())
+	}
 	return nil
 }
 
@@ -86,6 +163,7 @@ func (bwm *BitwardenManager) Unlock(password string) error {
 		log.Print("Bitwarden CLI check failed during unlock")
 		return err
 	}
+	RegisterSecret(password)
 	cmd := exec.Command("bw", "unlock", password, "--raw")
 	var out, stderr bytes.Buffer
 	cmd.Stdout = &out
@@ -97,14 +175,22 @@ func (bwm *BitwardenManager) Unlock(password string) error {
 	}
 	bwm.session = strings.TrimSpace(out.String())
 	bwm.authed = true
+	bwm.cacheSession()
 	return nil
 }
 
+// IsUnlocked reports whether a session key is available, which API key
+// and SSO logins do not give until the vault is unlocked
+func (bwm *BitwardenManager) IsUnlocked() bool {
+	return bwm.authed && bwm.session != ""
+}
+
 func (bwm *BitwardenManager) SessionKey() (string, error) {
 	if !bwm.authed || bwm.session == "" {
 		log.Print("Tried to fetch Bitwarden session key, but not authenticated")
 		return "", errors.New("Bitwarden session is not authenticated")
 	}
+	RegisterSecret(bwm.session)
 	return bwm.session, nil
 }
 
@@ -126,6 +212,41 @@ func (bwm *BitwardenManager) SetSelectedCollection(collection *Collection) {
 
 // ---- Storage Interface Implementation ----
 
+// bitwardenCapabilities: items are browsed by collection or folder, and key
+// files are uploaded as item attachments
+var bitwardenCapabilities = StorageCapabilities{Collections: true, Attachments: true}
+
+func (bwm *BitwardenManager) Capabilities() StorageCapabilities {
+	return bitwardenCapabilities
+}
+
+// StorageLabel names the collection or personal vault folder in view for
+// merged lists, e.g. "Bitwarden:TeamX"
+func (bwm *BitwardenManager) StorageLabel() string {
+	if bwm.IsPersonalVault() {
+		if folder := bwm.GetSelectedFolder(); folder != nil && folder.Name != "" {
+			return "Bitwarden:" + folder.Name
+		}
+		return "Bitwarden"
+	}
+	if coll := bwm.GetSelectedCollection(); coll != nil {
+		return "Bitwarden:" + coll.Name
+	}
+	return "Bitwarden"
+}
+
+// LoadView loads the personal vault, or the selected collection
+func (bwm *BitwardenManager) LoadView() error {
+	if bwm.IsPersonalVault() {
+		return bwm.Load()
+	}
+	coll := bwm.GetSelectedCollection()
+	if coll == nil {
+		return errors.New("no selected collection")
+	}
+	return bwm.LoadConnectionsByCollectionId(coll.ID)
+}
+
 func (bwm *BitwardenManager) Load() error {
 	bwm.vaultMutex.Lock()
 	defer bwm.vaultMutex.Unlock()
@@ -136,7 +257,15 @@ func (bwm *BitwardenManager) Load() error {
 		return err
 	}
 
-	cmd := exec.Command("bw", "list", "items", "--session", session, "--organizationid", "null")
+	args := []string{"list", "items", "--session", session, "--organizationid", "null"}
+	if bwm.selectedFolder != nil {
+		folderID := bwm.selectedFolder.ID
+		if folderID == "" {
+			folderID = "null"
+		}
+		args = append(args, "--folderid", folderID)
+	}
+	cmd := exec.Command("bw", args...)
 	var out, stderr bytes.Buffer
 	cmd.Stdout = &out
 	cmd.Stderr = &stderr
@@ -153,68 +282,17 @@ func (bwm *BitwardenManager) Load() error {
 	}
 
 	bwm.items = make(map[string]SSHConnection)
+	bwm.revisions = make(map[string]string)
 	for _, item := range allItems {
 		if t, ok := item["type"].(float64); !ok || int(t) != 1 {
 			continue
 		}
-		conn := SSHConnection{}
-		if id, ok := item["id"].(string); ok {
-			conn.ID = id
-		}
-		if name, ok := item["name"].(string); ok {
-			conn.Name = name
-		}
-		login, ok := item["login"].(map[string]any)
-		if ok {
-			if username, ok := login["username"].(string); ok {
-				conn.Username = username
-			}
-			if password, ok := login["password"].(string); ok {
-				conn.Password = password
-			}
-			if uris, ok := login["uris"].([]any); ok && len(uris) > 0 {
-				if first, ok := uris[0].(map[string]any); ok {
-					if uri, ok := first["uri"].(string); ok && strings.HasPrefix(uri, "ssh://") {
-						rest := strings.TrimPrefix(uri, "ssh://")
-						hostport := strings.Split(rest, ":")
-						conn.Host = hostport[0]
-						if len(hostport) > 1 {
-							if port, err := strconv.Atoi(hostport[1]); err == nil {
-								conn.Port = port
-							}
-						} else {
-							conn.Port = 22
-						}
-					} else {
-						continue
-					}
-				}
-			}
-		}
-		if fields, ok := item["fields"].([]any); ok {
-			for _, f := range fields {
-				if field, ok := f.(map[string]any); ok {
-					name, _ := field["name"].(string)
-					value, _ := field["value"].(string)
-					if strings.ToLower(name) == "use_password" {
-						conn.UsePassword = value == "true"
-					}
-					if strings.ToLower(name) == "sudo_password" {
-						conn.SudoPassword = value
-					}
-					if strings.ToLower(name) == "pinned" {
-						conn.Pinned = value == "true"
-					}
-					if strings.ToLower(name) == "order" {
-						if o, err := strconv.Atoi(value); err == nil {
-							conn.Order = o
-						}
-					}
-				}
-			}
+		if uri := itemURI(item); uri != "" && !strings.HasPrefix(uri, "ssh://") {
+			continue
 		}
-		if notes, ok := item["notes"].(string); ok && notes != "" {
-			conn.PublicKey = notes
+		conn := connectionFromItem(item)
+		if revision, ok := item["revisionDate"].(string); ok {
+			bwm.revisions[conn.ID] = revision
 		}
 		bwm.items[conn.ID] = conn
 	}
@@ -249,7 +327,12 @@ func (bwm *BitwardenManager) GetConnection(id string) (SSHConnection, bool) {
 	return c, ok
 }
 
+// AddConnection adds conn to the personal vault, or to the selected
+// collection when browsing one
 func (bwm *BitwardenManager) AddConnection(conn SSHConnection) error {
+	if coll := bwm.GetSelectedCollection(); coll != nil && !bwm.IsPersonalVault() {
+		return bwm.AddConnectionInCollectionAndOrganization(conn, coll.OrganizationID, coll.ID)
+	}
 	return bwm.AddConnectionInCollectionAndOrganization(conn, "", "")
 }
 
@@ -261,13 +344,16 @@ func (bwm *BitwardenManager) AddConnectionInCollectionAndOrganization(conn SSHCo
 	}
 
 	publicKey := conn.PublicKey
-	privateKey := conn.Password
+	// The password field holds the key passphrase, or the key itself for
+	// items saved before keys were attached
+	password := conn.Password
+	// keyPath is the key file to upload as an attachment, if any
+	var keyPath string
 
 	if !conn.UsePassword {
-		if privateKey == "" && conn.KeyFile != "" {
-			expanded := ExpandPath(conn.KeyFile)
-			keyData, err := os.ReadFile(expanded)
-			if err != nil {
+		if conn.KeyFile != "" {
+			keyPath = ExpandPath(conn.KeyFile)
+			if _, err := os.ReadFile(keyPath); err != nil {
 				tip := ""
 				if os.IsPermission(err) {
 					tip = " (permission denied: check file permissions, you may need to run as the user who owns the file or change ownership)"
@@ -275,7 +361,6 @@ func (bwm *BitwardenManager) AddConnectionInCollectionAndOrganization(conn SSHCo
 				log.Printf("Could not read private key file '%s': %v%s", conn.KeyFile, err, tip)
 				return errors.New("could not read private key file '" + conn.KeyFile + "': " + err.Error() + tip)
 			}
-			privateKey = string(keyData)
 		}
 		pubPath := ExpandPath(conn.KeyFile) + ".pub"
 		if publicKey == "" {
@@ -295,12 +380,24 @@ func (bwm *BitwardenManager) AddConnectionInCollectionAndOrganization(conn SSHCo
 		}
 	}
 
+	keyAttachment := conn.KeyAttachment
+	if keyPath != "" {
+		keyAttachment = keyAttachmentName(keyPath)
+	} else if conn.UsePassword {
+		keyAttachment = ""
+	}
+
 	fields := []map[string]any{
 		{
 			"name":  "use_password",
 			"value": strconv.FormatBool(conn.UsePassword),
 			"type":  0,
 		},
+		{
+			"name":  "key_attachment",
+			"value": keyAttachment,
+			"type":  0,
+		},
 		{
 			"name":  "sudo_password",
 			"value": conn.SudoPassword,
@@ -311,16 +408,156 @@ func (bwm *BitwardenManager) AddConnectionInCollectionAndOrganization(conn SSHCo
 			"value": strconv.FormatBool(conn.Pinned),
 			"type":  0,
 		},
+		{
+			"name":  "use_mosh",
+			"value": strconv.FormatBool(conn.UseMosh),
+			"type":  0,
+		},
+		{
+			"name":  "view_only",
+			"value": strconv.FormatBool(conn.ViewOnly),
+			"type":  0,
+		},
+		{
+			"name":  "danger_level",
+			"value": conn.DangerLevel,
+			"type":  0,
+		},
+		{
+			"name":  "forward_agent",
+			"value": strconv.FormatBool(conn.ForwardAgent),
+			"type":  0,
+		},
+		{
+			"name":  "forward_x11",
+			"value": strconv.FormatBool(conn.ForwardX11),
+			"type":  0,
+		},
+		{
+			"name":  "proxy",
+			"value": conn.Proxy,
+			"type":  0,
+		},
+		{
+			"name":  "proxy_password",
+			"value": conn.ProxyPassword,
+			"type":  1, // Hidden
+		},
+		{
+			"name":  "proxy_command",
+			"value": conn.ProxyCommand,
+			"type":  0,
+		},
+		{
+			"name":  "set_env",
+			"value": FormatSetEnv(conn.SetEnv),
+			"type":  0,
+		},
+		{
+			"name":  "proxy_jump",
+			"value": conn.ProxyJump,
+			"type":  0,
+		},
+		{
+			"name":  "identity_agent",
+			"value": conn.IdentityAgent,
+			"type":  0,
+		},
+		{
+			"name":  "server_alive_interval",
+			"value": strconv.Itoa(conn.ServerAliveInterval),
+			"type":  0,
+		},
+		{
+			"name":  "compression",
+			"value": strconv.FormatBool(conn.Compression),
+			"type":  0,
+		},
+		{
+			"name":  "strict_host_key_checking",
+			"value": conn.StrictHostKeyChecking,
+			"type":  0,
+		},
+		{
+			"name":  "local_forward",
+			"value": strings.Join(conn.LocalForward, "\n"),
+			"type":  0,
+		},
+		{
+			"name":  "remote_forward",
+			"value": strings.Join(conn.RemoteForward, "\n"),
+			"type":  0,
+		},
+		{
+			"name":  "startup_command",
+			"value": conn.StartupCommand,
+			"type":  0,
+		},
+		{
+			"name":  "shell",
+			"value": conn.Shell,
+			"type":  0,
+		},
+		{
+			"name":  "term",
+			"value": conn.Term,
+			"type":  0,
+		},
+		{
+			"name":  "pty_padding",
+			"value": FormatPtyPadding(conn.PtyPadColumns, conn.PtyPadRows),
+			"type":  0,
+		},
+		{
+			"name":  "session_timeouts",
+			"value": FormatSessionTimeouts(conn.IdleMinutes, conn.SessionMinutes),
+			"type":  0,
+		},
+		{
+			"name":  "on_connect",
+			"value": strings.Join(conn.OnConnect, "\n"),
+			"type":  0,
+		},
+		{
+			"name":  "on_connect_auto",
+			"value": strconv.FormatBool(conn.OnConnectAuto),
+			"type":  0,
+		},
+		{
+			"name":  "remote_start_path",
+			"value": conn.RemoteStartPath,
+			"type":  0,
+		},
+		{
+			"name":  "local_start_path",
+			"value": conn.LocalStartPath,
+			"type":  0,
+		},
+		{
+			"name":  "bookmarks",
+			"value": strings.Join(conn.Bookmarks, "\n"),
+			"type":  0,
+		},
 		{
 			"name":  "order",
 			"value": strconv.Itoa(conn.Order),
 			"type":  0,
 		},
+		{
+			"name":  "last_connected",
+			"value": strconv.FormatInt(conn.LastConnected, 10),
+			"type":  0,
+		},
+		{
+			"name":  "connect_count",
+			"value": strconv.Itoa(conn.ConnectCount),
+			"type":  0,
+		},
 	}
 
 	login := map[string]any{
 		"username": conn.Username,
-		"password": privateKey,
+		"password": password,
 		"uris": []map[string]any{
 			{
 				"uri": "ssh://" + conn.Host + ":" + strconv.Itoa(conn.Port),
@@ -341,11 +578,12 @@ func (bwm *BitwardenManager) AddConnectionInCollectionAndOrganization(conn SSHCo
 		}
 	} else {
 		item = map[string]any{
-			"type":   1, // Login
-			"name":   conn.Name,
-			"login":  login,
-			"fields": fields,
-			"notes":  publicKey,
+			"type":     1, // Login
+			"name":     conn.Name,
+			"login":    login,
+			"fields":   fields,
+			"notes":    publicKey,
+			"folderId": folderIDValue(conn.FolderID),
 		}
 	}
 
@@ -374,6 +612,18 @@ func (bwm *BitwardenManager) AddConnectionInCollectionAndOrganization(conn SSHCo
 		log.Printf("Failed to create Bitwarden item: %s - %s", err, createErr.String())
 		return errors.New("failed to create Bitwarden item: " + err.Error() + " - " + createErr.String())
 	}
+	if keyPath != "" {
+		var created struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(createOut.Bytes(), &created); err != nil {
+			log.Printf("Failed to parse created Bitwarden item: %v", err)
+			return err
+		}
+		if err := attachKey(session, created.ID, keyPath, ""); err != nil {
+			return err
+		}
+	}
 	if bwm.IsPersonalVault() {
 		return bwm.Load()
 	} else {
@@ -381,7 +631,19 @@ func (bwm *BitwardenManager) AddConnectionInCollectionAndOrganization(conn SSHCo
 	}
 }
 
+// EditConnection saves conn over its item, unless the item was changed in
+// the vault since it was loaded, which returns an *EditConflictError
 func (bwm *BitwardenManager) EditConnection(conn SSHConnection) error {
+	return bwm.editConnection(conn, false)
+}
+
+// OverwriteConnection saves conn over its item, whatever changed in the
+// vault since it was loaded
+func (bwm *BitwardenManager) OverwriteConnection(conn SSHConnection) error {
+	return bwm.editConnection(conn, true)
+}
+
+func (bwm *BitwardenManager) editConnection(conn SSHConnection, overwrite bool) error {
 	session, err := bwm.SessionKey()
 	if err != nil {
 		log.Print("Could not get Bitwarden session key during EditConnection")
@@ -391,15 +653,23 @@ func (bwm *BitwardenManager) EditConnection(conn SSHConnection) error {
 		log.Print("Missing Bitwarden item ID for edit")
 		return errors.New("missing Bitwarden item ID for edit")
 	}
+	if !overwrite {
+		if err := bwm.checkRevision(session, conn); err != nil {
+			return err
+		}
+	}
 
 	publicKey := conn.PublicKey
-	privateKey := conn.Password
+	// The password field holds the key passphrase, or the key itself for
+	// items saved before keys were attached
+	password := conn.Password
+	// keyPath is the key file to upload as an attachment, if any
+	var keyPath string
 
 	if !conn.UsePassword {
-		if privateKey == "" && conn.KeyFile != "" {
-			expanded := ExpandPath(conn.KeyFile)
-			keyData, err := os.ReadFile(expanded)
-			if err != nil {
+		if conn.KeyFile != "" {
+			keyPath = ExpandPath(conn.KeyFile)
+			if _, err := os.ReadFile(keyPath); err != nil {
 				tip := ""
 				if os.IsPermission(err) {
 					tip = " (permission denied: check file permissions, you may need to run as the user who owns the file or change ownership)"
@@ -407,7 +677,6 @@ func (bwm *BitwardenManager) EditConnection(conn SSHConnection) error {
 				log.Printf("Could not read private key file '%s': %v%s", conn.KeyFile, err, tip)
 				return errors.New("could not read private key file '" + conn.KeyFile + "': " + err.Error() + tip)
 			}
-			privateKey = string(keyData)
 		}
 		pubPath := ExpandPath(conn.KeyFile) + ".pub"
 		if publicKey == "" {
@@ -427,12 +696,24 @@ func (bwm *BitwardenManager) EditConnection(conn SSHConnection) error {
 		}
 	}
 
+	keyAttachment := conn.KeyAttachment
+	if keyPath != "" {
+		keyAttachment = keyAttachmentName(keyPath)
+	} else if conn.UsePassword {
+		keyAttachment = ""
+	}
+
 	fields := []map[string]any{
 		{
 			"name":  "use_password",
 			"value": strconv.FormatBool(conn.UsePassword),
 			"type":  0,
 		},
+		{
+			"name":  "key_attachment",
+			"value": keyAttachment,
+			"type":  0,
+		},
 		{
 			"name":  "sudo_password",
 			"value": conn.SudoPassword,
@@ -443,16 +724,156 @@ func (bwm *BitwardenManager) EditConnection(conn SSHConnection) error {
 			"value": strconv.FormatBool(conn.Pinned),
 			"type":  0,
 		},
+		{
+			"name":  "use_mosh",
+			"value": strconv.FormatBool(conn.UseMosh),
+			"type":  0,
+		},
+		{
+			"name":  "view_only",
+			"value": strconv.FormatBool(conn.ViewOnly),
+			"type":  0,
+		},
+		{
+			"name":  "danger_level",
+			"value": conn.DangerLevel,
+			"type":  0,
+		},
+		{
+			"name":  "forward_agent",
+			"value": strconv.FormatBool(conn.ForwardAgent),
+			"type":  0,
+		},
+		{
+			"name":  "forward_x11",
+			"value": strconv.FormatBool(conn.ForwardX11),
+			"type":  0,
+		},
+		{
+			"name":  "proxy",
+			"value": conn.Proxy,
+			"type":  0,
+		},
+		{
+			"name":  "proxy_password",
+			"value": conn.ProxyPassword,
+			"type":  1, // Hidden
+		},
+		{
+			"name":  "proxy_command",
+			"value": conn.ProxyCommand,
+			"type":  0,
+		},
+		{
+			"name":  "set_env",
+			"value": FormatSetEnv(conn.SetEnv),
+			"type":  0,
+		},
+		{
+			"name":  "proxy_jump",
+			"value": conn.ProxyJump,
+			"type":  0,
+		},
+		{
+			"name":  "identity_agent",
+			"value": conn.IdentityAgent,
+			"type":  0,
+		},
+		{
+			"name":  "server_alive_interval",
+			"value": strconv.Itoa(conn.ServerAliveInterval),
+			"type":  0,
+		},
+		{
+			"name":  "compression",
+			"value": strconv.FormatBool(conn.Compression),
+			"type":  0,
+		},
+		{
+			"name":  "strict_host_key_checking",
+			"value": conn.StrictHostKeyChecking,
+			"type":  0,
+		},
+		{
+			"name":  "local_forward",
+			"value": strings.Join(conn.LocalForward, "\n"),
+			"type":  0,
+		},
+		{
+			"name":  "remote_forward",
+			"value": strings.Join(conn.RemoteForward, "\n"),
+			"type":  0,
+		},
+		{
+			"name":  "startup_command",
+			"value": conn.StartupCommand,
+			"type":  0,
+		},
+		{
+			"name":  "shell",
+			"value": conn.Shell,
+			"type":  0,
+		},
+		{
+			"name":  "term",
+			"value": conn.Term,
+			"type":  0,
+		},
+		{
+			"name":  "pty_padding",
+			"value": FormatPtyPadding(conn.PtyPadColumns, conn.PtyPadRows),
+			"type":  0,
+		},
+		{
+			"name":  "session_timeouts",
+			"value": FormatSessionTimeouts(conn.IdleMinutes, conn.SessionMinutes),
+			"type":  0,
+		},
+		{
+			"name":  "on_connect",
+			"value": strings.Join(conn.OnConnect, "\n"),
+			"type":  0,
+		},
+		{
+			"name":  "on_connect_auto",
+			"value": strconv.FormatBool(conn.OnConnectAuto),
+			"type":  0,
+		},
+		{
+			"name":  "remote_start_path",
+			"value": conn.RemoteStartPath,
+			"type":  0,
+		},
+		{
+			"name":  "local_start_path",
+			"value": conn.LocalStartPath,
+			"type":  0,
+		},
+		{
+			"name":  "bookmarks",
+			"value": strings.Join(conn.Bookmarks, "\n"),
+			"type":  0,
+		},
 		{
 			"name":  "order",
 			"value": strconv.Itoa(conn.Order),
 			"type":  0,
 		},
+		{
+			"name":  "last_connected",
+			"value": strconv.FormatInt(conn.LastConnected, 10),
+			"type":  0,
+		},
+		{
+			"name":  "connect_count",
+			"value": strconv.Itoa(conn.ConnectCount),
+			"type":  0,
+		},
 	}
 
 	login := map[string]any{
 		"username": conn.Username,
-		"password": privateKey,
+		"password": password,
 		"uris": []map[string]any{
 			{
 				"uri": "ssh://" + conn.Host + ":" + strconv.Itoa(conn.Port),
@@ -461,11 +882,12 @@ func (bwm *BitwardenManager) EditConnection(conn SSHConnection) error {
 	}
 
 	item := map[string]any{
-		"type":   1, // Login
-		"name":   conn.Name,
-		"login":  login,
-		"fields": fields,
-		"notes":  publicKey,
+		"type":     1, // Login
+		"name":     conn.Name,
+		"login":    login,
+		"fields":   fields,
+		"notes":    publicKey,
+		"folderId": folderIDValue(conn.FolderID),
 	}
 
 	itemJSON, err := json.Marshal(item)
@@ -485,6 +907,16 @@ func (bwm *BitwardenManager) EditConnection(conn SSHConnection) error {
 		return errors.New("failed to encode Bitwarden item: " + err.Error() + " - " + encodeErr.String())
 	}
 
+	if keyPath != "" {
+		var replace string
+		if previous, ok := bwm.GetConnection(conn.ID); ok {
+			replace = previous.KeyAttachment
+		}
+		if err := attachKey(session, conn.ID, keyPath, replace); err != nil {
+			return err
+		}
+	}
+
 	editCmd := exec.Command("bw", "edit", "item", conn.ID, "--session", session)
 	editCmd.Stdin = bytes.NewReader(encodedOutput.Bytes())
 	var editOut, editErr bytes.Buffer
@@ -512,7 +944,12 @@ func (bwm *BitwardenManager) Status() (loggedIn bool, unlocked bool, err error)
 		log.Print("Bitwarden CLI check failed during Status")
 		return false, false, err
 	}
-	cmd := exec.Command("bw", "status")
+	bwm.restoreSession()
+	args := []string{"status"}
+	if bwm.session != "" {
+		args = append(args, "--session", bwm.session)
+	}
+	cmd := exec.Command("bw", args...)
 	var out, stderr bytes.Buffer
 	cmd.Stdout = &out
 	cmd.Stderr = &stderr
@@ -528,12 +965,22 @@ func (bwm *BitwardenManager) Status() (loggedIn bool, unlocked bool, err error)
 		log.Printf("Failed to parse Bitwarden status JSON: %v", err)
 		return false, false, err
 	}
+	if stat.Status != "unlocked" && bwm.session != "" {
+		// The vault was locked or logged out since the session was saved
+		clearCachedSession()
+		bwm.session = ""
+	}
 	switch stat.Status {
 	case "unauthenticated":
 		return false, false, nil
 	case "locked":
 		return true, false, nil
 	case "unlocked":
+		if bwm.session == "" {
+			// Unlocked for some other process, without a session for us
+			return true, false, nil
+		}
+		bwm.authed = true
 		return true, true, nil
 	}
 	log.Printf("Unknown Bitwarden status: %s", stat.Status)
@@ -585,6 +1032,56 @@ func (bwm *BitwardenManager) LoadOrganizations() error {
 	return nil
 }
 
+func (bwm *BitwardenManager) LoadFolders() error {
+	bwm.vaultMutex.Lock()
+	defer bwm.vaultMutex.Unlock()
+	if err := bwm.Sync(); err != nil {
+		return err
+	}
+	session, err := bwm.SessionKey()
+	if err != nil {
+		log.Print("Could not get Bitwarden session key during LoadFolders")
+		return err
+	}
+	out, err := runBw("list", "folders", "--session", session)
+	if err != nil {
+		log.Printf("Could not list folders: %v", err)
+		return errors.New("could not list folders: " + err.Error())
+	}
+	var folders []Folder
+	if err := json.Unmarshal(out, &folders); err != nil {
+		log.Printf("Failed to parse folders JSON: %v", err)
+		return err
+	}
+	bwm.folders = folders
+	return nil
+}
+
+// ListFolders returns the folders of the personal vault, including the
+// "No Folder" entry bw lists for items outside any folder
+func (bwm *BitwardenManager) ListFolders() []Folder {
+	bwm.vaultMutex.Lock()
+	defer bwm.vaultMutex.Unlock()
+	return bwm.folders
+}
+
+func (bwm *BitwardenManager) GetSelectedFolder() *Folder {
+	return bwm.selectedFolder
+}
+
+// SetSelectedFolder limits the personal vault to one folder, nil lists it all
+func (bwm *BitwardenManager) SetSelectedFolder(folder *Folder) {
+	bwm.selectedFolder = folder
+}
+
+// folderIDValue returns the folderId of an item, null for "No Folder"
+func folderIDValue(folderID string) any {
+	if folderID == "" {
+		return nil
+	}
+	return folderID
+}
+
 func (bwm *BitwardenManager) ListOrganizations() []Organization {
 	bwm.vaultMutex.Lock()
 	defer bwm.vaultMutex.Unlock()
@@ -658,81 +1155,205 @@ func (bwm *BitwardenManager) LoadConnectionsByCollectionId(collectionId string)
 	}
 
 	bwm.items = make(map[string]SSHConnection)
+	bwm.revisions = make(map[string]string)
 	for _, item := range allItems {
 		if t, ok := item["type"].(float64); !ok || int(t) != 1 {
 			continue
 		}
-		conn := SSHConnection{}
-		if id, ok := item["id"].(string); ok {
-			conn.ID = id
+		conn := connectionFromItem(item)
+		if revision, ok := item["revisionDate"].(string); ok {
+			bwm.revisions[conn.ID] = revision
 		}
-		if name, ok := item["name"].(string); ok {
-			conn.Name = name
+		bwm.items[conn.ID] = conn
+	}
+	return nil
+}
+
+// connectionFromItem reads a connection from a Bitwarden login item as
+// listed by bw
+func connectionFromItem(item map[string]any) SSHConnection {
+	conn := SSHConnection{}
+	if id, ok := item["id"].(string); ok {
+		conn.ID = id
+	}
+	if name, ok := item["name"].(string); ok {
+		conn.Name = name
+	}
+	login, ok := item["login"].(map[string]any)
+	if ok {
+		if username, ok := login["username"].(string); ok {
+			conn.Username = username
 		}
-		login, ok := item["login"].(map[string]any)
-		if ok {
-			if username, ok := login["username"].(string); ok {
-				conn.Username = username
-			}
-			if password, ok := login["password"].(string); ok {
-				conn.Password = password
-			}
-			if uris, ok := login["uris"].([]any); ok && len(uris) > 0 {
-				if first, ok := uris[0].(map[string]any); ok {
-					if uri, ok := first["uri"].(string); ok {
-						rest := strings.TrimPrefix(uri, "ssh://")
-						hostport := strings.Split(rest, ":")
-						conn.Host = hostport[0]
-						if len(hostport) > 1 {
-							if port, err := strconv.Atoi(hostport[1]); err == nil {
-								conn.Port = port
-							}
-						} else {
-							conn.Port = 22
+		if password, ok := login["password"].(string); ok {
+			conn.Password = password
+		}
+		if uris, ok := login["uris"].([]any); ok && len(uris) > 0 {
+			if first, ok := uris[0].(map[string]any); ok {
+				if uri, ok := first["uri"].(string); ok {
+					rest := strings.TrimPrefix(uri, "ssh://")
+					hostport := strings.Split(rest, ":")
+					conn.Host = hostport[0]
+					if len(hostport) > 1 {
+						if port, err := strconv.Atoi(hostport[1]); err == nil {
+							conn.Port = port
 						}
+					} else {
+						conn.Port = 22
 					}
 				}
 			}
 		}
-		if fields, ok := item["fields"].([]any); ok {
-			for _, f := range fields {
-				if field, ok := f.(map[string]any); ok {
-					name, _ := field["name"].(string)
-					value, _ := field["value"].(string)
-					if strings.ToLower(name) == "use_password" {
-						conn.UsePassword = value == "true"
+	}
+	if fields, ok := item["fields"].([]any); ok {
+		for _, f := range fields {
+			if field, ok := f.(map[string]any); ok {
+				name, _ := field["name"].(string)
+				value, _ := field["value"].(string)
+				if strings.ToLower(name) == "use_password" {
+					conn.UsePassword = value == "true"
+				}
+				if strings.ToLower(name) == "key_attachment" {
+					conn.KeyAttachment = value
+				}
+				if strings.ToLower(name) == "sudo_password" {
+					conn.SudoPassword = value
+				}
+				if strings.ToLower(name) == "pinned" {
+					conn.Pinned = value == "true"
+				}
+				if strings.ToLower(name) == "use_mosh" {
+					conn.UseMosh = value == "true"
+				}
+				if strings.ToLower(name) == "view_only" {
+					conn.ViewOnly = value == "true"
+				}
+				if strings.ToLower(name) == "danger_level" {
+					conn.DangerLevel = ParseDangerLevel(value)
+				}
+				if strings.ToLower(name) == "forward_agent" {
+					conn.ForwardAgent = value == "true"
+				}
+				if strings.ToLower(name) == "forward_x11" {
+					conn.ForwardX11 = value == "true"
+				}
+				if strings.ToLower(name) == "proxy" {
+					conn.Proxy = value
+				}
+				if strings.ToLower(name) == "proxy_password" {
+					conn.ProxyPassword = value
+				}
+				if strings.ToLower(name) == "proxy_command" {
+					conn.ProxyCommand = value
+				}
+				if strings.ToLower(name) == "set_env" {
+					if vars, err := ParseSetEnv(value); err == nil {
+						conn.SetEnv = vars
 					}
-					if strings.ToLower(name) == "sudo_password" {
-						conn.SudoPassword = value
+				}
+				if strings.ToLower(name) == "proxy_jump" {
+					conn.ProxyJump = value
+				}
+				if strings.ToLower(name) == "identity_agent" {
+					conn.IdentityAgent = value
+				}
+				if strings.ToLower(name) == "server_alive_interval" {
+					conn.ServerAliveInterval, _ = strconv.Atoi(value)
+				}
+				if strings.ToLower(name) == "compression" {
+					conn.Compression = value == "true"
+				}
+				if strings.ToLower(name) == "strict_host_key_checking" {
+					conn.StrictHostKeyChecking = value
+				}
+				if strings.ToLower(name) == "local_forward" && value != "" {
+					conn.LocalForward = strings.Split(value, "\n")
+				}
+				if strings.ToLower(name) == "remote_forward" && value != "" {
+					conn.RemoteForward = strings.Split(value, "\n")
+				}
+				if strings.ToLower(name) == "startup_command" {
+					conn.StartupCommand = value
+				}
+				if strings.ToLower(name) == "shell" {
+					conn.Shell = value
+				}
+				if strings.ToLower(name) == "term" {
+					conn.Term = value
+				}
+				if strings.ToLower(name) == "pty_padding" {
+					conn.PtyPadColumns, conn.PtyPadRows, _ = ParsePtyPadding(value)
+				}
+				if strings.ToLower(name) == "session_timeouts" {
+					conn.IdleMinutes, conn.SessionMinutes, _ = ParseSessionTimeouts(value)
+				}
+				if strings.ToLower(name) == "on_connect" && value != "" {
+					conn.OnConnect = strings.Split(value, "\n")
+				}
+				if strings.ToLower(name) == "on_connect_auto" {
+					conn.OnConnectAuto = value == "true"
+				}
+				if strings.ToLower(name) == "remote_start_path" {
+					conn.RemoteStartPath = value
+				}
+				if strings.ToLower(name) == "local_start_path" {
+					conn.LocalStartPath = value
+				}
+				if strings.ToLower(name) == "bookmarks" && value != "" {
+					conn.Bookmarks = strings.Split(value, "\n")
+				}
+				if strings.ToLower(name) == "order" {
+					if o, err := strconv.Atoi(value); err == nil {
+						conn.Order = o
 					}
-					if strings.ToLower(name) == "pinned" {
-						conn.Pinned = value == "true"
+				}
+				if strings.ToLower(name) == "last_connected" {
+					if t, err := strconv.ParseInt(value, 10, 64); err == nil {
+						conn.LastConnected = t
 					}
-					if strings.ToLower(name) == "order" {
-						if o, err := strconv.Atoi(value); err == nil {
-							conn.Order = o
-						}
+				}
+				if strings.ToLower(name) == "connect_count" {
+					if c, err := strconv.Atoi(value); err == nil {
+						conn.ConnectCount = c
 					}
 				}
 			}
 		}
-		if notes, ok := item["notes"].(string); ok && notes != "" {
-			conn.PublicKey = notes
-		}
-		if collectionIds, ok := item["collectionIds"].([]any); ok {
-			conn.CollectionIds = []string{}
-			for _, cid := range collectionIds {
-				if cidStr, ok := cid.(string); ok {
-					conn.CollectionIds = append(conn.CollectionIds, cidStr)
-				}
+	}
+	if notes, ok := item["notes"].(string); ok && notes != "" {
+		conn.PublicKey = notes
+	}
+	if collectionIds, ok := item["collectionIds"].([]any); ok {
+		conn.CollectionIds = []string{}
+		for _, cid := range collectionIds {
+			if cidStr, ok := cid.(string); ok {
+				conn.CollectionIds = append(conn.CollectionIds, cidStr)
 			}
 		}
-		if orgId, ok := item["organizationId"].(string); ok {
-			conn.OrganizationID = orgId
-		}
-		bwm.items[conn.ID] = conn
 	}
-	return nil
+	if orgId, ok := item["organizationId"].(string); ok {
+		conn.OrganizationID = orgId
+	}
+	if folderID, ok := item["folderId"].(string); ok {
+		conn.FolderID = folderID
+	}
+	// Items of collections shared read-only can't be edited anyway
+	if edit, ok := item["edit"].(bool); ok && !edit {
+		conn.ViewOnly = true
+	}
+	RegisterConnectionSecrets(conn)
+	return conn
+}
+
+// itemURI returns the first URI of a Bitwarden login item
+func itemURI(item map[string]any) string {
+	login, _ := item["login"].(map[string]any)
+	uris, _ := login["uris"].([]any)
+	if len(uris) == 0 {
+		return ""
+	}
+	first, _ := uris[0].(map[string]any)
+	uri, _ := first["uri"].(string)
+	return uri
 }
 
 func (bwm *BitwardenManager) ListConnections() []SSHConnection {
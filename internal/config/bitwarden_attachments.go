@@ -0,0 +1,104 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// bwAttachment is an attachment as listed in a Bitwarden item
+type bwAttachment struct {
+	ID       string `json:"id"`
+	FileName string `json:"fileName"`
+}
+
+// runBw runs the bw CLI and returns its output, or an error carrying
+// whatever it printed to stderr
+func runBw(args ...string) ([]byte, error) {
+	cmd := exec.Command("bw", args...)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.New(err.Error() + " - " + stderr.String())
+	}
+	return out.Bytes(), nil
+}
+
+// itemAttachments lists the attachments of a Bitwarden item
+func itemAttachments(session, itemID string) ([]bwAttachment, error) {
+	out, err := runBw("get", "item", itemID, "--session", session)
+	if err != nil {
+		return nil, err
+	}
+	var item struct {
+		Attachments []bwAttachment `json:"attachments"`
+	}
+	if err := json.Unmarshal(out, &item); err != nil {
+		return nil, err
+	}
+	return item.Attachments, nil
+}
+
+// keyAttachmentName returns the name a key file is attached under
+func keyAttachmentName(keyPath string) string {
+	return filepath.Base(keyPath)
+}
+
+// attachKey uploads the private key at keyPath to a Bitwarden item. The
+// attachments it replaces, those named replace or like the new key, are
+// deleted only once the upload has succeeded.
+func attachKey(session, itemID, keyPath, replace string) error {
+	existing, err := itemAttachments(session, itemID)
+	if err != nil {
+		log.Printf("Could not list Bitwarden attachments: %v", err)
+		return errors.New("could not list Bitwarden attachments: " + err.Error())
+	}
+	if _, err := runBw("create", "attachment", "--file", keyPath, "--itemid", itemID, "--session", session); err != nil {
+		log.Printf("Could not attach private key to Bitwarden item: %v", err)
+		return errors.New("could not attach private key to Bitwarden item: " + err.Error())
+	}
+	name := keyAttachmentName(keyPath)
+	for _, attachment := range existing {
+		if attachment.FileName != name && attachment.FileName != replace {
+			continue
+		}
+		if _, err := runBw("delete", "attachment", attachment.ID, "--itemid", itemID, "--session", session); err != nil {
+			log.Printf("Could not delete old Bitwarden attachment %s: %v", attachment.FileName, err)
+		}
+	}
+	return nil
+}
+
+// DownloadKeyAttachment saves the private key attached to conn to a new
+// temporary file that only the user can read and returns its path. The
+// caller removes the file once it is no longer needed.
+func (bwm *BitwardenManager) DownloadKeyAttachment(conn SSHConnection) (string, error) {
+	session, err := bwm.SessionKey()
+	if err != nil {
+		log.Print("Could not get Bitwarden session key during DownloadKeyAttachment")
+		return "", err
+	}
+	file, err := os.CreateTemp("", "sxt-key-*")
+	if err != nil {
+		return "", err
+	}
+	path := file.Name()
+	file.Close()
+
+	if _, err := runBw("get", "attachment", conn.KeyAttachment, "--itemid", conn.ID, "--output", path, "--session", session); err != nil {
+		os.Remove(path)
+		log.Printf("Could not download private key from Bitwarden: %v", err)
+		return "", errors.New("could not download private key from Bitwarden: " + err.Error())
+	}
+	// bw may have replaced the file with one of its own
+	if err := os.Chmod(path, 0600); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"os/exec"
+	"strings"
+)
+
+// organizationUserUser is the type bw list organizations gives plain
+// members, after owners (0) and admins (1)
+const organizationUserUser = 2
+
+// CanManageCollections reports whether the user's role may create and
+// rename collections. Custom roles are let through and bw has the final
+// say.
+func (o Organization) CanManageCollections() bool {
+	return o.Type != organizationUserUser
+}
+
+// bwEncode marshals v to JSON and encodes it with bw encode, as bw create
+// and bw edit expect
+func bwEncode(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command("bw", "encode")
+	cmd.Stdin = bytes.NewReader(data)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.New(err.Error() + " - " + stderr.String())
+	}
+	return out.Bytes(), nil
+}
+
+// runBwInput runs the bw CLI like runBw, feeding it input on stdin
+func runBwInput(input []byte, args ...string) ([]byte, error) {
+	cmd := exec.Command("bw", args...)
+	cmd.Stdin = bytes.NewReader(input)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.New(err.Error() + " - " + stderr.String())
+	}
+	return out.Bytes(), nil
+}
+
+// CreateCollection adds a collection named name to an organization
+func (bwm *BitwardenManager) CreateCollection(organizationID, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("collection name cannot be empty")
+	}
+	session, err := bwm.SessionKey()
+	if err != nil {
+		log.Print("Could not get Bitwarden session key during CreateCollection")
+		return err
+	}
+	encoded, err := bwEncode(map[string]any{
+		"organizationId": organizationID,
+		"name":           name,
+		"externalId":     nil,
+		"groups":         []any{},
+	})
+	if err != nil {
+		log.Printf("Failed to encode Bitwarden collection: %v", err)
+		return errors.New("failed to encode Bitwarden collection: " + err.Error())
+	}
+	if _, err := runBwInput(encoded, "create", "org-collection", "--organizationid", organizationID, "--session", session); err != nil {
+		log.Printf("Could not create collection: %v", err)
+		return errors.New("could not create collection: " + err.Error())
+	}
+	return nil
+}
+
+// RenameCollection renames a collection of an organization, keeping the
+// groups and users that have access to it
+func (bwm *BitwardenManager) RenameCollection(organizationID, collectionID, name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("collection name cannot be empty")
+	}
+	session, err := bwm.SessionKey()
+	if err != nil {
+		log.Print("Could not get Bitwarden session key during RenameCollection")
+		return err
+	}
+	out, err := runBw("get", "org-collection", collectionID, "--organizationid", organizationID, "--session", session)
+	if err != nil {
+		log.Printf("Could not get collection: %v", err)
+		return errors.New("could not get collection: " + err.Error())
+	}
+	var collection map[string]any
+	if err := json.Unmarshal(out, &collection); err != nil {
+		log.Printf("Failed to parse collection JSON: %v", err)
+		return err
+	}
+	collection["name"] = name
+	encoded, err := bwEncode(collection)
+	if err != nil {
+		log.Printf("Failed to encode Bitwarden collection: %v", err)
+		return errors.New("failed to encode Bitwarden collection: " + err.Error())
+	}
+	if _, err := runBwInput(encoded, "edit", "org-collection", collectionID, "--organizationid", organizationID, "--session", session); err != nil {
+		log.Printf("Could not rename collection: %v", err)
+		return errors.New("could not rename collection: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+)
+
+// EditConflictError is returned when a Bitwarden item was changed in the
+// vault, by a teammate or another device, after it was loaded
+type EditConflictError struct {
+	Base   SSHConnection // the item as it was loaded
+	Mine   SSHConnection // the edit that was not saved
+	Theirs SSHConnection // the item as it is now in the vault
+}
+
+func (e *EditConflictError) Error() string {
+	return fmt.Sprintf("%q was changed in Bitwarden since it was loaded", e.Base.Name)
+}
+
+// Merged returns the edit applied on top of the item in the vault: fields
+// only they changed keep their value, fields changed on both sides take
+// the edited value
+func (e *EditConflictError) Merged() SSHConnection {
+	return MergeConnections(e.Base, e.Mine, e.Theirs)
+}
+
+// checkRevision returns an *EditConflictError when the item behind conn has
+// a different revisionDate than when it was loaded
+func (bwm *BitwardenManager) checkRevision(session string, conn SSHConnection) error {
+	bwm.vaultMutex.Lock()
+	loaded, known := bwm.revisions[conn.ID]
+	base := bwm.items[conn.ID]
+	bwm.vaultMutex.Unlock()
+	if !known {
+		return nil
+	}
+
+	out, err := runBw("get", "item", conn.ID, "--session", session)
+	if err != nil {
+		log.Printf("Could not get Bitwarden item before editing: %v", err)
+		return errors.New("could not get Bitwarden item before editing: " + err.Error())
+	}
+	var item map[string]any
+	if err := json.Unmarshal(out, &item); err != nil {
+		log.Printf("Failed to parse Bitwarden item JSON: %v", err)
+		return err
+	}
+	if revision, _ := item["revisionDate"].(string); revision == loaded {
+		return nil
+	}
+	log.Printf("Bitwarden item %s changed since it was loaded", conn.ID)
+	return &EditConflictError{Base: base, Mine: conn, Theirs: connectionFromItem(item)}
+}
+
+// MergeConnections applies the changes from base to mine on top of theirs.
+// Where both changed a field, mine wins.
+func MergeConnections(base, mine, theirs SSHConnection) SSHConnection {
+	merged := theirs
+	b, m, out := reflect.ValueOf(base), reflect.ValueOf(mine), reflect.ValueOf(&merged).Elem()
+	for i := range b.NumField() {
+		if !sameValue(b.Field(i), m.Field(i)) {
+			out.Field(i).Set(m.Field(i))
+		}
+	}
+	return merged
+}
+
+// ChangedFields lists the JSON names of the fields that differ between a
+// and b
+func ChangedFields(a, b SSHConnection) []string {
+	var changed []string
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	for i := range va.NumField() {
+		if !sameValue(va.Field(i), vb.Field(i)) {
+			name, _, _ := strings.Cut(va.Type().Field(i).Tag.Get("json"), ",")
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// sameValue compares two field values, treating nil and empty lists alike
+func sameValue(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Slice && a.Len() == 0 && b.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMergeConnections(t *testing.T) {
+	base := SSHConnection{
+		ID:       "item",
+		Name:     "web",
+		Host:     "web.example.com",
+		Port:     22,
+		Username: "deploy",
+		Password: "old-secret",
+		SetEnv:   nil,
+	}
+
+	// I changed the port and the name, they rotated the password and
+	// renamed the item too
+	mine := base
+	mine.Port = 2222
+	mine.Name = "web (prod)"
+	mine.SetEnv = []string{}
+	theirs := base
+	theirs.Password = "new-secret"
+	theirs.Name = "web-1"
+	theirs.SetEnv = []string{"LANG=C.UTF-8"}
+
+	merged := MergeConnections(base, mine, theirs)
+	if merged.Port != 2222 {
+		t.Errorf("Expected my port, got %d", merged.Port)
+	}
+	if merged.Password != "new-secret" {
+		t.Errorf("Expected their password, got %q", merged.Password)
+	}
+	if merged.Name != "web (prod)" {
+		t.Errorf("Expected my name to win the conflict, got %q", merged.Name)
+	}
+	if !slices.Equal(merged.SetEnv, theirs.SetEnv) {
+		t.Errorf("Expected their environment, as an empty list is no change, got %v", merged.SetEnv)
+	}
+
+	if got := ChangedFields(base, theirs); !slices.Equal(got, []string{"name", "password", "set_env"}) {
+		t.Errorf("Unexpected changed fields %v", got)
+	}
+	if got := ChangedFields(base, base); len(got) != 0 {
+		t.Errorf("Expected no changed fields, got %v", got)
+	}
+}
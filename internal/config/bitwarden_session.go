@@ -0,0 +1,98 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"time"
+
+	"github.com/zalando/go-keyring"
+)
+
+// bitwardenSessionKey is the keyring entry caching the BW_SESSION token
+const bitwardenSessionKey = "bitwarden:session"
+
+// cachedBitwardenSession is the keyring value: the session token and when
+// it stops being used
+type cachedBitwardenSession struct {
+	Session string `json:"session"`
+	Expires int64  `json:"expires"`
+}
+
+// cacheSession keeps the session in the keyring for the configured TTL, so
+// the next launch does not ask for the master password. A TTL of 0 turns
+// the cache off.
+func (bwm *BitwardenManager) cacheSession() {
+	ttl := CurrentSettings().BitwardenSessionTTLMinutes
+	if ttl <= 0 || bwm.session == "" {
+		return
+	}
+	data, err := json.Marshal(cachedBitwardenSession{
+		Session: bwm.session,
+		Expires: time.Now().Add(time.Duration(ttl) * time.Minute).Unix(),
+	})
+	if err != nil {
+		return
+	}
+	if err := keyring.Set(keyringService, bitwardenSessionKey, string(data)); err != nil {
+		log.Printf("Failed to cache Bitwarden session in keyring: %v", err)
+	}
+}
+
+// cachedSession returns the session token cached in the keyring, if caching
+// is on and it has not expired. Expired tokens, and any token left from
+// before caching was turned off, are removed.
+func cachedSession() (string, bool) {
+	if CurrentSettings().BitwardenSessionTTLMinutes <= 0 {
+		clearCachedSession()
+		return "", false
+	}
+	data, err := keyring.Get(keyringService, bitwardenSessionKey)
+	if err != nil {
+		return "", false
+	}
+	var cached cachedBitwardenSession
+	if err := json.Unmarshal([]byte(data), &cached); err != nil || cached.Session == "" {
+		clearCachedSession()
+		return "", false
+	}
+	if time.Now().Unix() >= cached.Expires {
+		log.Print("Cached Bitwarden session expired")
+		clearCachedSession()
+		return "", false
+	}
+	return cached.Session, true
+}
+
+func clearCachedSession() {
+	if err := keyring.Delete(keyringService, bitwardenSessionKey); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		log.Printf("Failed to remove cached Bitwarden session: %v", err)
+	}
+}
+
+// restoreSession picks up a session the vault can be used with right away:
+// BW_SESSION from the environment, or one cached in the keyring
+func (bwm *BitwardenManager) restoreSession() {
+	if bwm.session != "" {
+		return
+	}
+	if session := os.Getenv("BW_SESSION"); session != "" {
+		bwm.session = session
+	} else if session, ok := cachedSession(); ok {
+		bwm.session = session
+	}
+}
+
+// Lock locks the vault with bw lock and forgets the session, cached or not,
+// so the master password is needed again
+func (bwm *BitwardenManager) Lock() error {
+	clearCachedSession()
+	bwm.session = ""
+	bwm.authed = false
+	if _, err := runBw("lock"); err != nil {
+		log.Printf("bw lock failed: %v", err)
+		return errors.New("bw lock failed: " + err.Error())
+	}
+	return nil
+}
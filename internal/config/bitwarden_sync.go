@@ -0,0 +1,105 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Refresh syncs the vault and reloads the connections in view, the personal
+// vault or the selected collection. It returns how many items were added,
+// changed or removed since they were last loaded.
+func (bwm *BitwardenManager) Refresh() (int, error) {
+	bwm.vaultMutex.Lock()
+	before := maps.Clone(bwm.revisions)
+	bwm.vaultMutex.Unlock()
+
+	if !bwm.IsPersonalVault() && bwm.GetSelectedCollection() == nil {
+		return 0, nil
+	}
+	var err error
+	if bwm.IsPersonalVault() {
+		err = bwm.Sync()
+	}
+	if err == nil {
+		// Loading a collection syncs before listing its items
+		err = bwm.LoadView()
+	}
+	if err != nil {
+		log.Printf("Background Bitwarden sync failed: %v", err)
+		return 0, err
+	}
+
+	bwm.vaultMutex.Lock()
+	defer bwm.vaultMutex.Unlock()
+	return changedRevisions(before, bwm.revisions), nil
+}
+
+// changedRevisions counts the items that differ between two revisionDate
+// snapshots, keyed by item ID
+func changedRevisions(before, after map[string]string) int {
+	changed := 0
+	for id, revision := range after {
+		if previous, ok := before[id]; !ok || previous != revision {
+			changed++
+		}
+	}
+	for id := range before {
+		if _, ok := after[id]; !ok {
+			changed++
+		}
+	}
+	return changed
+}
+
+// AllConnections lists the SSH connections of every vault the account can
+// see, the personal vault and all collections, for the ssh_config mirror
+func (bwm *BitwardenManager) AllConnections() ([]SSHConnection, error) {
+	session, err := bwm.SessionKey()
+	if err != nil {
+		return nil, err
+	}
+	out, err := runBw("list", "items", "--session", session)
+	if err != nil {
+		return nil, fmt.Errorf("bw list items failed: %w", err)
+	}
+	var items []map[string]any
+	if err := json.Unmarshal(out, &items); err != nil {
+		return nil, err
+	}
+
+	var conns []SSHConnection
+	for _, item := range items {
+		if t, ok := item["type"].(float64); !ok || int(t) != 1 {
+			continue
+		}
+		if !strings.HasPrefix(itemURI(item), "ssh://") {
+			continue
+		}
+		conns = append(conns, connectionFromItem(item))
+	}
+	slices.SortStableFunc(conns, func(a, b SSHConnection) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return conns, nil
+}
+
+// MirrorToSSHConfig writes every Bitwarden connection to the mirror block
+// of ~/.ssh/config and returns how many were written and where
+func (bwm *BitwardenManager) MirrorToSSHConfig() (int, string, error) {
+	conns, err := bwm.AllConnections()
+	if err != nil {
+		return 0, "", err
+	}
+	path, err := MirrorSSHConfigPath()
+	if err != nil {
+		return 0, "", err
+	}
+	if err := MirrorToSSHConfig(path, conns); err != nil {
+		return 0, path, err
+	}
+	return len(conns), path, nil
+}
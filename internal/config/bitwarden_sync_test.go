@@ -0,0 +1,31 @@
+package config
+
+import "testing"
+
+func TestChangedRevisions(t *testing.T) {
+	before := map[string]string{
+		"a": "2026-01-01T10:00:00.000Z",
+		"b": "2026-01-01T10:00:00.000Z",
+		"c": "2026-01-01T10:00:00.000Z",
+	}
+	tests := []struct {
+		name  string
+		after map[string]string
+		want  int
+	}{
+		{"unchanged", map[string]string{"a": before["a"], "b": before["b"], "c": before["c"]}, 0},
+		{"edited", map[string]string{"a": "2026-02-01T08:30:00.000Z", "b": before["b"], "c": before["c"]}, 1},
+		{"added", map[string]string{"a": before["a"], "b": before["b"], "c": before["c"], "d": "2026-02-01T08:30:00.000Z"}, 1},
+		{"removed", map[string]string{"a": before["a"]}, 2},
+		{"mixed", map[string]string{"a": "2026-02-01T08:30:00.000Z", "d": "2026-02-01T08:30:00.000Z"}, 4},
+	}
+	for _, tt := range tests {
+		if got := changedRevisions(before, tt.after); got != tt.want {
+			t.Errorf("%s: got %d changed items, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	if got := changedRevisions(nil, nil); got != 0 {
+		t.Errorf("Expected no changes between empty snapshots, got %d", got)
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"errors"
+	"strings"
+)
+
+// Bookmark is a directory of the file manager to jump back to, stored in
+// SSHConnection.Bookmarks as "local:" or "remote:" followed by the path
+type Bookmark struct {
+	Local bool
+	Path  string
+}
+
+// ParseBookmark reads a bookmark as stored in a connection. Paths without
+// a prefix are remote.
+func ParseBookmark(s string) Bookmark {
+	if path, ok := strings.CutPrefix(s, "local:"); ok {
+		return Bookmark{Local: true, Path: path}
+	}
+	return Bookmark{Path: strings.TrimPrefix(s, "remote:")}
+}
+
+func (b Bookmark) String() string {
+	if b.Local {
+		return "local:" + b.Path
+	}
+	return "remote:" + b.Path
+}
+
+// SetBookmarks replaces the file manager bookmarks of the connection with
+// the given ID
+func SetBookmarks(storage Storage, id string, bookmarks []string) error {
+	for _, conn := range storage.ListConnections() {
+		if conn.ID != id {
+			continue
+		}
+		conn.Bookmarks = bookmarks
+		return storage.EditConnection(conn)
+	}
+	return errors.New("connection with ID " + id + " not found")
+}
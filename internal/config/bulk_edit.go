@@ -0,0 +1,108 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BulkField is a connection setting that can be changed on many
+// connections at once
+type BulkField string
+
+const (
+	BulkUsername     BulkField = "username"
+	BulkPort         BulkField = "port"
+	BulkKeyFile      BulkField = "key_file"
+	BulkProxy        BulkField = "proxy"
+	BulkProxyCommand BulkField = "proxy_command"
+)
+
+// BulkFields are the fields offered by the bulk edit form, in order
+var BulkFields = []BulkField{BulkUsername, BulkPort, BulkKeyFile, BulkProxy, BulkProxyCommand}
+
+// Label names the field for display
+func (f BulkField) Label() string {
+	switch f {
+	case BulkUsername:
+		return "Username"
+	case BulkPort:
+		return "Port"
+	case BulkKeyFile:
+		return "Key File"
+	case BulkProxy:
+		return "Proxy"
+	case BulkProxyCommand:
+		return "ProxyCommand"
+	}
+	return string(f)
+}
+
+// ApplyBulkEdit returns conn with field set to value. An empty value clears
+// the optional fields. A key file switches the connection to key auth, and
+// a proxy replaces the ProxyCommand or the other way around, as only one
+// of them can be used.
+func ApplyBulkEdit(conn SSHConnection, field BulkField, value string) (SSHConnection, error) {
+	value = strings.TrimSpace(value)
+	switch field {
+	case BulkUsername:
+		if value == "" {
+			return conn, errors.New("username cannot be empty")
+		}
+		conn.Username = value
+	case BulkPort:
+		port, err := strconv.Atoi(value)
+		if err != nil || port < 1 || port > 65535 {
+			return conn, errors.New("port must be a number between 1 and 65535")
+		}
+		conn.Port = port
+	case BulkKeyFile:
+		conn.KeyFile = value
+		if value != "" {
+			conn.UsePassword = false
+		}
+	case BulkProxy:
+		conn.Proxy = value
+		if value != "" {
+			conn.ProxyCommand = ""
+		} else {
+			conn.ProxyPassword = ""
+		}
+	case BulkProxyCommand:
+		conn.ProxyCommand = value
+		if value != "" {
+			conn.Proxy = ""
+			conn.ProxyPassword = ""
+		}
+	default:
+		return conn, fmt.Errorf("unknown field %q", field)
+	}
+	return conn, nil
+}
+
+// BulkEdit sets field to value on the connections with the given IDs and
+// returns how many were updated. It carries on past connections that fail
+// and returns their errors joined.
+func BulkEdit(storage Storage, ids []string, field BulkField, value string) (int, error) {
+	updated := 0
+	var errs []error
+	for _, id := range ids {
+		// GetConnection includes the secrets, which editing must keep
+		conn, ok := storage.GetConnection(id)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: connection not found", id))
+			continue
+		}
+		edited, err := ApplyBulkEdit(conn, field, value)
+		if err != nil {
+			return updated, err
+		}
+		if err := storage.EditConnection(edited); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", conn.Name, err))
+			continue
+		}
+		updated++
+	}
+	return updated, errors.Join(errs...)
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// mapStorage keeps connections in memory, failing edits of failID
+type mapStorage struct {
+	conns  map[string]SSHConnection
+	failID string
+}
+
+func (s *mapStorage) Load() error                         { return nil }
+func (s *mapStorage) Save() error                         { return nil }
+func (s *mapStorage) AddConnection(c SSHConnection) error { s.conns[c.ID] = c; return nil }
+func (s *mapStorage) DeleteConnection(id string) error    { delete(s.conns, id); return nil }
+func (s *mapStorage) GetConnection(id string) (SSHConnection, bool) {
+	c, ok := s.conns[id]
+	return c, ok
+}
+func (s *mapStorage) ListConnections() []SSHConnection  { return nil }
+func (s *mapStorage) Capabilities() StorageCapabilities { return StorageCapabilities{} }
+func (s *mapStorage) EditConnection(c SSHConnection) error {
+	if c.ID == s.failID {
+		return errors.New("locked")
+	}
+	s.conns[c.ID] = c
+	return nil
+}
+
+func TestApplyBulkEdit(t *testing.T) {
+	conn := SSHConnection{Username: "root", Port: 22, UsePassword: true, ProxyCommand: "cloudflared access ssh --hostname %h"}
+
+	edited, err := ApplyBulkEdit(conn, BulkKeyFile, " ~/.ssh/id_team ")
+	if err != nil || edited.KeyFile != "~/.ssh/id_team" || edited.UsePassword {
+		t.Errorf("Expected key auth with the new key, got %+v (%v)", edited, err)
+	}
+	edited, err = ApplyBulkEdit(conn, BulkProxy, "socks5://bastion:1080")
+	if err != nil || edited.Proxy != "socks5://bastion:1080" || edited.ProxyCommand != "" {
+		t.Errorf("Expected the proxy to replace the ProxyCommand, got %+v (%v)", edited, err)
+	}
+	if edited, err = ApplyBulkEdit(conn, BulkPort, "2222"); err != nil || edited.Port != 2222 {
+		t.Errorf("Expected port 2222, got %+v (%v)", edited, err)
+	}
+	for _, bad := range []struct {
+		field BulkField
+		value string
+	}{{BulkPort, "0"}, {BulkPort, "ssh"}, {BulkUsername, "  "}, {"color", "red"}} {
+		if _, err := ApplyBulkEdit(conn, bad.field, bad.value); err == nil {
+			t.Errorf("Expected an error setting %s to %q", bad.field, bad.value)
+		}
+	}
+}
+
+func TestBulkEdit(t *testing.T) {
+	storage := &mapStorage{conns: map[string]SSHConnection{
+		"a": {ID: "a", Name: "A", Username: "root", Password: "pw-a"},
+		"b": {ID: "b", Name: "B", Username: "root"},
+		"c": {ID: "c", Name: "C", Username: "root"},
+	}, failID: "b"}
+
+	updated, err := BulkEdit(storage, []string{"a", "b", "c", "gone"}, BulkUsername, "deploy")
+	if updated != 2 {
+		t.Errorf("Expected 2 connections updated, got %d", updated)
+	}
+	if err == nil || !strings.Contains(err.Error(), "B: locked") || !strings.Contains(err.Error(), "gone: connection not found") {
+		t.Errorf("Expected the failures to be reported, got %v", err)
+	}
+	if a := storage.conns["a"]; a.Username != "deploy" || a.Password != "pw-a" {
+		t.Errorf("Expected the username changed and the password kept, got %+v", a)
+	}
+	if b := storage.conns["b"]; b.Username != "root" {
+		t.Errorf("Expected the failed connection unchanged, got %+v", b)
+	}
+}
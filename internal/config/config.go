@@ -21,7 +21,12 @@ type ConfigManager struct {
 	Config     *Config
 }
 
-var IsTmuxAvailable bool = false
+// ActiveMultiplexer is the terminal multiplexer connections can be opened
+// in (tmux, zellij or wezterm), or "" when there is none
+var ActiveMultiplexer string
+
+// LogFilePath is where sxt logs to, "" when logging is off
+var LogFilePath string
 
 func NewConfigManager() (*ConfigManager, error) {
 	homeDir, err := os.UserHomeDir()
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const defaultConnectionUsageFileName = "connection_usage.json"
+
+// ConnectionUsage is when a connection was last used and how often
+type ConnectionUsage struct {
+	LastConnected int64 `json:"last_connected"`
+	ConnectCount  int   `json:"connect_count"`
+}
+
+// ConnectionUsageStore keeps the usage of connections, by ID, in
+// ~/.config/ssh-x-term/connection_usage.json. Connecting then never writes
+// to the storage backend, where each save would be a new revision of a
+// shared item.
+type ConnectionUsageStore struct {
+	Path        string                     `json:"-"`
+	Connections map[string]ConnectionUsage `json:"connections"`
+}
+
+// NewConnectionUsageStore creates a connection usage store in the default
+// location
+func NewConnectionUsageStore() (*ConnectionUsageStore, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return &ConnectionUsageStore{
+		Path: filepath.Join(homeDir, ".config", "ssh-x-term", defaultConnectionUsageFileName),
+	}, nil
+}
+
+// Load reads the connection usage file. A missing file yields an empty
+// store.
+func (s *ConnectionUsageStore) Load() error {
+	s.Connections = nil
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", s.Path, err)
+	}
+	return nil
+}
+
+// Save writes the connection usage file, creating its directory if needed
+func (s *ConnectionUsageStore) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.Path, data, 0600); err != nil {
+		log.Printf("Failed to write connection usage file: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Record bumps the usage of conn and saves the store. Counts conn still
+// carries from its backend, saved there by older versions, are carried on.
+func (s *ConnectionUsageStore) Record(conn SSHConnection, now time.Time) error {
+	if s.Connections == nil {
+		s.Connections = make(map[string]ConnectionUsage)
+	}
+	usage := s.Connections[conn.ID]
+	s.Connections[conn.ID] = ConnectionUsage{
+		LastConnected: now.Unix(),
+		ConnectCount:  max(usage.ConnectCount, conn.ConnectCount) + 1,
+	}
+	return s.Save()
+}
+
+// Apply returns conns with the recorded usage merged in
+func (s *ConnectionUsageStore) Apply(conns []SSHConnection) []SSHConnection {
+	if len(s.Connections) == 0 {
+		return conns
+	}
+	merged := make([]SSHConnection, len(conns))
+	for i, conn := range conns {
+		if usage, ok := s.Connections[conn.ID]; ok {
+			conn.LastConnected = max(conn.LastConnected, usage.LastConnected)
+			conn.ConnectCount = max(conn.ConnectCount, usage.ConnectCount)
+		}
+		merged[i] = conn
+	}
+	return merged
+}
+
+// WithUsage returns conns with the usage recorded in the default store
+// merged in
+func WithUsage(conns []SSHConnection) []SSHConnection {
+	store, err := NewConnectionUsageStore()
+	if err != nil {
+		return conns
+	}
+	if err := store.Load(); err != nil {
+		log.Printf("Failed to load connection usage: %v", err)
+		return conns
+	}
+	return store.Apply(conns)
+}
+
+// RecordUsage bumps the usage of conn in the default store
+func RecordUsage(conn SSHConnection, now time.Time) error {
+	store, err := NewConnectionUsageStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Load(); err != nil {
+		return err
+	}
+	return store.Record(conn, now)
+}
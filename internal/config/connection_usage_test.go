@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConnectionUsageStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "connection_usage.json")
+	store := &ConnectionUsageStore{Path: path}
+	if err := store.Load(); err != nil || len(store.Connections) != 0 {
+		t.Fatalf("Expected missing file to load as empty, got %v", err)
+	}
+
+	web := SSHConnection{ID: "web", Name: "web"}
+	legacy := SSHConnection{ID: "db", Name: "db", LastConnected: 100, ConnectCount: 7}
+	for i := range 3 {
+		if err := store.Record(web, time.Unix(int64(1000+i), 0)); err != nil {
+			t.Fatalf("Failed to record a connection: %v", err)
+		}
+	}
+	if err := store.Record(legacy, time.Unix(2000, 0)); err != nil {
+		t.Fatalf("Failed to record a connection: %v", err)
+	}
+
+	reloaded := &ConnectionUsageStore{Path: path}
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Failed to reload connection usage: %v", err)
+	}
+	got := reloaded.Apply([]SSHConnection{web, legacy, {ID: "never"}})
+	if got[0].LastConnected != 1002 || got[0].ConnectCount != 3 {
+		t.Errorf("Expected web connected 3 times, last at 1002, got %+v", got[0])
+	}
+	if got[1].LastConnected != 2000 || got[1].ConnectCount != 8 {
+		t.Errorf("Expected the count stored with db carried on, got %+v", got[1])
+	}
+	if got[2].LastConnected != 0 || got[2].ConnectCount != 0 {
+		t.Errorf("Expected no usage for a connection never used, got %+v", got[2])
+	}
+
+	// Merging the usage again, as when a merged connection is saved, counts
+	// nothing twice
+	if again := reloaded.Apply(got); again[1].ConnectCount != 8 {
+		t.Errorf("Expected merging to be idempotent, got %+v", again[1])
+	}
+}
+
+func TestRecordConnectionLeavesStorage(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configPath := filepath.Join(home, "config")
+	original := "#sxt:id=web-1\n#sxt:name=Web\n#sxt:use_password=false\nHost web\n    HostName web.example.com\n"
+	if err := os.WriteFile(configPath, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+	scm := &SSHConfigManager{ConfigPath: configPath, Config: NewConfig()}
+	if err := scm.parseSSHConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RecordConnection(scm, "web-1", time.Unix(1700000000, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordConnection(scm, "missing", time.Unix(1700000000, 0)); err == nil {
+		t.Error("Expected an unknown ID to be refused")
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != original {
+		t.Errorf("Expected the SSH config unchanged, got:\n%s", data)
+	}
+	conns := WithUsage(scm.ListConnections())
+	if conns[0].LastConnected != 1700000000 || conns[0].ConnectCount != 1 {
+		t.Errorf("Expected the usage merged into the list, got %+v", conns[0])
+	}
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// debugBundleLogBytes is how much of the end of the log goes into a bundle
+const debugBundleLogBytes = 1 << 20
+
+// DefaultDebugBundlePath names a new bundle in the current directory
+func DefaultDebugBundlePath() string {
+	return fmt.Sprintf("sxt-debug-%s.zip", time.Now().Format("20060102-150405"))
+}
+
+// WriteDebugBundle writes a zip archive for bug reports to path: version
+// and environment details, the settings and the end of the log at logPath.
+// Everything is redacted, and no connections or credentials are included.
+func WriteDebugBundle(path, version, logPath string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+
+	if err := addBundleFile(zw, "info.txt", debugInfo(version, logPath)); err != nil {
+		return err
+	}
+	if settings := CurrentSettings(); settings.Path != "" {
+		if data, err := os.ReadFile(settings.Path); err == nil {
+			if err := addBundleFile(zw, "settings.toml", string(data)); err != nil {
+				return err
+			}
+		}
+	}
+	if logPath != "" {
+		if tail, err := readTail(logPath, debugBundleLogBytes); err == nil {
+			if err := addBundleFile(zw, "sxt.log", tail); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// addBundleFile adds a redacted file to the bundle
+func addBundleFile(zw *zip.Writer, name, content string) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, Redact(content))
+	return err
+}
+
+// debugInfo describes the build and the environment sxt runs in
+func debugInfo(version, logPath string) string {
+	lookup := func(name string) string {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+		return "not found"
+	}
+	set := func(name string) string {
+		if os.Getenv(name) != "" {
+			return "set"
+		}
+		return "unset"
+	}
+	info := fmt.Sprintf("version: %s\n", version)
+	info += fmt.Sprintf("go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	info += fmt.Sprintf("generated: %s\n", time.Now().Format(time.RFC3339))
+	info += fmt.Sprintf("TERM: %s\nCOLORTERM: %s\n", os.Getenv("TERM"), os.Getenv("COLORTERM"))
+	info += fmt.Sprintf("TMUX: %s\nZELLIJ: %s\nWEZTERM_PANE: %s\n", set("TMUX"), set("ZELLIJ"), set("WEZTERM_PANE"))
+	info += fmt.Sprintf("BW_SESSION: %s\n", set("BW_SESSION"))
+	for _, tool := range []string{"bw", "keepassxc-cli", "tmux", "zellij", "wezterm", "mosh"} {
+		info += fmt.Sprintf("%s: %s\n", tool, lookup(tool))
+	}
+	info += fmt.Sprintf("log: %s\n", logPath)
+	return info
+}
+
+// readTail returns up to n bytes from the end of the file, starting at a
+// line boundary when it had to cut
+func readTail(path string, n int64) (string, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	offset := max(stat.Size()-n, 0)
+	data := make([]byte, stat.Size()-offset)
+	if _, err := f.ReadAt(data, offset); err != nil && err != io.EOF {
+		return "", err
+	}
+	if offset > 0 {
+		for i, c := range data {
+			if c == '\n' {
+				data = data[i+1:]
+				break
+			}
+		}
+	}
+	return string(data), nil
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteDebugBundle(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "sxt.log")
+	RegisterSecret("bundle-pass-1")
+	logContent := "2024/01/01 connecting\n2024/01/01 auth with bundle-pass-1 failed\n"
+	if err := os.WriteFile(logPath, []byte(logContent), 0600); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+
+	path := filepath.Join(dir, "bundle.zip")
+	if err := WriteDebugBundle(path, "1.2.3", logPath); err != nil {
+		t.Fatalf("Failed to write bundle: %v", err)
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("Failed to open bundle: %v", err)
+	}
+	defer zr.Close()
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", f.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+	if !strings.Contains(files["info.txt"], "version: 1.2.3") {
+		t.Errorf("Expected the version in info.txt, got %q", files["info.txt"])
+	}
+	if got := files["sxt.log"]; !strings.Contains(got, "auth with [REDACTED] failed") || strings.Contains(got, "bundle-pass-1") {
+		t.Errorf("Expected a redacted log, got %q", got)
+	}
+}
+
+func TestReadTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sxt.log")
+	if err := os.WriteFile(path, []byte("first line\nsecond line\nthird\n"), 0600); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+	if got, err := readTail(path, 15); err != nil || got != "third\n" {
+		t.Errorf("Expected the tail to start at a full line, got %q (%v)", got, err)
+	}
+	if got, err := readTail(path, 1000); err != nil || got != "first line\nsecond line\nthird\n" {
+		t.Errorf("Expected the whole file, got %q (%v)", got, err)
+	}
+}
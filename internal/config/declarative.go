@@ -0,0 +1,216 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultDeclarativeFileName = "connections.yaml"
+	declarativeFileEnv         = "SXT_CONNECTIONS_FILE"
+)
+
+// DeclarativeFilePath overrides the location of the declarative connections
+// file (set from the -f command line flag).
+var DeclarativeFilePath string
+
+// DeclaredConnection is a connection entry in the declarative YAML file.
+// Secrets are deliberately not part of the format; passwords are prompted
+// for on first connect and stored in the keyring as usual.
+type DeclaredConnection struct {
+	ID          string `yaml:"id,omitempty"`
+	Name        string `yaml:"name"`
+	Host        string `yaml:"host"`
+	Port        int    `yaml:"port,omitempty"`
+	Username    string `yaml:"user,omitempty"`
+	KeyFile     string `yaml:"key_file,omitempty"`
+	UsePassword bool   `yaml:"use_password,omitempty"`
+	Notes       string `yaml:"notes,omitempty"`
+}
+
+// DeclarativeFile is the top-level layout of connections.yaml
+type DeclarativeFile struct {
+	// Prune removes connections from the backend that are not declared
+	Prune       bool                 `yaml:"prune,omitempty"`
+	Connections []DeclaredConnection `yaml:"connections"`
+}
+
+// ReconcilePlan lists the changes needed to bring a backend in line with
+// the declared connections.
+type ReconcilePlan struct {
+	Create []SSHConnection
+	Update []SSHConnection
+	Prune  []SSHConnection
+}
+
+// IsEmpty reports whether the backend already matches the declared set
+func (p *ReconcilePlan) IsEmpty() bool {
+	return len(p.Create) == 0 && len(p.Update) == 0 && len(p.Prune) == 0
+}
+
+// FindDeclarativeFile returns the declarative file to reconcile against, or
+// "" if none is configured. The -f flag wins over $SXT_CONNECTIONS_FILE,
+// which wins over ~/.config/ssh-x-term/connections.yaml.
+func FindDeclarativeFile() string {
+	if DeclarativeFilePath != "" {
+		return ExpandPath(DeclarativeFilePath)
+	}
+	if path := os.Getenv(declarativeFileEnv); path != "" {
+		return ExpandPath(path)
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	path := filepath.Join(homeDir, ".config", "ssh-x-term", defaultDeclarativeFileName)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// LoadDeclarativeFile reads and validates a declarative connections file
+func LoadDeclarativeFile(path string) (*DeclarativeFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file DeclarativeFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool)
+	for i, dc := range file.Connections {
+		if dc.Name == "" || dc.Host == "" {
+			return nil, fmt.Errorf("%s: connection #%d needs both name and host", path, i+1)
+		}
+		key := strings.ToLower(dc.Name)
+		if seen[key] {
+			return nil, fmt.Errorf("%s: duplicate connection name %q", path, dc.Name)
+		}
+		seen[key] = true
+	}
+	return &file, nil
+}
+
+// PlanReconcile compares the declared connections against the existing ones.
+// Declared entries are matched by id when given, otherwise by name
+// (case-insensitive). Local-only state such as pinning, ordering and stored
+// secrets is preserved on update.
+func PlanReconcile(declared *DeclarativeFile, existing []SSHConnection) *ReconcilePlan {
+	plan := &ReconcilePlan{}
+	matched := make(map[string]bool)
+
+	for _, dc := range declared.Connections {
+		want := dc.toConnection()
+		current, ok := findDeclaredMatch(dc, existing)
+		if !ok {
+			if want.ID == "" {
+				want.ID = generateID()
+			}
+			plan.Create = append(plan.Create, want)
+			continue
+		}
+		matched[current.ID] = true
+
+		updated := current
+		updated.Name = want.Name
+		updated.Host = want.Host
+		updated.Port = want.Port
+		updated.Username = want.Username
+		updated.KeyFile = want.KeyFile
+		updated.UsePassword = want.UsePassword
+		updated.Notes = want.Notes
+		if !sameDeclaredFields(current, updated) {
+			plan.Update = append(plan.Update, updated)
+		}
+	}
+
+	if declared.Prune {
+		for _, conn := range existing {
+			if !matched[conn.ID] {
+				plan.Prune = append(plan.Prune, conn)
+			}
+		}
+	}
+	return plan
+}
+
+// ApplyReconcilePlan writes the plan to the storage backend. It keeps going
+// after a failed entry and returns all errors joined together.
+func ApplyReconcilePlan(storage Storage, plan *ReconcilePlan) error {
+	var errs []error
+	for _, conn := range plan.Create {
+		if err := storage.AddConnection(conn); err != nil {
+			log.Printf("Reconcile: failed to create %s: %v", conn.Name, err)
+			errs = append(errs, fmt.Errorf("create %s: %w", conn.Name, err))
+		}
+	}
+	for _, conn := range plan.Update {
+		if err := storage.EditConnection(conn); err != nil {
+			log.Printf("Reconcile: failed to update %s: %v", conn.Name, err)
+			errs = append(errs, fmt.Errorf("update %s: %w", conn.Name, err))
+		}
+	}
+	for _, conn := range plan.Prune {
+		if err := storage.DeleteConnection(conn.ID); err != nil {
+			log.Printf("Reconcile: failed to prune %s: %v", conn.Name, err)
+			errs = append(errs, fmt.Errorf("prune %s: %w", conn.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (dc DeclaredConnection) toConnection() SSHConnection {
+	port := dc.Port
+	if port == 0 {
+		port = 22
+	}
+	return SSHConnection{
+		ID:          dc.ID,
+		Name:        dc.Name,
+		Host:        dc.Host,
+		Port:        port,
+		Username:    dc.Username,
+		KeyFile:     dc.KeyFile, // As written: it is expanded when used
+		UsePassword: dc.UsePassword,
+		Notes:       dc.Notes,
+	}
+}
+
+func findDeclaredMatch(dc DeclaredConnection, existing []SSHConnection) (SSHConnection, bool) {
+	for _, conn := range existing {
+		if dc.ID != "" && conn.ID == dc.ID {
+			return conn, true
+		}
+	}
+	if dc.ID != "" {
+		return SSHConnection{}, false
+	}
+	for _, conn := range existing {
+		if strings.EqualFold(conn.Name, dc.Name) {
+			return conn, true
+		}
+	}
+	return SSHConnection{}, false
+}
+
+// sameDeclaredFields compares what a declarative file sets. Key files are
+// the same when they expand to the same path, so ~/.ssh/id and
+// /home/me/.ssh/id are not a change.
+func sameDeclaredFields(a, b SSHConnection) bool {
+	return a.Name == b.Name &&
+		a.Host == b.Host &&
+		a.Port == b.Port &&
+		a.Username == b.Username &&
+		ExpandPath(a.KeyFile) == ExpandPath(b.KeyFile) &&
+		a.UsePassword == b.UsePassword &&
+		a.Notes == b.Notes
+}
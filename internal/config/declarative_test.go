@@ -0,0 +1,139 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDeclarativeFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "connections.yaml")
+	content := `prune: true
+connections:
+  - name: web-1
+    host: 10.0.0.1
+    user: deploy
+    key_file: /keys/id_ed25519
+  - id: sxt-fixed
+    name: db
+    host: db.internal
+    port: 2222
+    use_password: true
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write declarative file: %v", err)
+	}
+
+	file, err := LoadDeclarativeFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load declarative file: %v", err)
+	}
+	if !file.Prune {
+		t.Error("Expected prune to be true")
+	}
+	if len(file.Connections) != 2 {
+		t.Fatalf("Expected 2 connections, got %d", len(file.Connections))
+	}
+	if file.Connections[1].ID != "sxt-fixed" || file.Connections[1].Port != 2222 {
+		t.Errorf("Unexpected second connection: %+v", file.Connections[1])
+	}
+
+	t.Run("rejects duplicate names", func(t *testing.T) {
+		dup := filepath.Join(tmpDir, "dup.yaml")
+		os.WriteFile(dup, []byte("connections:\n  - {name: a, host: h1}\n  - {name: A, host: h2}\n"), 0600)
+		if _, err := LoadDeclarativeFile(dup); err == nil {
+			t.Error("Expected error for duplicate names")
+		}
+	})
+
+	t.Run("rejects missing host", func(t *testing.T) {
+		bad := filepath.Join(tmpDir, "bad.yaml")
+		os.WriteFile(bad, []byte("connections:\n  - name: a\n"), 0600)
+		if _, err := LoadDeclarativeFile(bad); err == nil {
+			t.Error("Expected error for missing host")
+		}
+	})
+}
+
+func TestPlanReconcile(t *testing.T) {
+	existing := []SSHConnection{
+		{ID: "id-web", Name: "web-1", Host: "10.0.0.1", Port: 22, Username: "deploy", Pinned: true, Password: "secret", UsePassword: true},
+		{ID: "id-db", Name: "db", Host: "db.old", Port: 22, Username: "root"},
+		{ID: "id-stale", Name: "stale", Host: "gone.example.com", Port: 22},
+	}
+	declared := &DeclarativeFile{
+		Connections: []DeclaredConnection{
+			{Name: "WEB-1", Host: "10.0.0.1", Username: "deploy", UsePassword: true},
+			{ID: "id-db", Name: "db", Host: "db.internal", Username: "root"},
+			{Name: "cache", Host: "cache.internal", Port: 6022},
+		},
+	}
+
+	t.Run("without prune", func(t *testing.T) {
+		plan := PlanReconcile(declared, existing)
+
+		if len(plan.Create) != 1 || plan.Create[0].Name != "cache" {
+			t.Fatalf("Expected cache to be created, got %+v", plan.Create)
+		}
+		if plan.Create[0].ID == "" || plan.Create[0].Port != 6022 {
+			t.Errorf("Created connection missing ID or port: %+v", plan.Create[0])
+		}
+
+		// web-1 only differs by name case, db changed host
+		if len(plan.Update) != 2 {
+			t.Fatalf("Expected 2 updates, got %d", len(plan.Update))
+		}
+		web := plan.Update[0]
+		if web.ID != "id-web" || web.Name != "WEB-1" || !web.Pinned || web.Password != "secret" {
+			t.Errorf("Update did not preserve local state: %+v", web)
+		}
+		if plan.Update[1].Host != "db.internal" {
+			t.Errorf("Expected db host to be updated, got %s", plan.Update[1].Host)
+		}
+
+		if len(plan.Prune) != 0 {
+			t.Errorf("Expected no prunes without prune flag, got %d", len(plan.Prune))
+		}
+	})
+
+	t.Run("with prune", func(t *testing.T) {
+		declared.Prune = true
+		defer func() { declared.Prune = false }()
+
+		plan := PlanReconcile(declared, existing)
+		if len(plan.Prune) != 1 || plan.Prune[0].ID != "id-stale" {
+			t.Errorf("Expected stale to be pruned, got %+v", plan.Prune)
+		}
+	})
+
+	t.Run("in sync", func(t *testing.T) {
+		inSync := &DeclarativeFile{
+			Connections: []DeclaredConnection{{Name: "stale", Host: "gone.example.com"}},
+		}
+		if plan := PlanReconcile(inSync, existing); !plan.IsEmpty() {
+			t.Errorf("Expected empty plan, got %+v", plan)
+		}
+	})
+
+	t.Run("key files as written", func(t *testing.T) {
+		keyed := []SSHConnection{
+			{ID: "id-a", Name: "a", Host: "a.internal", Port: 22, KeyFile: "~/.ssh/id_a"},
+			{ID: "id-b", Name: "b", Host: "b.internal", Port: 22, KeyFile: ExpandPath("~/.ssh/id_b")},
+		}
+		declared := &DeclarativeFile{
+			Connections: []DeclaredConnection{
+				{Name: "a", Host: "a.internal", KeyFile: "~/.ssh/id_a"},
+				{Name: "b", Host: "b.internal", KeyFile: "~/.ssh/id_b"},
+				{Name: "c", Host: "c.internal", KeyFile: "~/.ssh/id_c"},
+			},
+		}
+		plan := PlanReconcile(declared, keyed)
+		if len(plan.Update) != 0 {
+			t.Errorf("Expected no updates for the same key files, got %+v", plan.Update)
+		}
+		if len(plan.Create) != 1 || plan.Create[0].KeyFile != "~/.ssh/id_c" {
+			t.Errorf("Expected c created with its key file as written, got %+v", plan.Create)
+		}
+	})
+}
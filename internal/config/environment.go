@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ParseSetEnv reads whitespace-separated NAME=value pairs written like an
+// ssh_config SetEnv line, where a value with spaces goes in double quotes
+// (FOO="two words"). Inside quotes, \" and \\ are escapes.
+func ParseSetEnv(s string) ([]string, error) {
+	var (
+		vars    []string
+		current strings.Builder
+		inToken bool
+		quoted  bool
+	)
+	flush := func() {
+		if inToken {
+			vars = append(vars, current.String())
+		}
+		current.Reset()
+		inToken = false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+			inToken = true
+		case quoted && c == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\'):
+			i++
+			current.WriteByte(s[i])
+		case !quoted && (c == ' ' || c == '\t'):
+			flush()
+		default:
+			current.WriteByte(c)
+			inToken = true
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated quote in %q", s)
+	}
+	flush()
+
+	for _, v := range vars {
+		name, _, ok := strings.Cut(v, "=")
+		if !ok || !envNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid environment variable %q (expected NAME=value)", v)
+		}
+	}
+	return vars, nil
+}
+
+// FormatSetEnv writes NAME=value pairs the way ParseSetEnv reads them
+func FormatSetEnv(vars []string) string {
+	parts := make([]string, len(vars))
+	for i, v := range vars {
+		name, value, _ := strings.Cut(v, "=")
+		if value == "" || strings.ContainsAny(value, " \t\"\\") {
+			value = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+		}
+		parts[i] = name + "=" + value
+	}
+	return strings.Join(parts, " ")
+}
+
+// RemoteCommand is what sessions run instead of the account's login shell:
+// the startup command followed by the shell override, or the user's own
+// shell. It is "" when neither is set and the server's default applies.
+func (c SSHConnection) RemoteCommand() string {
+	if c.StartupCommand == "" && c.Shell == "" {
+		return ""
+	}
+	shell := c.Shell
+	if shell == "" {
+		shell = "$SHELL"
+	}
+	command := "exec " + shell + " -l"
+	if c.StartupCommand != "" {
+		command = c.StartupCommand + "; " + command
+	}
+	return command
+}
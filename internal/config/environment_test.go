@@ -0,0 +1,48 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSetEnv(t *testing.T) {
+	vars, err := ParseSetEnv(` LANG=C.UTF-8   GREETING="hello  world" QUOTE="say \"hi\"" EMPTY="" PATH_X=a=b `)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := []string{"LANG=C.UTF-8", "GREETING=hello  world", `QUOTE=say "hi"`, "EMPTY=", "PATH_X=a=b"}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("Expected %q, got %q", want, vars)
+	}
+
+	reparsed, err := ParseSetEnv(FormatSetEnv(vars))
+	if err != nil || !reflect.DeepEqual(reparsed, want) {
+		t.Errorf("Expected %q to round-trip, got %q (%v)", want, reparsed, err)
+	}
+
+	if vars, err := ParseSetEnv("  "); err != nil || vars != nil {
+		t.Errorf("Expected no variables from a blank line, got %q (%v)", vars, err)
+	}
+	for _, bad := range []string{"NOVALUE", "1X=y", "=y", `A="open`} {
+		if _, err := ParseSetEnv(bad); err == nil {
+			t.Errorf("Expected an error parsing %q", bad)
+		}
+	}
+}
+
+func TestRemoteCommand(t *testing.T) {
+	tests := []struct {
+		startup, shell, want string
+	}{
+		{"", "", ""},
+		{"", "/bin/zsh", "exec /bin/zsh -l"},
+		{"cd /srv/app", "", "cd /srv/app; exec $SHELL -l"},
+		{"tmux attach || true", "fish", "tmux attach || true; exec fish -l"},
+	}
+	for _, tt := range tests {
+		conn := SSHConnection{StartupCommand: tt.startup, Shell: tt.shell}
+		if got := conn.RemoteCommand(); got != tt.want {
+			t.Errorf("RemoteCommand(%q, %q) = %q, want %q", tt.startup, tt.shell, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExportFormat is a file format connections can be exported to
+type ExportFormat int
+
+const (
+	ExportJSON ExportFormat = iota
+	ExportYAML
+	ExportSSHConfig
+)
+
+// ExportFormats lists the formats in display order
+var ExportFormats = []ExportFormat{ExportJSON, ExportYAML, ExportSSHConfig}
+
+func (f ExportFormat) String() string {
+	switch f {
+	case ExportJSON:
+		return "json"
+	case ExportYAML:
+		return "yaml"
+	case ExportSSHConfig:
+		return "ssh_config"
+	}
+	return "unknown"
+}
+
+// Extension is the usual file extension for the format
+func (f ExportFormat) Extension() string {
+	switch f {
+	case ExportYAML:
+		return ".yaml"
+	case ExportSSHConfig:
+		return ".conf"
+	}
+	return ".json"
+}
+
+// SupportsSecrets reports whether passwords can be written in this format.
+// ssh_config has no place for them.
+func (f ExportFormat) SupportsSecrets() bool {
+	return f != ExportSSHConfig
+}
+
+// ParseExportFormat parses a format name as accepted by `sxt export`
+func ParseExportFormat(s string) (ExportFormat, error) {
+	switch strings.ToLower(s) {
+	case "json":
+		return ExportJSON, nil
+	case "yaml", "yml":
+		return ExportYAML, nil
+	case "ssh_config", "ssh-config", "sshconfig":
+		return ExportSSHConfig, nil
+	}
+	return 0, fmt.Errorf("unknown export format %q (use json, yaml or ssh_config)", s)
+}
+
+// exportedConnection is the JSON/YAML layout of an exported connection.
+// The YAML form can be used directly as a declarative connections file.
+type exportedConnection struct {
+	ID                    string   `json:"id" yaml:"id"`
+	Name                  string   `json:"name" yaml:"name"`
+	Host                  string   `json:"host" yaml:"host"`
+	Port                  int      `json:"port" yaml:"port"`
+	Username              string   `json:"user,omitempty" yaml:"user,omitempty"`
+	KeyFile               string   `json:"key_file,omitempty" yaml:"key_file,omitempty"`
+	UsePassword           bool     `json:"use_password,omitempty" yaml:"use_password,omitempty"`
+	Notes                 string   `json:"notes,omitempty" yaml:"notes,omitempty"`
+	ViewOnly              bool     `json:"view_only,omitempty" yaml:"view_only,omitempty"`
+	DangerLevel           string   `json:"danger_level,omitempty" yaml:"danger_level,omitempty"`
+	ProxyJump             string   `json:"proxy_jump,omitempty" yaml:"proxy_jump,omitempty"`
+	Proxy                 string   `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+	ProxyCommand          string   `json:"proxy_command,omitempty" yaml:"proxy_command,omitempty"`
+	IdentityAgent         string   `json:"identity_agent,omitempty" yaml:"identity_agent,omitempty"`
+	ServerAliveInterval   int      `json:"server_alive_interval,omitempty" yaml:"server_alive_interval,omitempty"`
+	Compression           bool     `json:"compression,omitempty" yaml:"compression,omitempty"`
+	StrictHostKeyChecking string   `json:"strict_host_key_checking,omitempty" yaml:"strict_host_key_checking,omitempty"`
+	ForwardAgent          bool     `json:"forward_agent,omitempty" yaml:"forward_agent,omitempty"`
+	ForwardX11            bool     `json:"forward_x11,omitempty" yaml:"forward_x11,omitempty"`
+	LocalForward          []string `json:"local_forward,omitempty" yaml:"local_forward,omitempty"`
+	RemoteForward         []string `json:"remote_forward,omitempty" yaml:"remote_forward,omitempty"`
+	SetEnv                []string `json:"set_env,omitempty" yaml:"set_env,omitempty"`
+	StartupCommand        string   `json:"startup_command,omitempty" yaml:"startup_command,omitempty"`
+	Password              string   `json:"password,omitempty" yaml:"password,omitempty"`
+	Passphrase            string   `json:"passphrase,omitempty" yaml:"passphrase,omitempty"`
+	SudoPassword          string   `json:"sudo_password,omitempty" yaml:"sudo_password,omitempty"`
+	ProxyPassword         string   `json:"proxy_password,omitempty" yaml:"proxy_password,omitempty"`
+}
+
+// ExportConnections renders connections in the given format. Passwords are
+// only written when includeSecrets is set and the format supports them.
+func ExportConnections(conns []SSHConnection, format ExportFormat, includeSecrets bool) ([]byte, error) {
+	if format == ExportSSHConfig {
+		return exportSSHConfig(conns), nil
+	}
+
+	exported := make([]exportedConnection, 0, len(conns))
+	for _, c := range conns {
+		e := exportedConnection{
+			ID:                    c.ID,
+			Name:                  c.Name,
+			Host:                  c.Host,
+			Port:                  c.Port,
+			Username:              c.Username,
+			KeyFile:               c.KeyFile,
+			UsePassword:           c.UsePassword,
+			Notes:                 c.Notes,
+			ViewOnly:              c.ViewOnly,
+			DangerLevel:           c.DangerLevel,
+			ProxyJump:             c.ProxyJump,
+			Proxy:                 c.Proxy,
+			ProxyCommand:          c.ProxyCommand,
+			IdentityAgent:         c.IdentityAgent,
+			ServerAliveInterval:   c.ServerAliveInterval,
+			Compression:           c.Compression,
+			StrictHostKeyChecking: c.StrictHostKeyChecking,
+			ForwardAgent:          c.ForwardAgent,
+			ForwardX11:            c.ForwardX11,
+			LocalForward:          c.LocalForward,
+			RemoteForward:         c.RemoteForward,
+			SetEnv:                c.SetEnv,
+			StartupCommand:        c.StartupCommand,
+		}
+		if includeSecrets {
+			// Key-based connections keep the key passphrase in Password
+			if c.UsePassword {
+				e.Password = c.Password
+			} else {
+				e.Passphrase = c.Password
+			}
+			e.SudoPassword = c.SudoPassword
+			e.ProxyPassword = c.ProxyPassword
+		}
+		exported = append(exported, e)
+	}
+
+	file := struct {
+		Connections []exportedConnection `json:"connections" yaml:"connections"`
+	}{exported}
+	if format == ExportYAML {
+		return yaml.Marshal(file)
+	}
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// ConnectionsForExport returns the connections with the given IDs, or all
+// connections if ids is empty. Secrets are looked up only when requested,
+// since ListConnections does not carry them for every backend.
+func ConnectionsForExport(storage Storage, ids []string, includeSecrets bool) []SSHConnection {
+	var conns []SSHConnection
+	for _, c := range storage.ListConnections() {
+		if len(ids) > 0 && !slices.Contains(ids, c.ID) {
+			continue
+		}
+		if includeSecrets {
+			if full, ok := storage.GetConnection(c.ID); ok {
+				c = full
+			}
+		}
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// ExportToFile writes an export to path. The file is only readable by the
+// owner, as it may contain passwords.
+func ExportToFile(path string, conns []SSHConnection, format ExportFormat, includeSecrets bool) error {
+	data, err := ExportConnections(conns, format, includeSecrets)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(ExpandPath(path), data, 0600)
+}
+
+// exportSSHConfig writes a standalone ssh_config fragment that can be
+// included from ~/.ssh/config
+func exportSSHConfig(conns []SSHConnection) []byte {
+	var b strings.Builder
+	b.WriteString("# Exported by ssh-x-term\n")
+	for _, c := range conns {
+		b.WriteString("\n")
+		if c.Notes != "" {
+			for _, line := range strings.Split(c.Notes, "\n") {
+				fmt.Fprintf(&b, "# %s\n", line)
+			}
+		}
+		fmt.Fprintf(&b, "Host %s\n", sshConfigAlias(c))
+		fmt.Fprintf(&b, "    HostName %s\n", c.Host)
+		if c.Port != 0 && c.Port != 22 {
+			fmt.Fprintf(&b, "    Port %d\n", c.Port)
+		}
+		if c.Username != "" {
+			fmt.Fprintf(&b, "    User %s\n", c.Username)
+		}
+		if c.KeyFile != "" && !c.UsePassword {
+			fmt.Fprintf(&b, "    IdentityFile %s\n", quoteSSHConfigValue(c.KeyFile))
+		}
+		if c.UsePassword {
+			b.WriteString("    PreferredAuthentications password,keyboard-interactive\n")
+		}
+		if c.ProxyCommand != "" {
+			fmt.Fprintf(&b, "    ProxyCommand %s\n", c.ProxyCommand)
+		}
+		if c.ForwardAgent {
+			b.WriteString("    ForwardAgent yes\n")
+		}
+		if c.ForwardX11 {
+			b.WriteString("    ForwardX11 yes\n")
+		}
+		if len(c.SetEnv) > 0 {
+			fmt.Fprintf(&b, "    SetEnv %s\n", FormatSetEnv(c.SetEnv))
+		}
+		writeSSHOptions(&b, &c)
+		if command := c.RemoteCommand(); command != "" {
+			// ssh expands %-tokens in RemoteCommand
+			fmt.Fprintf(&b, "    RemoteCommand %s\n    RequestTTY yes\n", strings.ReplaceAll(command, "%", "%%"))
+		}
+	}
+	return []byte(b.String())
+}
+
+// sshConfigAlias turns a connection name into a Host alias without
+// whitespace or pattern characters
+func sshConfigAlias(c SSHConnection) string {
+	if c.HostPattern != "" && !strings.ContainsAny(c.HostPattern, "*?! ") {
+		return c.HostPattern
+	}
+	alias := strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t':
+			return '-'
+		case '*', '?', '!', '"', '#':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(c.Name))
+	if alias == "" {
+		return c.Host
+	}
+	return alias
+}
+
+func quoteSSHConfigValue(s string) string {
+	if strings.ContainsAny(s, " \t") {
+		return `"` + s + `"`
+	}
+	return s
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestExportConnections(t *testing.T) {
+	conns := []SSHConnection{
+		{ID: "sxt-1", Name: "web server", Host: "web.example.com", Port: 22, Username: "deploy", KeyFile: "~/.ssh/id_ed25519", Password: "key-pass"},
+		{ID: "sxt-2", Name: "db", Host: "10.0.0.5", Port: 2222, Username: "admin", UsePassword: true, Password: "hunter2", Notes: "primary", ForwardAgent: true, ForwardX11: true, SetEnv: []string{"TZ=UTC"}, StartupCommand: "date +%F"},
+	}
+
+	t.Run("JSON strips secrets by default", func(t *testing.T) {
+		data, err := ExportConnections(conns, ExportJSON, false)
+		if err != nil {
+			t.Fatalf("Failed to export JSON: %v", err)
+		}
+		if strings.Contains(string(data), "hunter2") || strings.Contains(string(data), "key-pass") {
+			t.Errorf("Expected no secrets in export, got:\n%s", data)
+		}
+		if !strings.Contains(string(data), `"name": "web server"`) {
+			t.Errorf("Expected connection name in export, got:\n%s", data)
+		}
+	})
+
+	t.Run("JSON includes secrets on request", func(t *testing.T) {
+		data, err := ExportConnections(conns, ExportJSON, true)
+		if err != nil {
+			t.Fatalf("Failed to export JSON: %v", err)
+		}
+		if !strings.Contains(string(data), `"password": "hunter2"`) || !strings.Contains(string(data), `"passphrase": "key-pass"`) {
+			t.Errorf("Expected password and passphrase in export, got:\n%s", data)
+		}
+	})
+
+	t.Run("YAML loads as a declarative file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "connections.yaml")
+		if err := ExportToFile(path, conns, ExportYAML, false); err != nil {
+			t.Fatalf("Failed to export YAML: %v", err)
+		}
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+			t.Errorf("Expected export file with mode 0600, got %v (%v)", info.Mode().Perm(), err)
+		}
+
+		file, err := LoadDeclarativeFile(path)
+		if err != nil {
+			t.Fatalf("Failed to load exported YAML: %v", err)
+		}
+		if len(file.Connections) != 2 || file.Connections[1].Port != 2222 || !file.Connections[1].UsePassword {
+			t.Errorf("Unexpected declared connections: %+v", file.Connections)
+		}
+	})
+
+	t.Run("ssh_config fragment", func(t *testing.T) {
+		data, err := ExportConnections(conns, ExportSSHConfig, true)
+		if err != nil {
+			t.Fatalf("Failed to export ssh_config: %v", err)
+		}
+		out := string(data)
+		for _, want := range []string{
+			"Host web-server\n    HostName web.example.com\n    User deploy\n    IdentityFile ~/.ssh/id_ed25519\n",
+			"# primary\nHost db\n    HostName 10.0.0.5\n    Port 2222\n",
+			"    ForwardAgent yes\n    ForwardX11 yes\n    SetEnv TZ=UTC\n    RemoteCommand date +%%F; exec $SHELL -l\n    RequestTTY yes\n",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("Expected %q in:\n%s", want, out)
+			}
+		}
+		if strings.Contains(out, "hunter2") {
+			t.Error("Expected ssh_config export never to contain passwords")
+		}
+	})
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	conn := SSHConnection{
+		ID: "sxt-3", Name: "app", Host: "app.internal", Port: 2200, Username: "ops",
+		KeyFile: "~/.ssh/id_app", Password: "key-pass", SudoPassword: "sudo-pass", Notes: "behind the bastion",
+		ViewOnly: true, DangerLevel: DangerProduction,
+		ProxyJump: "admin@bastion:2222", Proxy: "socks5://proxy:1080", ProxyPassword: "proxy-pass", ProxyCommand: "nc -X 5 %h %p",
+		IdentityAgent: "~/.1password/agent.sock", ServerAliveInterval: 15, Compression: true, StrictHostKeyChecking: "accept-new",
+		ForwardAgent: true, ForwardX11: true,
+		LocalForward: []string{"8080 localhost:80"}, RemoteForward: []string{"9000 localhost:9000"},
+		SetEnv: []string{"TZ=UTC", "LANG=C"}, StartupCommand: "uptime",
+	}
+	want := exportedConnection{
+		ID: "sxt-3", Name: "app", Host: "app.internal", Port: 2200, Username: "ops",
+		KeyFile: "~/.ssh/id_app", Notes: "behind the bastion",
+		ViewOnly: true, DangerLevel: DangerProduction,
+		ProxyJump: "admin@bastion:2222", Proxy: "socks5://proxy:1080", ProxyCommand: "nc -X 5 %h %p",
+		IdentityAgent: "~/.1password/agent.sock", ServerAliveInterval: 15, Compression: true, StrictHostKeyChecking: "accept-new",
+		ForwardAgent: true, ForwardX11: true,
+		LocalForward: []string{"8080 localhost:80"}, RemoteForward: []string{"9000 localhost:9000"},
+		SetEnv: []string{"TZ=UTC", "LANG=C"}, StartupCommand: "uptime",
+		Passphrase: "key-pass", SudoPassword: "sudo-pass", ProxyPassword: "proxy-pass",
+	}
+
+	for _, format := range []ExportFormat{ExportJSON, ExportYAML} {
+		data, err := ExportConnections([]SSHConnection{conn}, format, true)
+		if err != nil {
+			t.Fatalf("%s: failed to export: %v", format, err)
+		}
+		var file struct {
+			Connections []exportedConnection `json:"connections" yaml:"connections"`
+		}
+		if format == ExportYAML {
+			err = yaml.Unmarshal(data, &file)
+		} else {
+			err = json.Unmarshal(data, &file)
+		}
+		if err != nil {
+			t.Fatalf("%s: failed to read the export back: %v", format, err)
+		}
+		if len(file.Connections) != 1 || !reflect.DeepEqual(file.Connections[0], want) {
+			t.Errorf("%s: read back %+v\nwant %+v", format, file.Connections, want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const defaultFileDirsFileName = "file_dirs.json"
+
+// FileDirs are the directories the file manager was left in for a
+// connection
+type FileDirs struct {
+	Local  string `json:"local,omitempty"`
+	Remote string `json:"remote,omitempty"`
+}
+
+// FileDirsStore keeps the directories the file manager was last left in
+// in ~/.config/ssh-x-term/file_dirs.json, keyed by connection ID, so it can
+// reopen there when the remember_dirs setting is on
+type FileDirsStore struct {
+	Path        string              `json:"-"`
+	Connections map[string]FileDirs `json:"connections"`
+}
+
+// NewFileDirsStore creates a file directories store in the default location
+func NewFileDirsStore() (*FileDirsStore, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return &FileDirsStore{
+		Path: filepath.Join(homeDir, ".config", "ssh-x-term", defaultFileDirsFileName),
+	}, nil
+}
+
+// Load reads the file directories file. A missing file yields an empty
+// store.
+func (s *FileDirsStore) Load() error {
+	s.Connections = nil
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", s.Path, err)
+	}
+	return nil
+}
+
+// Save writes the file directories file, creating its directory if needed
+func (s *FileDirsStore) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.Path, data, 0600); err != nil {
+		log.Printf("Failed to write file directories file: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Lookup returns the directories remembered for a connection
+func (s *FileDirsStore) Lookup(connID string) (FileDirs, bool) {
+	dirs, ok := s.Connections[connID]
+	return dirs, ok
+}
+
+// Record replaces the directories of a connection and saves the store
+func (s *FileDirsStore) Record(connID string, dirs FileDirs) error {
+	if s.Connections == nil {
+		s.Connections = make(map[string]FileDirs)
+	}
+	s.Connections[connID] = dirs
+	return s.Save()
+}
+
+// LastFileDirs returns the directories remembered for a connection in the
+// default store
+func LastFileDirs(connID string) (FileDirs, bool) {
+	store, err := NewFileDirsStore()
+	if err != nil {
+		return FileDirs{}, false
+	}
+	if err := store.Load(); err != nil {
+		log.Printf("Failed to load file directories: %v", err)
+		return FileDirs{}, false
+	}
+	return store.Lookup(connID)
+}
+
+// RecordFileDirs loads the default store, records the directories of a
+// connection and saves it
+func RecordFileDirs(connID string, dirs FileDirs) error {
+	store, err := NewFileDirsStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Load(); err != nil {
+		return err
+	}
+	return store.Record(connID, dirs)
+}
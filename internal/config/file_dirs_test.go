@@ -0,0 +1,39 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFileDirsStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file_dirs.json")
+	store := &FileDirsStore{Path: path}
+	if err := store.Load(); err != nil {
+		t.Fatalf("Expected missing file to load as empty, got %v", err)
+	}
+	if _, ok := store.Lookup("web"); ok {
+		t.Error("Expected no directories in an empty store")
+	}
+
+	if err := store.Record("web", FileDirs{Local: "/home/me", Remote: "/tmp"}); err != nil {
+		t.Fatalf("Failed to record directories: %v", err)
+	}
+	want := FileDirs{Local: "/home/me/projects/app", Remote: "/var/www/app"}
+	if err := store.Record("web", want); err != nil {
+		t.Fatalf("Failed to record directories: %v", err)
+	}
+	if err := store.Record("db", FileDirs{Remote: "/var/backups"}); err != nil {
+		t.Fatalf("Failed to record directories: %v", err)
+	}
+
+	reloaded := &FileDirsStore{Path: path}
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Failed to reload directories: %v", err)
+	}
+	if got, ok := reloaded.Lookup("web"); !ok || got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if got, _ := reloaded.Lookup("db"); got.Remote != "/var/backups" || got.Local != "" {
+		t.Errorf("Expected the db directories to be kept apart, got %+v", got)
+	}
+}
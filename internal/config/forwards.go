@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ParseForward reads a LocalForward or RemoteForward value,
+// "[bind_address:]port host:hostport", returning the address to listen on
+// and the one to connect to. Without a bind address the tunnel listens on
+// localhost, and with "*" on every interface.
+func ParseForward(spec string) (listen, target string, err error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 2 {
+		return "", "", fmt.Errorf("forward %q needs a port and a host:port", spec)
+	}
+	bind, port := "localhost", fields[0]
+	if host, p, err := net.SplitHostPort(fields[0]); err == nil {
+		bind, port = host, p
+		if bind == "*" {
+			bind = ""
+		}
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return "", "", fmt.Errorf("forward %q has an invalid port %q", spec, port)
+	}
+	host, hostPort, err := net.SplitHostPort(fields[1])
+	if err != nil {
+		return "", "", fmt.Errorf("forward %q: %w", spec, err)
+	}
+	if n, err := strconv.Atoi(hostPort); err != nil || n <= 0 || n > 65535 {
+		return "", "", fmt.Errorf("forward %q has an invalid port %q", spec, hostPort)
+	}
+	return net.JoinHostPort(bind, port), net.JoinHostPort(host, hostPort), nil
+}
+
+// ForwardWithPort returns spec listening on port instead, keeping its bind
+// address and target as written
+func ForwardWithPort(spec string, port int) string {
+	fields := strings.Fields(spec)
+	if len(fields) != 2 {
+		return spec
+	}
+	listen := strconv.Itoa(port)
+	if host, _, err := net.SplitHostPort(fields[0]); err == nil {
+		listen = net.JoinHostPort(host, listen)
+	}
+	return listen + " " + fields[1]
+}
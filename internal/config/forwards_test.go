@@ -0,0 +1,37 @@
+package config
+
+import "testing"
+
+func TestParseForward(t *testing.T) {
+	for _, tt := range []struct {
+		spec, listen, target string
+	}{
+		{"8080 localhost:80", "localhost:8080", "localhost:80"},
+		{"127.0.0.1:5432 db.internal:5432", "127.0.0.1:5432", "db.internal:5432"},
+		{"*:3000  [::1]:3000", ":3000", "[::1]:3000"},
+		{"[::1]:9000 web:9000", "[::1]:9000", "web:9000"},
+	} {
+		listen, target, err := ParseForward(tt.spec)
+		if err != nil || listen != tt.listen || target != tt.target {
+			t.Errorf("ParseForward(%q) = %q, %q, %v, want %q, %q", tt.spec, listen, target, err, tt.listen, tt.target)
+		}
+	}
+	for _, bad := range []string{"8080", "8080 web", "http web:80", "8080 web:0", "1 2 3"} {
+		if _, _, err := ParseForward(bad); err == nil {
+			t.Errorf("Expected an error parsing %q", bad)
+		}
+	}
+}
+
+func TestForwardWithPort(t *testing.T) {
+	for spec, want := range map[string]string{
+		"8080 localhost:80":                "8081 localhost:80",
+		"127.0.0.1:5432  db.internal:5432": "127.0.0.1:8081 db.internal:5432",
+		"*:3000 [::1]:3000":                "*:8081 [::1]:3000",
+		"[::1]:9000 web:9000":              "[::1]:8081 web:9000",
+	} {
+		if got := ForwardWithPort(spec, 8081); got != want {
+			t.Errorf("ForwardWithPort(%q) = %q, want %q", spec, got, want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxHostRangeInstances caps how many hosts a single template expands to
+const maxHostRangeInstances = 1000
+
+// hostRangePattern matches a numeric range like [01-20]
+var hostRangePattern = regexp.MustCompile(`\[(\d+)-(\d+)\]`)
+
+// hostInstance is one host of a range, with the number picked from each
+// range in the pattern
+type hostInstance struct {
+	host   string
+	values []string
+}
+
+// HasHostRange reports whether host contains a range like [01-20]
+func HasHostRange(host string) bool {
+	return hostRangePattern.MatchString(host)
+}
+
+// ExpandHostRange returns every host a pattern like web[01-20].example.com
+// stands for, in order. Numbers keep the zero padding of the range start,
+// and several ranges expand to all their combinations. A host without a
+// range is returned as is.
+func ExpandHostRange(host string) ([]string, error) {
+	instances, err := expandHostRange(host)
+	if err != nil {
+		return nil, err
+	}
+	hosts := make([]string, len(instances))
+	for i, instance := range instances {
+		hosts[i] = instance.host
+	}
+	return hosts, nil
+}
+
+func expandHostRange(host string) ([]hostInstance, error) {
+	loc := hostRangePattern.FindStringSubmatchIndex(host)
+	if loc == nil {
+		return []hostInstance{{host: host}}, nil
+	}
+	from, to := host[loc[2]:loc[3]], host[loc[4]:loc[5]]
+	start, err := strconv.Atoi(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid host range [%s-%s]", from, to)
+	}
+	end, err := strconv.Atoi(to)
+	if err != nil || end < start {
+		return nil, fmt.Errorf("invalid host range [%s-%s]", from, to)
+	}
+	if end-start+1 > maxHostRangeInstances {
+		return nil, fmt.Errorf("host range [%s-%s] has more than %d hosts", from, to, maxHostRangeInstances)
+	}
+	rest, err := expandHostRange(host[loc[1]:])
+	if err != nil {
+		return nil, err
+	}
+	if (end-start+1)*len(rest) > maxHostRangeInstances {
+		return nil, fmt.Errorf("host ranges of %s expand to more than %d hosts", host, maxHostRangeInstances)
+	}
+
+	width := 0
+	if len(from) > 1 && from[0] == '0' {
+		width = len(from)
+	}
+	prefix := host[:loc[0]]
+	instances := make([]hostInstance, 0, (end-start+1)*len(rest))
+	for n := start; n <= end; n++ {
+		value := fmt.Sprintf("%0*d", width, n)
+		for _, r := range rest {
+			instances = append(instances, hostInstance{
+				host:   prefix + value + r.host,
+				values: append([]string{value}, r.values...),
+			})
+		}
+	}
+	return instances, nil
+}
+
+// instanceName names one instance of a template: ranges in the name are
+// replaced like the ones in the host, otherwise the numbers are appended
+func instanceName(name string, values []string) string {
+	if len(hostRangePattern.FindAllString(name, -1)) == len(values) {
+		i := 0
+		return hostRangePattern.ReplaceAllStringFunc(name, func(string) string {
+			i++
+			return values[i-1]
+		})
+	}
+	return name + " " + strings.Join(values, "-")
+}
+
+// ExpandHostRanges replaces every connection whose host has a range with
+// one instance per host. Instances keep the template's ID, so they share
+// its credentials, and remember the template's host in HostRange. Hosts
+// with an invalid range are kept unexpanded.
+func ExpandHostRanges(connections []SSHConnection) []SSHConnection {
+	expanded := make([]SSHConnection, 0, len(connections))
+	for _, conn := range connections {
+		if !HasHostRange(conn.Host) {
+			expanded = append(expanded, conn)
+			continue
+		}
+		instances, err := expandHostRange(conn.Host)
+		if err != nil {
+			log.Printf("Not expanding connection %s: %v", conn.Name, err)
+			expanded = append(expanded, conn)
+			continue
+		}
+		for _, instance := range instances {
+			c := conn
+			c.Name = instanceName(conn.Name, instance.values)
+			c.Host = instance.host
+			c.HostRange = conn.Host
+			expanded = append(expanded, c)
+		}
+	}
+	return expanded
+}
+
+// InstanceRef identifies a connection for `sxt connect`: its ID, followed by
+// "#<host>" for an instance of a host range
+func InstanceRef(conn SSHConnection) string {
+	if conn.HostRange == "" {
+		return conn.ID
+	}
+	return conn.ID + "#" + conn.Host
+}
+
+// ResolveInstance returns the instance of the template conn for host, or
+// false when host isn't one of its hosts
+func ResolveInstance(conn SSHConnection, host string) (SSHConnection, bool) {
+	for _, instance := range ExpandHostRanges([]SSHConnection{conn}) {
+		if instance.HostRange != "" && strings.EqualFold(instance.Host, host) {
+			return instance, true
+		}
+	}
+	return SSHConnection{}, false
+}
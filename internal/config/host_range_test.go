@@ -0,0 +1,75 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandHostRange(t *testing.T) {
+	tests := []struct {
+		host    string
+		want    []string
+		wantErr bool
+	}{
+		{host: "web.example.com", want: []string{"web.example.com"}},
+		{host: "web[01-03].prod", want: []string{"web01.prod", "web02.prod", "web03.prod"}},
+		{host: "web[8-10]", want: []string{"web8", "web9", "web10"}},
+		{host: "web[098-100]", want: []string{"web098", "web099", "web100"}},
+		{host: "r[1-2]n[1-2]", want: []string{"r1n1", "r1n2", "r2n1", "r2n2"}},
+		{host: "web[05-01]", wantErr: true},
+		{host: "web[1-5000]", wantErr: true},
+		{host: "r[1-100]n[1-100]", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ExpandHostRange(tt.host)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %v", tt.host, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.host, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.host, tt.want, got)
+		}
+	}
+}
+
+func TestExpandHostRanges(t *testing.T) {
+	conns := []SSHConnection{
+		{ID: "db", Name: "DB", Host: "db.example.com"},
+		{ID: "web", Name: "Web", Host: "web[1-2].example.com"},
+		{ID: "node", Name: "node[01-02]", Host: "node[01-02]"},
+		{ID: "bad", Name: "Bad", Host: "bad[9-1]"},
+	}
+	got := ExpandHostRanges(conns)
+
+	want := []SSHConnection{
+		{ID: "db", Name: "DB", Host: "db.example.com"},
+		{ID: "web", Name: "Web 1", Host: "web1.example.com", HostRange: "web[1-2].example.com"},
+		{ID: "web", Name: "Web 2", Host: "web2.example.com", HostRange: "web[1-2].example.com"},
+		{ID: "node", Name: "node01", Host: "node01", HostRange: "node[01-02]"},
+		{ID: "node", Name: "node02", Host: "node02", HostRange: "node[01-02]"},
+		{ID: "bad", Name: "Bad", Host: "bad[9-1]"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	if ref := InstanceRef(got[2]); ref != "web#web2.example.com" {
+		t.Errorf("Expected instance ref web#web2.example.com, got %s", ref)
+	}
+	if ref := InstanceRef(got[0]); ref != "db" {
+		t.Errorf("Expected ref db, got %s", ref)
+	}
+	instance, ok := ResolveInstance(conns[1], "WEB2.example.com")
+	if !ok || !reflect.DeepEqual(instance, got[2]) {
+		t.Errorf("Expected %+v, got %+v (%v)", got[2], instance, ok)
+	}
+	if _, ok := ResolveInstance(conns[1], "web3.example.com"); ok {
+		t.Error("Expected web3 not to be an instance of web[1-2]")
+	}
+}
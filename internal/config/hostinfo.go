@@ -0,0 +1,126 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const defaultHostInfoFileName = "hosts.json"
+
+// HostInfo is what the last connection to a host learned about it: the
+// host key it presented and the algorithms negotiated for the session
+type HostInfo struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+	// KeyType is the host key algorithm, e.g. ssh-ed25519
+	KeyType string `json:"key_type"`
+	// Key is the host key in authorized_keys wire format, base64 encoded
+	Key                  string `json:"key"`
+	FingerprintSHA256    string `json:"fingerprint_sha256"`
+	FingerprintMD5       string `json:"fingerprint_md5"`
+	ServerVersion        string `json:"server_version,omitempty"`
+	KeyExchange          string `json:"key_exchange,omitempty"`
+	CipherClientToServer string `json:"cipher_client_to_server,omitempty"`
+	CipherServerToClient string `json:"cipher_server_to_client,omitempty"`
+	MACClientToServer    string `json:"mac_client_to_server,omitempty"`
+	MACServerToClient    string `json:"mac_server_to_client,omitempty"`
+	SeenAt               int64  `json:"seen_at"`
+}
+
+// Address is the host:port the info is stored under
+func (h HostInfo) Address() string {
+	return hostInfoKey(h.Host, h.Port)
+}
+
+// Seen returns when the host info was recorded
+func (h HostInfo) Seen() time.Time {
+	return time.Unix(h.SeenAt, 0)
+}
+
+func hostInfoKey(host string, port int) string {
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// HostInfoStore keeps the host info of past connections in
+// ~/.config/ssh-x-term/hosts.json, keyed by host:port
+type HostInfoStore struct {
+	Path  string              `json:"-"`
+	Hosts map[string]HostInfo `json:"hosts"`
+}
+
+// NewHostInfoStore creates a host info store in the default location
+func NewHostInfoStore() (*HostInfoStore, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return &HostInfoStore{
+		Path: filepath.Join(homeDir, ".config", "ssh-x-term", defaultHostInfoFileName),
+	}, nil
+}
+
+// Load reads the host info file. A missing file yields an empty store.
+func (s *HostInfoStore) Load() error {
+	s.Hosts = nil
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", s.Path, err)
+	}
+	return nil
+}
+
+// Save writes the host info file, creating its directory if needed
+func (s *HostInfoStore) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.Path, data, 0600); err != nil {
+		log.Printf("Failed to write host info file: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Lookup returns the recorded info of host:port
+func (s *HostInfoStore) Lookup(host string, port int) (HostInfo, bool) {
+	info, ok := s.Hosts[hostInfoKey(host, port)]
+	return info, ok
+}
+
+// Record replaces the info of the host and saves the store
+func (s *HostInfoStore) Record(info HostInfo) error {
+	if s.Hosts == nil {
+		s.Hosts = make(map[string]HostInfo)
+	}
+	s.Hosts[info.Address()] = info
+	return s.Save()
+}
+
+// RecordHostInfo loads the default store, records info and saves it
+func RecordHostInfo(info HostInfo) error {
+	store, err := NewHostInfoStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Load(); err != nil {
+		return err
+	}
+	return store.Record(info)
+}
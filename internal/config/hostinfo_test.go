@@ -0,0 +1,54 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestHostInfoStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hosts.json")
+	store := &HostInfoStore{Path: path}
+	if err := store.Load(); err != nil {
+		t.Fatalf("Expected missing file to load as empty, got %v", err)
+	}
+	if _, ok := store.Lookup("example.com", 22); ok {
+		t.Error("Expected no host info in an empty store")
+	}
+
+	info := HostInfo{
+		Host:              "example.com",
+		Port:              22,
+		KeyType:           "ssh-ed25519",
+		FingerprintSHA256: "SHA256:old",
+		KeyExchange:       "curve25519-sha256",
+		SeenAt:            100,
+	}
+	if err := store.Record(info); err != nil {
+		t.Fatalf("Failed to record host info: %v", err)
+	}
+	info.FingerprintSHA256 = "SHA256:new"
+	if err := store.Record(info); err != nil {
+		t.Fatalf("Failed to record host info: %v", err)
+	}
+	if err := store.Record(HostInfo{Host: "example.com", Port: 2222, FingerprintSHA256: "SHA256:other"}); err != nil {
+		t.Fatalf("Failed to record host info: %v", err)
+	}
+
+	reloaded := &HostInfoStore{Path: path}
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Failed to reload host info: %v", err)
+	}
+	if len(reloaded.Hosts) != 2 {
+		t.Errorf("Expected 2 hosts, got %d", len(reloaded.Hosts))
+	}
+	got, ok := reloaded.Lookup("example.com", 22)
+	if !ok || got != info {
+		t.Errorf("Expected %+v, got %+v", info, got)
+	}
+	if got, _ := reloaded.Lookup("example.com", 2222); got.FingerprintSHA256 != "SHA256:other" {
+		t.Errorf("Expected the port 2222 key to be kept apart, got %+v", got)
+	}
+	if got := (HostInfo{Host: "::1", Port: 22}).Address(); got != "[::1]:22" {
+		t.Errorf("Expected [::1]:22, got %q", got)
+	}
+}
@@ -0,0 +1,401 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// ImportSource identifies another SSH client's saved session format
+type ImportSource int
+
+const (
+	ImportPuTTY ImportSource = iota
+	ImportWinSCP
+	ImportTermius
+)
+
+// ImportSources lists the supported sources in display order
+var ImportSources = []ImportSource{ImportPuTTY, ImportWinSCP, ImportTermius}
+
+func (s ImportSource) String() string {
+	switch s {
+	case ImportPuTTY:
+		return "PuTTY"
+	case ImportWinSCP:
+		return "WinSCP"
+	case ImportTermius:
+		return "Termius"
+	}
+	return "unknown"
+}
+
+// Description says which file the importer expects
+func (s ImportSource) Description() string {
+	switch s {
+	case ImportPuTTY:
+		return "Registry export (.reg) or ~/.putty/sessions directory"
+	case ImportWinSCP:
+		return "WinSCP.ini (Tools > Export/Backup Configuration)"
+	case ImportTermius:
+		return "Termius export JSON"
+	}
+	return ""
+}
+
+// DefaultPath returns where the source usually keeps its sessions on this
+// machine, or "" if there is no usual location
+func (s ImportSource) DefaultPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	var candidates []string
+	switch s {
+	case ImportPuTTY:
+		candidates = []string{filepath.Join(homeDir, ".putty", "sessions")}
+	case ImportWinSCP:
+		candidates = []string{
+			filepath.Join(os.Getenv("APPDATA"), "WinSCP.ini"),
+			filepath.Join(homeDir, "WinSCP.ini"),
+		}
+	}
+	for _, path := range candidates {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// ImportResult holds the connections read from another client. Skipped
+// lists sessions that could not be mapped (e.g. telnet or FTP sessions),
+// Warnings those that were imported but need attention.
+type ImportResult struct {
+	Connections []SSHConnection
+	Skipped     []string
+	Warnings    []string
+}
+
+// add records an imported connection, warning about PuTTY-format keys,
+// which have to be converted before they can be used
+func (r *ImportResult) add(conn SSHConnection) {
+	if strings.EqualFold(filepath.Ext(conn.KeyFile), ".ppk") {
+		r.Warnings = append(r.Warnings, fmt.Sprintf(
+			"%s: convert %s with `puttygen KEY.ppk -O private-openssh -o KEY`", conn.Name, conn.KeyFile))
+	}
+	r.Connections = append(r.Connections, conn)
+}
+
+// ImportConnections reads the sessions stored at path by source
+func ImportConnections(source ImportSource, path string) (*ImportResult, error) {
+	path = ExpandPath(path)
+	switch source {
+	case ImportPuTTY:
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			return importPuTTYDir(path)
+		}
+		data, err := readTextFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return parsePuTTYReg(data), nil
+	case ImportWinSCP:
+		data, err := readTextFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return parseWinSCPIni(data), nil
+	case ImportTermius:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return parseTermiusJSON(data)
+	}
+	return nil, fmt.Errorf("unsupported import source %d", source)
+}
+
+// readTextFile reads a text file, decoding UTF-16 as written by regedit
+func readTextFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if len(data) >= 2 && data[0] == 0xFF && data[1] == 0xFE {
+		u16 := make([]uint16, 0, len(data)/2)
+		for i := 2; i+1 < len(data); i += 2 {
+			u16 = append(u16, uint16(data[i])|uint16(data[i+1])<<8)
+		}
+		return string(utf16.Decode(u16)), nil
+	}
+	return string(bytes.TrimPrefix(data, []byte("\xEF\xBB\xBF"))), nil
+}
+
+// iniSections parses INI-style text into sections of key/value pairs,
+// keeping section order. parseValue converts each raw value.
+func iniSections(text string, parseValue func(string) string) ([]string, map[string]map[string]string) {
+	var order []string
+	sections := make(map[string]map[string]string)
+	var current map[string]string
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			name := line[1 : len(line)-1]
+			if _, ok := sections[name]; !ok {
+				order = append(order, name)
+				sections[name] = make(map[string]string)
+			}
+			current = sections[name]
+		case current != nil:
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				continue
+			}
+			current[strings.Trim(key, `"`)] = parseValue(value)
+		}
+	}
+	return order, sections
+}
+
+// parseRegValue decodes "string" and dword:0000abcd registry values
+func parseRegValue(value string) string {
+	if strings.HasPrefix(value, "dword:") {
+		n, err := strconv.ParseUint(strings.TrimPrefix(value, "dword:"), 16, 32)
+		if err != nil {
+			return ""
+		}
+		return strconv.FormatUint(n, 10)
+	}
+	value = strings.TrimPrefix(strings.TrimSuffix(value, `"`), `"`)
+	return strings.NewReplacer(`\\`, `\`, `\"`, `"`).Replace(value)
+}
+
+const puttySessionsKey = `\Software\SimonTatham\PuTTY\Sessions\`
+
+func parsePuTTYReg(text string) *ImportResult {
+	result := &ImportResult{}
+	order, sections := iniSections(text, parseRegValue)
+	for _, section := range order {
+		i := strings.Index(section, puttySessionsKey)
+		if i < 0 {
+			continue
+		}
+		name := section[i+len(puttySessionsKey):]
+		addPuTTYSession(result, unescapePuTTYName(name), sections[section])
+	}
+	return result
+}
+
+func importPuTTYDir(dir string) (*ImportResult, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	result := &ImportResult{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		// Unix PuTTY session files are plain key=value lines
+		_, sections := iniSections("[session]\n"+string(data), func(v string) string { return v })
+		addPuTTYSession(result, unescapePuTTYName(entry.Name()), sections["session"])
+	}
+	return result, nil
+}
+
+// unescapePuTTYName decodes PuTTY's %XX escaping of session names
+func unescapePuTTYName(name string) string {
+	if decoded, err := url.PathUnescape(name); err == nil {
+		return decoded
+	}
+	return name
+}
+
+func addPuTTYSession(result *ImportResult, name string, values map[string]string) {
+	host := values["HostName"]
+	if name == "Default Settings" && host == "" {
+		return
+	}
+	if protocol := values["Protocol"]; protocol != "" && protocol != "ssh" {
+		result.Skipped = append(result.Skipped, fmt.Sprintf("%s (%s)", name, protocol))
+		return
+	}
+	if host == "" {
+		result.Skipped = append(result.Skipped, name+" (no host)")
+		return
+	}
+	username := values["UserName"]
+	// PuTTY allows user@host in the host field
+	if user, h, ok := strings.Cut(host, "@"); ok {
+		username, host = user, h
+	}
+	result.add(SSHConnection{
+		Name:        name,
+		Host:        host,
+		Port:        atoiDefault(values["PortNumber"], 22),
+		Username:    username,
+		KeyFile:     values["PublicKeyFile"],
+		UsePassword: values["PublicKeyFile"] == "",
+	})
+}
+
+func parseWinSCPIni(text string) *ImportResult {
+	result := &ImportResult{}
+	order, sections := iniSections(text, func(v string) string {
+		// WinSCP percent-encodes values, e.g. C%3A%5Ckeys%5Cid.ppk
+		if decoded, err := url.PathUnescape(v); err == nil {
+			return decoded
+		}
+		return v
+	})
+	for _, section := range order {
+		name, ok := strings.CutPrefix(section, `Sessions\`)
+		if !ok {
+			continue
+		}
+		name = unescapePuTTYName(name)
+		values := sections[section]
+		// FSProtocol: 0 SCP, 1 SFTP with SCP fallback, 2 SFTP (default);
+		// 5 FTP, 6 WebDAV, 7 S3 are not SSH
+		if protocol := atoiDefault(values["FSProtocol"], 2); protocol > 2 {
+			result.Skipped = append(result.Skipped, name+" (not SSH)")
+			continue
+		}
+		host := values["HostName"]
+		if host == "" {
+			result.Skipped = append(result.Skipped, name+" (no host)")
+			continue
+		}
+		// WinSCP stores folders as path segments in the session name
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			name = name[i+1:]
+		}
+		result.add(SSHConnection{
+			Name:        name,
+			Host:        host,
+			Port:        atoiDefault(values["PortNumber"], 22),
+			Username:    values["UserName"],
+			KeyFile:     values["PublicKeyFile"],
+			UsePassword: values["PublicKeyFile"] == "",
+		})
+	}
+	return result
+}
+
+// termiusHost covers the fields used by Termius exports. Older exports put
+// the port and identity under ssh_config, newer ones at the top level.
+type termiusHost struct {
+	Label     string `json:"label"`
+	Address   string `json:"address"`
+	Port      int    `json:"port"`
+	Username  string `json:"username"`
+	SSHConfig *struct {
+		Port     int `json:"port"`
+		Identity *struct {
+			Username string `json:"username"`
+		} `json:"identity"`
+	} `json:"ssh_config"`
+	Identity *struct {
+		Username string `json:"username"`
+	} `json:"identity"`
+}
+
+func parseTermiusJSON(data []byte) (*ImportResult, error) {
+	var hosts []termiusHost
+	if err := json.Unmarshal(data, &hosts); err != nil {
+		var wrapped struct {
+			Hosts []termiusHost `json:"hosts"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return nil, fmt.Errorf("not a Termius export: %w", err)
+		}
+		hosts = wrapped.Hosts
+	}
+
+	result := &ImportResult{}
+	for _, h := range hosts {
+		name := cmp.Or(h.Label, h.Address)
+		if h.Address == "" {
+			result.Skipped = append(result.Skipped, name+" (no address)")
+			continue
+		}
+		conn := SSHConnection{Name: name, Host: h.Address, Port: h.Port, Username: h.Username, UsePassword: true}
+		if h.SSHConfig != nil {
+			if conn.Port == 0 {
+				conn.Port = h.SSHConfig.Port
+			}
+			if conn.Username == "" && h.SSHConfig.Identity != nil {
+				conn.Username = h.SSHConfig.Identity.Username
+			}
+		}
+		if conn.Username == "" && h.Identity != nil {
+			conn.Username = h.Identity.Username
+		}
+		if conn.Port == 0 {
+			conn.Port = 22
+		}
+		result.add(conn)
+	}
+	return result, nil
+}
+
+func atoiDefault(s string, def int) int {
+	if n, err := strconv.Atoi(strings.TrimSpace(s)); err == nil && n > 0 {
+		return n
+	}
+	return def
+}
+
+// importKey identifies a target for duplicate detection
+func importKey(c SSHConnection) string {
+	return fmt.Sprintf("%s@%s:%d", c.Username, strings.ToLower(c.Host), c.Port)
+}
+
+// IsDuplicateImport reports whether an imported connection targets the same
+// user, host and port as an existing one
+func IsDuplicateImport(existing []SSHConnection, imported SSHConnection) bool {
+	key := importKey(imported)
+	for _, c := range existing {
+		if importKey(c) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// AddImportedConnections saves connections to the backend, collecting
+// individual failures instead of stopping at the first one
+func AddImportedConnections(storage Storage, conns []SSHConnection) (int, error) {
+	var errs []error
+	added := 0
+	for _, conn := range conns {
+		if err := storage.AddConnection(conn); err != nil {
+			log.Printf("Import: failed to add %s: %v", conn.Name, err)
+			errs = append(errs, fmt.Errorf("add %s: %w", conn.Name, err))
+			continue
+		}
+		added++
+	}
+	return added, errors.Join(errs...)
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportPuTTYReg(t *testing.T) {
+	reg := `Windows Registry Editor Version 5.00
+
+[HKEY_CURRENT_USER\Software\SimonTatham\PuTTY\Sessions\Default%20Settings]
+"PortNumber"=dword:00000016
+
+[HKEY_CURRENT_USER\Software\SimonTatham\PuTTY\Sessions\web%20server]
+"HostName"="deploy@web.example.com"
+"PortNumber"=dword:00000b3a
+"Protocol"="ssh"
+"PublicKeyFile"="C:\\keys\\web.ppk"
+
+[HKEY_CURRENT_USER\Software\SimonTatham\PuTTY\Sessions\router]
+"HostName"="192.168.1.1"
+"Protocol"="telnet"
+`
+	path := filepath.Join(t.TempDir(), "putty.reg")
+	if err := os.WriteFile(path, []byte(reg), 0600); err != nil {
+		t.Fatalf("Failed to write registry export: %v", err)
+	}
+
+	result, err := ImportConnections(ImportPuTTY, path)
+	if err != nil {
+		t.Fatalf("Failed to import PuTTY sessions: %v", err)
+	}
+	if len(result.Connections) != 1 {
+		t.Fatalf("Expected 1 connection, got %+v", result.Connections)
+	}
+	conn := result.Connections[0]
+	if conn.Name != "web server" || conn.Host != "web.example.com" || conn.Username != "deploy" || conn.Port != 2874 {
+		t.Errorf("Unexpected connection: %+v", conn)
+	}
+	if conn.KeyFile != `C:\keys\web.ppk` || conn.UsePassword {
+		t.Errorf("Expected key auth with C:\\keys\\web.ppk, got %+v", conn)
+	}
+	if len(result.Skipped) != 1 || len(result.Warnings) != 1 {
+		t.Errorf("Expected one skipped telnet session and one .ppk warning, got %v / %v", result.Skipped, result.Warnings)
+	}
+}
+
+func TestImportPuTTYSessionsDir(t *testing.T) {
+	dir := t.TempDir()
+	session := "HostName=db.internal\nPortNumber=2222\nUserName=admin\nProtocol=ssh\n"
+	if err := os.WriteFile(filepath.Join(dir, "db%20primary"), []byte(session), 0600); err != nil {
+		t.Fatalf("Failed to write session file: %v", err)
+	}
+
+	result, err := ImportConnections(ImportPuTTY, dir)
+	if err != nil {
+		t.Fatalf("Failed to import PuTTY sessions directory: %v", err)
+	}
+	if len(result.Connections) != 1 {
+		t.Fatalf("Expected 1 connection, got %+v", result.Connections)
+	}
+	conn := result.Connections[0]
+	if conn.Name != "db primary" || conn.Host != "db.internal" || conn.Port != 2222 || !conn.UsePassword {
+		t.Errorf("Unexpected connection: %+v", conn)
+	}
+}
+
+func TestImportWinSCPIni(t *testing.T) {
+	ini := `[Configuration\Interface]
+Theme=dark
+
+[Sessions\prod/api%20node]
+HostName=10.0.0.7
+UserName=ubuntu
+PublicKeyFile=C:%5Ckeys%5Capi.pem
+
+[Sessions\ftp%20mirror]
+HostName=ftp.example.com
+FSProtocol=5
+`
+	path := filepath.Join(t.TempDir(), "WinSCP.ini")
+	if err := os.WriteFile(path, []byte(ini), 0600); err != nil {
+		t.Fatalf("Failed to write WinSCP.ini: %v", err)
+	}
+
+	result, err := ImportConnections(ImportWinSCP, path)
+	if err != nil {
+		t.Fatalf("Failed to import WinSCP sessions: %v", err)
+	}
+	if len(result.Connections) != 1 {
+		t.Fatalf("Expected 1 connection, got %+v", result.Connections)
+	}
+	conn := result.Connections[0]
+	if conn.Name != "api node" || conn.Host != "10.0.0.7" || conn.Port != 22 || conn.KeyFile != `C:\keys\api.pem` {
+		t.Errorf("Unexpected connection: %+v", conn)
+	}
+	if len(result.Skipped) != 1 {
+		t.Errorf("Expected the FTP session to be skipped, got %v", result.Skipped)
+	}
+}
+
+func TestImportTermiusJSON(t *testing.T) {
+	export := `{"hosts": [
+  {"label": "staging", "address": "staging.example.com", "ssh_config": {"port": 2200, "identity": {"username": "ci"}}},
+  {"address": "10.1.1.1", "port": 22, "username": "root"},
+  {"label": "broken"}
+]}`
+	path := filepath.Join(t.TempDir(), "termius.json")
+	if err := os.WriteFile(path, []byte(export), 0600); err != nil {
+		t.Fatalf("Failed to write Termius export: %v", err)
+	}
+
+	result, err := ImportConnections(ImportTermius, path)
+	if err != nil {
+		t.Fatalf("Failed to import Termius hosts: %v", err)
+	}
+	if len(result.Connections) != 2 || len(result.Skipped) != 1 {
+		t.Fatalf("Expected 2 connections and 1 skipped, got %+v / %v", result.Connections, result.Skipped)
+	}
+	if c := result.Connections[0]; c.Name != "staging" || c.Port != 2200 || c.Username != "ci" {
+		t.Errorf("Unexpected first connection: %+v", c)
+	}
+	if c := result.Connections[1]; c.Name != "10.1.1.1" || c.Username != "root" {
+		t.Errorf("Unexpected second connection: %+v", c)
+	}
+}
+
+func TestIsDuplicateImport(t *testing.T) {
+	existing := []SSHConnection{{Name: "web", Host: "Web.Example.com", Port: 22, Username: "deploy"}}
+
+	if !IsDuplicateImport(existing, SSHConnection{Name: "other name", Host: "web.example.com", Port: 22, Username: "deploy"}) {
+		t.Error("Expected same user, host and port to be a duplicate")
+	}
+	if IsDuplicateImport(existing, SSHConnection{Host: "web.example.com", Port: 2222, Username: "deploy"}) {
+		t.Error("Expected a different port not to be a duplicate")
+	}
+}
@@ -0,0 +1,322 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"os/user"
+	"path"
+	"slices"
+	"strings"
+)
+
+// CloudProvider is a cloud whose running instances can be listed through
+// its command line tool, which has to be installed and logged in
+type CloudProvider int
+
+const (
+	CloudAWS CloudProvider = iota
+	CloudGCP
+	CloudHetzner
+)
+
+// CloudProviders lists the supported clouds in display order
+var CloudProviders = []CloudProvider{CloudAWS, CloudGCP, CloudHetzner}
+
+func (p CloudProvider) String() string {
+	switch p {
+	case CloudAWS:
+		return "AWS EC2"
+	case CloudGCP:
+		return "Google Cloud"
+	case CloudHetzner:
+		return "Hetzner Cloud"
+	}
+	return "unknown"
+}
+
+// Command is the CLI used to list the instances
+func (p CloudProvider) Command() string {
+	switch p {
+	case CloudAWS:
+		return "aws"
+	case CloudGCP:
+		return "gcloud"
+	case CloudHetzner:
+		return "hcloud"
+	}
+	return ""
+}
+
+// Description says where the instances are read from
+func (p CloudProvider) Description() string {
+	switch p {
+	case CloudAWS:
+		return "aws ec2 describe-instances (AWS_PROFILE, AWS_REGION)"
+	case CloudGCP:
+		return "gcloud compute instances list (the active project)"
+	case CloudHetzner:
+		return "hcloud server list (the active context)"
+	}
+	return ""
+}
+
+// DefaultUsername is the login of the provider's stock images
+func (p CloudProvider) DefaultUsername() string {
+	switch p {
+	case CloudAWS:
+		return "ec2-user"
+	case CloudHetzner:
+		return "root"
+	}
+	// GCP creates accounts named after the local user
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+// listArgs are the read-only CLI arguments listing running instances as JSON
+func (p CloudProvider) listArgs() []string {
+	switch p {
+	case CloudAWS:
+		return []string{"ec2", "describe-instances", "--filters", "Name=instance-state-name,Values=running", "--output", "json"}
+	case CloudGCP:
+		return []string{"compute", "instances", "list", "--filter=status=RUNNING", "--format=json"}
+	case CloudHetzner:
+		return []string{"server", "list", "--output", "json"}
+	}
+	return nil
+}
+
+// CloudInstance is a running instance reported by a cloud provider
+type CloudInstance struct {
+	Provider  CloudProvider
+	ID        string
+	Name      string
+	PublicIP  string
+	PrivateIP string
+	Zone      string
+	Tags      map[string]string // AWS tags, GCP or Hetzner labels
+}
+
+// ListCloudInstances asks the provider's CLI for the running instances
+func ListCloudInstances(provider CloudProvider) ([]CloudInstance, error) {
+	name := provider.Command()
+	if _, err := exec.LookPath(name); err != nil {
+		return nil, fmt.Errorf("%s not found in PATH, install it and log in first", name)
+	}
+	cmd := exec.Command(name, provider.listArgs()...)
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, errors.New(err.Error() + " - " + strings.TrimSpace(stderr.String()))
+	}
+	switch provider {
+	case CloudAWS:
+		return parseEC2Instances(out.Bytes())
+	case CloudGCP:
+		return parseGCPInstances(out.Bytes())
+	case CloudHetzner:
+		return parseHetznerServers(out.Bytes())
+	}
+	return nil, fmt.Errorf("unsupported cloud provider %d", provider)
+}
+
+// parseEC2Instances reads the output of aws ec2 describe-instances
+func parseEC2Instances(data []byte) ([]CloudInstance, error) {
+	var out struct {
+		Reservations []struct {
+			Instances []struct {
+				InstanceID       string `json:"InstanceId"`
+				PublicIPAddress  string `json:"PublicIpAddress"`
+				PrivateIPAddress string `json:"PrivateIpAddress"`
+				Placement        struct {
+					AvailabilityZone string `json:"AvailabilityZone"`
+				} `json:"Placement"`
+				State struct {
+					Name string `json:"Name"`
+				} `json:"State"`
+				Tags []struct {
+					Key   string `json:"Key"`
+					Value string `json:"Value"`
+				} `json:"Tags"`
+			} `json:"Instances"`
+		} `json:"Reservations"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse aws output: %w", err)
+	}
+	var instances []CloudInstance
+	for _, reservation := range out.Reservations {
+		for _, i := range reservation.Instances {
+			if i.State.Name != "" && i.State.Name != "running" {
+				continue
+			}
+			tags := make(map[string]string, len(i.Tags))
+			for _, tag := range i.Tags {
+				tags[tag.Key] = tag.Value
+			}
+			name := tags["Name"]
+			if name == "" {
+				name = i.InstanceID
+			}
+			instances = append(instances, CloudInstance{
+				Provider:  CloudAWS,
+				ID:        i.InstanceID,
+				Name:      name,
+				PublicIP:  i.PublicIPAddress,
+				PrivateIP: i.PrivateIPAddress,
+				Zone:      i.Placement.AvailabilityZone,
+				Tags:      tags,
+			})
+		}
+	}
+	return instances, nil
+}
+
+// parseGCPInstances reads the output of gcloud compute instances list
+func parseGCPInstances(data []byte) ([]CloudInstance, error) {
+	var out []struct {
+		ID                string            `json:"id"`
+		Name              string            `json:"name"`
+		Zone              string            `json:"zone"`
+		Status            string            `json:"status"`
+		Labels            map[string]string `json:"labels"`
+		NetworkInterfaces []struct {
+			NetworkIP     string `json:"networkIP"`
+			AccessConfigs []struct {
+				NatIP string `json:"natIP"`
+			} `json:"accessConfigs"`
+		} `json:"networkInterfaces"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse gcloud output: %w", err)
+	}
+	var instances []CloudInstance
+	for _, i := range out {
+		if i.Status != "" && i.Status != "RUNNING" {
+			continue
+		}
+		instance := CloudInstance{
+			Provider: CloudGCP,
+			ID:       i.ID,
+			Name:     i.Name,
+			Zone:     path.Base(i.Zone), // a URL ending in the zone name
+			Tags:     i.Labels,
+		}
+		if len(i.NetworkInterfaces) > 0 {
+			nic := i.NetworkInterfaces[0]
+			instance.PrivateIP = nic.NetworkIP
+			if len(nic.AccessConfigs) > 0 {
+				instance.PublicIP = nic.AccessConfigs[0].NatIP
+			}
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// parseHetznerServers reads the output of hcloud server list -o json
+func parseHetznerServers(data []byte) ([]CloudInstance, error) {
+	var out []struct {
+		ID        int64             `json:"id"`
+		Name      string            `json:"name"`
+		Status    string            `json:"status"`
+		Labels    map[string]string `json:"labels"`
+		PublicNet struct {
+			IPv4 struct {
+				IP string `json:"ip"`
+			} `json:"ipv4"`
+		} `json:"public_net"`
+		PrivateNet []struct {
+			IP string `json:"ip"`
+		} `json:"private_net"`
+		Datacenter struct {
+			Name string `json:"name"`
+		} `json:"datacenter"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("failed to parse hcloud output: %w", err)
+	}
+	var instances []CloudInstance
+	for _, s := range out {
+		if s.Status != "" && s.Status != "running" {
+			continue
+		}
+		instance := CloudInstance{
+			Provider: CloudHetzner,
+			ID:       fmt.Sprint(s.ID),
+			Name:     s.Name,
+			PublicIP: s.PublicNet.IPv4.IP,
+			Zone:     s.Datacenter.Name,
+			Tags:     s.Labels,
+		}
+		if len(s.PrivateNet) > 0 {
+			instance.PrivateIP = s.PrivateNet[0].IP
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// CloudMapping turns cloud instances into connections
+type CloudMapping struct {
+	// NameTag is the tag or label holding the connection name; the
+	// instance name is used when it's empty or the instance lacks the tag
+	NameTag string
+	// GroupTag is a tag or label whose value prefixes the name as
+	// "<group>/<name>", so instances of a group sort and filter together
+	GroupTag string
+	// Username logs in to every instance
+	Username string
+	// KeyFile authenticates every instance, the ssh-agent is used if empty
+	KeyFile string
+	// PrivateIP connects to the private address, e.g. over a VPN
+	PrivateIP bool
+}
+
+// CloudConnections maps instances to connections, sorted by name. Instances
+// without an address to connect to are skipped.
+func CloudConnections(instances []CloudInstance, mapping CloudMapping) *ImportResult {
+	result := &ImportResult{}
+	for _, instance := range instances {
+		host := instance.PublicIP
+		if mapping.PrivateIP || host == "" {
+			host = instance.PrivateIP
+		}
+		if mapping.PrivateIP && host == "" {
+			host = instance.PublicIP
+		}
+		if host == "" {
+			result.Skipped = append(result.Skipped, instance.Name+" (no address)")
+			continue
+		}
+		name := instance.Name
+		if value := instance.Tags[mapping.NameTag]; mapping.NameTag != "" && value != "" {
+			name = value
+		}
+		if group := instance.Tags[mapping.GroupTag]; mapping.GroupTag != "" && group != "" {
+			name = group + "/" + name
+		}
+		notes := fmt.Sprintf("%s instance %s", instance.Provider, instance.ID)
+		if instance.Zone != "" {
+			notes += " in " + instance.Zone
+		}
+		result.Connections = append(result.Connections, SSHConnection{
+			Name:     name,
+			Host:     host,
+			Port:     22,
+			Username: mapping.Username,
+			KeyFile:  mapping.KeyFile,
+			Notes:    notes,
+		})
+	}
+	slices.SortStableFunc(result.Connections, func(a, b SSHConnection) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return result
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestParseCloudInstances(t *testing.T) {
+	ec2 := `{"Reservations": [{"Instances": [
+		{"InstanceId": "i-0abc", "PublicIpAddress": "3.3.3.3", "PrivateIpAddress": "10.0.0.3",
+		 "Placement": {"AvailabilityZone": "eu-west-1a"}, "State": {"Name": "running"},
+		 "Tags": [{"Key": "Name", "Value": "web-1"}, {"Key": "env", "Value": "prod"}]},
+		{"InstanceId": "i-0def", "PrivateIpAddress": "10.0.0.4", "State": {"Name": "stopped"}}
+	]}]}`
+	instances, err := parseEC2Instances([]byte(ec2))
+	if err != nil {
+		t.Fatalf("Failed to parse EC2 output: %v", err)
+	}
+	if len(instances) != 1 {
+		t.Fatalf("Expected only the running instance, got %+v", instances)
+	}
+	if i := instances[0]; i.Name != "web-1" || i.PublicIP != "3.3.3.3" || i.Zone != "eu-west-1a" || i.Tags["env"] != "prod" {
+		t.Errorf("Unexpected EC2 instance: %+v", i)
+	}
+
+	gcp := `[{"id": "42", "name": "db", "status": "RUNNING",
+		"zone": "https://www.googleapis.com/compute/v1/projects/p/zones/europe-west1-b",
+		"labels": {"role": "database"},
+		"networkInterfaces": [{"networkIP": "10.1.0.2", "accessConfigs": [{"natIP": "34.1.2.3"}]}]}]`
+	instances, err = parseGCPInstances([]byte(gcp))
+	if err != nil {
+		t.Fatalf("Failed to parse gcloud output: %v", err)
+	}
+	if len(instances) != 1 || instances[0].Zone != "europe-west1-b" || instances[0].PublicIP != "34.1.2.3" || instances[0].PrivateIP != "10.1.0.2" {
+		t.Errorf("Unexpected GCP instances: %+v", instances)
+	}
+
+	hetzner := `[{"id": 7, "name": "cache", "status": "running", "labels": {},
+		"public_net": {"ipv4": {"ip": "5.5.5.5"}}, "private_net": [],
+		"datacenter": {"name": "fsn1-dc14"}},
+		{"id": 8, "name": "off", "status": "off"}]`
+	instances, err = parseHetznerServers([]byte(hetzner))
+	if err != nil {
+		t.Fatalf("Failed to parse hcloud output: %v", err)
+	}
+	if len(instances) != 1 || instances[0].ID != "7" || instances[0].PublicIP != "5.5.5.5" {
+		t.Errorf("Unexpected Hetzner servers: %+v", instances)
+	}
+}
+
+func TestCloudConnections(t *testing.T) {
+	instances := []CloudInstance{
+		{Provider: CloudAWS, ID: "i-1", Name: "web-1", PublicIP: "3.3.3.3", PrivateIP: "10.0.0.3",
+			Zone: "eu-west-1a", Tags: map[string]string{"env": "prod", "Name": "web-1"}},
+		{Provider: CloudAWS, ID: "i-2", Name: "i-2", PrivateIP: "10.0.0.4", Tags: map[string]string{"role": "worker"}},
+		{Provider: CloudAWS, ID: "i-3", Name: "lost"},
+	}
+
+	result := CloudConnections(instances, CloudMapping{NameTag: "role", GroupTag: "env", Username: "ubuntu"})
+	if len(result.Connections) != 2 || len(result.Skipped) != 1 {
+		t.Fatalf("Expected 2 connections and 1 skipped, got %+v / %v", result.Connections, result.Skipped)
+	}
+	// Sorted by name; instances without a public address use the private one
+	if c := result.Connections[0]; c.Name != "prod/web-1" || c.Host != "3.3.3.3" || c.Username != "ubuntu" || c.Port != 22 {
+		t.Errorf("Unexpected connection: %+v", c)
+	}
+	if c := result.Connections[1]; c.Name != "worker" || c.Host != "10.0.0.4" {
+		t.Errorf("Unexpected connection: %+v", c)
+	}
+	if notes := result.Connections[0].Notes; notes != "AWS EC2 instance i-1 in eu-west-1a" {
+		t.Errorf("Unexpected notes %q", notes)
+	}
+
+	result = CloudConnections(instances[:1], CloudMapping{PrivateIP: true})
+	if result.Connections[0].Host != "10.0.0.3" {
+		t.Errorf("Expected the private address, got %s", result.Connections[0].Host)
+	}
+}
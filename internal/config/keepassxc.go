@@ -0,0 +1,480 @@
+package config
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultKeePassGroup = "SSH"
+	keePassNotesMarker  = "-- ssh-x-term --"
+)
+
+// KeePassXCConfig locates a KeePass database and the credentials to open it.
+// Either a password, a key file or both must be given.
+type KeePassXCConfig struct {
+	DatabasePath string
+	KeyFile      string
+	Password     string
+	Group        string // Group holding the connections, created on first save
+}
+
+// KeePassXCManager implements Storage on top of a KeePass database through
+// keepassxc-cli. Each connection is an entry in the configured group: the
+// URL holds ssh://host:port, username and password map directly, and the
+// remaining settings are kept in a block at the end of the entry notes.
+type KeePassXCManager struct {
+	cfg   *KeePassXCConfig
+	mutex sync.Mutex
+	items map[string]SSHConnection
+}
+
+// NewKeePassXCManager creates a KeePassXC-backed storage manager
+func NewKeePassXCManager(cfg *KeePassXCConfig) (*KeePassXCManager, error) {
+	if err := checkKeePassXCCLI(); err != nil {
+		return nil, err
+	}
+	if cfg.DatabasePath == "" {
+		return nil, errors.New("database path is required")
+	}
+	cfg.DatabasePath = ExpandPath(cfg.DatabasePath)
+	if _, err := os.Stat(cfg.DatabasePath); err != nil {
+		return nil, fmt.Errorf("cannot open database: %w", err)
+	}
+	if cfg.KeyFile != "" {
+		cfg.KeyFile = ExpandPath(cfg.KeyFile)
+		if _, err := os.Stat(cfg.KeyFile); err != nil {
+			return nil, fmt.Errorf("cannot open key file: %w", err)
+		}
+	}
+	if cfg.Password == "" && cfg.KeyFile == "" {
+		return nil, errors.New("a password or key file is required")
+	}
+	RegisterSecret(cfg.Password)
+	cfg.Group = strings.Trim(cfg.Group, "/")
+	if cfg.Group == "" {
+		cfg.Group = defaultKeePassGroup
+	}
+	return &KeePassXCManager{cfg: cfg, items: make(map[string]SSHConnection)}, nil
+}
+
+func checkKeePassXCCLI() error {
+	if _, err := exec.LookPath("keepassxc-cli"); err != nil {
+		log.Print("KeePassXC CLI (`keepassxc-cli`) is not installed or not in your PATH")
+		return errors.New("KeePassXC CLI (`keepassxc-cli`) is not installed or not in your PATH. Please install KeePassXC 2.7 or later: https://keepassxc.org/download/")
+	}
+	return nil
+}
+
+// run executes a keepassxc-cli command against the database. The database
+// password is written to stdin first, followed by input (e.g. an entry
+// password for -p).
+func (km *KeePassXCManager) run(command string, options []string, entry string, input string) ([]byte, error) {
+	args := []string{command, "-q"}
+	if km.cfg.KeyFile != "" {
+		args = append(args, "-k", km.cfg.KeyFile)
+	}
+	if km.cfg.Password == "" {
+		args = append(args, "--no-password")
+	}
+	args = append(args, options...)
+	args = append(args, km.cfg.DatabasePath)
+	if entry != "" {
+		args = append(args, entry)
+	}
+
+	var stdin strings.Builder
+	if km.cfg.Password != "" {
+		stdin.WriteString(km.cfg.Password + "\n")
+	}
+	stdin.WriteString(input)
+
+	cmd := exec.Command("keepassxc-cli", args...)
+	cmd.Stdin = strings.NewReader(stdin.String())
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		log.Printf("keepassxc-cli %s failed: %s", command, msg)
+		return nil, fmt.Errorf("keepassxc-cli %s failed: %s", command, msg)
+	}
+	return out.Bytes(), nil
+}
+
+// ---- Storage Interface Implementation ----
+
+// keePassCapabilities: entries are listed all at once and refer to key
+// files by path
+var keePassCapabilities = StorageCapabilities{}
+
+func (km *KeePassXCManager) Capabilities() StorageCapabilities {
+	return keePassCapabilities
+}
+
+func (km *KeePassXCManager) Load() error {
+	km.mutex.Lock()
+	defer km.mutex.Unlock()
+
+	out, err := km.run("export", []string{"--format", "csv"}, "", "")
+	if err != nil {
+		return err
+	}
+	items, err := parseKeePassCSV(out, km.cfg.Group)
+	if err != nil {
+		log.Printf("Failed to parse KeePassXC export: %v", err)
+		return fmt.Errorf("failed to parse KeePassXC export: %w", err)
+	}
+	km.items = items
+	return nil
+}
+
+// Save is a no-op: every change is written to the database immediately.
+func (km *KeePassXCManager) Save() error {
+	return nil
+}
+
+func (km *KeePassXCManager) AddConnection(conn SSHConnection) error {
+	if strings.Contains(conn.Name, "/") {
+		return errors.New("connection names cannot contain '/' in a KeePass database")
+	}
+	// Creating the group fails harmlessly if it already exists
+	_, _ = km.run("mkdir", nil, km.cfg.Group, "")
+
+	options := append(keePassEntryOptions(conn), "-p")
+	if _, err := km.run("add", options, km.cfg.Group+"/"+conn.Name, conn.Password+"\n"); err != nil {
+		return err
+	}
+	return km.Load()
+}
+
+func (km *KeePassXCManager) EditConnection(conn SSHConnection) error {
+	if conn.ID == "" {
+		log.Print("Missing KeePassXC entry path for edit")
+		return errors.New("missing KeePassXC entry path for edit")
+	}
+	if strings.Contains(conn.Name, "/") {
+		return errors.New("connection names cannot contain '/' in a KeePass database")
+	}
+	options := append(keePassEntryOptions(conn), "-p")
+	if conn.ID[strings.LastIndex(conn.ID, "/")+1:] != conn.Name {
+		options = append(options, "--title", conn.Name)
+	}
+	if _, err := km.run("edit", options, conn.ID, conn.Password+"\n"); err != nil {
+		return err
+	}
+	return km.Load()
+}
+
+func (km *KeePassXCManager) DeleteConnection(id string) error {
+	// rm moves the entry to the recycle bin when the database has one
+	if _, err := km.run("rm", nil, id, ""); err != nil {
+		return err
+	}
+	return km.Load()
+}
+
+func (km *KeePassXCManager) GetConnection(id string) (SSHConnection, bool) {
+	km.mutex.Lock()
+	defer km.mutex.Unlock()
+	c, ok := km.items[id]
+	return c, ok
+}
+
+func (km *KeePassXCManager) ListConnections() []SSHConnection {
+	km.mutex.Lock()
+	defer km.mutex.Unlock()
+	conns := make([]SSHConnection, 0, len(km.items))
+	for _, c := range km.items {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// ---- Entry mapping ----
+
+// keePassEntryOptions are the add/edit flags describing a connection. The
+// password is not among them: it is written to stdin for -p.
+func keePassEntryOptions(conn SSHConnection) []string {
+	return []string{
+		"--username", conn.Username,
+		"--url", keePassURL(conn),
+		"--notes", keePassNotes(conn),
+	}
+}
+
+func keePassURL(conn SSHConnection) string {
+	port := conn.Port
+	if port == 0 {
+		port = 22
+	}
+	return "ssh://" + net.JoinHostPort(conn.Host, strconv.Itoa(port))
+}
+
+// keePassNotes appends the settings that have no KeePass field of their
+// own to the user's notes
+func keePassNotes(conn SSHConnection) string {
+	var b strings.Builder
+	if conn.Notes != "" {
+		b.WriteString(conn.Notes)
+		b.WriteString("\n\n")
+	}
+	b.WriteString(keePassNotesMarker + "\n")
+	fmt.Fprintf(&b, "use_password=%t\n", conn.UsePassword)
+	if conn.KeyFile != "" {
+		fmt.Fprintf(&b, "key_file=%s\n", conn.KeyFile)
+	}
+	if conn.SudoPassword != "" {
+		fmt.Fprintf(&b, "sudo_password=%s\n", conn.SudoPassword)
+	}
+	if conn.UseMosh {
+		b.WriteString("mosh=true\n")
+	}
+	if conn.ViewOnly {
+		b.WriteString("view_only=true\n")
+	}
+	if conn.DangerLevel != "" {
+		fmt.Fprintf(&b, "danger_level=%s\n", conn.DangerLevel)
+	}
+	if conn.ForwardAgent {
+		b.WriteString("forward_agent=true\n")
+	}
+	if conn.ForwardX11 {
+		b.WriteString("forward_x11=true\n")
+	}
+	if conn.Proxy != "" {
+		fmt.Fprintf(&b, "proxy=%s\n", conn.Proxy)
+	}
+	if conn.ProxyPassword != "" {
+		fmt.Fprintf(&b, "proxy_password=%s\n", conn.ProxyPassword)
+	}
+	if conn.ProxyCommand != "" {
+		fmt.Fprintf(&b, "proxy_command=%s\n", conn.ProxyCommand)
+	}
+	for _, v := range conn.SetEnv {
+		fmt.Fprintf(&b, "set_env=%s\n", v)
+	}
+	if conn.ProxyJump != "" {
+		fmt.Fprintf(&b, "proxy_jump=%s\n", conn.ProxyJump)
+	}
+	if conn.IdentityAgent != "" {
+		fmt.Fprintf(&b, "identity_agent=%s\n", conn.IdentityAgent)
+	}
+	if conn.ServerAliveInterval != 0 {
+		fmt.Fprintf(&b, "server_alive_interval=%d\n", conn.ServerAliveInterval)
+	}
+	if conn.Compression {
+		b.WriteString("compression=true\n")
+	}
+	if conn.StrictHostKeyChecking != "" {
+		fmt.Fprintf(&b, "strict_host_key_checking=%s\n", conn.StrictHostKeyChecking)
+	}
+	for _, forward := range conn.LocalForward {
+		fmt.Fprintf(&b, "local_forward=%s\n", forward)
+	}
+	for _, forward := range conn.RemoteForward {
+		fmt.Fprintf(&b, "remote_forward=%s\n", forward)
+	}
+	if conn.StartupCommand != "" {
+		fmt.Fprintf(&b, "startup_command=%s\n", conn.StartupCommand)
+	}
+	if conn.Shell != "" {
+		fmt.Fprintf(&b, "shell=%s\n", conn.Shell)
+	}
+	if conn.Term != "" {
+		fmt.Fprintf(&b, "term=%s\n", conn.Term)
+	}
+	if padding := FormatPtyPadding(conn.PtyPadColumns, conn.PtyPadRows); padding != "" {
+		fmt.Fprintf(&b, "pty_padding=%s\n", padding)
+	}
+	if timeouts := FormatSessionTimeouts(conn.IdleMinutes, conn.SessionMinutes); timeouts != "" {
+		fmt.Fprintf(&b, "session_timeouts=%s\n", timeouts)
+	}
+	for _, command := range conn.OnConnect {
+		fmt.Fprintf(&b, "on_connect=%s\n", command)
+	}
+	if conn.OnConnectAuto {
+		b.WriteString("on_connect_auto=true\n")
+	}
+	if conn.RemoteStartPath != "" {
+		fmt.Fprintf(&b, "remote_start_path=%s\n", conn.RemoteStartPath)
+	}
+	if conn.LocalStartPath != "" {
+		fmt.Fprintf(&b, "local_start_path=%s\n", conn.LocalStartPath)
+	}
+	for _, bookmark := range conn.Bookmarks {
+		fmt.Fprintf(&b, "bookmark=%s\n", bookmark)
+	}
+	fmt.Fprintf(&b, "pinned=%t\n", conn.Pinned)
+	if conn.LastConnected != 0 {
+		fmt.Fprintf(&b, "last_connected=%d\n", conn.LastConnected)
+	}
+	if conn.ConnectCount != 0 {
+		fmt.Fprintf(&b, "connect_count=%d\n", conn.ConnectCount)
+	}
+	fmt.Fprintf(&b, "order=%d", conn.Order)
+	return b.String()
+}
+
+// applyKeePassNotes splits entry notes into the user's notes and the
+// settings block written by keePassNotes
+func applyKeePassNotes(conn *SSHConnection, notes string) {
+	userNotes, settings, found := strings.Cut(notes, keePassNotesMarker)
+	if !found {
+		// Entries created outside ssh-x-term default to password auth
+		conn.Notes = strings.TrimSpace(notes)
+		conn.UsePassword = true
+		return
+	}
+	conn.Notes = strings.TrimSpace(userNotes)
+	for _, line := range strings.Split(settings, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "use_password":
+			conn.UsePassword = value == "true"
+		case "key_file":
+			conn.KeyFile = value
+		case "sudo_password":
+			conn.SudoPassword = value
+		case "pinned":
+			conn.Pinned = value == "true"
+		case "mosh":
+			conn.UseMosh = value == "true"
+		case "view_only":
+			conn.ViewOnly = value == "true"
+		case "danger_level":
+			conn.DangerLevel = ParseDangerLevel(value)
+		case "forward_agent":
+			conn.ForwardAgent = value == "true"
+		case "forward_x11":
+			conn.ForwardX11 = value == "true"
+		case "proxy":
+			conn.Proxy = value
+		case "proxy_password":
+			conn.ProxyPassword = value
+		case "proxy_command":
+			conn.ProxyCommand = value
+		case "set_env":
+			conn.SetEnv = append(conn.SetEnv, value)
+		case "proxy_jump":
+			conn.ProxyJump = value
+		case "identity_agent":
+			conn.IdentityAgent = value
+		case "server_alive_interval":
+			conn.ServerAliveInterval, _ = strconv.Atoi(value)
+		case "compression":
+			conn.Compression = value == "true"
+		case "strict_host_key_checking":
+			conn.StrictHostKeyChecking = value
+		case "local_forward":
+			conn.LocalForward = append(conn.LocalForward, value)
+		case "remote_forward":
+			conn.RemoteForward = append(conn.RemoteForward, value)
+		case "startup_command":
+			conn.StartupCommand = value
+		case "shell":
+			conn.Shell = value
+		case "term":
+			conn.Term = value
+		case "pty_padding":
+			conn.PtyPadColumns, conn.PtyPadRows, _ = ParsePtyPadding(value)
+		case "session_timeouts":
+			conn.IdleMinutes, conn.SessionMinutes, _ = ParseSessionTimeouts(value)
+		case "on_connect":
+			conn.OnConnect = append(conn.OnConnect, value)
+		case "on_connect_auto":
+			conn.OnConnectAuto = value == "true"
+		case "remote_start_path":
+			conn.RemoteStartPath = value
+		case "local_start_path":
+			conn.LocalStartPath = value
+		case "bookmark":
+			conn.Bookmarks = append(conn.Bookmarks, value)
+		case "order":
+			conn.Order, _ = strconv.Atoi(value)
+		case "last_connected":
+			conn.LastConnected, _ = strconv.ParseInt(value, 10, 64)
+		case "connect_count":
+			conn.ConnectCount, _ = strconv.Atoi(value)
+		}
+	}
+}
+
+// parseKeePassCSV reads `keepassxc-cli export --format csv` output and
+// returns the ssh:// entries in group (or its subgroups), keyed by their
+// entry path
+func parseKeePassCSV(data []byte, group string) (map[string]SSHConnection, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	items := make(map[string]SSHConnection)
+	if len(records) == 0 {
+		return items, nil
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+	for _, required := range []string{"Group", "Title", "Username", "Password", "URL", "Notes"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing %q column", required)
+		}
+	}
+
+	for _, record := range records[1:] {
+		field := func(name string) string {
+			if i := columns[name]; i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		// Group paths start with the root group's name, e.g. Root/SSH
+		entryGroup := field("Group")
+		if _, rest, ok := strings.Cut(entryGroup, "/"); ok {
+			entryGroup = rest
+		} else {
+			entryGroup = ""
+		}
+		if entryGroup != group && !strings.HasPrefix(entryGroup, group+"/") {
+			continue
+		}
+
+		u, err := url.Parse(field("URL"))
+		if err != nil || u.Scheme != "ssh" || u.Hostname() == "" {
+			continue
+		}
+		conn := SSHConnection{
+			ID:       entryGroup + "/" + field("Title"),
+			Name:     field("Title"),
+			Host:     u.Hostname(),
+			Port:     atoiDefault(u.Port(), 22),
+			Username: field("Username"),
+			Password: field("Password"),
+		}
+		if conn.Username == "" && u.User != nil {
+			conn.Username = u.User.Username()
+		}
+		applyKeePassNotes(&conn, field("Notes"))
+		RegisterConnectionSecrets(conn)
+		items[conn.ID] = conn
+	}
+	return items, nil
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestKeePassCSVRoundTrip(t *testing.T) {
+	conn := SSHConnection{
+		Name:            "web",
+		Host:            "web.example.com",
+		Port:            2222,
+		Username:        "deploy",
+		Password:        "key-passphrase",
+		KeyFile:         "~/.ssh/id_ed25519",
+		SudoPassword:    "sudo-secret",
+		Notes:           "Primary web node",
+		Pinned:          true,
+		Order:           3,
+		UseMosh:         true,
+		ViewOnly:        true,
+		DangerLevel:     DangerStaging,
+		ForwardAgent:    true,
+		ForwardX11:      true,
+		SetEnv:          []string{"LANG=C.UTF-8", "GREETING=hello world"},
+		ProxyJump:       "bastion",
+		LocalForward:    []string{"8080 localhost:80", "5433 db:5432"},
+		StartupCommand:  "cd /srv/app",
+		Shell:           "/bin/zsh",
+		OnConnect:       []string{"cd /var/www", "sudo -i"},
+		OnConnectAuto:   true,
+		RemoteStartPath: "/var/www/app",
+		LocalStartPath:  "~/projects/app",
+		Bookmarks:       []string{"remote:/var/log/app", "local:~/Downloads"},
+		LastConnected:   1760000000,
+		ConnectCount:    12,
+	}
+
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.WriteAll([][]string{
+		{"Group", "Title", "Username", "Password", "URL", "Notes", "TOTP", "Icon", "Last Modified", "Created"},
+		{"Root/SSH", conn.Name, conn.Username, conn.Password, keePassURL(conn), keePassNotes(conn), "", "0", "", ""},
+		{"Root/SSH/lab", "router", "admin", "hunter2", "ssh://[fe80::1]", "Edited by hand", "", "0", "", ""},
+		{"Root/Web", "bank", "me", "pw", "https://bank.example.com", "", "", "0", "", ""},
+		{"Root/SSH", "wiki", "me", "pw", "https://wiki.example.com", "", "", "0", "", ""},
+	})
+
+	items, err := parseKeePassCSV([]byte(b.String()), "SSH")
+	if err != nil {
+		t.Fatalf("Failed to parse export: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 SSH entries, got %+v", items)
+	}
+
+	got, ok := items["SSH/web"]
+	if !ok {
+		t.Fatalf("Expected entry SSH/web, got %+v", items)
+	}
+	if got.Host != conn.Host || got.Port != conn.Port || got.Username != conn.Username || got.Password != conn.Password {
+		t.Errorf("Unexpected connection fields: %+v", got)
+	}
+	if got.UsePassword || got.KeyFile != conn.KeyFile || got.SudoPassword != conn.SudoPassword {
+		t.Errorf("Expected key auth settings to round-trip, got %+v", got)
+	}
+	if got.Notes != conn.Notes || !got.Pinned || got.Order != 3 || !got.UseMosh || !got.ViewOnly || got.DangerLevel != DangerStaging {
+		t.Errorf("Expected notes, pin, order, mosh, view only and danger level to round-trip, got %+v", got)
+	}
+	if !got.ForwardAgent || !got.ForwardX11 || got.ProxyJump != conn.ProxyJump || !reflect.DeepEqual(got.LocalForward, conn.LocalForward) {
+		t.Errorf("Expected forwarding options to round-trip, got %+v", got)
+	}
+	if !reflect.DeepEqual(got.SetEnv, conn.SetEnv) || got.StartupCommand != conn.StartupCommand || got.Shell != conn.Shell ||
+		!reflect.DeepEqual(got.OnConnect, conn.OnConnect) || !got.OnConnectAuto {
+		t.Errorf("Expected environment, startup command and shell to round-trip, got %+v", got)
+	}
+	if got.RemoteStartPath != conn.RemoteStartPath || got.LocalStartPath != conn.LocalStartPath || !reflect.DeepEqual(got.Bookmarks, conn.Bookmarks) {
+		t.Errorf("Expected the file manager start paths and bookmarks to round-trip, got %+v", got)
+	}
+	if got.LastConnected != conn.LastConnected || got.ConnectCount != conn.ConnectCount {
+		t.Errorf("Expected usage stats to round-trip, got %+v", got)
+	}
+
+	router := items["SSH/lab/router"]
+	if router.Host != "fe80::1" || router.Port != 22 || !router.UsePassword || router.Notes != "Edited by hand" {
+		t.Errorf("Unexpected hand-made entry: %+v", router)
+	}
+}
+
+func TestKeePassCSVMissingColumns(t *testing.T) {
+	if _, err := parseKeePassCSV([]byte("Title,Username\nweb,deploy\n"), "SSH"); err == nil {
+		t.Error("Expected an error for an export without the required columns")
+	}
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+)
+
+// StorageSource is a backend of a MergedStorage and how the connection
+// list labels its connections, e.g. "Local" or "Bitwarden:TeamX"
+type StorageSource struct {
+	Label   string
+	Storage Storage
+}
+
+// StorageLabeler is implemented by backends naming the part of them in
+// view, such as "Bitwarden:TeamX" for a collection
+type StorageLabeler interface {
+	StorageLabel() string
+}
+
+// MergedStorage lists the connections of several backends at once, such as
+// personal hosts in ~/.ssh/config and shared ones in a Bitwarden
+// collection. Each connection is tagged with the label of its source in
+// SSHConnection.Source, and edits and deletions go back to that source.
+// New connections go to the source named by their Source, the first one
+// that isn't read-only otherwise.
+type MergedStorage struct {
+	sources []StorageSource
+	mutex   sync.Mutex
+	// owners maps connection IDs to the index of their source, the first
+	// one listing the ID when several do
+	owners map[string]int
+}
+
+// NewMergedStorage merges sources, in the order they are listed. Labels
+// used twice get a number so connections can be routed by label.
+func NewMergedStorage(sources ...StorageSource) *MergedStorage {
+	ms := &MergedStorage{owners: make(map[string]int)}
+	used := make(map[string]int)
+	for _, source := range sources {
+		used[source.Label]++
+		if n := used[source.Label]; n > 1 {
+			source.Label += " " + strconv.Itoa(n)
+		}
+		ms.sources = append(ms.sources, source)
+	}
+	return ms
+}
+
+// Sources returns the merged backends in order
+func (ms *MergedStorage) Sources() []StorageSource {
+	return ms.sources
+}
+
+// Contains reports whether storage is one of the merged backends
+func (ms *MergedStorage) Contains(storage Storage) bool {
+	for _, source := range ms.sources {
+		if source.Storage == storage {
+			return true
+		}
+	}
+	return false
+}
+
+// Capabilities: the merged list is loaded all at once; keys can be
+// attachments when a source keeps them, and it is read-only when all of
+// its sources are
+func (ms *MergedStorage) Capabilities() StorageCapabilities {
+	caps := StorageCapabilities{ReadOnly: len(ms.sources) > 0}
+	for _, source := range ms.sources {
+		sourceCaps := source.Storage.Capabilities()
+		caps.Attachments = caps.Attachments || sourceCaps.Attachments
+		caps.ReadOnly = caps.ReadOnly && sourceCaps.ReadOnly
+	}
+	return caps
+}
+
+// Load loads every source, the part in view of a ViewLoader. A source
+// failing to load doesn't stop the others from loading.
+func (ms *MergedStorage) Load() error {
+	var errs []error
+	for _, source := range ms.sources {
+		if err := LoadStorage(source.Storage); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.Label, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Save saves every source
+func (ms *MergedStorage) Save() error {
+	var errs []error
+	for _, source := range ms.sources {
+		if err := source.Storage.Save(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.Label, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ListConnections lists the connections of all sources, each tagged with
+// the label of its source
+func (ms *MergedStorage) ListConnections() []SSHConnection {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	clear(ms.owners)
+	var conns []SSHConnection
+	for i, source := range ms.sources {
+		for _, conn := range source.Storage.ListConnections() {
+			if owner, ok := ms.owners[conn.ID]; ok {
+				log.Printf("Connection %s is in both %s and %s, changes go to %s", conn.ID, ms.sources[owner].Label, source.Label, ms.sources[owner].Label)
+			} else {
+				ms.owners[conn.ID] = i
+			}
+			conn.Source = source.Label
+			conns = append(conns, conn)
+		}
+	}
+	return conns
+}
+
+// source returns the source of the connection with the given ID, or of the
+// one labeled label when set
+func (ms *MergedStorage) source(label, id string) (StorageSource, error) {
+	if label != "" {
+		for _, source := range ms.sources {
+			if source.Label == label {
+				return source, nil
+			}
+		}
+		return StorageSource{}, fmt.Errorf("storage %q is not in the list", label)
+	}
+	ms.mutex.Lock()
+	owner, ok := ms.owners[id]
+	ms.mutex.Unlock()
+	if !ok {
+		return StorageSource{}, errors.New("connection with ID " + id + " not found")
+	}
+	return ms.sources[owner], nil
+}
+
+// writable returns source, or an error when it is read-only
+func writable(source StorageSource) (StorageSource, error) {
+	if source.Storage.Capabilities().ReadOnly {
+		return StorageSource{}, errors.New(source.Label + " is read-only")
+	}
+	return source, nil
+}
+
+func (ms *MergedStorage) GetConnection(id string) (SSHConnection, bool) {
+	source, err := ms.source("", id)
+	if err != nil {
+		return SSHConnection{}, false
+	}
+	conn, ok := source.Storage.GetConnection(id)
+	conn.Source = source.Label
+	return conn, ok
+}
+
+// AddConnection adds conn to the source its Source names, or to the first
+// source that isn't read-only
+func (ms *MergedStorage) AddConnection(conn SSHConnection) error {
+	if conn.Source != "" {
+		source, err := ms.source(conn.Source, "")
+		if err == nil {
+			source, err = writable(source)
+		}
+		if err != nil {
+			return err
+		}
+		return source.Storage.AddConnection(conn)
+	}
+	for _, source := range ms.sources {
+		if !source.Storage.Capabilities().ReadOnly {
+			return source.Storage.AddConnection(conn)
+		}
+	}
+	return errors.New("all storages in the list are read-only")
+}
+
+// EditConnection saves conn to the source it was loaded from
+func (ms *MergedStorage) EditConnection(conn SSHConnection) error {
+	source, err := ms.source(conn.Source, conn.ID)
+	if err == nil {
+		source, err = writable(source)
+	}
+	if err != nil {
+		return err
+	}
+	return source.Storage.EditConnection(conn)
+}
+
+// DeleteConnection deletes the connection from the source it was loaded
+// from
+func (ms *MergedStorage) DeleteConnection(id string) error {
+	source, err := ms.source("", id)
+	if err == nil {
+		source, err = writable(source)
+	}
+	if err != nil {
+		return err
+	}
+	return source.Storage.DeleteConnection(id)
+}
+
+// DownloadKeyAttachment gets the key attached to conn from its source
+func (ms *MergedStorage) DownloadKeyAttachment(conn SSHConnection) (string, error) {
+	source, err := ms.source(conn.Source, conn.ID)
+	if err != nil {
+		return "", err
+	}
+	store, ok := source.Storage.(KeyAttachmentStorage)
+	if !ok {
+		return "", errors.New(source.Label + " doesn't keep key attachments")
+	}
+	return store.DownloadKeyAttachment(conn)
+}
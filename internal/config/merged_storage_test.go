@@ -0,0 +1,103 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+// listStorage keeps connections in order, and can be read-only
+type listStorage struct {
+	conns    []SSHConnection
+	readOnly bool
+}
+
+func (s *listStorage) Load() error { return nil }
+func (s *listStorage) Save() error { return nil }
+func (s *listStorage) AddConnection(c SSHConnection) error {
+	s.conns = append(s.conns, c)
+	return nil
+}
+func (s *listStorage) DeleteConnection(id string) error {
+	s.conns = slices.DeleteFunc(s.conns, func(c SSHConnection) bool { return c.ID == id })
+	return nil
+}
+func (s *listStorage) GetConnection(id string) (SSHConnection, bool) {
+	i := slices.IndexFunc(s.conns, func(c SSHConnection) bool { return c.ID == id })
+	if i < 0 {
+		return SSHConnection{}, false
+	}
+	return s.conns[i], true
+}
+func (s *listStorage) ListConnections() []SSHConnection { return s.conns }
+func (s *listStorage) EditConnection(c SSHConnection) error {
+	for i := range s.conns {
+		if s.conns[i].ID == c.ID {
+			s.conns[i] = c
+		}
+	}
+	return nil
+}
+func (s *listStorage) Capabilities() StorageCapabilities {
+	return StorageCapabilities{ReadOnly: s.readOnly}
+}
+
+func TestMergedStorage(t *testing.T) {
+	local := &listStorage{conns: []SSHConnection{{ID: "l1", Name: "laptop"}}}
+	team := &listStorage{conns: []SSHConnection{{ID: "t1", Name: "web"}, {ID: "t2", Name: "db"}}}
+	merged := NewMergedStorage(StorageSource{"Local", local}, StorageSource{"Bitwarden:TeamX", team})
+
+	conns := merged.ListConnections()
+	var sources []string
+	for _, conn := range conns {
+		sources = append(sources, conn.Name+"@"+conn.Source)
+	}
+	if !slices.Equal(sources, []string{"laptop@Local", "web@Bitwarden:TeamX", "db@Bitwarden:TeamX"}) {
+		t.Fatalf("Unexpected merged list %v", sources)
+	}
+
+	web := conns[1]
+	web.Name = "web-1"
+	if err := merged.EditConnection(web); err != nil || team.conns[0].Name != "web-1" {
+		t.Errorf("Expected the edit to go to the team storage, got %v (%+v)", err, team.conns[0])
+	}
+	if err := merged.DeleteConnection("l1"); err != nil || len(local.conns) != 0 {
+		t.Errorf("Expected the local connection to be deleted, got %v (%v)", err, local.conns)
+	}
+	if conn, ok := merged.GetConnection("t2"); !ok || conn.Name != "db" || conn.Source != "Bitwarden:TeamX" {
+		t.Errorf("Expected to get db from the team, got %+v, %v", conn, ok)
+	}
+
+	// New connections go to the first storage, duplicates to their original's
+	if err := merged.AddConnection(SSHConnection{ID: "n1"}); err != nil || len(local.conns) != 1 {
+		t.Errorf("Expected a new connection to go to the local storage, got %v", err)
+	}
+	if err := merged.AddConnection(SSHConnection{ID: "n2", Source: "Bitwarden:TeamX"}); err != nil || len(team.conns) != 3 {
+		t.Errorf("Expected a duplicate to go to the team storage, got %v", err)
+	}
+	if err := merged.AddConnection(SSHConnection{ID: "n3", Source: "Vault"}); err == nil {
+		t.Error("Expected an unknown source to be refused")
+	}
+}
+
+func TestMergedStorageReadOnly(t *testing.T) {
+	shared := &listStorage{conns: []SSHConnection{{ID: "s1"}}, readOnly: true}
+	mine := &listStorage{}
+	merged := NewMergedStorage(StorageSource{"Vault", shared}, StorageSource{"Vault", mine})
+
+	if labels := []string{merged.Sources()[0].Label, merged.Sources()[1].Label}; !slices.Equal(labels, []string{"Vault", "Vault 2"}) {
+		t.Errorf("Expected labels used twice to be numbered, got %v", labels)
+	}
+	merged.ListConnections()
+	if err := merged.DeleteConnection("s1"); err == nil {
+		t.Error("Expected deleting from a read-only storage to fail")
+	}
+	if err := merged.AddConnection(SSHConnection{ID: "n1"}); err != nil || len(mine.conns) != 1 {
+		t.Errorf("Expected a new connection to skip the read-only storage, got %v", err)
+	}
+	if merged.Capabilities().ReadOnly {
+		t.Error("Expected the list to be writable while one storage is")
+	}
+	if !NewMergedStorage(StorageSource{"Vault", shared}).Capabilities().ReadOnly {
+		t.Error("Expected the list to be read-only when all storages are")
+	}
+}
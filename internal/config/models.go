@@ -1,23 +1,64 @@
 package config
 
+import (
+	"slices"
+	"strings"
+)
+
 // SSHConnection represents a saved SSH connection configuration
 type SSHConnection struct {
-	ID             string   `json:"id"`
-	Name           string   `json:"name"`
-	HostPattern    string   `json:"host_pattern,omitempty"` // SSH config Host pattern
-	Host           string   `json:"host"`                   // Actual hostname
-	Port           int      `json:"port"`
-	Username       string   `json:"username"`
-	Password       string   `json:"password,omitempty"`
-	SudoPassword   string   `json:"sudo_password,omitempty"`
-	PublicKey      string   `json:"public_key,omitempty"`
-	UsePassword    bool     `json:"use_password"`
-	KeyFile        string   `json:"key_file,omitempty"`
-	Notes          string   `json:"notes,omitempty"`
-	OrganizationID string   `json:"organizationId"`
-	CollectionIds  []string `json:"collectionIds,omitempty"`
-	Pinned         bool     `json:"pinned"`
-	Order          int      `json:"order"`
+	ID              string   `json:"id"`
+	Name            string   `json:"name"`
+	HostPattern     string   `json:"host_pattern,omitempty"` // SSH config Host pattern
+	Host            string   `json:"host"`                   // Actual hostname
+	Port            int      `json:"port"`
+	Username        string   `json:"username"`
+	Password        string   `json:"password,omitempty"`
+	SudoPassword    string   `json:"sudo_password,omitempty"`
+	PublicKey       string   `json:"public_key,omitempty"`
+	UsePassword     bool     `json:"use_password"`
+	KeyFile         string   `json:"key_file,omitempty"`
+	KeyAttachment   string   `json:"key_attachment,omitempty"` // Bitwarden attachment, or Vault secret, holding the private key
+	Notes           string   `json:"notes,omitempty"`
+	OrganizationID  string   `json:"organizationId"`
+	CollectionIds   []string `json:"collectionIds,omitempty"`
+	FolderID        string   `json:"folderId,omitempty"` // Bitwarden personal vault folder
+	Pinned          bool     `json:"pinned"`
+	Order           int      `json:"order"`
+	ViewOnly        bool     `json:"view_only,omitempty"`     // Edits, deletion and uploads to the host are refused
+	DangerLevel     string   `json:"danger_level,omitempty"`  // "", DangerStaging or DangerProduction
+	UseMosh         bool     `json:"use_mosh,omitempty"`      // Start sessions with mosh when available
+	ForwardAgent    bool     `json:"forward_agent,omitempty"` // Like OpenSSH ForwardAgent
+	ForwardX11      bool     `json:"forward_x11,omitempty"`   // Like OpenSSH ForwardX11
+	Proxy           string   `json:"proxy,omitempty"`         // socks5://[user@]host:port or http://[user@]host:port
+	ProxyPassword   string   `json:"proxy_password,omitempty"`
+	ProxyCommand    string   `json:"proxy_command,omitempty"`     // Like OpenSSH ProxyCommand, with %h, %p and %r expanded
+	SetEnv          []string `json:"set_env,omitempty"`           // NAME=value pairs sent to the server, like OpenSSH SetEnv
+	StartupCommand  string   `json:"startup_command,omitempty"`   // Run before handing over the interactive shell
+	Shell           string   `json:"shell,omitempty"`             // Login shell to start instead of the account's default
+	Term            string   `json:"term,omitempty"`              // PTY terminal type, DefaultTerm when empty
+	PtyPadColumns   int      `json:"pty_pad_columns,omitempty"`   // Columns held back from the PTY size
+	PtyPadRows      int      `json:"pty_pad_rows,omitempty"`      // Rows held back from the PTY size
+	IdleMinutes     int      `json:"idle_minutes,omitempty"`      // Sessions without input are disconnected after this long
+	SessionMinutes  int      `json:"session_minutes,omitempty"`   // Sessions are disconnected after this long
+	OnConnect       []string `json:"on_connect,omitempty"`        // Typed into the built-in terminal at the first prompts
+	OnConnectAuto   bool     `json:"on_connect_auto,omitempty"`   // Run OnConnect without asking first
+	RemoteStartPath string   `json:"remote_start_path,omitempty"` // Where the file manager opens on the remote, the login directory when empty
+	LocalStartPath  string   `json:"local_start_path,omitempty"`  // Where the file manager opens locally, the working directory when empty
+	Bookmarks       []string `json:"bookmarks,omitempty"`         // File manager directories to jump to, see Bookmark
+	LastConnected   int64    `json:"last_connected,omitempty"`    // Unix time of the last session
+	ConnectCount    int      `json:"connect_count,omitempty"`
+	HostRange       string   `json:"-"` // Host of the template an instance of a host range was expanded from
+	Source          string   `json:"-"` // Label of the storage a merged list loaded it from, see MergedStorage
+
+	// More OpenSSH options, kept in ssh_config as the options they are named after
+	ProxyJump             string   `json:"proxy_jump,omitempty"`               // [user@]host[:port] hops, comma-separated
+	IdentityAgent         string   `json:"identity_agent,omitempty"`           // Agent socket used instead of SSH_AUTH_SOCK, "none" for no agent
+	ServerAliveInterval   int      `json:"server_alive_interval,omitempty"`    // Seconds between keepalives, keepalive_seconds when 0
+	Compression           bool     `json:"compression,omitempty"`              // For ssh outside sxt: the built-in client doesn't compress
+	StrictHostKeyChecking string   `json:"strict_host_key_checking,omitempty"` // yes, accept-new or no (the default), against ~/.ssh/known_hosts
+	LocalForward          []string `json:"local_forward,omitempty"`            // "[bind:]port host:hostport" tunnels
+	RemoteForward         []string `json:"remote_forward,omitempty"`           // "[bind:]port host:hostport" tunnels
 }
 
 // Organization represents the user's organization
@@ -39,6 +80,35 @@ type Collection struct {
 	ExternalID     *string `json:"externalId"`
 }
 
+// Folder represents a folder of the user's personal vault. Items outside
+// any folder are listed under "No Folder", which has no ID.
+type Folder struct {
+	Object string `json:"object"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+}
+
+// Danger levels of a connection. Sessions to production hosts open only
+// once the host name is typed, and their terminal header is red; staging
+// headers take the warning color.
+const (
+	DangerStaging    = "staging"
+	DangerProduction = "production"
+)
+
+// DangerLevels are the danger levels in the order the connection form
+// cycles through them, starting with none
+var DangerLevels = []string{"", DangerStaging, DangerProduction}
+
+// ParseDangerLevel reads a danger level as stored, "" when it is unknown
+func ParseDangerLevel(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if slices.Contains(DangerLevels, s) {
+		return s
+	}
+	return ""
+}
+
 type Config struct {
 	Connections []SSHConnection `json:"connections"`
 	LastUsed    string          `json:"last_used,omitempty"`
@@ -50,3 +120,28 @@ func NewConfig() *Config {
 		Connections: []SSHConnection{},
 	}
 }
+
+// DuplicateConnection returns a copy of conn to be saved as a new
+// connection: the ID is cleared so a new one is generated, the name gets a
+// " (copy)" suffix and the pin, order and usage history start over.
+// Bitwarden attachments belong to the original item; the key file is
+// attached again when the copy is saved.
+func DuplicateConnection(conn SSHConnection) SSHConnection {
+	dup := conn
+	dup.ID = ""
+	dup.Name = conn.Name + " (copy)"
+	dup.HostPattern = ""
+	dup.HostRange = ""
+	dup.Pinned = false
+	dup.Order = 0
+	dup.LastConnected = 0
+	dup.ConnectCount = 0
+	dup.KeyAttachment = ""
+	dup.CollectionIds = slices.Clone(conn.CollectionIds)
+	dup.SetEnv = slices.Clone(conn.SetEnv)
+	dup.OnConnect = slices.Clone(conn.OnConnect)
+	dup.Bookmarks = slices.Clone(conn.Bookmarks)
+	dup.LocalForward = slices.Clone(conn.LocalForward)
+	dup.RemoteForward = slices.Clone(conn.RemoteForward)
+	return dup
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDuplicateConnection(t *testing.T) {
+	conn := SSHConnection{
+		ID:            "web-1",
+		Name:          "Web 1",
+		HostPattern:   "web1",
+		Host:          "web1.example.com",
+		Port:          2222,
+		Username:      "deploy",
+		Password:      "secret",
+		KeyFile:       "~/.ssh/id_web",
+		KeyAttachment: "id_web",
+		CollectionIds: []string{"coll-1"},
+		SetEnv:        []string{"LANG=C.UTF-8"},
+		Pinned:        true,
+		Order:         3,
+		LastConnected: 1700000000,
+		ConnectCount:  12,
+	}
+	dup := DuplicateConnection(conn)
+
+	want := SSHConnection{
+		Name:          "Web 1 (copy)",
+		Host:          "web1.example.com",
+		Port:          2222,
+		Username:      "deploy",
+		Password:      "secret",
+		KeyFile:       "~/.ssh/id_web",
+		CollectionIds: []string{"coll-1"},
+		SetEnv:        []string{"LANG=C.UTF-8"},
+	}
+	if !reflect.DeepEqual(dup, want) {
+		t.Errorf("Expected %+v, got %+v", want, dup)
+	}
+
+	dup.SetEnv[0] = "LANG=C"
+	if conn.SetEnv[0] != "LANG=C.UTF-8" {
+		t.Errorf("Expected the copy not to share slices with the original")
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+const defaultRecentCollectionsFileName = "recent_collections.json"
+
+// maxRecentCollections is how many collections are remembered
+const maxRecentCollections = 9
+
+// RecentCollection is a Bitwarden collection recently browsed, with the
+// names shown in the quick-pick
+type RecentCollection struct {
+	OrganizationID   string `json:"organization_id"`
+	OrganizationName string `json:"organization_name,omitempty"`
+	CollectionID     string `json:"collection_id"`
+	CollectionName   string `json:"collection_name,omitempty"`
+}
+
+// Collection returns the collection the entry refers to
+func (r RecentCollection) Collection() Collection {
+	return Collection{ID: r.CollectionID, OrganizationID: r.OrganizationID, Name: r.CollectionName}
+}
+
+// RecentCollectionsStore keeps the Bitwarden collections last browsed in
+// ~/.config/ssh-x-term/recent_collections.json, most recent first, so the
+// connection list can switch back to one directly
+type RecentCollectionsStore struct {
+	Path        string             `json:"-"`
+	Collections []RecentCollection `json:"collections"`
+}
+
+// NewRecentCollectionsStore creates a recent collections store in the
+// default location
+func NewRecentCollectionsStore() (*RecentCollectionsStore, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return &RecentCollectionsStore{
+		Path: filepath.Join(homeDir, ".config", "ssh-x-term", defaultRecentCollectionsFileName),
+	}, nil
+}
+
+// Load reads the recent collections file. A missing file yields an empty
+// store.
+func (s *RecentCollectionsStore) Load() error {
+	s.Collections = nil
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", s.Path, err)
+	}
+	return nil
+}
+
+// Save writes the recent collections file, creating its directory if
+// needed
+func (s *RecentCollectionsStore) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.Path, data, 0600); err != nil {
+		log.Printf("Failed to write recent collections file: %v", err)
+		return err
+	}
+	return nil
+}
+
+// Record moves recent to the front of the list, dropping the oldest
+// entries past maxRecentCollections, and saves the store
+func (s *RecentCollectionsStore) Record(recent RecentCollection) error {
+	s.Collections = slices.DeleteFunc(s.Collections, func(r RecentCollection) bool {
+		return r.CollectionID == recent.CollectionID
+	})
+	s.Collections = append([]RecentCollection{recent}, s.Collections...)
+	if len(s.Collections) > maxRecentCollections {
+		s.Collections = s.Collections[:maxRecentCollections]
+	}
+	return s.Save()
+}
+
+// RecentCollections returns the collections last browsed in the default
+// store, most recent first
+func RecentCollections() []RecentCollection {
+	store, err := NewRecentCollectionsStore()
+	if err != nil {
+		return nil
+	}
+	if err := store.Load(); err != nil {
+		log.Printf("Failed to load recent collections: %v", err)
+		return nil
+	}
+	return store.Collections
+}
+
+// RecordRecentCollection loads the default store, puts recent first and
+// saves it
+func RecordRecentCollection(recent RecentCollection) error {
+	store, err := NewRecentCollectionsStore()
+	if err != nil {
+		return err
+	}
+	if err := store.Load(); err != nil {
+		return err
+	}
+	return store.Record(recent)
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecentCollectionsStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recent_collections.json")
+	store := &RecentCollectionsStore{Path: path}
+	if err := store.Load(); err != nil || len(store.Collections) != 0 {
+		t.Fatalf("Expected missing file to load as empty, got %v", err)
+	}
+
+	for i := range maxRecentCollections + 2 {
+		recent := RecentCollection{OrganizationID: "acme", CollectionID: fmt.Sprint(i), CollectionName: fmt.Sprint("team-", i)}
+		if err := store.Record(recent); err != nil {
+			t.Fatalf("Failed to record a collection: %v", err)
+		}
+	}
+	if err := store.Record(RecentCollection{OrganizationID: "acme", CollectionID: "5", CollectionName: "team-5"}); err != nil {
+		t.Fatalf("Failed to record a collection: %v", err)
+	}
+
+	reloaded := &RecentCollectionsStore{Path: path}
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Failed to reload recent collections: %v", err)
+	}
+	got := reloaded.Collections
+	if len(got) != maxRecentCollections {
+		t.Fatalf("Expected %d recent collections, got %d", maxRecentCollections, len(got))
+	}
+	if got[0].CollectionID != "5" || got[1].CollectionID != "10" || got[len(got)-1].CollectionID != "2" {
+		t.Errorf("Expected the last one browsed first without duplicates, got %+v", got)
+	}
+	if coll := got[0].Collection(); coll.ID != "5" || coll.OrganizationID != "acme" || coll.Name != "team-5" {
+		t.Errorf("Unexpected collection %+v", coll)
+	}
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// redactedMark replaces every secret written to the log
+const redactedMark = "[REDACTED]"
+
+// minSecretLength keeps very short passwords from blanking out common words
+const minSecretLength = 4
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+
+	// Key material and secrets in well-known argument or field positions,
+	// which are redacted even when they were never registered
+	secretPatterns = []struct {
+		re   *regexp.Regexp
+		repl string
+	}{
+		{regexp.MustCompile(`(?s)-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----.*?(-----END [A-Z0-9 ]*PRIVATE KEY-----|$)`), "[REDACTED PRIVATE KEY]"},
+		{regexp.MustCompile(`(--session[ =])\S+`), "${1}" + redactedMark},
+		{regexp.MustCompile(`((?:BW_SESSION|BW_CLIENTSECRET|VAULT_TOKEN|KEEPASSXC_PASSWORD)=)\S+`), "${1}" + redactedMark},
+		{regexp.MustCompile(`(?i)((?:^|[^a-z0-9_])"?(?:password|passphrase|sudo_password|proxy_password|client_token|secret_id|token)"?\s*[:=]\s*)("[^"]*"|\S+)`), "${1}" + redactedMark},
+	}
+)
+
+// RegisterSecret adds a value, like a password or session key, that is
+// masked wherever it shows up in the log from now on
+func RegisterSecret(secret string) {
+	secret = strings.TrimSpace(secret)
+	if len(secret) < minSecretLength {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	if slices.Contains(secrets, secret) {
+		return
+	}
+	secrets = append(secrets, secret)
+	// Longer secrets first, so one containing another is masked whole
+	slices.SortFunc(secrets, func(a, b string) int { return len(b) - len(a) })
+}
+
+// RegisterConnectionSecrets registers the passwords of a connection
+func RegisterConnectionSecrets(conn SSHConnection) {
+	RegisterSecret(conn.Password)
+	RegisterSecret(conn.SudoPassword)
+	RegisterSecret(conn.ProxyPassword)
+}
+
+// Redact masks registered secrets and private key material in s
+func Redact(s string) string {
+	secretsMu.RLock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, redactedMark)
+	}
+	secretsMu.RUnlock()
+	for _, p := range secretPatterns {
+		s = p.re.ReplaceAllString(s, p.repl)
+	}
+	return s
+}
+
+// redactingWriter redacts everything written through it. The log package
+// writes each entry in a single call, so secrets are never split.
+type redactingWriter struct {
+	w io.Writer
+}
+
+// NewRedactingWriter wraps w, the log output, so secrets never reach it
+func NewRedactingWriter(w io.Writer) io.Writer {
+	return &redactingWriter{w: w}
+}
+
+func (r *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	// Report the original length, the log package treats less as an error
+	return len(p), nil
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const defaultScrollbackDirName = "scrollback"
+
+// ScrollbackStore keeps the terminal scrollback of each connection in
+// ~/.config/ssh-x-term/scrollback, one gzip file per connection, so a new
+// session can scroll back into the output of the earlier ones
+type ScrollbackStore struct {
+	Dir string
+	// MaxBytes caps the size of all the files together; the scrollback of
+	// the connections closed longest ago is dropped first. 0 means no cap.
+	MaxBytes int64
+}
+
+// SavedScrollback is the text of a connection's earlier sessions, oldest
+// line first
+type SavedScrollback struct {
+	Lines []string
+	Saved time.Time
+}
+
+// NewScrollbackStore creates a scrollback store in the default location
+func NewScrollbackStore(maxBytes int64) (*ScrollbackStore, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return &ScrollbackStore{
+		Dir:      filepath.Join(homeDir, ".config", "ssh-x-term", defaultScrollbackDirName),
+		MaxBytes: maxBytes,
+	}, nil
+}
+
+// path names the file by a hash of the connection ID, which may hold
+// characters that aren't allowed in file names
+func (s *ScrollbackStore) path(connID string) string {
+	sum := sha256.Sum256([]byte(connID))
+	return filepath.Join(s.Dir, hex.EncodeToString(sum[:16])+".gz")
+}
+
+// Load reads the saved scrollback of a connection. A connection without
+// one yields nil.
+func (s *ScrollbackStore) Load(connID string) (*SavedScrollback, error) {
+	f, err := os.Open(s.path(connID))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scrollback of %s: %w", connID, err)
+	}
+	defer gz.Close()
+	saved := &SavedScrollback{Saved: gz.ModTime}
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		saved.Lines = append(saved.Lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read scrollback of %s: %w", connID, err)
+	}
+	return saved, nil
+}
+
+// Save replaces the saved scrollback of a connection with lines, then
+// drops the least recently saved files while the store is over MaxBytes
+func (s *ScrollbackStore) Save(connID string, lines []string) error {
+	// Terminal output can hold anything typed at a prompt, so keep it private
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.Dir, ".scrollback-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	gz := gzip.NewWriter(tmp)
+	gz.ModTime = time.Now()
+	w := bufio.NewWriter(gz)
+	for _, line := range lines {
+		w.WriteString(line)
+		w.WriteByte('\n')
+	}
+	err = w.Flush()
+	if err == nil {
+		err = gz.Close()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path(connID))
+	}
+	if err != nil {
+		return err
+	}
+	return s.prune()
+}
+
+// Purge deletes the saved scrollback of a connection
+func (s *ScrollbackStore) Purge(connID string) error {
+	if err := os.Remove(s.path(connID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// prune deletes the least recently saved files until the store fits in
+// MaxBytes
+func (s *ScrollbackStore) prune() error {
+	if s.MaxBytes <= 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return err
+	}
+	var files []os.FileInfo
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".gz") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, info)
+		total += info.Size()
+	}
+	slices.SortFunc(files, func(a, b os.FileInfo) int {
+		return a.ModTime().Compare(b.ModTime())
+	})
+	for _, info := range files {
+		if total <= s.MaxBytes {
+			break
+		}
+		if err := os.Remove(filepath.Join(s.Dir, info.Name())); err != nil {
+			return err
+		}
+		total -= info.Size()
+	}
+	return nil
+}
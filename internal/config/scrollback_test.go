@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestScrollbackStore(t *testing.T) {
+	store := &ScrollbackStore{Dir: t.TempDir()}
+	if saved, err := store.Load("sxt-web"); err != nil || saved != nil {
+		t.Fatalf("Expected no scrollback for a new connection, got %v, %v", saved, err)
+	}
+
+	lines := []string{"$ uptime", " 10:00:00 up 3 days", "", "$ echo héllo 世界"}
+	if err := store.Save("sxt-web", lines); err != nil {
+		t.Fatalf("Failed to save scrollback: %v", err)
+	}
+	saved, err := store.Load("sxt-web")
+	if err != nil || saved == nil {
+		t.Fatalf("Failed to load scrollback: %v", err)
+	}
+	if !slices.Equal(saved.Lines, lines) {
+		t.Errorf("Expected %q, got %q", lines, saved.Lines)
+	}
+	if time.Since(saved.Saved) > time.Minute {
+		t.Errorf("Expected the save time to be kept, got %v", saved.Saved)
+	}
+	info, err := os.Stat(store.path("sxt-web"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the file to be private, got %v", info.Mode().Perm())
+	}
+
+	if err := store.Purge("sxt-web"); err != nil {
+		t.Fatalf("Failed to purge scrollback: %v", err)
+	}
+	if saved, _ := store.Load("sxt-web"); saved != nil {
+		t.Error("Expected the purged scrollback to be gone")
+	}
+	if err := store.Purge("sxt-web"); err != nil {
+		t.Errorf("Expected purging twice to succeed, got %v", err)
+	}
+}
+
+func TestScrollbackStoreCap(t *testing.T) {
+	store := &ScrollbackStore{Dir: t.TempDir()}
+	lines := []string{"$ make", "ok"}
+	if err := store.Save("old", lines); err != nil {
+		t.Fatal(err)
+	}
+	// Make the first file clearly the least recently saved
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(store.path("old"), past, past); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(store.path("old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Room for one file but not two
+	store.MaxBytes = info.Size() * 3 / 2
+	if err := store.Save("new", lines); err != nil {
+		t.Fatal(err)
+	}
+	if saved, _ := store.Load("old"); saved != nil {
+		t.Error("Expected the oldest scrollback to be dropped over the cap")
+	}
+	if saved, _ := store.Load("new"); saved == nil {
+		t.Error("Expected the newest scrollback to be kept")
+	}
+}
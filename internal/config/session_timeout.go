@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSessionTimeout caps the idle and session time limits
+const maxSessionTimeout = 7 * 24 * time.Hour
+
+// IdleLimit returns how long a session may go without input before it is
+// disconnected, 0 for no limit
+func (c SSHConnection) IdleLimit() time.Duration {
+	return time.Duration(c.IdleMinutes) * time.Minute
+}
+
+// SessionLimit returns how long a session may stay connected, 0 for no
+// limit
+func (c SSHConnection) SessionLimit() time.Duration {
+	return time.Duration(c.SessionMinutes) * time.Minute
+}
+
+// ParseSessionTimeouts reads the idle and session time limits, written as
+// IDLE or IDLE/MAX (such as 15m, 15m/8h or 0/4h). Limits are durations of
+// whole minutes; a bare number counts minutes and 0 is no limit. "" is no
+// limit at all.
+func ParseSessionTimeouts(s string) (idleMinutes, maxMinutes int, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, 0, nil
+	}
+	idleText, maxText, _ := strings.Cut(s, "/")
+	idleMinutes, err = parseTimeoutMinutes(idleText)
+	if err == nil {
+		maxMinutes, err = parseTimeoutMinutes(maxText)
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid session timeouts %q (expected IDLE or IDLE/MAX, e.g. 15m or 15m/8h)", s)
+	}
+	return idleMinutes, maxMinutes, nil
+}
+
+// parseTimeoutMinutes reads one limit in minutes, "" and 0 being none
+func parseTimeoutMinutes(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+	if minutes, err := strconv.Atoi(s); err == nil {
+		s = strconv.Itoa(minutes) + "m"
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < time.Minute || d > maxSessionTimeout || d%time.Minute != 0 {
+		return 0, fmt.Errorf("invalid timeout %q", s)
+	}
+	return int(d / time.Minute), nil
+}
+
+// FormatSessionTimeouts writes the limits the way ParseSessionTimeouts
+// reads them, "" for none
+func FormatSessionTimeouts(idleMinutes, maxMinutes int) string {
+	switch {
+	case idleMinutes == 0 && maxMinutes == 0:
+		return ""
+	case maxMinutes == 0:
+		return formatTimeoutMinutes(idleMinutes)
+	default:
+		return formatTimeoutMinutes(idleMinutes) + "/" + formatTimeoutMinutes(maxMinutes)
+	}
+}
+
+// formatTimeoutMinutes writes minutes as 15m, 8h or 1h30m, and 0 as 0
+func formatTimeoutMinutes(minutes int) string {
+	switch {
+	case minutes == 0:
+		return "0"
+	case minutes%60 == 0:
+		return fmt.Sprintf("%dh", minutes/60)
+	case minutes > 60:
+		return fmt.Sprintf("%dh%dm", minutes/60, minutes%60)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSessionTimeouts(t *testing.T) {
+	tests := []struct {
+		in        string
+		idle, max int
+		formatted string
+	}{
+		{"", 0, 0, ""},
+		{"15", 15, 0, "15m"},
+		{" 15m/8h ", 15, 480, "15m/8h"},
+		{"0/90m", 0, 90, "0/1h30m"},
+		{"1h30m/", 90, 0, "1h30m"},
+	}
+	for _, tt := range tests {
+		idle, max, err := ParseSessionTimeouts(tt.in)
+		if err != nil || idle != tt.idle || max != tt.max {
+			t.Errorf("ParseSessionTimeouts(%q) = %d, %d, %v; want %d, %d", tt.in, idle, max, err, tt.idle, tt.max)
+		}
+		if got := FormatSessionTimeouts(idle, max); got != tt.formatted {
+			t.Errorf("FormatSessionTimeouts(%d, %d) = %q; want %q", idle, max, got, tt.formatted)
+		}
+		if againIdle, againMax, _ := ParseSessionTimeouts(tt.formatted); againIdle != idle || againMax != max {
+			t.Errorf("Expected %q to round-trip, got %d, %d", tt.formatted, againIdle, againMax)
+		}
+	}
+	for _, bad := range []string{"x", "-5", "30s", "90s", "15m/x", "9000h"} {
+		if _, _, err := ParseSessionTimeouts(bad); err == nil {
+			t.Errorf("Expected an error parsing %q", bad)
+		}
+	}
+
+	conn := SSHConnection{IdleMinutes: 15, SessionMinutes: 480}
+	if conn.IdleLimit() != 15*time.Minute || conn.SessionLimit() != 8*time.Hour {
+		t.Errorf("Expected 15m idle and 8h session limits, got %s and %s", conn.IdleLimit(), conn.SessionLimit())
+	}
+}
@@ -0,0 +1,623 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const defaultSettingsFileName = "settings.toml"
+
+// Themes are the built-in color themes. "auto" picks dark or light from
+// the terminal's background.
+var Themes = []string{"auto", "dark", "light", "solarized", "dracula", "high-contrast"}
+
+// ThemeColorKeys are the colors a custom theme can set
+var ThemeColorKeys = []string{
+	"primary", "secondary", "accent", "text", "subtext", "error", "inactive",
+	"bar", "selection", "header_text", "string", "success", "warning", "muted",
+}
+
+// CustomTheme is a user-defined palette from a [themes.<name>] table. Colors
+// it leaves out are taken from its base theme.
+type CustomTheme struct {
+	// Base is the built-in theme the palette starts from (default dark)
+	Base string
+	// Colors maps keys of ThemeColorKeys to "#rrggbb" or an ANSI color number
+	Colors map[string]string
+}
+
+var hexColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// StorageBackends that can be opened on startup, in the order the storage
+// selection screen lists them, as registered with RegisterStorageBackend.
+// An empty default means ask every time.
+var StorageBackends []string
+
+// ConnectionSorts are the orders the connection list can use below the
+// pinned connections: the user's own order, most recently connected first,
+// or most often connected first
+var ConnectionSorts = []string{"manual", "recent", "frequent"}
+
+// FileSorts are the orders of the file manager panels: by name, largest
+// first, newest first or by extension
+var FileSorts = []string{"name", "size", "mtime", "extension"}
+
+// ImagePreviews are the ways the file viewer draws images: "auto" picks the
+// kitty graphics protocol or sixel when the terminal supports one, and
+// ASCII art otherwise
+var ImagePreviews = []string{"auto", "kitty", "sixel", "ascii"}
+
+// Multiplexers that can open connections in their own windows or panes.
+// "auto" uses the one sxt runs inside.
+var Multiplexers = []string{"auto", "tmux", "zellij", "wezterm"}
+
+// MultiplexerPlacements are where a connection opened in a multiplexer goes:
+// a new window (a tab in WezTerm, a floating pane in zellij), or a split of
+// the current pane to the right or below
+var MultiplexerPlacements = []string{"window", "split-right", "split-down"}
+
+// DefaultMultiplexerName is the default name of the windows and panes opened
+// for a connection; see Settings.MultiplexerName for the placeholders
+const DefaultMultiplexerName = "{user}@{host}:{port} - {name}"
+
+// Settings are the user's global preferences, stored in
+// ~/.config/ssh-x-term/settings.toml
+type Settings struct {
+	// Path is the file the settings are loaded from and saved to
+	Path string
+
+	// Theme is the name of a built-in or custom color theme
+	Theme string
+	// CustomThemes are the user-defined themes, keyed by name
+	CustomThemes map[string]CustomTheme
+	// DefaultStorage skips the storage selection screen when set
+	DefaultStorage string
+	// DoubleEscTimeoutMs is how long to wait for the second ESC that
+	// leaves a terminal session
+	DoubleEscTimeoutMs int
+	// ScrollbackLines is how many lines each terminal keeps
+	ScrollbackLines int
+	// PersistScrollback saves the scrollback of each connection when its
+	// session ends and shows it above the output of the next session
+	PersistScrollback bool
+	// PersistScrollbackMB caps the disk space the saved scrollback of all
+	// connections takes together
+	PersistScrollbackMB int
+	// KeepaliveSeconds is the interval between SSH keepalives, 0 disables
+	KeepaliveSeconds int
+	// ResourceMonitorSeconds is how often the CPU, memory, load and disk
+	// usage of the connected host are refreshed in the terminal header;
+	// 0 leaves the monitor off until alt+m turns it on
+	ResourceMonitorSeconds int
+	// ConnectionSort is how the connection list is ordered, one of
+	// ConnectionSorts
+	ConnectionSort string
+	// ConfirmDelete asks before deleting a connection
+	ConfirmDelete bool
+	// RememberFileDirs reopens the file manager of a connection in the
+	// directories it was left in, instead of its start paths
+	RememberFileDirs bool
+	// ShowHiddenFiles lists dotfiles in the file manager panels
+	ShowHiddenFiles bool
+	// FileSort is how the file manager panels are ordered, one of FileSorts
+	FileSort string
+	// DirsFirst lists directories before files in the file manager panels
+	DirsFirst bool
+	// RemoteTrashDir is where the file manager moves deleted remote files,
+	// "~/" being the login directory; empty deletes them for good
+	RemoteTrashDir string
+	// WatchIgnore are the comma-separated patterns of the files and
+	// directories that watching a directory in the file manager skips
+	WatchIgnore string
+	// ImagePreview is how the file viewer draws images, one of
+	// ImagePreviews
+	ImagePreview string
+	// Multiplexer is one of Multiplexers
+	Multiplexer string
+	// MultiplexerOpenNew opens connections in the multiplexer instead of
+	// the built-in terminal by default when one is available; o toggles it
+	MultiplexerOpenNew bool
+	// MultiplexerPlacement is one of MultiplexerPlacements
+	MultiplexerPlacement string
+	// MultiplexerName names the window or pane; {name}, {user}, {host} and
+	// {port} are replaced with the connection's values
+	MultiplexerName string
+	// BitwardenSessionTTLMinutes is how long the Bitwarden session is kept
+	// in the OS keyring across launches, 0 disables the cache
+	BitwardenSessionTTLMinutes int
+	// BitwardenSyncIntervalMinutes is how often the vault is synced in the
+	// background while the connection list is open, 0 disables it
+	BitwardenSyncIntervalMinutes int
+	// BitwardenMirrorSSHConfig keeps a copy of the Bitwarden connections,
+	// without secrets, in a managed block of ~/.ssh/config
+	BitwardenMirrorSSHConfig bool
+	// LogEnabled turns the debug log on or off
+	LogEnabled bool
+	// LogFile overrides the log location; SSH_X_TERM_LOG still wins
+	LogFile string
+	// MetricsAddress is the local host:port serving the session and
+	// transfer counts at /metrics and /stats.json; empty turns it off
+	MetricsAddress string
+	// StatsFile is a JSON file the same counts are written to every
+	// StatsIntervalSeconds; empty turns it off
+	StatsFile string
+	// StatsIntervalSeconds is how often StatsFile is rewritten
+	StatsIntervalSeconds int
+	// HookConnected, HookDisconnected and HookTransferDone are shell
+	// commands run when a session connects, when it drops without being
+	// closed and when a long transfer ends; empty runs nothing
+	HookConnected    string
+	HookDisconnected string
+	HookTransferDone string
+	// HookNotify shows a desktop notification for the same events
+	HookNotify bool
+	// HookTransferMinSeconds is how long a transfer must take to count as
+	// long
+	HookTransferMinSeconds int
+}
+
+// DefaultSettings returns the preferences used when nothing is configured
+func DefaultSettings() Settings {
+	return Settings{
+		Theme:                        "auto",
+		DoubleEscTimeoutMs:           2000,
+		ScrollbackLines:              10000,
+		PersistScrollbackMB:          50,
+		ConnectionSort:               "manual",
+		ConfirmDelete:                true,
+		ShowHiddenFiles:              true,
+		FileSort:                     "name",
+		DirsFirst:                    true,
+		RemoteTrashDir:               "~/.sxt-trash",
+		WatchIgnore:                  ".git, node_modules",
+		ImagePreview:                 "auto",
+		Multiplexer:                  "auto",
+		MultiplexerOpenNew:           true,
+		MultiplexerPlacement:         "window",
+		MultiplexerName:              DefaultMultiplexerName,
+		BitwardenSyncIntervalMinutes: 5,
+		LogEnabled:                   true,
+		StatsIntervalSeconds:         60,
+		HookTransferMinSeconds:       30,
+	}
+}
+
+var (
+	currentSettings   = DefaultSettings()
+	currentSettingsMu sync.RWMutex
+)
+
+// CurrentSettings returns the settings in effect for this process
+func CurrentSettings() Settings {
+	currentSettingsMu.RLock()
+	defer currentSettingsMu.RUnlock()
+	return currentSettings
+}
+
+// SetCurrentSettings makes s the settings in effect for this process
+func SetCurrentSettings(s Settings) {
+	currentSettingsMu.Lock()
+	defer currentSettingsMu.Unlock()
+	currentSettings = s
+}
+
+// DefaultSettingsPath returns ~/.config/ssh-x-term/settings.toml
+func DefaultSettingsPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".config", "ssh-x-term", defaultSettingsFileName), nil
+}
+
+// LoadSettings reads the settings file at path. A missing file yields the
+// defaults; keys missing from the file keep their default values.
+func LoadSettings(path string) (Settings, error) {
+	s := DefaultSettings()
+	s.Path = path
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return s, err
+	}
+	if err := s.parse(data); err != nil {
+		return s, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return s, s.Validate()
+}
+
+// Validate checks that every value is in range
+func (s Settings) Validate() error {
+	if _, custom := s.CustomThemes[s.Theme]; !custom && !slices.Contains(Themes, s.Theme) {
+		return fmt.Errorf("unknown theme %q (expected one of %s)", s.Theme, strings.Join(s.ThemeNames(), ", "))
+	}
+	for name, theme := range s.CustomThemes {
+		if slices.Contains(Themes, name) {
+			return fmt.Errorf("custom theme %q has the name of a built-in theme", name)
+		}
+		if theme.Base != "" && (theme.Base == "auto" || !slices.Contains(Themes, theme.Base)) {
+			return fmt.Errorf("theme %q: unknown base theme %q", name, theme.Base)
+		}
+		for key, color := range theme.Colors {
+			if !slices.Contains(ThemeColorKeys, key) {
+				return fmt.Errorf("theme %q: unknown color %q", name, key)
+			}
+			if !validColor(color) {
+				return fmt.Errorf("theme %q: invalid color %q for %s (use #rrggbb or 0-255)", name, color, key)
+			}
+		}
+	}
+	if s.DefaultStorage != "" && !slices.Contains(StorageBackends, s.DefaultStorage) {
+		return fmt.Errorf("unknown default_storage %q (expected one of %s)", s.DefaultStorage, strings.Join(StorageBackends, ", "))
+	}
+	if !slices.Contains(ConnectionSorts, s.ConnectionSort) {
+		return fmt.Errorf("unknown connection_sort %q (expected one of %s)", s.ConnectionSort, strings.Join(ConnectionSorts, ", "))
+	}
+	if !slices.Contains(FileSorts, s.FileSort) {
+		return fmt.Errorf("unknown files.sort %q (expected one of %s)", s.FileSort, strings.Join(FileSorts, ", "))
+	}
+	if !slices.Contains(ImagePreviews, s.ImagePreview) {
+		return fmt.Errorf("unknown files.image_preview %q (expected one of %s)", s.ImagePreview, strings.Join(ImagePreviews, ", "))
+	}
+	if !slices.Contains(Multiplexers, s.Multiplexer) {
+		return fmt.Errorf("unknown multiplexer.use %q (expected one of %s)", s.Multiplexer, strings.Join(Multiplexers, ", "))
+	}
+	if !slices.Contains(MultiplexerPlacements, s.MultiplexerPlacement) {
+		return fmt.Errorf("unknown multiplexer.placement %q (expected one of %s)", s.MultiplexerPlacement, strings.Join(MultiplexerPlacements, ", "))
+	}
+	if strings.TrimSpace(s.MultiplexerName) == "" {
+		return errors.New("multiplexer.name cannot be empty")
+	}
+	if s.DoubleEscTimeoutMs < 100 || s.DoubleEscTimeoutMs > 10000 {
+		return fmt.Errorf("double_esc_timeout_ms must be between 100 and 10000, got %d", s.DoubleEscTimeoutMs)
+	}
+	if s.ScrollbackLines < 0 || s.ScrollbackLines > 100000 {
+		return fmt.Errorf("scrollback_lines must be between 0 and 100000, got %d", s.ScrollbackLines)
+	}
+	if s.PersistScrollbackMB < 1 || s.PersistScrollbackMB > 10000 {
+		return fmt.Errorf("persist_scrollback_mb must be between 1 and 10000, got %d", s.PersistScrollbackMB)
+	}
+	if s.KeepaliveSeconds < 0 {
+		return fmt.Errorf("keepalive_seconds cannot be negative, got %d", s.KeepaliveSeconds)
+	}
+	if s.ResourceMonitorSeconds < 0 || s.ResourceMonitorSeconds > 3600 {
+		return fmt.Errorf("resource_monitor_seconds must be between 0 and 3600, got %d", s.ResourceMonitorSeconds)
+	}
+	if s.BitwardenSessionTTLMinutes < 0 {
+		return fmt.Errorf("bitwarden.session_ttl_minutes cannot be negative, got %d", s.BitwardenSessionTTLMinutes)
+	}
+	if s.BitwardenSyncIntervalMinutes < 0 {
+		return fmt.Errorf("bitwarden.sync_interval_minutes cannot be negative, got %d", s.BitwardenSyncIntervalMinutes)
+	}
+	if s.MetricsAddress != "" {
+		if _, _, err := net.SplitHostPort(s.MetricsAddress); err != nil {
+			return fmt.Errorf("metrics.address must be host:port, got %q", s.MetricsAddress)
+		}
+	}
+	if s.StatsIntervalSeconds < 1 || s.StatsIntervalSeconds > 86400 {
+		return fmt.Errorf("metrics.stats_interval_seconds must be between 1 and 86400, got %d", s.StatsIntervalSeconds)
+	}
+	if s.HookTransferMinSeconds < 0 {
+		return fmt.Errorf("hooks.transfer_min_seconds cannot be negative, got %d", s.HookTransferMinSeconds)
+	}
+	return nil
+}
+
+// ThemeNames lists the built-in themes followed by the custom ones
+func (s Settings) ThemeNames() []string {
+	names := slices.Clone(Themes)
+	custom := make([]string, 0, len(s.CustomThemes))
+	for name := range s.CustomThemes {
+		custom = append(custom, name)
+	}
+	slices.Sort(custom)
+	return append(names, custom...)
+}
+
+// validColor accepts "#rrggbb" and ANSI color numbers
+func validColor(color string) bool {
+	if hexColorPattern.MatchString(color) {
+		return true
+	}
+	n, err := strconv.Atoi(color)
+	return err == nil && n >= 0 && n <= 255
+}
+
+// EscTimeoutSecs returns the double ESC timeout in seconds
+func (s Settings) EscTimeoutSecs() float64 {
+	return float64(s.DoubleEscTimeoutMs) / 1000
+}
+
+// Save writes the settings file, creating its directory if needed
+func (s Settings) Save() error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.Path, s.encode(), 0600); err != nil {
+		log.Printf("Failed to write settings file: %v", err)
+		return err
+	}
+	return nil
+}
+
+// encode renders the settings as TOML
+func (s Settings) encode() []byte {
+	var b bytes.Buffer
+	b.WriteString("# ssh-x-term settings, editable from the settings screen (,)\n\n")
+	fmt.Fprintf(&b, "theme = %s\n", strconv.Quote(s.Theme))
+	fmt.Fprintf(&b, "default_storage = %s\n", strconv.Quote(s.DefaultStorage))
+	fmt.Fprintf(&b, "double_esc_timeout_ms = %d\n", s.DoubleEscTimeoutMs)
+	fmt.Fprintf(&b, "scrollback_lines = %d\n", s.ScrollbackLines)
+	fmt.Fprintf(&b, "persist_scrollback = %t\n", s.PersistScrollback)
+	fmt.Fprintf(&b, "persist_scrollback_mb = %d\n", s.PersistScrollbackMB)
+	fmt.Fprintf(&b, "keepalive_seconds = %d\n", s.KeepaliveSeconds)
+	fmt.Fprintf(&b, "resource_monitor_seconds = %d\n", s.ResourceMonitorSeconds)
+	fmt.Fprintf(&b, "connection_sort = %s\n", strconv.Quote(s.ConnectionSort))
+	fmt.Fprintf(&b, "confirm_delete = %t\n", s.ConfirmDelete)
+	b.WriteString("\n[files]\n")
+	fmt.Fprintf(&b, "remember_dirs = %t\n", s.RememberFileDirs)
+	fmt.Fprintf(&b, "show_hidden = %t\n", s.ShowHiddenFiles)
+	fmt.Fprintf(&b, "sort = %s\n", strconv.Quote(s.FileSort))
+	fmt.Fprintf(&b, "dirs_first = %t\n", s.DirsFirst)
+	fmt.Fprintf(&b, "remote_trash = %s\n", strconv.Quote(s.RemoteTrashDir))
+	fmt.Fprintf(&b, "watch_ignore = %s\n", strconv.Quote(s.WatchIgnore))
+	fmt.Fprintf(&b, "image_preview = %s\n", strconv.Quote(s.ImagePreview))
+	b.WriteString("\n[multiplexer]\n")
+	fmt.Fprintf(&b, "use = %s\n", strconv.Quote(s.Multiplexer))
+	fmt.Fprintf(&b, "open_new = %t\n", s.MultiplexerOpenNew)
+	fmt.Fprintf(&b, "placement = %s\n", strconv.Quote(s.MultiplexerPlacement))
+	fmt.Fprintf(&b, "name = %s\n", strconv.Quote(s.MultiplexerName))
+	b.WriteString("\n[bitwarden]\n")
+	fmt.Fprintf(&b, "session_ttl_minutes = %d\n", s.BitwardenSessionTTLMinutes)
+	fmt.Fprintf(&b, "sync_interval_minutes = %d\n", s.BitwardenSyncIntervalMinutes)
+	fmt.Fprintf(&b, "mirror_ssh_config = %t\n", s.BitwardenMirrorSSHConfig)
+	b.WriteString("\n[log]\n")
+	fmt.Fprintf(&b, "enabled = %t\n", s.LogEnabled)
+	fmt.Fprintf(&b, "file = %s\n", strconv.Quote(s.LogFile))
+	b.WriteString("\n[metrics]\n")
+	fmt.Fprintf(&b, "address = %s\n", strconv.Quote(s.MetricsAddress))
+	fmt.Fprintf(&b, "stats_file = %s\n", strconv.Quote(s.StatsFile))
+	fmt.Fprintf(&b, "stats_interval_seconds = %d\n", s.StatsIntervalSeconds)
+	b.WriteString("\n[hooks]\n")
+	fmt.Fprintf(&b, "connected = %s\n", strconv.Quote(s.HookConnected))
+	fmt.Fprintf(&b, "disconnected = %s\n", strconv.Quote(s.HookDisconnected))
+	fmt.Fprintf(&b, "transfer_done = %s\n", strconv.Quote(s.HookTransferDone))
+	fmt.Fprintf(&b, "notify = %t\n", s.HookNotify)
+	fmt.Fprintf(&b, "transfer_min_seconds = %d\n", s.HookTransferMinSeconds)
+
+	for _, name := range s.ThemeNames()[len(Themes):] {
+		theme := s.CustomThemes[name]
+		fmt.Fprintf(&b, "\n[themes.%s]\n", tomlKey(name))
+		if theme.Base != "" {
+			fmt.Fprintf(&b, "base = %s\n", strconv.Quote(theme.Base))
+		}
+		for _, key := range ThemeColorKeys {
+			if color, ok := theme.Colors[key]; ok {
+				fmt.Fprintf(&b, "%s = %s\n", key, strconv.Quote(color))
+			}
+		}
+	}
+	return b.Bytes()
+}
+
+// parse reads the subset of TOML the settings file uses: comments, the
+// [files], [multiplexer] (or the older [tmux]), [bitwarden], [log],
+// [metrics], [hooks] and [themes.<name>] tables and key = value pairs holding strings, integers
+// and booleans.
+// Unknown keys are logged and ignored so newer files still load.
+func (s *Settings) parse(data []byte) error {
+	table := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(stripTOMLComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return fmt.Errorf("line %d: malformed table header", lineNo)
+			}
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			if name, ok := strings.CutPrefix(table, "themes."); ok {
+				name, err := parseTOMLKey(name)
+				if err != nil {
+					return fmt.Errorf("line %d: %w", lineNo, err)
+				}
+				table = "themes." + name
+				if s.CustomThemes == nil {
+					s.CustomThemes = make(map[string]CustomTheme)
+				}
+				s.CustomThemes[name] = CustomTheme{Colors: make(map[string]string)}
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		key = strings.TrimSpace(key)
+		if table != "" {
+			key = table + "." + key
+		}
+		if err := s.set(key, strings.TrimSpace(value)); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *Settings) set(key, value string) error {
+	if rest, ok := strings.CutPrefix(key, "themes."); ok {
+		// Theme names may contain dots only when quoted, so the color key
+		// is what follows the last one
+		i := strings.LastIndex(rest, ".")
+		if i < 0 {
+			return fmt.Errorf("%s must be in a [themes.<name>] table", key)
+		}
+		name, colorKey := rest[:i], rest[i+1:]
+		theme, ok := s.CustomThemes[name]
+		if !ok {
+			return fmt.Errorf("%s must be in a [themes.<name>] table", key)
+		}
+		color, err := parseTOMLString(value)
+		if err != nil {
+			return fmt.Errorf("invalid value for %s: %s", key, value)
+		}
+		if colorKey == "base" {
+			theme.Base = color
+			s.CustomThemes[name] = theme
+		} else {
+			theme.Colors[colorKey] = color
+		}
+		return nil
+	}
+
+	var err error
+	switch key {
+	case "theme":
+		s.Theme, err = parseTOMLString(value)
+	case "default_storage":
+		s.DefaultStorage, err = parseTOMLString(value)
+	case "double_esc_timeout_ms":
+		s.DoubleEscTimeoutMs, err = strconv.Atoi(value)
+	case "scrollback_lines":
+		s.ScrollbackLines, err = strconv.Atoi(value)
+	case "persist_scrollback":
+		s.PersistScrollback, err = strconv.ParseBool(value)
+	case "persist_scrollback_mb":
+		s.PersistScrollbackMB, err = strconv.Atoi(value)
+	case "keepalive_seconds":
+		s.KeepaliveSeconds, err = strconv.Atoi(value)
+	case "resource_monitor_seconds":
+		s.ResourceMonitorSeconds, err = strconv.Atoi(value)
+	case "connection_sort":
+		s.ConnectionSort, err = parseTOMLString(value)
+	case "confirm_delete":
+		s.ConfirmDelete, err = strconv.ParseBool(value)
+	case "files.remember_dirs":
+		s.RememberFileDirs, err = strconv.ParseBool(value)
+	case "files.show_hidden":
+		s.ShowHiddenFiles, err = strconv.ParseBool(value)
+	case "files.sort":
+		s.FileSort, err = parseTOMLString(value)
+	case "files.dirs_first":
+		s.DirsFirst, err = strconv.ParseBool(value)
+	case "files.remote_trash":
+		s.RemoteTrashDir, err = parseTOMLString(value)
+	case "files.watch_ignore":
+		s.WatchIgnore, err = parseTOMLString(value)
+	case "files.image_preview":
+		s.ImagePreview, err = parseTOMLString(value)
+	case "multiplexer.use":
+		s.Multiplexer, err = parseTOMLString(value)
+	// The [tmux] table predates zellij and WezTerm support
+	case "multiplexer.open_new", "tmux.open_new":
+		s.MultiplexerOpenNew, err = strconv.ParseBool(value)
+	case "multiplexer.placement", "tmux.placement":
+		s.MultiplexerPlacement, err = parseTOMLString(value)
+	case "multiplexer.name", "tmux.name":
+		s.MultiplexerName, err = parseTOMLString(value)
+	case "bitwarden.session_ttl_minutes":
+		s.BitwardenSessionTTLMinutes, err = strconv.Atoi(value)
+	case "bitwarden.sync_interval_minutes":
+		s.BitwardenSyncIntervalMinutes, err = strconv.Atoi(value)
+	case "bitwarden.mirror_ssh_config":
+		s.BitwardenMirrorSSHConfig, err = strconv.ParseBool(value)
+	case "log.enabled":
+		s.LogEnabled, err = strconv.ParseBool(value)
+	case "log.file":
+		s.LogFile, err = parseTOMLString(value)
+	case "metrics.address":
+		s.MetricsAddress, err = parseTOMLString(value)
+	case "metrics.stats_file":
+		s.StatsFile, err = parseTOMLString(value)
+	case "metrics.stats_interval_seconds":
+		s.StatsIntervalSeconds, err = strconv.Atoi(value)
+	case "hooks.connected":
+		s.HookConnected, err = parseTOMLString(value)
+	case "hooks.disconnected":
+		s.HookDisconnected, err = parseTOMLString(value)
+	case "hooks.transfer_done":
+		s.HookTransferDone, err = parseTOMLString(value)
+	case "hooks.notify":
+		s.HookNotify, err = strconv.ParseBool(value)
+	case "hooks.transfer_min_seconds":
+		s.HookTransferMinSeconds, err = strconv.Atoi(value)
+	default:
+		log.Printf("Ignoring unknown setting %q", key)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %s", key, value)
+	}
+	return nil
+}
+
+// parseTOMLString accepts basic ("...") and literal ('...') strings
+func parseTOMLString(value string) (string, error) {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1], nil
+	}
+	return strconv.Unquote(value)
+}
+
+// tomlKey quotes a table key unless it is a bare key
+func tomlKey(key string) string {
+	if bareKeyPattern.MatchString(key) {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+// parseTOMLKey accepts a bare or quoted key
+func parseTOMLKey(key string) (string, error) {
+	key = strings.TrimSpace(key)
+	if bareKeyPattern.MatchString(key) {
+		return key, nil
+	}
+	name, err := parseTOMLString(key)
+	if err != nil || name == "" {
+		return "", fmt.Errorf("invalid table name %q", key)
+	}
+	return name, nil
+}
+
+var bareKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// stripTOMLComment drops a # comment that is not inside a string
+func stripTOMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
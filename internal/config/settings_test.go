@@ -0,0 +1,133 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSettingsRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.toml")
+	loaded, err := LoadSettings(path)
+	if err != nil {
+		t.Fatalf("Expected missing file to load as defaults, got %v", err)
+	}
+	defaults := DefaultSettings()
+	defaults.Path = path
+	if !reflect.DeepEqual(loaded, defaults) {
+		t.Errorf("Expected defaults %+v, got %+v", defaults, loaded)
+	}
+
+	settings := loaded
+	settings.Theme = "my.team"
+	settings.DefaultStorage = "keepassxc"
+	settings.DoubleEscTimeoutMs = 750
+	settings.ScrollbackLines = 500
+	settings.PersistScrollback = true
+	settings.PersistScrollbackMB = 200
+	settings.KeepaliveSeconds = 30
+	settings.ResourceMonitorSeconds = 10
+	settings.ConnectionSort = "recent"
+	settings.ConfirmDelete = false
+	settings.RememberFileDirs = true
+	settings.ShowHiddenFiles = false
+	settings.FileSort = "mtime"
+	settings.DirsFirst = false
+	settings.RemoteTrashDir = "/srv/trash"
+	settings.WatchIgnore = ".git, *.swp"
+	settings.ImagePreview = "sixel"
+	settings.Multiplexer = "zellij"
+	settings.MultiplexerOpenNew = false
+	settings.MultiplexerPlacement = "split-down"
+	settings.MultiplexerName = "{name} ({host})"
+	settings.BitwardenSessionTTLMinutes = 480
+	settings.BitwardenSyncIntervalMinutes = 0
+	settings.BitwardenMirrorSSHConfig = true
+	settings.LogEnabled = false
+	settings.LogFile = `C:\logs\sxt "debug".log`
+	settings.MetricsAddress = "127.0.0.1:9464"
+	settings.StatsFile = "~/.cache/sxt-stats.json"
+	settings.StatsIntervalSeconds = 15
+	settings.HookConnected = `tmux display-message "$SXT_NAME up"`
+	settings.HookTransferDone = "paplay ~/done.oga"
+	settings.HookNotify = true
+	settings.HookTransferMinSeconds = 120
+	settings.CustomThemes = map[string]CustomTheme{
+		"nord":    {Base: "dark", Colors: map[string]string{"primary": "#88C0D0", "bar": "236"}},
+		"my.team": {Colors: map[string]string{"error": "#BF616A"}},
+	}
+	if err := settings.Save(); err != nil {
+		t.Fatalf("Failed to save settings: %v", err)
+	}
+
+	reloaded, err := LoadSettings(path)
+	if err != nil {
+		t.Fatalf("Failed to reload settings: %v", err)
+	}
+	if !reflect.DeepEqual(reloaded, settings) {
+		t.Errorf("Expected %+v after reload, got %+v", settings, reloaded)
+	}
+}
+
+func TestLoadSettingsHandWritten(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.toml")
+	content := `# my settings
+theme = 'high-contrast'   # easier to read
+keepalive_seconds = 15
+future_option = "ignored"
+
+[tmux]
+placement = "split-right"
+
+[log]
+file = "/tmp/sxt#1.log"
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := LoadSettings(path)
+	if err != nil {
+		t.Fatalf("Failed to load settings: %v", err)
+	}
+	if settings.Theme != "high-contrast" || settings.KeepaliveSeconds != 15 {
+		t.Errorf("Unexpected settings %+v", settings)
+	}
+	if settings.LogFile != "/tmp/sxt#1.log" {
+		t.Errorf("Expected a # inside a string to be kept, got %q", settings.LogFile)
+	}
+	if settings.MultiplexerPlacement != "split-right" {
+		t.Errorf("Expected the legacy [tmux] table to be read, got %q", settings.MultiplexerPlacement)
+	}
+	if settings.ScrollbackLines != 10000 || !settings.ConfirmDelete {
+		t.Errorf("Expected missing keys to keep their defaults, got %+v", settings)
+	}
+
+	for _, bad := range []string{
+		"theme = \"neon\"\n",
+		"scrollback_lines = lots\n",
+		"double_esc_timeout_ms = 5\n",
+		"resource_monitor_seconds = -1\n",
+		"persist_scrollback_mb = 0\n",
+		"connection_sort = \"alphabetical\"\n",
+		"[log\n",
+		"[multiplexer]\nuse = \"screen\"\n",
+		"[multiplexer]\nplacement = \"tab\"\n",
+		"[tmux]\nname = \"\"\n",
+		"[bitwarden]\nsession_ttl_minutes = -1\n",
+		"[bitwarden]\nsync_interval_minutes = -5\n",
+		"[themes.x]\nprimary = \"purple\"\n",
+		"[themes.x]\nsparkle = \"#FFFFFF\"\n",
+		"[themes.dracula]\nprimary = \"1\"\n",
+		"[themes.x]\nbase = \"x\"\n",
+		"[themes]\nprimary = \"1\"\n",
+	} {
+		if err := os.WriteFile(path, []byte(bad), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadSettings(path); err == nil {
+			t.Errorf("Expected an error loading %q", bad)
+		}
+	}
+}
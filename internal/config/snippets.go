@@ -0,0 +1,170 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+const defaultSnippetsFileName = "snippets.json"
+
+// Snippet is a named command that can be sent to a terminal session.
+// Commands may contain {{name}} placeholders that are filled in before
+// sending.
+type Snippet struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	// ConnectionID limits the snippet to one connection; empty means global
+	ConnectionID string `json:"connection_id,omitempty"`
+}
+
+// IsGlobal reports whether the snippet is offered for every connection
+func (s Snippet) IsGlobal() bool {
+	return s.ConnectionID == ""
+}
+
+// SnippetStore keeps command snippets in ~/.config/ssh-x-term/snippets.json.
+// Snippets are local to the machine regardless of the connection backend.
+type SnippetStore struct {
+	Path     string    `json:"-"`
+	Snippets []Snippet `json:"snippets"`
+}
+
+// NewSnippetStore creates a snippet store in the default location
+func NewSnippetStore() (*SnippetStore, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return &SnippetStore{
+		Path: filepath.Join(homeDir, ".config", "ssh-x-term", defaultSnippetsFileName),
+	}, nil
+}
+
+// Load reads the snippets file. A missing file yields an empty store.
+func (s *SnippetStore) Load() error {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			s.Snippets = nil
+			return nil
+		}
+		return err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", s.Path, err)
+	}
+	return nil
+}
+
+// Save writes the snippets file, creating its directory if needed
+func (s *SnippetStore) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.Path, data, 0600); err != nil {
+		log.Printf("Failed to write snippets file: %v", err)
+		return err
+	}
+	return nil
+}
+
+// AddSnippet stores a new snippet, assigning it an ID
+func (s *SnippetStore) AddSnippet(snippet Snippet) (Snippet, error) {
+	if err := validateSnippet(snippet); err != nil {
+		return Snippet{}, err
+	}
+	snippet.ID = generateID()
+	s.Snippets = append(s.Snippets, snippet)
+	return snippet, s.Save()
+}
+
+// EditSnippet replaces the snippet with the same ID
+func (s *SnippetStore) EditSnippet(snippet Snippet) error {
+	if err := validateSnippet(snippet); err != nil {
+		return err
+	}
+	i := slices.IndexFunc(s.Snippets, func(existing Snippet) bool { return existing.ID == snippet.ID })
+	if i < 0 {
+		return fmt.Errorf("snippet %s not found", snippet.ID)
+	}
+	s.Snippets[i] = snippet
+	return s.Save()
+}
+
+// DeleteSnippet removes the snippet with the given ID
+func (s *SnippetStore) DeleteSnippet(id string) error {
+	i := slices.IndexFunc(s.Snippets, func(existing Snippet) bool { return existing.ID == id })
+	if i < 0 {
+		return fmt.Errorf("snippet %s not found", id)
+	}
+	s.Snippets = slices.Delete(s.Snippets, i, i+1)
+	return s.Save()
+}
+
+// ForConnection returns the snippets offered for a connection: its own
+// snippets first, then the global ones, each group sorted by name
+func (s *SnippetStore) ForConnection(connectionID string) []Snippet {
+	var own, global []Snippet
+	for _, snippet := range s.Snippets {
+		switch {
+		case snippet.IsGlobal():
+			global = append(global, snippet)
+		case snippet.ConnectionID == connectionID:
+			own = append(own, snippet)
+		}
+	}
+	byName := func(a, b Snippet) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	}
+	slices.SortStableFunc(own, byName)
+	slices.SortStableFunc(global, byName)
+	return append(own, global...)
+}
+
+func validateSnippet(snippet Snippet) error {
+	if strings.TrimSpace(snippet.Name) == "" {
+		return errors.New("snippet name is required")
+	}
+	if strings.TrimSpace(snippet.Command) == "" {
+		return errors.New("snippet command is required")
+	}
+	return nil
+}
+
+var snippetPlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// SnippetPlaceholders returns the distinct placeholder names in command,
+// in order of first appearance
+func SnippetPlaceholders(command string) []string {
+	var names []string
+	for _, match := range snippetPlaceholder.FindAllStringSubmatch(command, -1) {
+		if !slices.Contains(names, match[1]) {
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+// ExpandSnippet substitutes placeholder values into command. Placeholders
+// without a value are left as they are.
+func ExpandSnippet(command string, values map[string]string) string {
+	return snippetPlaceholder.ReplaceAllStringFunc(command, func(match string) string {
+		name := snippetPlaceholder.FindStringSubmatch(match)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return match
+	})
+}
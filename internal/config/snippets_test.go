@@ -0,0 +1,74 @@
+package config
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSnippetStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snippets.json")
+	store := &SnippetStore{Path: path}
+	if err := store.Load(); err != nil {
+		t.Fatalf("Expected missing file to load as empty, got %v", err)
+	}
+
+	for _, snippet := range []Snippet{
+		{Name: "tail logs", Command: "tail -f /var/log/syslog"},
+		{Name: "restart", Command: "sudo systemctl restart {{unit}}", ConnectionID: "sxt-web"},
+		{Name: "disk usage", Command: "df -h"},
+		{Name: "db shell", Command: "psql", ConnectionID: "sxt-db"},
+	} {
+		if _, err := store.AddSnippet(snippet); err != nil {
+			t.Fatalf("Failed to add snippet %q: %v", snippet.Name, err)
+		}
+	}
+
+	if _, err := store.AddSnippet(Snippet{Name: "empty"}); err == nil {
+		t.Error("Expected an error for a snippet without a command")
+	}
+
+	reloaded := &SnippetStore{Path: path}
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Failed to reload snippets: %v", err)
+	}
+
+	var names []string
+	for _, snippet := range reloaded.ForConnection("sxt-web") {
+		names = append(names, snippet.Name)
+	}
+	want := []string{"restart", "disk usage", "tail logs"}
+	if !slices.Equal(names, want) {
+		t.Errorf("Expected %v for sxt-web, got %v", want, names)
+	}
+
+	restart := reloaded.ForConnection("sxt-web")[0]
+	restart.Command = "sudo systemctl restart nginx"
+	if err := reloaded.EditSnippet(restart); err != nil {
+		t.Fatalf("Failed to edit snippet: %v", err)
+	}
+	if err := reloaded.DeleteSnippet(restart.ID); err != nil {
+		t.Fatalf("Failed to delete snippet: %v", err)
+	}
+	if len(reloaded.ForConnection("sxt-web")) != 2 {
+		t.Errorf("Expected only global snippets after delete, got %+v", reloaded.ForConnection("sxt-web"))
+	}
+	if err := reloaded.DeleteSnippet(restart.ID); err == nil {
+		t.Error("Expected an error deleting a missing snippet")
+	}
+}
+
+func TestSnippetPlaceholders(t *testing.T) {
+	command := "ssh {{ host }} -p {{port}} 'grep {{pattern}} {{host}}.log'"
+
+	names := SnippetPlaceholders(command)
+	if want := []string{"host", "port", "pattern"}; !slices.Equal(names, want) {
+		t.Errorf("Expected placeholders %v, got %v", want, names)
+	}
+
+	got := ExpandSnippet(command, map[string]string{"host": "web", "port": "22"})
+	want := "ssh web -p 22 'grep {{pattern}} web.log'"
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
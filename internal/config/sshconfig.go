@@ -1,14 +1,15 @@
 package config
 
 import (
-	"bufio"
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -26,6 +27,24 @@ const (
 type SSHConfigManager struct {
 	ConfigPath string
 	Config     *Config
+	untagged   map[string]*untaggedEntry // Host entries read without an #sxt:id, by the ID they were given
+	removed    map[string]bool           // Connections deleted since the last write, whose entries go on save
+}
+
+// untaggedEntry is a Host entry written by hand, without #sxt: tags. It is
+// kept as written until its connection is edited.
+type untaggedEntry struct {
+	source string
+	read   SSHConnection // The connection as read from the entry
+	edited bool          // Set when it has to be tagged anyway, as secrets are stored under the ID
+}
+
+// keepUntagged reports whether the untagged entry of conn can be left as
+// written: conn is still what was read from it. Connect counts, kept in the
+// connection usage file, are not worth tagging the entry for.
+func (e *untaggedEntry) keepUntagged(conn SSHConnection) bool {
+	conn.LastConnected, conn.ConnectCount = e.read.LastConnected, e.read.ConnectCount
+	return !e.edited && reflect.DeepEqual(conn, e.read)
 }
 
 func NewSSHConfigManager() (*SSHConfigManager, error) {
@@ -51,179 +70,224 @@ func NewSSHConfigManager() (*SSHConfigManager, error) {
 
 // parseSSHConfig parses the SSH config file and extracts connections
 func (scm *SSHConfigManager) parseSSHConfig() error {
-	file, err := os.Open(scm.ConfigPath)
+	data, err := os.ReadFile(scm.ConfigPath)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return nil
 		}
 		return err
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	var currentConn *SSHConnection
-	var sxtMetadata map[string]string
 	connections := []SSHConnection{}
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		// Parse sxt metadata comments
-		if strings.HasPrefix(line, sxtCommentPrefix) {
-			if sxtMetadata == nil {
-				sxtMetadata = make(map[string]string)
-			}
-			metadata := strings.TrimPrefix(line, sxtCommentPrefix)
-			parts := strings.SplitN(metadata, "=", 2)
-			if len(parts) == 2 {
-				key := strings.TrimSpace(parts[0])
-				value := strings.TrimSpace(parts[1])
-				sxtMetadata[key] = value
-			}
+	scm.untagged = make(map[string]*untaggedEntry)
+	scm.removed = nil
+	// Only Host entries are connections: the lines between them and the
+	// hosts mirrored from Bitwarden are skipped
+	for _, part := range splitSSHConfig(string(data)) {
+		block := part.block
+		if block == nil {
 			continue
 		}
+		sxtMetadata := block.metadataValues()
+		value := block.pattern()
 
-		// Skip empty lines and regular comments
-		if line == "" || strings.HasPrefix(line, "#") {
-			// Reset metadata on empty line or regular comment if we're not in a Host block
-			if currentConn == nil {
-				sxtMetadata = nil
-			}
-			continue
-		}
-
-		// Parse SSH config directives
-		fields := strings.Fields(line)
-		if len(fields) < 2 {
-			continue
+		// Default to password auth unless a key file is found
+		currentConn := &SSHConnection{
+			Port:        22,    // Default SSH port
+			HostPattern: value, // Store the Host pattern
+			UsePassword: true,  // Default to password auth
 		}
 
-		keyword := strings.ToLower(fields[0])
-		value := strings.Join(fields[1:], " ")
-
-		if keyword == "host" {
-			// Save previous connection if exists
-			if currentConn != nil {
-				// If no explicit metadata about use_password
-				if sxtMetadata == nil || sxtMetadata["use_password"] == "" {
-					// Only force password auth if no key file AND UsePassword wasn't explicitly set to false
-					if currentConn.KeyFile == "" && currentConn.UsePassword {
-						currentConn.UsePassword = true
-					}
-				}
-				connections = append(connections, *currentConn)
+		// Apply metadata to new connection
+		if sxtMetadata != nil {
+			if id, ok := sxtMetadata["id"]; ok {
+				currentConn.ID = id
 			}
-
-			// Start new connection - default to password auth unless key file is found
-			currentConn = &SSHConnection{
-				Port:        22,    // Default SSH port
-				HostPattern: value, // Store the Host pattern
-				UsePassword: true,  // Default to password auth
+			if name, ok := sxtMetadata["name"]; ok {
+				currentConn.Name = name
 			}
-
-			// Apply metadata to new connection
-			if sxtMetadata != nil {
-				if id, ok := sxtMetadata["id"]; ok {
-					currentConn.ID = id
-				}
-				if name, ok := sxtMetadata["name"]; ok {
-					currentConn.Name = name
-				}
-				if notes, ok := sxtMetadata["notes"]; ok {
-					currentConn.Notes = notes
-				}
-				if usePassword, ok := sxtMetadata["use_password"]; ok {
-					currentConn.UsePassword = usePassword == "true"
-				}
-				if publicKey, ok := sxtMetadata["public_key"]; ok {
-					currentConn.PublicKey = publicKey
-				}
-				if orgID, ok := sxtMetadata["organization_id"]; ok {
-					currentConn.OrganizationID = orgID
-				}
-				if pinned, ok := sxtMetadata["pinned"]; ok {
-					currentConn.Pinned = pinned == "true"
-				}
-				if order, ok := sxtMetadata["order"]; ok {
-					if o, err := strconv.Atoi(order); err == nil {
-						currentConn.Order = o
-					}
-				}
+			if notes, ok := sxtMetadata["notes"]; ok {
+				currentConn.Notes = notes
 			}
-
-			// Generate ID if not set
-			if currentConn.ID == "" {
-				currentConn.ID = generateID()
+			if usePassword, ok := sxtMetadata["use_password"]; ok {
+				currentConn.UsePassword = usePassword == "true"
 			}
-
-			// Set name from Host pattern if not set
-			if currentConn.Name == "" {
-				currentConn.Name = value
+			if publicKey, ok := sxtMetadata["public_key"]; ok {
+				currentConn.PublicKey = publicKey
 			}
-
-			// Reset metadata for next host
-			sxtMetadata = nil
-
-		} else if currentConn != nil {
-			switch keyword {
-			case "hostname":
-				currentConn.Host = value
-			case "port":
-				if port, err := strconv.Atoi(value); err == nil {
-					currentConn.Port = port
+			if orgID, ok := sxtMetadata["organization_id"]; ok {
+				currentConn.OrganizationID = orgID
+			}
+			if pinned, ok := sxtMetadata["pinned"]; ok {
+				currentConn.Pinned = pinned == "true"
+			}
+			if mosh, ok := sxtMetadata["mosh"]; ok {
+				currentConn.UseMosh = mosh == "true"
+			}
+			if viewOnly, ok := sxtMetadata["view_only"]; ok {
+				currentConn.ViewOnly = viewOnly == "true"
+			}
+			if level, ok := sxtMetadata["danger_level"]; ok {
+				currentConn.DangerLevel = ParseDangerLevel(level)
+			}
+			if proxy, ok := sxtMetadata["proxy"]; ok {
+				currentConn.Proxy = proxy
+			}
+			if startup, ok := sxtMetadata["startup_command"]; ok {
+				currentConn.StartupCommand = startup
+			}
+			if shell, ok := sxtMetadata["shell"]; ok {
+				currentConn.Shell = shell
+			}
+			if term, ok := sxtMetadata["term"]; ok {
+				currentConn.Term = term
+			}
+			if padding, ok := sxtMetadata["pty_padding"]; ok {
+				currentConn.PtyPadColumns, currentConn.PtyPadRows, _ = ParsePtyPadding(padding)
+			}
+			if timeouts, ok := sxtMetadata["session_timeouts"]; ok {
+				currentConn.IdleMinutes, currentConn.SessionMinutes, _ = ParseSessionTimeouts(timeouts)
+			}
+			if onConnect, ok := sxtMetadata["on_connect"]; ok {
+				currentConn.OnConnect = strings.Split(onConnect, "\n")
+			}
+			if auto, ok := sxtMetadata["on_connect_auto"]; ok {
+				currentConn.OnConnectAuto = auto == "true"
+			}
+			if remote, ok := sxtMetadata["remote_start_path"]; ok {
+				currentConn.RemoteStartPath = remote
+			}
+			if local, ok := sxtMetadata["local_start_path"]; ok {
+				currentConn.LocalStartPath = local
+			}
+			if bookmarks, ok := sxtMetadata["bookmark"]; ok {
+				currentConn.Bookmarks = strings.Split(bookmarks, "\n")
+			}
+			if order, ok := sxtMetadata["order"]; ok {
+				if o, err := strconv.Atoi(order); err == nil {
+					currentConn.Order = o
 				}
-			case "user":
-				currentConn.Username = value
-			case "identityfile":
-				currentConn.KeyFile = value
-				currentConn.UsePassword = false // Has key file, not password auth
-			case "identitiesonly", "pubkeyauthentication":
-				// These options indicate key-based authentication
-				if value == "yes" {
-					currentConn.UsePassword = false
+			}
+			if last, ok := sxtMetadata["last_connected"]; ok {
+				if t, err := strconv.ParseInt(last, 10, 64); err == nil {
+					currentConn.LastConnected = t
 				}
-			case "preferredauthentications":
-				// Check if password is preferred over publickey
-				if strings.Contains(strings.ToLower(value), "publickey") {
-					currentConn.UsePassword = false
+			}
+			if count, ok := sxtMetadata["connect_count"]; ok {
+				if c, err := strconv.Atoi(count); err == nil {
+					currentConn.ConnectCount = c
 				}
 			}
 		}
-	}
 
-	// Save last connection
-	if currentConn != nil {
-		// If no explicit metadata about use_password
-		if sxtMetadata == nil || sxtMetadata["use_password"] == "" {
-			// If no key file AND UsePassword wasn't explicitly set to false by config options
-			// then default to password auth
-			if currentConn.KeyFile == "" && currentConn.UsePassword {
-				currentConn.UsePassword = true
-			}
+		// Generate ID if not set, remembering the entry to find it on save
+		tagged := currentConn.ID != ""
+		if !tagged {
+			currentConn.ID = generateID()
 		}
-		connections = append(connections, *currentConn)
-	}
 
-	if err := scanner.Err(); err != nil {
-		return err
+		// Set name from Host pattern if not set
+		if currentConn.Name == "" {
+			currentConn.Name = value
+		}
+
+		for _, line := range block.body {
+			applySSHOption(currentConn, line)
+		}
+		if !tagged {
+			scm.untagged[currentConn.ID] = &untaggedEntry{source: block.source(), read: *currentConn}
+		}
+		connections = append(connections, *currentConn)
 	}
 
 	scm.Config.Connections = connections
 	return nil
 }
 
-// writeSSHConfig writes connections to SSH config file
+// applySSHOption sets on conn what an option line of its Host entry
+// configures. Other lines are ignored.
+func applySSHOption(conn *SSHConnection, line string) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return
+	}
+
+	keyword := strings.ToLower(fields[0])
+	value := strings.Join(fields[1:], " ")
+
+	switch keyword {
+	case "hostname":
+		conn.Host = value
+	case "port":
+		if port, err := strconv.Atoi(value); err == nil {
+			conn.Port = port
+		}
+	case "user":
+		conn.Username = value
+	case "forwardagent":
+		conn.ForwardAgent = strings.EqualFold(value, "yes")
+	case "forwardx11":
+		conn.ForwardX11 = strings.EqualFold(value, "yes")
+	case "setenv":
+		// Quoted values may hold runs of spaces, so the raw
+		// arguments are parsed rather than the joined fields
+		raw := strings.TrimSpace(line[len(fields[0]):])
+		if vars, err := ParseSetEnv(raw); err == nil {
+			conn.SetEnv = append(conn.SetEnv, vars...)
+		} else {
+			log.Printf("Ignoring SetEnv for %s: %v", conn.HostPattern, err)
+		}
+	case "proxycommand":
+		if !strings.EqualFold(value, "none") {
+			conn.ProxyCommand = value
+		}
+	case "proxyjump":
+		if !strings.EqualFold(value, "none") {
+			conn.ProxyJump = value
+		}
+	case "identityagent":
+		conn.IdentityAgent = value
+	case "serveraliveinterval":
+		conn.ServerAliveInterval, _ = strconv.Atoi(value)
+	case "compression":
+		conn.Compression = strings.EqualFold(value, "yes")
+	case "stricthostkeychecking":
+		conn.StrictHostKeyChecking = strings.ToLower(value)
+	case "localforward":
+		conn.LocalForward = append(conn.LocalForward, value)
+	case "remoteforward":
+		conn.RemoteForward = append(conn.RemoteForward, value)
+	case "identityfile":
+		conn.KeyFile = value
+		conn.UsePassword = false // Has key file, not password auth
+	case "identitiesonly", "pubkeyauthentication":
+		// These options indicate key-based authentication
+		if value == "yes" {
+			conn.UsePassword = false
+		}
+	case "preferredauthentications":
+		// Check if password is preferred over publickey
+		if strings.Contains(strings.ToLower(value), "publickey") {
+			conn.UsePassword = false
+		}
+	}
+}
+
+// writeSSHConfig saves the connections to the SSH config file, patching
+// their Host entries in place so the rest of the file is kept as it is
 func (scm *SSHConfigManager) writeSSHConfig() error {
-	// Write new config with all entries properly tagged
-	file, err := os.OpenFile(scm.ConfigPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
+	data, err := os.ReadFile(scm.ConfigPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
-	defer file.Close()
-
-	writer := bufio.NewWriter(file)
+	content := string(data)
+	parts := splitSSHConfig(content)
 
-	// Write all connections with sxt metadata
+	byID := make(map[string]*SSHConnection)
 	for i := range scm.Config.Connections {
 		conn := &scm.Config.Connections[i]
 
@@ -232,6 +296,10 @@ func (scm *SSHConfigManager) writeSSHConfig() error {
 			conn.ID = generateID()
 		}
 
+		if entry, ok := scm.untagged[conn.ID]; ok && (conn.Password != "" || conn.SudoPassword != "" || conn.ProxyPassword != "") {
+			entry.edited = true
+		}
+
 		// Store password if it exists
 		if conn.Password != "" {
 			keyring.Set(sshKeyringService, conn.ID, conn.Password)
@@ -244,55 +312,179 @@ func (scm *SSHConfigManager) writeSSHConfig() error {
 			conn.SudoPassword = "" // Don't keep in memory
 		}
 
-		// Write metadata comments
-		fmt.Fprintf(writer, "%sid=%s\n", sxtCommentPrefix, conn.ID)
-		if conn.Name != "" {
-			fmt.Fprintf(writer, "%sname=%s\n", sxtCommentPrefix, conn.Name)
-		}
-		if conn.Notes != "" {
-			fmt.Fprintf(writer, "%snotes=%s\n", sxtCommentPrefix, conn.Notes)
-		}
-		fmt.Fprintf(writer, "%suse_password=%t\n", sxtCommentPrefix, conn.UsePassword)
-		if conn.PublicKey != "" {
-			fmt.Fprintf(writer, "%spublic_key=%s\n", sxtCommentPrefix, conn.PublicKey)
-		}
-		if conn.OrganizationID != "" {
-			fmt.Fprintf(writer, "%sorganization_id=%s\n", sxtCommentPrefix, conn.OrganizationID)
-		}
-		if conn.Pinned {
-			fmt.Fprintf(writer, "%spinned=true\n", sxtCommentPrefix)
-		}
-		if conn.Order != 0 {
-			fmt.Fprintf(writer, "%sorder=%d\n", sxtCommentPrefix, conn.Order)
+		// Store proxy password if it exists
+		if conn.ProxyPassword != "" {
+			keyring.Set(sshKeyringService, "proxy:"+conn.ID, conn.ProxyPassword)
+			conn.ProxyPassword = "" // Don't keep in memory
 		}
 
-		// Write SSH config
-		hostPattern := conn.HostPattern
-		if hostPattern == "" {
-			// Fallback to Name or Host if HostPattern not set
-			hostPattern = conn.Name
-			if hostPattern == "" {
-				hostPattern = conn.Host
+		byID[conn.ID] = conn
+	}
+
+	// Entries read without an ID are found by their text
+	sourceIDs := make(map[string]string)
+	for id, entry := range scm.untagged {
+		sourceIDs[entry.source] = id
+	}
+	eol := "\n"
+	if strings.Contains(content, "\r\n") {
+		eol = "\r\n"
+	}
+
+	// New connections go after the last entry and the lines following it,
+	// or before the mirror block when there is no entry
+	insertAt := len(parts)
+	for i, part := range parts {
+		if part.mirror && insertAt == len(parts) {
+			insertAt = i
+		}
+		if part.block != nil {
+			insertAt = i + 1
+			if i+1 < len(parts) && parts[i+1].block == nil && !parts[i+1].mirror {
+				insertAt = i + 2
 			}
 		}
-		fmt.Fprintf(writer, "Host %s\n", hostPattern)
+	}
 
-		if conn.Host != "" {
-			fmt.Fprintf(writer, "    HostName %s\n", conn.Host)
+	var out strings.Builder
+	written := make(map[string]bool)
+	deleted := false
+	for i := 0; i <= len(parts); i++ {
+		if i == insertAt {
+			for j := range scm.Config.Connections {
+				conn := &scm.Config.Connections[j]
+				if written[conn.ID] {
+					continue
+				}
+				written[conn.ID] = true
+				if out.Len() > 0 && !strings.HasSuffix(out.String(), "\n") {
+					out.WriteString(eol)
+				}
+				if out.Len() > 0 && !strings.HasSuffix(out.String(), eol+eol) {
+					out.WriteString(eol)
+				}
+				out.WriteString(renderNewHostEntry(conn, eol) + eol)
+			}
 		}
-		if conn.Port != 0 && conn.Port != 22 {
-			fmt.Fprintf(writer, "    Port %d\n", conn.Port)
+		if i == len(parts) {
+			break
 		}
-		if conn.Username != "" {
-			fmt.Fprintf(writer, "    User %s\n", conn.Username)
+
+		part := parts[i]
+		text := part.text
+		if deleted && part.block == nil {
+			// The blank lines after a deleted entry go with it
+			text = strings.TrimLeft(text, "\r\n")
 		}
-		if conn.KeyFile != "" {
-			fmt.Fprintf(writer, "    IdentityFile %s\n", conn.KeyFile)
+		deleted = false
+		if block := part.block; block != nil {
+			id := block.metadataValues()["id"]
+			if id == "" {
+				id = sourceIDs[block.source()]
+			}
+			conn, ok := byID[id]
+			switch {
+			case id == "":
+				// Added since the file was read, and left alone
+			case ok && !written[id]:
+				if entry, untagged := scm.untagged[id]; !untagged || !entry.keepUntagged(*conn) {
+					text = block.render(conn)
+					delete(scm.untagged, id)
+				}
+				written[id] = true
+			case scm.removed[id] || written[id]:
+				text, deleted = "", true
+				delete(scm.untagged, id)
+			default:
+				// Added by another sxt or by hand since the file was read,
+				// and left alone
+			}
 		}
-		fmt.Fprintf(writer, "\n")
+		out.WriteString(text)
 	}
 
-	return writer.Flush()
+	file, err := os.OpenFile(scm.ConfigPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := file.WriteString(out.String()); err != nil {
+		return err
+	}
+	scm.removed = nil
+	return nil
+}
+
+// writeSxtMetadata writes the #sxt: metadata lines of a Host entry
+func writeSxtMetadata(w io.Writer, conn *SSHConnection, eol string) {
+	fmt.Fprintf(w, "%sid=%s%s", sxtCommentPrefix, conn.ID, eol)
+	if conn.Name != "" {
+		fmt.Fprintf(w, "%sname=%s%s", sxtCommentPrefix, conn.Name, eol)
+	}
+	if conn.Notes != "" {
+		fmt.Fprintf(w, "%snotes=%s%s", sxtCommentPrefix, conn.Notes, eol)
+	}
+	fmt.Fprintf(w, "%suse_password=%t%s", sxtCommentPrefix, conn.UsePassword, eol)
+	if conn.PublicKey != "" {
+		fmt.Fprintf(w, "%spublic_key=%s%s", sxtCommentPrefix, conn.PublicKey, eol)
+	}
+	if conn.OrganizationID != "" {
+		fmt.Fprintf(w, "%sorganization_id=%s%s", sxtCommentPrefix, conn.OrganizationID, eol)
+	}
+	if conn.Pinned {
+		fmt.Fprintf(w, "%spinned=true%s", sxtCommentPrefix, eol)
+	}
+	if conn.Order != 0 {
+		fmt.Fprintf(w, "%sorder=%d%s", sxtCommentPrefix, conn.Order, eol)
+	}
+	if conn.UseMosh {
+		fmt.Fprintf(w, "%smosh=true%s", sxtCommentPrefix, eol)
+	}
+	if conn.ViewOnly {
+		fmt.Fprintf(w, "%sview_only=true%s", sxtCommentPrefix, eol)
+	}
+	if conn.DangerLevel != "" {
+		fmt.Fprintf(w, "%sdanger_level=%s%s", sxtCommentPrefix, conn.DangerLevel, eol)
+	}
+	if conn.Proxy != "" {
+		fmt.Fprintf(w, "%sproxy=%s%s", sxtCommentPrefix, conn.Proxy, eol)
+	}
+	if conn.StartupCommand != "" {
+		fmt.Fprintf(w, "%sstartup_command=%s%s", sxtCommentPrefix, conn.StartupCommand, eol)
+	}
+	if conn.Shell != "" {
+		fmt.Fprintf(w, "%sshell=%s%s", sxtCommentPrefix, conn.Shell, eol)
+	}
+	if conn.Term != "" {
+		fmt.Fprintf(w, "%sterm=%s%s", sxtCommentPrefix, conn.Term, eol)
+	}
+	if padding := FormatPtyPadding(conn.PtyPadColumns, conn.PtyPadRows); padding != "" {
+		fmt.Fprintf(w, "%spty_padding=%s%s", sxtCommentPrefix, padding, eol)
+	}
+	if timeouts := FormatSessionTimeouts(conn.IdleMinutes, conn.SessionMinutes); timeouts != "" {
+		fmt.Fprintf(w, "%ssession_timeouts=%s%s", sxtCommentPrefix, timeouts, eol)
+	}
+	for _, command := range conn.OnConnect {
+		fmt.Fprintf(w, "%son_connect=%s%s", sxtCommentPrefix, command, eol)
+	}
+	if conn.OnConnectAuto {
+		fmt.Fprintf(w, "%son_connect_auto=true%s", sxtCommentPrefix, eol)
+	}
+	if conn.RemoteStartPath != "" {
+		fmt.Fprintf(w, "%sremote_start_path=%s%s", sxtCommentPrefix, conn.RemoteStartPath, eol)
+	}
+	if conn.LocalStartPath != "" {
+		fmt.Fprintf(w, "%slocal_start_path=%s%s", sxtCommentPrefix, conn.LocalStartPath, eol)
+	}
+	for _, bookmark := range conn.Bookmarks {
+		fmt.Fprintf(w, "%sbookmark=%s%s", sxtCommentPrefix, bookmark, eol)
+	}
+	if conn.LastConnected != 0 {
+		fmt.Fprintf(w, "%slast_connected=%d%s", sxtCommentPrefix, conn.LastConnected, eol)
+	}
+	if conn.ConnectCount != 0 {
+		fmt.Fprintf(w, "%sconnect_count=%d%s", sxtCommentPrefix, conn.ConnectCount, eol)
+	}
 }
 
 // AddConnection stores an SSH connection in the SSH config.
@@ -320,6 +512,15 @@ func (scm *SSHConfigManager) AddConnection(conn SSHConnection) error {
 		conn.SudoPassword = ""
 	}
 
+	// Handle proxy password securely using keyring
+	if conn.ProxyPassword != "" {
+		if err := keyring.Set(sshKeyringService, "proxy:"+conn.ID, conn.ProxyPassword); err != nil {
+			log.Printf("Failed to store proxy password in keyring: %v", err)
+			return err
+		}
+		conn.ProxyPassword = ""
+	}
+
 	// Check if connection already exists
 	for i, existing := range scm.Config.Connections {
 		if existing.ID == conn.ID {
@@ -334,6 +535,12 @@ func (scm *SSHConfigManager) AddConnection(conn SSHConnection) error {
 
 // EditConnection updates an existing SSH connection in the configuration.
 func (scm *SSHConfigManager) EditConnection(conn SSHConnection) error {
+	// Secrets are kept under the ID, which an untagged entry has to be
+	// tagged with to find them again
+	if entry, ok := scm.untagged[conn.ID]; ok && (conn.Password != "" || conn.SudoPassword != "" || conn.ProxyPassword != "") {
+		entry.edited = true
+	}
+
 	// Handle password securely using keyring
 	if conn.Password != "" {
 		if err := keyring.Set(sshKeyringService, conn.ID, conn.Password); err != nil {
@@ -352,6 +559,15 @@ func (scm *SSHConfigManager) EditConnection(conn SSHConnection) error {
 		conn.SudoPassword = ""
 	}
 
+	// Handle proxy password securely using keyring
+	if conn.ProxyPassword != "" {
+		if err := keyring.Set(sshKeyringService, "proxy:"+conn.ID, conn.ProxyPassword); err != nil {
+			log.Printf("Failed to store proxy password in keyring: %v", err)
+			return err
+		}
+		conn.ProxyPassword = ""
+	}
+
 	for i, existing := range scm.Config.Connections {
 		if existing.ID == conn.ID {
 			scm.Config.Connections[i] = conn
@@ -375,9 +591,18 @@ func (scm *SSHConfigManager) DeleteConnection(id string) error {
 		log.Printf("Failed to delete sudo password from keyring (may not exist): %v", err)
 	}
 
+	// Remove proxy password from keyring
+	if err := keyring.Delete(sshKeyringService, "proxy:"+id); err != nil {
+		log.Printf("Failed to delete proxy password from keyring (may not exist): %v", err)
+	}
+
 	for i, conn := range scm.Config.Connections {
 		if conn.ID == id {
 			scm.Config.Connections = append(scm.Config.Connections[:i], scm.Config.Connections[i+1:]...)
+			if scm.removed == nil {
+				scm.removed = make(map[string]bool)
+			}
+			scm.removed[id] = true
 			return scm.Save()
 		}
 	}
@@ -416,6 +641,14 @@ func (scm *SSHConfigManager) GetConnection(id string) (SSHConnection, bool) {
 				log.Printf("Retrieved sudo password from keyring for connection ID: %s", id)
 			}
 
+			// Retrieve proxy password from keyring
+			if conn.Proxy != "" {
+				if proxyPassword, err := keyring.Get(sshKeyringService, "proxy:"+id); err == nil {
+					conn.ProxyPassword = proxyPassword
+				}
+			}
+			RegisterConnectionSecrets(conn)
+
 			return conn, true
 		}
 	}
@@ -427,6 +660,15 @@ func (scm *SSHConfigManager) ListConnections() []SSHConnection {
 	return scm.Config.Connections
 }
 
+// sshConfigCapabilities: hosts are listed all at once and refer to key
+// files by path
+var sshConfigCapabilities = StorageCapabilities{}
+
+// Capabilities tells what the SSH config storage supports
+func (scm *SSHConfigManager) Capabilities() StorageCapabilities {
+	return sshConfigCapabilities
+}
+
 // Load loads the SSH connections from the SSH config file.
 func (scm *SSHConfigManager) Load() error {
 	err := scm.parseSSHConfig()
@@ -465,6 +707,11 @@ func (scm *SSHConfigManager) Load() error {
 			if conn.ID == "" {
 				conn.ID = generateID()
 			}
+			// The migration, unlike a later save, tags every entry: the
+			// recovered passwords are kept under the IDs
+			if entry, ok := scm.untagged[conn.ID]; ok {
+				entry.edited = true
+			}
 
 			// Try to recover password if not set
 			if conn.UsePassword && conn.Password == "" {
@@ -0,0 +1,323 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ~/.ssh/config is saved by patching it rather than rewriting it: in each
+// Host entry only the #sxt: metadata and the options sxt changed are
+// rewritten. Comments, Match blocks, global options, options sxt doesn't
+// know about and the Bitwarden mirror are kept byte for byte.
+
+// sshConfigPart is a stretch of an ssh_config file: a Host entry, the
+// mirror block, or the lines between them
+type sshConfigPart struct {
+	text   string          // The lines as written, with their line endings
+	block  *sshConfigBlock // Set for a Host entry
+	mirror bool            // The Bitwarden mirror block
+}
+
+// sshConfigBlock is a Host entry: the #sxt: lines right before the Host
+// line, the Host line and the lines after it up to the next entry, less
+// the comments and blank lines ending it
+type sshConfigBlock struct {
+	metadata []string // #sxt: lines, as written
+	host     string   // Host line, as written
+	body     []string // Lines after the Host line, as written
+}
+
+// sshDirective is a Host option sxt reads and writes. values returns the
+// values a connection sets, one per line, and none for nothing.
+type sshDirective struct {
+	keyword string
+	values  func(conn *SSHConnection) []string
+}
+
+// sshDirectives are the Host options of ~/.ssh/config entries, in the
+// order new entries get them
+var sshDirectives = append([]sshDirective{
+	{"HostName", func(conn *SSHConnection) []string { return nonEmpty(conn.Host) }},
+	{"Port", func(conn *SSHConnection) []string {
+		if conn.Port != 0 && conn.Port != 22 {
+			return []string{strconv.Itoa(conn.Port)}
+		}
+		return nil
+	}},
+	{"User", func(conn *SSHConnection) []string { return nonEmpty(conn.Username) }},
+	{"IdentityFile", func(conn *SSHConnection) []string { return nonEmpty(conn.KeyFile) }},
+	{"ProxyCommand", func(conn *SSHConnection) []string { return nonEmpty(conn.ProxyCommand) }},
+	{"ForwardAgent", func(conn *SSHConnection) []string { return yesOrNone(conn.ForwardAgent) }},
+	{"SetEnv", func(conn *SSHConnection) []string {
+		if len(conn.SetEnv) > 0 {
+			return []string{FormatSetEnv(conn.SetEnv)}
+		}
+		return nil
+	}},
+	{"ForwardX11", func(conn *SSHConnection) []string { return yesOrNone(conn.ForwardX11) }},
+}, sshOptions...)
+
+// sshOptions are the Host options every ssh_config writer, exports and
+// the Bitwarden mirror included, writes the same way
+var sshOptions = []sshDirective{
+	{"ProxyJump", func(conn *SSHConnection) []string { return nonEmpty(conn.ProxyJump) }},
+	{"IdentityAgent", func(conn *SSHConnection) []string { return nonEmpty(conn.IdentityAgent) }},
+	{"ServerAliveInterval", func(conn *SSHConnection) []string {
+		if conn.ServerAliveInterval > 0 {
+			return []string{strconv.Itoa(conn.ServerAliveInterval)}
+		}
+		return nil
+	}},
+	{"Compression", func(conn *SSHConnection) []string { return yesOrNone(conn.Compression) }},
+	{"StrictHostKeyChecking", func(conn *SSHConnection) []string { return nonEmpty(conn.StrictHostKeyChecking) }},
+	{"LocalForward", func(conn *SSHConnection) []string { return conn.LocalForward }},
+	{"RemoteForward", func(conn *SSHConnection) []string { return conn.RemoteForward }},
+}
+
+// writeSSHOptions writes the sshOptions conn sets, indented, one per line
+func writeSSHOptions(w io.Writer, conn *SSHConnection) {
+	for _, option := range sshOptions {
+		for _, value := range option.values(conn) {
+			fmt.Fprintf(w, "    %s %s\n", option.keyword, value)
+		}
+	}
+}
+
+func nonEmpty(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+func yesOrNone(b bool) []string {
+	if b {
+		return []string{"yes"}
+	}
+	return nil
+}
+
+// findSSHDirective returns the index in sshDirectives of keyword, or -1
+func findSSHDirective(keyword string) int {
+	for i, d := range sshDirectives {
+		if strings.EqualFold(d.keyword, keyword) {
+			return i
+		}
+	}
+	return -1
+}
+
+// splitSSHConfig cuts the content of an ssh_config file into parts that
+// join back into it exactly
+func splitSSHConfig(content string) []sshConfigPart {
+	lines := strings.SplitAfter(content, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var parts []sshConfigPart
+	var gap strings.Builder
+	flush := func() {
+		if gap.Len() > 0 {
+			parts = append(parts, sshConfigPart{text: gap.String()})
+			gap.Reset()
+		}
+	}
+	for i := 0; i < len(lines); {
+		trimmed := strings.TrimSpace(lines[i])
+		if isMirrorBegin(trimmed) {
+			flush()
+			j := i + 1
+			for j < len(lines) && !isMirrorEnd(strings.TrimSpace(lines[j-1])) {
+				j++
+			}
+			parts = append(parts, sshConfigPart{text: strings.Join(lines[i:j], ""), mirror: true})
+			i = j
+			continue
+		}
+
+		// A Host line, with the metadata lines right before it
+		j := i
+		for j < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[j]), sxtCommentPrefix) {
+			j++
+		}
+		if j == len(lines) || sshKeyword(lines[j]) != "host" {
+			gap.WriteString(lines[i])
+			i++
+			continue
+		}
+		block := &sshConfigBlock{metadata: lines[i:j], host: lines[j]}
+		end := j + 1
+		for end < len(lines) && !endsHostBody(lines[end]) {
+			end++
+		}
+		// Comments and blank lines at the end stay in place if it goes
+		last := end
+		for last > j+1 && isCommentOrBlank(lines[last-1]) {
+			last--
+		}
+		block.body = lines[j+1 : last]
+		flush()
+		parts = append(parts, sshConfigPart{text: strings.Join(lines[i:last], ""), block: block})
+		i = last
+	}
+	flush()
+	return parts
+}
+
+// sshKeyword returns the option name a line starts with, in lower case,
+// or "" when the line doesn't hold an option and its value
+func sshKeyword(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+		return ""
+	}
+	return strings.ToLower(fields[0])
+}
+
+// endsHostBody reports whether line starts the next entry or part
+func endsHostBody(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	keyword := sshKeyword(line)
+	return keyword == "host" || keyword == "match" || strings.HasPrefix(trimmed, sxtCommentPrefix) || isMirrorBegin(trimmed)
+}
+
+func isCommentOrBlank(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return trimmed == "" || strings.HasPrefix(trimmed, "#")
+}
+
+// lineEnding returns the line ending of line, "\n" when it has none
+func lineEnding(line string) string {
+	if strings.HasSuffix(line, "\r\n") {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// indentation returns the whitespace line starts with
+func indentation(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// pattern returns the host patterns of the Host line
+func (b *sshConfigBlock) pattern() string {
+	return strings.Join(strings.Fields(b.host)[1:], " ")
+}
+
+// source is the entry without its metadata, telling apart the entries
+// read without an ID
+func (b *sshConfigBlock) source() string {
+	return b.host + strings.Join(b.body, "")
+}
+
+// metadataValues returns the #sxt: key=value pairs of the entry. On-connect
+// commands and bookmarks have a line each and are joined with newlines.
+func (b *sshConfigBlock) metadataValues() map[string]string {
+	if len(b.metadata) == 0 {
+		return nil
+	}
+	values := make(map[string]string)
+	for _, line := range b.metadata {
+		key, value, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(line), sxtCommentPrefix), "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if previous, ok := values[key]; ok && (key == "on_connect" || key == "bookmark") {
+			value = previous + "\n" + value
+		}
+		values[key] = value
+	}
+	return values
+}
+
+// render writes the entry back for conn: as it was read when nothing
+// changed, and otherwise with new metadata and the options conn changed
+// replaced in place, added after the others or removed
+func (b *sshConfigBlock) render(conn *SSHConnection) string {
+	eol := lineEnding(b.host)
+	var out strings.Builder
+	writeSxtMetadata(&out, conn, eol)
+
+	host := b.host
+	if pattern := hostPattern(conn); pattern != b.pattern() {
+		host = indentation(b.host) + "Host " + pattern + eol
+	}
+
+	old := SSHConnection{Port: 22}
+	for _, line := range b.body {
+		applySSHOption(&old, line)
+	}
+	indent, indented := "    ", false
+	var body []string
+	insertAt := 0 // After the last option, where missing ones go
+	written := make([]bool, len(sshDirectives))
+	for _, line := range b.body {
+		if isCommentOrBlank(line) {
+			body = append(body, line)
+			continue
+		}
+		if !indented {
+			indent, indented = indentation(line), true
+		}
+		fields := strings.Fields(line)
+		d := findSSHDirective(fields[0])
+		if d < 0 || slices.Equal(sshDirectives[d].values(&old), sshDirectives[d].values(conn)) {
+			body = append(body, line)
+			insertAt = len(body)
+			continue
+		}
+		// The first line of a changed option takes the new values, and
+		// the others go
+		if !written[d] {
+			written[d] = true
+			for _, value := range sshDirectives[d].values(conn) {
+				body = append(body, indentation(line)+fields[0]+" "+value+eol)
+				insertAt = len(body)
+			}
+		}
+	}
+
+	var added []string
+	for d, directive := range sshDirectives {
+		values := directive.values(conn)
+		if written[d] || slices.Equal(values, directive.values(&old)) {
+			continue
+		}
+		for _, value := range values {
+			added = append(added, indent+directive.keyword+" "+value+eol)
+		}
+	}
+	body = append(body[:insertAt], append(added, body[insertAt:]...)...)
+	for i, line := range append([]string{host}, body...) {
+		// Only the last line of the file may have no ending
+		if i > 0 && !strings.HasSuffix(out.String(), "\n") {
+			out.WriteString(eol)
+		}
+		out.WriteString(line)
+	}
+	return out.String()
+}
+
+// renderNewHostEntry writes a Host entry for a connection not yet in the
+// file
+func renderNewHostEntry(conn *SSHConnection, eol string) string {
+	block := &sshConfigBlock{host: "Host " + hostPattern(conn) + eol}
+	return block.render(conn)
+}
+
+// hostPattern returns the Host patterns of a connection, falling back to
+// its name or address
+func hostPattern(conn *SSHConnection) string {
+	switch {
+	case conn.HostPattern != "":
+		return conn.HostPattern
+	case conn.Name != "":
+		return conn.Name
+	}
+	return conn.Host
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The Bitwarden mirror is kept between these markers at the end of
+// ~/.ssh/config. Everything in between is rewritten on each sync.
+const (
+	mirrorBeginMarker = "# BEGIN ssh-x-term bitwarden mirror"
+	mirrorEndMarker   = "# END ssh-x-term bitwarden mirror"
+)
+
+// isMirrorBegin and isMirrorEnd match the marker lines, trimmed
+func isMirrorBegin(line string) bool { return strings.HasPrefix(line, mirrorBeginMarker) }
+func isMirrorEnd(line string) bool   { return strings.HasPrefix(line, mirrorEndMarker) }
+
+// splitMirrorBlock separates the mirror block, markers included, from the
+// rest of an ssh_config file
+func splitMirrorBlock(content string) (rest, block string) {
+	var outside, inside strings.Builder
+	for _, part := range splitSSHConfig(content) {
+		if part.mirror {
+			inside.WriteString(part.text)
+		} else {
+			outside.WriteString(part.text)
+		}
+	}
+	return outside.String(), inside.String()
+}
+
+// mirrorAlias turns a connection name into a Host alias usable with
+// ssh <alias>: whitespace and pattern characters become dashes
+func mirrorAlias(name string) string {
+	alias := strings.Map(func(r rune) rune {
+		switch {
+		case r <= ' ', strings.ContainsRune(`*?!,"'#\`, r):
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	return strings.Trim(alias, "-")
+}
+
+// renderMirrorBlock writes the Host entries of conns between the markers.
+// Secrets never leave the vault: passwords are left out and keys stored as
+// Bitwarden attachments are not referenced. Host ranges get one entry per
+// host, as ssh_config has no syntax for them.
+func renderMirrorBlock(conns []SSHConnection) string {
+	var b strings.Builder
+	b.WriteString(mirrorBeginMarker + " (managed by sxt, edits are overwritten)\n")
+	used := make(map[string]bool)
+	for _, conn := range ExpandHostRanges(conns) {
+		alias := mirrorAlias(conn.Name)
+		if alias == "" {
+			alias = mirrorAlias(conn.Host)
+		}
+		if alias == "" || conn.Host == "" {
+			continue
+		}
+		base := alias
+		for n := 2; used[alias]; n++ {
+			alias = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[alias] = true
+
+		fmt.Fprintf(&b, "Host %s\n", alias)
+		fmt.Fprintf(&b, "    HostName %s\n", conn.Host)
+		if conn.Port != 0 && conn.Port != 22 {
+			fmt.Fprintf(&b, "    Port %d\n", conn.Port)
+		}
+		if conn.Username != "" {
+			fmt.Fprintf(&b, "    User %s\n", conn.Username)
+		}
+		if conn.KeyFile != "" && conn.KeyAttachment == "" {
+			fmt.Fprintf(&b, "    IdentityFile %s\n", conn.KeyFile)
+		}
+		if conn.ProxyCommand != "" {
+			fmt.Fprintf(&b, "    ProxyCommand %s\n", conn.ProxyCommand)
+		}
+		if conn.ForwardAgent {
+			b.WriteString("    ForwardAgent yes\n")
+		}
+		if conn.ForwardX11 {
+			b.WriteString("    ForwardX11 yes\n")
+		}
+		if len(conn.SetEnv) > 0 {
+			fmt.Fprintf(&b, "    SetEnv %s\n", FormatSetEnv(conn.SetEnv))
+		}
+		writeSSHOptions(&b, &conn)
+	}
+	b.WriteString(mirrorEndMarker + "\n")
+	return b.String()
+}
+
+// MirrorSSHConfigPath returns the ssh_config file the mirror is written to
+func MirrorSSHConfigPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, ".ssh", sshConfigFileName), nil
+}
+
+// MirrorToSSHConfig replaces the mirror block of the ssh_config file at
+// path with conns, so ssh <name> works outside sxt. Host entries outside
+// the block are left alone and, coming first, win over mirrored ones.
+func MirrorToSSHConfig(path string, conns []SSHConnection) error {
+	// Write through a symlinked config, as kept in dotfile repositories
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	rest, _ := splitMirrorBlock(string(data))
+	rest = strings.TrimRight(rest, "\n")
+	if rest != "" {
+		rest += "\n\n"
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-sxt-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(rest + renderMirrorBlock(conns)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		log.Printf("Failed to write %s: %v", path, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMirrorToSSHConfig(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), ".ssh", "config")
+	if err := os.MkdirAll(filepath.Dir(configPath), 0700); err != nil {
+		t.Fatalf("Failed to create .ssh directory: %v", err)
+	}
+	local := `#sxt:id=local-1
+#sxt:name=Local
+Host local
+    HostName 10.0.0.1
+    User me
+`
+	if err := os.WriteFile(configPath, []byte(local), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	conns := []SSHConnection{
+		{Name: "Web Server", Host: "web.example.com", Port: 2222, Username: "deploy", Password: "hunter2", ForwardAgent: true},
+		{Name: "Web Server", Host: "web2.example.com", Port: 22, Username: "deploy", KeyFile: "~/.ssh/id_web", KeyAttachment: "id_web"},
+		{Name: "db*", Host: "db.example.com", KeyFile: "~/.ssh/id_db", SetEnv: []string{"LANG=C.UTF-8"}},
+	}
+	if err := MirrorToSSHConfig(configPath, conns); err != nil {
+		t.Fatalf("Failed to mirror: %v", err)
+	}
+	// Syncing again replaces the block instead of adding another
+	if err := MirrorToSSHConfig(configPath, conns); err != nil {
+		t.Fatalf("Failed to mirror again: %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatalf("Failed to read config: %v", err)
+	}
+	content := string(data)
+	if !strings.HasPrefix(content, local) {
+		t.Errorf("Expected the local entries to be kept first, got:\n%s", content)
+	}
+	if n := strings.Count(content, mirrorBeginMarker); n != 1 {
+		t.Errorf("Expected one mirror block, got %d", n)
+	}
+	for _, want := range []string{
+		"Host Web-Server\n    HostName web.example.com\n    Port 2222\n    User deploy\n    ForwardAgent yes\n",
+		"Host Web-Server-2\n    HostName web2.example.com\n    User deploy\n",
+		"Host db\n    HostName db.example.com\n    IdentityFile ~/.ssh/id_db\n    SetEnv LANG=C.UTF-8\n",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Expected %q in:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "hunter2") || strings.Contains(content, "id_web") {
+		t.Errorf("Secrets or attached keys leaked into the mirror:\n%s", content)
+	}
+
+	// The local backend doesn't pick the mirrored hosts up as its own
+	scm := &SSHConfigManager{ConfigPath: configPath, Config: NewConfig()}
+	if err := scm.parseSSHConfig(); err != nil {
+		t.Fatalf("Failed to parse config: %v", err)
+	}
+	if len(scm.Config.Connections) != 1 || scm.Config.Connections[0].ID != "local-1" {
+		t.Errorf("Expected only the local connection, got %+v", scm.Config.Connections)
+	}
+
+	// An empty vault leaves an empty block
+	if err := MirrorToSSHConfig(configPath, nil); err != nil {
+		t.Fatalf("Failed to mirror: %v", err)
+	}
+	data, _ = os.ReadFile(configPath)
+	if strings.Contains(string(data), "Host Web-Server") {
+		t.Errorf("Expected the mirrored hosts to be removed, got:\n%s", data)
+	}
+}
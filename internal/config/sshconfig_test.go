@@ -3,8 +3,10 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestSSHConfigParsing(t *testing.T) {
@@ -21,6 +23,9 @@ func TestSSHConfigParsing(t *testing.T) {
 #sxt:name=Test Server 1
 #sxt:notes=Test notes
 #sxt:use_password=true
+#sxt:last_connected=1760000000
+#sxt:connect_count=7
+#sxt:danger_level=Production
 Host testserver1
     HostName 192.168.1.100
     Port 2222
@@ -29,10 +34,23 @@ Host testserver1
 #sxt:id=test-id-2
 #sxt:name=Test Server 2
 #sxt:use_password=false
+#sxt:view_only=true
+#sxt:startup_command=cd /srv/app
+#sxt:term=screen-256color
+#sxt:pty_padding=1x1
+#sxt:session_timeouts=15m/8h
+#sxt:on_connect=cd /var/www
+#sxt:on_connect=sudo -i
+#sxt:bookmark=remote:/var/www/releases
+#sxt:bookmark=local:~/deploy
 Host testserver2
     HostName example.com
     User admin
     IdentityFile ~/.ssh/id_rsa
+    ProxyCommand cloudflared access ssh --hostname %h
+    ForwardAgent yes
+    ForwardX11 no
+    SetEnv LANG=C.UTF-8 GREETING="hello  world"
 
 # Regular SSH config entry (not managed by sxt)
 Host regularhost
@@ -97,6 +115,12 @@ Host regularhost
 	if conn1.Notes != "Test notes" {
 		t.Errorf("Expected notes 'Test notes', got '%s'", conn1.Notes)
 	}
+	if conn1.DangerLevel != DangerProduction {
+		t.Errorf("Expected danger level production, got %q", conn1.DangerLevel)
+	}
+	if conn1.LastConnected != 1760000000 || conn1.ConnectCount != 7 {
+		t.Errorf("Expected usage 1760000000/7, got %d/%d", conn1.LastConnected, conn1.ConnectCount)
+	}
 
 	// Check second connection
 	var conn2 *SSHConnection
@@ -114,9 +138,36 @@ Host regularhost
 	if conn2.UsePassword {
 		t.Error("Expected UsePassword to be false")
 	}
+	if !conn2.ViewOnly || conn1.ViewOnly {
+		t.Error("Expected only the second connection to be view-only")
+	}
 	if conn2.KeyFile != "~/.ssh/id_rsa" {
 		t.Errorf("Expected KeyFile '~/.ssh/id_rsa', got '%s'", conn2.KeyFile)
 	}
+	if conn2.ProxyCommand != "cloudflared access ssh --hostname %h" {
+		t.Errorf("Expected ProxyCommand to be parsed, got '%s'", conn2.ProxyCommand)
+	}
+	if !reflect.DeepEqual(conn2.SetEnv, []string{"LANG=C.UTF-8", "GREETING=hello  world"}) {
+		t.Errorf("Expected SetEnv to be parsed, got %q", conn2.SetEnv)
+	}
+	if !reflect.DeepEqual(conn2.OnConnect, []string{"cd /var/www", "sudo -i"}) {
+		t.Errorf("Expected both on-connect commands, got %q", conn2.OnConnect)
+	}
+	if !reflect.DeepEqual(conn2.Bookmarks, []string{"remote:/var/www/releases", "local:~/deploy"}) {
+		t.Errorf("Expected both bookmarks, got %q", conn2.Bookmarks)
+	}
+	if conn2.StartupCommand != "cd /srv/app" {
+		t.Errorf("Expected startup command 'cd /srv/app', got '%s'", conn2.StartupCommand)
+	}
+	if conn2.Term != "screen-256color" || conn2.PtyPadColumns != 1 || conn2.PtyPadRows != 1 {
+		t.Errorf("Expected TERM screen-256color with 1x1 padding, got %q and %dx%d", conn2.Term, conn2.PtyPadColumns, conn2.PtyPadRows)
+	}
+	if conn2.IdleMinutes != 15 || conn2.SessionMinutes != 480 {
+		t.Errorf("Expected 15m idle and 8h session timeouts, got %d and %d minutes", conn2.IdleMinutes, conn2.SessionMinutes)
+	}
+	if !conn2.ForwardAgent || conn2.ForwardX11 {
+		t.Errorf("Expected ForwardAgent yes and ForwardX11 no, got %t and %t", conn2.ForwardAgent, conn2.ForwardX11)
+	}
 }
 
 func TestSSHConfigWriting(t *testing.T) {
@@ -370,3 +421,255 @@ Host github.com
 		}
 	}
 }
+
+func TestSSHConfigPatchesEntriesInPlace(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	original := `# Global options
+Include ~/.ssh/config.d/*
+ServerAliveInterval 30
+
+#sxt:id=web-1
+#sxt:name=Web
+#sxt:use_password=false
+Host web
+  HostName web.example.com
+  User deploy
+  ControlMaster auto
+  ControlPath ~/.ssh/cm-%r@%h:%p
+  IdentityFile ~/.ssh/id_web
+  # keep this comment
+
+#sxt:id=db-1
+#sxt:name=DB
+#sxt:use_password=true
+Host db
+	HostName db.example.com
+	ForwardAgent no
+
+Match host *.internal
+    User ops
+
+# BEGIN ssh-x-term bitwarden mirror (managed by sxt, edits are overwritten)
+Host vault
+    HostName vault.example.com
+# END ssh-x-term bitwarden mirror
+`
+	if err := os.WriteFile(configPath, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+	scm := &SSHConfigManager{ConfigPath: configPath, Config: NewConfig()}
+	if err := scm.parseSSHConfig(); err != nil {
+		t.Fatal(err)
+	}
+	read := func() string {
+		t.Helper()
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	if err := scm.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(); got != original {
+		t.Fatalf("Expected an unchanged file saved byte for byte, got:\n%s", got)
+	}
+
+	if err := scm.DeleteConnection("db-1"); err != nil {
+		t.Fatal(err)
+	}
+	web := scm.Config.Connections[0]
+	web.Username = "www"
+	web.Port = 2222
+	web.ForwardAgent = true
+	scm.Config.Connections[0] = web
+	scm.Config.Connections = append(scm.Config.Connections, SSHConnection{ID: "new-1", Name: "New", Host: "new.example.com"})
+	if err := scm.Save(); err != nil {
+		t.Fatal(err)
+	}
+	want := `# Global options
+Include ~/.ssh/config.d/*
+ServerAliveInterval 30
+
+#sxt:id=web-1
+#sxt:name=Web
+#sxt:use_password=false
+Host web
+  HostName web.example.com
+  User www
+  ControlMaster auto
+  ControlPath ~/.ssh/cm-%r@%h:%p
+  IdentityFile ~/.ssh/id_web
+  Port 2222
+  ForwardAgent yes
+  # keep this comment
+
+Match host *.internal
+    User ops
+
+#sxt:id=new-1
+#sxt:name=New
+#sxt:use_password=false
+Host New
+    HostName new.example.com
+
+# BEGIN ssh-x-term bitwarden mirror (managed by sxt, edits are overwritten)
+Host vault
+    HostName vault.example.com
+# END ssh-x-term bitwarden mirror
+`
+	if got := read(); got != want {
+		t.Errorf("Expected only the changed options patched, got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestSSHConfigKeepsUntaggedEntries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	configPath := filepath.Join(t.TempDir(), "config")
+	original := "Host legacy\r\n    HostName legacy.example.com\r\n    ControlMaster auto\r\n\r\nHost other\r\n    HostName other.example.com\r\n"
+	if err := os.WriteFile(configPath, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+	scm := &SSHConfigManager{ConfigPath: configPath, Config: NewConfig()}
+	if err := scm.parseSSHConfig(); err != nil {
+		t.Fatal(err)
+	}
+	read := func() string {
+		data, _ := os.ReadFile(configPath)
+		return string(data)
+	}
+
+	// Saving, or connecting, changes nothing
+	if err := scm.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecordConnection(scm, scm.Config.Connections[1].ID, time.Unix(1700000000, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(); got != original {
+		t.Errorf("Expected the file unchanged, got %q", got)
+	}
+
+	// Edited, the entry is tagged and otherwise kept
+	legacy := scm.Config.Connections[0]
+	legacy.Notes = "old box"
+	if err := scm.EditConnection(legacy); err != nil {
+		t.Fatal(err)
+	}
+	want := "#sxt:id=" + legacy.ID + "\r\n#sxt:name=legacy\r\n#sxt:notes=old box\r\n#sxt:use_password=true\r\n" +
+		"Host legacy\r\n    HostName legacy.example.com\r\n    ControlMaster auto\r\n\r\nHost other\r\n    HostName other.example.com\r\n"
+	if got := read(); got != want {
+		t.Errorf("Expected only the edited entry tagged, got %q", got)
+	}
+
+	// Deleted, it goes with its tags
+	if err := scm.DeleteConnection(legacy.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(); got != "Host other\r\n    HostName other.example.com\r\n" {
+		t.Errorf("Expected the entry deleted, got %q", got)
+	}
+}
+
+func TestSSHConfigKeepsEntriesAddedElsewhere(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	web := "#sxt:id=web-1\n#sxt:name=Web\n#sxt:use_password=false\nHost web\n    HostName web.example.com\n"
+	if err := os.WriteFile(configPath, []byte(web), 0600); err != nil {
+		t.Fatal(err)
+	}
+	scm := &SSHConfigManager{ConfigPath: configPath, Config: NewConfig()}
+	if err := scm.parseSSHConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another sxt adds a connection after this one read the file
+	db := "\n#sxt:id=db-1\n#sxt:name=DB\n#sxt:use_password=false\nHost db\n    HostName db.example.com\n"
+	if err := os.WriteFile(configPath, []byte(web+db), 0600); err != nil {
+		t.Fatal(err)
+	}
+	conn := scm.Config.Connections[0]
+	conn.Username = "www"
+	scm.Config.Connections[0] = conn
+	if err := scm.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "User www") || !strings.HasSuffix(string(data), db) {
+		t.Errorf("Expected the other entry kept, got:\n%s", data)
+	}
+
+	if err := scm.DeleteConnection("web-1"); err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != strings.TrimPrefix(db, "\n") {
+		t.Errorf("Expected only the deleted entry gone, got:\n%s", got)
+	}
+}
+
+func TestSSHConfigOptions(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	original := `#sxt:id=app-1
+#sxt:name=App
+#sxt:use_password=false
+Host app
+    HostName app.internal
+    ProxyJump admin@bastion:2222,jump2
+    IdentityAgent ~/.1password/agent.sock
+    ServerAliveInterval 15
+    Compression yes
+    StrictHostKeyChecking Accept-New
+    LocalForward 8080 localhost:80
+    LocalForward 127.0.0.1:5433 db:5432
+`
+	if err := os.WriteFile(configPath, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+	scm := &SSHConfigManager{ConfigPath: configPath, Config: NewConfig()}
+	if err := scm.parseSSHConfig(); err != nil {
+		t.Fatal(err)
+	}
+	conn := scm.Config.Connections[0]
+	if conn.ProxyJump != "admin@bastion:2222,jump2" || conn.IdentityAgent != "~/.1password/agent.sock" ||
+		conn.ServerAliveInterval != 15 || !conn.Compression || conn.StrictHostKeyChecking != "accept-new" {
+		t.Errorf("Options not parsed: %+v", conn)
+	}
+	if !reflect.DeepEqual(conn.LocalForward, []string{"8080 localhost:80", "127.0.0.1:5433 db:5432"}) {
+		t.Errorf("Expected both LocalForward lines, got %q", conn.LocalForward)
+	}
+
+	conn.LocalForward = conn.LocalForward[1:]
+	conn.RemoteForward = []string{"9000 localhost:9000"}
+	conn.Compression = false
+	scm.Config.Connections[0] = conn
+	if err := scm.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `#sxt:id=app-1
+#sxt:name=App
+#sxt:use_password=false
+Host app
+    HostName app.internal
+    ProxyJump admin@bastion:2222,jump2
+    IdentityAgent ~/.1password/agent.sock
+    ServerAliveInterval 15
+    StrictHostKeyChecking Accept-New
+    LocalForward 127.0.0.1:5433 db:5432
+    RemoteForward 9000 localhost:9000
+`
+	if string(data) != want {
+		t.Errorf("Expected the changed options patched, got:\n%s\nwant:\n%s", data, want)
+	}
+}
@@ -1,5 +1,10 @@
 package config
 
+import (
+	"errors"
+	"time"
+)
+
 // Storage defines the backend interface for SSH connection storage.
 type Storage interface {
 	Load() error
@@ -9,4 +14,127 @@ type Storage interface {
 	GetConnection(id string) (SSHConnection, bool)
 	ListConnections() []SSHConnection
 	EditConnection(conn SSHConnection) error
+	// Capabilities tells what the backend can do beyond these methods
+	Capabilities() StorageCapabilities
+}
+
+// StorageCapabilities are what a backend supports, so the UI only offers
+// what works with it
+type StorageCapabilities struct {
+	// Collections is set when connections are browsed by organization and
+	// collection, or folder, rather than all at once
+	Collections bool
+	// Attachments is set when key files are kept with their connection,
+	// see KeyAttachmentStorage
+	Attachments bool
+	// ReadOnly is set when connections can't be added, changed or deleted
+	ReadOnly bool
+}
+
+// ViewLoader is implemented by backends showing part of their connections
+// at a time, such as one Bitwarden collection
+type ViewLoader interface {
+	// LoadView loads the connections of the part in view
+	LoadView() error
+}
+
+// KeyAttachmentStorage is implemented by backends with the Attachments
+// capability
+type KeyAttachmentStorage interface {
+	// DownloadKeyAttachment saves the key attached to conn to a private
+	// temporary file and returns its path
+	DownloadKeyAttachment(conn SSHConnection) (string, error)
+}
+
+// LoadStorage loads the connections storage shows, the part in view for a
+// ViewLoader and all of them otherwise
+func LoadStorage(storage Storage) error {
+	if view, ok := storage.(ViewLoader); ok {
+		return view.LoadView()
+	}
+	return storage.Load()
+}
+
+// StorageBackendInfo describes a backend offered on the storage selection
+// screen
+type StorageBackendInfo struct {
+	// Name is how the default_storage setting names the backend
+	Name        string
+	Title       string
+	Description string
+	// Label names the backend's connections in a merged list
+	Label string
+	// Capabilities of the backend's storage once opened
+	Capabilities StorageCapabilities
+}
+
+// storageBackends are the registered backends, in the order the selection
+// screen lists them
+var storageBackends []StorageBackendInfo
+
+// RegisterStorageBackend adds a backend to the selection screen and to the
+// default_storage choices. The UI opens it with the opener registered for
+// the same name.
+func RegisterStorageBackend(info StorageBackendInfo) {
+	storageBackends = append(storageBackends, info)
+	StorageBackends = append(StorageBackends, info.Name)
+}
+
+// RegisteredStorageBackends returns the registered backends in order
+func RegisteredStorageBackends() []StorageBackendInfo {
+	return storageBackends
+}
+
+// LookupStorageBackend returns the registered backend named name
+func LookupStorageBackend(name string) (StorageBackendInfo, bool) {
+	for _, info := range storageBackends {
+		if info.Name == name {
+			return info, true
+		}
+	}
+	return StorageBackendInfo{}, false
+}
+
+func init() {
+	RegisterStorageBackend(StorageBackendInfo{
+		Name:         "local",
+		Label:        "Local",
+		Title:        "Local Storage",
+		Description:  "Local SSH config",
+		Capabilities: sshConfigCapabilities,
+	})
+	RegisterStorageBackend(StorageBackendInfo{
+		Name:         "bitwarden",
+		Label:        "Bitwarden",
+		Title:        "Bitwarden",
+		Description:  "Sync with Bitwarden vault",
+		Capabilities: bitwardenCapabilities,
+	})
+	RegisterStorageBackend(StorageBackendInfo{
+		Name:         "vault",
+		Label:        "Vault",
+		Title:        "HashiCorp Vault",
+		Description:  "Store in a Vault KV v2 mount",
+		Capabilities: vaultCapabilities,
+	})
+	RegisterStorageBackend(StorageBackendInfo{
+		Name:         "keepassxc",
+		Label:        "KeePassXC",
+		Title:        "KeePassXC",
+		Description:  "Entries in a KeePass database",
+		Capabilities: keePassCapabilities,
+	})
+}
+
+// RecordConnection bumps the connect count and last-connected time of the
+// connection with the given ID in the connection usage file, so lists can
+// be ordered by recency or frequency
+func RecordConnection(storage Storage, id string, now time.Time) error {
+	for _, conn := range storage.ListConnections() {
+		if conn.ID != id {
+			continue
+		}
+		return RecordUsage(conn, now)
+	}
+	return errors.New("connection with ID " + id + " not found")
 }
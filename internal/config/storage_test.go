@@ -0,0 +1,59 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRegisteredStorageBackends(t *testing.T) {
+	var names []string
+	for _, info := range RegisteredStorageBackends() {
+		if info.Title == "" || info.Description == "" {
+			t.Errorf("Backend %q is missing its title or description", info.Name)
+		}
+		names = append(names, info.Name)
+	}
+	if !slices.Equal(names, StorageBackends) || !slices.Equal(names, []string{"local", "bitwarden", "vault", "keepassxc"}) {
+		t.Errorf("Expected the built-in backends in order, got %v (settings know %v)", names, StorageBackends)
+	}
+
+	bitwarden, ok := LookupStorageBackend("bitwarden")
+	if !ok || bitwarden.Capabilities != (&BitwardenManager{}).Capabilities() {
+		t.Errorf("Expected the registered Bitwarden capabilities to match the manager's, got %+v", bitwarden.Capabilities)
+	}
+	if !bitwarden.Capabilities.Collections || !bitwarden.Capabilities.Attachments {
+		t.Errorf("Expected Bitwarden to support collections and attachments, got %+v", bitwarden.Capabilities)
+	}
+	if local, _ := LookupStorageBackend("local"); local.Capabilities != (StorageCapabilities{}) {
+		t.Errorf("Expected no extra capabilities for local storage, got %+v", local.Capabilities)
+	}
+	if _, ok := LookupStorageBackend("1password"); ok {
+		t.Error("Expected unknown backends not to be found")
+	}
+}
+
+// viewStorage loads only the part in view
+type viewStorage struct {
+	mapStorage
+	loaded string
+}
+
+func (s *viewStorage) Load() error     { s.loaded = "all"; return nil }
+func (s *viewStorage) LoadView() error { s.loaded = "view"; return nil }
+
+func TestLoadStorage(t *testing.T) {
+	view := &viewStorage{}
+	if err := LoadStorage(view); err != nil || view.loaded != "view" {
+		t.Errorf("Expected a ViewLoader to load its view, loaded %q (%v)", view.loaded, err)
+	}
+
+	plain := &mapStorage{conns: map[string]SSHConnection{}}
+	if err := LoadStorage(plain); err != nil {
+		t.Errorf("Expected plain storage to load, got %v", err)
+	}
+
+	bw := &BitwardenManager{}
+	if err := LoadStorage(bw); err == nil || err.Error() != "no selected collection" {
+		t.Errorf("Expected Bitwarden without a collection in view to fail, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+// ParseTarget reads an ad-hoc destination written as [user@]host[:port] or
+// ssh://[user@]host[:port]. IPv6 addresses with a port go in brackets. The
+// username defaults to the local one, as with ssh. The result is named
+// after the target and has no ID, so it is not tied to any storage.
+func ParseTarget(target string) (SSHConnection, error) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return SSHConnection{}, errors.New("enter a host, e.g. user@host:22")
+	}
+
+	var username, hostPort string
+	if len(target) >= 6 && strings.EqualFold(target[:6], "ssh://") {
+		u, err := url.Parse(target)
+		if err != nil {
+			return SSHConnection{}, fmt.Errorf("invalid ssh:// URI: %w", err)
+		}
+		if u.Path != "" && u.Path != "/" || u.RawQuery != "" {
+			return SSHConnection{}, errors.New("an ssh:// URI cannot have a path or query")
+		}
+		if u.User != nil {
+			// Drop connection parameters such as ;fingerprint=... from
+			// the ssh URI draft
+			username, _, _ = strings.Cut(u.User.Username(), ";")
+		}
+		hostPort = u.Host
+	} else {
+		// Usernames may contain @ themselves (user@domain@host)
+		if i := strings.LastIndex(target, "@"); i >= 0 {
+			username, hostPort = target[:i], target[i+1:]
+		} else {
+			hostPort = target
+		}
+	}
+
+	host, port, err := splitTargetHostPort(hostPort)
+	if err != nil {
+		return SSHConnection{}, err
+	}
+	if username == "" {
+		if username = localUsername(); username == "" {
+			return SSHConnection{}, errors.New("enter a username, e.g. user@" + hostPort)
+		}
+	}
+
+	conn := SSHConnection{
+		Name:     username + "@" + host,
+		Host:     host,
+		Port:     port,
+		Username: username,
+	}
+	if port != 22 {
+		conn.Name = username + "@" + net.JoinHostPort(host, strconv.Itoa(port))
+	}
+	return conn, nil
+}
+
+// splitTargetHostPort splits host[:port], [ipv6]:port or a bare IPv6 address
+func splitTargetHostPort(hostPort string) (string, int, error) {
+	host, portStr := hostPort, ""
+	switch {
+	case strings.HasPrefix(hostPort, "[") && strings.HasSuffix(hostPort, "]"):
+		host = hostPort[1 : len(hostPort)-1]
+	case strings.HasPrefix(hostPort, "[") || strings.Count(hostPort, ":") == 1:
+		var err error
+		if host, portStr, err = net.SplitHostPort(hostPort); err != nil {
+			return "", 0, fmt.Errorf("invalid host %q: %w", hostPort, err)
+		}
+	}
+	if host == "" || strings.ContainsAny(host, " \t/") {
+		return "", 0, fmt.Errorf("invalid host %q", hostPort)
+	}
+
+	port := 22
+	if portStr != "" {
+		p, err := strconv.Atoi(portStr)
+		if err != nil || p < 1 || p > 65535 {
+			return "", 0, fmt.Errorf("invalid port %q", portStr)
+		}
+		port = p
+	}
+	return host, port, nil
+}
+
+// localUsername is the current user's login name without a Windows domain
+func localUsername() string {
+	usr, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	name := usr.Username
+	if i := strings.LastIndex(name, `\`); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,42 @@
+package config
+
+import "testing"
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		target, host, username, name string
+		port                         int
+	}{
+		{"deploy@web.example.com", "web.example.com", "deploy", "deploy@web.example.com", 22},
+		{" deploy@10.0.0.5:2222 ", "10.0.0.5", "deploy", "deploy@10.0.0.5:2222", 2222},
+		{"ssh://root@db:2200", "db", "root", "root@db:2200", 2200},
+		{"ssh://admin@[fe80::1]/", "fe80::1", "admin", "admin@fe80::1", 22},
+		{"SSH://ops;fingerprint=ssh-ed25519-abc@gw:2022", "gw", "ops", "ops@gw:2022", 2022},
+		{"me@[::1]:2022", "::1", "me", "me@[::1]:2022", 2022},
+		{"me@fe80::1", "fe80::1", "me", "me@fe80::1", 22},
+		{"alice@corp.example@bastion", "bastion", "alice@corp.example", "alice@corp.example@bastion", 22},
+	}
+	for _, tt := range tests {
+		conn, err := ParseTarget(tt.target)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", tt.target, err)
+			continue
+		}
+		if conn.Host != tt.host || conn.Port != tt.port || conn.Username != tt.username || conn.Name != tt.name {
+			t.Errorf("%q: got %+v", tt.target, conn)
+		}
+		if conn.ID != "" {
+			t.Errorf("%q: expected no ID, got %q", tt.target, conn.ID)
+		}
+	}
+
+	if conn, err := ParseTarget("web.example.com"); err == nil && conn.Username == "" {
+		t.Errorf("Expected the local username to be used, got %+v", conn)
+	}
+
+	for _, bad := range []string{"", "me@", "me@host:0", "me@host:ssh", "ssh://me@host/path", "me@[::1", "me@my host"} {
+		if _, err := ParseTarget(bad); err == nil {
+			t.Errorf("Expected an error parsing %q", bad)
+		}
+	}
+}
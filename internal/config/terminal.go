@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultTerm is the terminal type sessions ask for unless the connection
+// sets its own
+const DefaultTerm = "xterm-256color"
+
+// CommonTerms are terminal types worth trying with hosts whose terminfo
+// or applications misbehave with the default
+var CommonTerms = []string{"xterm-256color", "xterm", "screen-256color", "tmux-256color", "linux", "vt100"}
+
+var termPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._+-]*$`)
+
+// maxPtyPadding caps the columns or rows held back from the PTY size
+const maxPtyPadding = 20
+
+// TermType returns the terminal type the PTY is requested with
+func (c SSHConnection) TermType() string {
+	if c.Term == "" {
+		return DefaultTerm
+	}
+	return c.Term
+}
+
+// ValidateTerm checks that term can name a terminfo entry
+func ValidateTerm(term string) error {
+	if term != "" && !termPattern.MatchString(term) {
+		return fmt.Errorf("invalid terminal type %q (e.g. %s)", term, strings.Join(CommonTerms, ", "))
+	}
+	return nil
+}
+
+// PtySize returns the window size reported to the server for a terminal
+// of width by height, less the connection's padding. Some appliances
+// wrap or scroll a column or row early and look right with one less.
+func (c SSHConnection) PtySize(width, height int) (int, int) {
+	return max(width-c.PtyPadColumns, 1), max(height-c.PtyPadRows, 1)
+}
+
+// ParsePtyPadding reads the columns and rows held back from the PTY size,
+// written as COLUMNS or COLUMNSxROWS (such as 1 or 1x1). "" is no padding.
+func ParsePtyPadding(s string) (columns, rows int, err error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, 0, nil
+	}
+	colText, rowText, _ := strings.Cut(strings.ToLower(s), "x")
+	columns, err = strconv.Atoi(strings.TrimSpace(colText))
+	if err == nil && rowText != "" {
+		rows, err = strconv.Atoi(strings.TrimSpace(rowText))
+	}
+	if err != nil || columns < 0 || rows < 0 || columns > maxPtyPadding || rows > maxPtyPadding {
+		return 0, 0, fmt.Errorf("invalid window padding %q (expected COLUMNS or COLUMNSxROWS, up to %d)", s, maxPtyPadding)
+	}
+	return columns, rows, nil
+}
+
+// FormatPtyPadding writes the padding the way ParsePtyPadding reads it,
+// "" for none
+func FormatPtyPadding(columns, rows int) string {
+	switch {
+	case columns == 0 && rows == 0:
+		return ""
+	case rows == 0:
+		return strconv.Itoa(columns)
+	default:
+		return fmt.Sprintf("%dx%d", columns, rows)
+	}
+}
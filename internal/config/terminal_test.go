@@ -0,0 +1,56 @@
+package config
+
+import "testing"
+
+func TestParsePtyPadding(t *testing.T) {
+	tests := []struct {
+		in            string
+		columns, rows int
+	}{
+		{"", 0, 0},
+		{"1", 1, 0},
+		{" 2x1 ", 2, 1},
+		{"0X3", 0, 3},
+	}
+	for _, tt := range tests {
+		columns, rows, err := ParsePtyPadding(tt.in)
+		if err != nil || columns != tt.columns || rows != tt.rows {
+			t.Errorf("ParsePtyPadding(%q) = %d, %d, %v; want %d, %d", tt.in, columns, rows, err, tt.columns, tt.rows)
+		}
+		if again, _, _ := ParsePtyPadding(FormatPtyPadding(columns, rows)); again != columns {
+			t.Errorf("Expected %q to round-trip, got %q", tt.in, FormatPtyPadding(columns, rows))
+		}
+	}
+	for _, bad := range []string{"x", "-1", "1x-1", "a", "99"} {
+		if _, _, err := ParsePtyPadding(bad); err == nil {
+			t.Errorf("Expected an error parsing %q", bad)
+		}
+	}
+}
+
+func TestTermAndPtySize(t *testing.T) {
+	conn := SSHConnection{}
+	if conn.TermType() != DefaultTerm {
+		t.Errorf("Expected %s by default, got %s", DefaultTerm, conn.TermType())
+	}
+	if w, h := conn.PtySize(80, 24); w != 80 || h != 24 {
+		t.Errorf("Expected no padding by default, got %dx%d", w, h)
+	}
+
+	conn = SSHConnection{Term: "screen-256color", PtyPadColumns: 1, PtyPadRows: 30}
+	if conn.TermType() != "screen-256color" {
+		t.Errorf("Expected the connection's terminal type, got %s", conn.TermType())
+	}
+	if w, h := conn.PtySize(80, 24); w != 79 || h != 1 {
+		t.Errorf("Expected 79x1 after padding, got %dx%d", w, h)
+	}
+
+	for _, term := range CommonTerms {
+		if err := ValidateTerm(term); err != nil {
+			t.Errorf("Expected %s to be valid, got %v", term, err)
+		}
+	}
+	if err := ValidateTerm("xterm 256"); err == nil {
+		t.Error("Expected a terminal type with a space to be rejected")
+	}
+}
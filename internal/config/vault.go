@@ -0,0 +1,600 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VaultAuthMethod selects how the VaultManager obtains a client token.
+type VaultAuthMethod int
+
+const (
+	VaultAuthToken VaultAuthMethod = iota
+	VaultAuthAppRole
+	VaultAuthOIDC
+)
+
+const (
+	defaultVaultMount      = "secret"
+	defaultVaultPath       = "ssh-x-term"
+	defaultVaultOIDCPort   = "8250"
+	vaultOIDCLoginTimeout  = 3 * time.Minute
+	vaultHTTPClientTimeout = 30 * time.Second
+)
+
+func (m VaultAuthMethod) String() string {
+	switch m {
+	case VaultAuthAppRole:
+		return "AppRole"
+	case VaultAuthOIDC:
+		return "OIDC"
+	default:
+		return "Token"
+	}
+}
+
+// VaultConfig holds the settings needed to reach a HashiCorp Vault KV v2 mount.
+type VaultConfig struct {
+	Address    string
+	Namespace  string
+	Mount      string // KV v2 mount, e.g. "secret"
+	Path       string // Prefix under the mount where connections are stored
+	AuthMethod VaultAuthMethod
+	Token      string
+	RoleID     string
+	SecretID   string
+	OIDCRole   string
+	AuthMount  string // Optional non-default mount of the auth method
+}
+
+// VaultManager implements Storage on top of a Vault KV v2 secrets engine.
+// Each connection is stored as its own secret at <mount>/data/<path>/<id>,
+// along with its private key.
+type VaultManager struct {
+	cfg        *VaultConfig
+	token      string
+	httpClient *http.Client
+	mutex      sync.Mutex
+	items      map[string]SSHConnection
+}
+
+// NewVaultManager creates a new Vault-backed storage manager. Empty settings
+// fall back to the usual VAULT_* environment variables and sensible defaults.
+func NewVaultManager(cfg *VaultConfig) (*VaultManager, error) {
+	if cfg.Address == "" {
+		cfg.Address = os.Getenv("VAULT_ADDR")
+	}
+	if cfg.Address == "" {
+		return nil, errors.New("vault address is required")
+	}
+	if _, err := url.ParseRequestURI(cfg.Address); err != nil {
+		return nil, fmt.Errorf("invalid vault address %q: %w", cfg.Address, err)
+	}
+	cfg.Address = strings.TrimRight(cfg.Address, "/")
+	if cfg.Namespace == "" {
+		cfg.Namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	if cfg.Mount == "" {
+		cfg.Mount = defaultVaultMount
+	}
+	if cfg.Path == "" {
+		cfg.Path = defaultVaultPath
+	}
+	cfg.Mount = strings.Trim(cfg.Mount, "/")
+	cfg.Path = strings.Trim(cfg.Path, "/")
+
+	return &VaultManager{
+		cfg:        cfg,
+		httpClient: &http.Client{Timeout: vaultHTTPClientTimeout},
+		items:      make(map[string]SSHConnection),
+	}, nil
+}
+
+// Config returns the configuration the manager was created with.
+func (vm *VaultManager) Config() *VaultConfig {
+	return vm.cfg
+}
+
+// Login authenticates against Vault using the configured auth method.
+func (vm *VaultManager) Login() error {
+	switch vm.cfg.AuthMethod {
+	case VaultAuthToken:
+		token := vm.cfg.Token
+		if token == "" {
+			token = os.Getenv("VAULT_TOKEN")
+		}
+		if token == "" {
+			return errors.New("vault token is required")
+		}
+		vm.token = token
+		RegisterSecret(token)
+		// Validate the token before accepting it
+		if _, err := vm.request(http.MethodGet, "auth/token/lookup-self", nil); err != nil {
+			vm.token = ""
+			log.Printf("Vault token lookup failed: %v", err)
+			return fmt.Errorf("vault token lookup failed: %w", err)
+		}
+		return nil
+	case VaultAuthAppRole:
+		return vm.loginAppRole()
+	case VaultAuthOIDC:
+		return vm.loginOIDC()
+	}
+	return fmt.Errorf("unsupported vault auth method: %d", vm.cfg.AuthMethod)
+}
+
+func (vm *VaultManager) authMount(def string) string {
+	if vm.cfg.AuthMount != "" {
+		return strings.Trim(vm.cfg.AuthMount, "/")
+	}
+	return def
+}
+
+func (vm *VaultManager) loginAppRole() error {
+	if vm.cfg.RoleID == "" {
+		return errors.New("approle role_id is required")
+	}
+	body := map[string]any{"role_id": vm.cfg.RoleID}
+	if vm.cfg.SecretID != "" {
+		body["secret_id"] = vm.cfg.SecretID
+	}
+	resp, err := vm.request(http.MethodPost, "auth/"+vm.authMount("approle")+"/login", body)
+	if err != nil {
+		log.Printf("Vault AppRole login failed: %v", err)
+		return fmt.Errorf("vault approle login failed: %w", err)
+	}
+	return vm.setTokenFromAuth(resp)
+}
+
+// loginOIDC runs the same browser-based flow as `vault login -method=oidc`:
+// request an auth URL, open it locally and wait for the provider to redirect
+// back to a loopback listener with the authorization code.
+func (vm *VaultManager) loginOIDC() error {
+	mount := vm.authMount("oidc")
+	redirectURI := "http://localhost:" + defaultVaultOIDCPort + "/oidc/callback"
+
+	nonce, err := randomHex(16)
+	if err != nil {
+		return err
+	}
+	resp, err := vm.request(http.MethodPost, "auth/"+mount+"/oidc/auth_url", map[string]any{
+		"role":         vm.cfg.OIDCRole,
+		"redirect_uri": redirectURI,
+		"client_nonce": nonce,
+	})
+	if err != nil {
+		log.Printf("Vault OIDC auth_url request failed: %v", err)
+		return fmt.Errorf("vault oidc auth url request failed: %w", err)
+	}
+	data, _ := resp["data"].(map[string]any)
+	authURL, _ := data["auth_url"].(string)
+	if authURL == "" {
+		return errors.New("vault did not return an OIDC auth url (check the role name and allowed_redirect_uris)")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:"+defaultVaultOIDCPort)
+	if err != nil {
+		log.Printf("Could not start OIDC callback listener: %v", err)
+		return fmt.Errorf("could not listen for oidc callback: %w", err)
+	}
+
+	type callbackResult struct {
+		query url.Values
+		err   error
+	}
+	results := make(chan callbackResult, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oidc/callback", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if e := q.Get("error"); e != "" {
+			fmt.Fprintln(w, "Vault login failed. You can close this window.")
+			results <- callbackResult{err: fmt.Errorf("oidc provider error: %s %s", e, q.Get("error_description"))}
+			return
+		}
+		fmt.Fprintln(w, "Vault login successful. You can close this window and return to ssh-x-term.")
+		results <- callbackResult{query: q}
+	})
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("OIDC callback server error: %v", err)
+		}
+	}()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_ = server.Shutdown(ctx)
+	}()
+
+	if err := OpenBrowser(authURL); err != nil {
+		log.Printf("Could not open browser for OIDC login, visit manually: %s", authURL)
+	}
+
+	var result callbackResult
+	select {
+	case result = <-results:
+	case <-time.After(vaultOIDCLoginTimeout):
+		return errors.New("timed out waiting for OIDC login in the browser")
+	}
+	if result.err != nil {
+		return result.err
+	}
+
+	params := url.Values{}
+	params.Set("state", result.query.Get("state"))
+	params.Set("code", result.query.Get("code"))
+	params.Set("id_token", result.query.Get("id_token"))
+	params.Set("client_nonce", nonce)
+	resp, err = vm.request(http.MethodGet, "auth/"+mount+"/oidc/callback?"+params.Encode(), nil)
+	if err != nil {
+		log.Printf("Vault OIDC callback exchange failed: %v", err)
+		return fmt.Errorf("vault oidc callback failed: %w", err)
+	}
+	return vm.setTokenFromAuth(resp)
+}
+
+func (vm *VaultManager) setTokenFromAuth(resp map[string]any) error {
+	auth, _ := resp["auth"].(map[string]any)
+	token, _ := auth["client_token"].(string)
+	if token == "" {
+		return errors.New("vault login response did not contain a client token")
+	}
+	vm.token = token
+	RegisterSecret(token)
+	return nil
+}
+
+// IsAuthenticated reports whether a client token is available.
+func (vm *VaultManager) IsAuthenticated() bool {
+	return vm.token != ""
+}
+
+// ---- Storage Interface Implementation ----
+
+// vaultCapabilities: secrets are listed all at once and keep the private
+// key of their connection, see DownloadKeyAttachment
+var vaultCapabilities = StorageCapabilities{Attachments: true}
+
+func (vm *VaultManager) Capabilities() StorageCapabilities {
+	return vaultCapabilities
+}
+
+func (vm *VaultManager) Load() error {
+	vm.mutex.Lock()
+	defer vm.mutex.Unlock()
+
+	resp, err := vm.request("LIST", vm.metadataPath(""), nil)
+	if err != nil {
+		var notFound *vaultNotFoundError
+		if errors.As(err, &notFound) {
+			// Nothing stored yet
+			vm.items = make(map[string]SSHConnection)
+			return nil
+		}
+		log.Printf("Vault list connections failed: %v", err)
+		return fmt.Errorf("vault list connections failed: %w", err)
+	}
+
+	data, _ := resp["data"].(map[string]any)
+	keys, _ := data["keys"].([]any)
+
+	items := make(map[string]SSHConnection, len(keys))
+	for _, k := range keys {
+		key, ok := k.(string)
+		if !ok || strings.HasSuffix(key, "/") {
+			continue // Skip nested folders
+		}
+		conn, err := vm.readConnection(key)
+		if err != nil {
+			log.Printf("Skipping vault secret %q: %v", key, err)
+			continue
+		}
+		items[conn.ID] = conn
+	}
+	vm.items = items
+	return nil
+}
+
+// Save is a no-op: every change is written to Vault immediately.
+func (vm *VaultManager) Save() error {
+	return nil
+}
+
+func (vm *VaultManager) AddConnection(conn SSHConnection) error {
+	if conn.ID == "" {
+		conn.ID = generateID()
+	}
+	if err := vm.writeConnection(conn); err != nil {
+		return err
+	}
+	return vm.Load()
+}
+
+func (vm *VaultManager) EditConnection(conn SSHConnection) error {
+	if conn.ID == "" {
+		log.Print("Missing Vault secret ID for edit")
+		return errors.New("missing vault secret ID for edit")
+	}
+	if err := vm.writeConnection(conn); err != nil {
+		return err
+	}
+	return vm.Load()
+}
+
+func (vm *VaultManager) DeleteConnection(id string) error {
+	// Deleting metadata removes all versions of the secret
+	if _, err := vm.request(http.MethodDelete, vm.metadataPath(id), nil); err != nil {
+		log.Printf("Could not delete Vault secret %s: %v", id, err)
+		return fmt.Errorf("could not delete vault secret: %w", err)
+	}
+	return vm.Load()
+}
+
+func (vm *VaultManager) GetConnection(id string) (SSHConnection, bool) {
+	vm.mutex.Lock()
+	defer vm.mutex.Unlock()
+	c, ok := vm.items[id]
+	return c, ok
+}
+
+func (vm *VaultManager) ListConnections() []SSHConnection {
+	vm.mutex.Lock()
+	defer vm.mutex.Unlock()
+	conns := make([]SSHConnection, 0, len(vm.items))
+	for _, c := range vm.items {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// ---- Vault HTTP helpers ----
+
+type vaultNotFoundError struct {
+	path string
+}
+
+func (e *vaultNotFoundError) Error() string {
+	return "vault path not found: " + e.path
+}
+
+func (vm *VaultManager) dataPath(id string) string {
+	return vm.cfg.Mount + "/data/" + vm.cfg.Path + "/" + url.PathEscape(id)
+}
+
+func (vm *VaultManager) metadataPath(id string) string {
+	p := vm.cfg.Mount + "/metadata/" + vm.cfg.Path
+	if id != "" {
+		p += "/" + url.PathEscape(id)
+	}
+	return p
+}
+
+// vaultPrivateKeyField is the field of a connection's secret holding its
+// private key, next to the connection's own fields
+const vaultPrivateKeyField = "private_key"
+
+// readSecret returns the data of the secret at key
+func (vm *VaultManager) readSecret(key string) (map[string]any, error) {
+	resp, err := vm.request(http.MethodGet, vm.cfg.Mount+"/data/"+vm.cfg.Path+"/"+key, nil)
+	if err != nil {
+		return nil, err
+	}
+	outer, _ := resp["data"].(map[string]any)
+	inner, ok := outer["data"].(map[string]any)
+	if !ok {
+		return nil, errors.New("secret has no data")
+	}
+	return inner, nil
+}
+
+func (vm *VaultManager) readConnection(key string) (SSHConnection, error) {
+	inner, err := vm.readSecret(key)
+	if err != nil {
+		return SSHConnection{}, err
+	}
+	raw, err := json.Marshal(inner)
+	if err != nil {
+		return SSHConnection{}, err
+	}
+	var conn SSHConnection
+	if err := json.Unmarshal(raw, &conn); err != nil {
+		return SSHConnection{}, err
+	}
+	if conn.ID == "" {
+		conn.ID, _ = url.PathUnescape(key)
+	}
+	if conn.Port == 0 {
+		conn.Port = 22
+	}
+	RegisterConnectionSecrets(conn)
+	return conn, nil
+}
+
+// writeConnection stores conn and, for key authentication, the private key
+// it points to, so the connection works on machines without the key file.
+// A key file that can't be read is an error, unless the secret holds the
+// key already.
+func (vm *VaultManager) writeConnection(conn SSHConnection) error {
+	var privateKey string
+	if conn.UsePassword {
+		conn.KeyAttachment = ""
+	} else if conn.KeyFile != "" {
+		keyPath := ExpandPath(conn.KeyFile)
+		keyData, err := os.ReadFile(keyPath)
+		switch {
+		case err == nil:
+			privateKey = string(keyData)
+			conn.KeyAttachment = keyAttachmentName(keyPath)
+		case conn.KeyAttachment != "":
+			if privateKey, err = vm.storedPrivateKey(conn.ID); err != nil {
+				log.Printf("Could not read private key of Vault secret %s: %v", conn.ID, err)
+				return fmt.Errorf("could not read private key file '%s' nor the one in vault: %w", conn.KeyFile, err)
+			}
+		default:
+			tip := ""
+			if os.IsPermission(err) {
+				tip = " (permission denied: check file permissions, you may need to run as the user who owns the file or change ownership)"
+			}
+			log.Printf("Could not read private key file '%s': %v%s", conn.KeyFile, err, tip)
+			return errors.New("could not read private key file '" + conn.KeyFile + "': " + err.Error() + tip)
+		}
+		if conn.PublicKey == "" {
+			if pubData, err := os.ReadFile(keyPath + ".pub"); err == nil {
+				conn.PublicKey = string(pubData)
+			}
+		}
+	}
+
+	raw, err := json.Marshal(conn)
+	if err != nil {
+		return err
+	}
+	var data map[string]any
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return err
+	}
+	if privateKey != "" {
+		data[vaultPrivateKeyField] = privateKey
+	}
+	if _, err := vm.request(http.MethodPost, vm.dataPath(conn.ID), map[string]any{"data": data}); err != nil {
+		log.Printf("Could not write Vault secret %s: %v", conn.ID, err)
+		return fmt.Errorf("could not write vault secret: %w", err)
+	}
+	return nil
+}
+
+// storedPrivateKey returns the private key kept in the secret of id
+func (vm *VaultManager) storedPrivateKey(id string) (string, error) {
+	data, err := vm.readSecret(url.PathEscape(id))
+	if err != nil {
+		return "", err
+	}
+	key, _ := data[vaultPrivateKeyField].(string)
+	if key == "" {
+		return "", errors.New("secret has no private key")
+	}
+	return key, nil
+}
+
+// DownloadKeyAttachment saves the private key kept with conn to a new
+// temporary file that only the user can read and returns its path. The
+// caller removes the file once it is no longer needed.
+func (vm *VaultManager) DownloadKeyAttachment(conn SSHConnection) (string, error) {
+	key, err := vm.storedPrivateKey(conn.ID)
+	if err != nil {
+		log.Printf("Could not get private key from Vault: %v", err)
+		return "", fmt.Errorf("could not get private key from vault: %w", err)
+	}
+	file, err := os.CreateTemp("", "sxt-key-*")
+	if err != nil {
+		return "", err
+	}
+	path := file.Name()
+	if _, err := file.WriteString(key); err != nil {
+		file.Close()
+		os.Remove(path)
+		return "", err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// request performs a Vault API call and decodes the JSON response body.
+func (vm *VaultManager) request(method, path string, body any) (map[string]any, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, vm.cfg.Address+"/v1/"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if vm.token != "" {
+		req.Header.Set("X-Vault-Token", vm.token)
+	}
+	if vm.cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", vm.cfg.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := vm.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, &vaultNotFoundError{path: path}
+	}
+	if resp.StatusCode >= 300 {
+		var verr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(respBody, &verr) == nil && len(verr.Errors) > 0 {
+			return nil, fmt.Errorf("%s (HTTP %d)", strings.Join(verr.Errors, "; "), resp.StatusCode)
+		}
+		return nil, fmt.Errorf("unexpected HTTP status %d", resp.StatusCode)
+	}
+
+	result := map[string]any{}
+	if len(bytes.TrimSpace(respBody)) == 0 {
+		return result, nil
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// OpenBrowser opens url with the platform's default handler.
+func OpenBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
@@ -0,0 +1,263 @@
+// Package diff compares texts line by line with the Myers algorithm and
+// formats the result as unified diff hunks
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxEdits is how many inserted and deleted lines the shortest edit script
+// is searched for. Past it the rest of the texts is shown as replaced,
+// which keeps the memory bounded on unrelated files.
+const maxEdits = 1000
+
+// Kind tells whether a line is in both texts or only in one
+type Kind int
+
+const (
+	Equal  Kind = iota
+	Delete      // Only in the first text
+	Insert      // Only in the second text
+)
+
+// Line is a line of the comparison
+type Line struct {
+	Kind Kind
+	Text string
+	A    int // Line number in the first text, 0 for inserted lines
+	B    int // Line number in the second text, 0 for deleted lines
+}
+
+// Hunk is a run of changes with the equal lines around them
+type Hunk struct {
+	AStart, ALen int
+	BStart, BLen int
+	Lines        []Line
+}
+
+// Header returns the unified diff header of the hunk, e.g.
+// "@@ -12,7 +12,8 @@"
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%s +%s @@", span(h.AStart, h.ALen), span(h.BStart, h.BLen))
+}
+
+func span(start, n int) string {
+	if n == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, n)
+}
+
+// SplitLines splits text into lines, without their line endings
+func SplitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// Lines compares a and b, returning every line of both in order
+func Lines(a, b []string) []Line {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	kinds := make([]Kind, 0, len(a)+len(b))
+	for range prefix {
+		kinds = append(kinds, Equal)
+	}
+	kinds = append(kinds, editScript(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for range suffix {
+		kinds = append(kinds, Equal)
+	}
+
+	lines := make([]Line, 0, len(kinds))
+	i, j := 0, 0
+	for _, kind := range kinds {
+		switch kind {
+		case Equal:
+			lines = append(lines, Line{Kind: Equal, Text: a[i], A: i + 1, B: j + 1})
+			i++
+			j++
+		case Delete:
+			lines = append(lines, Line{Kind: Delete, Text: a[i], A: i + 1})
+			i++
+		case Insert:
+			lines = append(lines, Line{Kind: Insert, Text: b[j], B: j + 1})
+			j++
+		}
+	}
+	return lines
+}
+
+// editScript returns the shortest sequence of equal, deleted and inserted
+// lines turning a into b, or a replacement of all of a by all of b when
+// that takes more than maxEdits
+func editScript(a, b []string) []Kind {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxEdits)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int // v[-d..d] before step d
+
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, n, m)
+			}
+		}
+	}
+
+	kinds := make([]Kind, 0, n+m)
+	for range n {
+		kinds = append(kinds, Delete)
+	}
+	for range m {
+		kinds = append(kinds, Insert)
+	}
+	return kinds
+}
+
+// backtrack follows the trace of editScript back from the end of both
+// texts to their start
+func backtrack(trace [][]int, x, y int) []Kind {
+	var kinds []Kind
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d] }
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := 0
+		if d > 0 {
+			prevX = at(prevK)
+		}
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			kinds = append(kinds, Equal)
+			x--
+			y--
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			kinds = append(kinds, Insert)
+		} else {
+			kinds = append(kinds, Delete)
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(kinds)-1; i < j; i, j = i+1, j-1 {
+		kinds[i], kinds[j] = kinds[j], kinds[i]
+	}
+	return kinds
+}
+
+// Hunks groups the changes of lines with up to context equal lines around
+// them, merging changes closer than twice that
+func Hunks(lines []Line, context int) []Hunk {
+	var hunks []Hunk
+	start, end := -1, -1 // Range of lines of the hunk being built
+	flush := func() {
+		if start < 0 {
+			return
+		}
+		h := Hunk{Lines: lines[start:end]}
+		for _, l := range h.Lines {
+			if l.Kind != Insert {
+				h.ALen++
+				if h.AStart == 0 {
+					h.AStart = l.A
+				}
+			}
+			if l.Kind != Delete {
+				h.BLen++
+				if h.BStart == 0 {
+					h.BStart = l.B
+				}
+			}
+		}
+		// An empty side starts at the line before, as in diff -u
+		if h.ALen == 0 {
+			h.AStart = lineBefore(lines, start, func(l Line) int { return l.A })
+		}
+		if h.BLen == 0 {
+			h.BStart = lineBefore(lines, start, func(l Line) int { return l.B })
+		}
+		hunks = append(hunks, h)
+		start = -1
+	}
+
+	for i, l := range lines {
+		if l.Kind == Equal {
+			continue
+		}
+		from := max(i-context, 0)
+		if start >= 0 && from > end {
+			flush()
+		}
+		if start < 0 {
+			start = from
+		}
+		end = min(i+1+context, len(lines))
+	}
+	flush()
+	return hunks
+}
+
+// lineBefore returns the number in one text of the last line before index
+// i that has one there
+func lineBefore(lines []Line, i int, number func(Line) int) int {
+	for i--; i >= 0; i-- {
+		if n := number(lines[i]); n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// Unified formats hunks as a unified diff of aName and bName
+func Unified(aName, bName string, hunks []Hunk) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", aName, bName)
+	for _, h := range hunks {
+		b.WriteString(h.Header() + "\n")
+		for _, l := range h.Lines {
+			switch l.Kind {
+			case Equal:
+				b.WriteString(" ")
+			case Delete:
+				b.WriteString("-")
+			case Insert:
+				b.WriteString("+")
+			}
+			b.WriteString(l.Text + "\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,89 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// apply rebuilds both texts from the lines of a comparison
+func apply(lines []Line) (a, b []string) {
+	for _, l := range lines {
+		if l.Kind != Insert {
+			a = append(a, l.Text)
+		}
+		if l.Kind != Delete {
+			b = append(b, l.Text)
+		}
+	}
+	return a, b
+}
+
+func TestLinesRebuildsBothTexts(t *testing.T) {
+	tests := []struct{ a, b string }{
+		{"", ""},
+		{"", "x\ny"},
+		{"x\ny", ""},
+		{"a\nb\nc", "a\nb\nc"},
+		{"a\nb\nc\nd", "a\nx\nc\nd\ne"},
+		{"listen 80\nroot /var/www\nindex index.html", "listen 443 ssl\nroot /var/www\nindex index.php index.html"},
+	}
+	for _, tt := range tests {
+		a, b := SplitLines(tt.a), SplitLines(tt.b)
+		lines := Lines(a, b)
+		gotA, gotB := apply(lines)
+		if strings.Join(gotA, "\n") != tt.a || strings.Join(gotB, "\n") != tt.b {
+			t.Errorf("Lines(%q, %q) rebuilt %q and %q", tt.a, tt.b, gotA, gotB)
+		}
+	}
+}
+
+func TestLinesIsMinimal(t *testing.T) {
+	a := SplitLines("a\nb\nc\na\nb\nb\na")
+	b := SplitLines("c\nb\na\nb\na\nc")
+	changes := 0
+	for _, l := range Lines(a, b) {
+		if l.Kind != Equal {
+			changes++
+		}
+	}
+	// The classic example of Myers' paper takes 5 edits
+	if changes != 5 {
+		t.Errorf("Expected 5 changed lines, got %d", changes)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	var a, b []string
+	for i := 1; i <= 20; i++ {
+		a = append(a, fmt.Sprint(i))
+		b = append(b, fmt.Sprint(i))
+	}
+	b[2] = "three"
+	b = append(b[:15], b[16:]...) // drop 16
+	b = append(b, "21")
+
+	got := Unified("local/app.conf", "web:/etc/app.conf", Hunks(Lines(a, b), 2))
+	want := `--- local/app.conf
++++ web:/etc/app.conf
+@@ -1,5 +1,5 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+@@ -14,7 +14,7 @@
+ 14
+ 15
+-16
+ 17
+ 18
+ 19
+ 20
++21
+`
+	if got != want {
+		t.Errorf("Expected\n%s\ngot\n%s", want, got)
+	}
+}
@@ -0,0 +1,148 @@
+// Package hooks runs the commands set in the [hooks] settings, and shows
+// desktop notifications, when a session connects or drops and when a long
+// transfer finishes, so sxt can ping its user while they work elsewhere
+package hooks
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// Kind names an event; it is also the SXT_EVENT of the hook command
+type Kind string
+
+const (
+	Connected    Kind = "connected"
+	Disconnected Kind = "disconnected"
+	TransferDone Kind = "transfer_done"
+)
+
+// Event is something that happened to a connection
+type Event struct {
+	Kind       Kind
+	Connection config.SSHConnection
+	Message    string // What happened, e.g. "upload of disk.img finished in 12m5s"
+	Failed     bool
+}
+
+// commandTimeout stops hook commands that hang
+const commandTimeout = time.Minute
+
+// Fire runs the hook command of the event and shows its notification, as
+// the current settings ask, in the background
+func Fire(e Event) {
+	settings := config.CurrentSettings()
+	if command(settings, e.Kind) == "" && !settings.HookNotify {
+		return
+	}
+	go fire(settings, e)
+}
+
+// TransferFinished fires TransferDone for a transfer that took at least
+// the hooks.transfer_min_seconds setting; shorter ones are not worth a ping
+func TransferFinished(conn config.SSHConnection, what string, took time.Duration, err error) {
+	if took < time.Duration(config.CurrentSettings().HookTransferMinSeconds)*time.Second {
+		return
+	}
+	e := Event{Kind: TransferDone, Connection: conn, Failed: err != nil}
+	if err != nil {
+		e.Message = fmt.Sprintf("%s failed after %s: %v", what, took.Round(time.Second), err)
+	} else {
+		e.Message = fmt.Sprintf("%s finished in %s", what, took.Round(time.Second))
+	}
+	Fire(e)
+}
+
+// command returns the hook command set for kind
+func command(settings config.Settings, kind Kind) string {
+	switch kind {
+	case Connected:
+		return settings.HookConnected
+	case Disconnected:
+		return settings.HookDisconnected
+	case TransferDone:
+		return settings.HookTransferDone
+	}
+	return ""
+}
+
+// fire runs the hook command and the notification, waiting for both
+func fire(settings config.Settings, e Event) {
+	if cmd := command(settings, e.Kind); cmd != "" {
+		run(shellCommand(cmd), e)
+	}
+	if settings.HookNotify {
+		run(notifyCommand(title(e), e.Message), e)
+	}
+}
+
+// run starts cmd with the event in its environment, logging what it
+// wrote when it fails. It has no terminal: the TUI owns the screen.
+func run(cmd *exec.Cmd, e Event) {
+	cmd.Env = append(append(os.Environ(), environment(e)...), cmd.Env...)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Start(); err != nil {
+		log.Printf("[hooks] Failed to run %s hook: %v", e.Kind, err)
+		return
+	}
+	timer := time.AfterFunc(commandTimeout, func() { cmd.Process.Kill() })
+	defer timer.Stop()
+	if err := cmd.Wait(); err != nil {
+		log.Printf("[hooks] %s hook %q failed: %v: %s", e.Kind, cmd.String(), err, strings.TrimSpace(output.String()))
+	}
+}
+
+// environment describes the event to the hook command
+func environment(e Event) []string {
+	status := "ok"
+	if e.Failed {
+		status = "failed"
+	}
+	return []string{
+		"SXT_EVENT=" + string(e.Kind),
+		"SXT_NAME=" + e.Connection.Name,
+		"SXT_HOST=" + e.Connection.Host,
+		"SXT_USER=" + e.Connection.Username,
+		fmt.Sprintf("SXT_PORT=%d", e.Connection.Port),
+		"SXT_STATUS=" + status,
+		"SXT_MESSAGE=" + e.Message,
+	}
+}
+
+// title heads the notification of an event, e.g. "sxt: web disconnected"
+func title(e Event) string {
+	name := e.Connection.Name
+	if name == "" {
+		name = e.Connection.Host
+	}
+	switch e.Kind {
+	case Connected:
+		return "sxt: " + name + " connected"
+	case Disconnected:
+		return "sxt: " + name + " disconnected"
+	case TransferDone:
+		if e.Failed {
+			return "sxt: transfer with " + name + " failed"
+		}
+		return "sxt: transfer with " + name + " finished"
+	}
+	return "sxt"
+}
+
+// shellCommand runs command through the platform's shell
+func shellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
@@ -0,0 +1,110 @@
+package hooks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+var web = config.SSHConnection{Name: "web", Host: "web.example.com", Port: 22, Username: "deploy"}
+
+// waitFile waits for a hook to write path and returns what it wrote
+func waitFile(t *testing.T, path string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if data, err := os.ReadFile(path); err == nil && len(data) > 0 {
+			return string(data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("the hook never wrote %s", path)
+	return ""
+}
+
+func TestFireRunsCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook is a POSIX shell command")
+	}
+	out := filepath.Join(t.TempDir(), "out")
+	settings := config.DefaultSettings()
+	settings.HookDisconnected = `echo "$SXT_EVENT $SXT_NAME $SXT_USER@$SXT_HOST:$SXT_PORT $SXT_STATUS: $SXT_MESSAGE" > ` + out
+
+	fire(settings, Event{Kind: Disconnected, Connection: web, Message: "connection lost", Failed: true})
+	got := waitFile(t, out)
+	want := "disconnected web deploy@web.example.com:22 failed: connection lost\n"
+	if got != want {
+		t.Errorf("hook wrote %q, want %q", got, want)
+	}
+
+	// Other events have no command
+	fire(settings, Event{Kind: Connected, Connection: web})
+}
+
+func TestFireNotifies(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("the fake notifier stands in for notify-send")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	script := "#!/bin/sh\nprintf '%s|' \"$@\" > " + out + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "notify-send"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	settings := config.DefaultSettings()
+	settings.HookNotify = true
+	fire(settings, Event{Kind: TransferDone, Connection: web, Message: "upload of disk.img finished in 2m0s"})
+	got := waitFile(t, out)
+	if want := "--app-name=sxt|sxt: transfer with web finished|upload of disk.img finished in 2m0s|"; got != want {
+		t.Errorf("notify-send got %q, want %q", got, want)
+	}
+}
+
+func TestTransferFinished(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hook is a POSIX shell command")
+	}
+	dir := t.TempDir()
+	settings := config.DefaultSettings()
+	settings.HookTransferMinSeconds = 60
+	settings.HookTransferDone = `echo "$SXT_STATUS: $SXT_MESSAGE" > ` + dir + `/"$SXT_NAME"`
+	previous := config.CurrentSettings()
+	config.SetCurrentSettings(settings)
+	t.Cleanup(func() { config.SetCurrentSettings(previous) })
+
+	short := web
+	short.Name = "short"
+	TransferFinished(short, "upload of a.txt", 5*time.Second, nil)
+	TransferFinished(web, "download of disk.img", 90*time.Second, errors.New("connection lost"))
+
+	got := waitFile(t, filepath.Join(dir, "web"))
+	if want := "failed: download of disk.img failed after 1m30s: connection lost\n"; got != want {
+		t.Errorf("hook wrote %q, want %q", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "short")); err == nil {
+		t.Error("a transfer shorter than transfer_min_seconds fired the hook")
+	}
+}
+
+func TestTitle(t *testing.T) {
+	unnamed := config.SSHConnection{Host: "db.example.com"}
+	for _, tt := range []struct {
+		event Event
+		want  string
+	}{
+		{Event{Kind: Connected, Connection: web}, "sxt: web connected"},
+		{Event{Kind: Disconnected, Connection: unnamed}, "sxt: db.example.com disconnected"},
+		{Event{Kind: TransferDone, Connection: web, Failed: true}, "sxt: transfer with web failed"},
+	} {
+		if got := title(tt.event); got != tt.want {
+			t.Errorf("title(%v) = %q, want %q", tt.event.Kind, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package hooks
+
+import "os/exec"
+
+// notifyCommand shows a Notification Center banner through osascript.
+// The texts are passed as arguments, so they need no AppleScript quoting.
+func notifyCommand(title, message string) *exec.Cmd {
+	return exec.Command("osascript",
+		"-e", "on run argv",
+		"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+		"-e", "end run",
+		title, message)
+}
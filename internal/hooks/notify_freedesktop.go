@@ -0,0 +1,11 @@
+//go:build !windows && !darwin
+
+package hooks
+
+import "os/exec"
+
+// notifyCommand shows a desktop notification with notify-send, which
+// talks to the notification daemon of GNOME, KDE, dunst and the like
+func notifyCommand(title, message string) *exec.Cmd {
+	return exec.Command("notify-send", "--app-name=sxt", title, message)
+}
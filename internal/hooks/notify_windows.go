@@ -0,0 +1,20 @@
+package hooks
+
+import "os/exec"
+
+// balloonScript shows a tray balloon, which Windows 10 and later turn
+// into a toast, with the title and message from the environment
+const balloonScript = `Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Information
+$n.Visible = $true
+$n.ShowBalloonTip(5000, $env:SXT_NOTIFY_TITLE, $env:SXT_NOTIFY_MESSAGE, 'Info')
+Start-Sleep -Seconds 6
+$n.Dispose()`
+
+// notifyCommand shows a notification through PowerShell
+func notifyCommand(title, message string) *exec.Cmd {
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", balloonScript)
+	cmd.Env = []string{"SXT_NOTIFY_TITLE=" + title, "SXT_NOTIFY_MESSAGE=" + message}
+	return cmd
+}
@@ -0,0 +1,9 @@
+// Package localshell runs the user's own shell in a pseudo terminal, for
+// the local features of the TUI. Windows uses ConPTY, so no PuTTY or
+// winpty binaries are needed.
+package localshell
+
+// clampSize keeps a pseudo terminal at least one cell in each direction
+func clampSize(width, height int) (int, int) {
+	return max(width, 1), max(height, 1)
+}
@@ -0,0 +1,134 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package localshell
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// Shell is a local shell attached to a pseudo terminal
+type Shell struct {
+	Path string // The shell's executable
+
+	pty     *os.File // The controlling side of the pseudo terminal
+	cmd     *exec.Cmd
+	done    chan struct{}
+	code    int
+	closing sync.Once
+}
+
+// DefaultShell returns the shell in SHELL, or /bin/sh
+func DefaultShell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return "/bin/sh"
+}
+
+// Start runs path, or the default shell when it is empty, in a pseudo
+// terminal of width by height cells, in the current directory
+func Start(path string, width, height int) (*Shell, error) {
+	if path == "" {
+		path = DefaultShell()
+	}
+	ptmx, ttyPath, err := openPTY()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a pseudo terminal: %w", err)
+	}
+	tty, err := os.OpenFile(ttyPath, os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		ptmx.Close()
+		return nil, fmt.Errorf("failed to open %s: %w", ttyPath, err)
+	}
+	// The shell keeps its own copies
+	defer tty.Close()
+
+	s := &Shell{Path: path, pty: ptmx, done: make(chan struct{})}
+	if err := s.Resize(width, height); err != nil {
+		ptmx.Close()
+		return nil, fmt.Errorf("failed to size the pseudo terminal: %w", err)
+	}
+
+	// The shell leads a session of its own with the terminal as its
+	// controlling terminal, so job control and Ctrl+C work as usual
+	s.cmd = exec.Command(path)
+	s.cmd.Env = append(os.Environ(), "TERM=xterm-256color", "COLORTERM=truecolor")
+	s.cmd.Stdin, s.cmd.Stdout, s.cmd.Stderr = tty, tty, tty
+	s.cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	if err := s.cmd.Start(); err != nil {
+		ptmx.Close()
+		return nil, fmt.Errorf("failed to start %s: %w", path, err)
+	}
+	go func() {
+		defer close(s.done)
+		s.cmd.Wait()
+		s.code = s.cmd.ProcessState.ExitCode()
+	}()
+	return s, nil
+}
+
+// Read reads what the shell writes to the terminal. Linux reports the
+// terminal hung up as EIO, which is read as the end of the output.
+func (s *Shell) Read(p []byte) (int, error) {
+	n, err := s.pty.Read(p)
+	if errors.Is(err, syscall.EIO) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Write types p into the shell
+func (s *Shell) Write(p []byte) (int, error) {
+	return s.pty.Write(p)
+}
+
+// Resize changes the size of the pseudo terminal, which signals the
+// programs in it with SIGWINCH
+func (s *Shell) Resize(width, height int) error {
+	width, height = clampSize(width, height)
+	return control(s.pty, func(fd int) error {
+		return unix.IoctlSetWinsize(fd, unix.TIOCSWINSZ, &unix.Winsize{Row: uint16(height), Col: uint16(width)})
+	})
+}
+
+// Wait waits for the shell to exit and returns its exit code, -1 when a
+// signal killed it
+func (s *Shell) Wait() int {
+	<-s.done
+	return s.code
+}
+
+// Close hangs up the terminal, which ends the shell
+func (s *Shell) Close() error {
+	var err error
+	s.closing.Do(func() {
+		err = s.pty.Close()
+		if s.cmd != nil && s.cmd.Process != nil {
+			s.cmd.Process.Signal(syscall.SIGHUP)
+		}
+	})
+	return err
+}
+
+// control runs fn on the file descriptor of f without taking it out of
+// the runtime's poller, so Close still interrupts a Read
+func control(f *os.File, fn func(fd int) error) error {
+	conn, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var fnErr error
+	if err := conn.Control(func(fd uintptr) { fnErr = fn(int(fd)) }); err != nil {
+		return err
+	}
+	return fnErr
+}
@@ -0,0 +1,55 @@
+//go:build !windows && !linux && !darwin
+// +build !windows,!linux,!darwin
+
+package localshell
+
+import (
+	"errors"
+	"os"
+)
+
+var errUnsupported = errors.New("local shells are not supported on this system")
+
+// Shell is a local shell attached to a pseudo terminal
+type Shell struct {
+	Path string // The shell's executable
+}
+
+// DefaultShell returns the shell in SHELL, or /bin/sh
+func DefaultShell() string {
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return "/bin/sh"
+}
+
+// Start runs path, or the default shell when it is empty, in a pseudo
+// terminal of width by height cells
+func Start(path string, width, height int) (*Shell, error) {
+	return nil, errUnsupported
+}
+
+// Read reads what the shell writes to the terminal
+func (s *Shell) Read(p []byte) (int, error) {
+	return 0, errUnsupported
+}
+
+// Write types p into the shell
+func (s *Shell) Write(p []byte) (int, error) {
+	return 0, errUnsupported
+}
+
+// Resize changes the size of the pseudo terminal
+func (s *Shell) Resize(width, height int) error {
+	return errUnsupported
+}
+
+// Wait waits for the shell to exit and returns its exit code
+func (s *Shell) Wait() int {
+	return -1
+}
+
+// Close ends the shell
+func (s *Shell) Close() error {
+	return nil
+}
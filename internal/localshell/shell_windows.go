@@ -0,0 +1,167 @@
+//go:build windows
+// +build windows
+
+package localshell
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Shell is a local shell attached to a ConPTY pseudo console
+type Shell struct {
+	Path string // The shell's executable
+
+	console windows.Handle
+	in      *os.File // Keys typed into the shell
+	out     *os.File // What the shell writes to the console
+	done    chan struct{}
+	code    int
+	closing sync.Once
+	ending  sync.Once // Closes the console
+}
+
+// DefaultShell returns PowerShell 7 or Windows PowerShell when installed,
+// or else the command interpreter in COMSPEC
+func DefaultShell() string {
+	for _, name := range []string{"pwsh.exe", "powershell.exe"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path
+		}
+	}
+	if comspec := os.Getenv("COMSPEC"); comspec != "" {
+		return comspec
+	}
+	return "cmd.exe"
+}
+
+// Start runs path, or the default shell when it is empty, in a pseudo
+// console of width by height cells, in the current directory
+func Start(path string, width, height int) (*Shell, error) {
+	if path == "" {
+		path = DefaultShell()
+	}
+	width, height = clampSize(width, height)
+
+	// The pseudo console reads keys from one pipe and writes to the other
+	var consoleIn, inWrite, outRead, consoleOut windows.Handle
+	if err := windows.CreatePipe(&consoleIn, &inWrite, nil, 0); err != nil {
+		return nil, fmt.Errorf("failed to create the console input pipe: %w", err)
+	}
+	if err := windows.CreatePipe(&outRead, &consoleOut, nil, 0); err != nil {
+		windows.CloseHandle(consoleIn)
+		windows.CloseHandle(inWrite)
+		return nil, fmt.Errorf("failed to create the console output pipe: %w", err)
+	}
+	var console windows.Handle
+	err := windows.CreatePseudoConsole(windows.Coord{X: int16(width), Y: int16(height)}, consoleIn, consoleOut, 0, &console)
+	// The pseudo console keeps its own copies of its ends of the pipes
+	windows.CloseHandle(consoleIn)
+	windows.CloseHandle(consoleOut)
+	in := os.NewFile(uintptr(inWrite), "conpty-in")
+	out := os.NewFile(uintptr(outRead), "conpty-out")
+	if err != nil {
+		in.Close()
+		out.Close()
+		return nil, fmt.Errorf("failed to create a pseudo console (Windows 10 1809 or later is needed): %w", err)
+	}
+
+	process, err := startAttached(path, console)
+	if err != nil {
+		windows.ClosePseudoConsole(console)
+		in.Close()
+		out.Close()
+		return nil, fmt.Errorf("failed to start %s: %w", path, err)
+	}
+
+	s := &Shell{Path: path, console: console, in: in, out: out, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		defer windows.CloseHandle(process)
+		windows.WaitForSingleObject(process, windows.INFINITE)
+		var code uint32
+		if err := windows.GetExitCodeProcess(process, &code); err == nil {
+			s.code = int(code)
+		}
+		// The output pipe only ends once the console is closed
+		s.closeConsole()
+	}()
+	return s, nil
+}
+
+// startAttached starts path with console as its console and returns the
+// handle of the process
+func startAttached(path string, console windows.Handle) (windows.Handle, error) {
+	attrs, err := windows.NewProcThreadAttributeList(1)
+	if err != nil {
+		return 0, err
+	}
+	defer attrs.Delete()
+	// The attribute's value is the console handle itself
+	if err := attrs.Update(windows.PROC_THREAD_ATTRIBUTE_PSEUDOCONSOLE, *(*unsafe.Pointer)(unsafe.Pointer(&console)), unsafe.Sizeof(console)); err != nil {
+		return 0, err
+	}
+
+	startup := &windows.StartupInfoEx{ProcThreadAttributeList: attrs.List()}
+	startup.Cb = uint32(unsafe.Sizeof(*startup))
+	// Without this the shell would also get the TUI's own standard handles
+	startup.Flags = windows.STARTF_USESTDHANDLES
+
+	commandLine, err := windows.UTF16PtrFromString(windows.ComposeCommandLine([]string{path}))
+	if err != nil {
+		return 0, err
+	}
+	var info windows.ProcessInformation
+	err = windows.CreateProcess(nil, commandLine, nil, nil, false,
+		windows.EXTENDED_STARTUPINFO_PRESENT|windows.CREATE_UNICODE_ENVIRONMENT,
+		nil, nil, &startup.StartupInfo, &info)
+	if err != nil {
+		return 0, err
+	}
+	windows.CloseHandle(info.Thread)
+	return info.Process, nil
+}
+
+// Read reads what the shell writes to the console, as VT sequences
+func (s *Shell) Read(p []byte) (int, error) {
+	return s.out.Read(p)
+}
+
+// Write types p into the shell
+func (s *Shell) Write(p []byte) (int, error) {
+	return s.in.Write(p)
+}
+
+// Resize changes the size of the pseudo console
+func (s *Shell) Resize(width, height int) error {
+	width, height = clampSize(width, height)
+	return windows.ResizePseudoConsole(s.console, windows.Coord{X: int16(width), Y: int16(height)})
+}
+
+// Wait waits for the shell to exit and returns its exit code
+func (s *Shell) Wait() int {
+	<-s.done
+	return s.code
+}
+
+// Close closes the pseudo console, which ends the shell
+func (s *Shell) Close() error {
+	s.closing.Do(func() {
+		// Closing the output first keeps ClosePseudoConsole from waiting
+		// for it to be drained
+		s.in.Close()
+		s.out.Close()
+		s.closeConsole()
+	})
+	return nil
+}
+
+// closeConsole closes the pseudo console once, ending its output
+func (s *Shell) closeConsole() {
+	s.ending.Do(func() { windows.ClosePseudoConsole(s.console) })
+}
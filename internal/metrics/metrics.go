@@ -0,0 +1,255 @@
+// Package metrics counts sessions, reconnects and file transfers per host
+// and publishes the counts for graphing: on a local HTTP endpoint in the
+// Prometheus text format, or in a JSON file rewritten periodically
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/transfer"
+)
+
+// HostStats are the counts of one host since sxt started
+type HostStats struct {
+	OpenSessions     int64 `json:"open_sessions"`
+	Sessions         int64 `json:"sessions_total"`
+	Reconnects       int64 `json:"reconnects_total"`
+	BytesUploaded    int64 `json:"bytes_uploaded_total"`
+	BytesDownloaded  int64 `json:"bytes_downloaded_total"`
+	Transfers        int64 `json:"transfers_total"`
+	TransferFailures int64 `json:"transfer_failures_total"`
+}
+
+func (h *HostStats) add(other HostStats) {
+	h.OpenSessions += other.OpenSessions
+	h.Sessions += other.Sessions
+	h.Reconnects += other.Reconnects
+	h.BytesUploaded += other.BytesUploaded
+	h.BytesDownloaded += other.BytesDownloaded
+	h.Transfers += other.Transfers
+	h.TransferFailures += other.TransferFailures
+}
+
+// Snapshot holds the counts of every host at one time
+type Snapshot struct {
+	Time  time.Time            `json:"time"`
+	Hosts map[string]HostStats `json:"hosts"`
+	Total HostStats            `json:"total"`
+}
+
+var registry = struct {
+	sync.Mutex
+	hosts map[string]*HostStats
+}{hosts: make(map[string]*HostStats)}
+
+func update(host string, change func(*HostStats)) {
+	registry.Lock()
+	defer registry.Unlock()
+	stats, ok := registry.hosts[host]
+	if !ok {
+		stats = &HostStats{}
+		registry.hosts[host] = stats
+	}
+	change(stats)
+}
+
+// SessionOpened counts a terminal session to host that started
+func SessionOpened(host string) {
+	update(host, func(s *HostStats) {
+		s.OpenSessions++
+		s.Sessions++
+	})
+}
+
+// SessionClosed counts a terminal session to host that ended
+func SessionClosed(host string) {
+	update(host, func(s *HostStats) {
+		s.OpenSessions = max(s.OpenSessions-1, 0)
+	})
+}
+
+// Reconnected counts a session to host opened again after it ended
+func Reconnected(host string) {
+	update(host, func(s *HostStats) { s.Reconnects++ })
+}
+
+// Transferred counts n bytes of file data copied to or from host
+func Transferred(host string, direction transfer.Direction, n int64) {
+	if n <= 0 {
+		return
+	}
+	update(host, func(s *HostStats) {
+		if direction == transfer.Download {
+			s.BytesDownloaded += n
+		} else {
+			s.BytesUploaded += n
+		}
+	})
+}
+
+// TransferDone counts a queued transfer with host that finished, failed
+// when err is set
+func TransferDone(host string, err error) {
+	update(host, func(s *HostStats) {
+		s.Transfers++
+		if err != nil {
+			s.TransferFailures++
+		}
+	})
+}
+
+// Current returns the counts as they are now
+func Current() Snapshot {
+	registry.Lock()
+	defer registry.Unlock()
+	snapshot := Snapshot{Time: time.Now(), Hosts: make(map[string]HostStats, len(registry.hosts))}
+	for host, stats := range registry.hosts {
+		snapshot.Hosts[host] = *stats
+		snapshot.Total.add(*stats)
+	}
+	return snapshot
+}
+
+// promMetrics are the series written for each host, in order
+var promMetrics = []struct {
+	name, kind, help string
+	labels           string
+	value            func(HostStats) int64
+}{
+	{"sxt_open_sessions", "gauge", "Terminal sessions open now.", "", func(s HostStats) int64 { return s.OpenSessions }},
+	{"sxt_sessions_total", "counter", "Terminal sessions opened.", "", func(s HostStats) int64 { return s.Sessions }},
+	{"sxt_reconnects_total", "counter", "Sessions opened again after they ended.", "", func(s HostStats) int64 { return s.Reconnects }},
+	{"sxt_transferred_bytes_total", "counter", "File data copied by the file manager.", `direction="upload"`, func(s HostStats) int64 { return s.BytesUploaded }},
+	{"sxt_transferred_bytes_total", "", "", `direction="download"`, func(s HostStats) int64 { return s.BytesDownloaded }},
+	{"sxt_transfers_total", "counter", "Queued transfers finished, failed or not.", "", func(s HostStats) int64 { return s.Transfers }},
+	{"sxt_transfer_failures_total", "counter", "Queued transfers that failed.", "", func(s HostStats) int64 { return s.TransferFailures }},
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus writes the snapshot in the Prometheus text format, one
+// series per host
+func WritePrometheus(w io.Writer, snapshot Snapshot) error {
+	hosts := slices.Sorted(maps.Keys(snapshot.Hosts))
+	var b strings.Builder
+	for _, metric := range promMetrics {
+		if metric.help != "" {
+			fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind)
+		}
+		for _, host := range hosts {
+			labels := `host="` + labelEscaper.Replace(host) + `"`
+			if metric.labels != "" {
+				labels += "," + metric.labels
+			}
+			fmt.Fprintf(&b, "%s{%s} %d\n", metric.name, labels, metric.value(snapshot.Hosts[host]))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Handler serves /metrics in the Prometheus text format and /stats.json
+// as the JSON of a Snapshot
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WritePrometheus(w, Current())
+	})
+	mux.HandleFunc("/stats.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Current())
+	})
+	return mux
+}
+
+// WriteStatsFile writes the current counts to path as JSON. The file is
+// replaced in one step, so readers never see half of it.
+func WriteStatsFile(path string) error {
+	data, err := json.MarshalIndent(Current(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".stats-*.json")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Start publishes the counts on a local HTTP endpoint at address and in
+// the stats file at statsFile every interval, each when set. The stop
+// function it returns shuts the endpoint down and writes the file a last
+// time.
+func Start(address, statsFile string, interval time.Duration) (stop func(), err error) {
+	var server *http.Server
+	if address != "" {
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			return nil, fmt.Errorf("metrics endpoint: %w", err)
+		}
+		server = &http.Server{Handler: Handler(), ReadHeaderTimeout: 5 * time.Second}
+		go func() {
+			if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+				log.Printf("[metrics] Endpoint stopped: %v", err)
+			}
+		}()
+		log.Printf("[metrics] Serving http://%s/metrics", listener.Addr())
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	if statsFile != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				if err := WriteStatsFile(statsFile); err != nil {
+					log.Printf("[metrics] Failed to write %s: %v", statsFile, err)
+				}
+				select {
+				case <-ticker.C:
+				case <-done:
+					if err := WriteStatsFile(statsFile); err != nil {
+						log.Printf("[metrics] Failed to write %s: %v", statsFile, err)
+					}
+					return
+				}
+			}
+		}()
+	}
+
+	return func() {
+		if server != nil {
+			server.Close()
+		}
+		close(done)
+		wg.Wait()
+	}, nil
+}
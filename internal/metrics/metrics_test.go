@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/transfer"
+)
+
+// reset forgets the counts of earlier tests
+func reset() {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.hosts = make(map[string]*HostStats)
+}
+
+func TestCounts(t *testing.T) {
+	reset()
+	SessionOpened("web")
+	SessionOpened("web")
+	SessionClosed("web")
+	Reconnected("web")
+	Transferred("web", transfer.Upload, 100)
+	Transferred("db", transfer.Download, 50)
+	TransferDone("db", nil)
+	TransferDone("db", errors.New("connection lost"))
+	SessionClosed("db") // never below zero
+
+	snapshot := Current()
+	web := HostStats{OpenSessions: 1, Sessions: 2, Reconnects: 1, BytesUploaded: 100}
+	db := HostStats{BytesDownloaded: 50, Transfers: 2, TransferFailures: 1}
+	if snapshot.Hosts["web"] != web {
+		t.Errorf("web = %+v, want %+v", snapshot.Hosts["web"], web)
+	}
+	if snapshot.Hosts["db"] != db {
+		t.Errorf("db = %+v, want %+v", snapshot.Hosts["db"], db)
+	}
+	if snapshot.Total.Sessions != 2 || snapshot.Total.Transfers != 2 || snapshot.Total.BytesUploaded+snapshot.Total.BytesDownloaded != 150 {
+		t.Errorf("Total = %+v", snapshot.Total)
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	reset()
+	SessionOpened(`odd"host`)
+	Transferred("db", transfer.Download, 7)
+
+	var b strings.Builder
+	if err := WritePrometheus(&b, Current()); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"# TYPE sxt_open_sessions gauge\n",
+		`sxt_open_sessions{host="odd\"host"} 1` + "\n",
+		`sxt_transferred_bytes_total{host="db",direction="download"} 7` + "\n",
+		`sxt_transferred_bytes_total{host="db",direction="upload"} 0` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	// One HELP and TYPE per metric, not per series
+	if n := strings.Count(out, "# TYPE sxt_transferred_bytes_total"); n != 1 {
+		t.Errorf("sxt_transferred_bytes_total has %d TYPE lines", n)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	reset()
+	SessionOpened("web")
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+
+	res, err := server.Client().Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if !strings.Contains(string(body), `sxt_sessions_total{host="web"} 1`) {
+		t.Errorf("/metrics = %s", body)
+	}
+
+	res, err = server.Client().Get(server.URL + "/stats.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snapshot Snapshot
+	err = json.NewDecoder(res.Body).Decode(&snapshot)
+	res.Body.Close()
+	if err != nil || snapshot.Hosts["web"].OpenSessions != 1 {
+		t.Errorf("/stats.json = %+v, %v", snapshot, err)
+	}
+}
+
+func TestStartStatsFile(t *testing.T) {
+	reset()
+	path := filepath.Join(t.TempDir(), "stats", "sxt.json")
+	stop, err := Start("", path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	TransferDone("web", nil)
+	// Stopping writes the counts since the last write
+	stop()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Hosts["web"].Transfers != 1 {
+		t.Errorf("stats file = %s", data)
+	}
+}
+
+func TestStartEndpoint(t *testing.T) {
+	stop, err := Start("127.0.0.1:0", "", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop()
+
+	if _, err := Start("256.0.0.1:1", "", time.Minute); err == nil {
+		t.Error("Start on an invalid address succeeded")
+	}
+}
@@ -0,0 +1,144 @@
+// Package mux opens commands in a new window, tab or pane of the terminal
+// multiplexer sxt runs in: tmux, zellij or WezTerm.
+package mux
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Supported multiplexers
+const (
+	Tmux    = "tmux"
+	Zellij  = "zellij"
+	WezTerm = "wezterm"
+)
+
+// Placements of a new session
+const (
+	// Window is a tmux window, a WezTerm tab or a zellij floating pane
+	// (zellij cannot start a new tab running a command)
+	Window     = "window"
+	SplitRight = "split-right"
+	SplitDown  = "split-down"
+)
+
+// Detect returns the multiplexer to open sessions in, or "" for none.
+// With preference "auto" it is the one sxt runs inside, checking the
+// innermost first; a named preference is used when its CLI is installed.
+func Detect(preference string) string {
+	if preference != "" && preference != "auto" {
+		if _, err := exec.LookPath(preference); err != nil {
+			log.Printf("[mux] %s is selected but not installed: %v", preference, err)
+			return ""
+		}
+		return preference
+	}
+	switch {
+	case os.Getenv("TMUX") != "":
+		return Tmux
+	case os.Getenv("ZELLIJ") != "":
+		return Zellij
+	case os.Getenv("WEZTERM_PANE") != "":
+		return WezTerm
+	}
+	return ""
+}
+
+// Open runs argv in a new session of the multiplexer at placement, named or
+// titled name where the multiplexer allows it
+func Open(multiplexer, placement, name string, argv []string) error {
+	switch multiplexer {
+	case Tmux:
+		return openTmux(placement, name, argv)
+	case Zellij:
+		return openZellij(placement, name, argv)
+	case WezTerm:
+		return openWezTerm(placement, name, argv)
+	}
+	return fmt.Errorf("unsupported multiplexer %q", multiplexer)
+}
+
+// run executes a multiplexer CLI and returns its trimmed output
+func run(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %v: %s", name, args[0], err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func openTmux(placement, name string, argv []string) error {
+	// tmux runs a single argument through the shell
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		quoted[i] = shellQuote(arg)
+	}
+	command := strings.Join(quoted, " ")
+
+	switch placement {
+	case SplitRight, SplitDown:
+		direction := "-h"
+		if placement == SplitDown {
+			direction = "-v"
+		}
+		args := []string{"split-window", direction, "-P", "-F", "#{pane_id}"}
+		if pane := os.Getenv("TMUX_PANE"); pane != "" {
+			args = append(args, "-t", pane)
+		}
+		// Panes have titles rather than names
+		paneID, err := run("tmux", append(args, command)...)
+		if err != nil {
+			return err
+		}
+		_, err = run("tmux", "select-pane", "-t", paneID, "-T", name)
+		return err
+	}
+	_, err := run("tmux", "new-window", "-n", name, command)
+	return err
+}
+
+func openZellij(placement, name string, argv []string) error {
+	args := []string{"run", "--close-on-exit", "--name", name}
+	switch placement {
+	case SplitRight:
+		args = append(args, "--direction", "right")
+	case SplitDown:
+		args = append(args, "--direction", "down")
+	default:
+		args = append(args, "--floating")
+	}
+	_, err := run("zellij", append(append(args, "--"), argv...)...)
+	return err
+}
+
+func openWezTerm(placement, name string, argv []string) error {
+	var args []string
+	switch placement {
+	case SplitRight:
+		args = []string{"cli", "split-pane", "--right"}
+	case SplitDown:
+		args = []string{"cli", "split-pane", "--bottom"}
+	default:
+		args = []string{"cli", "spawn"}
+	}
+	paneID, err := run("wezterm", append(append(args, "--"), argv...)...)
+	if err != nil {
+		return err
+	}
+	// Only tabs have titles; a split shares the tab of sxt
+	if placement != SplitRight && placement != SplitDown {
+		if _, err := run("wezterm", "cli", "set-tab-title", "--pane-id", paneID, name); err != nil {
+			log.Printf("[mux] Failed to set the WezTerm tab title: %v", err)
+		}
+	}
+	return nil
+}
+
+// shellQuote quotes s as a single POSIX shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,112 @@
+package ssh
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// archiveFormats maps archive name suffixes to their format, longest first
+var archiveFormats = []struct{ suffix, format string }{
+	{".tar.gz", "tar.gz"},
+	{".tgz", "tar.gz"},
+	{".tar.bz2", "tar.bz2"},
+	{".tbz2", "tar.bz2"},
+	{".tar.xz", "tar.xz"},
+	{".txz", "tar.xz"},
+	{".tar", "tar"},
+	{".zip", "zip"},
+}
+
+// ArchiveFormat returns the format of an archive from its name: "tar.gz",
+// "tar.bz2", "tar.xz", "tar" or "zip", or "" for other files
+func ArchiveFormat(name string) string {
+	name = strings.ToLower(name)
+	for _, f := range archiveFormats {
+		if strings.HasSuffix(name, f.suffix) {
+			return f.format
+		}
+	}
+	return ""
+}
+
+// CreateArchive packs the entries names of the remote directory dir into
+// archive with tar or zip on the server, so many small files travel as one.
+// The format comes from the name of archive: tar.gz, tar or zip. It
+// returns the size of the archive.
+func (s *SFTPClient) CreateArchive(dir string, names []string, archive string) (int64, error) {
+	if s.sftpClient == nil {
+		return 0, fmt.Errorf("SFTP client not connected")
+	}
+	if _, err := s.sftpClient.Lstat(archive); err == nil {
+		return 0, fmt.Errorf("%s already exists", archive)
+	}
+
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		// zip takes no --, so names starting with - are made relative
+		quoted[i] = ShellQuote("./" + name)
+	}
+	var tool, command string
+	switch ArchiveFormat(archive) {
+	case "tar.gz":
+		tool, command = "tar", "tar -czf "+ShellQuote(archive)+" -- "
+	case "tar":
+		tool, command = "tar", "tar -cf "+ShellQuote(archive)+" -- "
+	case "zip":
+		tool, command = "zip", "zip -qr "+ShellQuote(archive)+" "
+	default:
+		return 0, fmt.Errorf("%s is not a .tar.gz, .tar or .zip name", path.Base(archive))
+	}
+	command = "cd -- " + ShellQuote(dir) + " && " + command + strings.Join(quoted, " ")
+
+	if err := s.runArchiver(tool, command); err != nil {
+		s.sftpClient.Remove(archive)
+		return 0, err
+	}
+	// A forced sftp subsystem can accept the command without running it
+	info, err := s.sftpClient.Lstat(archive)
+	if err != nil {
+		return 0, fmt.Errorf("the server does not run commands, so archives can't be made there")
+	}
+	return info.Size(), nil
+}
+
+// ExtractArchive unpacks a remote archive into its directory with tar or
+// unzip on the server. Files already there are not overwritten.
+func (s *SFTPClient) ExtractArchive(archive string) error {
+	if s.sftpClient == nil {
+		return fmt.Errorf("SFTP client not connected")
+	}
+	a, dir := ShellQuote(archive), ShellQuote(path.Dir(archive))
+	var tool, command string
+	switch ArchiveFormat(archive) {
+	case "tar.gz":
+		tool, command = "tar", "tar -xzkf "+a+" -C "+dir
+	case "tar.bz2":
+		tool, command = "tar", "tar -xjkf "+a+" -C "+dir
+	case "tar.xz":
+		tool, command = "tar", "tar -xJkf "+a+" -C "+dir
+	case "tar":
+		tool, command = "tar", "tar -xkf "+a+" -C "+dir
+	case "zip":
+		tool, command = "unzip", "unzip -q -n "+a+" -d "+dir
+	default:
+		return fmt.Errorf("%s is not an archive", path.Base(archive))
+	}
+	return s.runArchiver(tool, command)
+}
+
+// runArchiver runs an archiving command over an exec channel
+func (s *SFTPClient) runArchiver(tool, command string) error {
+	res, err := s.run(command)
+	switch {
+	case err != nil:
+		return err
+	case res.ExitStatus == 127:
+		return fmt.Errorf("%s is not installed on the server", tool)
+	case res.ExitStatus != 0:
+		return fmt.Errorf("%s failed: %s", tool, strings.TrimSpace(res.Stderr))
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// installKeyScript appends the key read from stdin to ~/.ssh/authorized_keys
+// unless it is already present, creating the directory and file with the
+// modes sshd's StrictModes expects. It also makes sure an existing file ends
+// with a newline so the key isn't glued onto the previous entry.
+const installKeyScript = `umask 077
+mkdir -p "$HOME/.ssh" && chmod 700 "$HOME/.ssh" || exit 1
+f="$HOME/.ssh/authorized_keys"
+touch "$f" && chmod 600 "$f" || exit 1
+IFS= read -r key
+if grep -qxF "$key" "$f"; then exit 0; fi
+if [ -s "$f" ] && [ -n "$(tail -c 1 "$f")" ]; then echo >> "$f"; fi
+printf '%s\n' "$key" >> "$f"`
+
+// InstallPublicKey adds an authorized_keys line on the remote host, like
+// ssh-copy-id. Installing a key that is already present is a no-op.
+func (c *Client) InstallPublicKey(authorizedKey string) error {
+	authorizedKey = strings.TrimSpace(authorizedKey)
+	if authorizedKey == "" || strings.ContainsAny(authorizedKey, "\r\n") {
+		return errors.New("public key must be a single authorized_keys line")
+	}
+	res, err := c.RunWithInput("sh -c "+ShellQuote(installKeyScript), authorizedKey+"\n")
+	if err != nil {
+		return err
+	}
+	if res.ExitStatus != 0 {
+		return fmt.Errorf("installing public key exited with status %d: %s", res.ExitStatus, strings.TrimSpace(res.Stderr))
+	}
+	return nil
+}
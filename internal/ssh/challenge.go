@@ -0,0 +1,94 @@
+package ssh
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/term"
+)
+
+// ChallengeHandler answers the prompts of a keyboard-interactive login,
+// such as one-time passwords. It returns one answer per question.
+type ChallengeHandler func(conn config.SSHConnection, name, instruction string, questions []string, echos []bool) ([]string, error)
+
+var (
+	challengeMu      sync.Mutex
+	challengeHandler ChallengeHandler = TerminalChallenge
+)
+
+// SetChallengeHandler replaces the handler used for keyboard-interactive
+// prompts. The TUI installs one that shows a form; the default reads from
+// the terminal.
+func SetChallengeHandler(handler ChallengeHandler) {
+	challengeMu.Lock()
+	defer challengeMu.Unlock()
+	challengeHandler = handler
+}
+
+// keyboardInteractive builds the challenge callback for a connection. A
+// lone password prompt is answered with the saved password once; anything
+// else (or a second password prompt after a wrong one) goes to the handler.
+func keyboardInteractive(conn config.SSHConnection) ssh.KeyboardInteractiveChallenge {
+	passwordSent := false
+	return func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+		if len(questions) == 0 {
+			// Informational round, e.g. a banner before the real prompt
+			return nil, nil
+		}
+		if conn.Password != "" && conn.UsePassword && !passwordSent &&
+			len(questions) == 1 && !echos[0] && strings.Contains(strings.ToLower(questions[0]), "password") {
+			passwordSent = true
+			return []string{conn.Password}, nil
+		}
+
+		challengeMu.Lock()
+		handler := challengeHandler
+		challengeMu.Unlock()
+		if handler == nil {
+			return nil, errors.New("server requires keyboard-interactive authentication")
+		}
+		return handler(conn, name, instruction, questions, echos)
+	}
+}
+
+// TerminalChallenge prompts for keyboard-interactive answers on the
+// terminal, hiding input the server marks as secret
+func TerminalChallenge(conn config.SSHConnection, name, instruction string, questions []string, echos []bool) ([]string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("%s@%s requires keyboard-interactive authentication, which needs a terminal", conn.Username, conn.Host)
+	}
+	if name != "" {
+		fmt.Fprintln(os.Stderr, name)
+	}
+	if instruction != "" {
+		fmt.Fprintln(os.Stderr, instruction)
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	answers := make([]string, len(questions))
+	for i, question := range questions {
+		fmt.Fprint(os.Stderr, question)
+		if echos[i] {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return nil, fmt.Errorf("failed to read answer: %w", err)
+			}
+			answers[i] = strings.TrimRight(line, "\r\n")
+			continue
+		}
+		secret, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read answer: %w", err)
+		}
+		answers[i] = string(secret)
+	}
+	return answers, nil
+}
@@ -6,6 +6,7 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
@@ -40,6 +41,12 @@ func (e *PasswordRequiredError) Error() string {
 // Client represents an SSH client connection
 type Client struct {
 	conn *ssh.Client
+	// shared is set on clients from Connect, whose connection may serve
+	// other clients too
+	shared  *sharedConn
+	release sync.Once
+	// host labels the client's sessions and transfers in the metrics
+	host string
 }
 
 // NewClient creates a new SSH client from a connection configuration
@@ -57,18 +64,26 @@ func NewClient(connConfig config.SSHConnection) (*Client, error) {
 		}
 		connConfig.Password = password
 	}
+	// Passwords typed into a prompt weren't seen by any backend
+	config.RegisterConnectionSecrets(connConfig)
 
 	// Prepare Auth Methods
 	var authMethods []ssh.AuthMethod
 	var agentAuthAvailable bool
+	var agentClient agent.ExtendedAgent
+	var preferredKey ssh.PublicKey // Offered first by the agent, e.g. a security key
 
 	// 1. SSH Agent Support (Attempt this first for keys)
-	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" && !connConfig.UsePassword {
+	if socket := agentSocket(connConfig); socket != "" && !connConfig.UsePassword {
 		// Only use SSH Agent for non-password connections
 		log.Printf("[NewClient] SSH_AUTH_SOCK found: %s (will attempt agent auth)", socket)
 		if conn, err := net.Dial("unix", socket); err == nil {
-			agentClient := agent.NewClient(conn)
-			authMethods = append(authMethods, ssh.PublicKeysCallback(agentClient.Signers))
+			agentClient = agent.NewClient(conn)
+			// Only one publickey method is tried per connection, so the
+			// preferred key is moved to the front of the agent's keys
+			authMethods = append(authMethods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+				return agentSigners(agentClient, preferredKey)
+			}))
 			agentAuthAvailable = true
 			log.Printf("[NewClient] Added SSH agent auth method")
 		} else {
@@ -110,7 +125,22 @@ func NewClient(connConfig config.SSHConnection) (*Client, error) {
 			if err != nil {
 				log.Printf("[NewClient] Failed to read key file %s: %v", keyFile, err)
 				// Don't fail - SSH agent might have the key
+			} else if pub, err := privateKeyPublicPart(keyBytes); err == nil && isSecurityKeyType(pub.Type()) {
+				// FIDO2 keys cannot be used directly; the agent signs with them
+				log.Printf("[NewClient] %s is a security key (%s)", keyFile, pub.Type())
+				if agentClient == nil {
+					return nil, fmt.Errorf("security key %s needs a running ssh-agent to sign (SSH_AUTH_SOCK is not set)", keyFile)
+				}
+				if err := ensureSecurityKeyInAgent(agentClient, keyFile, pub); err != nil {
+					return nil, err
+				}
+				preferredKey = pub
 			} else {
+				if stale := dropStalePassphrase(connConfig.ID, keyBytes); stale != "" && connConfig.Password == stale {
+					// Loaded from the keyring along with the connection
+					connConfig.Password = ""
+				}
+
 				// Try standard key parsing
 				signer, err := ssh.ParsePrivateKey(keyBytes)
 
@@ -142,6 +172,7 @@ func NewClient(connConfig config.SSHConnection) (*Client, error) {
 								signer, err = ssh.ParsePrivateKeyWithPassphrase(keyBytes, []byte(cachedPassphrase))
 								if err != nil {
 									log.Printf("[NewClient] Cached passphrase is invalid: %v", err)
+									ForgetPassphrase(connConfig.ID)
 									// Cached passphrase is wrong - need to prompt for new one
 									if !agentAuthAvailable {
 										return nil, &PassphraseRequiredError{KeyFile: keyFile}
@@ -182,31 +213,76 @@ func NewClient(connConfig config.SSHConnection) (*Client, error) {
 		log.Printf("[NewClient] Added password auth method")
 	}
 
+	// 4. Keyboard-interactive (OTP and other server prompts)
+	authMethods = append(authMethods, ssh.KeyboardInteractive(keyboardInteractive(connConfig)))
+
 	log.Printf("[NewClient] Total auth methods: %d", len(authMethods))
 
-	// Create SSH client configuration
+	// Create SSH client configuration. Any host key is accepted unless
+	// StrictHostKeyChecking says otherwise; the one presented is recorded
+	// for the connection details.
+	hostKeys := &hostKeyRecorder{strict: connConfig.StrictHostKeyChecking, port: connConfig.Port}
 	sshConfig := &ssh.ClientConfig{
 		User:            connConfig.Username,
 		Auth:            authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // Note: In production, consider using a more secure approach
+		HostKeyCallback: hostKeys.callback,
 		Timeout:         10 * time.Second,
 	}
 
 	// Connect to the SSH server
 	addr := fmt.Sprintf("%s:%d", connConfig.Host, connConfig.Port)
 	log.Printf("[NewClient] Attempting to connect to %s", addr)
-	conn, err := ssh.Dial("tcp", addr, sshConfig)
+	netConn, err := currentDialer().Dial(connConfig, addr, sshConfig.Timeout)
 	if err != nil {
 		log.Printf("[NewClient] Failed to connect to SSH server %s: %v", addr, err)
 		return nil, fmt.Errorf("failed to connect to SSH server: %w", err)
 	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, addr, sshConfig)
+	if err != nil {
+		netConn.Close()
+		if pc, ok := netConn.(*commandConn); ok {
+			err = pc.describe(err)
+		}
+		log.Printf("[NewClient] SSH handshake with %s failed: %v", addr, err)
+		return nil, fmt.Errorf("failed to connect to SSH server: %w", err)
+	}
+	if err := config.RecordHostInfo(hostKeys.hostInfo(connConfig, sshConn)); err != nil {
+		log.Printf("[NewClient] Failed to record host info for %s: %v", addr, err)
+	}
+	conn := ssh.NewClient(sshConn, chans, reqs)
+	seconds := config.CurrentSettings().KeepaliveSeconds
+	if connConfig.ServerAliveInterval > 0 {
+		seconds = connConfig.ServerAliveInterval
+	}
+	if seconds > 0 {
+		go keepAlive(conn, time.Duration(seconds)*time.Second)
+	}
 
 	log.Printf("[NewClient] Successfully connected to %s", addr)
-	return &Client{conn: conn}, nil
+	return &Client{conn: conn, host: connConfig.Host}, nil
 }
 
-// Close closes the SSH client connection
+// agentSocket returns the ssh-agent socket of a connection: its
+// IdentityAgent, with ~ and environment variables expanded, or else
+// SSH_AUTH_SOCK. "none" means no agent.
+func agentSocket(connConfig config.SSHConnection) string {
+	switch connConfig.IdentityAgent {
+	case "", "SSH_AUTH_SOCK":
+		return os.Getenv("SSH_AUTH_SOCK")
+	case "none":
+		return ""
+	}
+	return config.ExpandPath(os.ExpandEnv(connConfig.IdentityAgent))
+}
+
+// Close closes the SSH client connection, or releases the client's share
+// of a pooled one
 func (c *Client) Close() error {
+	if c.shared != nil {
+		var err error
+		c.release.Do(func() { err = c.shared.release() })
+		return err
+	}
 	if c.conn != nil {
 		return c.conn.Close()
 	}
@@ -0,0 +1,43 @@
+package ssh
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// Dialer opens the transport an SSH connection runs over
+type Dialer interface {
+	Dial(conn config.SSHConnection, addr string, timeout time.Duration) (net.Conn, error)
+}
+
+// DialerFunc lets a plain function be used as a Dialer
+type DialerFunc func(conn config.SSHConnection, addr string, timeout time.Duration) (net.Conn, error)
+
+func (f DialerFunc) Dial(conn config.SSHConnection, addr string, timeout time.Duration) (net.Conn, error) {
+	return f(conn, addr, timeout)
+}
+
+var (
+	dialerMu sync.Mutex
+	dialer   Dialer = DialerFunc(dial)
+)
+
+// SetDialer replaces how NewClient reaches hosts and returns the previous
+// dialer. The default honors ProxyCommand, ProxyJump and Proxy; tests
+// install one connected to an in-process server.
+func SetDialer(d Dialer) Dialer {
+	dialerMu.Lock()
+	defer dialerMu.Unlock()
+	previous := dialer
+	dialer = d
+	return previous
+}
+
+func currentDialer() Dialer {
+	dialerMu.Lock()
+	defer dialerMu.Unlock()
+	return dialer
+}
@@ -0,0 +1,313 @@
+package ssh
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// sshServices are the DNS-SD service types announcing an SSH server; macOS
+// and many NAS systems only announce the SFTP one
+var sshServices = []string{"_ssh._tcp.local.", "_sftp-ssh._tcp.local."}
+
+// mdnsAddr is the IPv4 mDNS group
+var mdnsAddr = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// DNS record types used by DNS-SD
+const (
+	dnsTypeA    = 1
+	dnsTypePTR  = 12
+	dnsTypeAAAA = 28
+	dnsTypeSRV  = 33
+)
+
+// DiscoveredHost is an SSH server announced on the local network
+type DiscoveredHost struct {
+	Name     string   // service instance name, e.g. "nas"
+	Hostname string   // host the service runs on, e.g. "nas.local"
+	Port     int      // SSH port
+	Addrs    []string // IPv4 addresses first
+}
+
+// Address returns the address to connect to: the first IP address, or the
+// hostname when none was announced
+func (h DiscoveredHost) Address() string {
+	if len(h.Addrs) > 0 {
+		return h.Addrs[0]
+	}
+	return strings.TrimSuffix(h.Hostname, ".")
+}
+
+// mdnsBrowse collects the records of one browse
+type mdnsBrowse struct {
+	instances map[string]string   // instance names from PTR records, by lower case
+	srv       map[string]mdnsSRV  // by instance name
+	addrs     map[string][]net.IP // by hostname
+	asked     map[string]bool     // names already queried directly
+}
+
+type mdnsSRV struct {
+	target string
+	port   int
+}
+
+// DiscoverSSHHosts browses the local network for SSH servers announced over
+// mDNS (DNS-SD) for timeout, and returns them sorted by name. Instances
+// whose port or address is missing from the answers are queried directly.
+func DiscoverSSHHosts(timeout time.Duration) ([]DiscoveredHost, error) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mDNS socket: %w", err)
+	}
+	defer conn.Close()
+
+	// Queries from a port other than 5353 get unicast (legacy) answers, so
+	// nothing has to join the multicast group
+	if _, err := conn.WriteToUDP(mdnsQuery(dnsTypePTR, sshServices...), mdnsAddr); err != nil {
+		return nil, fmt.Errorf("failed to send mDNS query: %w", err)
+	}
+
+	b := &mdnsBrowse{
+		instances: make(map[string]string),
+		srv:       make(map[string]mdnsSRV),
+		addrs:     make(map[string][]net.IP),
+		asked:     make(map[string]bool),
+	}
+	deadline := time.Now().Add(timeout)
+	buf := make([]byte, 9000)
+	for {
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				break
+			}
+			return nil, err
+		}
+		if err := b.read(buf[:n]); err != nil {
+			continue // not a DNS message we understand
+		}
+		for _, query := range b.followUps() {
+			_, _ = conn.WriteToUDP(query, mdnsAddr)
+		}
+	}
+	return b.hosts(), nil
+}
+
+// read adds the records of a response to the browse
+func (b *mdnsBrowse) read(msg []byte) error {
+	if len(msg) < 12 {
+		return errors.New("short DNS message")
+	}
+	if msg[2]&0x80 == 0 {
+		return errors.New("not a response")
+	}
+	qdCount := int(binary.BigEndian.Uint16(msg[4:]))
+	rrCount := int(binary.BigEndian.Uint16(msg[6:])) + int(binary.BigEndian.Uint16(msg[8:])) + int(binary.BigEndian.Uint16(msg[10:]))
+	off := 12
+	for range qdCount {
+		_, next, err := readDNSName(msg, off)
+		if err != nil {
+			return err
+		}
+		off = next + 4
+	}
+	for range rrCount {
+		name, next, err := readDNSName(msg, off)
+		if err != nil {
+			return err
+		}
+		if next+10 > len(msg) {
+			return errors.New("truncated DNS record")
+		}
+		rrType := binary.BigEndian.Uint16(msg[next:])
+		rdLen := int(binary.BigEndian.Uint16(msg[next+8:]))
+		rdata := next + 10
+		if rdata+rdLen > len(msg) {
+			return errors.New("truncated DNS record")
+		}
+		name = strings.ToLower(name)
+		switch rrType {
+		case dnsTypePTR:
+			if slices.Contains(sshServices, name) {
+				instance, _, err := readDNSName(msg, rdata)
+				if err == nil {
+					b.instances[strings.ToLower(instance)] = instance
+				}
+			}
+		case dnsTypeSRV:
+			if rdLen > 6 {
+				target, _, err := readDNSName(msg, rdata+6)
+				if err == nil {
+					port := int(binary.BigEndian.Uint16(msg[rdata+4:]))
+					b.srv[name] = mdnsSRV{target: strings.ToLower(target), port: port}
+				}
+			}
+		case dnsTypeA, dnsTypeAAAA:
+			if rdLen == net.IPv4len || rdLen == net.IPv6len {
+				ip := net.IP(slices.Clone(msg[rdata : rdata+rdLen]))
+				if !slices.ContainsFunc(b.addrs[name], ip.Equal) {
+					b.addrs[name] = append(b.addrs[name], ip)
+				}
+			}
+		}
+		off = rdata + rdLen
+	}
+	return nil
+}
+
+// followUps returns queries for the SRV records and addresses still missing
+func (b *mdnsBrowse) followUps() [][]byte {
+	var queries [][]byte
+	for key, instance := range b.instances {
+		if _, ok := b.srv[key]; !ok && !b.asked[key] {
+			b.asked[key] = true
+			queries = append(queries, mdnsQuery(dnsTypeSRV, instance))
+		}
+	}
+	for _, srv := range b.srv {
+		if len(b.addrs[srv.target]) == 0 && !b.asked[srv.target] {
+			b.asked[srv.target] = true
+			queries = append(queries, mdnsQuery(dnsTypeA, srv.target))
+		}
+	}
+	return queries
+}
+
+// hosts merges the records into hosts, one per host and port, as the same
+// machine usually announces both services
+func (b *mdnsBrowse) hosts() []DiscoveredHost {
+	var hosts []DiscoveredHost
+	for key, instance := range b.instances {
+		srv, ok := b.srv[key]
+		if !ok {
+			continue
+		}
+		if slices.ContainsFunc(hosts, func(h DiscoveredHost) bool {
+			return h.Hostname == srv.target && h.Port == srv.port
+		}) {
+			continue
+		}
+		ips := slices.Clone(b.addrs[srv.target])
+		// IPv4 first, link-local IPv6 addresses need a zone to be usable
+		slices.SortStableFunc(ips, func(a, b net.IP) int {
+			return boolRank(a.To4() == nil) - boolRank(b.To4() == nil)
+		})
+		var addrs []string
+		for _, ip := range ips {
+			if ip.To4() == nil && ip.IsLinkLocalUnicast() {
+				continue
+			}
+			addrs = append(addrs, ip.String())
+		}
+		hosts = append(hosts, DiscoveredHost{
+			Name:     instanceLabel(instance),
+			Hostname: srv.target,
+			Port:     srv.port,
+			Addrs:    addrs,
+		})
+	}
+	slices.SortFunc(hosts, func(a, b DiscoveredHost) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return hosts
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// instanceLabel strips the service type from an instance name
+func instanceLabel(instance string) string {
+	for _, service := range sshServices {
+		if len(instance) > len(service) && strings.EqualFold(instance[len(instance)-len(service):], service) {
+			label := strings.TrimSuffix(instance[:len(instance)-len(service)], ".")
+			return strings.ReplaceAll(label, `\.`, ".")
+		}
+	}
+	return instance
+}
+
+// mdnsQuery builds a DNS query for names of type qtype
+func mdnsQuery(qtype uint16, names ...string) []byte {
+	msg := make([]byte, 12, 512)
+	binary.BigEndian.PutUint16(msg[4:], uint16(len(names)))
+	for _, name := range names {
+		for _, label := range splitDNSName(name) {
+			msg = append(msg, byte(len(label)))
+			msg = append(msg, label...)
+		}
+		msg = append(msg, 0)
+		msg = binary.BigEndian.AppendUint16(msg, qtype)
+		msg = binary.BigEndian.AppendUint16(msg, 1) // IN
+	}
+	return msg
+}
+
+// splitDNSName splits a name as returned by readDNSName into its labels
+func splitDNSName(name string) []string {
+	var labels []string
+	var label strings.Builder
+	name = strings.TrimSuffix(name, ".")
+	for i := 0; i < len(name); i++ {
+		switch {
+		case name[i] == '\\' && i+1 < len(name) && name[i+1] == '.':
+			label.WriteByte('.')
+			i++
+		case name[i] == '.':
+			labels = append(labels, label.String())
+			label.Reset()
+		default:
+			label.WriteByte(name[i])
+		}
+	}
+	return append(labels, label.String())
+}
+
+// readDNSName reads the possibly compressed name at off and returns it with
+// a trailing dot, and the offset after it
+func readDNSName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	next := -1
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errors.New("truncated DNS name")
+		}
+		length := int(msg[off])
+		switch {
+		case length == 0:
+			if next < 0 {
+				next = off + 1
+			}
+			return strings.Join(labels, ".") + ".", next, nil
+		case length&0xC0 == 0xC0:
+			if off+1 >= len(msg) {
+				return "", 0, errors.New("truncated DNS name")
+			}
+			if jumps++; jumps > 16 {
+				return "", 0, errors.New("DNS name compression loop")
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3FFF)
+		default:
+			if off+1+length > len(msg) {
+				return "", 0, errors.New("truncated DNS name")
+			}
+			// Dots inside a label, as in instance names, are escaped
+			labels = append(labels, strings.ReplaceAll(string(msg[off+1:off+1+length]), ".", `\.`))
+			off += 1 + length
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package ssh
+
+import (
+	"log"
+	"strings"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// requestEnv sends the connection's environment variables. Servers only
+// accept the names listed in their AcceptEnv, so a refusal is logged and
+// the session goes on.
+func requestEnv(session *ssh.Session, conn config.SSHConnection) {
+	for _, v := range conn.SetEnv {
+		name, value, _ := strings.Cut(v, "=")
+		if err := session.Setenv(name, value); err != nil {
+			log.Printf("Server did not accept %s (check AcceptEnv in sshd_config): %v", name, err)
+		}
+	}
+}
+
+// startShell starts the interactive shell, running the connection's
+// startup command and shell override first when set
+func startShell(session *ssh.Session, conn config.SSHConnection) error {
+	if command := conn.RemoteCommand(); command != "" {
+		return session.Start(command)
+	}
+	return session.Shell()
+}
+
+// requestPty asks for a PTY with the connection's terminal type, sized
+// width by height less its window padding
+func requestPty(session *ssh.Session, conn config.SSHConnection, width, height int, modes ssh.TerminalModes) error {
+	width, height = conn.PtySize(width, height)
+	return session.RequestPty(conn.TermType(), height, width, modes)
+}
@@ -0,0 +1,84 @@
+package ssh
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// CommandResult holds the output of a non-interactive remote command
+type CommandResult struct {
+	Stdout     string
+	Stderr     string
+	ExitStatus int
+}
+
+// SessionRunner opens sessions on an SSH connection. Client is one, as is
+// the *ssh.Client an SFTPClient runs its helper commands on.
+type SessionRunner interface {
+	NewSession() (*ssh.Session, error)
+}
+
+// Run executes a command on the remote host over an exec channel (no PTY).
+// A non-zero exit status is reported in the result, not as an error.
+func (c *Client) Run(command string) (*CommandResult, error) {
+	return runCommand(c, command, nil)
+}
+
+// RunWithInput is like Run but feeds stdin to the remote command
+func (c *Client) RunWithInput(command, stdin string) (*CommandResult, error) {
+	return runCommand(c, command, strings.NewReader(stdin))
+}
+
+// runCommand runs command in a new session of runner
+func runCommand(runner SessionRunner, command string, stdin io.Reader) (*CommandResult, error) {
+	session, err := runner.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if stdin != nil {
+		session.Stdin = stdin
+	}
+
+	result := &CommandResult{}
+	if err := session.Run(command); err != nil {
+		var exitErr *ssh.ExitError
+		if !errors.As(err, &exitErr) {
+			log.Printf("Remote command %q failed: %v", command, err)
+			return nil, fmt.Errorf("remote command failed: %w", err)
+		}
+		result.ExitStatus = exitErr.ExitStatus()
+	}
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	return result, nil
+}
+
+// RunPrivileged runs command as root: directly for the root user, through
+// `sudo -S` when a sudo password is known, and `sudo -n` otherwise.
+func (c *Client) RunPrivileged(command string, conn config.SSHConnection) (*CommandResult, error) {
+	switch {
+	case conn.Username == "root":
+		return c.Run(command)
+	case conn.SudoPassword != "":
+		return c.RunWithInput("sudo -S -p '' "+command, conn.SudoPassword+"\n")
+	default:
+		return c.Run("sudo -n " + command)
+	}
+}
+
+// ShellQuote quotes s for safe use as a single POSIX shell word
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
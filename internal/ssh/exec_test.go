@@ -0,0 +1,83 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	server := newTestServer(t)
+	server.exec = func(command string, stdin io.Reader, stdout, stderr io.Writer) int {
+		switch command {
+		case "hostname":
+			fmt.Fprintln(stdout, "test-host")
+			return 0
+		case "cat":
+			io.Copy(stdout, stdin)
+			return 0
+		}
+		fmt.Fprintln(stderr, "denied")
+		return 3
+	}
+	client := server.client()
+
+	res, err := client.Run("hostname")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.Stdout != "test-host\n" || res.ExitStatus != 0 {
+		t.Errorf("Run(hostname) = %+v", res)
+	}
+
+	res, err = client.RunWithInput("cat", "typed input")
+	if err != nil {
+		t.Fatalf("RunWithInput: %v", err)
+	}
+	if res.Stdout != "typed input" {
+		t.Errorf("RunWithInput(cat) stdout = %q", res.Stdout)
+	}
+
+	// A failing command is a result, not an error
+	res, err = client.Run("reboot")
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if res.ExitStatus != 3 || res.Stderr != "denied\n" {
+		t.Errorf("Run(reboot) = %+v", res)
+	}
+}
+
+func TestRunPrivileged(t *testing.T) {
+	server := newTestServer(t)
+	var commands []string
+	server.exec = func(command string, stdin io.Reader, stdout, stderr io.Writer) int {
+		input, _ := io.ReadAll(stdin)
+		commands = append(commands, command+"|"+string(input))
+		return 0
+	}
+	client := server.client()
+
+	conn := server.connection()
+	if _, err := client.RunPrivileged("id", conn); err != nil {
+		t.Fatal(err)
+	}
+	conn.SudoPassword = "hunter2"
+	if _, err := client.RunPrivileged("id", conn); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"sudo -n id|", "sudo -S -p '' id|hunter2\n"}
+	if strings.Join(commands, ",") != strings.Join(want, ",") {
+		t.Errorf("commands = %q, want %q", commands, want)
+	}
+}
+
+func TestNewClientWrongPassword(t *testing.T) {
+	server := newTestServer(t)
+	conn := server.connection()
+	conn.Password = "wrong"
+	if _, err := NewClient(conn); err == nil {
+		t.Fatal("NewClient with a wrong password succeeded")
+	}
+}
@@ -0,0 +1,282 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const x11AuthProtocol = "MIT-MAGIC-COOKIE-1"
+
+// requestForwarding asks the server to forward the local SSH agent and X11
+// display to session, as set on the connection. Like OpenSSH, a failure
+// only logs a warning and the session goes on without forwarding.
+func (c *Client) requestForwarding(session *ssh.Session, conn config.SSHConnection) {
+	if conn.ForwardAgent {
+		if err := c.forwardAgent(session); err != nil {
+			log.Printf("[forwarding] Agent forwarding failed: %v", err)
+		}
+	}
+	if conn.ForwardX11 {
+		if err := c.forwardX11(session); err != nil {
+			log.Printf("[forwarding] X11 forwarding failed: %v", err)
+		}
+	}
+}
+
+// forwardAgent relays the remote side's agent requests to SSH_AUTH_SOCK
+func (c *Client) forwardAgent(session *ssh.Session) error {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return errors.New("SSH_AUTH_SOCK is not set")
+	}
+	if err := c.relayAgent(socket); err != nil {
+		return err
+	}
+	return agent.RequestAgentForwarding(session)
+}
+
+// relayAgent relays the agent channels the server opens to socket, once
+// for all the sessions on a pooled connection
+func (c *Client) relayAgent(socket string) error {
+	if c.shared == nil {
+		return agent.ForwardToRemote(c.conn, socket)
+	}
+	c.shared.mu.Lock()
+	defer c.shared.mu.Unlock()
+	if c.shared.agentRelayed {
+		return nil
+	}
+	if err := agent.ForwardToRemote(c.conn, socket); err != nil {
+		return err
+	}
+	c.shared.agentRelayed = true
+	return nil
+}
+
+// x11Request is the payload of an x11-req channel request (RFC 4254 6.3.1)
+type x11Request struct {
+	SingleConnection bool
+	AuthProtocol     string
+	AuthCookie       string
+	ScreenNumber     uint32
+}
+
+// forwardX11 requests X11 forwarding for session and relays the server's
+// x11 channels to the local display in DISPLAY. The server gets a random
+// cookie which is swapped for the display's real one on the way through,
+// so the real cookie never leaves this machine.
+func (c *Client) forwardX11(session *ssh.Session) error {
+	display := os.Getenv("DISPLAY")
+	if display == "" {
+		return errors.New("DISPLAY is not set")
+	}
+	network, address, screen, err := parseDisplay(display)
+	if err != nil {
+		return err
+	}
+
+	fakeCookie, err := c.relayX11Channels(display, network, address)
+	if err != nil {
+		return err
+	}
+
+	ok, err := session.SendRequest("x11-req", true, ssh.Marshal(&x11Request{
+		AuthProtocol: x11AuthProtocol,
+		AuthCookie:   hex.EncodeToString(fakeCookie),
+		ScreenNumber: uint32(screen),
+	}))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("the server refused X11 forwarding")
+	}
+	return nil
+}
+
+// relayX11Channels relays the x11 channels the server opens to the local
+// display and returns the fake cookie they must carry. The sessions on a
+// pooled connection share the relay and its cookie.
+func (c *Client) relayX11Channels(display, network, address string) ([]byte, error) {
+	if c.shared != nil {
+		c.shared.mu.Lock()
+		defer c.shared.mu.Unlock()
+		if c.shared.x11Cookie != nil {
+			return c.shared.x11Cookie, nil
+		}
+	}
+
+	fakeCookie := make([]byte, 16)
+	if _, err := rand.Read(fakeCookie); err != nil {
+		return nil, err
+	}
+	realCookie := xauthCookie(display)
+
+	channels := c.conn.HandleChannelOpen("x11")
+	if channels == nil {
+		return nil, errors.New("x11 channels are already handled")
+	}
+	go func() {
+		for newChannel := range channels {
+			go relayX11(newChannel, network, address, fakeCookie, realCookie)
+		}
+	}()
+	if c.shared != nil {
+		c.shared.x11Cookie = fakeCookie
+	}
+	return fakeCookie, nil
+}
+
+// relayX11 connects one forwarded X11 client to the local display
+func relayX11(newChannel ssh.NewChannel, network, address string, fakeCookie, realCookie []byte) {
+	local, err := net.Dial(network, address)
+	if err != nil {
+		log.Printf("[forwarding] Failed to connect to the X11 display %s: %v", address, err)
+		newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	defer local.Close()
+
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		log.Printf("[forwarding] Failed to accept X11 channel: %v", err)
+		return
+	}
+	defer channel.Close()
+	go ssh.DiscardRequests(requests)
+
+	remote := bufio.NewReader(channel)
+	setup, err := spoofX11Auth(remote, fakeCookie, realCookie)
+	if err != nil {
+		log.Printf("[forwarding] Rejected X11 connection: %v", err)
+		return
+	}
+	if _, err := local.Write(setup); err != nil {
+		return
+	}
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(local, remote)
+		if conn, ok := local.(interface{ CloseWrite() error }); ok {
+			conn.CloseWrite()
+		}
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(channel, local)
+		channel.CloseWrite()
+		done <- struct{}{}
+	}()
+	<-done
+	<-done
+}
+
+// spoofX11Auth reads the X11 connection setup from r, checks it carries the
+// fake cookie and returns it rewritten with the real one (or no
+// authorization when the display has no cookie)
+func spoofX11Auth(r io.Reader, fakeCookie, realCookie []byte) ([]byte, error) {
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	var order binary.ByteOrder
+	switch header[0] {
+	case 'B':
+		order = binary.BigEndian
+	case 'l':
+		order = binary.LittleEndian
+	default:
+		return nil, fmt.Errorf("unknown byte order %q", header[0])
+	}
+	nameLen := int(order.Uint16(header[6:8]))
+	dataLen := int(order.Uint16(header[8:10]))
+	auth := make([]byte, pad4(nameLen)+pad4(dataLen))
+	if _, err := io.ReadFull(r, auth); err != nil {
+		return nil, err
+	}
+	name := string(auth[:nameLen])
+	data := auth[pad4(nameLen) : pad4(nameLen)+dataLen]
+	if name != x11AuthProtocol || !bytes.Equal(data, fakeCookie) {
+		return nil, errors.New("wrong authorization cookie")
+	}
+
+	if realCookie == nil {
+		order.PutUint16(header[6:8], 0)
+		order.PutUint16(header[8:10], 0)
+		return header, nil
+	}
+	order.PutUint16(header[8:10], uint16(len(realCookie)))
+	setup := append(header, auth[:pad4(nameLen)]...)
+	setup = append(setup, realCookie...)
+	return append(setup, make([]byte, pad4(len(realCookie))-len(realCookie))...), nil
+}
+
+// pad4 rounds n up to a multiple of four, as X11 pads its strings
+func pad4(n int) int {
+	return (n + 3) &^ 3
+}
+
+// parseDisplay turns DISPLAY into the address of the X server and the screen
+// number: ":0" and "unix:0" are local sockets, "host:10.0" is TCP port 6010
+// and XQuartz's "/path/org.xquartz:0" is the socket at that path
+func parseDisplay(display string) (network, address string, screen int, err error) {
+	i := strings.LastIndex(display, ":")
+	if i < 0 {
+		return "", "", 0, fmt.Errorf("invalid DISPLAY %q", display)
+	}
+	host, rest := display[:i], display[i+1:]
+	number, screenStr, _ := strings.Cut(rest, ".")
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return "", "", 0, fmt.Errorf("invalid DISPLAY %q", display)
+	}
+	if screenStr != "" {
+		if screen, err = strconv.Atoi(screenStr); err != nil {
+			return "", "", 0, fmt.Errorf("invalid DISPLAY %q", display)
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(host, "/"):
+		return "unix", host + ":" + number, screen, nil
+	case host == "" || host == "unix":
+		return "unix", "/tmp/.X11-unix/X" + number, screen, nil
+	}
+	return "tcp", net.JoinHostPort(strings.Trim(host, "[]"), strconv.Itoa(6000+n)), screen, nil
+}
+
+// xauthCookie looks up the display's MIT-MAGIC-COOKIE-1 with xauth, or
+// returns nil when there is none
+func xauthCookie(display string) []byte {
+	out, err := exec.Command("xauth", "list", display).Output()
+	if err != nil {
+		log.Printf("[forwarding] xauth list %s failed: %v", display, err)
+		return nil
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 3 && fields[1] == x11AuthProtocol {
+			if cookie, err := hex.DecodeString(fields[2]); err == nil {
+				return cookie
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package ssh
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// KnownHostsStatus is how a host key compares with ~/.ssh/known_hosts
+type KnownHostsStatus int
+
+const (
+	// KnownHostsUnavailable means there is no known_hosts file to check
+	KnownHostsUnavailable KnownHostsStatus = iota
+	// KnownHostsNotListed means known_hosts has no key for the host
+	KnownHostsNotListed
+	// KnownHostsMatch means the key is the one known_hosts lists
+	KnownHostsMatch
+	// KnownHostsMismatch means known_hosts lists a different key
+	KnownHostsMismatch
+	// KnownHostsRevoked means known_hosts marks the key @revoked
+	KnownHostsRevoked
+)
+
+func (s KnownHostsStatus) String() string {
+	switch s {
+	case KnownHostsNotListed:
+		return "not in known_hosts"
+	case KnownHostsMatch:
+		return "matches known_hosts"
+	case KnownHostsMismatch:
+		return "DIFFERS from known_hosts"
+	case KnownHostsRevoked:
+		return "REVOKED in known_hosts"
+	}
+	return "no known_hosts file"
+}
+
+// hostKeyRecorder keeps the host key the server presented so it can be
+// shown in the connection details. Any key is accepted, as sessions always
+// have, unless strict is a StrictHostKeyChecking of "yes" or "accept-new".
+type hostKeyRecorder struct {
+	key    ssh.PublicKey
+	strict string
+	port   int
+}
+
+func (r *hostKeyRecorder) callback(hostname string, _ net.Addr, key ssh.PublicKey) error {
+	r.key = key
+	if r.strict != "yes" && r.strict != "accept-new" {
+		return nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	return verifyHostKey(filepath.Join(homeDir, ".ssh", "known_hosts"), r.strict == "accept-new", hostname, r.port, key)
+}
+
+// verifyHostKey accepts key only if the known_hosts file at path lists it
+// for hostname or, with acceptNew, if it lists no key for hostname, in
+// which case key is added to it like OpenSSH does
+func verifyHostKey(path string, acceptNew bool, hostname string, port int, key ssh.PublicKey) error {
+	err := errHostNotListed
+	callback, cbErr := knownhosts.New(path)
+	switch {
+	case cbErr == nil:
+		// The hostname takes precedence over the remote address, which only
+		// has to parse: the one of the connection names its proxy command
+		// when there is one
+		err = callback(hostname, &net.TCPAddr{IP: net.IPv4zero, Port: port}, key)
+	case !errors.Is(cbErr, os.ErrNotExist):
+		return cbErr
+	}
+
+	var keyErr *knownhosts.KeyError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &keyErr) && len(keyErr.Want) > 0:
+		return fmt.Errorf("host key of %s differs from the one in %s, refusing to connect (StrictHostKeyChecking)", hostname, path)
+	case errors.As(err, &keyErr) || errors.Is(err, errHostNotListed):
+		if !acceptNew {
+			return fmt.Errorf("host key of %s is not in %s (StrictHostKeyChecking yes)", hostname, path)
+		}
+		return addKnownHost(path, hostname, key)
+	}
+	return err
+}
+
+// errHostNotListed stands for the KeyError of a missing known_hosts file
+var errHostNotListed = errors.New("host not in known_hosts")
+
+// addKnownHost appends key for hostname to the known_hosts file at path
+func addKnownHost(path, hostname string, key ssh.PublicKey) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	log.Printf("[hostkey] Adding %s to %s", hostname, path)
+	_, err = fmt.Fprintln(file, knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key))
+	return err
+}
+
+// hostInfo describes what the handshake with conn.Host negotiated
+func (r *hostKeyRecorder) hostInfo(conn config.SSHConnection, sshConn ssh.Conn) config.HostInfo {
+	info := config.HostInfo{
+		Host:          conn.Host,
+		Port:          conn.Port,
+		ServerVersion: string(sshConn.ServerVersion()),
+		SeenAt:        time.Now().Unix(),
+	}
+	if r.key != nil {
+		info.KeyType = r.key.Type()
+		info.Key = base64.StdEncoding.EncodeToString(r.key.Marshal())
+		info.FingerprintSHA256 = ssh.FingerprintSHA256(r.key)
+		info.FingerprintMD5 = ssh.FingerprintLegacyMD5(r.key)
+	}
+	if negotiated, ok := sshConn.(ssh.AlgorithmsConnMetadata); ok {
+		algorithms := negotiated.Algorithms()
+		info.KeyExchange = algorithms.KeyExchange
+		info.CipherClientToServer = algorithms.Write.Cipher
+		info.CipherServerToClient = algorithms.Read.Cipher
+		info.MACClientToServer = algorithms.Write.MAC
+		info.MACServerToClient = algorithms.Read.MAC
+	}
+	return info
+}
+
+// CheckKnownHosts compares the recorded host key of info with
+// ~/.ssh/known_hosts
+func CheckKnownHosts(info config.HostInfo) (KnownHostsStatus, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return KnownHostsUnavailable, err
+	}
+	return checkKnownHosts(filepath.Join(homeDir, ".ssh", "known_hosts"), info)
+}
+
+func checkKnownHosts(path string, info config.HostInfo) (KnownHostsStatus, error) {
+	wire, err := base64.StdEncoding.DecodeString(info.Key)
+	if err != nil {
+		return KnownHostsUnavailable, err
+	}
+	key, err := ssh.ParsePublicKey(wire)
+	if err != nil {
+		return KnownHostsUnavailable, err
+	}
+	callback, err := knownhosts.New(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return KnownHostsUnavailable, nil
+		}
+		return KnownHostsUnavailable, err
+	}
+
+	// The hostname takes precedence over the remote address, which only
+	// has to parse
+	remote := &net.TCPAddr{IP: net.IPv4zero, Port: info.Port}
+	err = callback(info.Address(), remote, key)
+	var keyErr *knownhosts.KeyError
+	var revokedErr *knownhosts.RevokedError
+	switch {
+	case err == nil:
+		return KnownHostsMatch, nil
+	case errors.As(err, &revokedErr):
+		return KnownHostsRevoked, nil
+	case errors.As(err, &keyErr) && len(keyErr.Want) > 0:
+		return KnownHostsMismatch, nil
+	case errors.As(err, &keyErr):
+		return KnownHostsNotListed, nil
+	}
+	return KnownHostsUnavailable, err
+}
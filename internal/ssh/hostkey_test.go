@@ -0,0 +1,82 @@
+package ssh
+
+import (
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// TestProxyCommandHelper is the proxy command of the tests when run as
+// one: it relays its stdin and stdout to SXT_TEST_PROXY_ADDR, like nc
+func TestProxyCommandHelper(t *testing.T) {
+	addr := os.Getenv("SXT_TEST_PROXY_ADDR")
+	if addr == "" {
+		t.Skip("only run as a proxy command")
+	}
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		os.Exit(1)
+	}
+	go func() {
+		io.Copy(conn, os.Stdin)
+		conn.Close()
+	}()
+	io.Copy(os.Stdout, conn)
+	os.Exit(0)
+}
+
+// dialThroughProxyCommand makes the test server reachable only through a
+// proxy command, whose connections have the command as remote address
+func dialThroughProxyCommand(t *testing.T, s *testServer) {
+	t.Helper()
+	previous := SetDialer(DialerFunc(func(conn config.SSHConnection, addr string, timeout time.Duration) (net.Conn, error) {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestProxyCommandHelper$")
+		cmd.Env = append(os.Environ(), "SXT_TEST_PROXY_ADDR="+s.listener.Addr().String())
+		return dialCommand(cmd, "cloudflared access ssh --hostname "+conn.Host)
+	}))
+	t.Cleanup(func() { SetDialer(previous) })
+}
+
+func TestStrictHostKeyCheckingThroughProxyCommand(t *testing.T) {
+	server := newTestServer(t)
+	dialThroughProxyCommand(t, server)
+	knownHosts := filepath.Join(os.Getenv("HOME"), ".ssh", "known_hosts")
+
+	conn := server.connection()
+	conn.StrictHostKeyChecking = "yes"
+	if _, err := NewClient(conn); err == nil || !strings.Contains(err.Error(), "not in") {
+		t.Fatalf("NewClient with an unknown host key = %v, want a refusal", err)
+	}
+
+	// accept-new adds the key, then both modes accept it from the file
+	for _, strict := range []string{"accept-new", "accept-new", "yes"} {
+		conn.StrictHostKeyChecking = strict
+		client, err := NewClient(conn)
+		if err != nil {
+			t.Fatalf("NewClient with StrictHostKeyChecking %s: %v", strict, err)
+		}
+		client.Close()
+	}
+	data, err := os.ReadFile(knownHosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 1 {
+		t.Errorf("known_hosts has %d lines, want the key added once:\n%s", lines, data)
+	}
+
+	// A different key for the host is refused
+	if err := os.WriteFile(knownHosts, []byte("[test.invalid]:22 ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIDZS5Pb7Ylql/6Pxj7VOujvGUSb3Kq2MUpyTLKWoxCUW\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewClient(conn); err == nil || !strings.Contains(err.Error(), "differs") {
+		t.Errorf("NewClient with a changed host key = %v, want a refusal", err)
+	}
+}
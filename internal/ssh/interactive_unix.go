@@ -13,7 +13,6 @@ import (
 	"syscall"
 
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
-	"github.com/zalando/go-keyring"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/term"
 )
@@ -24,6 +23,10 @@ func ConnectInteractive(connConfig config.SSHConnection) error {
 	log.Printf("[ConnectInteractive] Starting interactive session for %s@%s:%d",
 		connConfig.Username, connConfig.Host, connConfig.Port)
 
+	if UsesSecurityKey(connConfig) {
+		fmt.Fprintf(os.Stderr, "%s (%s)\n", SecurityKeyNotice, connConfig.KeyFile)
+	}
+
 	// Create SSH client (this handles keyring password retrieval and SSH agent)
 	client, err := NewClient(connConfig)
 	if err != nil {
@@ -48,19 +51,32 @@ func ConnectInteractive(connConfig config.SSHConnection) error {
 			}
 
 			// Save passphrase to keyring for future use
-			savePassphraseToKeyring(connConfig.ID, string(passphrase))
+			savePassphraseToKeyring(connConfig.ID, passphraseErr.KeyFile, string(passphrase))
 		} else {
 			return fmt.Errorf("failed to create SSH client: %w", err)
 		}
 	}
 	defer client.Close()
 
+	if connConfig.UseMosh {
+		err := connectMosh(connConfig, client)
+		if !errors.Is(err, ErrMoshUnavailable) {
+			return err
+		}
+		log.Printf("[ConnectInteractive] %v, falling back to SSH", err)
+		fmt.Fprintf(os.Stderr, "%v, falling back to SSH\n", err)
+	}
+
+	startForwards(client.conn, connConfig)
+
 	// Create SSH session
 	session, err := client.NewSession()
 	if err != nil {
 		return fmt.Errorf("failed to create SSH session: %w", err)
 	}
 	defer session.Close()
+	client.requestForwarding(session, connConfig)
+	requestEnv(session, connConfig)
 
 	// Get current terminal file descriptor
 	fd := int(os.Stdin.Fd())
@@ -92,8 +108,8 @@ func ConnectInteractive(connConfig config.SSHConnection) error {
 		ssh.ISIG:          1,
 	}
 
-	// Request PTY with xterm-256color
-	if err := session.RequestPty("xterm-256color", height, width, modes); err != nil {
+	// Request PTY with the connection's terminal type
+	if err := requestPty(session, connConfig, width, height, modes); err != nil {
 		return fmt.Errorf("failed to request PTY: %w", err)
 	}
 
@@ -120,7 +136,8 @@ func ConnectInteractive(connConfig config.SSHConnection) error {
 		for range sigwinch {
 			width, height, err := term.GetSize(fd)
 			if err == nil {
-				session.WindowChange(height, width)
+				ptyWidth, ptyHeight := connConfig.PtySize(width, height)
+				session.WindowChange(ptyHeight, ptyWidth)
 				log.Printf("[ConnectInteractive] Window resized to %dx%d", width, height)
 			}
 		}
@@ -128,7 +145,7 @@ func ConnectInteractive(connConfig config.SSHConnection) error {
 	defer signal.Stop(sigwinch)
 
 	// Start shell
-	if err := session.Shell(); err != nil {
+	if err := startShell(session, connConfig); err != nil {
 		return fmt.Errorf("failed to start shell: %w", err)
 	}
 
@@ -164,16 +181,3 @@ func ConnectInteractive(connConfig config.SSHConnection) error {
 	log.Printf("[ConnectInteractive] Session closed")
 	return nil
 }
-
-// savePassphraseToKeyring saves the passphrase to the system keyring
-func savePassphraseToKeyring(connectionID, passphrase string) {
-	keyID := "passphrase:" + connectionID
-	err := keyring.Set("ssh-x-term", keyID, passphrase)
-	if err != nil {
-		log.Printf("[savePassphraseToKeyring] Failed to save passphrase to keyring: %v", err)
-		fmt.Fprintf(os.Stderr, "Note: Could not save passphrase to keyring (will be prompted again next time)\n")
-	} else {
-		log.Printf("[savePassphraseToKeyring] Saved passphrase for connection %s to keyring", connectionID)
-		fmt.Fprintf(os.Stderr, "Passphrase saved to keyring.\n")
-	}
-}
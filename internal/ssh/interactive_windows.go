@@ -9,10 +9,11 @@ import (
 	"log"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
-	"github.com/zalando/go-keyring"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/sys/windows"
 	"golang.org/x/term"
 )
 
@@ -22,6 +23,10 @@ func ConnectInteractive(connConfig config.SSHConnection) error {
 	log.Printf("[ConnectInteractive] Starting interactive session for %s@%s:%d",
 		connConfig.Username, connConfig.Host, connConfig.Port)
 
+	if UsesSecurityKey(connConfig) {
+		fmt.Fprintf(os.Stderr, "%s (%s)\n", SecurityKeyNotice, connConfig.KeyFile)
+	}
+
 	// Create SSH client (this handles keyring password retrieval and SSH agent)
 	client, err := NewClient(connConfig)
 	if err != nil {
@@ -46,19 +51,32 @@ func ConnectInteractive(connConfig config.SSHConnection) error {
 			}
 
 			// Save passphrase to keyring for future use
-			savePassphraseToKeyring(connConfig.ID, string(passphrase))
+			savePassphraseToKeyring(connConfig.ID, passphraseErr.KeyFile, string(passphrase))
 		} else {
 			return fmt.Errorf("failed to create SSH client: %w", err)
 		}
 	}
 	defer client.Close()
 
+	if connConfig.UseMosh {
+		err := connectMosh(connConfig, client)
+		if !errors.Is(err, ErrMoshUnavailable) {
+			return err
+		}
+		log.Printf("[ConnectInteractive] %v, falling back to SSH", err)
+		fmt.Fprintf(os.Stderr, "%v, falling back to SSH\n", err)
+	}
+
+	startForwards(client.conn, connConfig)
+
 	// Create SSH session
 	session, err := client.NewSession()
 	if err != nil {
 		return fmt.Errorf("failed to create SSH session: %w", err)
 	}
 	defer session.Close()
+	client.requestForwarding(session, connConfig)
+	requestEnv(session, connConfig)
 
 	// Get current terminal file descriptor
 	fd := int(os.Stdin.Fd())
@@ -75,8 +93,12 @@ func ConnectInteractive(connConfig config.SSHConnection) error {
 	}
 	defer term.Restore(fd, oldState)
 
-	// Get terminal size
-	width, height, err := term.GetSize(fd)
+	// The console renders the remote's escape sequences itself
+	restoreOutput := enableVirtualTerminal(os.Stdout)
+	defer restoreOutput()
+
+	// Get terminal size, which only the output handle knows
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
 	if err != nil {
 		log.Printf("[ConnectInteractive] Failed to get terminal size: %v, using defaults", err)
 		width, height = 80, 24
@@ -90,8 +112,8 @@ func ConnectInteractive(connConfig config.SSHConnection) error {
 		ssh.ISIG:          1,
 	}
 
-	// Request PTY with xterm-256color
-	if err := session.RequestPty("xterm-256color", height, width, modes); err != nil {
+	// Request PTY with the connection's terminal type
+	if err := requestPty(session, connConfig, width, height, modes); err != nil {
 		return fmt.Errorf("failed to request PTY: %w", err)
 	}
 
@@ -111,10 +133,16 @@ func ConnectInteractive(connConfig config.SSHConnection) error {
 		return fmt.Errorf("failed to setup stderr pipe: %w", err)
 	}
 
-	// Note: Windows doesn't support SIGWINCH, so window resize is not handled
+	// Windows has no SIGWINCH, so watch the console size instead
+	stopWatching := watchConsoleSize(width, height, func(width, height int) {
+		ptyWidth, ptyHeight := connConfig.PtySize(width, height)
+		session.WindowChange(ptyHeight, ptyWidth)
+		log.Printf("[ConnectInteractive] Window resized to %dx%d", width, height)
+	})
+	defer stopWatching()
 
 	// Start shell
-	if err := session.Shell(); err != nil {
+	if err := startShell(session, connConfig); err != nil {
 		return fmt.Errorf("failed to start shell: %w", err)
 	}
 
@@ -151,15 +179,44 @@ func ConnectInteractive(connConfig config.SSHConnection) error {
 	return nil
 }
 
-// savePassphraseToKeyring saves the passphrase to the system keyring
-func savePassphraseToKeyring(connectionID, passphrase string) {
-	keyID := "passphrase:" + connectionID
-	err := keyring.Set("ssh-x-term", keyID, passphrase)
-	if err != nil {
-		log.Printf("[savePassphraseToKeyring] Failed to save passphrase to keyring: %v", err)
-		fmt.Fprintf(os.Stderr, "Note: Could not save passphrase to keyring (will be prompted again next time)\n")
-	} else {
-		log.Printf("[savePassphraseToKeyring] Saved passphrase for connection %s to keyring", connectionID)
-		fmt.Fprintf(os.Stderr, "Passphrase saved to keyring.\n")
+// consoleSizePoll is how often the console size is checked for a resize
+const consoleSizePoll = 250 * time.Millisecond
+
+// watchConsoleSize calls resized with the new size whenever the console
+// differs from width by height, until the returned stop is called
+func watchConsoleSize(width, height int, resized func(width, height int)) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(consoleSizePoll)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			w, h, err := term.GetSize(int(os.Stdout.Fd()))
+			if err != nil || (w == width && h == height) {
+				continue
+			}
+			width, height = w, h
+			resized(width, height)
+		}
+	}()
+	return func() { close(done) }
+}
+
+// enableVirtualTerminal makes the console interpret the VT sequences
+// written to f, as Unix terminals do, and returns how to undo it
+func enableVirtualTerminal(f *os.File) (restore func()) {
+	handle := windows.Handle(f.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(handle, &mode); err != nil {
+		return func() {}
+	}
+	if err := windows.SetConsoleMode(handle, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil {
+		log.Printf("[ConnectInteractive] Failed to enable VT processing: %v", err)
+		return func() {}
 	}
+	return func() { windows.SetConsoleMode(handle, mode) }
 }
@@ -0,0 +1,56 @@
+package ssh
+
+import (
+	"log"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// keepaliveMaxMissed is how many unanswered keepalives close the
+// connection, like OpenSSH's ServerAliveCountMax
+const keepaliveMaxMissed = 3
+
+// keepAlive sends a keepalive request every interval until the connection
+// closes, giving up on a server that stops answering
+func keepAlive(conn *ssh.Client, interval time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		conn.Wait()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	missed := 0
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		reply := make(chan error, 1)
+		go func() {
+			_, _, err := conn.SendRequest("keepalive@openssh.com", true, nil)
+			reply <- err
+		}()
+		select {
+		case <-done:
+			return
+		case err := <-reply:
+			if err != nil {
+				return
+			}
+			missed = 0
+		case <-time.After(interval):
+			missed++
+			log.Printf("[keepAlive] No reply from %s (%d/%d)", conn.RemoteAddr(), missed, keepaliveMaxMissed)
+			if missed >= keepaliveMaxMissed {
+				log.Printf("[keepAlive] Closing unresponsive connection to %s", conn.RemoteAddr())
+				conn.Close()
+				return
+			}
+		}
+	}
+}
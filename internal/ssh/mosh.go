@@ -0,0 +1,102 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// ErrMoshUnavailable means mosh cannot be used, locally or on the remote
+// host, and the session should fall back to plain SSH
+var ErrMoshUnavailable = errors.New("mosh is not available")
+
+// moshClientBinary is started with the key and port printed by mosh-server.
+// The mosh wrapper script is not used as it would authenticate with the
+// system ssh instead of our own client.
+const moshClientBinary = "mosh-client"
+
+// moshServerCommand starts mosh-server detached; exit status 127 means it is
+// not installed
+const moshServerCommand = "command -v mosh-server >/dev/null 2>&1 || exit 127; " +
+	"mosh-server new -s -c 256 -l LANG=en_US.UTF-8"
+
+var moshConnectLine = regexp.MustCompile(`(?m)^MOSH CONNECT (\d+) (\S+)\s*$`)
+
+// MoshSession is what mosh-client needs to attach to a bootstrapped server
+type MoshSession struct {
+	Host string
+	Port int
+	Key  string
+}
+
+// MoshClientAvailable reports whether mosh-client is installed locally
+func MoshClientAvailable() bool {
+	_, err := exec.LookPath(moshClientBinary)
+	return err == nil
+}
+
+// StartMoshServer launches mosh-server on the remote host over an exec
+// channel and returns the session mosh-client connects to
+func (c *Client) StartMoshServer() (*MoshSession, error) {
+	result, err := c.Run(moshServerCommand)
+	if err != nil {
+		return nil, err
+	}
+	if result.ExitStatus == 127 {
+		return nil, fmt.Errorf("%w: mosh-server not found on the remote host", ErrMoshUnavailable)
+	}
+
+	match := moshConnectLine.FindStringSubmatch(result.Stdout + "\n" + result.Stderr)
+	if match == nil {
+		log.Printf("[StartMoshServer] Unexpected mosh-server output: %q %q", result.Stdout, result.Stderr)
+		return nil, fmt.Errorf("%w: mosh-server did not start (exit status %d)", ErrMoshUnavailable, result.ExitStatus)
+	}
+	port, _ := strconv.Atoi(match[1])
+
+	// mosh-client needs an address; use the one the SSH connection reached
+	host, _, err := net.SplitHostPort(c.conn.RemoteAddr().String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve remote address: %w", err)
+	}
+	return &MoshSession{Host: host, Port: port, Key: match[2]}, nil
+}
+
+// Attach runs mosh-client on the current terminal until the session ends
+func (m *MoshSession) Attach() error {
+	cmd := exec.Command(moshClientBinary, m.Host, strconv.Itoa(m.Port))
+	cmd.Env = append(os.Environ(), "MOSH_KEY="+m.Key)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("mosh-client failed: %w", err)
+	}
+	return nil
+}
+
+// connectMosh hands an authenticated connection over to mosh, returning
+// ErrMoshUnavailable when the caller should carry on with a plain SSH shell
+func connectMosh(conn config.SSHConnection, client *Client) error {
+	if conn.Proxy != "" || conn.ProxyCommand != "" || conn.ProxyJump != "" {
+		// mosh talks UDP straight to the server, which a proxy cannot carry
+		return fmt.Errorf("%w: the connection goes through a proxy", ErrMoshUnavailable)
+	}
+	if !MoshClientAvailable() {
+		return fmt.Errorf("%w: %s not found in PATH", ErrMoshUnavailable, moshClientBinary)
+	}
+	session, err := client.StartMoshServer()
+	if err != nil {
+		return err
+	}
+	// The SSH connection is only needed to bootstrap the server
+	client.Close()
+	log.Printf("[connectMosh] Attaching mosh-client to %s:%d", session.Host, session.Port)
+	return session.Attach()
+}
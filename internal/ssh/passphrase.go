@@ -0,0 +1,111 @@
+package ssh
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// keyringKeyHashPrefix stores the hash of the key file a cached passphrase
+// belongs to, so replacing the key invalidates the passphrase
+const keyringKeyHashPrefix = "passphrase-key:"
+
+// keyFileHash identifies the contents of a key file
+func keyFileHash(keyBytes []byte) string {
+	sum := sha256.Sum256(keyBytes)
+	return hex.EncodeToString(sum[:])
+}
+
+// CachePassphrase stores the passphrase of keyFile in the OS keyring under
+// "passphrase:<id>", along with the hash of the key it unlocks
+func CachePassphrase(connectionID, keyFile, passphrase string) error {
+	if connectionID == "" {
+		return errors.New("connection has no ID to store the passphrase under")
+	}
+	keyBytes, err := os.ReadFile(config.ExpandPath(keyFile))
+	if err != nil {
+		return err
+	}
+	if err := keyring.Set(keyringService, keyringPassphrasePrefix+connectionID, passphrase); err != nil {
+		return err
+	}
+	if err := keyring.Set(keyringService, keyringKeyHashPrefix+connectionID, keyFileHash(keyBytes)); err != nil {
+		log.Printf("Failed to store key hash for connection %s: %v", connectionID, err)
+	}
+	return nil
+}
+
+// ForgetPassphrase removes the cached passphrase of a connection
+func ForgetPassphrase(connectionID string) {
+	for _, key := range []string{keyringPassphrasePrefix + connectionID, keyringKeyHashPrefix + connectionID} {
+		if err := keyring.Delete(keyringService, key); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+			log.Printf("Failed to remove %s from keyring: %v", key, err)
+		}
+	}
+}
+
+// dropStalePassphrase forgets the cached passphrase of a connection when its
+// key file changed since it was stored, and returns the forgotten passphrase.
+// Passphrases cached before key hashes were stored are kept.
+func dropStalePassphrase(connectionID string, keyBytes []byte) string {
+	if connectionID == "" {
+		return ""
+	}
+	hash, err := keyring.Get(keyringService, keyringKeyHashPrefix+connectionID)
+	if err != nil || hash == keyFileHash(keyBytes) {
+		return ""
+	}
+	stale, _ := keyring.Get(keyringService, keyringPassphrasePrefix+connectionID)
+	log.Printf("[NewClient] Key file of connection %s changed, forgetting its cached passphrase", connectionID)
+	ForgetPassphrase(connectionID)
+	return stale
+}
+
+// savePassphraseToKeyring caches a passphrase typed on the terminal
+func savePassphraseToKeyring(connectionID, keyFile, passphrase string) {
+	if err := CachePassphrase(connectionID, keyFile, passphrase); err != nil {
+		log.Printf("[savePassphraseToKeyring] Failed to save passphrase to keyring: %v", err)
+		fmt.Fprintf(os.Stderr, "Note: Could not save passphrase to keyring (will be prompted again next time)\n")
+	} else {
+		log.Printf("[savePassphraseToKeyring] Saved passphrase for connection %s to keyring", connectionID)
+		fmt.Fprintf(os.Stderr, "Passphrase saved to keyring.\n")
+	}
+}
+
+// AddKeyToAgent decrypts keyFile with passphrase and adds it to the running
+// ssh-agent, as an alternative to keeping the passphrase in the keyring.
+// lifetimeSecs limits how long the agent keeps it, 0 is until it stops.
+func AddKeyToAgent(keyFile, passphrase string, lifetimeSecs uint32) error {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return errors.New("no ssh-agent is running (SSH_AUTH_SOCK is not set)")
+	}
+	keyFile = config.ExpandPath(keyFile)
+	keyBytes, err := os.ReadFile(keyFile)
+	if err != nil {
+		return err
+	}
+	key, err := ssh.ParseRawPrivateKeyWithPassphrase(keyBytes, []byte(passphrase))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt %s: %w", keyFile, err)
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return fmt.Errorf("failed to connect to ssh-agent: %w", err)
+	}
+	defer conn.Close()
+	return agent.NewClient(conn).Add(agent.AddedKey{
+		PrivateKey:   key,
+		Comment:      keyFile,
+		LifetimeSecs: lifetimeSecs,
+	})
+}
@@ -0,0 +1,119 @@
+package ssh
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// Like OpenSSH's ControlMaster, the terminal, the file manager and the
+// tools opened for a connection share one SSH connection to its host.
+// Each gets its own Client, and the connection is closed when the last
+// of them is.
+
+// sharedConn is an SSH connection counting the clients using it
+type sharedConn struct {
+	key  string
+	conn *ssh.Client
+	host string
+	refs int // Guarded by pool
+
+	// Agent and X11 channels from the server are relayed once per
+	// connection, for all the sessions asking for them
+	mu           sync.Mutex
+	agentRelayed bool
+	x11Cookie    []byte // Cookie given to the server, nil until X11 is relayed
+}
+
+var pool = struct {
+	sync.Mutex
+	conns map[string]*sharedConn
+}{conns: make(map[string]*sharedConn)}
+
+// poolKey identifies the connections that may be shared: the same saved
+// connection, or the same user and address for unsaved ones
+func poolKey(connConfig config.SSHConnection) string {
+	return fmt.Sprintf("%s\x00%s@%s:%d", connConfig.ID, connConfig.Username, connConfig.Host, connConfig.Port)
+}
+
+// Connect returns a client for connConfig on the connection already open
+// to its host, or on a new one. Closing the client releases its share of
+// the connection.
+func Connect(connConfig config.SSHConnection) (*Client, error) {
+	key := poolKey(connConfig)
+	if client := acquire(key); client != nil {
+		log.Printf("[pool] Sharing the open connection to %s@%s:%d", connConfig.Username, connConfig.Host, connConfig.Port)
+		return client, nil
+	}
+
+	client, err := NewClient(connConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	pool.Lock()
+	if _, ok := pool.conns[key]; ok {
+		// Another caller connected in the meantime, so use theirs
+		pool.Unlock()
+		client.Close()
+		if shared := acquire(key); shared != nil {
+			return shared, nil
+		}
+		return Connect(connConfig)
+	}
+	shared := &sharedConn{key: key, conn: client.conn, host: client.host, refs: 1}
+	pool.conns[key] = shared
+	pool.Unlock()
+	startForwards(shared.conn, connConfig)
+
+	// A connection the server dropped isn't handed out again
+	go func() {
+		shared.conn.Wait()
+		pool.Lock()
+		if pool.conns[key] == shared {
+			delete(pool.conns, key)
+		}
+		pool.Unlock()
+	}()
+
+	client.shared = shared
+	return client, nil
+}
+
+// acquire returns a new client on the open connection for key, or nil
+func acquire(key string) *Client {
+	pool.Lock()
+	defer pool.Unlock()
+	shared, ok := pool.conns[key]
+	if !ok {
+		return nil
+	}
+	shared.refs++
+	return &Client{conn: shared.conn, shared: shared, host: shared.host}
+}
+
+// pooled reports whether a connection to connConfig is open and shared
+func pooled(connConfig config.SSHConnection) bool {
+	pool.Lock()
+	defer pool.Unlock()
+	_, ok := pool.conns[poolKey(connConfig)]
+	return ok
+}
+
+// release drops a client's share, closing the connection with the last one
+func (s *sharedConn) release() error {
+	pool.Lock()
+	s.refs--
+	last := s.refs == 0
+	if last && pool.conns[s.key] == s {
+		delete(pool.conns, s.key)
+	}
+	pool.Unlock()
+	if last {
+		return s.conn.Close()
+	}
+	return nil
+}
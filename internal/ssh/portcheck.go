@@ -0,0 +1,189 @@
+package ssh
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// maxPortSearch bounds how far NextFreeLocalPort looks past the requested port
+const maxPortSearch = 100
+
+// PortInUseError reports that a local port is already bound, and by whom if known
+type PortInUseError struct {
+	Port  int
+	Owner *PortOwner
+}
+
+func (e *PortInUseError) Error() string {
+	if e.Owner != nil {
+		return fmt.Sprintf("local port %d is in use by %s (pid %d)", e.Port, e.Owner.Command, e.Owner.PID)
+	}
+	return fmt.Sprintf("local port %d is in use", e.Port)
+}
+
+// PortOwner identifies the local process listening on a port
+type PortOwner struct {
+	PID     int
+	Command string
+}
+
+// CheckLocalPort verifies that bindAddr:port can be bound before starting a
+// local forward, bindAddr "" meaning every interface. When the port is
+// taken it returns a *PortInUseError describing the owner; other failures,
+// such as a privileged port or an unknown address, are returned as they are.
+func CheckLocalPort(bindAddr string, port int) error {
+	ln, err := net.Listen("tcp", net.JoinHostPort(bindAddr, strconv.Itoa(port)))
+	if err != nil {
+		if !isAddrInUse(err) {
+			return err
+		}
+		owner, _ := FindLocalPortOwner(port)
+		return &PortInUseError{Port: port, Owner: owner}
+	}
+	ln.Close()
+	return nil
+}
+
+// isAddrInUse reports whether err is EADDRINUSE, or WSAEADDRINUSE on Windows
+func isAddrInUse(err error) bool {
+	var errno syscall.Errno
+	return errors.As(err, &errno) && (errno == syscall.EADDRINUSE || errno == 10048)
+}
+
+// LocalForwardConflict is a LocalForward whose local port is taken
+type LocalForwardConflict struct {
+	Spec string // The forward as configured
+	Err  *PortInUseError
+	// Replacement is the forward moved to the next free port, empty when
+	// none was found
+	Replacement string
+}
+
+// LocalForwardConflicts checks the local ports of the connection's
+// LocalForward before they are bound. A connection that is already open
+// has its forwards running, so it reports none.
+func LocalForwardConflicts(conn config.SSHConnection) []LocalForwardConflict {
+	if len(conn.LocalForward) == 0 || pooled(conn) {
+		return nil
+	}
+	var conflicts []LocalForwardConflict
+	for _, spec := range conn.LocalForward {
+		listen, _, err := config.ParseForward(spec)
+		if err != nil {
+			continue // startForwards logs it
+		}
+		host, portStr, _ := net.SplitHostPort(listen)
+		port, _ := strconv.Atoi(portStr)
+		var inUse *PortInUseError
+		if err := CheckLocalPort(host, port); !errors.As(err, &inUse) {
+			continue
+		}
+		conflict := LocalForwardConflict{Spec: spec, Err: inUse}
+		if next, err := NextFreeLocalPort(host, port+1); err == nil {
+			conflict.Replacement = config.ForwardWithPort(spec, next)
+		}
+		conflicts = append(conflicts, conflict)
+	}
+	return conflicts
+}
+
+// NextFreeLocalPort returns the first port from start upwards that can be
+// bound on bindAddr
+func NextFreeLocalPort(bindAddr string, start int) (int, error) {
+	for port := start; port < start+maxPortSearch && port <= 65535; port++ {
+		if CheckLocalPort(bindAddr, port) == nil {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no free local port found in %d-%d", start, start+maxPortSearch-1)
+}
+
+// FindLocalPortOwner looks up the process listening on port using lsof on
+// Unix-like systems and netstat/tasklist on Windows.
+func FindLocalPortOwner(port int) (*PortOwner, error) {
+	if runtime.GOOS == "windows" {
+		return findPortOwnerNetstat(port)
+	}
+	return findPortOwnerLsof(port)
+}
+
+func findPortOwnerLsof(port int) (*PortOwner, error) {
+	if _, err := exec.LookPath("lsof"); err != nil {
+		return nil, errors.New("lsof not available")
+	}
+	// -F pc prints one field per line: p<pid> and c<command>
+	out, err := exec.Command("lsof", "-nP", "-iTCP:"+strconv.Itoa(port), "-sTCP:LISTEN", "-F", "pc").Output()
+	if err != nil {
+		return nil, fmt.Errorf("lsof failed: %w", err)
+	}
+	return parseLsofFields(out)
+}
+
+func parseLsofFields(out []byte) (*PortOwner, error) {
+	owner := &PortOwner{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) < 2 {
+			continue
+		}
+		switch line[0] {
+		case 'p':
+			if owner.PID != 0 {
+				return owner, nil // Only report the first process
+			}
+			owner.PID, _ = strconv.Atoi(line[1:])
+		case 'c':
+			owner.Command = line[1:]
+		}
+	}
+	if owner.PID == 0 {
+		return nil, errors.New("no listening process found")
+	}
+	return owner, nil
+}
+
+func findPortOwnerNetstat(port int) (*PortOwner, error) {
+	out, err := exec.Command("netstat", "-ano", "-p", "TCP").Output()
+	if err != nil {
+		return nil, fmt.Errorf("netstat failed: %w", err)
+	}
+	pid := parseNetstatListeningPID(string(out), port)
+	if pid == 0 {
+		return nil, errors.New("no listening process found")
+	}
+	owner := &PortOwner{PID: pid}
+	// tasklist /FO CSV /NH prints: "image.exe","1234",...
+	if taskOut, err := exec.Command("tasklist", "/FI", "PID eq "+strconv.Itoa(pid), "/FO", "CSV", "/NH").Output(); err == nil {
+		if fields := strings.Split(strings.TrimSpace(string(taskOut)), ","); len(fields) > 0 {
+			owner.Command = strings.Trim(fields[0], `"`)
+		}
+	}
+	return owner, nil
+}
+
+// parseNetstatListeningPID finds the PID in `netstat -ano` output listening on port
+func parseNetstatListeningPID(output string, port int) int {
+	suffix := ":" + strconv.Itoa(port)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || !strings.EqualFold(fields[3], "LISTENING") {
+			continue
+		}
+		if strings.HasSuffix(fields[1], suffix) {
+			pid, _ := strconv.Atoi(fields[4])
+			return pid
+		}
+	}
+	return 0
+}
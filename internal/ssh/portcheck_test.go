@@ -0,0 +1,116 @@
+package ssh
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+func TestParseLsofFields(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		out  string
+		want *PortOwner
+	}{
+		{"one process", "p1234\ncnginx\nf6\n", &PortOwner{PID: 1234, Command: "nginx"}},
+		{"first of several", "p10\ncnode\nf20\np11\ncnode\nf21\n", &PortOwner{PID: 10, Command: "node"}},
+		{"no command", "p99\n", &PortOwner{PID: 99}},
+		{"nothing", "", nil},
+		{"garbage", "x\n\nzz\n", nil},
+	} {
+		got, err := parseLsofFields([]byte(tt.out))
+		switch {
+		case tt.want == nil && err == nil:
+			t.Errorf("%s: parseLsofFields = %+v, want an error", tt.name, got)
+		case tt.want != nil && (err != nil || *got != *tt.want):
+			t.Errorf("%s: parseLsofFields = %+v, %v, want %+v", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseNetstatListeningPID(t *testing.T) {
+	const output = `
+Active Connections
+
+  Proto  Local Address          Foreign Address        State           PID
+  TCP    0.0.0.0:135            0.0.0.0:0              LISTENING       1044
+  TCP    127.0.0.1:8080         0.0.0.0:0              LISTENING       4321
+  TCP    127.0.0.1:18080        0.0.0.0:0              LISTENING       555
+  TCP    127.0.0.1:50123        127.0.0.1:8080         ESTABLISHED     7777
+  TCP    [::]:5432              [::]:0                 LISTENING       2020
+`
+	for _, tt := range []struct {
+		port, want int
+	}{
+		{135, 1044},
+		{8080, 4321},
+		{18080, 555},
+		{5432, 2020},
+		{50123, 0}, // Not listening
+		{9999, 0},
+	} {
+		if got := parseNetstatListeningPID(output, tt.port); got != tt.want {
+			t.Errorf("parseNetstatListeningPID(%d) = %d, want %d", tt.port, got, tt.want)
+		}
+	}
+}
+
+// listenLocal holds a loopback port for the test and returns it
+func listenLocal(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestCheckLocalPort(t *testing.T) {
+	port := listenLocal(t)
+	var inUse *PortInUseError
+	if err := CheckLocalPort("127.0.0.1", port); !errors.As(err, &inUse) || inUse.Port != port {
+		t.Errorf("CheckLocalPort on a bound port = %v, want a PortInUseError", err)
+	}
+	if err := CheckLocalPort("192.0.2.1", 8080); err == nil || errors.As(err, &inUse) {
+		t.Errorf("CheckLocalPort on an address of no interface = %v, want a plain error", err)
+	}
+	next, err := NextFreeLocalPort("127.0.0.1", port)
+	if err != nil || next <= port {
+		t.Errorf("NextFreeLocalPort(%d) = %d, %v", port, next, err)
+	}
+}
+
+func TestLocalForwardConflicts(t *testing.T) {
+	server := newTestServer(t)
+	port := listenLocal(t)
+	free := freePort(t)
+
+	conn := server.connection()
+	conn.LocalForward = []string{
+		"127.0.0.1:" + strconv.Itoa(port) + " db:5432",
+		free + " web:80",
+	}
+	conflicts := LocalForwardConflicts(conn)
+	if len(conflicts) != 1 || conflicts[0].Spec != conn.LocalForward[0] || conflicts[0].Err.Port != port {
+		t.Fatalf("LocalForwardConflicts = %+v, want the first forward", conflicts)
+	}
+	listen, target, err := config.ParseForward(conflicts[0].Replacement)
+	if host, p, _ := net.SplitHostPort(listen); err != nil || host != "127.0.0.1" || p == strconv.Itoa(port) || target != "db:5432" {
+		t.Errorf("Replacement = %q, want the forward on another port", conflicts[0].Replacement)
+	}
+
+	// The forwards of an open connection are its own
+	conn.LocalForward = []string{free + " web:80"}
+	client, err := Connect(conn)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer client.Close()
+	if conflicts := LocalForwardConflicts(conn); len(conflicts) != 0 {
+		t.Errorf("LocalForwardConflicts of an open connection = %+v", conflicts)
+	}
+}
@@ -0,0 +1,85 @@
+package ssh
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Process is a single row of `ps aux` output on the remote host
+type Process struct {
+	User    string
+	PID     int
+	CPU     float64
+	Mem     float64
+	VSZ     int64 // KiB
+	RSS     int64 // KiB
+	TTY     string
+	Stat    string
+	Start   string
+	Time    string
+	Command string
+}
+
+// Signals that can be sent from the process manager
+var ProcessSignals = []string{"TERM", "KILL", "HUP"}
+
+// ListProcesses returns the remote process table via `ps aux`
+func (c *Client) ListProcesses() ([]Process, error) {
+	res, err := c.Run("LC_ALL=C ps aux")
+	if err != nil {
+		return nil, err
+	}
+	if res.ExitStatus != 0 {
+		return nil, fmt.Errorf("ps exited with status %d: %s", res.ExitStatus, strings.TrimSpace(res.Stderr))
+	}
+	return ParsePS(res.Stdout), nil
+}
+
+// ParsePS parses the output of `ps aux`, skipping the header and malformed lines
+func ParsePS(output string) []Process {
+	var procs []Process
+	for i, line := range strings.Split(output, "\n") {
+		if i == 0 || strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 11 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		cpu, _ := strconv.ParseFloat(fields[2], 64)
+		mem, _ := strconv.ParseFloat(fields[3], 64)
+		vsz, _ := strconv.ParseInt(fields[4], 10, 64)
+		rss, _ := strconv.ParseInt(fields[5], 10, 64)
+		procs = append(procs, Process{
+			User:    fields[0],
+			PID:     pid,
+			CPU:     cpu,
+			Mem:     mem,
+			VSZ:     vsz,
+			RSS:     rss,
+			TTY:     fields[6],
+			Stat:    fields[7],
+			Start:   fields[8],
+			Time:    fields[9],
+			Command: strings.Join(fields[10:], " "),
+		})
+	}
+	return procs
+}
+
+// SignalProcess sends signal (e.g. "TERM") to the remote process pid
+func (c *Client) SignalProcess(pid int, signal string) error {
+	res, err := c.Run(fmt.Sprintf("kill -s %s %d", signal, pid))
+	if err != nil {
+		return err
+	}
+	if res.ExitStatus != 0 {
+		return fmt.Errorf("kill exited with status %d: %s", res.ExitStatus, strings.TrimSpace(res.Stderr))
+	}
+	return nil
+}
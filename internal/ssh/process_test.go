@@ -0,0 +1,52 @@
+package ssh
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePS(t *testing.T) {
+	const header = "USER         PID %CPU %MEM    VSZ   RSS TTY      STAT START   TIME COMMAND\n"
+	for _, tt := range []struct {
+		name string
+		out  string
+		want []Process
+	}{
+		{
+			"header only",
+			header,
+			nil,
+		},
+		{
+			"one process",
+			header + "root           1  0.0  0.1 167744 11520 ?        Ss   Jan01   0:12 /sbin/init\n",
+			[]Process{{User: "root", PID: 1, CPU: 0, Mem: 0.1, VSZ: 167744, RSS: 11520, TTY: "?", Stat: "Ss", Start: "Jan01", Time: "0:12", Command: "/sbin/init"}},
+		},
+		{
+			"command with spaces",
+			header + "www-data  4321 12.5  3.2 923456 65432 pts/0    Sl+  10:15   1:02 nginx: worker process  --flag\n",
+			[]Process{{User: "www-data", PID: 4321, CPU: 12.5, Mem: 3.2, VSZ: 923456, RSS: 65432, TTY: "pts/0", Stat: "Sl+", Start: "10:15", Time: "1:02", Command: "nginx: worker process --flag"}},
+		},
+		{
+			"short and malformed lines",
+			header + "root 2 0.0 0.0 0 0 ? S\n\n   \ngarbage\n" +
+				"root           3  0.0  0.0      0     0 ?        I<   Jan01   0:00 [rcu_gp]\n",
+			[]Process{{User: "root", PID: 3, TTY: "?", Stat: "I<", Start: "Jan01", Time: "0:00", Command: "[rcu_gp]"}},
+		},
+		{
+			"non-numeric PID",
+			header + "root         abc  0.0  0.0      0     0 ?        S    Jan01   0:00 sleep\n" +
+				"root          -   0.0  0.0      0     0 ?        S    Jan01   0:00 sleep\n",
+			nil,
+		},
+		{
+			"nothing",
+			"",
+			nil,
+		},
+	} {
+		if got := ParsePS(tt.out); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ParsePS = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
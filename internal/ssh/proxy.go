@@ -0,0 +1,252 @@
+package ssh
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/zalando/go-keyring"
+)
+
+const keyringProxyPrefix = "proxy:"
+
+// ParseProxy validates a proxy URL: socks5://[user@]host:port or
+// http://[user@]host:port. socks5h is accepted as an alias of socks5, as
+// names are always resolved by the proxy.
+func ParseProxy(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", raw, err)
+	}
+	switch u.Scheme {
+	case "socks5", "socks5h", "http":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (use socks5:// or http://)", u.Scheme)
+	}
+	if u.Hostname() == "" || u.Port() == "" {
+		return nil, fmt.Errorf("proxy %q needs a host and a port", raw)
+	}
+	return u, nil
+}
+
+// dial opens the transport for an SSH session: the connection's proxy
+// command, its jump hosts, its proxy, or a plain TCP connection
+func dial(conn config.SSHConnection, addr string, timeout time.Duration) (net.Conn, error) {
+	if conn.ProxyCommand != "" {
+		return dialProxyCommand(conn)
+	}
+	if conn.ProxyJump != "" {
+		return dialProxyJump(conn)
+	}
+	if conn.Proxy == "" {
+		return net.DialTimeout("tcp", addr, timeout)
+	}
+
+	proxy, err := ParseProxy(conn.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	user := proxy.User.Username()
+	password := conn.ProxyPassword
+	if password == "" {
+		password, _ = proxy.User.Password()
+	}
+	if password == "" && user != "" {
+		password, err = keyring.Get(keyringService, keyringProxyPrefix+conn.ID)
+		if err != nil {
+			log.Printf("[dial] No proxy password in keyring for connection ID %s: %v", conn.ID, err)
+		}
+	}
+
+	log.Printf("[dial] Connecting to %s through %s proxy %s", addr, proxy.Scheme, proxy.Host)
+	proxyConn, err := net.DialTimeout("tcp", proxy.Host, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach proxy %s: %w", proxy.Host, err)
+	}
+
+	// The handshake with the proxy shares the dial timeout
+	proxyConn.SetDeadline(time.Now().Add(timeout))
+	var tunnel net.Conn
+	if proxy.Scheme == "http" {
+		tunnel, err = httpConnect(proxyConn, addr, user, password)
+	} else {
+		tunnel, err = socks5Connect(proxyConn, addr, user, password)
+	}
+	if err != nil {
+		proxyConn.Close()
+		return nil, fmt.Errorf("proxy %s: %w", proxy.Host, err)
+	}
+	proxyConn.SetDeadline(time.Time{})
+	return tunnel, nil
+}
+
+// socks5Replies are the failure messages of RFC 1928, section 6
+var socks5Replies = map[byte]string{
+	1: "general SOCKS server failure",
+	2: "connection not allowed by ruleset",
+	3: "network unreachable",
+	4: "host unreachable",
+	5: "connection refused",
+	6: "TTL expired",
+	7: "command not supported",
+	8: "address type not supported",
+}
+
+// socks5Connect asks a SOCKS5 proxy to connect to addr, authenticating
+// with a username and password (RFC 1929) when user is set
+func socks5Connect(conn net.Conn, addr, user, password string) (net.Conn, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", portStr)
+	}
+
+	methods := []byte{0x00}
+	if user != "" {
+		methods = []byte{0x00, 0x02}
+	}
+	if _, err := conn.Write(append([]byte{0x05, byte(len(methods))}, methods...)); err != nil {
+		return nil, err
+	}
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return nil, fmt.Errorf("reading SOCKS5 greeting: %w", err)
+	}
+	if reply[0] != 0x05 {
+		return nil, errors.New("not a SOCKS5 proxy")
+	}
+
+	switch reply[1] {
+	case 0x00:
+	case 0x02:
+		if user == "" {
+			return nil, errors.New("SOCKS5 proxy requires a username and password")
+		}
+		if len(user) > 255 || len(password) > 255 {
+			return nil, errors.New("SOCKS5 username and password are limited to 255 bytes")
+		}
+		auth := []byte{0x01, byte(len(user))}
+		auth = append(auth, user...)
+		auth = append(auth, byte(len(password)))
+		auth = append(auth, password...)
+		if _, err := conn.Write(auth); err != nil {
+			return nil, err
+		}
+		if _, err := io.ReadFull(conn, reply); err != nil {
+			return nil, fmt.Errorf("reading SOCKS5 authentication reply: %w", err)
+		}
+		if reply[1] != 0x00 {
+			return nil, errors.New("SOCKS5 authentication failed")
+		}
+	default:
+		return nil, errors.New("SOCKS5 proxy accepts none of our authentication methods")
+	}
+
+	// Names are sent as they are so the proxy resolves them
+	request := []byte{0x05, 0x01, 0x00}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			request = append(append(request, 0x01), ip4...)
+		} else {
+			request = append(append(request, 0x04), ip.To16()...)
+		}
+	} else {
+		if len(host) > 255 {
+			return nil, fmt.Errorf("host name %q is too long for SOCKS5", host)
+		}
+		request = append(append(request, 0x03, byte(len(host))), host...)
+	}
+	request = binary.BigEndian.AppendUint16(request, uint16(port))
+	if _, err := conn.Write(request); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return nil, fmt.Errorf("reading SOCKS5 connect reply: %w", err)
+	}
+	if header[1] != 0x00 {
+		if msg, ok := socks5Replies[header[1]]; ok {
+			return nil, fmt.Errorf("SOCKS5 connect to %s failed: %s", addr, msg)
+		}
+		return nil, fmt.Errorf("SOCKS5 connect to %s failed with code %d", addr, header[1])
+	}
+
+	// Skip the bound address that follows, whose length depends on its type
+	var skip int
+	switch header[3] {
+	case 0x01:
+		skip = net.IPv4len
+	case 0x04:
+		skip = net.IPv6len
+	case 0x03:
+		size := make([]byte, 1)
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return nil, err
+		}
+		skip = int(size[0])
+	default:
+		return nil, fmt.Errorf("SOCKS5 reply has unknown address type %d", header[3])
+	}
+	if _, err := io.ReadFull(conn, make([]byte, skip+2)); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// httpConnect opens a tunnel to addr with an HTTP CONNECT request
+func httpConnect(conn net.Conn, addr, user, password string) (net.Conn, error) {
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, fmt.Errorf("reading CONNECT response: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CONNECT to %s failed: %s", addr, resp.Status)
+	}
+
+	// The server's SSH banner may already sit in the reader's buffer
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn reads what was buffered while parsing the proxy's response
+// before reading from the connection again
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
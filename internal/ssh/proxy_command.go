@@ -0,0 +1,194 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// proxyStderrLimit is how much of a proxy command's stderr is kept for
+// error messages
+const proxyStderrLimit = 2048
+
+// expandProxyCommand replaces the OpenSSH tokens %h, %p, %r, %n and %% in a
+// ProxyCommand
+func expandProxyCommand(command string, conn config.SSHConnection) string {
+	port := conn.Port
+	if port == 0 {
+		port = 22
+	}
+	alias := conn.HostPattern
+	if alias == "" {
+		alias = conn.Host
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(command); i++ {
+		if command[i] != '%' || i+1 == len(command) {
+			b.WriteByte(command[i])
+			continue
+		}
+		i++
+		switch command[i] {
+		case 'h':
+			b.WriteString(conn.Host)
+		case 'p':
+			b.WriteString(strconv.Itoa(port))
+		case 'r':
+			b.WriteString(conn.Username)
+		case 'n':
+			b.WriteString(alias)
+		case '%':
+			b.WriteByte('%')
+		default:
+			// Unknown tokens are left as written
+			b.WriteByte('%')
+			b.WriteByte(command[i])
+		}
+	}
+	return b.String()
+}
+
+// dialProxyCommand starts the connection's ProxyCommand through the shell
+// and uses its stdin and stdout as the transport
+func dialProxyCommand(conn config.SSHConnection) (net.Conn, error) {
+	command := expandProxyCommand(conn.ProxyCommand, conn)
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	return dialCommand(cmd, command)
+}
+
+// dialProxyJump reaches the host through the connection's ProxyJump hops
+// like OpenSSH does, with ssh -W to the host through the last hop, and the
+// hops before it given to -J
+func dialProxyJump(conn config.SSHConnection) (net.Conn, error) {
+	port := conn.Port
+	if port == 0 {
+		port = 22
+	}
+	hops := strings.Split(conn.ProxyJump, ",")
+	var args []string
+	if len(hops) > 1 {
+		args = append(args, "-J", strings.Join(hops[:len(hops)-1], ","))
+	}
+	args = append(args, "-W", net.JoinHostPort(conn.Host, strconv.Itoa(port)), jumpDestination(hops[len(hops)-1]))
+	return dialCommand(exec.Command("ssh", args...), "ssh "+strings.Join(args, " "))
+}
+
+// jumpDestination turns a [user@]host[:port] hop into a destination for
+// ssh, which takes a port only in an ssh:// URI
+func jumpDestination(hop string) string {
+	hop = strings.TrimSpace(hop)
+	if _, _, err := net.SplitHostPort(hop[strings.LastIndex(hop, "@")+1:]); err == nil {
+		return "ssh://" + hop
+	}
+	return hop
+}
+
+// dialCommand starts cmd, described by command in errors, and uses its
+// stdin and stdout as the transport
+func dialCommand(cmd *exec.Cmd, command string) (net.Conn, error) {
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	pc := &commandConn{cmd: cmd, command: command, stdin: stdin, stdout: stdout}
+	cmd.Stderr = &pc.stderr
+	startInProcessGroup(cmd)
+	// Children that escaped the process group may hold stderr open;
+	// don't let Close wait on them
+	cmd.WaitDelay = time.Second
+
+	log.Printf("[dialProxyCommand] Starting %q", command)
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start proxy command %q: %w", command, err)
+	}
+	return pc, nil
+}
+
+// commandConn is a net.Conn over the stdio of a proxy command. Deadlines
+// are not supported and silently ignored.
+type commandConn struct {
+	cmd       *exec.Cmd
+	command   string
+	stdin     io.WriteCloser
+	stdout    io.ReadCloser
+	stderr    stderrTail
+	closeOnce sync.Once
+}
+
+func (c *commandConn) Read(p []byte) (int, error)  { return c.stdout.Read(p) }
+func (c *commandConn) Write(p []byte) (int, error) { return c.stdin.Write(p) }
+
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		killProcessTree(c.cmd)
+		c.cmd.Wait()
+		if tail := c.stderr.String(); tail != "" {
+			log.Printf("[commandConn] %q stderr: %s", c.command, tail)
+		}
+	})
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr                { return commandAddr(c.command) }
+func (c *commandConn) RemoteAddr() net.Addr               { return commandAddr(c.command) }
+func (c *commandConn) SetDeadline(t time.Time) error      { return nil }
+func (c *commandConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *commandConn) SetWriteDeadline(t time.Time) error { return nil }
+
+// describe adds what the proxy command printed to an error, which usually
+// explains why the handshake failed
+func (c *commandConn) describe(err error) error {
+	if tail := strings.TrimSpace(c.stderr.String()); tail != "" {
+		return fmt.Errorf("%w (proxy command: %s)", err, tail)
+	}
+	return err
+}
+
+// commandAddr names a proxy command where an address is expected
+type commandAddr string
+
+func (a commandAddr) Network() string { return "proxy-command" }
+func (a commandAddr) String() string  { return string(a) }
+
+// stderrTail keeps the last proxyStderrLimit bytes written to it
+type stderrTail struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (s *stderrTail) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buf = append(s.buf, p...)
+	if over := len(s.buf) - proxyStderrLimit; over > 0 {
+		s.buf = s.buf[over:]
+	}
+	return len(p), nil
+}
+
+func (s *stderrTail) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return string(s.buf)
+}
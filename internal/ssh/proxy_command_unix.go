@@ -0,0 +1,24 @@
+//go:build !windows
+// +build !windows
+
+package ssh
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// startInProcessGroup runs cmd in a process group of its own so that
+// killProcessTree also reaches whatever the proxy command spawned
+func startInProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func killProcessTree(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		cmd.Process.Kill()
+	}
+}
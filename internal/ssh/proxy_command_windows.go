@@ -0,0 +1,14 @@
+//go:build windows
+// +build windows
+
+package ssh
+
+import "os/exec"
+
+func startInProcessGroup(cmd *exec.Cmd) {}
+
+func killProcessTree(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,60 @@
+package ssh
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// RateLimitEnv sets the default bandwidth limit of SFTP transfers in KB/s
+const RateLimitEnv = "SXT_BANDWIDTH_LIMIT"
+
+// DefaultRateLimit returns the limit set in $SXT_BANDWIDTH_LIMIT in KB/s,
+// or 0 (unlimited) when it is unset or invalid
+func DefaultRateLimit() int {
+	kbps, err := strconv.Atoi(os.Getenv(RateLimitEnv))
+	if err != nil || kbps < 0 {
+		return 0
+	}
+	return kbps
+}
+
+// FormatRateLimit renders a limit in KB/s for display
+func FormatRateLimit(kbps int) string {
+	switch {
+	case kbps <= 0:
+		return "unlimited"
+	case kbps >= 1024 && kbps%1024 == 0:
+		return strconv.Itoa(kbps/1024) + " MB/s"
+	default:
+		return strconv.Itoa(kbps) + " KB/s"
+	}
+}
+
+// rateLimitedReader slows reads down to an average of bytesPerSec
+type rateLimitedReader struct {
+	r           io.Reader
+	bytesPerSec int64
+	start       time.Time
+	total       int64
+}
+
+func newRateLimitedReader(r io.Reader, kbps int) *rateLimitedReader {
+	return &rateLimitedReader{r: r, bytesPerSec: int64(kbps) * 1024, start: time.Now()}
+}
+
+func (l *rateLimitedReader) Read(p []byte) (int, error) {
+	// Small reads keep the rate smooth instead of bursting a whole buffer
+	if chunk := max(l.bytesPerSec/10, 1024); int64(len(p)) > chunk {
+		p = p[:chunk]
+	}
+	n, err := l.r.Read(p)
+	l.total += int64(n)
+
+	due := time.Duration(float64(l.total) / float64(l.bytesPerSec) * float64(time.Second))
+	if wait := due - time.Since(l.start); wait > 0 {
+		time.Sleep(wait)
+	}
+	return n, err
+}
@@ -0,0 +1,189 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPortTargets bounds how many ports one check may expand to
+const maxPortTargets = 256
+
+// PortTarget is a port to check from the remote host; an empty Host means
+// the remote host itself
+type PortTarget struct {
+	Host string
+	Port int
+}
+
+func (t PortTarget) String() string {
+	if t.Host == "" {
+		return strconv.Itoa(t.Port)
+	}
+	return net.JoinHostPort(t.Host, strconv.Itoa(t.Port))
+}
+
+// ParsePortTargets reads a list like "22, 80 443, 8000-8005, db:5432"
+func ParsePortTargets(spec string) ([]PortTarget, error) {
+	var targets []PortTarget
+	for _, item := range strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || r == ' ' }) {
+		host, ports := "", item
+		if i := strings.LastIndex(item, ":"); i >= 0 {
+			host, ports = strings.Trim(item[:i], "[]"), item[i+1:]
+			if host == "" {
+				return nil, fmt.Errorf("missing host in %q", item)
+			}
+		}
+		first, last, isRange := strings.Cut(ports, "-")
+		if !isRange {
+			last = first
+		}
+		start, err := parsePort(first)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port in %q", item)
+		}
+		end, err := parsePort(last)
+		if err != nil || end < start {
+			return nil, fmt.Errorf("invalid port range in %q", item)
+		}
+		for port := start; port <= end; port++ {
+			if len(targets) == maxPortTargets {
+				return nil, fmt.Errorf("too many ports, at most %d can be checked at once", maxPortTargets)
+			}
+			targets = append(targets, PortTarget{Host: host, Port: port})
+		}
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("no ports to check")
+	}
+	return targets, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return port, nil
+}
+
+// DialRemotePort opens a TCP connection to target from the remote host,
+// through a forwarded channel, and returns how long it took. A refused
+// connection means nothing is listening.
+func (c *Client) DialRemotePort(target PortTarget, timeout time.Duration) (time.Duration, error) {
+	host := target.Host
+	if host == "" {
+		host = "localhost"
+	}
+	type dialResult struct {
+		conn net.Conn
+		err  error
+	}
+	start := time.Now()
+	done := make(chan dialResult, 1)
+	go func() {
+		conn, err := c.conn.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(target.Port)))
+		done <- dialResult{conn, err}
+	}()
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return 0, res.err
+		}
+		res.conn.Close()
+		return time.Since(start), nil
+	case <-time.After(timeout):
+		// Close the channel if it opens after all
+		go func() {
+			if res := <-done; res.conn != nil {
+				res.conn.Close()
+			}
+		}()
+		return 0, fmt.Errorf("timed out after %s", timeout)
+	}
+}
+
+// ListeningSocket is a TCP socket listening on the remote host
+type ListeningSocket struct {
+	Address string // local address, "*" or "0.0.0.0" for all
+	Port    int
+	Process string // name and pid, when the user may see them
+}
+
+// ListListeningPorts returns the TCP sockets listening on the remote host,
+// read with ss or, on hosts without it, netstat
+func (c *Client) ListListeningPorts() ([]ListeningSocket, error) {
+	res, err := c.Run("LC_ALL=C ss -Hltnp 2>/dev/null || LC_ALL=C netstat -ltnp 2>/dev/null || LC_ALL=C netstat -an -p tcp")
+	if err != nil {
+		return nil, err
+	}
+	if res.ExitStatus != 0 {
+		return nil, fmt.Errorf("neither ss nor netstat worked (status %d): %s", res.ExitStatus, strings.TrimSpace(res.Stderr))
+	}
+	return ParseListeningSockets(res.Stdout), nil
+}
+
+// ParseListeningSockets parses the output of `ss -Hltnp`, `netstat -ltnp`
+// (Linux) or `netstat -an -p tcp` (BSD, macOS), keeping listening sockets
+func ParseListeningSockets(output string) []ListeningSocket {
+	var sockets []ListeningSocket
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		var local, process string
+		switch {
+		case fields[0] == "LISTEN" && len(fields) >= 5:
+			// ss: State Recv-Q Send-Q Local Peer [Process]
+			local = fields[3]
+			if len(fields) > 5 {
+				process = ssProcess(strings.Join(fields[5:], " "))
+			}
+		case strings.HasPrefix(fields[0], "tcp") && len(fields) >= 6 && fields[5] == "LISTEN":
+			// netstat: Proto Recv-Q Send-Q Local Foreign State [PID/Program]
+			local = fields[3]
+			if len(fields) > 6 && fields[6] != "-" {
+				process = fields[6]
+			}
+		default:
+			continue
+		}
+		address, port, ok := splitSocketAddress(local)
+		if !ok {
+			continue
+		}
+		sockets = append(sockets, ListeningSocket{Address: address, Port: port, Process: process})
+	}
+	return sockets
+}
+
+// ssProcess turns `users:(("sshd",pid=812,fd=3))` into "sshd/812"
+func ssProcess(users string) string {
+	name, rest, ok := strings.Cut(strings.TrimPrefix(users, `users:((`), `",pid=`)
+	if !ok {
+		return ""
+	}
+	pid, _, _ := strings.Cut(rest, ",")
+	return strings.Trim(name, `"`) + "/" + pid
+}
+
+// splitSocketAddress splits "0.0.0.0:22", "[::]:22", "*:22", "*.22" or
+// "127.0.0.1.5432" (BSD netstat) into address and port
+func splitSocketAddress(local string) (string, int, bool) {
+	i := strings.LastIndexAny(local, ":.")
+	if i < 0 {
+		return "", 0, false
+	}
+	port, err := strconv.Atoi(local[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	address := strings.Trim(local[:i], "[]")
+	// ss prints the interface of link-local and device-bound sockets
+	address, _, _ = strings.Cut(address, "%")
+	return address, port, true
+}
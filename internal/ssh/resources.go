@@ -0,0 +1,92 @@
+package ssh
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// hostStatsCommand prints the load average, the aggregate CPU counters, the
+// memory totals and the usage of the root filesystem of a Linux host
+const hostStatsCommand = "cat /proc/loadavg; head -n 1 /proc/stat; " +
+	"grep -E '^(MemTotal|MemAvailable):' /proc/meminfo; LC_ALL=C df -Pk / | tail -n 1"
+
+// HostStats is a sample of the resource usage of the remote host
+type HostStats struct {
+	// CPU is the busy percentage since the previous sample, -1 for the first
+	CPU float64
+	// MemUsed and MemTotal are in KiB; used excludes caches
+	MemUsed  int64
+	MemTotal int64
+	// Load1, Load5 and Load15 are the load averages
+	Load1  float64
+	Load5  float64
+	Load15 float64
+	// Disk is the used percentage of the root filesystem, -1 if unknown
+	Disk int
+
+	cpuBusy  uint64
+	cpuTotal uint64
+}
+
+// HostStats samples the resource usage of the remote host over an exec
+// channel next to any open shell. prev is the previous sample, if any, to
+// compute the CPU usage from.
+func (c *Client) HostStats(prev *HostStats) (*HostStats, error) {
+	res, err := c.Run(hostStatsCommand)
+	if err != nil {
+		return nil, err
+	}
+	// df may fail on its own without spoiling the rest
+	return ParseHostStats(res.Stdout, prev)
+}
+
+// ParseHostStats parses the output of hostStatsCommand
+func ParseHostStats(output string, prev *HostStats) (*HostStats, error) {
+	stats := &HostStats{CPU: -1, Disk: -1}
+	var available int64
+	var haveLoad, haveCPU bool
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+			continue
+		case fields[0] == "cpu":
+			var total, idle uint64
+			// guest time, after steal, is already counted in user and nice
+			for i, field := range fields[1:min(len(fields), 9)] {
+				n, _ := strconv.ParseUint(field, 10, 64)
+				total += n
+				// idle and iowait
+				if i == 3 || i == 4 {
+					idle += n
+				}
+			}
+			stats.cpuTotal = total
+			stats.cpuBusy = total - idle
+			haveCPU = true
+		case fields[0] == "MemTotal:" && len(fields) > 1:
+			stats.MemTotal, _ = strconv.ParseInt(fields[1], 10, 64)
+		case fields[0] == "MemAvailable:" && len(fields) > 1:
+			available, _ = strconv.ParseInt(fields[1], 10, 64)
+		case len(fields) == 5 && strings.Contains(fields[3], "/"):
+			stats.Load1, _ = strconv.ParseFloat(fields[0], 64)
+			stats.Load5, _ = strconv.ParseFloat(fields[1], 64)
+			stats.Load15, _ = strconv.ParseFloat(fields[2], 64)
+			haveLoad = true
+		case len(fields) >= 6 && strings.HasSuffix(fields[4], "%"):
+			if used, err := strconv.Atoi(strings.TrimSuffix(fields[4], "%")); err == nil {
+				stats.Disk = used
+			}
+		}
+	}
+	if !haveLoad || !haveCPU {
+		return nil, errors.New("no /proc on the remote host, only Linux is supported")
+	}
+	stats.MemUsed = max(stats.MemTotal-available, 0)
+
+	if prev != nil && stats.cpuTotal > prev.cpuTotal && stats.cpuBusy >= prev.cpuBusy {
+		stats.CPU = float64(stats.cpuBusy-prev.cpuBusy) * 100 / float64(stats.cpuTotal-prev.cpuTotal)
+	}
+	return stats, nil
+}
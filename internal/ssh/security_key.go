@@ -0,0 +1,151 @@
+package ssh
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// SecurityKeyNotice is shown while connecting with a FIDO2 security key
+const SecurityKeyNotice = "Touch your security key to authenticate"
+
+var (
+	securityKeyMu    sync.Mutex
+	securityKeyCache = map[string]bool{}
+)
+
+// IsSecurityKey reports whether keyFile holds a FIDO2 security key
+// (sk-ssh-ed25519@openssh.com or sk-ecdsa-sha2-nistp256@openssh.com).
+// Results are cached, as this is called while rendering.
+func IsSecurityKey(keyFile string) bool {
+	if keyFile == "" {
+		return false
+	}
+	path := config.ExpandPath(keyFile)
+
+	securityKeyMu.Lock()
+	defer securityKeyMu.Unlock()
+	if isSK, ok := securityKeyCache[path]; ok {
+		return isSK
+	}
+	isSK := false
+	if keyBytes, err := os.ReadFile(path); err == nil {
+		if pub, err := privateKeyPublicPart(keyBytes); err == nil {
+			isSK = isSecurityKeyType(pub.Type())
+		}
+	}
+	securityKeyCache[path] = isSK
+	return isSK
+}
+
+// UsesSecurityKey reports whether connecting to conn will need a touch
+func UsesSecurityKey(conn config.SSHConnection) bool {
+	return !conn.UsePassword && IsSecurityKey(conn.KeyFile)
+}
+
+func isSecurityKeyType(keyType string) bool {
+	return keyType == ssh.KeyAlgoSKED25519 || keyType == ssh.KeyAlgoSKECDSA256
+}
+
+// privateKeyPublicPart reads the public key stored unencrypted in the
+// header of an OpenSSH private key, so it works without the passphrase
+func privateKeyPublicPart(keyBytes []byte) (ssh.PublicKey, error) {
+	block, _ := pem.Decode(keyBytes)
+	if block == nil || block.Type != "OPENSSH PRIVATE KEY" {
+		return nil, errors.New("not an OpenSSH private key")
+	}
+	const magic = "openssh-key-v1\x00"
+	data, ok := bytes.CutPrefix(block.Bytes, []byte(magic))
+	if !ok {
+		return nil, errors.New("invalid OpenSSH private key header")
+	}
+	// ciphername, kdfname and kdfoptions precede the key count
+	for range 3 {
+		if data, ok = skipSSHString(data); !ok {
+			return nil, errors.New("truncated OpenSSH private key")
+		}
+	}
+	if len(data) < 4 || binary.BigEndian.Uint32(data) < 1 {
+		return nil, errors.New("OpenSSH private key has no keys")
+	}
+	data = data[4:]
+	if len(data) < 4 {
+		return nil, errors.New("truncated OpenSSH private key")
+	}
+	n := binary.BigEndian.Uint32(data)
+	if uint32(len(data)-4) < n {
+		return nil, errors.New("truncated OpenSSH private key")
+	}
+	return ssh.ParsePublicKey(data[4 : 4+n])
+}
+
+func skipSSHString(data []byte) ([]byte, bool) {
+	if len(data) < 4 {
+		return nil, false
+	}
+	n := binary.BigEndian.Uint32(data)
+	if uint32(len(data)-4) < n {
+		return nil, false
+	}
+	return data[4+n:], true
+}
+
+// ensureSecurityKeyInAgent makes sure the agent holds the security key, as
+// signing has to go through ssh-agent and its FIDO helper. Keys that are not
+// loaded yet are added with ssh-add, which only works without a passphrase.
+func ensureSecurityKeyInAgent(agentClient agent.ExtendedAgent, keyFile string, pub ssh.PublicKey) error {
+	if agentHasKey(agentClient, pub) {
+		return nil
+	}
+	log.Printf("[NewClient] Security key %s not in agent, running ssh-add", keyFile)
+	cmd := exec.Command("ssh-add", keyFile)
+	// Never let ssh-add prompt on the terminal the TUI owns
+	cmd.Env = append(os.Environ(), "SSH_ASKPASS_REQUIRE=never")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil || !agentHasKey(agentClient, pub) {
+		log.Printf("[NewClient] ssh-add %s failed: %v %s", keyFile, err, stderr.String())
+		return fmt.Errorf("security key %s is not loaded in ssh-agent; add it with `ssh-add %s` and try again", keyFile, keyFile)
+	}
+	return nil
+}
+
+// agentSigners returns the agent's signers with preferred, if set, first
+func agentSigners(agentClient agent.ExtendedAgent, preferred ssh.PublicKey) ([]ssh.Signer, error) {
+	signers, err := agentClient.Signers()
+	if err != nil || preferred == nil {
+		return signers, err
+	}
+	want := preferred.Marshal()
+	for i, signer := range signers {
+		if bytes.Equal(signer.PublicKey().Marshal(), want) {
+			ordered := append([]ssh.Signer{signer}, signers[:i]...)
+			return append(ordered, signers[i+1:]...), nil
+		}
+	}
+	return signers, nil
+}
+
+func agentHasKey(agentClient agent.ExtendedAgent, pub ssh.PublicKey) bool {
+	keys, err := agentClient.List()
+	if err != nil {
+		return false
+	}
+	want := pub.Marshal()
+	for _, k := range keys {
+		if bytes.Equal(k.Marshal(), want) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package ssh
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// openSSHKeyFile encodes an unencrypted OpenSSH private key file holding the
+// public key blob pub. The private section is never read here, so it's left
+// empty.
+func openSSHKeyFile(pub []byte) []byte {
+	data := append([]byte("openssh-key-v1\x00"), ssh.Marshal(struct {
+		CipherName   string
+		KdfName      string
+		KdfOpts      string
+		NumKeys      uint32
+		PubKey       []byte
+		PrivKeyBlock []byte
+	}{"none", "none", "", 1, pub, nil})...)
+	return pem.EncodeToMemory(&pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: data})
+}
+
+func TestPrivateKeyPublicPart(t *testing.T) {
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sshPub, err := ssh.NewPublicKey(edPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skPub := ssh.Marshal(struct {
+		Name        string
+		KeyBytes    []byte
+		Application string
+	}{ssh.KeyAlgoSKED25519, edPub, "ssh:"})
+
+	plain, err := ssh.MarshalPrivateKey(edPriv, "plain")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := ssh.MarshalPrivateKeyWithPassphrase(edPriv, "encrypted", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)})
+	truncated := pem.EncodeToMemory(&pem.Block{Type: plain.Type, Bytes: plain.Bytes[:60]})
+	noKeys := pem.EncodeToMemory(&pem.Block{Type: plain.Type, Bytes: bytes.Replace(plain.Bytes, []byte{0, 0, 0, 1}, []byte{0, 0, 0, 0}, 1)})
+
+	for _, tt := range []struct {
+		name     string
+		key      []byte
+		wantType string // "" when an error is expected
+		wantSK   bool
+	}{
+		{"security key", openSSHKeyFile(skPub), ssh.KeyAlgoSKED25519, true},
+		{"ed25519", pem.EncodeToMemory(plain), ssh.KeyAlgoED25519, false},
+		{"encrypted ed25519", pem.EncodeToMemory(encrypted), ssh.KeyAlgoED25519, false},
+		{"PEM RSA", rsaPEM, "", false},
+		{"truncated", truncated, "", false},
+		{"no keys", noKeys, "", false},
+		{"not a key", []byte("hello"), "", false},
+	} {
+		pub, err := privateKeyPublicPart(tt.key)
+		if tt.wantType == "" {
+			if err == nil {
+				t.Errorf("%s: privateKeyPublicPart = %s, want an error", tt.name, pub.Type())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: privateKeyPublicPart: %v", tt.name, err)
+			continue
+		}
+		if pub.Type() != tt.wantType || isSecurityKeyType(pub.Type()) != tt.wantSK {
+			t.Errorf("%s: got a %s key, want %s", tt.name, pub.Type(), tt.wantType)
+		}
+		if !tt.wantSK && !bytes.Equal(pub.Marshal(), sshPub.Marshal()) {
+			t.Errorf("%s: got a different public key than the one generated", tt.name)
+		}
+	}
+}
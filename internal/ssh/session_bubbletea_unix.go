@@ -10,6 +10,7 @@ import (
 	"sync"
 
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/metrics"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -17,6 +18,7 @@ import (
 type BubbleTeaSession struct {
 	client  *Client
 	session *ssh.Session
+	conn    config.SSHConnection
 	stdin   io.WriteCloser
 	stdout  io.Reader
 	stderr  io.Reader
@@ -26,11 +28,14 @@ type BubbleTeaSession struct {
 	mutex   sync.Mutex
 	width   int
 	height  int
+
+	exitOnce sync.Once
+	exit     SessionExit
 }
 
 // NewBubbleTeaSession creates a new SSH session for use within Bubble Tea
 func NewBubbleTeaSession(connConfig config.SSHConnection, width, height int) (*BubbleTeaSession, error) {
-	client, err := NewClient(connConfig)
+	client, err := Connect(connConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -40,6 +45,8 @@ func NewBubbleTeaSession(connConfig config.SSHConnection, width, height int) (*B
 		client.Close()
 		return nil, fmt.Errorf("failed to create SSH session: %w", err)
 	}
+	client.requestForwarding(sshSession, connConfig)
+	requestEnv(sshSession, connConfig)
 
 	// Set up terminal modes for raw mode (better terminal emulation)
 	modes := ssh.TerminalModes{
@@ -49,8 +56,14 @@ func NewBubbleTeaSession(connConfig config.SSHConnection, width, height int) (*B
 		ssh.ISIG:          1, // Enable signal generation (Ctrl+C, etc.)
 	}
 
+	// The embedded terminal renders 24-bit color; most servers only accept
+	// LANG/LC_* so a rejected request is not an error
+	if err := sshSession.Setenv("COLORTERM", "truecolor"); err != nil {
+		log.Printf("Server did not accept COLORTERM: %v", err)
+	}
+
 	// Request PTY
-	if err := sshSession.RequestPty("xterm-256color", height, width, modes); err != nil {
+	if err := requestPty(sshSession, connConfig, width, height, modes); err != nil {
 		sshSession.Close()
 		client.Close()
 		log.Printf("Failed to request PTY: %v", err)
@@ -85,6 +98,7 @@ func NewBubbleTeaSession(connConfig config.SSHConnection, width, height int) (*B
 	s := &BubbleTeaSession{
 		client:  client,
 		session: sshSession,
+		conn:    connConfig,
 		stdin:   stdin,
 		stdout:  stdout,
 		stderr:  stderr,
@@ -92,13 +106,14 @@ func NewBubbleTeaSession(connConfig config.SSHConnection, width, height int) (*B
 		width:   width,
 		height:  height,
 	}
+	metrics.SessionOpened(connConfig.Host)
 
 	return s, nil
 }
 
 // Start starts the SSH shell
 func (s *BubbleTeaSession) Start() error {
-	if err := s.session.Shell(); err != nil {
+	if err := startShell(s.session, s.conn); err != nil {
 		log.Printf("Failed to start shell: %v", err)
 		return fmt.Errorf("failed to start shell: %w", err)
 	}
@@ -140,6 +155,7 @@ func (s *BubbleTeaSession) Resize(width, height int) error {
 	s.width = width
 	s.height = height
 
+	width, height = s.conn.PtySize(width, height)
 	return s.session.WindowChange(height, width)
 }
 
@@ -158,6 +174,7 @@ func (s *BubbleTeaSession) Close() error {
 	case <-s.done:
 	default:
 		close(s.done)
+		metrics.SessionClosed(s.conn.Host)
 	}
 
 	var sessionErr, clientErr error
@@ -183,3 +200,9 @@ func (s *BubbleTeaSession) IsTerminated() bool {
 		return false
 	}
 }
+
+// Client returns the connection the session runs on, to open more channels
+// such as commands next to the shell
+func (s *BubbleTeaSession) Client() *Client {
+	return s.client
+}
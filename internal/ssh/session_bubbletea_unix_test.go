@@ -0,0 +1,57 @@
+//go:build !windows
+// +build !windows
+
+package ssh
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBubbleTeaSession(t *testing.T) {
+	server := newTestServer(t)
+	conn := server.connection()
+	conn.PtyPadColumns = 1
+	session, err := NewBubbleTeaSession(conn, 80, 24)
+	if err != nil {
+		t.Fatalf("NewBubbleTeaSession: %v", err)
+	}
+	defer session.Close()
+	if err := session.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if event := server.waitEvent("pty "); event != "pty xterm-256color 79x24" {
+		t.Errorf("PTY request = %q", event)
+	}
+
+	// The test server's shell echoes its input
+	if _, err := session.Write([]byte("ls -l\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	var output strings.Builder
+	buf := make([]byte, 64)
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(output.String(), "ls -l\n") && time.Now().Before(deadline) {
+		n, err := session.Read(buf)
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		output.Write(buf[:n])
+	}
+	if output.String() != "ls -l\n" {
+		t.Errorf("shell output = %q", output.String())
+	}
+
+	if err := session.Resize(120, 40); err != nil {
+		t.Fatalf("Resize: %v", err)
+	}
+	if event := server.waitEvent("window "); event != "window 119x40" {
+		t.Errorf("window change = %q", event)
+	}
+
+	session.Close()
+	if !session.IsTerminated() {
+		t.Error("closed session not terminated")
+	}
+}
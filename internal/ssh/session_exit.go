@@ -0,0 +1,65 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// exitStatusWait bounds the wait for the exit status once the output of a
+// session has ended, for servers that close the channel without one
+const exitStatusWait = 2 * time.Second
+
+// SessionExit tells how the remote shell of a session ended
+type SessionExit struct {
+	Status int    // Exit status sent by the server
+	Signal string // Signal that killed the shell, such as "KILL"
+	Err    error  // Set when the session ended without a status, as when the connection drops
+}
+
+// String describes the end as "exit 1", "signal KILL" or "no exit status"
+func (e SessionExit) String() string {
+	switch {
+	case e.Signal != "":
+		return "signal " + e.Signal
+	case e.Err != nil:
+		return "no exit status"
+	}
+	return fmt.Sprintf("exit %d", e.Status)
+}
+
+// Failed reports whether the shell ended with an error, a signal or
+// without telling
+func (e SessionExit) Failed() bool {
+	return e.Status != 0 || e.Signal != "" || e.Err != nil
+}
+
+// ExitStatus waits for the exit status of the remote shell once its
+// output has ended. It can be called any number of times.
+func (s *BubbleTeaSession) ExitStatus() SessionExit {
+	s.exitOnce.Do(func() {
+		waited := make(chan error, 1)
+		go func() { waited <- s.session.Wait() }()
+		select {
+		case err := <-waited:
+			s.exit = sessionExit(err)
+		case <-time.After(exitStatusWait):
+			s.exit = SessionExit{Err: errors.New("timed out waiting for the exit status")}
+		}
+	})
+	return s.exit
+}
+
+// sessionExit turns the result of ssh.Session.Wait into a SessionExit
+func sessionExit(err error) SessionExit {
+	var exitErr *ssh.ExitError
+	switch {
+	case err == nil:
+		return SessionExit{}
+	case errors.As(err, &exitErr):
+		return SessionExit{Status: exitErr.ExitStatus(), Signal: exitErr.Signal()}
+	}
+	return SessionExit{Err: err}
+}
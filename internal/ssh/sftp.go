@@ -1,6 +1,8 @@
 package ssh
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"io"
@@ -8,12 +10,15 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/metrics"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/transfer"
+	"github.com/kr/fs"
 	"github.com/pkg/sftp"
 	"github.com/zalando/go-keyring"
-	"golang.org/x/crypto/ssh"
 )
 
 // FileInfo represents a file or directory with extended metadata
@@ -26,12 +31,42 @@ type FileInfo struct {
 	Perm    string    // Permission string (e.g. drwxr-xr-x)
 	Owner   string    // UID or Owner Name
 	Group   string    // GID or Group Name
+	// ModeBits is the raw mode, used to edit permissions
+	ModeBits os.FileMode
+}
+
+// SFTP is the part of an SFTP session the file manager uses. *sftp.Client
+// is one.
+type SFTP interface {
+	Getwd() (string, error)
+	ReadDir(path string) ([]os.FileInfo, error)
+	Stat(path string) (os.FileInfo, error)
+	Lstat(path string) (os.FileInfo, error)
+	Walk(root string) *fs.Walker
+	StatVFS(path string) (*sftp.StatVFS, error)
+	Open(path string) (*sftp.File, error)
+	OpenFile(path string, flags int) (*sftp.File, error)
+	Create(path string) (*sftp.File, error)
+	MkdirAll(path string) error
+	Rename(oldPath, newPath string) error
+	Remove(path string) error
+	RemoveDirectory(path string) error
+	Chmod(path string, mode os.FileMode) error
+	Chown(path string, uid, gid int) error
+	Chtimes(path string, atime, mtime time.Time) error
+	Close() error
 }
 
 // SFTPClient wraps an SFTP client connection
 type SFTPClient struct {
-	sshClient  *ssh.Client
-	sftpClient *sftp.Client
+	// runner runs the commands SFTP has no request for, such as du and mv
+	runner     SessionRunner
+	sftpClient SFTP
+	rateLimit  int // KB/s for transfers, 0 = unlimited
+	host       string
+	// owner is released on Close, nil when the connection belongs to
+	// another client and stays open
+	owner *Client
 }
 
 // NewSFTPClient creates a new SFTP client connection
@@ -46,8 +81,8 @@ func NewSFTPClient(connConfig config.SSHConnection) (*SFTPClient, error) {
 		connConfig.Password = password
 	}
 
-	// Create SSH client first
-	client, err := NewClient(connConfig)
+	// Share the connection already open to the host, if any
+	client, err := Connect(connConfig)
 	if err != nil {
 		// Check if it's a passphrase error - don't wrap it
 		var passphraseErr *PassphraseRequiredError
@@ -65,11 +100,76 @@ func NewSFTPClient(connConfig config.SSHConnection) (*SFTPClient, error) {
 	}
 
 	return &SFTPClient{
-		sshClient:  client.conn,
+		runner:     client.conn,
 		sftpClient: sftpClient,
+		rateLimit:  DefaultRateLimit(),
+		host:       client.host,
+		owner:      client,
 	}, nil
 }
 
+// NewSFTPClientOn opens an SFTP channel on an established connection,
+// such as the one of a terminal session. Closing the SFTP client leaves
+// the connection open for its owner.
+func NewSFTPClientOn(client *Client) (*SFTPClient, error) {
+	if client == nil || client.conn == nil {
+		return nil, fmt.Errorf("SSH client not connected")
+	}
+	sftpClient, err := sftp.NewClient(client.conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SFTP client: %w", err)
+	}
+	return &SFTPClient{
+		runner:     client.conn,
+		sftpClient: sftpClient,
+		rateLimit:  DefaultRateLimit(),
+		host:       client.host,
+	}, nil
+}
+
+// WithRateLimit returns a client sharing this connection whose transfers
+// are limited to kbps KB/s (0 = unlimited). Close only the original client.
+func (s *SFTPClient) WithRateLimit(kbps int) *SFTPClient {
+	limited := *s
+	limited.rateLimit = max(kbps, 0)
+	return &limited
+}
+
+// RateLimit returns the transfer limit in KB/s, 0 meaning unlimited
+func (s *SFTPClient) RateLimit() int {
+	return s.rateLimit
+}
+
+// copy copies file data for a transfer, within the client's rate limit
+func (s *SFTPClient) copy(dst io.Writer, src io.Reader) (int64, error) {
+	if s.rateLimit > 0 {
+		src = newRateLimitedReader(src, s.rateLimit)
+	}
+	return io.Copy(dst, src)
+}
+
+// download and upload copy file data like copy, counting it in the
+// host's metrics
+func (s *SFTPClient) download(dst io.Writer, src io.Reader) (int64, error) {
+	n, err := s.copy(dst, src)
+	metrics.Transferred(s.host, transfer.Download, n)
+	return n, err
+}
+
+func (s *SFTPClient) upload(dst io.Writer, src io.Reader) (int64, error) {
+	n, err := s.copy(dst, src)
+	metrics.Transferred(s.host, transfer.Upload, n)
+	return n, err
+}
+
+// run runs a helper command on the host of the SFTP session
+func (s *SFTPClient) run(command string) (*CommandResult, error) {
+	if s.runner == nil {
+		return nil, fmt.Errorf("SSH client not connected")
+	}
+	return runCommand(s.runner, command, nil)
+}
+
 // GetWorkingDir returns the current working directory of the SFTP connection
 func (s *SFTPClient) GetWorkingDir() (string, error) {
 	if s.sftpClient == nil {
@@ -78,14 +178,14 @@ func (s *SFTPClient) GetWorkingDir() (string, error) {
 	return s.sftpClient.Getwd()
 }
 
-// Close closes the SFTP and SSH connections
+// Close closes the SFTP channel and releases the SSH connection it opened
 func (s *SFTPClient) Close() error {
 	var err error
 	if s.sftpClient != nil {
 		err = s.sftpClient.Close()
 	}
-	if s.sshClient != nil {
-		if closeErr := s.sshClient.Close(); closeErr != nil && err == nil {
+	if s.owner != nil {
+		if closeErr := s.owner.Close(); closeErr != nil && err == nil {
 			err = closeErr
 		}
 	}
@@ -116,6 +216,8 @@ func (s *SFTPClient) ListFiles(path string) ([]FileInfo, error) {
 			Perm:    entry.Mode().String(),
 			Owner:   owner,
 			Group:   group,
+
+			ModeBits: entry.Mode(),
 		})
 	}
 
@@ -148,6 +250,8 @@ func ListLocalFiles(path string) ([]FileInfo, error) {
 			Perm:    info.Mode().String(),
 			Owner:   owner,
 			Group:   group,
+
+			ModeBits: info.Mode(),
 		})
 	}
 
@@ -165,6 +269,18 @@ func sortFiles(files []FileInfo) {
 	})
 }
 
+// IsDir reports whether a remote path is a directory
+func (s *SFTPClient) IsDir(remotePath string) (bool, error) {
+	if s.sftpClient == nil {
+		return false, fmt.Errorf("SFTP client not connected")
+	}
+	info, err := s.sftpClient.Stat(remotePath)
+	if err != nil {
+		return false, fmt.Errorf("failed to stat remote path: %w", err)
+	}
+	return info.IsDir(), nil
+}
+
 // DownloadFile downloads a file from remote to local
 func (s *SFTPClient) DownloadFile(remotePath, localPath string) error {
 	if s.sftpClient == nil {
@@ -197,7 +313,7 @@ func (s *SFTPClient) DownloadFile(remotePath, localPath string) error {
 	defer localFile.Close()
 
 	// Copy data
-	_, err = io.Copy(localFile, remoteFile)
+	_, err = s.download(localFile, remoteFile)
 	if err != nil {
 		return fmt.Errorf("failed to download file: %w", err)
 	}
@@ -246,7 +362,7 @@ func (s *SFTPClient) DownloadDir(remotePath, localPath string) error {
 				return fmt.Errorf("failed to create local file %s: %w", entry.Name(), err)
 			}
 
-			_, err = io.Copy(localFile, remoteFile)
+			_, err = s.download(localFile, remoteFile)
 			remoteFile.Close()
 			localFile.Close()
 
@@ -291,7 +407,7 @@ func (s *SFTPClient) UploadFile(localPath, remotePath string) error {
 	defer remoteFile.Close()
 
 	// Copy data
-	_, err = io.Copy(remoteFile, localFile)
+	_, err = s.upload(remoteFile, localFile)
 	if err != nil {
 		return fmt.Errorf("failed to upload file: %w", err)
 	}
@@ -299,6 +415,134 @@ func (s *SFTPClient) UploadFile(localPath, remotePath string) error {
 	return nil
 }
 
+// resumeCheckSize is how much of a partial file, counted back from its end,
+// is compared with the source before a transfer is resumed
+const resumeCheckSize = 64 * 1024
+
+// ErrPartialMismatch is returned when a partial file no longer matches the
+// start of its source, so continuing it would corrupt the result
+var ErrPartialMismatch = errors.New("partial file does not match the source, overwrite it instead")
+
+// ResumeDownload continues an interrupted download of remotePath into the
+// partial file at localPath, from the last byte already written
+func (s *SFTPClient) ResumeDownload(remotePath, localPath string) error {
+	if s.sftpClient == nil {
+		return fmt.Errorf("SFTP client not connected")
+	}
+
+	remoteFile, err := s.sftpClient.Open(remotePath)
+	if err != nil {
+		return fmt.Errorf("failed to open remote file: %w", err)
+	}
+	defer remoteFile.Close()
+
+	localFile, err := os.OpenFile(localPath, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open partial local file: %w", err)
+	}
+	defer localFile.Close()
+
+	remoteInfo, err := remoteFile.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat remote file: %w", err)
+	}
+	localInfo, err := localFile.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat local file: %w", err)
+	}
+
+	offset, err := resumeOffset(remoteFile, remoteInfo.Size(), localFile, localInfo.Size())
+	if err != nil {
+		return err
+	}
+	if _, err := remoteFile.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek remote file: %w", err)
+	}
+	if _, err := localFile.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek local file: %w", err)
+	}
+
+	if _, err := s.download(localFile, remoteFile); err != nil {
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+	return nil
+}
+
+// ResumeUpload continues an interrupted upload of localPath into the partial
+// file at remotePath, from the last byte already written
+func (s *SFTPClient) ResumeUpload(localPath, remotePath string) error {
+	if s.sftpClient == nil {
+		return fmt.Errorf("SFTP client not connected")
+	}
+
+	localFile, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer localFile.Close()
+
+	remoteFile, err := s.sftpClient.OpenFile(remotePath, os.O_RDWR)
+	if err != nil {
+		return fmt.Errorf("failed to open partial remote file: %w", err)
+	}
+	defer remoteFile.Close()
+
+	localInfo, err := localFile.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat local file: %w", err)
+	}
+	remoteInfo, err := remoteFile.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat remote file: %w", err)
+	}
+
+	offset, err := resumeOffset(localFile, localInfo.Size(), remoteFile, remoteInfo.Size())
+	if err != nil {
+		return err
+	}
+	if _, err := localFile.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek local file: %w", err)
+	}
+	if _, err := remoteFile.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek remote file: %w", err)
+	}
+
+	if _, err := s.upload(remoteFile, localFile); err != nil {
+		return fmt.Errorf("failed to upload file: %w", err)
+	}
+	return nil
+}
+
+// resumeOffset returns where a transfer into a partial target continues.
+// The target must be no larger than the source, and its last
+// resumeCheckSize bytes must hash the same as the source at that position.
+func resumeOffset(src io.ReaderAt, srcSize int64, dst io.ReaderAt, dstSize int64) (int64, error) {
+	if dstSize > srcSize {
+		return 0, ErrPartialMismatch
+	}
+	start := max(dstSize-resumeCheckSize, 0)
+	srcSum, err := chunkSum(src, start, dstSize-start)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read source: %w", err)
+	}
+	dstSum, err := chunkSum(dst, start, dstSize-start)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read partial file: %w", err)
+	}
+	if !bytes.Equal(srcSum, dstSum) {
+		return 0, ErrPartialMismatch
+	}
+	return dstSize, nil
+}
+
+func chunkSum(r io.ReaderAt, offset, length int64) ([]byte, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(r, offset, length)); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
 // UploadDir recursively uploads a directory from local to remote
 func (s *SFTPClient) UploadDir(localPath, remotePath string) error {
 	if s.sftpClient == nil {
@@ -340,7 +584,7 @@ func (s *SFTPClient) UploadDir(localPath, remotePath string) error {
 				return fmt.Errorf("failed to create remote file %s: %w", entry.Name(), err)
 			}
 
-			_, err = io.Copy(remoteFile, localFile)
+			_, err = s.upload(remoteFile, localFile)
 			localFile.Close()
 			remoteFile.Close()
 
@@ -353,6 +597,46 @@ func (s *SFTPClient) UploadDir(localPath, remotePath string) error {
 	return nil
 }
 
+// ReadFile reads up to limit bytes of a remote file for previewing. It
+// reports whether the file was longer than limit.
+func (s *SFTPClient) ReadFile(remotePath string, limit int64) ([]byte, bool, error) {
+	if s.sftpClient == nil {
+		return nil, false, fmt.Errorf("SFTP client not connected")
+	}
+
+	file, err := s.sftpClient.Open(remotePath)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open remote file: %w", err)
+	}
+	defer file.Close()
+
+	return readLimited(file, limit)
+}
+
+// ReadLocalFile reads up to limit bytes of a local file for previewing. It
+// reports whether the file was longer than limit.
+func ReadLocalFile(path string, limit int64) ([]byte, bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer file.Close()
+
+	return readLimited(file, limit)
+}
+
+func readLimited(r io.Reader, limit int64) ([]byte, bool, error) {
+	// Read one extra byte to tell a file of exactly limit bytes from a longer one
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read file: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return data[:limit], true, nil
+	}
+	return data, false, nil
+}
+
 // CreateFile creates a new empty file
 func (s *SFTPClient) CreateFile(path string) error {
 	if s.sftpClient == nil {
@@ -382,6 +666,136 @@ func (s *SFTPClient) RenameFile(oldPath, newPath string) error {
 	return nil
 }
 
+// Chmod changes the permissions of a remote file
+func (s *SFTPClient) Chmod(path string, mode os.FileMode) error {
+	if s.sftpClient == nil {
+		return fmt.Errorf("SFTP client not connected")
+	}
+	if err := s.sftpClient.Chmod(path, mode); err != nil {
+		return fmt.Errorf("failed to change permissions: %w", err)
+	}
+	return nil
+}
+
+// Chown changes the numeric owner and group of a remote file
+func (s *SFTPClient) Chown(path string, uid, gid int) error {
+	if s.sftpClient == nil {
+		return fmt.Errorf("SFTP client not connected")
+	}
+	if err := s.sftpClient.Chown(path, uid, gid); err != nil {
+		return fmt.Errorf("failed to change owner: %w", err)
+	}
+	return nil
+}
+
+// ChmodLocal changes the permissions of a local file
+func ChmodLocal(path string, mode os.FileMode) error {
+	if err := os.Chmod(path, mode); err != nil {
+		return fmt.Errorf("failed to change permissions: %w", err)
+	}
+	return nil
+}
+
+// ChownLocal changes the numeric owner and group of a local file
+func ChownLocal(path string, uid, gid int) error {
+	if err := os.Chown(path, uid, gid); err != nil {
+		return fmt.Errorf("failed to change owner: %w", err)
+	}
+	return nil
+}
+
+// MoveRemote moves a remote file or directory to dstPath, which may be in
+// another directory. Renames that the server refuses, e.g. across
+// filesystems, are retried with mv over an exec channel.
+func (s *SFTPClient) MoveRemote(srcPath, dstPath string) error {
+	if s.sftpClient == nil {
+		return fmt.Errorf("SFTP client not connected")
+	}
+	if _, err := s.sftpClient.Lstat(dstPath); err == nil {
+		return fmt.Errorf("%s already exists", dstPath)
+	}
+
+	renameErr := s.sftpClient.Rename(srcPath, dstPath)
+	if renameErr == nil {
+		return nil
+	}
+	log.Printf("SFTP rename %s -> %s failed, trying mv: %v", srcPath, dstPath, renameErr)
+	res, err := s.run(fmt.Sprintf("mv -- %s %s", ShellQuote(srcPath), ShellQuote(dstPath)))
+	if err != nil || res.ExitStatus != 0 {
+		return fmt.Errorf("failed to move %s: %w", srcPath, renameErr)
+	}
+	return nil
+}
+
+// CopyRemote copies a remote file or directory to dstPath without the data
+// leaving the server. It runs cp over an exec channel and, where the server
+// offers no shell (e.g. sftp-only accounts), streams the copy through the
+// SFTP connection instead.
+func (s *SFTPClient) CopyRemote(srcPath, dstPath string) error {
+	if s.sftpClient == nil {
+		return fmt.Errorf("SFTP client not connected")
+	}
+	if _, err := s.sftpClient.Lstat(dstPath); err == nil {
+		return fmt.Errorf("%s already exists", dstPath)
+	}
+
+	res, err := s.run(fmt.Sprintf("cp -R -p -- %s %s", ShellQuote(srcPath), ShellQuote(dstPath)))
+	if err == nil && res.ExitStatus != 0 && res.ExitStatus != 127 {
+		return fmt.Errorf("failed to copy %s: %s", srcPath, strings.TrimSpace(res.Stderr))
+	}
+	// A forced sftp subsystem can accept the command without running it,
+	// so only trust cp once the copy is really there
+	if err == nil && res.ExitStatus == 0 {
+		if _, statErr := s.sftpClient.Lstat(dstPath); statErr == nil {
+			return nil
+		}
+	}
+	log.Printf("Remote cp %s -> %s unavailable, copying over SFTP", srcPath, dstPath)
+	return s.copyRemoteStream(srcPath, dstPath)
+}
+
+// copyRemoteStream copies srcPath to dstPath by reading and writing through
+// the SFTP connection, recursing into directories
+func (s *SFTPClient) copyRemoteStream(srcPath, dstPath string) error {
+	info, err := s.sftpClient.Stat(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat remote path: %w", err)
+	}
+
+	if info.IsDir() {
+		if err := s.sftpClient.MkdirAll(dstPath); err != nil {
+			return fmt.Errorf("failed to create remote directory: %w", err)
+		}
+		entries, err := s.sftpClient.ReadDir(srcPath)
+		if err != nil {
+			return fmt.Errorf("failed to read remote directory: %w", err)
+		}
+		for _, entry := range entries {
+			if err := s.copyRemoteStream(filepath.Join(srcPath, entry.Name()), filepath.Join(dstPath, entry.Name())); err != nil {
+				return err
+			}
+		}
+		return s.sftpClient.Chmod(dstPath, info.Mode())
+	}
+
+	src, err := s.sftpClient.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to open remote file %s: %w", info.Name(), err)
+	}
+	defer src.Close()
+
+	dst, err := s.sftpClient.Create(dstPath)
+	if err != nil {
+		return fmt.Errorf("failed to create remote file %s: %w", info.Name(), err)
+	}
+	defer dst.Close()
+
+	if _, err := s.copy(dst, src); err != nil {
+		return fmt.Errorf("failed to copy file %s: %w", info.Name(), err)
+	}
+	return s.sftpClient.Chmod(dstPath, info.Mode())
+}
+
 // CreateLocalFile creates a new empty file locally
 func CreateLocalFile(path string) error {
 	file, err := os.Create(path)
@@ -476,6 +890,30 @@ func (s *SFTPClient) DeleteFile(path string, isDir bool) error {
 	return nil
 }
 
+// MoveToTrash moves a remote file or directory into trashDir, creating it
+// if needed, and returns where it went. The time of deletion prefixes the
+// name so files deleted under the same name are kept apart.
+func (s *SFTPClient) MoveToTrash(remotePath, trashDir string) (string, error) {
+	if s.sftpClient == nil {
+		return "", fmt.Errorf("SFTP client not connected")
+	}
+	if err := s.sftpClient.MkdirAll(trashDir); err != nil {
+		return "", fmt.Errorf("failed to create trash directory %s: %w", trashDir, err)
+	}
+	name := time.Now().Format("20060102-150405") + "-" + filepath.Base(remotePath)
+	target := filepath.ToSlash(filepath.Join(trashDir, name))
+	for i := 1; ; i++ {
+		if _, err := s.sftpClient.Lstat(target); err != nil {
+			break
+		}
+		target = filepath.ToSlash(filepath.Join(trashDir, fmt.Sprintf("%s-%d", name, i)))
+	}
+	if err := s.sftpClient.Rename(remotePath, target); err != nil {
+		return "", fmt.Errorf("failed to move to trash: %w", err)
+	}
+	return target, nil
+}
+
 // removeDir recursively removes a directory
 func (s *SFTPClient) removeDir(path string) error {
 	// List directory contents
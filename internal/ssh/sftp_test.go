@@ -0,0 +1,115 @@
+package ssh
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/metrics"
+)
+
+// newTestSFTP opens an SFTP client on a test server, with local and
+// "remote" temporary directories (the server shares the filesystem)
+func newTestSFTP(t *testing.T) (client *SFTPClient, local, remote string) {
+	t.Helper()
+	server := newTestServer(t)
+	client, err := NewSFTPClient(server.connection())
+	if err != nil {
+		t.Fatalf("NewSFTPClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, t.TempDir(), t.TempDir()
+}
+
+func TestSFTPTransfers(t *testing.T) {
+	client, local, remote := newTestSFTP(t)
+	data := bytes.Repeat([]byte("0123456789"), 20000)
+	if err := os.WriteFile(filepath.Join(local, "data.bin"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	before := metrics.Current().Hosts["test.invalid"]
+	if err := client.UploadFile(filepath.Join(local, "data.bin"), filepath.Join(remote, "data.bin")); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+	files, err := client.ListFiles(remote)
+	if err != nil {
+		t.Fatalf("ListFiles: %v", err)
+	}
+	if len(files) != 1 || files[0].Name != "data.bin" || files[0].Size != int64(len(data)) {
+		t.Errorf("ListFiles = %+v", files)
+	}
+
+	if err := client.DownloadFile(filepath.Join(remote, "data.bin"), filepath.Join(local, "copy.bin")); err != nil {
+		t.Fatalf("DownloadFile: %v", err)
+	}
+	got, _ := os.ReadFile(filepath.Join(local, "copy.bin"))
+	if !bytes.Equal(got, data) {
+		t.Errorf("downloaded %d bytes differing from the %d uploaded", len(got), len(data))
+	}
+	after := metrics.Current().Hosts["test.invalid"]
+	if after.BytesUploaded-before.BytesUploaded != int64(len(data)) || after.BytesDownloaded-before.BytesDownloaded != int64(len(data)) {
+		t.Errorf("metrics counted %d bytes up and %d down, want %d each",
+			after.BytesUploaded-before.BytesUploaded, after.BytesDownloaded-before.BytesDownloaded, len(data))
+	}
+
+	head, truncated, err := client.ReadFile(filepath.Join(remote, "data.bin"), 10)
+	if err != nil || string(head) != "0123456789" || !truncated {
+		t.Errorf("ReadFile = %q, %v, %v", head, truncated, err)
+	}
+}
+
+func TestSFTPResume(t *testing.T) {
+	client, local, remote := newTestSFTP(t)
+	data := bytes.Repeat([]byte("abcdefgh"), 30000)
+	os.WriteFile(filepath.Join(remote, "big"), data, 0o644)
+	os.WriteFile(filepath.Join(local, "big"), data[:100000], 0o644)
+
+	if err := client.ResumeDownload(filepath.Join(remote, "big"), filepath.Join(local, "big")); err != nil {
+		t.Fatalf("ResumeDownload: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(local, "big")); !bytes.Equal(got, data) {
+		t.Errorf("resumed download has %d bytes, want %d", len(got), len(data))
+	}
+
+	// A partial file that isn't a prefix of the source is refused
+	os.WriteFile(filepath.Join(remote, "other"), []byte("something else"), 0o644)
+	err := client.ResumeUpload(filepath.Join(local, "big"), filepath.Join(remote, "other"))
+	if !errors.Is(err, ErrPartialMismatch) {
+		t.Errorf("ResumeUpload onto a different file = %v, want ErrPartialMismatch", err)
+	}
+}
+
+func TestSFTPRemoteChanges(t *testing.T) {
+	client, _, remote := newTestSFTP(t)
+	if err := client.CreateDirAndFile(filepath.Join(remote, "dir"), filepath.Join(remote, "dir", "notes.txt")); err != nil {
+		t.Fatalf("CreateDirAndFile: %v", err)
+	}
+	if isDir, err := client.IsDir(filepath.Join(remote, "dir")); err != nil || !isDir {
+		t.Errorf("IsDir(dir) = %v, %v", isDir, err)
+	}
+
+	// The test server runs no cp, so the copy is streamed over SFTP
+	if err := client.CopyRemote(filepath.Join(remote, "dir"), filepath.Join(remote, "copy")); err != nil {
+		t.Fatalf("CopyRemote: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(remote, "copy", "notes.txt")); err != nil {
+		t.Errorf("copied file missing: %v", err)
+	}
+
+	if err := client.MoveRemote(filepath.Join(remote, "copy"), filepath.Join(remote, "moved")); err != nil {
+		t.Fatalf("MoveRemote: %v", err)
+	}
+	if err := client.MoveRemote(filepath.Join(remote, "moved"), filepath.Join(remote, "dir")); err == nil {
+		t.Error("MoveRemote onto an existing directory succeeded")
+	}
+
+	if err := client.DeleteFile(filepath.Join(remote, "moved"), true); err != nil {
+		t.Fatalf("DeleteFile: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(remote, "moved")); !os.IsNotExist(err) {
+		t.Errorf("deleted directory still there: %v", err)
+	}
+}
@@ -7,11 +7,17 @@ package ssh
 import (
 	"strconv"
 	"syscall"
+
+	"github.com/pkg/sftp"
 )
 
 func getOwnerGroup(sys interface{}) (string, string) {
-	if stat, ok := sys.(*syscall.Stat_t); ok {
+	switch stat := sys.(type) {
+	case *syscall.Stat_t:
 		return strconv.Itoa(int(stat.Uid)), strconv.Itoa(int(stat.Gid))
+	case *sftp.FileStat:
+		// Remote entries carry the SFTP attributes instead
+		return strconv.Itoa(int(stat.UID)), strconv.Itoa(int(stat.GID))
 	}
 	return "-", "-"
 }
@@ -0,0 +1,215 @@
+package ssh
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// checksumBatch is how many files a remote sha256sum command hashes
+const checksumBatch = 200
+
+// LocalTree lists every file and directory under root, keyed by their path
+// relative to it with / separators. Symbolic links are skipped. Closing
+// done stops the walk with ErrUsageCanceled.
+func LocalTree(root string, done <-chan struct{}) (map[string]FileInfo, error) {
+	tree := make(map[string]FileInfo)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		select {
+		case <-done:
+			return ErrUsageCanceled
+		default:
+		}
+		if path == root || d.Type()&fs.ModeSymlink != 0 {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		tree[filepath.ToSlash(rel)] = FileInfo{Name: d.Name(), Size: info.Size(), IsDir: d.IsDir(), ModTime: info.ModTime()}
+		return nil
+	})
+	return tree, err
+}
+
+// Tree lists every file and directory under the remote root, keyed by
+// their path relative to it. Symbolic links are skipped. Closing done stops
+// the walk with ErrUsageCanceled.
+func (s *SFTPClient) Tree(root string, done <-chan struct{}) (map[string]FileInfo, error) {
+	if s.sftpClient == nil {
+		return nil, fmt.Errorf("SFTP client not connected")
+	}
+	tree := make(map[string]FileInfo)
+	prefix := strings.TrimSuffix(root, "/") + "/"
+	walker := s.sftpClient.Walk(root)
+	for walker.Step() {
+		select {
+		case <-done:
+			return tree, ErrUsageCanceled
+		default:
+		}
+		if err := walker.Err(); err != nil {
+			return tree, err
+		}
+		info := walker.Stat()
+		if walker.Path() == root || info.Mode()&fs.ModeSymlink != 0 {
+			continue
+		}
+		rel := strings.TrimPrefix(walker.Path(), prefix)
+		tree[rel] = FileInfo{Name: info.Name(), Size: info.Size(), IsDir: info.IsDir(), ModTime: info.ModTime()}
+	}
+	return tree, nil
+}
+
+// LocalChecksums returns the SHA-256 of the files under root at the
+// relative paths given. Unreadable files are left out.
+func LocalChecksums(root string, paths []string, done <-chan struct{}) (map[string]string, error) {
+	sums := make(map[string]string, len(paths))
+	for _, rel := range paths {
+		select {
+		case <-done:
+			return sums, ErrUsageCanceled
+		default:
+		}
+		file, err := os.Open(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			continue
+		}
+		if sum, err := hashReader(file); err == nil {
+			sums[rel] = sum
+		}
+		file.Close()
+	}
+	return sums, nil
+}
+
+// Checksums returns the SHA-256 of the remote files under root at the
+// relative paths given. The server hashes them with sha256sum when it runs
+// commands; otherwise they are read over SFTP. Unreadable files are left
+// out.
+func (s *SFTPClient) Checksums(root string, paths []string, done <-chan struct{}) (map[string]string, error) {
+	if s.sftpClient == nil {
+		return nil, fmt.Errorf("SFTP client not connected")
+	}
+	sums := make(map[string]string, len(paths))
+	remote := true
+	for start := 0; start < len(paths); start += checksumBatch {
+		batch := paths[start:min(start+checksumBatch, len(paths))]
+		if remote {
+			quoted := make([]string, len(batch))
+			for i, rel := range batch {
+				quoted[i] = ShellQuote(rel)
+			}
+			res, err := s.run("cd -- " + ShellQuote(root) + " && sha256sum -- " + strings.Join(quoted, " "))
+			// sha256sum exits with 1 when some files are unreadable, but
+			// prints the others
+			if err == nil && res.ExitStatus != 127 && parseChecksums(res.Stdout, sums) > 0 {
+				continue
+			}
+			log.Printf("Remote sha256sum in %s unavailable, reading files over SFTP", root)
+			remote = false
+		}
+		for _, rel := range batch {
+			select {
+			case <-done:
+				return sums, ErrUsageCanceled
+			default:
+			}
+			file, err := s.sftpClient.Open(filepath.Join(root, rel))
+			if err != nil {
+				continue
+			}
+			if sum, err := hashReader(file); err == nil {
+				sums[rel] = sum
+			}
+			file.Close()
+		}
+	}
+	return sums, nil
+}
+
+// parseChecksums reads sha256sum output into sums and returns how many
+// lines it read. Names with a backslash or a newline are escaped, and
+// their line starts with a backslash.
+func parseChecksums(output string, sums map[string]string) int {
+	n := 0
+	for _, line := range strings.Split(output, "\n") {
+		escaped := strings.HasPrefix(line, `\`)
+		line = strings.TrimPrefix(line, `\`)
+		sum, name, ok := strings.Cut(line, "  ")
+		if !ok || len(sum) != 64 {
+			continue
+		}
+		if escaped {
+			name = strings.NewReplacer(`\\`, `\`, `\n`, "\n").Replace(name)
+		}
+		sums[name] = sum
+		n++
+	}
+	return n
+}
+
+func hashReader(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SyncUpload copies a local file or empty directory for a directory sync:
+// the missing remote directories are created and the copy keeps the
+// modification time of the original
+func (s *SFTPClient) SyncUpload(localPath, remotePath string) error {
+	if s.sftpClient == nil {
+		return fmt.Errorf("SFTP client not connected")
+	}
+	info, err := os.Stat(localPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat local path: %w", err)
+	}
+	if info.IsDir() {
+		return s.sftpClient.MkdirAll(remotePath)
+	}
+	if err := s.sftpClient.MkdirAll(filepath.Dir(remotePath)); err != nil {
+		return fmt.Errorf("failed to create remote directory: %w", err)
+	}
+	if err := s.UploadFile(localPath, remotePath); err != nil {
+		return err
+	}
+	return s.sftpClient.Chtimes(remotePath, info.ModTime(), info.ModTime())
+}
+
+// SyncDownload copies a remote file or empty directory for a directory
+// sync: the missing local directories are created and the copy keeps the
+// modification time of the original
+func (s *SFTPClient) SyncDownload(remotePath, localPath string) error {
+	if s.sftpClient == nil {
+		return fmt.Errorf("SFTP client not connected")
+	}
+	info, err := s.sftpClient.Stat(remotePath)
+	if err != nil {
+		return fmt.Errorf("failed to stat remote path: %w", err)
+	}
+	if info.IsDir() {
+		return os.MkdirAll(localPath, 0755)
+	}
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return fmt.Errorf("failed to create local directory: %w", err)
+	}
+	if err := s.DownloadFile(remotePath, localPath); err != nil {
+		return err
+	}
+	return os.Chtimes(localPath, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,81 @@
+package ssh
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// SystemdUnit is a single row of `systemctl list-units` output
+type SystemdUnit struct {
+	Unit        string
+	Load        string
+	Active      string
+	Sub         string
+	Description string
+}
+
+// SystemdActions are the unit operations offered by the systemd browser
+var SystemdActions = []string{"start", "stop", "restart", "enable", "disable"}
+
+// ListSystemdUnits returns all service units known to the remote systemd
+func (c *Client) ListSystemdUnits() ([]SystemdUnit, error) {
+	res, err := c.Run("systemctl list-units --type=service --all --no-legend --no-pager --plain")
+	if err != nil {
+		return nil, err
+	}
+	if res.ExitStatus != 0 {
+		return nil, fmt.Errorf("systemctl exited with status %d: %s", res.ExitStatus, strings.TrimSpace(res.Stderr))
+	}
+	return ParseSystemdUnits(res.Stdout), nil
+}
+
+// ParseSystemdUnits parses `systemctl list-units --no-legend --plain` output
+func ParseSystemdUnits(output string) []SystemdUnit {
+	var units []SystemdUnit
+	for _, line := range strings.Split(output, "\n") {
+		// Older systemd versions prefix failed units with a bullet even in --plain mode
+		line = strings.TrimLeft(strings.TrimSpace(line), "●* ")
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		units = append(units, SystemdUnit{
+			Unit:        fields[0],
+			Load:        fields[1],
+			Active:      fields[2],
+			Sub:         fields[3],
+			Description: strings.Join(fields[4:], " "),
+		})
+	}
+	return units
+}
+
+// SystemctlAction runs `systemctl <action> <unit>` with root privileges
+func (c *Client) SystemctlAction(action, unit string, conn config.SSHConnection) error {
+	res, err := c.RunPrivileged(fmt.Sprintf("systemctl %s %s", action, ShellQuote(unit)), conn)
+	if err != nil {
+		return err
+	}
+	if res.ExitStatus != 0 {
+		msg := strings.TrimSpace(res.Stderr)
+		if strings.Contains(msg, "a password is required") {
+			msg = "sudo requires a password; set the sudo password for this connection"
+		}
+		return fmt.Errorf("systemctl %s exited with status %d: %s", action, res.ExitStatus, msg)
+	}
+	return nil
+}
+
+// UnitJournal returns the last lines of the unit's journal
+func (c *Client) UnitJournal(unit string, lines int) (string, error) {
+	res, err := c.Run(fmt.Sprintf("journalctl -u %s -n %d --no-pager -o short-iso", ShellQuote(unit), lines))
+	if err != nil {
+		return "", err
+	}
+	if res.ExitStatus != 0 && res.Stdout == "" {
+		return "", fmt.Errorf("journalctl exited with status %d: %s", res.ExitStatus, strings.TrimSpace(res.Stderr))
+	}
+	return res.Stdout, nil
+}
@@ -0,0 +1,52 @@
+package ssh
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSystemdUnits(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		out  string
+		want []SystemdUnit
+	}{
+		{
+			"running unit",
+			"cron.service loaded active running Regular background program processing daemon\n",
+			[]SystemdUnit{{Unit: "cron.service", Load: "loaded", Active: "active", Sub: "running", Description: "Regular background program processing daemon"}},
+		},
+		{
+			"aligned columns",
+			"  ssh.service              loaded    active   running OpenBSD Secure Shell server\n" +
+				"  systemd-fsck@dev-sda1.service loaded active exited  File System Check on /dev/sda1\n",
+			[]SystemdUnit{
+				{Unit: "ssh.service", Load: "loaded", Active: "active", Sub: "running", Description: "OpenBSD Secure Shell server"},
+				{Unit: "systemd-fsck@dev-sda1.service", Load: "loaded", Active: "active", Sub: "exited", Description: "File System Check on /dev/sda1"},
+			},
+		},
+		{
+			"failed units with a bullet",
+			"● nginx.service loaded failed failed A high performance web server\n" +
+				"* apache2.service loaded failed failed The Apache HTTP Server\n",
+			[]SystemdUnit{
+				{Unit: "nginx.service", Load: "loaded", Active: "failed", Sub: "failed", Description: "A high performance web server"},
+				{Unit: "apache2.service", Load: "loaded", Active: "failed", Sub: "failed", Description: "The Apache HTTP Server"},
+			},
+		},
+		{
+			"no description",
+			"foo.service not-found inactive dead\n",
+			[]SystemdUnit{{Unit: "foo.service", Load: "not-found", Active: "inactive", Sub: "dead"}},
+		},
+		{
+			"short and blank lines",
+			"\n   \nbar.service loaded\n●\n",
+			nil,
+		},
+	} {
+		if got := ParseSystemdUnits(tt.out); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ParseSystemdUnits = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
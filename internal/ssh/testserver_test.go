@@ -0,0 +1,264 @@
+package ssh
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	testUser     = "tester"
+	testPassword = "secret"
+)
+
+// testServer is an in-process SSH server for integration tests. It takes
+// the password of testUser, runs exec requests through exec, echoes what a
+// shell is sent, serves SFTP and opens direct-tcpip channels. Every host
+// is dialed to it while it runs.
+type testServer struct {
+	t        *testing.T
+	config   *ssh.ServerConfig
+	listener net.Listener
+	// exec runs a command and returns its exit status; by default every
+	// command fails with 127
+	exec func(command string, stdin io.Reader, stdout, stderr io.Writer) int
+
+	mu     sync.Mutex
+	events []string // pty and window-change requests, as "pty xterm 80x24"
+}
+
+// newTestServer starts a server for the test, with its home directory in a
+// temporary one so host info and settings stay out of the real one
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{
+		t:        t,
+		listener: listener,
+		exec: func(command string, stdin io.Reader, stdout, stderr io.Writer) int {
+			fmt.Fprintf(stderr, "%s: command not found\n", command)
+			return 127
+		},
+		config: &ssh.ServerConfig{
+			PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+				if meta.User() == testUser && string(password) == testPassword {
+					return nil, nil
+				}
+				return nil, fmt.Errorf("wrong password for %s", meta.User())
+			},
+		},
+	}
+	s.config.AddHostKey(signer)
+	go s.accept()
+
+	previous := SetDialer(DialerFunc(func(conn config.SSHConnection, addr string, timeout time.Duration) (net.Conn, error) {
+		return net.DialTimeout("tcp", listener.Addr().String(), timeout)
+	}))
+	t.Cleanup(func() {
+		SetDialer(previous)
+		listener.Close()
+	})
+	return s
+}
+
+// connection returns a saved connection to the server, unique to the test
+// so pooled connections aren't shared between tests
+func (s *testServer) connection() config.SSHConnection {
+	return config.SSHConnection{
+		ID:          s.t.Name(),
+		Name:        s.t.Name(),
+		Host:        "test.invalid",
+		Port:        22,
+		Username:    testUser,
+		UsePassword: true,
+		Password:    testPassword,
+	}
+}
+
+// client connects to the server, closing the connection with the test
+func (s *testServer) client() *Client {
+	s.t.Helper()
+	client, err := NewClient(s.connection())
+	if err != nil {
+		s.t.Fatalf("NewClient: %v", err)
+	}
+	s.t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// waitEvent waits for a pty or window-change request starting with prefix
+func (s *testServer) waitEvent(prefix string) string {
+	s.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		for _, event := range s.events {
+			if strings.HasPrefix(event, prefix) {
+				s.mu.Unlock()
+				return event
+			}
+		}
+		s.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	s.t.Fatalf("no %q request, got %q", prefix, s.events)
+	return ""
+}
+
+func (s *testServer) record(event string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+}
+
+func (s *testServer) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.serve(conn)
+	}
+}
+
+func (s *testServer) serve(netConn net.Conn) {
+	conn, chans, reqs, err := ssh.NewServerConn(netConn, s.config)
+	if err != nil {
+		netConn.Close()
+		return
+	}
+	defer conn.Close()
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		switch newChannel.ChannelType() {
+		case "session":
+			go s.session(newChannel)
+		case "direct-tcpip":
+			go s.directTCPIP(newChannel)
+		default:
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+		}
+	}
+}
+
+func (s *testServer) session(newChannel ssh.NewChannel) {
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		return
+	}
+	defer channel.Close()
+	exit := func(status int) {
+		channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{uint32(status)}))
+	}
+	for req := range requests {
+		switch req.Type {
+		case "pty-req":
+			var pty struct {
+				Term                         string
+				Columns, Rows, Width, Height uint32
+				Modes                        string
+			}
+			if err := ssh.Unmarshal(req.Payload, &pty); err != nil {
+				req.Reply(false, nil)
+				continue
+			}
+			s.record(fmt.Sprintf("pty %s %dx%d", pty.Term, pty.Columns, pty.Rows))
+			req.Reply(true, nil)
+		case "window-change":
+			var size struct{ Columns, Rows, Width, Height uint32 }
+			if err := ssh.Unmarshal(req.Payload, &size); err == nil {
+				s.record(fmt.Sprintf("window %dx%d", size.Columns, size.Rows))
+			}
+		case "env":
+			req.Reply(true, nil)
+		case "exec":
+			var payload struct{ Command string }
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil {
+				req.Reply(false, nil)
+				continue
+			}
+			req.Reply(true, nil)
+			exit(s.exec(payload.Command, channel, channel, channel.Stderr()))
+			return
+		case "shell":
+			req.Reply(true, nil)
+			go func() {
+				io.Copy(channel, channel)
+				exit(0)
+				channel.Close()
+			}()
+		case "subsystem":
+			var payload struct{ Name string }
+			if err := ssh.Unmarshal(req.Payload, &payload); err != nil || payload.Name != "sftp" {
+				req.Reply(false, nil)
+				continue
+			}
+			req.Reply(true, nil)
+			server, err := sftp.NewServer(channel)
+			if err != nil {
+				return
+			}
+			server.Serve()
+			server.Close()
+			return
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+// directTCPIP connects a channel opened for a local forward to its target
+func (s *testServer) directTCPIP(newChannel ssh.NewChannel) {
+	var payload struct {
+		Host       string
+		Port       uint32
+		OriginHost string
+		OriginPort uint32
+	}
+	if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+		newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+	if err != nil {
+		newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	defer target.Close()
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		return
+	}
+	defer channel.Close()
+	go ssh.DiscardRequests(requests)
+	go func() {
+		io.Copy(channel, target)
+		channel.CloseWrite()
+	}()
+	io.Copy(target, channel)
+}
@@ -0,0 +1,84 @@
+package ssh
+
+import (
+	"io"
+	"log"
+	"net"
+	"strconv"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// startForwards opens the LocalForward and RemoteForward tunnels of a
+// connection, for as long as conn stays open. Like OpenSSH, a tunnel that
+// cannot be opened only logs a warning.
+func startForwards(conn *ssh.Client, connConfig config.SSHConnection) {
+	for _, spec := range connConfig.LocalForward {
+		listen, target, err := config.ParseForward(spec)
+		if err == nil {
+			var ln net.Listener
+			if ln, err = net.Listen("tcp", listen); err == nil {
+				log.Printf("[tunnels] Forwarding local %s to %s", listen, target)
+				go serveTunnel(conn, ln, func() (net.Conn, error) { return conn.Dial("tcp", target) })
+			} else if isAddrInUse(err) {
+				// Name the process holding the port
+				_, port, _ := net.SplitHostPort(listen)
+				n, _ := strconv.Atoi(port)
+				owner, _ := FindLocalPortOwner(n)
+				err = &PortInUseError{Port: n, Owner: owner}
+			}
+		}
+		if err != nil {
+			log.Printf("[tunnels] LocalForward %s failed: %v", spec, err)
+		}
+	}
+	for _, spec := range connConfig.RemoteForward {
+		listen, target, err := config.ParseForward(spec)
+		if err == nil {
+			var ln net.Listener
+			if ln, err = conn.Listen("tcp", listen); err == nil {
+				log.Printf("[tunnels] Forwarding remote %s to %s", listen, target)
+				go serveTunnel(conn, ln, func() (net.Conn, error) { return net.Dial("tcp", target) })
+			}
+		}
+		if err != nil {
+			log.Printf("[tunnels] RemoteForward %s failed: %v", spec, err)
+		}
+	}
+}
+
+// serveTunnel relays each connection ln accepts to a connection from dial,
+// until conn closes
+func serveTunnel(conn *ssh.Client, ln net.Listener, dial func() (net.Conn, error)) {
+	go func() {
+		conn.Wait()
+		ln.Close()
+	}()
+	for {
+		accepted, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer accepted.Close()
+			target, err := dial()
+			if err != nil {
+				log.Printf("[tunnels] Failed to connect a tunnel from %s: %v", ln.Addr(), err)
+				return
+			}
+			defer target.Close()
+			done := make(chan struct{}, 2)
+			go func() {
+				io.Copy(target, accepted)
+				done <- struct{}{}
+			}()
+			go func() {
+				io.Copy(accepted, target)
+				done <- struct{}{}
+			}()
+			// Either side closing ends the tunnel
+			<-done
+		}()
+	}
+}
@@ -0,0 +1,93 @@
+package ssh
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+)
+
+// freePort returns a loopback address nothing listens on
+func freePort(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestLocalForward(t *testing.T) {
+	server := newTestServer(t)
+
+	// The forward's target answers each line with its reverse
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				line, _ := bufio.NewReader(conn).ReadString('\n')
+				reversed := []byte(line[:len(line)-1])
+				for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+					reversed[i], reversed[j] = reversed[j], reversed[i]
+				}
+				conn.Write(append(reversed, '\n'))
+			}()
+		}
+	}()
+
+	listen := freePort(t)
+	conn := server.connection()
+	conn.LocalForward = []string{listen + " " + target.Addr().String()}
+	client, err := Connect(conn)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer client.Close()
+
+	tunnel, err := net.DialTimeout("tcp", listen, 5*time.Second)
+	if err != nil {
+		t.Fatalf("dialing the forwarded port: %v", err)
+	}
+	defer tunnel.Close()
+	tunnel.SetDeadline(time.Now().Add(5 * time.Second))
+	tunnel.Write([]byte("forward\n"))
+	reply, err := bufio.NewReader(tunnel).ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading through the tunnel: %v", err)
+	}
+	if reply != "drawrof\n" {
+		t.Errorf("reply through the tunnel = %q, want %q", reply, "drawrof\n")
+	}
+}
+
+func TestConnectSharesConnections(t *testing.T) {
+	server := newTestServer(t)
+	first, err := Connect(server.connection())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Connect(server.connection())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.conn != second.conn {
+		t.Error("two clients of one connection opened two SSH connections")
+	}
+
+	// The connection stays open for the second client
+	first.Close()
+	if _, err := second.Run("true"); err != nil {
+		t.Errorf("Run after the first client closed: %v", err)
+	}
+	second.Close()
+}
@@ -0,0 +1,152 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// usageProgressEvery is how many entries a directory walk counts between
+// progress reports
+const usageProgressEvery = 256
+
+// ErrUsageCanceled is returned when a directory size calculation is stopped
+var ErrUsageCanceled = errors.New("size calculation cancelled")
+
+// DirUsage is the space taken by a directory tree
+type DirUsage struct {
+	Bytes  int64
+	Files  int // Files counted, 0 when du measured the tree
+	Dirs   int
+	DU     bool // Bytes is the disk usage reported by du rather than the sum of the file sizes
+	Capped bool // The walk stopped at its limit, the tree holds more
+}
+
+// DiskSpace is the size and free space of a filesystem
+type DiskSpace struct {
+	Total uint64
+	Free  uint64
+	Avail uint64 // Free space available to unprivileged users
+}
+
+// LocalDirUsage adds up the sizes of the files under root, reporting the
+// count so far to progress now and then. Unreadable subdirectories are
+// skipped. Closing done stops the walk with ErrUsageCanceled.
+func LocalDirUsage(root string, done <-chan struct{}, progress func(DirUsage)) (DirUsage, error) {
+	var usage DirUsage
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		select {
+		case <-done:
+			return ErrUsageCanceled
+		default:
+		}
+		if d.IsDir() {
+			if path != root {
+				usage.Dirs++
+			}
+		} else {
+			usage.Files++
+			if info, err := d.Info(); err == nil {
+				usage.Bytes += info.Size()
+			}
+		}
+		if (usage.Files+usage.Dirs)%usageProgressEvery == 0 {
+			progress(usage)
+		}
+		return nil
+	})
+	return usage, err
+}
+
+// DirUsage measures the remote directory root with du when the server
+// runs commands, and otherwise adds up the sizes of its files over SFTP,
+// reporting the count so far to progress now and then. Closing done stops
+// the walk with ErrUsageCanceled.
+func (s *SFTPClient) DirUsage(root string, done <-chan struct{}, progress func(DirUsage)) (DirUsage, error) {
+	if s.sftpClient == nil {
+		return DirUsage{}, fmt.Errorf("SFTP client not connected")
+	}
+
+	// du prints the total even when some subdirectories are unreadable, so
+	// its output is trusted whatever the exit status
+	res, err := s.run("du -sk -- " + ShellQuote(root))
+	if err == nil && res.ExitStatus != 127 {
+		lines := strings.Split(strings.TrimSpace(res.Stdout), "\n")
+		if fields := strings.Fields(lines[len(lines)-1]); len(fields) > 0 {
+			if kb, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+				return DirUsage{Bytes: kb * 1024, DU: true}, nil
+			}
+		}
+	}
+	log.Printf("Remote du %s unavailable, walking over SFTP", root)
+	return s.CountTree(root, 0, done, progress)
+}
+
+// CountTree adds up the sizes of the files under the remote directory root
+// over SFTP, reporting the count so far to progress now and then. With a
+// limit above 0 the walk stops after that many entries, with Capped set.
+// Closing done stops the walk with ErrUsageCanceled.
+func (s *SFTPClient) CountTree(root string, limit int, done <-chan struct{}, progress func(DirUsage)) (DirUsage, error) {
+	if s.sftpClient == nil {
+		return DirUsage{}, fmt.Errorf("SFTP client not connected")
+	}
+
+	var usage DirUsage
+	walker := s.sftpClient.Walk(root)
+	for walker.Step() {
+		select {
+		case <-done:
+			return usage, ErrUsageCanceled
+		default:
+		}
+		if err := walker.Err(); err != nil {
+			if walker.Path() == root {
+				return usage, err
+			}
+			continue
+		}
+		if limit > 0 && usage.Files+usage.Dirs >= limit {
+			usage.Capped = true
+			return usage, nil
+		}
+		if info := walker.Stat(); info.IsDir() {
+			if walker.Path() != root {
+				usage.Dirs++
+			}
+		} else {
+			usage.Files++
+			usage.Bytes += info.Size()
+		}
+		if (usage.Files+usage.Dirs)%usageProgressEvery == 0 {
+			progress(usage)
+		}
+	}
+	return usage, nil
+}
+
+// DiskSpace reports the size and free space of the remote filesystem
+// holding path, for servers with the statvfs@openssh.com extension
+func (s *SFTPClient) DiskSpace(path string) (DiskSpace, error) {
+	if s.sftpClient == nil {
+		return DiskSpace{}, fmt.Errorf("SFTP client not connected")
+	}
+	st, err := s.sftpClient.StatVFS(path)
+	if err != nil {
+		return DiskSpace{}, fmt.Errorf("server does not report free space: %w", err)
+	}
+	return DiskSpace{
+		Total: st.TotalSpace(),
+		Free:  st.FreeSpace(),
+		Avail: st.Bavail * st.Frsize,
+	}, nil
+}
@@ -0,0 +1,28 @@
+package termimg
+
+import (
+	"image"
+	"strings"
+)
+
+// asciiRamp goes from the darkest to the brightest character, for
+// terminals with a dark background
+const asciiRamp = " .:-=+*#%@"
+
+// ASCIIArt draws img as cols × rows characters, one line per row
+func ASCIIArt(img image.Image, cols, rows int) []string {
+	small := resize(img, cols, rows)
+	lines := make([]string, rows)
+	var b strings.Builder
+	for y := range rows {
+		b.Reset()
+		for x := range cols {
+			c := small.NRGBAAt(x, y)
+			// Rec. 601 luma, faded with the opacity
+			luma := (299*int(c.R) + 587*int(c.G) + 114*int(c.B)) / 1000 * int(c.A) / 255
+			b.WriteByte(asciiRamp[luma*(len(asciiRamp)-1)/255])
+		}
+		lines[y] = strings.TrimRight(b.String(), " ")
+	}
+	return lines
+}
@@ -0,0 +1,49 @@
+package termimg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+)
+
+// KittyClear deletes the images placed with the kitty graphics protocol
+const KittyClear = "\x1b_Ga=d,q=2\x1b\\"
+
+// kittyChunk is the most base64 data a kitty escape sequence carries
+const kittyChunk = 4096
+
+// EncodeKitty encodes img for the kitty graphics protocol, placed over
+// cols × rows cells at the cursor without moving it. Images placed before
+// are deleted first, so drawing the same screen again doesn't stack them.
+func EncodeKitty(img image.Image, cols, rows int) string {
+	// Sending more pixels than the cells show only costs time
+	if w, h := fitPixels(img.Bounds(), cols, rows); w < img.Bounds().Dx() {
+		img = resize(img, w, h)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return ""
+	}
+	data := base64.StdEncoding.EncodeToString(buf.Bytes())
+
+	var out strings.Builder
+	out.WriteString(KittyClear)
+	for i := 0; i < len(data); i += kittyChunk {
+		chunk := data[i:min(i+kittyChunk, len(data))]
+		more := 0
+		if i+kittyChunk < len(data) {
+			more = 1
+		}
+		if i == 0 {
+			// q=2 keeps the terminal from answering, as answers would
+			// arrive as key presses
+			fmt.Fprintf(&out, "\x1b_Ga=T,f=100,q=2,C=1,c=%d,r=%d,m=%d;%s\x1b\\", cols, rows, more, chunk)
+		} else {
+			fmt.Fprintf(&out, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+	}
+	return out.String()
+}
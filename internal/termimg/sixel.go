@@ -0,0 +1,242 @@
+package termimg
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"slices"
+	"strings"
+)
+
+// maxSixelSize bounds the width and height of a decoded sixel image
+const maxSixelSize = 8192
+
+// EncodeSixel encodes img for sixel terminals, scaled to fit in cols × rows
+// cells at the cursor. The cursor is put back where it was, so the lines
+// drawn after it are not pushed down.
+func EncodeSixel(img image.Image, cols, rows int) string {
+	w, h := fitPixels(img.Bounds(), cols, rows)
+	small := resize(img, w, h)
+
+	// Colors are rounded to a 6×6×6 cube, which needs no palette search
+	index := func(c color.NRGBA) int {
+		return int(c.R)*5/255*36 + int(c.G)*5/255*6 + int(c.B)*5/255
+	}
+
+	var out strings.Builder
+	// P2=1 leaves transparent pixels as they are
+	fmt.Fprintf(&out, "\x1b7\x1bP0;1;0q\"1;1;%d;%d", w, h)
+	defined := make(map[int]bool)
+	for y0 := 0; y0 < h; y0 += 6 {
+		masks := make(map[int][]byte)
+		for y := y0; y < min(y0+6, h); y++ {
+			for x := range w {
+				c := small.NRGBAAt(x, y)
+				if c.A < 128 {
+					continue
+				}
+				i := index(c)
+				if masks[i] == nil {
+					masks[i] = make([]byte, w)
+				}
+				masks[i][x] |= 1 << (y - y0)
+			}
+		}
+		colors := make([]int, 0, len(masks))
+		for i := range masks {
+			colors = append(colors, i)
+		}
+		slices.Sort(colors)
+		for n, i := range colors {
+			if !defined[i] {
+				fmt.Fprintf(&out, "#%d;2;%d;%d;%d", i, i/36*20, i/6%6*20, i%6*20)
+				defined[i] = true
+			}
+			if n > 0 {
+				out.WriteByte('$')
+			}
+			fmt.Fprintf(&out, "#%d", i)
+			writeSixelRun(&out, masks[i])
+		}
+		out.WriteByte('-')
+	}
+	out.WriteString("\x1b\\\x1b8")
+	return out.String()
+}
+
+// writeSixelRun writes the columns of one color of a band, repeating runs
+// of the same column with !
+func writeSixelRun(out *strings.Builder, masks []byte) {
+	// Empty columns at the end need not be written
+	end := len(masks)
+	for end > 0 && masks[end-1] == 0 {
+		end--
+	}
+	for x := 0; x < end; {
+		n := 1
+		for x+n < end && masks[x+n] == masks[x] {
+			n++
+		}
+		ch := byte('?' + masks[x])
+		if n > 3 {
+			fmt.Fprintf(out, "!%d%c", n, ch)
+		} else {
+			for range n {
+				out.WriteByte(ch)
+			}
+		}
+		x += n
+	}
+}
+
+// DecodeSixel draws the sixel data that follows the q of a sixel sequence,
+// up to its string terminator. Pixels that are not drawn are transparent.
+func DecodeSixel(data []byte) (image.Image, error) {
+	palette := make(map[int]color.NRGBA)
+	var pixels [][]int // Color register + 1 by row, 0 for none
+	current, x, y, width := 0, 0, 0, 0
+
+	// number reads the decimal number at data[i:], returning it and the
+	// index after it
+	number := func(i int) (int, int) {
+		n := 0
+		for ; i < len(data) && data[i] >= '0' && data[i] <= '9'; i++ {
+			n = min(n*10+int(data[i]-'0'), math.MaxInt32)
+		}
+		return n, i
+	}
+	draw := func(ch byte, repeat int) error {
+		if x+repeat > maxSixelSize || y+6 > maxSixelSize {
+			return errors.New("sixel image too large")
+		}
+		bits := ch - '?'
+		for k := range 6 {
+			if bits&(1<<k) == 0 {
+				continue
+			}
+			for len(pixels) <= y+k {
+				pixels = append(pixels, nil)
+			}
+			row := pixels[y+k]
+			if len(row) < x+repeat {
+				row = append(row, make([]int, x+repeat-len(row))...)
+			}
+			for i := x; i < x+repeat; i++ {
+				row[i] = current + 1
+			}
+			pixels[y+k] = row
+		}
+		x += repeat
+		width = max(width, x)
+		return nil
+	}
+
+	for i := 0; i < len(data); {
+		switch c := data[i]; {
+		case c == '"':
+			// Raster attributes only give the aspect ratio and a size hint
+			i++
+			for i < len(data) && (data[i] >= '0' && data[i] <= '9' || data[i] == ';') {
+				i++
+			}
+		case c == '#':
+			var args []int
+			var n int
+			for i++; ; i++ {
+				n, i = number(i)
+				args = append(args, n)
+				if i >= len(data) || data[i] != ';' {
+					break
+				}
+			}
+			current = args[0]
+			if len(args) >= 5 {
+				switch args[1] {
+				case 1:
+					palette[current] = hlsColor(args[2], args[3], args[4])
+				case 2:
+					palette[current] = color.NRGBA{R: percent(args[2]), G: percent(args[3]), B: percent(args[4]), A: 255}
+				}
+			}
+		case c == '!':
+			var n int
+			n, i = number(i + 1)
+			if i < len(data) && data[i] >= '?' && data[i] <= '~' {
+				if err := draw(data[i], max(n, 1)); err != nil {
+					return nil, err
+				}
+				i++
+			}
+		case c == '$':
+			x = 0
+			i++
+		case c == '-':
+			x = 0
+			y += 6
+			i++
+		case c >= '?' && c <= '~':
+			if err := draw(c, 1); err != nil {
+				return nil, err
+			}
+			i++
+		default:
+			i++
+		}
+	}
+
+	if width == 0 || len(pixels) == 0 {
+		return nil, errors.New("empty sixel image")
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, width, len(pixels)))
+	for py, row := range pixels {
+		for px, register := range row {
+			if register == 0 {
+				continue
+			}
+			c, ok := palette[register-1]
+			if !ok {
+				c = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+			}
+			img.SetNRGBA(px, py, c)
+		}
+	}
+	return img, nil
+}
+
+// percent converts a 0-100 color component to 0-255
+func percent(p int) uint8 {
+	return uint8(min(p, 100) * 255 / 100)
+}
+
+// hlsColor converts a sixel HLS color, whose hue starts at blue, to RGB
+func hlsColor(hue, lightness, saturation int) color.NRGBA {
+	h := float64((hue+240)%360) / 360
+	l, s := float64(min(lightness, 100))/100, float64(min(saturation, 100))/100
+	if s == 0 {
+		v := uint8(l * 255)
+		return color.NRGBA{R: v, G: v, B: v, A: 255}
+	}
+	q := l + s - l*s
+	if l < 0.5 {
+		q = l * (1 + s)
+	}
+	p := 2*l - q
+	channel := func(t float64) uint8 {
+		t -= math.Floor(t)
+		var v float64
+		switch {
+		case t < 1.0/6:
+			v = p + (q-p)*6*t
+		case t < 0.5:
+			v = q
+		case t < 2.0/3:
+			v = p + (q-p)*(2.0/3-t)*6
+		default:
+			v = p
+		}
+		return uint8(v*255 + 0.5)
+	}
+	return color.NRGBA{R: channel(h + 1.0/3), G: channel(h), B: channel(h - 1.0/3), A: 255}
+}
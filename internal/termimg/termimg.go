@@ -0,0 +1,159 @@
+// Package termimg shows images in a terminal: with the kitty graphics
+// protocol or sixel where the terminal supports one of them, and as ASCII
+// art everywhere else.
+package termimg
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/gif" // Registered for Decode
+	_ "image/jpeg"
+	_ "image/png"
+	"path/filepath"
+	"strings"
+)
+
+// Protocol is a way of drawing images in a terminal
+type Protocol int
+
+const (
+	ASCII Protocol = iota
+	Kitty
+	Sixel
+)
+
+func (p Protocol) String() string {
+	switch p {
+	case Kitty:
+		return "kitty"
+	case Sixel:
+		return "sixel"
+	default:
+		return "ascii"
+	}
+}
+
+// Cells are assumed to be this many pixels wide and high, as terminals
+// don't all report it
+const (
+	cellWidth  = 10
+	cellHeight = 20
+)
+
+// ParseProtocol returns the protocol named "kitty", "sixel" or "ascii",
+// detecting it from the environment for any other name, like "auto"
+func ParseProtocol(name string, getenv func(string) string) Protocol {
+	switch name {
+	case "kitty":
+		return Kitty
+	case "sixel":
+		return Sixel
+	case "ascii":
+		return ASCII
+	}
+	return Detect(getenv)
+}
+
+// Detect guesses the protocol the terminal supports from its environment
+// variables. Multiplexers don't pass graphics through by default, so
+// inside one it is ASCII.
+func Detect(getenv func(string) string) Protocol {
+	term, program := getenv("TERM"), getenv("TERM_PROGRAM")
+	if getenv("TMUX") != "" || getenv("ZELLIJ") != "" || strings.HasPrefix(term, "screen") {
+		return ASCII
+	}
+	switch {
+	case getenv("KITTY_WINDOW_ID") != "", term == "xterm-kitty", term == "xterm-ghostty",
+		program == "ghostty", program == "WezTerm":
+		return Kitty
+	case strings.Contains(term, "sixel"), strings.HasPrefix(term, "foot"), term == "mlterm",
+		program == "iTerm.app", program == "mintty":
+		return Sixel
+	}
+	return ASCII
+}
+
+// IsImage reports whether name has the extension of an image Decode reads
+func IsImage(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".png", ".jpg", ".jpeg", ".gif":
+		return true
+	}
+	return false
+}
+
+// Decode reads a PNG, JPEG or GIF image
+func Decode(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// Fit returns the columns and rows an image of width × height pixels takes
+// when scaled to fit in cols × rows cells, keeping its proportions. Small
+// images are not enlarged.
+func Fit(width, height, cols, rows int) (int, int) {
+	if width <= 0 || height <= 0 || cols <= 0 || rows <= 0 {
+		return 0, 0
+	}
+	// Scale in pixels, as cells are twice as high as they are wide
+	scale := min(float64(cols*cellWidth)/float64(width), float64(rows*cellHeight)/float64(height), 1)
+	w := int(float64(width)*scale/cellWidth + 0.5)
+	h := int(float64(height)*scale/cellHeight + 0.5)
+	return max(min(w, cols), 1), max(min(h, rows), 1)
+}
+
+// Render draws img scaled to fit in cols × rows cells with protocol p. The
+// result is as many lines as the image takes; with kitty and sixel the
+// first line carries the image and the others are left empty under it.
+func Render(img image.Image, cols, rows int, p Protocol) string {
+	b := img.Bounds()
+	cols, rows = Fit(b.Dx(), b.Dy(), cols, rows)
+	if cols == 0 {
+		return ""
+	}
+	var lines []string
+	switch p {
+	case Kitty:
+		lines = append([]string{EncodeKitty(img, cols, rows)}, make([]string, rows-1)...)
+	case Sixel:
+		lines = append([]string{EncodeSixel(img, cols, rows)}, make([]string, rows-1)...)
+	default:
+		lines = ASCIIArt(img, cols, rows)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fitPixels returns the size in pixels of an image of the given bounds
+// scaled to fit in cols × rows cells, keeping its proportions
+func fitPixels(b image.Rectangle, cols, rows int) (int, int) {
+	scale := min(float64(cols*cellWidth)/float64(b.Dx()), float64(rows*cellHeight)/float64(b.Dy()), 1)
+	return max(int(float64(b.Dx())*scale), 1), max(int(float64(b.Dy())*scale), 1)
+}
+
+// resize scales img to width × height pixels, averaging the pixels each
+// one covers
+func resize(img image.Image, width, height int) *image.NRGBA {
+	b := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		y0 := b.Min.Y + y*b.Dy()/height
+		y1 := max(b.Min.Y+(y+1)*b.Dy()/height, y0+1)
+		for x := range width {
+			x0 := b.Min.X + x*b.Dx()/width
+			x1 := max(b.Min.X+(x+1)*b.Dx()/width, x0+1)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(pr), g+uint64(pg), bl+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			// The sums are alpha-premultiplied, 16 bits per channel
+			avg := color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)}
+			out.Set(x, y, avg)
+		}
+	}
+	return out
+}
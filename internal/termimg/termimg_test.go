@@ -0,0 +1,147 @@
+package termimg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	for _, tt := range []struct {
+		env  map[string]string
+		want Protocol
+	}{
+		{map[string]string{"TERM": "xterm-kitty"}, Kitty},
+		{map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "WezTerm"}, Kitty},
+		{map[string]string{"TERM": "foot"}, Sixel},
+		{map[string]string{"TERM": "xterm-256color", "TERM_PROGRAM": "iTerm.app"}, Sixel},
+		{map[string]string{"TERM": "xterm-256color"}, ASCII},
+		{map[string]string{"TERM": "xterm-kitty", "TMUX": "/tmp/tmux-1000/default,1,0"}, ASCII},
+	} {
+		getenv := func(key string) string { return tt.env[key] }
+		if got := Detect(getenv); got != tt.want {
+			t.Errorf("Detect(%v) = %s, want %s", tt.env, got, tt.want)
+		}
+	}
+	none := func(string) string { return "" }
+	if ParseProtocol("sixel", none) != Sixel || ParseProtocol("kitty", none) != Kitty || ParseProtocol("auto", none) != ASCII {
+		t.Error("Expected ParseProtocol to honor a protocol name and detect otherwise")
+	}
+}
+
+func TestFit(t *testing.T) {
+	for _, tt := range []struct {
+		w, h, cols, rows   int
+		wantCols, wantRows int
+	}{
+		{800, 400, 40, 40, 40, 10}, // Wide: limited by the columns
+		{400, 800, 80, 20, 20, 20}, // Tall: limited by the rows
+		{50, 20, 80, 24, 5, 1},     // Small: not enlarged
+		{1, 1000, 80, 24, 1, 24},   // Never less than a cell
+	} {
+		cols, rows := Fit(tt.w, tt.h, tt.cols, tt.rows)
+		if cols != tt.wantCols || rows != tt.wantRows {
+			t.Errorf("Fit(%d, %d, %d, %d) = %d, %d, want %d, %d", tt.w, tt.h, tt.cols, tt.rows, cols, rows, tt.wantCols, tt.wantRows)
+		}
+	}
+}
+
+// testImage is white on its left half and red on its right half, with a
+// transparent bottom row
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h - 1 {
+		for x := range w {
+			c := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+			if x >= w/2 {
+				c = color.NRGBA{R: 255, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestASCIIArt(t *testing.T) {
+	lines := strings.Split(Render(testImage(200, 100), 20, 10, ASCII), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 rows for a 2:1 image 20 columns wide, got %d", len(lines))
+	}
+	if !strings.HasPrefix(lines[0], "@@@@@@@@@@") || strings.Contains(lines[0], " ") {
+		t.Errorf("Expected white drawn with @ and red with a lighter character, got %q", lines[0])
+	}
+}
+
+func TestSixelRoundTrip(t *testing.T) {
+	encoded := EncodeSixel(testImage(40, 13), 4, 1)
+	data, ok := strings.CutPrefix(encoded, "\x1b7\x1bP0;1;0q")
+	data, ok2 := strings.CutSuffix(data, "\x1b\\\x1b8")
+	if !ok || !ok2 {
+		t.Fatalf("Expected the image wrapped in a DCS saving the cursor, got %q", encoded)
+	}
+	img, err := DecodeSixel([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 40 || b.Dy() != 12 {
+		t.Errorf("Decoded size = %v, want 40×12 without the transparent row", b)
+	}
+	if r, g, b, _ := img.At(5, 5).RGBA(); r>>8 != 255 || g>>8 != 255 || b>>8 != 255 {
+		t.Errorf("Expected white on the left, got %v", img.At(5, 5))
+	}
+	if r, g, _, _ := img.At(30, 11).RGBA(); r>>8 != 255 || g != 0 {
+		t.Errorf("Expected red on the right, got %v", img.At(30, 11))
+	}
+	if _, err := DecodeSixel([]byte("#0;2;0;0;0$-")); err == nil {
+		t.Error("Expected an error for an empty image")
+	}
+}
+
+func TestDecodeSixelHLS(t *testing.T) {
+	// Sixel hues start at blue: 120 is red
+	img, err := DecodeSixel([]byte("#1;1;120;50;100#1!3~"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := color.NRGBAModel.Convert(img.At(2, 5)).(color.NRGBA); c.R != 255 || c.G != 0 || c.B != 0 {
+		t.Errorf("HLS 120;50;100 = %v, want red", c)
+	}
+}
+
+func TestEncodeKitty(t *testing.T) {
+	// Noise, so the PNG takes several chunks
+	noise := testImage(2000, 1000)
+	for i := range noise.Pix {
+		noise.Pix[i] |= byte(i * 7919 >> 3)
+	}
+	encoded := EncodeKitty(noise, 40, 10)
+	if !strings.HasPrefix(encoded, KittyClear+"\x1b_Ga=T,f=100,q=2,C=1,c=40,r=10,m=1;") {
+		t.Fatalf("Unexpected start of %.80q", encoded)
+	}
+	var data strings.Builder
+	if !strings.Contains(encoded, "\x1b_Gm=0;") {
+		t.Error("Expected the last chunk to end the image")
+	}
+	for _, m := range regexp.MustCompile(`\x1b_G[^;]*;([^\x1b]*)\x1b\\`).FindAllStringSubmatch(encoded, -1) {
+		if len(m[1]) > kittyChunk {
+			t.Fatalf("Chunk of %d bytes", len(m[1]))
+		}
+		data.WriteString(m[1])
+	}
+	raw, err := base64.StdEncoding.DecodeString(data.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 400 || b.Dy() != 200 {
+		t.Errorf("Expected the image scaled down to the 400×200 pixels of its cells, got %v", b)
+	}
+}
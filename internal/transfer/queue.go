@@ -0,0 +1,301 @@
+// Package transfer runs file transfers through a queue with a bounded
+// number of concurrent workers
+package transfer
+
+import (
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Direction is the way an item is copied
+type Direction int
+
+const (
+	Upload Direction = iota
+	Download
+)
+
+func (d Direction) String() string {
+	if d == Download {
+		return "Download"
+	}
+	return "Upload"
+}
+
+// State is where an item is in the queue
+type State int
+
+const (
+	Pending State = iota
+	Active
+	Completed
+	Failed
+)
+
+func (s State) String() string {
+	switch s {
+	case Active:
+		return "Active"
+	case Completed:
+		return "Completed"
+	case Failed:
+		return "Failed"
+	default:
+		return "Pending"
+	}
+}
+
+const (
+	// DefaultConcurrency is how many transfers run at once unless configured
+	DefaultConcurrency = 3
+	// MaxConcurrency caps the number of simultaneous transfers
+	MaxConcurrency = 8
+	// ConcurrencyEnv overrides DefaultConcurrency
+	ConcurrencyEnv = "SXT_TRANSFER_CONCURRENCY"
+)
+
+// ConcurrencyFromEnv returns the concurrency set in $SXT_TRANSFER_CONCURRENCY,
+// or DefaultConcurrency when it is unset or invalid
+func ConcurrencyFromEnv() int {
+	n, err := strconv.Atoi(os.Getenv(ConcurrencyEnv))
+	if err != nil || n < 1 {
+		return DefaultConcurrency
+	}
+	return min(n, MaxConcurrency)
+}
+
+// Item is one file or directory in the queue
+type Item struct {
+	ID        int
+	Name      string
+	Source    string
+	Target    string
+	Direction Direction
+	IsDir     bool
+	Size      int64
+	// Resume continues a partial target instead of overwriting it
+	Resume bool
+	// RateLimit caps the transfer in KB/s, 0 meaning unlimited
+	RateLimit int
+	// Sync copies a file of a directory sync or watch: missing parent
+	// directories are created and the copy keeps the modification time of
+	// the source
+	Sync  bool
+	State State
+	Err   error
+}
+
+// Func performs the transfer of a single item
+type Func func(item Item) error
+
+// Queue runs queued items with at most Concurrency of them active at once
+type Queue struct {
+	mu          sync.Mutex
+	run         Func
+	concurrency int
+	items       []*Item
+	nextID      int
+	active      int
+	closed      bool
+	updates     chan struct{}
+}
+
+// New creates a queue that transfers items with run
+func New(concurrency int, run Func) *Queue {
+	return &Queue{
+		run:         run,
+		concurrency: max(min(concurrency, MaxConcurrency), 1),
+		nextID:      1,
+		updates:     make(chan struct{}, 1),
+	}
+}
+
+// Updates is signalled whenever an item changes state. It is closed by Close.
+func (q *Queue) Updates() <-chan struct{} {
+	return q.updates
+}
+
+// Add queues items and starts them as workers become free. It returns
+// the IDs given to the items.
+func (q *Queue) Add(items ...Item) []int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil
+	}
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		item.ID = q.nextID
+		ids = append(ids, item.ID)
+		item.State = Pending
+		item.Err = nil
+		q.nextID++
+		q.items = append(q.items, &item)
+	}
+	q.schedule()
+	q.notify()
+	return ids
+}
+
+// Retry puts a failed item back in the queue
+func (q *Queue) Retry(id int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, item := range q.items {
+		if item.ID == id && item.State == Failed {
+			item.State = Pending
+			item.Err = nil
+			q.schedule()
+			q.notify()
+			return true
+		}
+	}
+	return false
+}
+
+// RetryFailed puts every failed item back in the queue and returns how many
+func (q *Queue) RetryFailed() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	n := 0
+	for _, item := range q.items {
+		if item.State == Failed {
+			item.State = Pending
+			item.Err = nil
+			n++
+		}
+	}
+	if n > 0 {
+		q.schedule()
+		q.notify()
+	}
+	return n
+}
+
+// SetRateLimit changes the limit of an item that has not started yet
+func (q *Queue) SetRateLimit(id, kbps int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, item := range q.items {
+		if item.ID == id && (item.State == Pending || item.State == Failed) {
+			item.RateLimit = max(kbps, 0)
+			q.notify()
+			return true
+		}
+	}
+	return false
+}
+
+// ClearCompleted drops completed items from the queue
+func (q *Queue) ClearCompleted() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	kept := q.items[:0]
+	for _, item := range q.items {
+		if item.State != Completed {
+			kept = append(kept, item)
+		}
+	}
+	clear(q.items[len(kept):])
+	q.items = kept
+	q.notify()
+}
+
+// Items returns a snapshot of the queue in the order items were added
+func (q *Queue) Items() []Item {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	items := make([]Item, len(q.items))
+	for i, item := range q.items {
+		items[i] = *item
+	}
+	return items
+}
+
+// Counts returns how many items are in each state
+func (q *Queue) Counts() map[State]int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	counts := make(map[State]int, 4)
+	for _, item := range q.items {
+		counts[item.State]++
+	}
+	return counts
+}
+
+// Concurrency returns the maximum number of simultaneous transfers
+func (q *Queue) Concurrency() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.concurrency
+}
+
+// SetConcurrency changes the number of simultaneous transfers, clamped to
+// 1..MaxConcurrency. Active transfers are never interrupted.
+func (q *Queue) SetConcurrency(n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.concurrency = max(min(n, MaxConcurrency), 1)
+	q.schedule()
+	q.notify()
+}
+
+// Close stops starting new transfers and closes Updates. Active transfers
+// run until their Func returns.
+func (q *Queue) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.updates)
+}
+
+// schedule starts pending items while workers are free. Callers hold q.mu.
+func (q *Queue) schedule() {
+	for _, item := range q.items {
+		if q.closed || q.active >= q.concurrency {
+			return
+		}
+		if item.State != Pending {
+			continue
+		}
+		item.State = Active
+		q.active++
+		go q.execute(item)
+	}
+}
+
+func (q *Queue) execute(item *Item) {
+	q.mu.Lock()
+	snapshot := *item
+	q.mu.Unlock()
+
+	err := q.run(snapshot)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.active--
+	if err != nil {
+		item.State = Failed
+		item.Err = err
+	} else {
+		item.State = Completed
+	}
+	q.schedule()
+	q.notify()
+}
+
+// notify signals Updates without blocking; one pending signal is enough as
+// listeners read the whole queue. Callers hold q.mu.
+func (q *Queue) notify() {
+	if q.closed {
+		return
+	}
+	select {
+	case q.updates <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,121 @@
+package transfer
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitFor polls the queue until done reports true or the test times out
+func waitFor(t *testing.T, q *Queue, done func(map[State]int) bool) {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		if done(q.Counts()) {
+			return
+		}
+		select {
+		case <-q.Updates():
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatalf("Timed out waiting for queue, counts: %v", q.Counts())
+		}
+	}
+}
+
+func TestQueueLimitsConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	running, peak := 0, 0
+	release := make(chan struct{})
+
+	q := New(2, func(item Item) error {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+	defer q.Close()
+
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		q.Add(Item{Name: name})
+	}
+	waitFor(t, q, func(c map[State]int) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return running == 2
+	})
+	if c := q.Counts(); c[Active] != 2 || c[Pending] != 3 {
+		t.Errorf("Expected 2 active and 3 pending items, got %v", c)
+	}
+
+	close(release)
+	waitFor(t, q, func(c map[State]int) bool { return c[Completed] == 5 })
+	if peak > 2 {
+		t.Errorf("Expected at most 2 transfers at once, got %d", peak)
+	}
+
+	q.ClearCompleted()
+	if items := q.Items(); len(items) != 0 {
+		t.Errorf("Expected an empty queue after clearing, got %+v", items)
+	}
+}
+
+func TestQueueRetry(t *testing.T) {
+	var mu sync.Mutex
+	attempts := map[string]int{}
+
+	q := New(DefaultConcurrency, func(item Item) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[item.Name]++
+		if item.Name == "flaky" && attempts[item.Name] == 1 {
+			return errors.New("connection reset")
+		}
+		return nil
+	})
+	defer q.Close()
+
+	q.Add(Item{Name: "flaky", Direction: Download}, Item{Name: "ok", Direction: Upload})
+	waitFor(t, q, func(c map[State]int) bool { return c[Completed] == 1 && c[Failed] == 1 })
+
+	var failed Item
+	for _, item := range q.Items() {
+		if item.State == Failed {
+			failed = item
+		}
+	}
+	if failed.Name != "flaky" || failed.Err == nil {
+		t.Fatalf("Expected flaky to fail with an error, got %+v", failed)
+	}
+	if !q.SetRateLimit(failed.ID, 512) || q.SetRateLimit(failed.ID+1, 512) {
+		t.Error("Expected only the failed item to accept a new rate limit")
+	}
+	if q.Retry(failed.ID + 1) {
+		t.Error("Expected retrying a completed item to be refused")
+	}
+	if !q.Retry(failed.ID) {
+		t.Fatal("Expected the failed item to be retried")
+	}
+	waitFor(t, q, func(c map[State]int) bool { return c[Completed] == 2 })
+	if got := q.Items()[0]; got.RateLimit != 512 {
+		t.Errorf("Expected the retried item to keep its rate limit, got %+v", got)
+	}
+	if attempts["flaky"] != 2 || attempts["ok"] != 1 {
+		t.Errorf("Unexpected attempts: %v", attempts)
+	}
+}
+
+func TestConcurrencyFromEnv(t *testing.T) {
+	for value, want := range map[string]int{"": DefaultConcurrency, "5": 5, "0": DefaultConcurrency, "x": DefaultConcurrency, "99": MaxConcurrency} {
+		t.Setenv(ConcurrencyEnv, value)
+		if got := ConcurrencyFromEnv(); got != want {
+			t.Errorf("%s=%q: expected %d, got %d", ConcurrencyEnv, value, want, got)
+		}
+	}
+}
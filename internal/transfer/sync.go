@@ -0,0 +1,218 @@
+package transfer
+
+import (
+	"path"
+	"sort"
+	"time"
+)
+
+// syncTimeTolerance is how far apart modification times can be and still
+// count as the same, as SFTP and FAT keep them to the second or two
+const syncTimeTolerance = 2 * time.Second
+
+// SyncMode is which way a directory sync copies
+type SyncMode int
+
+const (
+	MirrorUp   SyncMode = iota // Make the remote tree a copy of the local one
+	MirrorDown                 // Make the local tree a copy of the remote one
+	TwoWay                     // Copy the newer side of each file both ways
+)
+
+func (m SyncMode) String() string {
+	switch m {
+	case MirrorDown:
+		return "remote → local"
+	case TwoWay:
+		return "both ways"
+	default:
+		return "local → remote"
+	}
+}
+
+// SyncEntry is a file or directory of a tree being synced
+type SyncEntry struct {
+	Size    int64
+	ModTime time.Time
+	IsDir   bool
+	Sum     string // Checksum of the content, when files are compared by it
+}
+
+// SyncOptions tunes a sync plan
+type SyncOptions struct {
+	Mode SyncMode
+	// Delete removes from the target of a mirror what the source lacks
+	Delete bool
+}
+
+// SyncAction is one step of a sync plan
+type SyncAction struct {
+	Path      string // Relative to the roots, with / separators
+	Direction Direction
+	// Delete removes Path from the target side of Direction instead of
+	// copying it there
+	Delete bool
+	IsDir  bool
+	Size   int64
+	Reason string
+}
+
+// SyncPlan is what a sync does, in path order
+type SyncPlan struct {
+	Actions []SyncAction
+	// Conflicts are the paths left alone: changed on both sides at the same
+	// time, or a file on one side and a directory on the other
+	Conflicts []string
+}
+
+// Counts returns how many files are uploaded, downloaded and deleted by
+// the plan, and the bytes copied
+func (p SyncPlan) Counts() (uploads, downloads, deletes int, bytes int64) {
+	for _, a := range p.Actions {
+		switch {
+		case a.Delete:
+			deletes++
+		case a.Direction == Upload:
+			uploads++
+			bytes += a.Size
+		default:
+			downloads++
+			bytes += a.Size
+		}
+	}
+	return uploads, downloads, deletes, bytes
+}
+
+// PlanSync compares the local and remote trees, keyed by path relative to
+// their roots, and returns the steps that bring them in line. Files differ
+// by size, then by checksum when both have one, and otherwise by
+// modification time. Directories missing from the target are created
+// through the files copied into them; deleted ones are removed whole.
+func PlanSync(local, remote map[string]SyncEntry, opts SyncOptions) SyncPlan {
+	paths := make([]string, 0, len(local)+len(remote))
+	for p := range local {
+		paths = append(paths, p)
+	}
+	for p := range remote {
+		if _, ok := local[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	var plan SyncPlan
+	localDirs, remoteDirs := parentDirs(local), parentDirs(remote)
+	skipped := make(map[string]bool) // Directories whose entries are covered
+	covered := func(p string) bool {
+		for dir := path.Dir(p); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			if skipped[dir] {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, p := range paths {
+		if covered(p) {
+			continue
+		}
+		l, inLocal := local[p]
+		r, inRemote := remote[p]
+		switch {
+		case inLocal && inRemote:
+			if l.IsDir != r.IsDir {
+				plan.Conflicts = append(plan.Conflicts, p)
+				skipped[p] = true
+				continue
+			}
+			if l.IsDir {
+				continue
+			}
+			reason := syncDifference(l, r)
+			if reason == "" {
+				continue
+			}
+			direction := Upload
+			switch opts.Mode {
+			case MirrorDown:
+				direction = Download
+			case TwoWay:
+				diff := l.ModTime.Sub(r.ModTime)
+				if diff.Abs() <= syncTimeTolerance {
+					plan.Conflicts = append(plan.Conflicts, p)
+					continue
+				}
+				if diff < 0 {
+					direction = Download
+				}
+				reason = "newer"
+			}
+			size := l.Size
+			if direction == Download {
+				size = r.Size
+			}
+			plan.Actions = append(plan.Actions, SyncAction{Path: p, Direction: direction, Size: size, Reason: reason})
+
+		case inLocal:
+			plan.add(p, l, Upload, localDirs, opts, skipped)
+		default:
+			plan.add(p, r, Download, remoteDirs, opts, skipped)
+		}
+	}
+	return plan
+}
+
+// add plans an entry found only on the source side of direction: it is
+// copied when the mode copies that way, and otherwise deleted from the
+// other side if deletions are on. nonEmpty holds the directories of the
+// entry's tree that have entries.
+func (p *SyncPlan) add(rel string, e SyncEntry, direction Direction, nonEmpty map[string]bool, opts SyncOptions, skipped map[string]bool) {
+	copies := opts.Mode == TwoWay ||
+		(opts.Mode == MirrorUp && direction == Upload) ||
+		(opts.Mode == MirrorDown && direction == Download)
+	if !copies {
+		if e.IsDir {
+			skipped[rel] = true
+		}
+		if opts.Delete {
+			// Mirrors delete on their target, the side opposite the entry
+			target := Upload
+			if direction == Upload {
+				target = Download
+			}
+			p.Actions = append(p.Actions, SyncAction{Path: rel, Direction: target, Delete: true, IsDir: e.IsDir, Reason: "deleted"})
+		}
+		return
+	}
+	// Only empty directories need creating; the others come with their files
+	if e.IsDir && nonEmpty[rel] {
+		return
+	}
+	p.Actions = append(p.Actions, SyncAction{Path: rel, Direction: direction, IsDir: e.IsDir, Size: e.Size, Reason: "new"})
+}
+
+// syncDifference tells how two versions of a file differ, or "" when they
+// are the same
+func syncDifference(l, r SyncEntry) string {
+	switch {
+	case l.Size != r.Size:
+		return "size differs"
+	case l.Sum != "" && r.Sum != "":
+		if l.Sum != r.Sum {
+			return "content differs"
+		}
+		return ""
+	case l.ModTime.Sub(r.ModTime).Abs() > syncTimeTolerance:
+		return "modified"
+	}
+	return ""
+}
+
+// parentDirs returns the directories of tree that have entries
+func parentDirs(tree map[string]SyncEntry) map[string]bool {
+	dirs := make(map[string]bool)
+	for p := range tree {
+		dirs[path.Dir(p)] = true
+	}
+	return dirs
+}
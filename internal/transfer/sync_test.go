@@ -0,0 +1,133 @@
+package transfer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPlanSync(t *testing.T) {
+	old := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	newer := old.Add(time.Hour)
+	local := map[string]SyncEntry{
+		"same.txt":        {Size: 10, ModTime: old},
+		"edited.txt":      {Size: 10, ModTime: newer},
+		"grown.txt":       {Size: 12, ModTime: old},
+		"new.txt":         {Size: 5, ModTime: old},
+		"src":             {IsDir: true},
+		"src/main.go":     {Size: 100, ModTime: old},
+		"empty":           {IsDir: true},
+		"clash":           {IsDir: true},
+		"clash/inner.txt": {Size: 1, ModTime: old},
+	}
+	remote := map[string]SyncEntry{
+		"same.txt":      {Size: 10, ModTime: old.Add(time.Second)},
+		"edited.txt":    {Size: 10, ModTime: old},
+		"grown.txt":     {Size: 10, ModTime: old},
+		"stale.log":     {Size: 7, ModTime: old},
+		"cache":         {IsDir: true},
+		"cache/a.bin":   {Size: 1, ModTime: old},
+		"cache/b/c.bin": {Size: 1, ModTime: old},
+		"clash":         {Size: 3, ModTime: old},
+	}
+
+	plan := PlanSync(local, remote, SyncOptions{Mode: MirrorUp})
+	want := []SyncAction{
+		{Path: "edited.txt", Direction: Upload, Size: 10, Reason: "modified"},
+		{Path: "empty", Direction: Upload, IsDir: true, Reason: "new"},
+		{Path: "grown.txt", Direction: Upload, Size: 12, Reason: "size differs"},
+		{Path: "new.txt", Direction: Upload, Size: 5, Reason: "new"},
+		{Path: "src/main.go", Direction: Upload, Size: 100, Reason: "new"},
+	}
+	if !reflect.DeepEqual(plan.Actions, want) {
+		t.Errorf("Mirror up plan:\n got %+v\nwant %+v", plan.Actions, want)
+	}
+	if !reflect.DeepEqual(plan.Conflicts, []string{"clash"}) {
+		t.Errorf("Expected the file/directory clash as a conflict, got %v", plan.Conflicts)
+	}
+
+	plan = PlanSync(local, remote, SyncOptions{Mode: MirrorUp, Delete: true})
+	var deletes []SyncAction
+	for _, a := range plan.Actions {
+		if a.Delete {
+			deletes = append(deletes, a)
+		}
+	}
+	wantDeletes := []SyncAction{
+		{Path: "cache", Direction: Upload, Delete: true, IsDir: true, Reason: "deleted"},
+		{Path: "stale.log", Direction: Upload, Delete: true, Reason: "deleted"},
+	}
+	if !reflect.DeepEqual(deletes, wantDeletes) {
+		t.Errorf("Expected the remote extras deleted whole:\n got %+v\nwant %+v", deletes, wantDeletes)
+	}
+	if up, down, del, bytes := plan.Counts(); up != 5 || down != 0 || del != 2 || bytes != 127 {
+		t.Errorf("Counts = %d, %d, %d, %d", up, down, del, bytes)
+	}
+}
+
+func TestPlanSyncDownAndTwoWay(t *testing.T) {
+	old := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	local := map[string]SyncEntry{
+		"a.txt":    {Size: 1, ModTime: old.Add(time.Hour)},
+		"b.txt":    {Size: 1, ModTime: old},
+		"both.txt": {Size: 1, ModTime: old},
+		"mine.txt": {Size: 1, ModTime: old},
+	}
+	remote := map[string]SyncEntry{
+		"a.txt":      {Size: 2, ModTime: old},
+		"b.txt":      {Size: 2, ModTime: old.Add(time.Hour)},
+		"both.txt":   {Size: 2, ModTime: old},
+		"theirs.txt": {Size: 3, ModTime: old},
+	}
+
+	plan := PlanSync(local, remote, SyncOptions{Mode: MirrorDown, Delete: true})
+	var got []string
+	for _, a := range plan.Actions {
+		got = append(got, a.Direction.String()+" "+a.Path+" "+a.Reason)
+	}
+	want := []string{
+		"Download a.txt size differs",
+		"Download b.txt size differs",
+		"Download both.txt size differs",
+		"Download mine.txt deleted",
+		"Download theirs.txt new",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Mirror down plan:\n got %v\nwant %v", got, want)
+	}
+
+	plan = PlanSync(local, remote, SyncOptions{Mode: TwoWay, Delete: true})
+	got = nil
+	for _, a := range plan.Actions {
+		got = append(got, a.Direction.String()+" "+a.Path+" "+a.Reason)
+	}
+	want = []string{
+		"Upload a.txt newer",
+		"Download b.txt newer",
+		"Upload mine.txt new",
+		"Download theirs.txt new",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Two-way plan:\n got %v\nwant %v", got, want)
+	}
+	if !reflect.DeepEqual(plan.Conflicts, []string{"both.txt"}) {
+		t.Errorf("Expected both.txt changed at the same time as a conflict, got %v", plan.Conflicts)
+	}
+}
+
+func TestPlanSyncChecksums(t *testing.T) {
+	old := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	local := map[string]SyncEntry{
+		"touched.txt": {Size: 4, ModTime: old.Add(time.Hour), Sum: "aaa"},
+		"changed.txt": {Size: 4, ModTime: old, Sum: "bbb"},
+	}
+	remote := map[string]SyncEntry{
+		"touched.txt": {Size: 4, ModTime: old, Sum: "aaa"},
+		"changed.txt": {Size: 4, ModTime: old, Sum: "ccc"},
+	}
+	plan := PlanSync(local, remote, SyncOptions{Mode: MirrorUp})
+	want := []SyncAction{{Path: "changed.txt", Direction: Upload, Size: 4, Reason: "content differs"}}
+	if !reflect.DeepEqual(plan.Actions, want) {
+		t.Errorf("Expected only the content change, got %+v", plan.Actions)
+	}
+}
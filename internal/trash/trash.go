@@ -0,0 +1,53 @@
+// Package trash moves local files to the trash of the operating system
+// instead of deleting them, so they can be put back
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNoRestore is returned by Restore for files only the operating system
+// can put back, such as those in the Windows Recycle Bin
+var ErrNoRestore = errors.New("the file can only be restored from the trash itself")
+
+// Item is a file moved to the trash
+type Item struct {
+	Path    string // Where the file was
+	Trashed string // Where the file is now, empty when it can't be told
+	info    string // The freedesktop.org .trashinfo file, if any
+}
+
+// Restore moves a trashed file back where it was. A file created there
+// since is not overwritten.
+func Restore(item Item) error {
+	if item.Trashed == "" {
+		return ErrNoRestore
+	}
+	if _, err := os.Lstat(item.Path); err == nil {
+		return fmt.Errorf("%s exists again", item.Path)
+	}
+	if err := os.Rename(item.Trashed, item.Path); err != nil {
+		return err
+	}
+	if item.info != "" {
+		os.Remove(item.info)
+	}
+	return nil
+}
+
+// uniqueName returns the ith candidate name for a file named name in the
+// trash: name itself, then name-1.ext, name-2.ext...
+func uniqueName(name string, i int) string {
+	if i == 0 {
+		return name
+	}
+	ext := filepath.Ext(name)
+	if ext == name {
+		ext = "" // A dotfile such as .bashrc has no extension
+	}
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, ext), i, ext)
+}
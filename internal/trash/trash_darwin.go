@@ -0,0 +1,38 @@
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// Move moves path to ~/.Trash, where Finder shows it
+func Move(path string) (Item, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Item{}, err
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return Item{}, err
+	}
+	trash := filepath.Join(home, ".Trash")
+	if err := os.MkdirAll(trash, 0700); err != nil {
+		return Item{}, err
+	}
+	for i := 0; ; i++ {
+		trashed := filepath.Join(trash, uniqueName(filepath.Base(abs), i))
+		if _, err := os.Lstat(trashed); err == nil {
+			continue
+		}
+		if err := os.Rename(abs, trashed); err != nil {
+			if errors.Is(err, syscall.EXDEV) {
+				return Item{}, fmt.Errorf("%s is on another volume than the trash", path)
+			}
+			return Item{}, err
+		}
+		return Item{Path: abs, Trashed: trashed}, nil
+	}
+}
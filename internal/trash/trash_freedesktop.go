@@ -0,0 +1,76 @@
+//go:build !windows && !darwin
+
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// dir returns the home trash of the freedesktop.org trash specification,
+// which file managers such as Nautilus and Dolphin show
+func dir() (string, error) {
+	if data := os.Getenv("XDG_DATA_HOME"); data != "" {
+		return filepath.Join(data, "Trash"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "Trash"), nil
+}
+
+// Move moves path to the trash, recording where it came from so file
+// managers can put it back too
+func Move(path string) (Item, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Item{}, err
+	}
+	trash, err := dir()
+	if err != nil {
+		return Item{}, err
+	}
+	files := filepath.Join(trash, "files")
+	infos := filepath.Join(trash, "info")
+	for _, d := range []string{files, infos} {
+		if err := os.MkdirAll(d, 0700); err != nil {
+			return Item{}, err
+		}
+	}
+
+	// The .trashinfo file is created first, which claims the name
+	for i := 0; ; i++ {
+		name := uniqueName(filepath.Base(abs), i)
+		info := filepath.Join(infos, name+".trashinfo")
+		f, err := os.OpenFile(info, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return Item{}, err
+		}
+		_, err = fmt.Fprintf(f, "[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+			(&url.URL{Path: abs}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		trashed := filepath.Join(files, name)
+		if err == nil {
+			err = os.Rename(abs, trashed)
+		}
+		if err != nil {
+			os.Remove(info)
+			if errors.Is(err, syscall.EXDEV) {
+				return Item{}, fmt.Errorf("%s is on another filesystem than the trash", path)
+			}
+			return Item{}, err
+		}
+		return Item{Path: abs, Trashed: trashed, info: info}, nil
+	}
+}
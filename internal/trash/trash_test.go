@@ -0,0 +1,53 @@
+//go:build !windows && !darwin
+
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMoveAndRestore(t *testing.T) {
+	data := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", data)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes v2.txt")
+	for range 2 {
+		if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Move(path); err != nil {
+			t.Fatalf("Move failed: %v", err)
+		}
+	}
+	if err := os.WriteFile(path, []byte("third"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	item, err := Move(path)
+	if err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+
+	if want := filepath.Join(data, "Trash", "files", "notes v2-2.txt"); item.Trashed != want {
+		t.Errorf("Expected a numbered name in the trash, got %s", item.Trashed)
+	}
+	info, err := os.ReadFile(filepath.Join(data, "Trash", "info", "notes v2-2.txt.trashinfo"))
+	if err != nil || !strings.Contains(string(info), "Path="+filepath.ToSlash(dir)+"/notes%20v2.txt\n") {
+		t.Errorf("Expected the original path in the .trashinfo file, got %q (%v)", info, err)
+	}
+
+	if err := Restore(item); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "third" {
+		t.Errorf("Expected the file to be back, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(data, "Trash", "info", "notes v2-2.txt.trashinfo")); !os.IsNotExist(err) {
+		t.Error("Expected the .trashinfo file to be removed")
+	}
+	if err := Restore(Item{Path: path, Trashed: filepath.Join(data, "Trash", "files", "notes v2.txt")}); err == nil {
+		t.Error("Expected Restore not to overwrite a file")
+	}
+}
@@ -0,0 +1,59 @@
+package trash
+
+import (
+	"fmt"
+	"path/filepath"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var procSHFileOperationW = windows.NewLazySystemDLL("shell32.dll").NewProc("SHFileOperationW")
+
+// SHFileOperation constants
+const (
+	foDelete          = 0x3
+	fofSilent         = 0x4
+	fofNoConfirmation = 0x10
+	fofAllowUndo      = 0x40
+	fofNoErrorUI      = 0x400
+)
+
+// shFileOpStruct is SHFILEOPSTRUCTW
+type shFileOpStruct struct {
+	hwnd                  uintptr
+	wFunc                 uint32
+	pFrom                 *uint16
+	pTo                   *uint16
+	fFlags                uint16
+	fAnyOperationsAborted int32
+	hNameMappings         uintptr
+	lpszProgressTitle     *uint16
+}
+
+// Move moves path to the Recycle Bin. Windows doesn't tell where the file
+// went, so it is restored from the Recycle Bin itself.
+func Move(path string) (Item, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Item{}, err
+	}
+	// pFrom is a list of paths ending with an empty one
+	from, err := windows.UTF16FromString(abs)
+	if err != nil {
+		return Item{}, err
+	}
+	from = append(from, 0)
+	op := shFileOpStruct{
+		wFunc:  foDelete,
+		pFrom:  &from[0],
+		fFlags: fofAllowUndo | fofNoConfirmation | fofSilent | fofNoErrorUI,
+	}
+	if r, _, _ := procSHFileOperationW.Call(uintptr(unsafe.Pointer(&op))); r != 0 {
+		return Item{}, fmt.Errorf("failed to move %s to the Recycle Bin (error %#x)", path, r)
+	}
+	if op.fAnyOperationsAborted != 0 {
+		return Item{}, fmt.Errorf("moving %s to the Recycle Bin was aborted", path)
+	}
+	return Item{Path: abs}, nil
+}
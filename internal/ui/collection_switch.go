@@ -0,0 +1,123 @@
+package ui
+
+import (
+	"log"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ui/components"
+)
+
+// When browsing Bitwarden, the header shows where the connections come
+// from (Org ▸ Collection) and g switches to another collection, recent
+// ones first, without going back through the organization and collection
+// lists.
+
+// breadcrumbSeparator separates the levels of the breadcrumb
+const breadcrumbSeparator = " ▸ "
+
+// organizationNames maps the IDs of the loaded organizations to their names
+func organizationNames(bw *config.BitwardenManager) map[string]string {
+	names := make(map[string]string)
+	for _, org := range bw.ListOrganizations() {
+		names[org.ID] = org.Name
+	}
+	return names
+}
+
+// bitwardenBreadcrumb tells which part of the vault the connection list
+// shows, e.g. "Acme ▸ Web Team", "" for other storages
+func (m *Model) bitwardenBreadcrumb() string {
+	bw, ok := m.storageBackend.(*config.BitwardenManager)
+	if !ok {
+		return ""
+	}
+	if bw.IsPersonalVault() {
+		crumb := "My Vault"
+		if folder := bw.GetSelectedFolder(); folder != nil {
+			name := folder.Name
+			if folder.ID == "" {
+				name = "No Folder"
+			}
+			crumb += breadcrumbSeparator + name
+		}
+		return crumb
+	}
+	coll := bw.GetSelectedCollection()
+	if coll == nil {
+		return ""
+	}
+	org, ok := organizationNames(bw)[coll.OrganizationID]
+	if !ok {
+		org = "Bitwarden"
+	}
+	return org + breadcrumbSeparator + coll.Name
+}
+
+// openCollectionPicker offers the recent collections and those of the
+// organization loaded
+func (m *Model) openCollectionPicker() tea.Cmd {
+	bw, ok := m.storageBackend.(*config.BitwardenManager)
+	if !ok {
+		return nil
+	}
+	current := ""
+	if coll := bw.GetSelectedCollection(); coll != nil && !bw.IsPersonalVault() {
+		current = coll.ID
+	}
+	m.collectionPicker = components.NewCollectionPicker(config.RecentCollections(), bw.ListCollections(), organizationNames(bw), current)
+	m.collectionPicker.SetSize(m.width, m.height)
+	m.state = StateCollectionPicker
+	return m.collectionPicker.Init()
+}
+
+// handleCollectionPicker loads the collection picked, or returns to the
+// list
+func (m *Model) handleCollectionPicker(model tea.Model) tea.Cmd {
+	m.collectionPicker = model.(*components.CollectionPicker)
+	coll, chosen := m.collectionPicker.Chosen()
+	if !chosen && !m.collectionPicker.IsCanceled() {
+		return nil
+	}
+	m.collectionPicker = nil
+	m.state = StateConnectionList
+	m.connectionList.Reset()
+	if !chosen {
+		return nil
+	}
+
+	bw := m.bitwardenManager
+	bw.SetPersonalVault(false)
+	bw.SetSelectedFolder(nil)
+	// Esc goes back to the organizations when the collection list is of
+	// another one
+	if !slices.ContainsFunc(bw.ListCollections(), func(c config.Collection) bool { return c.ID == coll.ID }) {
+		m.bitwardenCollectionList = nil
+	}
+	bw.SetSelectedCollection(&coll)
+	m.storageBackend = bw
+	m.loading = true
+	return tea.Batch(
+		loadBitwardenConnectionsByCollectionCmd(bw, coll.ID),
+		m.spinner.Tick,
+	)
+}
+
+// recordRecentCollection remembers the collection in view for the picker
+func (m *Model) recordRecentCollection() {
+	coll := m.bitwardenManager.GetSelectedCollection()
+	if coll == nil {
+		return
+	}
+	recent := config.RecentCollection{
+		OrganizationID:   coll.OrganizationID,
+		OrganizationName: organizationNames(m.bitwardenManager)[coll.OrganizationID],
+		CollectionID:     coll.ID,
+		CollectionName:   coll.Name,
+	}
+	if err := config.RecordRecentCollection(recent); err != nil {
+		log.Printf("Failed to record the recent collection: %v", err)
+	}
+}
@@ -7,8 +7,16 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 )
 
+// SaveCollectionMsg asks for a collection to be created, or renamed when
+// Collection is set
+type SaveCollectionMsg struct {
+	Collection *config.Collection
+	Name       string
+}
+
 type collectionItem struct {
 	collection config.Collection
 }
@@ -22,6 +30,10 @@ type BitwardenCollectionList struct {
 	collections           []config.Collection
 	selectedCollection    *config.Collection
 	highlightedCollection *config.Collection
+
+	organization       string
+	collectionModal    *CollectionModal
+	renamingCollection *config.Collection
 }
 
 func NewBitwardenCollectionList(collections []config.Collection) *BitwardenCollectionList {
@@ -53,6 +65,26 @@ func (cl *BitwardenCollectionList) Init() tea.Cmd { return nil }
 
 func (cl *BitwardenCollectionList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
+
+	// If the create or rename modal is showing, delegate to it
+	if cl.collectionModal != nil {
+		var modalModel tea.Model
+		modalModel, cmd = cl.collectionModal.Update(msg)
+		cl.collectionModal = modalModel.(*CollectionModal)
+
+		if cl.collectionModal.IsConfirmed() {
+			saveMsg := SaveCollectionMsg{Collection: cl.renamingCollection, Name: cl.collectionModal.Value()}
+			cl.collectionModal = nil
+			cl.renamingCollection = nil
+			return cl, func() tea.Msg { return saveMsg }
+		} else if cl.collectionModal.IsCanceled() {
+			cl.collectionModal = nil
+			cl.renamingCollection = nil
+		}
+
+		return cl, cmd
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		cl.SetSize(msg.Width, msg.Height)
@@ -86,6 +118,17 @@ func (cl *BitwardenCollectionList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (cl *BitwardenCollectionList) View() string {
+	if cl.collectionModal != nil {
+		return lipgloss.Place(
+			cl.list.Width(),
+			cl.list.Height(),
+			lipgloss.Center,
+			lipgloss.Center,
+			cl.collectionModal.View(),
+			lipgloss.WithWhitespaceChars(" "),
+			lipgloss.WithWhitespaceForeground(lipgloss.Color("0")),
+		)
+	}
 	if len(cl.collections) == 0 {
 		return fmt.Sprintf("\n%s\n\n  No collections found.\n\n", titleStyle.Render("Collections"))
 	}
@@ -111,6 +154,35 @@ func (cl *BitwardenCollectionList) SetCollections(collections []config.Collectio
 
 func (cl *BitwardenCollectionList) List() *list.Model { return &cl.list }
 
+// SetOrganization sets the organization name shown when creating or
+// renaming collections
+func (cl *BitwardenCollectionList) SetOrganization(name string) {
+	cl.organization = name
+}
+
+// ShowCreate asks for the name of a new collection
+func (cl *BitwardenCollectionList) ShowCreate() tea.Cmd {
+	cl.renamingCollection = nil
+	cl.collectionModal = NewCollectionModal(cl.organization, "")
+	cl.collectionModal.SetSize(cl.list.Width(), cl.list.Height())
+	return cl.collectionModal.Init()
+}
+
+// ShowRename asks for a new name for the highlighted collection
+func (cl *BitwardenCollectionList) ShowRename() tea.Cmd {
+	if cl.highlightedCollection == nil {
+		return nil
+	}
+	cl.renamingCollection = cl.highlightedCollection
+	cl.collectionModal = NewCollectionModal(cl.organization, cl.highlightedCollection.Name)
+	cl.collectionModal.SetSize(cl.list.Width(), cl.list.Height())
+	return cl.collectionModal.Init()
+}
+
+func (cl *BitwardenCollectionList) IsShowingCollectionModal() bool {
+	return cl.collectionModal != nil
+}
+
 func (cl *BitwardenCollectionList) Reset() {
 	cl.selectedCollection = nil
 	cl.list.Select(0)
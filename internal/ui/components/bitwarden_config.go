@@ -1,56 +1,93 @@
 package components
 
 import (
+	"os"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// Input indices for the Bitwarden config form
+const (
+	bitwardenInputServerURL = iota
+	bitwardenInputEmail
+	bitwardenInputClientID
+	bitwardenInputClientSecret
+	bitwardenInputCount
 )
 
+var bitwardenInputLabels = [bitwardenInputCount]string{
+	"Server URL",
+	"Email",
+	"Client ID",
+	"Client Secret",
+}
+
 type BitwardenConfigForm struct {
-	inputs     []textinput.Model
-	focusIndex int
-	submitted  bool
-	canceled   bool
-	ErrorMsg   string
-	width      int
-	height     int
+	inputs      []textinput.Model
+	loginMethod config.BitwardenLoginMethod
+	focusIndex  int
+	submitted   bool
+	canceled    bool
+	ErrorMsg    string
+	width       int
+	height      int
 }
 
-// NewBitwardenConfigForm creates a new Bitwarden config form
+// NewBitwardenConfigForm creates a new Bitwarden config form, with the API
+// key prefilled from BW_CLIENTID and BW_CLIENTSECRET
 func NewBitwardenConfigForm() *BitwardenConfigForm {
-	inputs := make([]textinput.Model, 2)
-
-	// Server URL
-	inputs[0] = textinput.New()
-	inputs[0].Placeholder = "https://bitwarden.com"
-	inputs[0].Focus()
-	inputs[0].Width = 50
-	inputs[0].Prompt = "" // Clean look
-	inputs[0].PromptStyle = focusedStyle
-	inputs[0].TextStyle = focusedStyle
-
-	// Email
-	inputs[1] = textinput.New()
-	inputs[1].Placeholder = "user@example.com"
-	inputs[1].Width = 50
-	inputs[1].Prompt = ""
-	inputs[1].PromptStyle = blurredStyle
-	inputs[1].TextStyle = blurredStyle
-
-	return &BitwardenConfigForm{
-		inputs:     inputs,
-		focusIndex: 0,
+	inputs := make([]textinput.Model, bitwardenInputCount)
+	placeholders := [bitwardenInputCount]string{
+		"https://bitwarden.com",
+		"user@example.com",
+		"user.xxxxxxxx-… or organization.…",
+		"",
 	}
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Placeholder = placeholders[i]
+		inputs[i].Width = 50
+		inputs[i].Prompt = "" // Clean look
+		inputs[i].PromptStyle = blurredStyle
+		inputs[i].TextStyle = blurredStyle
+	}
+	inputs[bitwardenInputClientSecret].EchoMode = textinput.EchoPassword
+
+	inputs[bitwardenInputClientID].SetValue(os.Getenv("BW_CLIENTID"))
+	inputs[bitwardenInputClientSecret].SetValue(os.Getenv("BW_CLIENTSECRET"))
+
+	f := &BitwardenConfigForm{
+		inputs:      inputs,
+		loginMethod: config.BitwardenLoginPassword,
+	}
+	f.updateFocus()
+	return f
 }
 
 func (f *BitwardenConfigForm) Init() tea.Cmd {
 	return textinput.Blink
 }
 
+// visibleInputs returns the indices of the inputs used by the current login method
+func (f *BitwardenConfigForm) visibleInputs() []int {
+	switch f.loginMethod {
+	case config.BitwardenLoginAPIKey:
+		return []int{bitwardenInputServerURL, bitwardenInputClientID, bitwardenInputClientSecret}
+	case config.BitwardenLoginSSO:
+		return []int{bitwardenInputServerURL}
+	default:
+		return []int{bitwardenInputServerURL, bitwardenInputEmail}
+	}
+}
+
 func (f *BitwardenConfigForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
+	visible := f.visibleInputs()
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
@@ -62,53 +99,66 @@ func (f *BitwardenConfigForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+c", "esc":
 			f.canceled = true
 			return f, nil
+		case "ctrl+t":
+			// Cycle through the available login methods
+			f.loginMethod = (f.loginMethod + 1) % config.BitwardenLoginMethod(len(config.BitwardenLoginMethods))
+			f.focusIndex = min(f.focusIndex, len(f.visibleInputs()))
+			f.ErrorMsg = ""
+			f.updateFocus()
+			return f, nil
 		case "tab", "down", "enter":
 			// Special handling for Enter on the Submit button
-			if msg.String() == "enter" && f.focusIndex == len(f.inputs) {
+			if msg.String() == "enter" && f.focusIndex == len(visible) {
 				if valid, err := f.validateForm(); valid {
 					f.submitted = true
-					return f, nil
 				} else {
 					f.ErrorMsg = err
-					return f, nil
 				}
+				return f, nil
 			}
 
 			// Cycle focus
 			f.focusIndex++
-			if f.focusIndex > len(f.inputs) {
+			if f.focusIndex > len(visible) {
 				f.focusIndex = 0
 			}
+			f.updateFocus()
+			return f, nil
 		case "shift+tab", "up":
 			f.focusIndex--
 			if f.focusIndex < 0 {
-				f.focusIndex = len(f.inputs)
+				f.focusIndex = len(visible)
 			}
+			f.updateFocus()
+			return f, nil
 		}
 	}
 
-	// Set focus/blurring on inputs
-	for i := 0; i < len(f.inputs); i++ {
-		if i == f.focusIndex {
-			f.inputs[i].Focus()
-			f.inputs[i].PromptStyle = focusedStyle
-			f.inputs[i].TextStyle = focusedStyle
-		} else {
-			f.inputs[i].Blur()
-			f.inputs[i].PromptStyle = blurredStyle
-			f.inputs[i].TextStyle = blurredStyle
-		}
-	}
-
-	if f.focusIndex < len(f.inputs) {
-		newInput, cmd := f.inputs[f.focusIndex].Update(msg)
-		f.inputs[f.focusIndex] = newInput
+	if f.focusIndex < len(visible) {
+		idx := visible[f.focusIndex]
+		newInput, cmd := f.inputs[idx].Update(msg)
+		f.inputs[idx] = newInput
 		cmds = append(cmds, cmd)
 	}
 
 	return f, tea.Batch(cmds...)
 }
 
+func (f *BitwardenConfigForm) updateFocus() {
+	visible := f.visibleInputs()
+	for i := range f.inputs {
+		f.inputs[i].Blur()
+		f.inputs[i].PromptStyle = blurredStyle
+		f.inputs[i].TextStyle = blurredStyle
+	}
+	if f.focusIndex < len(visible) {
+		idx := visible[f.focusIndex]
+		f.inputs[idx].Focus()
+		f.inputs[idx].PromptStyle = focusedStyle
+		f.inputs[idx].TextStyle = focusedStyle
+	}
+}
+
 func (f *BitwardenConfigForm) View() string {
 	// 1. Build the form content with Left Alignment
 	var b strings.Builder
@@ -119,19 +169,39 @@ func (f *BitwardenConfigForm) View() string {
 	b.WriteString(sectionTitleStyle.Render("Bitwarden Setup"))
 	b.WriteString("\n\n")
 
-	b.WriteString(labelStyle.Render("Server URL"))
+	// Login method selector
+	var methods []string
+	for _, m := range config.BitwardenLoginMethods {
+		if m == f.loginMethod {
+			methods = append(methods, focusedStyle.Bold(true).Render("● "+m.String()))
+		} else {
+			methods = append(methods, blurredStyle.Render("○ "+m.String()))
+		}
+	}
+	b.WriteString(labelStyle.Render("Login Method (ctrl+t to change)"))
 	b.WriteString("\n")
-	b.WriteString(f.inputs[0].View())
+	b.WriteString(strings.Join(methods, "   "))
 	b.WriteString("\n\n")
 
-	b.WriteString(labelStyle.Render("Email"))
-	b.WriteString("\n")
-	b.WriteString(f.inputs[1].View())
-	b.WriteString("\n\n")
+	for _, idx := range f.visibleInputs() {
+		b.WriteString(labelStyle.Render(bitwardenInputLabels[idx]))
+		b.WriteString("\n")
+		b.WriteString(f.inputs[idx].View())
+		b.WriteString("\n\n")
+	}
+
+	switch f.loginMethod {
+	case config.BitwardenLoginAPIKey:
+		b.WriteString(labelStyle.Render("The vault is unlocked with the master password next."))
+		b.WriteString("\n\n")
+	case config.BitwardenLoginSSO:
+		b.WriteString(labelStyle.Render("A browser window will open to complete the login;\nthe vault is unlocked with the master password next."))
+		b.WriteString("\n\n")
+	}
 
 	// Render submit button
 	button := blurredButton
-	if f.focusIndex == len(f.inputs) {
+	if f.focusIndex == len(f.visibleInputs()) {
 		button = focusedButton
 	}
 	b.WriteString(button)
@@ -175,17 +245,38 @@ func (f *BitwardenConfigForm) IsCanceled() bool {
 	return f.canceled
 }
 
-func (f *BitwardenConfigForm) Config() (serverURL, email string) {
-	url := f.inputs[0].Value()
+// ResetSubmitted allows the form to be submitted again after a failed login
+func (f *BitwardenConfigForm) ResetSubmitted() {
+	f.submitted = false
+}
+
+// Config returns the Bitwarden configuration entered in the form
+func (f *BitwardenConfigForm) Config() *config.BitwardenConfig {
+	value := func(i int) string { return strings.TrimSpace(f.inputs[i].Value()) }
+	url := value(bitwardenInputServerURL)
 	if url == "" {
 		url = "https://bitwarden.com"
 	}
-	return url, f.inputs[1].Value()
+	return &config.BitwardenConfig{
+		ServerURL:    url,
+		Email:        value(bitwardenInputEmail),
+		LoginMethod:  f.loginMethod,
+		ClientID:     value(bitwardenInputClientID),
+		ClientSecret: value(bitwardenInputClientSecret),
+	}
 }
 
 func (f *BitwardenConfigForm) validateForm() (bool, string) {
-	if strings.TrimSpace(f.inputs[1].Value()) == "" {
-		return false, "Email is required."
+	switch f.loginMethod {
+	case config.BitwardenLoginPassword:
+		if strings.TrimSpace(f.inputs[bitwardenInputEmail].Value()) == "" {
+			return false, "Email is required."
+		}
+	case config.BitwardenLoginAPIKey:
+		if strings.TrimSpace(f.inputs[bitwardenInputClientID].Value()) == "" ||
+			strings.TrimSpace(f.inputs[bitwardenInputClientSecret].Value()) == "" {
+			return false, "Client ID and client secret are required."
+		}
 	}
 	return true, ""
 }
@@ -0,0 +1,115 @@
+package components
+
+import (
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// folderItem is a folder of the personal vault, or all of it when folder is nil
+type folderItem struct {
+	folder *config.Folder
+}
+
+func (i folderItem) FilterValue() string { return i.Title() }
+func (i folderItem) Title() string {
+	if i.folder == nil {
+		return "All Items"
+	}
+	return i.folder.Name
+}
+func (i folderItem) Description() string { return "" }
+
+type BitwardenFolderList struct {
+	list           list.Model
+	folders        []config.Folder
+	chosen         bool
+	selectedFolder *config.Folder
+}
+
+func NewBitwardenFolderList(folders []config.Folder) *BitwardenFolderList {
+	l := list.New(folderItems(folders), list.NewDefaultDelegate(), 60, 20)
+	l.Title = "Folders"
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(true)
+	l.Styles.Title = titleStyle
+	l.Styles.PaginationStyle = paginationStyle
+	l.Styles.HelpStyle = helpStyle
+
+	return &BitwardenFolderList{
+		list:    l,
+		folders: folders,
+	}
+}
+
+// folderItems lists "All Items" followed by the folders
+func folderItems(folders []config.Folder) []list.Item {
+	items := make([]list.Item, 0, len(folders)+1)
+	items = append(items, folderItem{})
+	for i := range folders {
+		items = append(items, folderItem{folder: &folders[i]})
+	}
+	return items
+}
+
+func (fl *BitwardenFolderList) Init() tea.Cmd { return nil }
+
+func (fl *BitwardenFolderList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		fl.SetSize(msg.Width, msg.Height)
+		return fl, nil
+	case tea.KeyMsg:
+		if fl.list.FilterState() == list.Filtering {
+			newList, cmd := fl.list.Update(msg)
+			fl.list = newList
+			return fl, cmd
+		}
+		switch {
+		case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
+			if item, ok := fl.list.SelectedItem().(folderItem); ok {
+				fl.chosen = true
+				fl.selectedFolder = item.folder
+				return fl, nil
+			}
+		}
+	}
+	newList, cmd := fl.list.Update(msg)
+	fl.list = newList
+	return fl, cmd
+}
+
+func (fl *BitwardenFolderList) View() string {
+	return fl.list.View()
+}
+
+// IsChosen returns whether a folder, or the whole vault, was picked
+func (fl *BitwardenFolderList) IsChosen() bool {
+	return fl.chosen
+}
+
+// SelectedFolder returns the folder picked, nil for the whole vault
+func (fl *BitwardenFolderList) SelectedFolder() *config.Folder {
+	return fl.selectedFolder
+}
+
+func (fl *BitwardenFolderList) List() *list.Model { return &fl.list }
+
+func (fl *BitwardenFolderList) Reset() {
+	fl.chosen = false
+	fl.selectedFolder = nil
+}
+
+func (fl *BitwardenFolderList) SetSize(width, height int) {
+	if width <= 0 {
+		width = 60
+	}
+	if height <= 0 {
+		height = 20
+	}
+	fl.list.SetWidth(width)
+	// Use full available height for the list
+	fl.list.SetHeight(height)
+}
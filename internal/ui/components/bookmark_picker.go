@@ -0,0 +1,163 @@
+package components
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// BookmarkPicker lists the bookmarked directories of a connection in the
+// file manager, to jump to one or bookmark the directory of a panel
+type BookmarkPicker struct {
+	bookmarks   []config.Bookmark
+	current     config.Bookmark // Directory of the active panel, bookmarked by a
+	selectedIdx int
+	chosen      *config.Bookmark
+	changed     bool
+	canceled    bool
+	error       string
+	width       int
+	height      int
+}
+
+// NewBookmarkPicker opens the bookmarks of a connection, as stored in
+// SSHConnection.Bookmarks. current is the directory of the active panel.
+func NewBookmarkPicker(bookmarks []string, current config.Bookmark) *BookmarkPicker {
+	p := &BookmarkPicker{current: current}
+	for _, b := range bookmarks {
+		p.bookmarks = append(p.bookmarks, config.ParseBookmark(b))
+	}
+	return p
+}
+
+func (p *BookmarkPicker) Init() tea.Cmd {
+	return nil
+}
+
+func (p *BookmarkPicker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.SetSize(msg.Width, msg.Height)
+		return p, nil
+	case tea.KeyMsg:
+		p.error = ""
+		switch key := msg.String(); key {
+		case "esc", "q", "b":
+			p.canceled = true
+		case "up", "k":
+			if p.selectedIdx > 0 {
+				p.selectedIdx--
+			}
+		case "down", "j":
+			if p.selectedIdx < len(p.bookmarks)-1 {
+				p.selectedIdx++
+			}
+		case "enter":
+			if len(p.bookmarks) > 0 {
+				p.chosen = &p.bookmarks[p.selectedIdx]
+			}
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			if i := int(key[0] - '1'); i < len(p.bookmarks) {
+				p.chosen = &p.bookmarks[i]
+			}
+		case "a":
+			if slices.Contains(p.bookmarks, p.current) {
+				p.error = "This directory is already bookmarked"
+				return p, nil
+			}
+			p.bookmarks = append(p.bookmarks, p.current)
+			p.selectedIdx = len(p.bookmarks) - 1
+			p.changed = true
+		case "d":
+			if len(p.bookmarks) > 0 {
+				p.bookmarks = slices.Delete(p.bookmarks, p.selectedIdx, p.selectedIdx+1)
+				p.selectedIdx = max(min(p.selectedIdx, len(p.bookmarks)-1), 0)
+				p.changed = true
+			}
+		}
+	}
+	return p, nil
+}
+
+func (p *BookmarkPicker) View() string {
+	var b strings.Builder
+	hintStyle := lipgloss.NewStyle().Foreground(colorInactive)
+
+	b.WriteString(sectionTitleStyle.Render("Bookmarks"))
+	b.WriteString("\n\n")
+	if len(p.bookmarks) == 0 {
+		b.WriteString(blurredStyle.Render("No bookmarks yet. Press a to bookmark the current directory."))
+		b.WriteString("\n")
+	}
+	for i, bookmark := range p.bookmarks {
+		side := "remote"
+		if bookmark.Local {
+			side = "local "
+		}
+		number := " "
+		if i < 9 {
+			number = fmt.Sprint(i + 1)
+		}
+		line := fmt.Sprintf("%s  %s  %s", number, side, truncate(bookmark.Path, 60))
+		if i == p.selectedIdx {
+			b.WriteString(focusedStyle.Bold(true).Render("> " + line))
+		} else {
+			b.WriteString(blurredStyle.Render("  " + line))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(hintStyle.Render("enter/1-9: go | a: bookmark " + truncate(p.current.Path, 30) + " | d: delete | esc: close"))
+
+	if p.error != "" {
+		b.WriteString("\n\n")
+		b.WriteString(errorStyle.Render(p.error))
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(80).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	return lipgloss.Place(p.width, max(p.height-3, 0), lipgloss.Center, lipgloss.Center, box)
+}
+
+func (p *BookmarkPicker) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Chosen returns the bookmark to jump to, if one was picked
+func (p *BookmarkPicker) Chosen() (config.Bookmark, bool) {
+	if p.chosen == nil {
+		return config.Bookmark{}, false
+	}
+	return *p.chosen, true
+}
+
+// Bookmarks returns the bookmarks as stored in SSHConnection.Bookmarks
+func (p *BookmarkPicker) Bookmarks() []string {
+	bookmarks := make([]string, len(p.bookmarks))
+	for i, b := range p.bookmarks {
+		bookmarks[i] = b.String()
+	}
+	return bookmarks
+}
+
+// IsChanged reports whether bookmarks were added or deleted
+func (p *BookmarkPicker) IsChanged() bool {
+	return p.changed
+}
+
+// IsDone reports whether the picker is closed, by choosing or not
+func (p *BookmarkPicker) IsDone() bool {
+	return p.chosen != nil || p.canceled
+}
@@ -0,0 +1,199 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+// BulkEditForm picks one field and the value to set it to on all the
+// connections selected in the connection list
+type BulkEditForm struct {
+	connections []config.SSHConnection
+	field       int
+	textInput   textinput.Model
+	submitted   bool
+	canceled    bool
+	ErrorMsg    string
+	width       int
+	height      int
+}
+
+// NewBulkEditForm creates a form editing connections
+func NewBulkEditForm(connections []config.SSHConnection) *BulkEditForm {
+	ti := textinput.New()
+	ti.Focus()
+	ti.CharLimit = 255
+	ti.Width = 44
+	ti.Prompt = "> "
+	ti.PromptStyle = focusedStyle
+	ti.TextStyle = focusedStyle
+
+	f := &BulkEditForm{connections: connections, textInput: ti}
+	f.updatePlaceholder()
+	return f
+}
+
+func (f *BulkEditForm) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (f *BulkEditForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		f.SetSize(msg.Width, msg.Height)
+		return f, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			f.canceled = true
+			return f, nil
+		case "tab", "shift+tab":
+			step := 1
+			if msg.String() == "shift+tab" {
+				step = len(config.BulkFields) - 1
+			}
+			f.field = (f.field + step) % len(config.BulkFields)
+			f.textInput.SetValue("")
+			f.ErrorMsg = ""
+			f.updatePlaceholder()
+			return f, nil
+		case "enter":
+			if err := f.validate(); err != nil {
+				f.ErrorMsg = err.Error()
+				return f, nil
+			}
+			f.ErrorMsg = ""
+			f.submitted = true
+			return f, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	f.textInput, cmd = f.textInput.Update(msg)
+	return f, cmd
+}
+
+// validate checks the value on a copy of the first connection, and proxies
+// the way the connection form does
+func (f *BulkEditForm) validate() error {
+	if len(f.connections) == 0 {
+		return fmt.Errorf("no connections selected")
+	}
+	if _, err := config.ApplyBulkEdit(f.connections[0], f.Field(), f.Value()); err != nil {
+		return err
+	}
+	if f.Field() == config.BulkProxy && f.Value() != "" {
+		if _, err := ssh.ParseProxy(f.Value()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *BulkEditForm) updatePlaceholder() {
+	switch f.Field() {
+	case config.BulkUsername:
+		f.textInput.Placeholder = "New username"
+	case config.BulkPort:
+		f.textInput.Placeholder = "New port, e.g. 2222"
+	case config.BulkKeyFile:
+		f.textInput.Placeholder = "Path to SSH key, empty to clear"
+	case config.BulkProxy:
+		f.textInput.Placeholder = "socks5://[user@]host:port, empty to clear"
+	case config.BulkProxyCommand:
+		f.textInput.Placeholder = "ProxyCommand, empty to clear"
+	}
+}
+
+func (f *BulkEditForm) View() string {
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colorPrimary).
+		Render(fmt.Sprintf("Edit %d Connections", len(f.connections)))
+	hint := lipgloss.NewStyle().Foreground(colorInactive)
+
+	var fields []string
+	for i, field := range config.BulkFields {
+		if i == f.field {
+			fields = append(fields, focusedStyle.Bold(true).Render("● "+field.Label()))
+		} else {
+			fields = append(fields, blurredStyle.Render("○ "+field.Label()))
+		}
+	}
+
+	var names []string
+	for i, conn := range f.connections {
+		if i == 5 {
+			names = append(names, fmt.Sprintf("and %d more", len(f.connections)-i))
+			break
+		}
+		names = append(names, conn.Name)
+	}
+
+	parts := []string{
+		title,
+		"\n",
+		lipgloss.NewStyle().Foreground(colorSubText).Render(strings.Join(names, ", ")),
+		"\n",
+		strings.Join(fields, "  "),
+		"\n",
+		f.textInput.View(),
+		"\n",
+		hint.Render("Tab to pick the field, Enter to apply to all, Esc to cancel"),
+	}
+	if f.ErrorMsg != "" {
+		parts = append(parts, "\n", errorStyle.Render(f.ErrorMsg))
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(76).
+		Align(lipgloss.Center).
+		Render(lipgloss.JoinVertical(lipgloss.Center, parts...))
+
+	return lipgloss.Place(
+		f.width,
+		max(f.height-3, 0),
+		lipgloss.Center,
+		lipgloss.Center,
+		box,
+	)
+}
+
+func (f *BulkEditForm) SetSize(width, height int) {
+	f.width = width
+	f.height = height
+}
+
+func (f *BulkEditForm) IsSubmitted() bool {
+	return f.submitted
+}
+
+func (f *BulkEditForm) IsCanceled() bool {
+	return f.canceled
+}
+
+// Field returns the field to change
+func (f *BulkEditForm) Field() config.BulkField {
+	return config.BulkFields[f.field]
+}
+
+// Value returns the value to set the field to
+func (f *BulkEditForm) Value() string {
+	return strings.TrimSpace(f.textInput.Value())
+}
+
+// Connections returns the connections to edit
+func (f *BulkEditForm) Connections() []config.SSHConnection {
+	return f.connections
+}
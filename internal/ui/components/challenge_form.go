@@ -0,0 +1,170 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// ChallengeForm shows the prompts of a keyboard-interactive login, e.g. a
+// verification code, while the connection waits for the answers
+type ChallengeForm struct {
+	Connection  config.SSHConnection
+	name        string
+	instruction string
+	questions   []string
+	inputs      []textinput.Model
+	focusIndex  int
+	submitted   bool
+	canceled    bool
+	width       int
+	height      int
+}
+
+func NewChallengeForm(conn config.SSHConnection, name, instruction string, questions []string, echos []bool) *ChallengeForm {
+	inputs := make([]textinput.Model, len(questions))
+	for i := range questions {
+		inputs[i] = textinput.New()
+		inputs[i].Width = 50
+		inputs[i].PromptStyle = blurredStyle
+		inputs[i].TextStyle = blurredStyle
+		if !echos[i] {
+			inputs[i].EchoMode = textinput.EchoPassword
+		}
+	}
+	f := &ChallengeForm{
+		Connection:  conn,
+		name:        name,
+		instruction: instruction,
+		questions:   questions,
+		inputs:      inputs,
+	}
+	f.updateFocus()
+	return f
+}
+
+func (f *ChallengeForm) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (f *ChallengeForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		f.SetSize(msg.Width, msg.Height)
+		return f, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			f.canceled = true
+			return f, nil
+		case "enter":
+			// Enter moves through the prompts and answers after the last one
+			if f.focusIndex == len(f.inputs)-1 {
+				f.submitted = true
+				return f, nil
+			}
+			f.focusIndex++
+			f.updateFocus()
+			return f, nil
+		case "tab", "down":
+			f.focusIndex = (f.focusIndex + 1) % len(f.inputs)
+			f.updateFocus()
+			return f, nil
+		case "shift+tab", "up":
+			f.focusIndex = (f.focusIndex + len(f.inputs) - 1) % len(f.inputs)
+			f.updateFocus()
+			return f, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	f.inputs[f.focusIndex], cmd = f.inputs[f.focusIndex].Update(msg)
+	return f, cmd
+}
+
+func (f *ChallengeForm) updateFocus() {
+	for i := range f.inputs {
+		if i == f.focusIndex {
+			f.inputs[i].Focus()
+			f.inputs[i].PromptStyle = focusedStyle
+			f.inputs[i].TextStyle = focusedStyle
+		} else {
+			f.inputs[i].Blur()
+			f.inputs[i].PromptStyle = blurredStyle
+			f.inputs[i].TextStyle = blurredStyle
+		}
+	}
+}
+
+func (f *ChallengeForm) View() string {
+	var b strings.Builder
+	labelStyle := lipgloss.NewStyle().Foreground(colorSubText)
+
+	title := f.name
+	if title == "" {
+		title = fmt.Sprintf("Verification for '%s'", f.Connection.Name)
+	}
+	b.WriteString(sectionTitleStyle.Render(title))
+	b.WriteString("\n\n")
+	b.WriteString(labelStyle.Render(fmt.Sprintf("%s@%s asks:", f.Connection.Username, f.Connection.Host)))
+	b.WriteString("\n")
+	if instruction := strings.TrimSpace(f.instruction); instruction != "" {
+		b.WriteString(labelStyle.Render(instruction))
+		b.WriteString("\n")
+	}
+
+	for i, question := range f.questions {
+		b.WriteString("\n")
+		if i == f.focusIndex {
+			b.WriteString(focusedStyle.Render(strings.TrimSpace(question)))
+		} else {
+			b.WriteString(blurredStyle.Render(strings.TrimSpace(question)))
+		}
+		b.WriteString("\n")
+		b.WriteString(f.inputs[i].View())
+		b.WriteString("\n")
+	}
+
+	formBox := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(60).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	return lipgloss.Place(
+		f.width,
+		max(f.height-3, 0),
+		lipgloss.Center,
+		lipgloss.Center,
+		formBox,
+	)
+}
+
+func (f *ChallengeForm) SetSize(width, height int) {
+	f.width = width
+	f.height = height
+}
+
+func (f *ChallengeForm) IsSubmitted() bool {
+	return f.submitted
+}
+
+func (f *ChallengeForm) IsCanceled() bool {
+	return f.canceled
+}
+
+// Answers returns one answer per question
+func (f *ChallengeForm) Answers() []string {
+	answers := make([]string, len(f.inputs))
+	for i, input := range f.inputs {
+		answers[i] = input.Value()
+	}
+	return answers
+}
@@ -0,0 +1,400 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// CloudInstancesMsg carries the instances listed by a cloud provider's CLI
+type CloudInstancesMsg struct {
+	Provider  config.CloudProvider
+	Instances []config.CloudInstance
+	Err       error
+}
+
+// cloudStep is the page the cloud import is showing
+type cloudStep int
+
+const (
+	cloudStepProvider cloudStep = iota
+	cloudStepMapping
+	cloudStepLoading
+	cloudStepPreview
+)
+
+// Fields of the mapping page; the last one is the private address toggle
+const (
+	cloudFieldNameTag = iota
+	cloudFieldGroupTag
+	cloudFieldUsername
+	cloudFieldKeyFile
+	cloudFieldPrivateIP
+)
+
+// CloudImport lists the running instances of a cloud provider: pick the
+// provider, how tags map to names and how to log in, then choose instances
+// to import or connect to one without saving it
+type CloudImport struct {
+	existing     []config.SSHConnection
+	step         cloudStep
+	providerIdx  int
+	inputs       []textinput.Model
+	focused      int
+	privateIP    bool
+	candidates   []importCandidate
+	skipped      []string
+	selectedIdx  int
+	scrollOffset int
+	confirmed    bool
+	connectTo    *config.SSHConnection
+	canceled     bool
+	error        string
+	width        int
+	height       int
+}
+
+// NewCloudImport creates a cloud import. existing is used to flag instances
+// that are already saved.
+func NewCloudImport(existing []config.SSHConnection) *CloudImport {
+	placeholders := []string{
+		"Tag or label holding the name, e.g. Name (default: instance name)",
+		"Tag or label grouping instances, e.g. env (optional)",
+		"Username",
+		"SSH key file (optional, the ssh-agent is used otherwise)",
+	}
+	inputs := make([]textinput.Model, len(placeholders))
+	for i, placeholder := range placeholders {
+		inputs[i] = textinput.New()
+		inputs[i].Width = 50
+		inputs[i].Prompt = "> "
+		inputs[i].Placeholder = placeholder
+	}
+	return &CloudImport{existing: existing, inputs: inputs}
+}
+
+func (c *CloudImport) Init() tea.Cmd {
+	return nil
+}
+
+func (c *CloudImport) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		c.SetSize(msg.Width, msg.Height)
+		return c, nil
+	case CloudInstancesMsg:
+		c.loaded(msg)
+		return c, nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	switch c.step {
+	case cloudStepProvider:
+		if !ok {
+			return c, nil
+		}
+		switch keyMsg.String() {
+		case "up", "k":
+			if c.providerIdx > 0 {
+				c.providerIdx--
+			}
+		case "down", "j":
+			if c.providerIdx < len(config.CloudProviders)-1 {
+				c.providerIdx++
+			}
+		case "enter":
+			if c.inputs[cloudFieldUsername].Value() == "" {
+				c.inputs[cloudFieldUsername].SetValue(c.provider().DefaultUsername())
+			}
+			c.step = cloudStepMapping
+			return c, c.focus(cloudFieldNameTag)
+		case "esc", "q":
+			c.canceled = true
+		}
+		return c, nil
+
+	case cloudStepMapping:
+		if ok {
+			switch keyMsg.String() {
+			case "esc":
+				c.error = ""
+				c.focus(-1)
+				c.step = cloudStepProvider
+				return c, nil
+			case "tab", "down":
+				return c, c.focus((c.focused + 1) % (cloudFieldPrivateIP + 1))
+			case "shift+tab", "up":
+				return c, c.focus((c.focused + cloudFieldPrivateIP) % (cloudFieldPrivateIP + 1))
+			case " ":
+				if c.focused == cloudFieldPrivateIP {
+					c.privateIP = !c.privateIP
+					return c, nil
+				}
+			case "enter":
+				if strings.TrimSpace(c.inputs[cloudFieldUsername].Value()) == "" {
+					c.error = "Username is required"
+					return c, nil
+				}
+				return c, c.fetch()
+			}
+		}
+		if c.focused == cloudFieldPrivateIP {
+			return c, nil
+		}
+		var cmd tea.Cmd
+		c.inputs[c.focused], cmd = c.inputs[c.focused].Update(msg)
+		return c, cmd
+
+	case cloudStepLoading:
+		if ok && keyMsg.String() == "esc" {
+			// The listing finishes in the background and is ignored
+			c.step = cloudStepMapping
+			return c, c.focus(c.focused)
+		}
+		return c, nil
+	}
+
+	if !ok {
+		return c, nil
+	}
+	switch keyMsg.String() {
+	case "up", "k":
+		if c.selectedIdx > 0 {
+			c.selectedIdx--
+		}
+	case "down", "j":
+		if c.selectedIdx < len(c.candidates)-1 {
+			c.selectedIdx++
+		}
+	case " ", "x":
+		if len(c.candidates) > 0 {
+			c.candidates[c.selectedIdx].selected = !c.candidates[c.selectedIdx].selected
+		}
+	case "a":
+		// Select all new instances, or clear the selection if they already are
+		all := true
+		for _, candidate := range c.candidates {
+			if !candidate.duplicate && !candidate.selected {
+				all = false
+			}
+		}
+		for i := range c.candidates {
+			c.candidates[i].selected = !all && !c.candidates[i].duplicate
+		}
+	case "c":
+		if len(c.candidates) > 0 {
+			conn := c.candidates[c.selectedIdx].conn
+			c.connectTo = &conn
+		}
+	case "r":
+		return c, c.fetch()
+	case "enter":
+		if len(c.Selected()) == 0 {
+			c.error = "Nothing selected to import."
+			return c, nil
+		}
+		c.confirmed = true
+	case "esc":
+		c.error = ""
+		c.step = cloudStepMapping
+		return c, c.focus(c.focused)
+	}
+	return c, nil
+}
+
+func (c *CloudImport) provider() config.CloudProvider {
+	return config.CloudProviders[c.providerIdx]
+}
+
+// focus moves the cursor to field i of the mapping page, -1 blurs them all
+func (c *CloudImport) focus(i int) tea.Cmd {
+	for j := range c.inputs {
+		c.inputs[j].Blur()
+		c.inputs[j].PromptStyle = blurredStyle
+		c.inputs[j].TextStyle = blurredStyle
+	}
+	if i < 0 {
+		return nil
+	}
+	c.focused = i
+	if i >= len(c.inputs) {
+		return nil
+	}
+	c.inputs[i].PromptStyle = focusedStyle
+	c.inputs[i].TextStyle = focusedStyle
+	return c.inputs[i].Focus()
+}
+
+// fetch lists the provider's instances in the background
+func (c *CloudImport) fetch() tea.Cmd {
+	provider := c.provider()
+	c.error = ""
+	c.step = cloudStepLoading
+	return func() tea.Msg {
+		instances, err := config.ListCloudInstances(provider)
+		return CloudInstancesMsg{Provider: provider, Instances: instances, Err: err}
+	}
+}
+
+// mapping is the tag mapping and login set on the mapping page
+func (c *CloudImport) mapping() config.CloudMapping {
+	return config.CloudMapping{
+		NameTag:   strings.TrimSpace(c.inputs[cloudFieldNameTag].Value()),
+		GroupTag:  strings.TrimSpace(c.inputs[cloudFieldGroupTag].Value()),
+		Username:  strings.TrimSpace(c.inputs[cloudFieldUsername].Value()),
+		KeyFile:   strings.TrimSpace(c.inputs[cloudFieldKeyFile].Value()),
+		PrivateIP: c.privateIP,
+	}
+}
+
+// loaded shows the listed instances, unless the user went back meanwhile
+func (c *CloudImport) loaded(msg CloudInstancesMsg) {
+	if c.step != cloudStepLoading || msg.Provider != c.provider() {
+		return
+	}
+	if msg.Err != nil {
+		c.error = fmt.Sprintf("Failed to list %s instances: %s", msg.Provider, msg.Err)
+		c.step = cloudStepMapping
+		c.focus(c.focused)
+		return
+	}
+	result := config.CloudConnections(msg.Instances, c.mapping())
+	if len(result.Connections) == 0 {
+		c.error = fmt.Sprintf("No running %s instances found.", msg.Provider)
+		c.step = cloudStepMapping
+		c.focus(c.focused)
+		return
+	}
+
+	c.candidates = c.candidates[:0]
+	for _, conn := range result.Connections {
+		duplicate := config.IsDuplicateImport(c.existing, conn)
+		c.candidates = append(c.candidates, importCandidate{conn: conn, duplicate: duplicate, selected: !duplicate})
+	}
+	c.skipped = result.Skipped
+	c.selectedIdx, c.scrollOffset = 0, 0
+	c.focus(-1)
+	c.step = cloudStepPreview
+}
+
+func (c *CloudImport) View() string {
+	var b strings.Builder
+	labelStyle := lipgloss.NewStyle().Foreground(colorSubText)
+	hintStyle := lipgloss.NewStyle().Foreground(colorInactive)
+
+	switch c.step {
+	case cloudStepProvider:
+		b.WriteString(sectionTitleStyle.Render("Import from Cloud"))
+		b.WriteString("\n\n")
+		for i, provider := range config.CloudProviders {
+			if i == c.providerIdx {
+				b.WriteString(focusedStyle.Bold(true).Render("> " + provider.String()))
+			} else {
+				b.WriteString(blurredStyle.Render("  " + provider.String()))
+			}
+			b.WriteString("\n")
+			b.WriteString(hintStyle.Render("    " + provider.Description()))
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+		b.WriteString(hintStyle.Render("Instances are only listed, nothing is changed in the cloud."))
+
+	case cloudStepMapping:
+		b.WriteString(sectionTitleStyle.Render("Import from " + c.provider().String()))
+		b.WriteString("\n\n")
+		labels := []string{"Name tag", "Group tag", "Username", "Key file"}
+		for i, input := range c.inputs {
+			b.WriteString(labelStyle.Render(labels[i]))
+			b.WriteString("\n")
+			b.WriteString(input.View())
+			b.WriteString("\n")
+		}
+		checkbox := "[ ]"
+		if c.privateIP {
+			checkbox = "[x]"
+		}
+		toggle := checkbox + " Connect to private addresses"
+		if c.focused == cloudFieldPrivateIP {
+			b.WriteString(focusedStyle.Bold(true).Render("> " + toggle))
+		} else {
+			b.WriteString(blurredStyle.Render("  " + toggle))
+		}
+
+	case cloudStepLoading:
+		b.WriteString(sectionTitleStyle.Render("Import from " + c.provider().String()))
+		b.WriteString("\n\n")
+		b.WriteString(labelStyle.Render(fmt.Sprintf("Listing running instances with %s...", c.provider().Command())))
+
+	case cloudStepPreview:
+		b.WriteString(sectionTitleStyle.Render(fmt.Sprintf("%s: %d of %d selected",
+			c.provider(), len(c.Selected()), len(c.candidates))))
+		b.WriteString("\n\n")
+		rows := max(c.height-16, 3)
+		b.WriteString(renderImportCandidates(c.candidates, c.selectedIdx, &c.scrollOffset, rows))
+		if len(c.skipped) > 0 {
+			b.WriteString("\n")
+			b.WriteString(hintStyle.Render(truncate("Skipped: "+strings.Join(c.skipped, ", "), 70)))
+		}
+	}
+
+	if c.error != "" {
+		b.WriteString("\n\n")
+		b.WriteString(errorStyle.Render(c.error))
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(80).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	availableHeight := max(c.height-3, 0)
+	return lipgloss.Place(c.width, availableHeight, lipgloss.Center, lipgloss.Center, box)
+}
+
+func (c *CloudImport) SetSize(width, height int) {
+	c.width = width
+	c.height = height
+}
+
+// Selected returns the connections chosen for import
+func (c *CloudImport) Selected() []config.SSHConnection {
+	var conns []config.SSHConnection
+	for _, candidate := range c.candidates {
+		if candidate.selected {
+			conns = append(conns, candidate.conn)
+		}
+	}
+	return conns
+}
+
+// IsPreviewing reports whether the instance selection page is showing
+func (c *CloudImport) IsPreviewing() bool {
+	return c.step == cloudStepPreview
+}
+
+// IsEditingMapping reports whether the tag mapping page is showing
+func (c *CloudImport) IsEditingMapping() bool {
+	return c.step == cloudStepMapping
+}
+
+// ConnectTarget returns the instance to connect to without saving it, if
+// the user asked for one
+func (c *CloudImport) ConnectTarget() *config.SSHConnection {
+	return c.connectTo
+}
+
+func (c *CloudImport) IsConfirmed() bool {
+	return c.confirmed
+}
+
+func (c *CloudImport) IsCanceled() bool {
+	return c.canceled
+}
@@ -0,0 +1,164 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// CollectionModal asks for the name of a new or renamed Bitwarden
+// collection, then for confirmation before anything changes in the vault
+type CollectionModal struct {
+	textInput    textinput.Model
+	organization string
+	currentName  string // empty when creating a collection
+	confirming   bool
+	confirmed    bool
+	canceled     bool
+	width        int
+	height       int
+}
+
+func NewCollectionModal(organization, currentName string) *CollectionModal {
+	ti := textinput.New()
+	ti.SetValue(currentName)
+	ti.Placeholder = "Servers/Production"
+	ti.Focus()
+	ti.CharLimit = 100
+	ti.Width = 40
+	ti.Prompt = "Name: "
+	ti.PromptStyle = focusedStyle
+	ti.TextStyle = focusedStyle
+
+	return &CollectionModal{
+		textInput:    ti,
+		organization: organization,
+		currentName:  currentName,
+	}
+}
+
+func (m *CollectionModal) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m *CollectionModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.SetSize(msg.Width, msg.Height)
+		return m, nil
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			m.canceled = true
+			return m, nil
+		}
+		if m.confirming {
+			switch msg.String() {
+			case "y", "Y":
+				m.confirmed = true
+			case "n", "N", "esc":
+				// Back to the name
+				m.confirming = false
+			}
+			return m, nil
+		}
+		switch msg.String() {
+		case "enter":
+			if name := m.Value(); name != "" && name != m.currentName {
+				m.confirming = true
+			}
+			return m, nil
+		case "esc":
+			m.canceled = true
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+func (m *CollectionModal) View() string {
+	if m.canceled {
+		return ""
+	}
+
+	heading := "✚ New Collection"
+	if m.currentName != "" {
+		heading = "✏ Rename Collection"
+	}
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colorPrimary).
+		Render(heading)
+
+	details := lipgloss.NewStyle().
+		Foreground(colorSubText).
+		Render(fmt.Sprintf("Organization: %s", m.organization))
+
+	var body, hint string
+	if m.confirming {
+		question := fmt.Sprintf("Create collection %q?", m.Value())
+		if m.currentName != "" {
+			question = fmt.Sprintf("Rename %q to %q?", m.currentName, m.Value())
+		}
+		body = lipgloss.NewStyle().Bold(true).Foreground(colorPrimary).Render(question)
+		hint = "Press Y to confirm, N or Esc to go back"
+	} else {
+		body = m.textInput.View()
+		hint = "Press Enter to continue, Esc to cancel"
+	}
+	prompt := lipgloss.NewStyle().
+		Foreground(colorInactive).
+		Render(hint)
+
+	content := lipgloss.JoinVertical(lipgloss.Center,
+		title,
+		"\n",
+		details,
+		"\n",
+		body,
+		"\n\n",
+		prompt,
+	)
+
+	// Wrap in a bordered box
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(60).
+		Align(lipgloss.Center).
+		Render(content)
+
+	// Center on screen
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		box,
+	)
+}
+
+func (m *CollectionModal) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
+
+func (m *CollectionModal) IsConfirmed() bool {
+	return m.confirmed
+}
+
+func (m *CollectionModal) IsCanceled() bool {
+	return m.canceled
+}
+
+// Value returns the collection name entered
+func (m *CollectionModal) Value() string {
+	return strings.TrimSpace(m.textInput.Value())
+}
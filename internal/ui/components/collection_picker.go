@@ -0,0 +1,197 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// pickEntry is a collection offered by the CollectionPicker
+type pickEntry struct {
+	collection config.Collection
+	org        string // Name of the collection's organization
+	recent     bool
+}
+
+// CollectionPicker switches the connection list to another Bitwarden
+// collection without going back through the organization and collection
+// lists: the collections browsed recently come first, then those of the
+// organization loaded. Typing filters them by name.
+type CollectionPicker struct {
+	entries     []pickEntry
+	current     string // ID of the collection in view, left out
+	query       string
+	selectedIdx int
+	chosen      *config.Collection
+	canceled    bool
+	width       int
+	height      int
+}
+
+// NewCollectionPicker offers recent, then collections, with orgNames
+// naming the organizations by ID
+func NewCollectionPicker(recent []config.RecentCollection, collections []config.Collection, orgNames map[string]string, current string) *CollectionPicker {
+	p := &CollectionPicker{current: current}
+	seen := map[string]bool{current: true}
+	for _, r := range recent {
+		if seen[r.CollectionID] {
+			continue
+		}
+		seen[r.CollectionID] = true
+		org := r.OrganizationName
+		if name, ok := orgNames[r.OrganizationID]; ok {
+			org = name
+		}
+		p.entries = append(p.entries, pickEntry{collection: r.Collection(), org: org, recent: true})
+	}
+	for _, c := range collections {
+		if seen[c.ID] {
+			continue
+		}
+		seen[c.ID] = true
+		p.entries = append(p.entries, pickEntry{collection: c, org: orgNames[c.OrganizationID]})
+	}
+	return p
+}
+
+func (p *CollectionPicker) Init() tea.Cmd {
+	return nil
+}
+
+// visible returns the entries matching the query
+func (p *CollectionPicker) visible() []pickEntry {
+	if p.query == "" {
+		return p.entries
+	}
+	query := strings.ToLower(p.query)
+	var matches []pickEntry
+	for _, e := range p.entries {
+		if strings.Contains(strings.ToLower(e.collection.Name+" "+e.org), query) {
+			matches = append(matches, e)
+		}
+	}
+	return matches
+}
+
+func (p *CollectionPicker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.SetSize(msg.Width, msg.Height)
+	case tea.KeyMsg:
+		visible := p.visible()
+		switch key := msg.String(); key {
+		case "esc":
+			p.canceled = true
+		case "up", "ctrl+p":
+			if p.selectedIdx > 0 {
+				p.selectedIdx--
+			}
+		case "down", "ctrl+n":
+			if p.selectedIdx < len(visible)-1 {
+				p.selectedIdx++
+			}
+		case "enter":
+			if p.selectedIdx < len(visible) {
+				p.chosen = &visible[p.selectedIdx].collection
+			}
+		case "backspace":
+			if p.query != "" {
+				p.query = p.query[:len(p.query)-1]
+				p.selectedIdx = 0
+			}
+		case "1", "2", "3", "4", "5", "6", "7", "8", "9":
+			// Numbers pick recent collections until a filter is typed
+			if i := int(key[0] - '1'); p.query == "" && i < len(visible) && visible[i].recent {
+				p.chosen = &visible[i].collection
+				return p, nil
+			}
+			p.query += key
+			p.selectedIdx = 0
+		default:
+			if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+				p.query += string(msg.Runes)
+				p.selectedIdx = 0
+			}
+		}
+	}
+	return p, nil
+}
+
+func (p *CollectionPicker) View() string {
+	var b strings.Builder
+	hintStyle := lipgloss.NewStyle().Foreground(colorInactive)
+	groupStyle := lipgloss.NewStyle().Bold(true).Foreground(colorSubText)
+
+	b.WriteString(sectionTitleStyle.Render("Switch Collection"))
+	b.WriteString("\n\n")
+	b.WriteString(focusedStyle.Render("> " + p.query + "█"))
+	b.WriteString("\n\n")
+
+	visible := p.visible()
+	if len(visible) == 0 {
+		b.WriteString(blurredStyle.Render("No other collection matches."))
+		b.WriteString("\n")
+	}
+	group := ""
+	for i, e := range visible {
+		heading := e.org
+		if e.recent {
+			heading = "Recent"
+		}
+		if heading != group {
+			if group != "" {
+				b.WriteString("\n")
+			}
+			group = heading
+			b.WriteString(groupStyle.Render(heading) + "\n")
+		}
+		number := " "
+		if e.recent && p.query == "" && i < 9 {
+			number = fmt.Sprint(i + 1)
+		}
+		line := number + "  " + truncate(e.collection.Name, 40)
+		if e.recent && e.org != "" {
+			line += blurredStyle.Render("  " + e.org)
+		}
+		if i == p.selectedIdx {
+			b.WriteString(focusedStyle.Bold(true).Render("> " + line))
+		} else {
+			b.WriteString(blurredStyle.Render("  " + line))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(hintStyle.Render("type to filter | ↑/↓: move | enter/1-9: switch | esc: close"))
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(70).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	return lipgloss.Place(p.width, max(p.height-3, 0), lipgloss.Center, lipgloss.Center, box)
+}
+
+func (p *CollectionPicker) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Chosen returns the collection to switch to, if one was picked
+func (p *CollectionPicker) Chosen() (config.Collection, bool) {
+	if p.chosen == nil {
+		return config.Collection{}, false
+	}
+	return *p.chosen, true
+}
+
+// IsCanceled reports whether the picker was closed without switching
+func (p *CollectionPicker) IsCanceled() bool {
+	return p.canceled
+}
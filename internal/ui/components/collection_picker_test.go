@@ -0,0 +1,55 @@
+package components
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+func TestCollectionPicker(t *testing.T) {
+	recent := []config.RecentCollection{
+		{OrganizationID: "acme", OrganizationName: "Acme", CollectionID: "web", CollectionName: "Web"},
+		{OrganizationID: "other", OrganizationName: "Other Inc", CollectionID: "ops", CollectionName: "Ops"},
+		{OrganizationID: "acme", CollectionID: "db", CollectionName: "Databases"},
+	}
+	collections := []config.Collection{
+		{ID: "web", OrganizationID: "acme", Name: "Web"},
+		{ID: "db", OrganizationID: "acme", Name: "Databases"},
+		{ID: "lab", OrganizationID: "acme", Name: "Lab"},
+	}
+	orgs := map[string]string{"acme": "Acme Corp"}
+
+	// The collection in view is left out, recent ones come first
+	p := NewCollectionPicker(recent, collections, orgs, "web")
+	var names []string
+	for _, e := range p.entries {
+		names = append(names, e.collection.Name+"/"+e.org)
+	}
+	want := []string{"Ops/Other Inc", "Databases/Acme Corp", "Lab/Acme Corp"}
+	if len(names) != len(want) || names[0] != want[0] || names[1] != want[1] || names[2] != want[2] {
+		t.Fatalf("Expected %v, got %v", want, names)
+	}
+
+	p.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")})
+	if coll, ok := p.Chosen(); !ok || coll.ID != "db" || coll.OrganizationID != "acme" {
+		t.Errorf("Expected 2 to pick the second recent collection, got %+v", coll)
+	}
+
+	p = NewCollectionPicker(recent, collections, orgs, "web")
+	p.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("la")})
+	p.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if coll, ok := p.Chosen(); !ok || coll.ID != "lab" {
+		t.Errorf("Expected typing to filter down to Lab, got %+v", coll)
+	}
+
+	p = NewCollectionPicker(nil, collections, orgs, "")
+	p.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("3")})
+	if _, ok := p.Chosen(); ok {
+		t.Error("Expected numbers to filter when nothing is recent")
+	}
+	p.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if !p.IsCanceled() {
+		t.Error("Expected esc to close the picker")
+	}
+}
@@ -0,0 +1,636 @@
+package components
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+// commandRunnerWorkers caps how many hosts a command runs on at once
+const commandRunnerWorkers = 8
+
+// CommandHostResultMsg carries the outcome of a command on one host
+type CommandHostResultMsg struct {
+	RunID    int
+	Index    int
+	Result   *ssh.CommandResult
+	Err      error
+	Duration time.Duration
+}
+
+type commandRunnerPhase int
+
+const (
+	phaseSelectHosts commandRunnerPhase = iota
+	phaseResults
+)
+
+// hostRun is the state of the command on a single host
+type hostRun struct {
+	conn     config.SSHConnection
+	done     bool
+	result   *ssh.CommandResult
+	err      error
+	duration time.Duration
+	expanded bool
+}
+
+// CommandRunner executes one command over ssh exec on several connections
+// concurrently and shows the output of each host
+type CommandRunner struct {
+	connections  []config.SSHConnection
+	selected     map[int]bool
+	phase        commandRunnerPhase
+	input        textinput.Model
+	exportInput  textinput.Model
+	exporting    bool
+	confirmInput textinput.Model // Typed confirmation of a run on production hosts
+	confirming   bool
+	confirmWant  string                 // What has to be typed
+	pending      []config.SSHConnection // The hosts waiting for it
+	hostsFocused bool
+	cursor       int
+	scrollOffset int
+	command      string
+	runs         []hostRun
+	runID        int
+	started      time.Time
+	status       string
+	error        string
+	finished     bool
+	width        int
+	height       int
+}
+
+// NewCommandRunner creates the runner for connections, with highlighted
+// (when not nil) selected up front
+func NewCommandRunner(connections []config.SSHConnection, highlighted *config.SSHConnection) *CommandRunner {
+	input := textinput.New()
+	input.Placeholder = "uptime"
+	input.Prompt = "$ "
+	input.Focus()
+
+	exportInput := textinput.New()
+	exportInput.Prompt = ""
+
+	confirmInput := textinput.New()
+	confirmInput.Prompt = ""
+	confirmInput.CharLimit = 255
+
+	r := &CommandRunner{
+		connections:  connections,
+		selected:     make(map[int]bool),
+		input:        input,
+		exportInput:  exportInput,
+		confirmInput: confirmInput,
+		status:       "Select hosts and enter a command",
+	}
+	if highlighted != nil {
+		for i, conn := range connections {
+			if conn.ID == highlighted.ID {
+				r.selected[i] = true
+				r.cursor = i
+				break
+			}
+		}
+	}
+	return r
+}
+
+func (r *CommandRunner) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (r *CommandRunner) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		r.SetSize(msg.Width, msg.Height)
+		return r, nil
+
+	case CommandHostResultMsg:
+		// Results of an earlier run arriving after a rerun are dropped
+		if msg.RunID != r.runID || msg.Index >= len(r.runs) {
+			return r, nil
+		}
+		run := &r.runs[msg.Index]
+		run.done = true
+		run.result = msg.Result
+		run.err = msg.Err
+		run.duration = msg.Duration
+		r.updateStatus()
+		return r, nil
+
+	case tea.KeyMsg:
+		if r.confirming {
+			return r.handleConfirmKey(msg)
+		}
+		if r.exporting {
+			return r.handleExportKey(msg)
+		}
+		if r.phase == phaseResults {
+			return r.handleResultsKey(msg)
+		}
+		return r.handleSelectKey(msg)
+	}
+	return r, nil
+}
+
+func (r *CommandRunner) handleSelectKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		r.finished = true
+		return r, nil
+	case "tab", "shift+tab":
+		r.hostsFocused = !r.hostsFocused
+		if r.hostsFocused {
+			r.input.Blur()
+		} else {
+			r.input.Focus()
+		}
+		return r, nil
+	case "enter":
+		return r, r.start()
+	case "up":
+		if r.cursor > 0 {
+			r.cursor--
+		}
+		return r, nil
+	case "down":
+		if r.cursor < len(r.connections)-1 {
+			r.cursor++
+		}
+		return r, nil
+	}
+
+	if r.hostsFocused {
+		switch msg.String() {
+		case " ", "x":
+			r.selected[r.cursor] = !r.selected[r.cursor]
+		case "a":
+			// Select everything, or clear the selection when all are selected
+			all := len(r.selectedConnections()) < len(r.connections)
+			for i := range r.connections {
+				r.selected[i] = all
+			}
+		case "k":
+			if r.cursor > 0 {
+				r.cursor--
+			}
+		case "j":
+			if r.cursor < len(r.connections)-1 {
+				r.cursor++
+			}
+		}
+		return r, nil
+	}
+
+	var cmd tea.Cmd
+	r.input, cmd = r.input.Update(msg)
+	return r, cmd
+}
+
+func (r *CommandRunner) handleResultsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		// Back to host selection; running commands finish in the background
+		r.phase = phaseSelectHosts
+		r.runID++
+		r.hostsFocused = false
+		r.input.Focus()
+		r.scrollOffset = 0
+		r.status = "Select hosts and enter a command"
+	case "up", "k":
+		if r.cursor > 0 {
+			r.cursor--
+		}
+	case "down", "j":
+		if r.cursor < len(r.runs)-1 {
+			r.cursor++
+		}
+	case "enter", " ":
+		if r.cursor < len(r.runs) {
+			r.runs[r.cursor].expanded = !r.runs[r.cursor].expanded
+		}
+	case "E":
+		// Expand all, or collapse all when everything is expanded
+		expand := false
+		for _, run := range r.runs {
+			if !run.expanded {
+				expand = true
+				break
+			}
+		}
+		for i := range r.runs {
+			r.runs[i].expanded = expand
+		}
+	case "r":
+		return r, r.start()
+	case "e":
+		r.exporting = true
+		r.exportInput.SetValue(fmt.Sprintf("sxt-run-%s.txt", r.started.Format("20060102-150405")))
+		r.exportInput.CursorEnd()
+		return r, r.exportInput.Focus()
+	}
+	return r, nil
+}
+
+func (r *CommandRunner) handleExportKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		r.exporting = false
+		r.exportInput.Blur()
+		return r, nil
+	case "enter":
+		r.exporting = false
+		r.exportInput.Blur()
+		path := strings.TrimSpace(r.exportInput.Value())
+		if path == "" {
+			r.error = "No file name given"
+			return r, nil
+		}
+		if err := os.WriteFile(path, []byte(r.Report()), 0600); err != nil {
+			r.error = fmt.Sprintf("Export failed: %s", err)
+			return r, nil
+		}
+		r.status = fmt.Sprintf("Exported results to %s successfully", path)
+		return r, nil
+	}
+	var cmd tea.Cmd
+	r.exportInput, cmd = r.exportInput.Update(msg)
+	return r, cmd
+}
+
+func (r *CommandRunner) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		r.confirming = false
+		r.pending = nil
+		r.confirmInput.Blur()
+		r.status = "Run canceled"
+		if r.phase == phaseSelectHosts && !r.hostsFocused {
+			return r, r.input.Focus()
+		}
+		return r, nil
+	case "enter":
+		if strings.TrimSpace(r.confirmInput.Value()) != r.confirmWant {
+			r.error = fmt.Sprintf("Type %s to run on production hosts, Esc to cancel", r.confirmWant)
+			return r, nil
+		}
+		r.confirming = false
+		r.confirmInput.Blur()
+		conns := r.pending
+		r.pending = nil
+		return r, r.launch(conns)
+	}
+	var cmd tea.Cmd
+	r.confirmInput, cmd = r.confirmInput.Update(msg)
+	return r, cmd
+}
+
+// productionHosts returns the production connections among conns
+func productionHosts(conns []config.SSHConnection) []config.SSHConnection {
+	var production []config.SSHConnection
+	for _, conn := range conns {
+		if conn.DangerLevel == config.DangerProduction {
+			production = append(production, conn)
+		}
+	}
+	return production
+}
+
+// selectedConnections returns the selected connections in list order
+func (r *CommandRunner) selectedConnections() []config.SSHConnection {
+	var conns []config.SSHConnection
+	for i, conn := range r.connections {
+		if r.selected[i] {
+			conns = append(conns, conn)
+		}
+	}
+	return conns
+}
+
+// start runs the command on every selected host, or on the hosts of the
+// last run when rerunning. Production hosts among them have to be
+// confirmed first, like connecting to one: by typing its host name, or
+// "production" when there are several.
+func (r *CommandRunner) start() tea.Cmd {
+	command := strings.TrimSpace(r.input.Value())
+	if r.phase == phaseResults {
+		command = r.command
+	}
+	if command == "" {
+		r.error = "Enter a command to run"
+		return nil
+	}
+	var conns []config.SSHConnection
+	if r.phase == phaseResults {
+		for _, run := range r.runs {
+			conns = append(conns, run.conn)
+		}
+	} else {
+		conns = r.selectedConnections()
+	}
+	if len(conns) == 0 {
+		r.error = "Select at least one host (tab, then space)"
+		return nil
+	}
+	r.command = command
+
+	production := productionHosts(conns)
+	if len(production) == 0 {
+		return r.launch(conns)
+	}
+	r.confirmWant = "production"
+	if len(production) == 1 {
+		r.confirmWant = production[0].Host
+	}
+	r.confirming = true
+	r.pending = conns
+	r.input.Blur()
+	r.confirmInput.SetValue("")
+	return r.confirmInput.Focus()
+}
+
+// launch runs the command on conns, at most commandRunnerWorkers at a time
+func (r *CommandRunner) launch(conns []config.SSHConnection) tea.Cmd {
+	command := r.command
+	r.runID++
+	r.phase = phaseResults
+	r.input.Blur()
+	r.started = time.Now()
+	r.runs = make([]hostRun, len(conns))
+	r.cursor = 0
+	r.scrollOffset = 0
+	r.updateStatus()
+
+	workers := make(chan struct{}, commandRunnerWorkers)
+	cmds := make([]tea.Cmd, len(conns))
+	for i, conn := range conns {
+		r.runs[i].conn = conn
+		cmds[i] = runOnHost(r.runID, i, conn, command, workers)
+	}
+	return tea.Batch(cmds...)
+}
+
+// runOnHost executes command on conn once a slot in workers is free
+func runOnHost(runID, index int, conn config.SSHConnection, command string, workers chan struct{}) tea.Cmd {
+	return func() tea.Msg {
+		workers <- struct{}{}
+		defer func() { <-workers }()
+
+		start := time.Now()
+		msg := CommandHostResultMsg{RunID: runID, Index: index}
+		client, err := ssh.Connect(conn)
+		if err != nil {
+			msg.Err = err
+			msg.Duration = time.Since(start)
+			return msg
+		}
+		defer client.Close()
+
+		msg.Result, msg.Err = client.Run(command)
+		msg.Duration = time.Since(start)
+		return msg
+	}
+}
+
+func (r *CommandRunner) updateStatus() {
+	done, failed := 0, 0
+	for _, run := range r.runs {
+		if !run.done {
+			continue
+		}
+		done++
+		if run.err != nil || run.result.ExitStatus != 0 {
+			failed++
+		}
+	}
+	if done < len(r.runs) {
+		r.status = fmt.Sprintf("Running on %d hosts... %d/%d done", len(r.runs), done, len(r.runs))
+		return
+	}
+	r.status = fmt.Sprintf("Finished on %d hosts in %s, %d failed",
+		len(r.runs), time.Since(r.started).Round(time.Millisecond), failed)
+}
+
+// exitLabel describes how the command ended on a host
+func (run hostRun) exitLabel() string {
+	switch {
+	case !run.done:
+		return "running"
+	case run.err != nil:
+		return "error"
+	default:
+		return fmt.Sprintf("exit %d", run.result.ExitStatus)
+	}
+}
+
+// output is everything the host printed, or the error that prevented it
+func (run hostRun) output() string {
+	if run.err != nil {
+		return run.err.Error()
+	}
+	if run.result == nil {
+		return ""
+	}
+	out := strings.TrimRight(run.result.Stdout, "\n")
+	if stderr := strings.TrimRight(run.result.Stderr, "\n"); stderr != "" {
+		if out != "" {
+			out += "\n"
+		}
+		out += stderr
+	}
+	return out
+}
+
+// Report renders the results of the last run as plain text
+func (r *CommandRunner) Report() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Command: %s\n", r.command)
+	fmt.Fprintf(&b, "Started: %s\n", r.started.Format("2006-01-02 15:04:05 MST"))
+	for _, run := range r.runs {
+		fmt.Fprintf(&b, "\n=== %s (%s@%s:%d) - %s",
+			run.conn.Name, run.conn.Username, run.conn.Host, run.conn.Port, run.exitLabel())
+		if run.done {
+			fmt.Fprintf(&b, " in %s", run.duration.Round(time.Millisecond))
+		}
+		b.WriteString(" ===\n")
+		if out := run.output(); out != "" {
+			b.WriteString(out)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// bodyHeight is the number of rows available between header and status bar
+func (r *CommandRunner) bodyHeight() int {
+	return max(r.height-2, 1)
+}
+
+func (r *CommandRunner) View() string {
+	if r.finished {
+		return ""
+	}
+
+	headerText := fmt.Sprintf("Run Command - %d of %d hosts selected", len(r.selectedConnections()), len(r.connections))
+	if r.phase == phaseResults {
+		headerText = fmt.Sprintf("Run Command - %s", r.command)
+	}
+	header := scpHeaderStyle.Width(r.width).Render(truncate(headerText, max(r.width-2, 10)))
+
+	var lines []string
+	selectedLine := 0
+	if r.phase == phaseResults {
+		lines, selectedLine = r.resultLines()
+	} else {
+		lines, selectedLine = r.selectLines()
+	}
+
+	height := r.bodyHeight()
+	if selectedLine < r.scrollOffset {
+		r.scrollOffset = selectedLine
+	}
+	if selectedLine >= r.scrollOffset+height {
+		r.scrollOffset = selectedLine - height + 1
+	}
+	end := min(r.scrollOffset+height, len(lines))
+	visible := append([]string(nil), lines[min(r.scrollOffset, end):end]...)
+	for len(visible) < height {
+		visible = append(visible, "")
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, strings.Join(visible, "\n"), r.renderStatus())
+}
+
+// selectLines renders the command input and the host checklist, returning
+// the line of the cursor
+func (r *CommandRunner) selectLines() ([]string, int) {
+	lines := []string{r.input.View(), ""}
+	hostStyle := lipgloss.NewStyle()
+	if !r.hostsFocused {
+		hostStyle = hostStyle.Foreground(colorSubText)
+	}
+	for i, conn := range r.connections {
+		check := "[ ]"
+		if r.selected[i] {
+			check = "[x]"
+		}
+		line := fmt.Sprintf("%s %-24s %s@%s:%d", check, truncate(conn.Name, 24), conn.Username, conn.Host, conn.Port)
+		switch {
+		case i == r.cursor && r.hostsFocused:
+			line = scpSelectedStyle.Width(r.width).Render(line)
+		case r.selected[i]:
+			line = scpMarkedStyle.Render(line)
+		default:
+			line = hostStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(r.connections) == 0 {
+		lines = append(lines, "  (no connections)")
+	}
+	return lines, r.cursor + 2
+}
+
+// resultLines renders one row per host followed by the output of expanded
+// hosts, returning the line of the cursor
+func (r *CommandRunner) resultLines() ([]string, int) {
+	okStyle := lipgloss.NewStyle().Foreground(colorSuccess).Bold(true)
+	failStyle := lipgloss.NewStyle().Foreground(colorError).Bold(true)
+	outputStyle := lipgloss.NewStyle().Foreground(colorSubText)
+
+	var lines []string
+	selectedLine := 0
+	for i, run := range r.runs {
+		marker := "▸"
+		if run.expanded {
+			marker = "▾"
+		}
+		label := run.exitLabel()
+		duration := ""
+		if run.done {
+			duration = run.duration.Round(time.Millisecond).String()
+		}
+		line := fmt.Sprintf("%s %-24s %-10s %10s  %s@%s", marker, truncate(run.conn.Name, 24), label, duration, run.conn.Username, run.conn.Host)
+		switch {
+		case i == r.cursor:
+			line = scpSelectedStyle.Width(r.width).Render(line)
+		case !run.done:
+		case label == "exit 0":
+			line = okStyle.Render(line)
+		default:
+			line = failStyle.Render(line)
+		}
+		if i == r.cursor {
+			selectedLine = len(lines)
+		}
+		lines = append(lines, line)
+
+		if run.expanded && run.done {
+			out := run.output()
+			if out == "" {
+				out = "(no output)"
+			}
+			for _, l := range strings.Split(out, "\n") {
+				lines = append(lines, outputStyle.Render("    "+truncate(l, max(r.width-5, 10))))
+			}
+		}
+	}
+	return lines, selectedLine
+}
+
+func (r *CommandRunner) renderStatus() string {
+	containerStyle := scpStatusStyle.Width(r.width).Align(lipgloss.Center)
+	errStyle := lipgloss.NewStyle().Foreground(colorError).Bold(true)
+	successStyle := lipgloss.NewStyle().Foreground(colorSuccess).Bold(true)
+
+	switch {
+	case r.error != "":
+		text := errStyle.Render(r.error)
+		r.error = ""
+		return containerStyle.Render(text)
+	case r.confirming:
+		production := productionHosts(r.pending)
+		names := make([]string, len(production))
+		for i, conn := range production {
+			names[i] = conn.Name
+		}
+		prompt := fmt.Sprintf("⚠ Production: %s. Type %s to run: ", strings.Join(names, ", "), r.confirmWant)
+		return containerStyle.Render(errStyle.Render(prompt) + r.confirmInput.View())
+	case r.exporting:
+		return containerStyle.Render("Export to: " + r.exportInput.View())
+	case strings.Contains(r.status, "successfully"):
+		return containerStyle.Render(successStyle.Render(r.status))
+	}
+	return containerStyle.Render(r.status)
+}
+
+func (r *CommandRunner) SetSize(width, height int) {
+	r.width = width
+	r.height = height
+	r.input.Width = max(width-4, 10)
+	r.exportInput.Width = max(width/2, 20)
+	r.confirmInput.Width = max(width/3, 20)
+}
+
+func (r *CommandRunner) IsFinished() bool {
+	return r.finished
+}
+
+// ShowingResults reports whether the results of a run are on screen
+func (r *CommandRunner) ShowingResults() bool {
+	return r.phase == phaseResults
+}
+
+// IsTyping reports whether keys go to the command or export path input
+func (r *CommandRunner) IsTyping() bool {
+	return r.phase == phaseSelectHosts || r.exporting || r.confirming
+}
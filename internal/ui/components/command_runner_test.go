@@ -0,0 +1,104 @@
+package components
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+func TestCommandRunner(t *testing.T) {
+	conns := []config.SSHConnection{
+		{ID: "web", Name: "web", Host: "10.0.0.1", Port: 22, Username: "deploy"},
+		{ID: "db", Name: "db", Host: "10.0.0.2", Port: 22, Username: "deploy"},
+	}
+
+	t.Run("The highlighted connection is preselected", func(t *testing.T) {
+		r := NewCommandRunner(conns, &conns[1])
+		got := r.selectedConnections()
+		if len(got) != 1 || got[0].ID != "db" {
+			t.Errorf("Expected only db to be selected, got %v", got)
+		}
+		r.Update(tea.KeyMsg{Type: tea.KeyTab})
+		r.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'a'}})
+		if len(r.selectedConnections()) != 2 {
+			t.Error("Expected a to select every host")
+		}
+	})
+
+	t.Run("Running needs a command", func(t *testing.T) {
+		r := NewCommandRunner(conns, &conns[0])
+		if cmd := r.start(); cmd != nil || r.ShowingResults() {
+			t.Error("Expected an empty command not to start a run")
+		}
+	})
+
+	t.Run("Results are collected per host and stale ones dropped", func(t *testing.T) {
+		r := NewCommandRunner(conns, nil)
+		r.selected[0], r.selected[1] = true, true
+		r.input.SetValue("uptime")
+		if r.start() == nil || !r.ShowingResults() {
+			t.Fatal("Expected the run to start")
+		}
+
+		r.Update(CommandHostResultMsg{RunID: r.runID - 1, Index: 0, Result: &ssh.CommandResult{Stdout: "stale\n"}})
+		if r.runs[0].done {
+			t.Fatal("Expected a result from an earlier run to be ignored")
+		}
+		r.Update(CommandHostResultMsg{RunID: r.runID, Index: 0, Result: &ssh.CommandResult{Stdout: "up 3 days\n"}, Duration: time.Second})
+		r.Update(CommandHostResultMsg{RunID: r.runID, Index: 1, Result: &ssh.CommandResult{Stderr: "boom\n", ExitStatus: 2}})
+		if !strings.Contains(r.status, "1 failed") {
+			t.Errorf("Expected one failed host, got status %q", r.status)
+		}
+
+		report := r.Report()
+		for _, want := range []string{"Command: uptime", "web (deploy@10.0.0.1:22) - exit 0 in 1s", "up 3 days", "db (deploy@10.0.0.2:22) - exit 2", "boom"} {
+			if !strings.Contains(report, want) {
+				t.Errorf("Expected report to contain %q, got:\n%s", want, report)
+			}
+		}
+	})
+
+	t.Run("Connection errors are shown as output", func(t *testing.T) {
+		run := hostRun{done: true, err: errors.New("dial tcp: connection refused")}
+		if run.exitLabel() != "error" || run.output() != "dial tcp: connection refused" {
+			t.Errorf("Unexpected label %q or output %q", run.exitLabel(), run.output())
+		}
+	})
+	t.Run("Production hosts are confirmed before running", func(t *testing.T) {
+		prod := append([]config.SSHConnection{}, conns...)
+		prod[1].DangerLevel = config.DangerProduction
+		r := NewCommandRunner(prod, nil)
+		r.selected[0], r.selected[1] = true, true
+		r.input.SetValue("reboot")
+		r.start()
+		if r.ShowingResults() || !r.IsTyping() || r.confirmWant != "10.0.0.2" {
+			t.Fatalf("Expected the host name of db to be asked for, want %q", r.confirmWant)
+		}
+		r.confirmInput.SetValue("10.0.0.1")
+		r.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		if r.ShowingResults() {
+			t.Fatal("Expected the wrong host name not to run")
+		}
+		r.confirmInput.SetValue("10.0.0.2")
+		if _, cmd := r.Update(tea.KeyMsg{Type: tea.KeyEnter}); cmd == nil || !r.ShowingResults() || len(r.runs) != 2 {
+			t.Fatal("Expected the host name to run on both hosts")
+		}
+
+		// Rerunning asks again, and Esc cancels
+		r.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'r'}})
+		if !r.confirming {
+			t.Fatal("Expected a rerun to ask again")
+		}
+		runID := r.runID
+		r.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		if r.confirming || r.runID != runID {
+			t.Error("Expected Esc to cancel the rerun")
+		}
+	})
+}
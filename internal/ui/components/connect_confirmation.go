@@ -0,0 +1,171 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// ConnectionAction is what a connection of the list is opened for
+type ConnectionAction int
+
+const (
+	ActionConnect ConnectionAction = iota
+	ActionSCP
+	ActionProcesses
+	ActionServices
+	ActionPorts
+	ActionDeployKey
+)
+
+// verb says what confirming goes on to do, e.g. "open its processes"
+func (a ConnectionAction) verb() string {
+	switch a {
+	case ActionSCP:
+		return "open its files"
+	case ActionProcesses:
+		return "open its processes"
+	case ActionServices:
+		return "open its services"
+	case ActionPorts:
+		return "check its ports"
+	case ActionDeployKey:
+		return "deploy a key"
+	}
+	return "connect"
+}
+
+// ProductionConfirmedMsg lets an action other than connecting go ahead on
+// a production connection whose host name was typed
+type ProductionConfirmedMsg struct {
+	Action     ConnectionAction
+	Connection config.SSHConnection
+}
+
+// ConnectConfirmation asks for the host name to be typed before a session
+// to a production host is opened, or anything else reaching it
+type ConnectConfirmation struct {
+	textInput  textinput.Model
+	connection config.SSHConnection
+	action     ConnectionAction
+	mismatch   bool
+	confirmed  bool
+	canceled   bool
+	width      int
+	height     int
+}
+
+func NewConnectConfirmation(conn config.SSHConnection, action ConnectionAction) *ConnectConfirmation {
+	ti := textinput.New()
+	ti.Focus()
+	ti.CharLimit = 255
+	ti.Width = 40
+	ti.Prompt = "Host: "
+	ti.PromptStyle = focusedStyle
+	ti.TextStyle = focusedStyle
+
+	return &ConnectConfirmation{
+		textInput:  ti,
+		connection: conn,
+		action:     action,
+	}
+}
+
+func (m *ConnectConfirmation) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m *ConnectConfirmation) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.SetSize(msg.Width, msg.Height)
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			if strings.TrimSpace(m.textInput.Value()) == m.connection.Host {
+				m.confirmed = true
+			} else {
+				m.mismatch = true
+			}
+			return m, nil
+		case "esc", "ctrl+c":
+			m.canceled = true
+			return m, nil
+		}
+		m.mismatch = false
+	}
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+func (m *ConnectConfirmation) View() string {
+	if m.canceled {
+		return ""
+	}
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colorError).
+		Render("⚠ Production Host")
+
+	details := lipgloss.NewStyle().
+		Foreground(colorSubText).
+		Render(fmt.Sprintf("%s is a production host. Type %s to %s.", m.connection.Name, m.connection.Host, m.action.verb()))
+
+	prompt := lipgloss.NewStyle().
+		Foreground(colorInactive).
+		Render("Press Enter to " + m.action.verb() + ", Esc to cancel")
+	if m.mismatch {
+		prompt = lipgloss.NewStyle().
+			Foreground(colorError).
+			Render("That is not the host name")
+	}
+
+	content := lipgloss.JoinVertical(lipgloss.Center,
+		title,
+		"\n",
+		details,
+		"\n",
+		m.textInput.View(),
+		"\n\n",
+		prompt,
+	)
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorError).
+		Padding(1, 3).
+		Width(60).
+		Align(lipgloss.Center).
+		Render(content)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		box,
+	)
+}
+
+func (m *ConnectConfirmation) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
+
+func (m *ConnectConfirmation) IsConfirmed() bool {
+	return m.confirmed
+}
+
+func (m *ConnectConfirmation) IsCanceled() bool {
+	return m.canceled
+}
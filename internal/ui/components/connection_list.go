@@ -1,8 +1,10 @@
 package components
 
 import (
+	"cmp"
 	"fmt"
 	"io"
+	"log"
 	"slices"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -10,6 +12,7 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
 )
 
 type ToggleOpenInNewTerminalMsg struct{}
@@ -39,7 +42,9 @@ type connectionItem struct {
 	connection config.SSHConnection
 }
 
-func (i connectionItem) FilterValue() string { return i.connection.Name + " " + i.connection.Host }
+func (i connectionItem) FilterValue() string {
+	return i.connection.Name + " " + i.connection.Host + " " + i.connection.Source
+}
 
 // connectionDelegate handles the rendering of each list item with dynamic widths
 type connectionDelegate struct {
@@ -48,6 +53,11 @@ type connectionDelegate struct {
 	userWidth int
 	portWidth int
 	authWidth int
+	// sourceWidth is 0 unless the list merges several storages
+	sourceWidth int
+
+	// marked holds the IDs of the connections selected for bulk edits
+	marked map[string]bool
 }
 
 func (d connectionDelegate) Height() int { return 1 }
@@ -68,15 +78,23 @@ func (d connectionDelegate) Render(w io.Writer, m list.Model, index int, listIte
 	authMethod := "Agent"
 	if conn.UsePassword {
 		authMethod = "Password"
+	} else if ssh.IsSecurityKey(conn.KeyFile) {
+		authMethod = "Security Key"
 	} else if conn.KeyFile != "" {
 		authMethod = "Key File"
 	}
 
 	// Format columns using the dynamic widths stored in the delegate
 	name := conn.Name
+	if conn.ViewOnly {
+		name = "🔒 " + name
+	}
 	if conn.Pinned {
 		name = "📌 " + name
 	}
+	if d.marked[conn.ID] {
+		name = "✓ " + name
+	}
 	name = truncate(name, d.nameWidth)
 	host := truncate(conn.Host, d.hostWidth)
 	user := truncate(conn.Username, d.userWidth)
@@ -99,10 +117,25 @@ func (d connectionDelegate) Render(w io.Writer, m list.Model, index int, listIte
 		lipgloss.NewStyle().Width(d.portWidth).Render(port),
 		lipgloss.NewStyle().Width(d.authWidth).Render(auth),
 	)
+	if d.sourceWidth > 0 {
+		row += lipgloss.NewStyle().Width(d.sourceWidth).Render(truncate(conn.Source, d.sourceWidth))
+	}
 
 	fmt.Fprint(w, style.Render(row))
 }
 
+// nameHeader labels the name column with the list order when it isn't
+// the manual one
+func nameHeader(sortMode string) string {
+	switch sortMode {
+	case "recent":
+		return "Name (recent)"
+	case "frequent":
+		return "Name (frequent)"
+	}
+	return "Name"
+}
+
 // Helper to truncate strings that are too long
 func truncate(s string, max int) string {
 	if max < 3 {
@@ -134,11 +167,40 @@ type ConnectionList struct {
 	showRenameModal bool
 	renameModal     *RenameModal
 
+	// Host details panel
+	showHostDetails bool
+	hostDetails     *HostDetailsModal
+
+	// Typed confirmation before connecting to a production host
+	showConnectConfirm bool
+	connectConfirm     *ConnectConfirmation
+	pendingConnect     *config.SSHConnection
+	pendingAction      ConnectionAction
+
+	// Local forward ports found taken when connecting
+	showPortConflict bool
+	portConflict     *PortConflictPrompt
+	checkingPorts    bool // The ports of a connection being opened are checked
+
 	// layout stores the current column widths for header rendering
 	layout connectionDelegate
+
+	// sortMode orders the connections below the pinned ones
+	sortMode string
+
+	// marked holds the IDs of the connections selected with space, shared
+	// with the delegate
+	marked map[string]bool
+
+	// stored holds the connections as saved, in list order. Connections has
+	// host range templates expanded into their instances.
+	stored []config.SSHConnection
 }
 
-func sortConnections(connections []config.SSHConnection) []config.SSHConnection {
+// sortConnections puts pinned connections first, then orders each group
+// by mode: "recent" and "frequent" put the most recently or most often used
+// connections first, anything else keeps the manual order
+func sortConnections(connections []config.SSHConnection, mode string) []config.SSHConnection {
 	sorted := make([]config.SSHConnection, len(connections))
 	copy(sorted, connections)
 	slices.SortStableFunc(sorted, func(a, b config.SSHConnection) int {
@@ -148,6 +210,19 @@ func sortConnections(connections []config.SSHConnection) []config.SSHConnection
 		if !a.Pinned && b.Pinned {
 			return 1
 		}
+		switch mode {
+		case "recent":
+			if c := cmp.Compare(b.LastConnected, a.LastConnected); c != 0 {
+				return c
+			}
+		case "frequent":
+			if c := cmp.Compare(b.ConnectCount, a.ConnectCount); c != 0 {
+				return c
+			}
+			if c := cmp.Compare(b.LastConnected, a.LastConnected); c != 0 {
+				return c
+			}
+		}
 		// If both have same pinned status, sort by order
 		if a.Order < b.Order {
 			return -1
@@ -168,7 +243,9 @@ func sortConnections(connections []config.SSHConnection) []config.SSHConnection
 }
 
 func NewConnectionList(connections []config.SSHConnection) *ConnectionList {
-	sorted := sortConnections(connections)
+	sortMode := config.CurrentSettings().ConnectionSort
+	stored := sortConnections(config.WithUsage(connections), sortMode)
+	sorted := config.ExpandHostRanges(stored)
 	items := make([]list.Item, len(sorted))
 	for i, conn := range sorted {
 		items[i] = connectionItem{connection: conn}
@@ -177,6 +254,7 @@ func NewConnectionList(connections []config.SSHConnection) *ConnectionList {
 	// Initial delegate with default widths (will be resized immediately)
 	defaultDelegate := connectionDelegate{
 		nameWidth: 20, hostWidth: 20, userWidth: 15, portWidth: 8, authWidth: 10,
+		marked: make(map[string]bool),
 	}
 
 	l := list.New(items, defaultDelegate, 80, 20)
@@ -195,8 +273,11 @@ func NewConnectionList(connections []config.SSHConnection) *ConnectionList {
 		list:              l,
 		Connections:       sorted,
 		highlightedConn:   highlighted,
-		openInNewTerminal: config.IsTmuxAvailable,
+		openInNewTerminal: config.ActiveMultiplexer != "" && config.CurrentSettings().MultiplexerOpenNew,
 		layout:            defaultDelegate,
+		sortMode:          sortMode,
+		marked:            defaultDelegate.marked,
+		stored:            stored,
 	}
 
 	// Trigger an initial layout calculation
@@ -210,6 +291,10 @@ func (cl *ConnectionList) Init() tea.Cmd { return nil }
 func (cl *ConnectionList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
+	if msg, ok := msg.(portConflictsMsg); ok {
+		return cl, cl.checkedPorts(msg)
+	}
+
 	// If delete confirmation is showing, delegate to it
 	if cl.showDeleteConfirm && cl.deleteConfirm != nil {
 		var confirmModel tea.Model
@@ -259,7 +344,7 @@ func (cl *ConnectionList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cl.showRenameModal = false
 			if cl.highlightedConn != nil {
 				renameMsg := RenameConnectionMsg{
-					Connection: *cl.highlightedConn,
+					Connection: cl.StoredConnection(*cl.highlightedConn),
 					NewName:    cl.renameModal.Value(),
 				}
 				cl.renameModal = nil
@@ -274,6 +359,72 @@ func (cl *ConnectionList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return cl, cmd
 	}
 
+	// If the production confirmation is showing, delegate to it
+	if cl.showConnectConfirm && cl.connectConfirm != nil {
+		var modalModel tea.Model
+		modalModel, cmd = cl.connectConfirm.Update(msg)
+		cl.connectConfirm = modalModel.(*ConnectConfirmation)
+
+		if cl.connectConfirm.IsConfirmed() {
+			conn, action := *cl.pendingConnect, cl.pendingAction
+			cl.showConnectConfirm = false
+			cl.connectConfirm = nil
+			cl.pendingConnect = nil
+			if action == ActionConnect {
+				return cl, cl.connect(conn)
+			}
+			return cl, func() tea.Msg { return ProductionConfirmedMsg{Action: action, Connection: conn} }
+		}
+		if cl.connectConfirm.IsCanceled() {
+			cl.showConnectConfirm = false
+			cl.connectConfirm = nil
+			cl.pendingConnect = nil
+		}
+
+		return cl, cmd
+	}
+
+	// If taken local forward ports are being reported, delegate to it
+	if cl.showPortConflict && cl.portConflict != nil {
+		var modalModel tea.Model
+		modalModel, cmd = cl.portConflict.Update(msg)
+		cl.portConflict = modalModel.(*PortConflictPrompt)
+
+		prompt := cl.portConflict
+		switch {
+		case prompt.IsMoved():
+			conn := prompt.Connection()
+			cl.selectedConn = &conn
+			if stored := cl.StoredConnection(conn); !stored.ViewOnly {
+				stored.LocalForward = movedForwards(stored.LocalForward, prompt.conflicts)
+				cmd = func() tea.Msg { return ForwardsChangedMsg{Connection: stored} }
+			}
+		case prompt.IsKept():
+			conn := prompt.connection
+			cl.selectedConn = &conn
+		}
+		if prompt.IsMoved() || prompt.IsKept() || prompt.IsCanceled() {
+			cl.showPortConflict = false
+			cl.portConflict = nil
+		}
+
+		return cl, cmd
+	}
+
+	// If host details are showing, delegate to them
+	if cl.showHostDetails && cl.hostDetails != nil {
+		var modalModel tea.Model
+		modalModel, cmd = cl.hostDetails.Update(msg)
+		cl.hostDetails = modalModel.(*HostDetailsModal)
+
+		if cl.hostDetails.IsCanceled() {
+			cl.showHostDetails = false
+			cl.hostDetails = nil
+		}
+
+		return cl, cmd
+	}
+
 	switch msg := msg.(type) {
 	case ToggleOpenInNewTerminalMsg:
 		return cl, nil
@@ -292,6 +443,16 @@ func (cl *ConnectionList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if cl.renameModal != nil {
 			cl.renameModal.SetSize(msg.Width, msg.Height)
 		}
+		// Also update host details if they exist
+		if cl.hostDetails != nil {
+			cl.hostDetails.SetSize(msg.Width, msg.Height)
+		}
+		if cl.connectConfirm != nil {
+			cl.connectConfirm.SetSize(msg.Width, msg.Height)
+		}
+		if cl.portConflict != nil {
+			cl.portConflict.SetSize(msg.Width, msg.Height)
+		}
 		return cl, nil
 
 	case tea.KeyMsg:
@@ -305,15 +466,23 @@ func (cl *ConnectionList) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, key.NewBinding(key.WithKeys("enter"))):
 			if selectedItem := cl.list.SelectedItem(); selectedItem != nil {
 				if connItem, ok := selectedItem.(connectionItem); ok {
-					cl.selectedConn = &connItem.connection
-					return cl, nil
+					if connItem.connection.DangerLevel == config.DangerProduction {
+						return cl, cl.ConfirmProduction(connItem.connection, ActionConnect)
+					}
+					return cl, cl.connect(connItem.connection)
 				}
 			}
 		case key.Matches(msg, key.NewBinding(key.WithKeys("d", "D"))):
 			// Show delete confirmation for highlighted connection
 			if cl.highlightedConn != nil {
-				cl.pendingDelete = cl.highlightedConn
-				cl.deleteConfirm = NewDeleteConfirmation(cl.highlightedConn.Name)
+				// Instances of a host range delete the whole template
+				conn := cl.StoredConnection(*cl.highlightedConn)
+				if !config.CurrentSettings().ConfirmDelete {
+					deleteMsg := DeleteConnectionMsg{Connection: conn}
+					return cl, func() tea.Msg { return deleteMsg }
+				}
+				cl.pendingDelete = &conn
+				cl.deleteConfirm = NewDeleteConfirmation(conn.Name)
 				cl.deleteConfirm.SetSize(cl.list.Width(), cl.list.Height())
 				cl.showDeleteConfirm = true
 				return cl, nil
@@ -343,15 +512,17 @@ func (cl *ConnectionList) View() string {
 	// We only render the Table Headers and the List itself.
 
 	// Construct Table Headers using the DYNAMIC layout widths
-	headers := lipgloss.NewStyle().PaddingLeft(2).Render(
-		lipgloss.JoinHorizontal(lipgloss.Left,
-			headerStyle.Width(cl.layout.nameWidth).Render("Name"),
-			headerStyle.Width(cl.layout.hostWidth).Render("Host"),
-			headerStyle.Width(cl.layout.userWidth).Render("User"),
-			headerStyle.Width(cl.layout.portWidth).Render("Port"),
-			headerStyle.Width(cl.layout.authWidth).Render("Auth Method"),
-		),
-	)
+	columns := []string{
+		headerStyle.Width(cl.layout.nameWidth).Render(nameHeader(cl.sortMode)),
+		headerStyle.Width(cl.layout.hostWidth).Render("Host"),
+		headerStyle.Width(cl.layout.userWidth).Render("User"),
+		headerStyle.Width(cl.layout.portWidth).Render("Port"),
+		headerStyle.Width(cl.layout.authWidth).Render("Auth Method"),
+	}
+	if cl.layout.sourceWidth > 0 {
+		columns = append(columns, headerStyle.Width(cl.layout.sourceWidth).Render("Source"))
+	}
+	headers := lipgloss.NewStyle().PaddingLeft(2).Render(lipgloss.JoinHorizontal(lipgloss.Left, columns...))
 
 	listView := lipgloss.JoinVertical(lipgloss.Left,
 		headers,
@@ -402,6 +573,45 @@ func (cl *ConnectionList) View() string {
 		)
 	}
 
+	// If the production confirmation is showing, overlay it on top
+	if cl.showConnectConfirm && cl.connectConfirm != nil {
+		return lipgloss.Place(
+			cl.list.Width(),
+			cl.list.Height(),
+			lipgloss.Center,
+			lipgloss.Center,
+			cl.connectConfirm.View(),
+			lipgloss.WithWhitespaceChars(" "),
+			lipgloss.WithWhitespaceForeground(lipgloss.Color("0")),
+		)
+	}
+
+	// If taken local forward ports are being reported, overlay it on top
+	if cl.showPortConflict && cl.portConflict != nil {
+		return lipgloss.Place(
+			cl.list.Width(),
+			cl.list.Height(),
+			lipgloss.Center,
+			lipgloss.Center,
+			cl.portConflict.View(),
+			lipgloss.WithWhitespaceChars(" "),
+			lipgloss.WithWhitespaceForeground(lipgloss.Color("0")),
+		)
+	}
+
+	// If host details are showing, overlay them on top
+	if cl.showHostDetails && cl.hostDetails != nil {
+		return lipgloss.Place(
+			cl.list.Width(),
+			cl.list.Height(),
+			lipgloss.Center,
+			lipgloss.Center,
+			cl.hostDetails.View(),
+			lipgloss.WithWhitespaceChars(" "),
+			lipgloss.WithWhitespaceForeground(lipgloss.Color("0")),
+		)
+	}
+
 	return listView
 }
 
@@ -427,6 +637,76 @@ func (cl *ConnectionList) IsShowingRenameModal() bool {
 	return cl.showRenameModal
 }
 
+// IsShowingConnectConfirm reports whether the host name of a production
+// host is being typed to connect
+func (cl *ConnectionList) IsShowingConnectConfirm() bool {
+	return cl.showConnectConfirm
+}
+
+// IsShowingPortConflict reports whether taken local forward ports of the
+// connection being opened are shown
+func (cl *ConnectionList) IsShowingPortConflict() bool {
+	return cl.showPortConflict
+}
+
+// ConfirmProduction asks for the host name of the production connection
+// conn to be typed before action goes ahead: connecting, or else a
+// ProductionConfirmedMsg
+func (cl *ConnectionList) ConfirmProduction(conn config.SSHConnection, action ConnectionAction) tea.Cmd {
+	cl.pendingConnect = &conn
+	cl.pendingAction = action
+	cl.connectConfirm = NewConnectConfirmation(conn, action)
+	cl.connectConfirm.SetSize(cl.list.Width(), cl.list.Height())
+	cl.showConnectConfirm = true
+	return cl.connectConfirm.Init()
+}
+
+// connect selects conn to be opened once its local forward ports are
+// checked, asking what to do about the taken ones first
+func (cl *ConnectionList) connect(conn config.SSHConnection) tea.Cmd {
+	if len(conn.LocalForward) == 0 {
+		cl.selectedConn = &conn
+		return nil
+	}
+	if cl.checkingPorts {
+		return nil
+	}
+	// Finding what holds a port runs lsof or netstat, so it's done in the
+	// background
+	cl.checkingPorts = true
+	return func() tea.Msg {
+		return portConflictsMsg{conn: conn, conflicts: ssh.LocalForwardConflicts(conn)}
+	}
+}
+
+// portConflictsMsg carries the taken LocalForward ports of a connection
+// being opened
+type portConflictsMsg struct {
+	conn      config.SSHConnection
+	conflicts []ssh.LocalForwardConflict
+}
+
+// checkedPorts connects, or reports the taken ports first, unless the list
+// was reset meanwhile
+func (cl *ConnectionList) checkedPorts(msg portConflictsMsg) tea.Cmd {
+	if !cl.checkingPorts {
+		return nil
+	}
+	cl.checkingPorts = false
+	if len(msg.conflicts) == 0 {
+		cl.selectedConn = &msg.conn
+		return nil
+	}
+	cl.portConflict = NewPortConflictPrompt(msg.conn, msg.conflicts)
+	cl.portConflict.SetSize(cl.list.Width(), cl.list.Height())
+	cl.showPortConflict = true
+	return cl.portConflict.Init()
+}
+
+func (cl *ConnectionList) IsShowingHostDetails() bool {
+	return cl.showHostDetails
+}
+
 func (cl *ConnectionList) ShowPassword(conn config.SSHConnection) {
 	entries := []PasswordEntry{}
 	if conn.Password != "" {
@@ -458,11 +738,33 @@ func (cl *ConnectionList) ShowRename() {
 	if cl.highlightedConn == nil {
 		return
 	}
-	cl.renameModal = NewRenameModal(cl.highlightedConn.Name, cl.highlightedConn.Host)
+	conn := cl.StoredConnection(*cl.highlightedConn)
+	cl.renameModal = NewRenameModal(conn.Name, conn.Host)
 	cl.renameModal.SetSize(cl.list.Width(), cl.list.Height())
 	cl.showRenameModal = true
 }
 
+// ShowHostDetails opens the host key and algorithm details recorded by the
+// last connection to the highlighted host
+func (cl *ConnectionList) ShowHostDetails() {
+	if cl.highlightedConn == nil {
+		return
+	}
+	var info *config.HostInfo
+	store, err := config.NewHostInfoStore()
+	if err == nil {
+		err = store.Load()
+	}
+	if err != nil {
+		log.Printf("Failed to load host info: %v", err)
+	} else if recorded, ok := store.Lookup(cl.highlightedConn.Host, cl.highlightedConn.Port); ok {
+		info = &recorded
+	}
+	cl.hostDetails = NewHostDetailsModal(*cl.highlightedConn, info)
+	cl.hostDetails.SetSize(cl.list.Width(), cl.list.Height())
+	cl.showHostDetails = true
+}
+
 func (cl *ConnectionList) Rename() tea.Cmd {
 	if cl.highlightedConn == nil {
 		return nil
@@ -472,20 +774,63 @@ func (cl *ConnectionList) Rename() tea.Cmd {
 }
 
 func (cl *ConnectionList) SetConnections(connections []config.SSHConnection) {
-	sorted := sortConnections(connections)
+	cl.stored = sortConnections(config.WithUsage(connections), cl.sortMode)
+	sorted := config.ExpandHostRanges(cl.stored)
 	cl.Connections = sorted
+	// Forget marks of connections that are gone
+	for id := range cl.marked {
+		if !slices.ContainsFunc(cl.stored, func(c config.SSHConnection) bool { return c.ID == id }) {
+			delete(cl.marked, id)
+		}
+	}
 	items := make([]list.Item, len(sorted))
 	for i, conn := range sorted {
 		items[i] = connectionItem{connection: conn}
 	}
 	cl.list.SetItems(items)
+	if width := cl.list.Width(); width > 0 {
+		cl.recalculateTableLayout(width)
+	}
+}
+
+// SortMode returns how the list is ordered, one of config.ConnectionSorts
+func (cl *ConnectionList) SortMode() string {
+	return cl.sortMode
+}
+
+// SetSortMode reorders the list, keeping the highlighted connection selected
+func (cl *ConnectionList) SetSortMode(mode string) {
+	if mode == cl.sortMode {
+		return
+	}
+	cl.sortMode = mode
+	var highlightedID, highlightedHost string
+	if cl.highlightedConn != nil {
+		highlightedID, highlightedHost = cl.highlightedConn.ID, cl.highlightedConn.Host
+	}
+	cl.SetConnections(cl.stored)
+	for i, conn := range cl.Connections {
+		if conn.ID == highlightedID && conn.Host == highlightedHost {
+			cl.list.Select(i)
+			cl.highlightedConn = &cl.Connections[i]
+			break
+		}
+	}
+}
+
+// CycleSortMode switches to the next order of config.ConnectionSorts and
+// returns it
+func (cl *ConnectionList) CycleSortMode() string {
+	i := slices.Index(config.ConnectionSorts, cl.sortMode)
+	cl.SetSortMode(config.ConnectionSorts[(i+1)%len(config.ConnectionSorts)])
+	return cl.sortMode
 }
 
 func (cl *ConnectionList) TogglePinned() tea.Cmd {
 	if cl.highlightedConn == nil {
 		return nil
 	}
-	conn := *cl.highlightedConn
+	conn := cl.StoredConnection(*cl.highlightedConn)
 	return func() tea.Msg { return TogglePinnedMsg{Connection: conn} }
 }
 
@@ -493,7 +838,7 @@ func (cl *ConnectionList) MoveUp() tea.Cmd {
 	if cl.highlightedConn == nil {
 		return nil
 	}
-	conn := *cl.highlightedConn
+	conn := cl.StoredConnection(*cl.highlightedConn)
 	return func() tea.Msg { return MoveConnectionUpMsg{Connection: conn} }
 }
 
@@ -501,14 +846,69 @@ func (cl *ConnectionList) MoveDown() tea.Cmd {
 	if cl.highlightedConn == nil {
 		return nil
 	}
-	conn := *cl.highlightedConn
+	conn := cl.StoredConnection(*cl.highlightedConn)
 	return func() tea.Msg { return MoveConnectionDownMsg{Connection: conn} }
 }
 
+// ToggleMarked selects or unselects the highlighted connection for a bulk
+// edit and moves on to the next one
+func (cl *ConnectionList) ToggleMarked() {
+	if cl.highlightedConn == nil {
+		return
+	}
+	id := cl.highlightedConn.ID
+	if cl.marked[id] {
+		delete(cl.marked, id)
+	} else {
+		cl.marked[id] = true
+	}
+	cl.list.CursorDown()
+	if item, ok := cl.list.SelectedItem().(connectionItem); ok {
+		cl.highlightedConn = &item.connection
+	}
+}
+
+// MarkedConnections returns the selected connections in list order. A
+// marked instance of a host range selects its template.
+func (cl *ConnectionList) MarkedConnections() []config.SSHConnection {
+	var marked []config.SSHConnection
+	for _, conn := range cl.stored {
+		if cl.marked[conn.ID] {
+			marked = append(marked, conn)
+		}
+	}
+	return marked
+}
+
+// StoredConnection returns the saved connection conn is listed for: the
+// template of an instance of a host range, or else conn itself
+func (cl *ConnectionList) StoredConnection(conn config.SSHConnection) config.SSHConnection {
+	if conn.HostRange == "" {
+		return conn
+	}
+	for _, stored := range cl.stored {
+		if stored.ID == conn.ID {
+			return stored
+		}
+	}
+	return conn
+}
+
+// MarkedCount returns how many connections are selected
+func (cl *ConnectionList) MarkedCount() int {
+	return len(cl.marked)
+}
+
+// ClearMarks unselects all connections
+func (cl *ConnectionList) ClearMarks() {
+	clear(cl.marked)
+}
+
 func (cl *ConnectionList) List() *list.Model { return &cl.list }
 
 func (cl *ConnectionList) Reset() {
 	cl.selectedConn = nil
+	cl.checkingPorts = false
 	cl.list.Select(0)
 	if len(cl.Connections) > 0 {
 		cl.highlightedConn = &cl.Connections[0]
@@ -540,6 +940,8 @@ func (cl *ConnectionList) recalculateTableLayout(totalWidth int) {
 	const (
 		minPortWidth = 12
 		minAuthWidth = 16
+		// maxSourceWidth caps the source column of merged lists
+		maxSourceWidth = 24
 	)
 
 	// Strategy:
@@ -547,7 +949,15 @@ func (cl *ConnectionList) recalculateTableLayout(totalWidth int) {
 	portW := minPortWidth
 	authW := minAuthWidth
 
-	remaining := availableWidth - portW - authW
+	// The source column fits the longest label, when storages are merged
+	sourceW := 0
+	for _, conn := range cl.stored {
+		if conn.Source != "" {
+			sourceW = max(sourceW, min(len(conn.Source)+3, maxSourceWidth))
+		}
+	}
+
+	remaining := availableWidth - portW - authW - sourceW
 
 	// Distribute remaining space:
 	// Name: 40%, Host: 35%, User: 25%
@@ -568,11 +978,13 @@ func (cl *ConnectionList) recalculateTableLayout(totalWidth int) {
 
 	// Create new delegate with calculated widths
 	newLayout := connectionDelegate{
-		nameWidth: nameW,
-		hostWidth: hostW,
-		userWidth: userW,
-		portWidth: portW,
-		authWidth: authW,
+		nameWidth:   nameW,
+		hostWidth:   hostW,
+		userWidth:   userW,
+		portWidth:   portW,
+		authWidth:   authW,
+		sourceWidth: sourceW,
+		marked:      cl.marked,
 	}
 
 	// Store layout for Header rendering
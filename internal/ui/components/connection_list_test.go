@@ -0,0 +1,250 @@
+package components
+
+import (
+	"net"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+func TestSortConnections(t *testing.T) {
+	connections := []config.SSHConnection{
+		{ID: "old", Name: "a", Order: 1, LastConnected: 100, ConnectCount: 9},
+		{ID: "new", Name: "b", Order: 2, LastConnected: 300, ConnectCount: 2},
+		{ID: "never", Name: "c", Order: 0},
+		{ID: "pinned", Name: "d", Order: 3, Pinned: true, LastConnected: 50, ConnectCount: 1},
+		{ID: "tie", Name: "e", Order: 4, LastConnected: 200, ConnectCount: 2},
+	}
+
+	for mode, want := range map[string][]string{
+		"manual":   {"pinned", "never", "old", "new", "tie"},
+		"recent":   {"pinned", "new", "tie", "old", "never"},
+		"frequent": {"pinned", "old", "new", "tie", "never"},
+	} {
+		var got []string
+		for _, conn := range sortConnections(connections, mode) {
+			got = append(got, conn.ID)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s: expected %v, got %v", mode, want, got)
+		}
+	}
+}
+
+func TestCycleSortModeKeepsHighlight(t *testing.T) {
+	cl := NewConnectionList([]config.SSHConnection{
+		{ID: "a", Name: "a", Order: 0},
+		{ID: "b", Name: "b", Order: 1, LastConnected: 10},
+	})
+	cl.list.Select(1)
+	cl.highlightedConn = &cl.Connections[1]
+
+	if mode := cl.CycleSortMode(); mode != "recent" {
+		t.Fatalf("Expected recent after manual, got %q", mode)
+	}
+	if cl.Connections[0].ID != "b" || cl.list.Index() != 0 || cl.HighlightedConnection().ID != "b" {
+		t.Errorf("Expected b first and still highlighted, got %+v at %d", cl.Connections, cl.list.Index())
+	}
+	cl.CycleSortMode()
+	if mode := cl.CycleSortMode(); mode != "manual" {
+		t.Errorf("Expected the order to wrap back to manual, got %q", mode)
+	}
+}
+
+func TestMarkedConnections(t *testing.T) {
+	cl := NewConnectionList([]config.SSHConnection{
+		{ID: "a", Name: "a", Order: 0},
+		{ID: "b", Name: "b", Order: 1},
+		{ID: "c", Name: "c", Order: 2},
+	})
+	cl.ToggleMarked() // a, moves to b
+	cl.ToggleMarked() // b, moves to c
+	cl.list.Select(0)
+	cl.highlightedConn = &cl.Connections[0]
+	cl.ToggleMarked() // unmarks a
+
+	var got []string
+	for _, conn := range cl.MarkedConnections() {
+		got = append(got, conn.ID)
+	}
+	if !slices.Equal(got, []string{"b"}) {
+		t.Errorf("Expected only b marked, got %v", got)
+	}
+
+	// Marks of removed connections are dropped on reload
+	cl.SetConnections([]config.SSHConnection{{ID: "a", Name: "a"}, {ID: "c", Name: "c"}})
+	if cl.MarkedCount() != 0 {
+		t.Errorf("Expected no marks after b was removed, got %d", cl.MarkedCount())
+	}
+}
+
+func TestHostRangeInstances(t *testing.T) {
+	cl := NewConnectionList([]config.SSHConnection{
+		{ID: "db", Name: "db", Host: "db.example.com", Order: 0},
+		{ID: "web", Name: "web[1-3]", Host: "web[1-3].example.com", Order: 1},
+	})
+
+	var got []string
+	for _, conn := range cl.Connections {
+		got = append(got, conn.Name)
+	}
+	if !slices.Equal(got, []string{"db", "web1", "web2", "web3"}) {
+		t.Fatalf("Expected the template expanded into three instances, got %v", got)
+	}
+
+	// Instances are edited, renamed and marked through their template
+	stored := cl.StoredConnection(cl.Connections[2])
+	if stored.Name != "web[1-3]" || stored.Host != "web[1-3].example.com" || stored.HostRange != "" {
+		t.Errorf("Expected the template, got %+v", stored)
+	}
+	cl.list.Select(2)
+	cl.highlightedConn = &cl.Connections[2]
+	cl.ToggleMarked()
+	marked := cl.MarkedConnections()
+	if len(marked) != 1 || marked[0].Host != "web[1-3].example.com" {
+		t.Errorf("Expected the template marked once, got %+v", marked)
+	}
+}
+
+func TestProductionConnectConfirmation(t *testing.T) {
+	cl := NewConnectionList([]config.SSHConnection{
+		{ID: "db", Name: "db", Host: "db1.prod.example.com", DangerLevel: config.DangerProduction},
+	})
+	press := func(keys ...string) {
+		for _, k := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+			if k == "enter" {
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			}
+			cl.Update(msg)
+		}
+	}
+
+	press("enter")
+	if !cl.IsShowingConnectConfirm() || cl.SelectedConnection() != nil {
+		t.Fatal("Expected enter to ask for the host name first")
+	}
+	press("db1", "enter")
+	if !cl.IsShowingConnectConfirm() || cl.SelectedConnection() != nil {
+		t.Fatal("Expected a wrong host name not to connect")
+	}
+	cl.connectConfirm.textInput.SetValue("")
+	press("db1.prod.example.com", "enter")
+	if cl.IsShowingConnectConfirm() || cl.SelectedConnection() == nil || cl.SelectedConnection().ID != "db" {
+		t.Errorf("Expected the typed host name to connect, got %+v", cl.SelectedConnection())
+	}
+}
+
+func TestProductionActionConfirmation(t *testing.T) {
+	conn := config.SSHConnection{ID: "db", Name: "db", Host: "db1.prod.example.com", DangerLevel: config.DangerProduction}
+	cl := NewConnectionList([]config.SSHConnection{conn})
+
+	cl.ConfirmProduction(conn, ActionSCP)
+	if !cl.IsShowingConnectConfirm() {
+		t.Fatal("Expected the host name to be asked for first")
+	}
+	if view := cl.View(); !strings.Contains(view, "open its files") {
+		t.Errorf("Expected the action in the prompt, got:\n%s", view)
+	}
+	cl.connectConfirm.textInput.SetValue("db1.prod.example.com")
+	_, cmd := cl.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cl.IsShowingConnectConfirm() || cl.SelectedConnection() != nil {
+		t.Fatal("Expected the confirmation to close without connecting")
+	}
+	if cmd == nil {
+		t.Fatal("Expected the confirmed action to be sent")
+	}
+	if msg, ok := cmd().(ProductionConfirmedMsg); !ok || msg.Action != ActionSCP || msg.Connection.ID != "db" {
+		t.Errorf("Expected the SCP action confirmed for db, got %+v", msg)
+	}
+}
+
+func TestPortConflictPrompt(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+	spec := taken.Addr().String() + " db:5432"
+	newList := func() *ConnectionList {
+		return NewConnectionList([]config.SSHConnection{
+			{ID: "web", Name: "web", Host: "web.example.com", Port: 22, LocalForward: []string{spec}},
+		})
+	}
+	enter := func(cl *ConnectionList) {
+		_, cmd := cl.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		if cl.SelectedConnection() != nil || cmd == nil {
+			t.Fatal("Expected enter to check the ports before connecting")
+		}
+		cl.Update(cmd())
+	}
+
+	cl := newList()
+	enter(cl)
+	if !cl.IsShowingPortConflict() || cl.SelectedConnection() != nil {
+		t.Fatal("Expected enter to report the taken port first")
+	}
+	if view := cl.View(); !strings.Contains(view, "is in use") {
+		t.Errorf("Expected the taken port in the prompt, got:\n%s", view)
+	}
+	_, cmd := cl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	conn := cl.SelectedConnection()
+	if cl.IsShowingPortConflict() || conn == nil || conn.LocalForward[0] == spec {
+		t.Fatalf("Expected y to connect on a free port, got %+v", conn)
+	}
+	if cmd == nil {
+		t.Fatal("Expected y to save the moved forward")
+	}
+	if saved, ok := cmd().(ForwardsChangedMsg); !ok || saved.Connection.LocalForward[0] != conn.LocalForward[0] {
+		t.Errorf("Expected the moved forward saved, got %+v", saved)
+	}
+
+	cl = NewConnectionList([]config.SSHConnection{
+		{ID: "web", Name: "web", Host: "web.example.com", Port: 22, LocalForward: []string{spec}, ViewOnly: true},
+	})
+	enter(cl)
+	_, cmd = cl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if conn := cl.SelectedConnection(); conn == nil || conn.LocalForward[0] == spec {
+		t.Fatalf("Expected y to connect a view-only connection on a free port, got %+v", conn)
+	}
+	if cmd != nil {
+		t.Errorf("Expected the moved forward of a view-only connection not saved, got %+v", cmd())
+	}
+
+	cl = newList()
+	enter(cl)
+	cl.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if conn := cl.SelectedConnection(); conn == nil || conn.LocalForward[0] != spec {
+		t.Errorf("Expected n to connect with the forward as it is, got %+v", conn)
+	}
+
+	cl = newList()
+	enter(cl)
+	cl.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if cl.IsShowingPortConflict() || cl.SelectedConnection() != nil {
+		t.Error("Expected esc to cancel connecting")
+	}
+}
+
+func TestSourceColumn(t *testing.T) {
+	cl := NewConnectionList([]config.SSHConnection{{ID: "a", Name: "a", Host: "a.example.com"}})
+	cl.SetSize(140, 20)
+	if strings.Contains(cl.View(), "Source") || cl.layout.sourceWidth != 0 {
+		t.Error("Expected no source column for a single storage")
+	}
+
+	cl.SetConnections([]config.SSHConnection{
+		{ID: "a", Name: "a", Host: "a.example.com", Source: "Local"},
+		{ID: "b", Name: "b", Host: "b.example.com", Source: "Bitwarden:TeamX"},
+	})
+	view := cl.View()
+	if !strings.Contains(view, "Source") || !strings.Contains(view, "Bitwarden:TeamX") {
+		t.Errorf("Expected a source column in a merged list, got:\n%s", view)
+	}
+	if cl.list.SetFilterText("teamx"); len(cl.list.VisibleItems()) != 1 {
+		t.Errorf("Expected filtering by source, got %d items", len(cl.list.VisibleItems()))
+	}
+}
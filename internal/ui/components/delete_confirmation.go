@@ -1,21 +1,53 @@
 package components
 
 import (
+	"fmt"
+	"strings"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
 type DeleteConfirmation struct {
-	connectionName string
-	confirmed      bool
-	canceled       bool
-	width          int
-	height         int
+	title     string
+	message   string
+	target    string
+	trash     bool   // Y moves to the trash and D deletes permanently
+	counting  bool   // Confirming waits for the contents to be counted
+	contents  string // What the directories to delete hold
+	confirmed bool
+	permanent bool
+	canceled  bool
+	width     int
+	height    int
 }
 
 func NewDeleteConfirmation(connectionName string) *DeleteConfirmation {
 	return &DeleteConfirmation{
-		connectionName: connectionName,
+		title:     "Delete Connection",
+		message:   "Are you sure you want to delete this connection?",
+		target:    connectionName,
+		permanent: true,
+	}
+}
+
+// NewFileDeleteConfirmation asks before deleting files from location,
+// offering to move them to the trash instead when trash is set
+func NewFileDeleteConfirmation(files []string, location string, trash bool) *DeleteConfirmation {
+	what := "this file"
+	if len(files) > 1 {
+		what = fmt.Sprintf("these %d files", len(files))
+	}
+	target := strings.Join(files, ", ")
+	if len(files) > 5 {
+		target = strings.Join(files[:5], ", ") + fmt.Sprintf(" and %d more", len(files)-5)
+	}
+	return &DeleteConfirmation{
+		title:     "Delete Files",
+		message:   fmt.Sprintf("Are you sure you want to delete %s from %s?", what, location),
+		target:    target,
+		trash:     trash,
+		permanent: !trash,
 	}
 }
 
@@ -36,7 +68,15 @@ func (d *DeleteConfirmation) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		switch msg.String() {
 		case "y", "Y":
-			d.confirmed = true
+			if !d.counting {
+				d.confirmed = true
+			}
+			return d, nil
+		case "D":
+			if d.trash && !d.counting {
+				d.confirmed = true
+				d.permanent = true
+			}
 			return d, nil
 		case "n", "N", "esc":
 			d.canceled = true
@@ -58,36 +98,43 @@ func (d *DeleteConfirmation) View() string {
 	// Build the confirmation message
 	title := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("196")). // Red color for warning
-		Render("⚠ Delete Connection")
+		Foreground(colorError). // Red color for warning
+		Render("⚠ " + d.title)
 
 	message := lipgloss.NewStyle().
 		Foreground(colorSubText).
-		Render("Are you sure you want to delete this connection?")
+		Render(d.message)
 
-	connectionDisplay := lipgloss.NewStyle().
+	targetDisplay := lipgloss.NewStyle().
 		Bold(true).
 		Foreground(colorPrimary).
-		Render(d.connectionName)
-
+		Render(d.target)
+
+	promptText := "Press Y to confirm, N or Esc to cancel"
+	switch {
+	case d.counting:
+		promptText = "Counting the files to delete... N or Esc to cancel"
+	case d.trash:
+		promptText = "Press Y to move to the trash, D to delete permanently, N or Esc to cancel"
+	}
 	prompt := lipgloss.NewStyle().
 		Foreground(colorInactive).
-		Render("Press Y to confirm, N or Esc to cancel")
-
-	content := lipgloss.JoinVertical(lipgloss.Center,
-		title,
-		"\n",
-		message,
-		"\n",
-		connectionDisplay,
-		"\n\n",
-		prompt,
-	)
+		Render(promptText)
+
+	lines := []string{title, "\n", message, "\n", targetDisplay}
+	if d.contents != "" {
+		lines = append(lines, "\n", lipgloss.NewStyle().
+			Bold(true).
+			Foreground(colorWarning).
+			Render(d.contents))
+	}
+	lines = append(lines, "\n\n", prompt)
+	content := lipgloss.JoinVertical(lipgloss.Center, lines...)
 
 	// Wrap in a bordered box
 	box := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
-		BorderForeground(lipgloss.Color("196")). // Red border
+		BorderForeground(colorError). // Red border
 		Padding(1, 3).
 		Width(60).
 		Align(lipgloss.Center).
@@ -109,6 +156,26 @@ func (d *DeleteConfirmation) SetSize(width, height int) {
 	d.height = height
 }
 
+// CountContents keeps the dialog from being confirmed until SetContents
+// tells what the directories to delete hold
+func (d *DeleteConfirmation) CountContents() {
+	d.counting = true
+	d.contents = "Counting..."
+}
+
+// SetContents shows what the directories to delete hold, and lets the
+// dialog be confirmed
+func (d *DeleteConfirmation) SetContents(contents string) {
+	d.counting = false
+	d.contents = contents
+}
+
+// IsCounting reports whether the dialog waits for the contents to be
+// counted
+func (d *DeleteConfirmation) IsCounting() bool {
+	return d.counting
+}
+
 func (d *DeleteConfirmation) IsConfirmed() bool {
 	return d.confirmed
 }
@@ -116,3 +183,9 @@ func (d *DeleteConfirmation) IsConfirmed() bool {
 func (d *DeleteConfirmation) IsCanceled() bool {
 	return d.canceled
 }
+
+// IsPermanent reports whether the confirmed files are to be deleted rather
+// than moved to the trash
+func (d *DeleteConfirmation) IsPermanent() bool {
+	return d.permanent
+}
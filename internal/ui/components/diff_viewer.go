@@ -0,0 +1,254 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/diff"
+)
+
+// diffContext is how many unchanged lines surround each change
+const diffContext = 3
+
+// DiffViewer shows the differences between a local and a remote file,
+// unified or side by side, shown full screen by the SCP manager
+type DiffViewer struct {
+	localName   string
+	remoteName  string
+	hunks       []diff.Hunk
+	truncated   bool
+	sideBySide  bool
+	hunkOffsets []int // First row of each hunk in the rendered content
+	viewport    viewport.Model
+	closed      bool
+	width       int
+	height      int
+}
+
+// NewDiffViewer compares the local and remote contents. truncated reports
+// that only the first FileViewerLimit bytes of either file were read.
+func NewDiffViewer(localName, remoteName string, local, remote []byte, truncated bool) *DiffViewer {
+	expand := func(content []byte) []string {
+		return diff.SplitLines(strings.ReplaceAll(string(content), "\t", "    "))
+	}
+	v := &DiffViewer{
+		localName:  localName,
+		remoteName: remoteName,
+		hunks:      diff.Hunks(diff.Lines(expand(local), expand(remote)), diffContext),
+		truncated:  truncated,
+		viewport:   viewport.New(0, 0),
+	}
+	v.viewport.SetHorizontalStep(8)
+	v.render()
+	return v
+}
+
+// IsIdentical reports whether the files have no differences
+func (v *DiffViewer) IsIdentical() bool {
+	return len(v.hunks) == 0
+}
+
+// render lays out the hunks for the current layout and width
+func (v *DiffViewer) render() {
+	if v.IsIdentical() {
+		v.viewport.SetContent(lipgloss.NewStyle().Foreground(colorSubText).Render("  The files are identical"))
+		return
+	}
+	hunkStyle := lipgloss.NewStyle().Foreground(colorPrimary)
+	var rows []string
+	v.hunkOffsets = v.hunkOffsets[:0]
+	for _, h := range v.hunks {
+		v.hunkOffsets = append(v.hunkOffsets, len(rows))
+		rows = append(rows, hunkStyle.Render(h.Header()))
+		if v.sideBySide {
+			rows = append(rows, v.sideBySideRows(h)...)
+		} else {
+			rows = append(rows, v.unifiedRows(h)...)
+		}
+	}
+	v.viewport.SetContent(strings.Join(rows, "\n"))
+}
+
+// unifiedRows renders a hunk as in diff -u, with the line numbers of both
+// files in the gutter
+func (v *DiffViewer) unifiedRows(h diff.Hunk) []string {
+	numberStyle := lipgloss.NewStyle().Foreground(colorInactive)
+	rows := make([]string, 0, len(h.Lines))
+	for _, l := range h.Lines {
+		gutter := numberStyle.Render(fmt.Sprintf("%5s %5s │ ", lineNumber(l.A), lineNumber(l.B)))
+		rows = append(rows, gutter+diffLineStyle(l.Kind).Render(diffMarker(l.Kind)+l.Text))
+	}
+	return rows
+}
+
+// sideBySideRows renders a hunk in two columns, local on the left, pairing
+// the deleted lines of a change with the inserted ones
+func (v *DiffViewer) sideBySideRows(h diff.Hunk) []string {
+	column := max((v.viewport.Width-3)/2, 10)
+	numberStyle := lipgloss.NewStyle().Foreground(colorInactive)
+	cell := func(l *diff.Line, number int) string {
+		if l == nil {
+			return strings.Repeat(" ", column)
+		}
+		text := runewidth.Truncate(fmt.Sprintf("%5s %s", lineNumber(number), l.Text), column, "…")
+		text = runewidth.FillRight(text, column)
+		return numberStyle.Render(text[:5]) + diffLineStyle(l.Kind).Render(text[5:])
+	}
+
+	var rows []string
+	lines := h.Lines
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == diff.Equal {
+			rows = append(rows, cell(&lines[i], lines[i].A)+" │ "+cell(&lines[i], lines[i].B))
+			i++
+			continue
+		}
+		var deleted, inserted []*diff.Line
+		for ; i < len(lines) && lines[i].Kind != diff.Equal; i++ {
+			if lines[i].Kind == diff.Delete {
+				deleted = append(deleted, &lines[i])
+			} else {
+				inserted = append(inserted, &lines[i])
+			}
+		}
+		for j := range max(len(deleted), len(inserted)) {
+			left, right := cell(nil, 0), cell(nil, 0)
+			if j < len(deleted) {
+				left = cell(deleted[j], deleted[j].A)
+			}
+			if j < len(inserted) {
+				right = cell(inserted[j], inserted[j].B)
+			}
+			rows = append(rows, left+" │ "+right)
+		}
+	}
+	return rows
+}
+
+func lineNumber(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprint(n)
+}
+
+func diffMarker(kind diff.Kind) string {
+	switch kind {
+	case diff.Delete:
+		return "-"
+	case diff.Insert:
+		return "+"
+	}
+	return " "
+}
+
+func diffLineStyle(kind diff.Kind) lipgloss.Style {
+	switch kind {
+	case diff.Delete:
+		return lipgloss.NewStyle().Foreground(colorError)
+	case diff.Insert:
+		return lipgloss.NewStyle().Foreground(colorSuccess)
+	}
+	return lipgloss.NewStyle()
+}
+
+func (v *DiffViewer) Init() tea.Cmd {
+	return nil
+}
+
+func (v *DiffViewer) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.SetSize(msg.Width, msg.Height)
+		return v, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc":
+			v.closed = true
+			return v, nil
+		case "s":
+			v.sideBySide = !v.sideBySide
+			v.render()
+			return v, nil
+		case "n":
+			v.jumpHunk(1)
+			return v, nil
+		case "N":
+			v.jumpHunk(-1)
+			return v, nil
+		case "g", "home":
+			v.viewport.GotoTop()
+			return v, nil
+		case "G", "end":
+			v.viewport.GotoBottom()
+			return v, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	v.viewport, cmd = v.viewport.Update(msg)
+	return v, cmd
+}
+
+// jumpHunk scrolls to the next (1) or previous (-1) hunk
+func (v *DiffViewer) jumpHunk(dir int) {
+	top := v.viewport.YOffset
+	if dir > 0 {
+		for _, offset := range v.hunkOffsets {
+			if offset > top {
+				v.viewport.SetYOffset(offset)
+				return
+			}
+		}
+		return
+	}
+	for i := len(v.hunkOffsets) - 1; i >= 0; i-- {
+		if v.hunkOffsets[i] < top {
+			v.viewport.SetYOffset(v.hunkOffsets[i])
+			return
+		}
+	}
+}
+
+func (v *DiffViewer) View() string {
+	info := fmt.Sprintf("- %s\n+ %s", v.localName, v.remoteName)
+	if v.truncated {
+		info += fmt.Sprintf(" (first %s only)", formatSize(FileViewerLimit))
+	}
+	changes := fmt.Sprintf("Diff — %d changes", len(v.hunks))
+	if len(v.hunks) == 1 {
+		changes = "Diff — 1 change"
+	}
+	title := lipgloss.JoinVertical(lipgloss.Left,
+		sectionTitleStyle.Render(changes),
+		lipgloss.NewStyle().Foreground(colorSubText).Render(info),
+	)
+	hint := lipgloss.NewStyle().Foreground(colorSubText).
+		Render("↑/↓/pgup/pgdn: scroll | n/N: next/previous change | s: side by side/unified | q/esc: close")
+
+	return scpActivePanelStyle.
+		Width(max(v.width-2, 20)).
+		Render(lipgloss.JoinVertical(lipgloss.Left, title, "", v.viewport.View(), "", hint))
+}
+
+// SetSize sizes the viewer to fill width x height, border included
+func (v *DiffViewer) SetSize(width, height int) {
+	v.width = width
+	v.height = height
+	// Border and padding take 4 columns and 4 rows; title, hint and spacing 6 rows
+	v.viewport.Width = max(width-8, 10)
+	v.viewport.Height = max(height-10, 1)
+	if v.sideBySide {
+		v.render()
+	}
+}
+
+// IsClosed reports whether the user closed the viewer
+func (v *DiffViewer) IsClosed() bool {
+	return v.closed
+}
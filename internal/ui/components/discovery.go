@@ -0,0 +1,261 @@
+package components
+
+import (
+	"fmt"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+// discoveryTimeout is how long a scan waits for answers
+const discoveryTimeout = 3 * time.Second
+
+// DiscoveryResultMsg carries the SSH servers found on the local network
+type DiscoveryResultMsg struct {
+	Hosts []ssh.DiscoveredHost
+	Err   error
+}
+
+// DiscoveryView lists the SSH servers announced over mDNS on the local
+// network, to connect to one right away or save it as a connection
+type DiscoveryView struct {
+	hosts        []ssh.DiscoveredHost
+	selectedIdx  int
+	scrollOffset int
+	username     textinput.Model
+	editingUser  bool
+	usePassword  bool
+	scanning     bool
+	connectTo    *config.SSHConnection
+	saveAs       *config.SSHConnection
+	canceled     bool
+	error        string
+	width        int
+	height       int
+}
+
+// NewDiscoveryView creates the view, logging in as the local user by default
+func NewDiscoveryView() *DiscoveryView {
+	input := textinput.New()
+	input.Width = 30
+	input.Prompt = "> "
+	input.PromptStyle = focusedStyle
+	input.TextStyle = focusedStyle
+	input.Placeholder = "username"
+	if u, err := user.Current(); err == nil {
+		// Windows reports DOMAIN\user
+		name := u.Username
+		if i := strings.LastIndex(name, `\`); i >= 0 {
+			name = name[i+1:]
+		}
+		input.SetValue(name)
+	}
+	return &DiscoveryView{username: input}
+}
+
+func (d *DiscoveryView) Init() tea.Cmd {
+	return d.scan()
+}
+
+// scan browses the network in the background
+func (d *DiscoveryView) scan() tea.Cmd {
+	d.scanning = true
+	d.error = ""
+	return func() tea.Msg {
+		hosts, err := ssh.DiscoverSSHHosts(discoveryTimeout)
+		return DiscoveryResultMsg{Hosts: hosts, Err: err}
+	}
+}
+
+func (d *DiscoveryView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.SetSize(msg.Width, msg.Height)
+		return d, nil
+
+	case DiscoveryResultMsg:
+		d.scanning = false
+		if msg.Err != nil {
+			d.error = fmt.Sprintf("Discovery failed: %s", msg.Err)
+			return d, nil
+		}
+		d.hosts = msg.Hosts
+		d.selectedIdx = min(d.selectedIdx, max(len(d.hosts)-1, 0))
+		return d, nil
+
+	case tea.KeyMsg:
+		if d.editingUser {
+			switch msg.String() {
+			case "enter", "esc", "tab":
+				d.editingUser = false
+				d.username.Blur()
+				return d, nil
+			}
+			var cmd tea.Cmd
+			d.username, cmd = d.username.Update(msg)
+			return d, cmd
+		}
+
+		switch msg.String() {
+		case "up", "k":
+			if d.selectedIdx > 0 {
+				d.selectedIdx--
+			}
+		case "down", "j":
+			if d.selectedIdx < len(d.hosts)-1 {
+				d.selectedIdx++
+			}
+		case "u":
+			d.editingUser = true
+			return d, d.username.Focus()
+		case "p":
+			d.usePassword = !d.usePassword
+		case "r":
+			if !d.scanning {
+				return d, d.scan()
+			}
+		case "enter", "c":
+			if conn, ok := d.selected(); ok {
+				d.connectTo = &conn
+			}
+		case "s":
+			if conn, ok := d.selected(); ok {
+				d.saveAs = &conn
+			}
+		case "esc", "q":
+			d.canceled = true
+		}
+	}
+	return d, nil
+}
+
+// selected returns the highlighted host as a connection
+func (d *DiscoveryView) selected() (config.SSHConnection, bool) {
+	if len(d.hosts) == 0 {
+		return config.SSHConnection{}, false
+	}
+	username := strings.TrimSpace(d.username.Value())
+	if username == "" {
+		d.error = "Press u to enter the username to log in with."
+		return config.SSHConnection{}, false
+	}
+	host := d.hosts[d.selectedIdx]
+	return config.SSHConnection{
+		Name:        host.Name,
+		Host:        host.Address(),
+		Port:        host.Port,
+		Username:    username,
+		UsePassword: d.usePassword,
+		Notes:       "Discovered over mDNS as " + strings.TrimSuffix(host.Hostname, "."),
+	}, true
+}
+
+func (d *DiscoveryView) View() string {
+	var b strings.Builder
+	labelStyle := lipgloss.NewStyle().Foreground(colorSubText)
+	hintStyle := lipgloss.NewStyle().Foreground(colorInactive)
+
+	title := fmt.Sprintf("SSH Servers on the Local Network (%d)", len(d.hosts))
+	b.WriteString(sectionTitleStyle.Render(title))
+	b.WriteString("\n\n")
+
+	auth := "Agent / default key"
+	if d.usePassword {
+		auth = "Password"
+	}
+	b.WriteString(labelStyle.Render("Log in as "))
+	if d.editingUser {
+		b.WriteString(d.username.View())
+	} else {
+		b.WriteString(blurredStyle.Render(d.username.Value()))
+	}
+	b.WriteString(labelStyle.Render("  Auth: " + auth))
+	b.WriteString("\n\n")
+
+	switch {
+	case d.scanning && len(d.hosts) == 0:
+		b.WriteString(labelStyle.Render("Browsing _ssh._tcp and _sftp-ssh._tcp over mDNS..."))
+	case len(d.hosts) == 0:
+		b.WriteString(hintStyle.Render("No SSH servers announced themselves. Press r to scan again."))
+	default:
+		b.WriteString(d.renderHosts())
+		if d.scanning {
+			b.WriteString("\n")
+			b.WriteString(hintStyle.Render("Scanning..."))
+		}
+	}
+
+	if d.error != "" {
+		b.WriteString("\n\n")
+		b.WriteString(errorStyle.Render(d.error))
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(80).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	availableHeight := max(d.height-3, 0)
+	return lipgloss.Place(d.width, availableHeight, lipgloss.Center, lipgloss.Center, box)
+}
+
+func (d *DiscoveryView) renderHosts() string {
+	rows := max(d.height-16, 3)
+	if d.selectedIdx < d.scrollOffset {
+		d.scrollOffset = d.selectedIdx
+	}
+	if d.selectedIdx >= d.scrollOffset+rows {
+		d.scrollOffset = d.selectedIdx - rows + 1
+	}
+
+	var b strings.Builder
+	end := min(d.scrollOffset+rows, len(d.hosts))
+	for i := d.scrollOffset; i < end; i++ {
+		host := d.hosts[i]
+		target := fmt.Sprintf("%s:%d", host.Address(), host.Port)
+		line := fmt.Sprintf("%-24s %-22s %s", truncate(host.Name, 24), truncate(strings.TrimSuffix(host.Hostname, "."), 22), truncate(target, 24))
+		if i == d.selectedIdx {
+			b.WriteString(focusedStyle.Bold(true).Render("> " + line))
+		} else {
+			b.WriteString(blurredStyle.Render("  " + line))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (d *DiscoveryView) SetSize(width, height int) {
+	d.width = width
+	d.height = height
+}
+
+// IsTyping reports whether the username is being edited
+func (d *DiscoveryView) IsTyping() bool {
+	return d.editingUser
+}
+
+// ConnectTarget returns the host to connect to without saving it, if the
+// user asked for one
+func (d *DiscoveryView) ConnectTarget() *config.SSHConnection {
+	return d.connectTo
+}
+
+// SaveTarget returns the host to open in the connection form, if the user
+// asked to save one
+func (d *DiscoveryView) SaveTarget() *config.SSHConnection {
+	return d.saveAs
+}
+
+func (d *DiscoveryView) IsCanceled() bool {
+	return d.canceled
+}
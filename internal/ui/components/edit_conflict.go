@@ -0,0 +1,129 @@
+package components
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// ConflictResolution is how the user resolved an edit conflict
+type ConflictResolution int
+
+const (
+	ConflictUnresolved ConflictResolution = iota
+	ConflictReload                        // drop the edit and show their version
+	ConflictOverwrite                     // save the edit over their changes
+	ConflictMerge                         // keep their changes to fields the edit left alone
+	ConflictBack                          // go back to the form
+)
+
+// EditConflictDialog asks what to do when a shared Bitwarden item was
+// changed by someone else while it was being edited
+type EditConflictDialog struct {
+	conflict   *config.EditConflictError
+	resolution ConflictResolution
+	width      int
+	height     int
+}
+
+func NewEditConflictDialog(conflict *config.EditConflictError) *EditConflictDialog {
+	return &EditConflictDialog{conflict: conflict}
+}
+
+func (d *EditConflictDialog) Init() tea.Cmd {
+	return nil
+}
+
+func (d *EditConflictDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if d.resolution != ConflictUnresolved {
+		return d, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.SetSize(msg.Width, msg.Height)
+		return d, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "r", "R":
+			d.resolution = ConflictReload
+		case "o", "O":
+			d.resolution = ConflictOverwrite
+		case "m", "M":
+			d.resolution = ConflictMerge
+		case "esc", "ctrl+c":
+			d.resolution = ConflictBack
+		}
+	}
+
+	return d, nil
+}
+
+func (d *EditConflictDialog) View() string {
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colorWarning).
+		Render("⚠ Changed in Bitwarden")
+
+	message := lipgloss.NewStyle().
+		Foreground(colorSubText).
+		Render(truncate(d.conflict.Base.Name, 40) + " was changed by someone else\nsince it was loaded.")
+
+	fields := func(label string, names []string) string {
+		value := "nothing visible here"
+		if len(names) > 0 {
+			value = strings.Join(names, ", ")
+		}
+		return lipgloss.NewStyle().Bold(true).Render(label) + " " +
+			lipgloss.NewStyle().Foreground(colorAccent).Width(38).Render(value)
+	}
+	theirs := config.ChangedFields(d.conflict.Base, d.conflict.Theirs)
+	mine := config.ChangedFields(d.conflict.Base, d.conflict.Mine)
+
+	options := lipgloss.NewStyle().
+		Foreground(colorInactive).
+		Render("R reload their version   O overwrite with mine\nM merge (mine wins where both changed)   Esc back")
+
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		title,
+		"",
+		message,
+		"",
+		fields("They changed:", theirs),
+		fields("You changed: ", mine),
+		"",
+		options,
+	)
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorWarning).
+		Padding(1, 3).
+		Width(60).
+		Render(content)
+
+	availableHeight := max(d.height-3, 0)
+	return lipgloss.Place(
+		d.width,
+		availableHeight,
+		lipgloss.Center,
+		lipgloss.Center,
+		box,
+	)
+}
+
+func (d *EditConflictDialog) SetSize(width, height int) {
+	d.width = width
+	d.height = height
+}
+
+func (d *EditConflictDialog) Conflict() *config.EditConflictError {
+	return d.conflict
+}
+
+func (d *EditConflictDialog) Resolution() ConflictResolution {
+	return d.resolution
+}
@@ -0,0 +1,187 @@
+package components
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// ErrorView shows a failure that stopped sxt from going on, with its
+// probable cause and fix, and offers to retry, to go back or to open the
+// log
+type ErrorView struct {
+	title    string
+	err      error
+	cause    string
+	fix      string
+	canRetry bool
+	notice   string // Outcome of opening the log
+	retry    bool
+	back     bool
+	width    int
+	height   int
+}
+
+// errorHint is the probable cause and fix of errors mentioning any of match
+type errorHint struct {
+	match []string
+	cause string
+	fix   string
+}
+
+// errorHints are checked in order against the lowercased error
+var errorHints = []errorHint{
+	{[]string{"bitwarden cli", "`bw`"},
+		"The Bitwarden CLI (bw) isn't installed or isn't on PATH",
+		"Install it from https://bitwarden.com/help/cli/ and retry"},
+	{[]string{"keepassxc-cli"},
+		"The KeePassXC CLI isn't installed or isn't on PATH",
+		"Install KeePassXC 2.7 or later and retry"},
+	{[]string{"not logged in", "not authenticated", "vault is locked"},
+		"The Bitwarden session expired or the vault is locked",
+		"Go back to log in or unlock the vault again"},
+	{[]string{"org.freedesktop.secrets", "keyring", "secret service"},
+		"No system keyring is available to keep passwords in",
+		"Start a Secret Service provider such as gnome-keyring, or use key authentication"},
+	{[]string{"x509", "certificate"},
+		"The server's TLS certificate isn't trusted",
+		"Install its CA (VAULT_CACERT for Vault, NODE_EXTRA_CA_CERTS for bw) and retry"},
+	{[]string{"http 401", "http 403", "permission denied", "access denied"},
+		"Access was refused, by the file system or by the server",
+		"Check the file permissions, or the token's policies and role"},
+	{[]string{"no such file or directory", "cannot find the file"},
+		"A file sxt reads is missing",
+		"Check the paths in the settings and in the connection"},
+	{[]string{"connection refused"},
+		"Nothing is listening at the address",
+		"Check the address and port, and that the service runs"},
+	{[]string{"no such host", "server misbehaving"},
+		"The host name doesn't resolve",
+		"Check the name, and your DNS or VPN"},
+	{[]string{"i/o timeout", "deadline exceeded", "timed out"},
+		"The server didn't answer in time",
+		"Check your network, VPN or proxy and retry"},
+	{[]string{"yaml", "toml", "json", "parse", "invalid"},
+		"A file sxt reads isn't valid",
+		"Fix the line the error names and retry"},
+}
+
+// explainError returns the probable cause and fix of err, "" when there is
+// no hint for it
+func explainError(err error) (cause, fix string) {
+	text := strings.ToLower(err.Error())
+	for _, hint := range errorHints {
+		for _, match := range hint.match {
+			if strings.Contains(text, match) {
+				return hint.cause, hint.fix
+			}
+		}
+	}
+	return "", ""
+}
+
+// NewErrorView shows err under title; R retries when canRetry is set
+func NewErrorView(title string, err error, canRetry bool) *ErrorView {
+	cause, fix := explainError(err)
+	return &ErrorView{
+		title:    title,
+		err:      err,
+		cause:    cause,
+		fix:      fix,
+		canRetry: canRetry,
+	}
+}
+
+func (e *ErrorView) Init() tea.Cmd {
+	return nil
+}
+
+func (e *ErrorView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		e.SetSize(msg.Width, msg.Height)
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "r", "R":
+			if e.canRetry {
+				e.retry = true
+			}
+		case "l", "L":
+			e.openLog()
+		case "esc", "enter", "b":
+			e.back = true
+		}
+	}
+	return e, nil
+}
+
+// openLog opens the log file with the default application
+func (e *ErrorView) openLog() {
+	if config.LogFilePath == "" {
+		e.notice = "Logging is off, turn it on in the settings"
+		return
+	}
+	if err := openWithDefaultApp(config.LogFilePath); err != nil {
+		e.notice = "Cannot open the log: " + err.Error()
+		return
+	}
+	e.notice = "Opened " + config.LogFilePath
+}
+
+func (e *ErrorView) View() string {
+	var b strings.Builder
+	label := lipgloss.NewStyle().Bold(true).Foreground(colorSubText)
+
+	b.WriteString(lipgloss.NewStyle().Bold(true).Foreground(colorError).Render("✗ " + e.title))
+	b.WriteString("\n\n")
+	b.WriteString(e.err.Error())
+	b.WriteString("\n\n")
+	if e.cause != "" {
+		b.WriteString(label.Render("Probable cause: ") + e.cause + "\n")
+		b.WriteString(label.Render("Suggested fix:  ") + e.fix + "\n\n")
+	} else {
+		b.WriteString(label.Render("More details may be in the log.") + "\n\n")
+	}
+	if e.notice != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(colorSecondary).Render(e.notice) + "\n\n")
+	}
+
+	actions := "l: open log | esc: back"
+	if e.canRetry {
+		actions = "r: retry | " + actions
+	}
+	b.WriteString(lipgloss.NewStyle().Foreground(colorInactive).Render(actions))
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorError).
+		Padding(1, 3).
+		Width(min(80, max(e.width-4, 40))).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	return lipgloss.Place(e.width, max(e.height-3, 0), lipgloss.Center, lipgloss.Center, box)
+}
+
+func (e *ErrorView) SetSize(width, height int) {
+	e.width = width
+	e.height = height
+}
+
+// CanRetry reports whether R retries what failed
+func (e *ErrorView) CanRetry() bool {
+	return e.canRetry
+}
+
+// IsRetry reports whether R was pressed to try again
+func (e *ErrorView) IsRetry() bool {
+	return e.retry
+}
+
+// IsBack reports whether the view was left to go back
+func (e *ErrorView) IsBack() bool {
+	return e.back
+}
@@ -0,0 +1,56 @@
+package components
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestExplainError(t *testing.T) {
+	tests := []struct {
+		err   string
+		cause string
+	}{
+		{"Bitwarden CLI (`bw`) is not installed or not in your PATH", "Bitwarden CLI"},
+		{"vault token lookup failed: permission denied (HTTP 403)", "Access was refused"},
+		{"Failed to store password: The name org.freedesktop.secrets was not provided", "No system keyring"},
+		{"dial tcp: lookup vault.example.com: no such host", "doesn't resolve"},
+	}
+	for _, tt := range tests {
+		cause, fix := explainError(errors.New(tt.err))
+		if !strings.Contains(cause, tt.cause) || fix == "" {
+			t.Errorf("explainError(%q) = %q, %q; want a cause with %q", tt.err, cause, fix, tt.cause)
+		}
+	}
+	if cause, fix := explainError(errors.New("something odd")); cause != "" || fix != "" {
+		t.Errorf("Expected no hint for an unknown error, got %q, %q", cause, fix)
+	}
+}
+
+func TestErrorViewActions(t *testing.T) {
+	press := func(e *ErrorView, k string) {
+		e.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+
+	e := NewErrorView("Cannot load the connections", errors.New("i/o timeout"), false)
+	e.SetSize(100, 30)
+	press(e, "r")
+	if e.IsRetry() || strings.Contains(e.View(), "r: retry") {
+		t.Error("Expected no retry without a way to retry")
+	}
+	if !strings.Contains(e.View(), "didn't answer in time") {
+		t.Error("Expected the probable cause to be shown")
+	}
+	e.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if !e.IsBack() {
+		t.Error("Expected esc to go back")
+	}
+
+	e = NewErrorView("Cannot reach Bitwarden", errors.New("bw status failed"), true)
+	press(e, "r")
+	if !e.IsRetry() || e.IsBack() {
+		t.Error("Expected r to retry")
+	}
+}
@@ -0,0 +1,243 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// exportField is the row focused in the export form
+type exportField int
+
+const (
+	exportFieldScope exportField = iota
+	exportFieldFormat
+	exportFieldSecrets
+	exportFieldPath
+	exportFieldCount
+)
+
+const defaultExportBase = "~/sxt-connections"
+
+// ExportForm chooses what to export, in which format and where to write it
+type ExportForm struct {
+	highlighted *config.SSHConnection
+	total       int
+	onlyOne     bool
+	formatIdx   int
+	secrets     bool
+	pathInput   textinput.Model
+	focus       exportField
+	confirmed   bool
+	canceled    bool
+	error       string
+	width       int
+	height      int
+}
+
+// NewExportForm creates an export form. highlighted is the connection
+// offered for a single-connection export and may be nil.
+func NewExportForm(highlighted *config.SSHConnection, total int) *ExportForm {
+	input := textinput.New()
+	input.Width = 50
+	input.Prompt = "> "
+	input.PromptStyle = focusedStyle
+	input.TextStyle = focusedStyle
+	input.SetValue(defaultExportBase + config.ExportJSON.Extension())
+	return &ExportForm{highlighted: highlighted, total: total, pathInput: input}
+}
+
+func (f *ExportForm) Init() tea.Cmd {
+	return nil
+}
+
+func (f *ExportForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		f.SetSize(size.Width, size.Height)
+		return f, nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		if f.focus == exportFieldPath {
+			var cmd tea.Cmd
+			f.pathInput, cmd = f.pathInput.Update(msg)
+			return f, cmd
+		}
+		return f, nil
+	}
+
+	switch keyMsg.String() {
+	case "esc":
+		f.canceled = true
+		return f, nil
+	case "tab", "down":
+		return f, f.setFocus((f.focus + 1) % exportFieldCount)
+	case "shift+tab", "up":
+		return f, f.setFocus((f.focus + exportFieldCount - 1) % exportFieldCount)
+	case "enter":
+		if strings.TrimSpace(f.pathInput.Value()) == "" {
+			f.error = "Enter the file to write."
+			return f, f.setFocus(exportFieldPath)
+		}
+		f.confirmed = true
+		return f, nil
+	}
+
+	if f.focus == exportFieldPath {
+		var cmd tea.Cmd
+		f.pathInput, cmd = f.pathInput.Update(msg)
+		return f, cmd
+	}
+
+	switch keyMsg.String() {
+	case " ", "left", "right", "h", "l":
+		f.toggle(keyMsg.String() == "left" || keyMsg.String() == "h")
+	}
+	return f, nil
+}
+
+func (f *ExportForm) setFocus(field exportField) tea.Cmd {
+	f.focus = field
+	if field == exportFieldPath {
+		f.pathInput.Focus()
+		return textinput.Blink
+	}
+	f.pathInput.Blur()
+	return nil
+}
+
+// toggle changes the value of the focused row
+func (f *ExportForm) toggle(backwards bool) {
+	switch f.focus {
+	case exportFieldScope:
+		if f.highlighted != nil {
+			f.onlyOne = !f.onlyOne
+		}
+	case exportFieldFormat:
+		n := len(config.ExportFormats)
+		if backwards {
+			f.formatIdx = (f.formatIdx + n - 1) % n
+		} else {
+			f.formatIdx = (f.formatIdx + 1) % n
+		}
+		// Keep the extension in step with the format unless the path was changed
+		path := f.pathInput.Value()
+		for _, format := range config.ExportFormats {
+			if path == defaultExportBase+format.Extension() {
+				f.pathInput.SetValue(defaultExportBase + f.Format().Extension())
+				break
+			}
+		}
+		if !f.Format().SupportsSecrets() {
+			f.secrets = false
+		}
+	case exportFieldSecrets:
+		if f.Format().SupportsSecrets() {
+			f.secrets = !f.secrets
+		}
+	}
+}
+
+func (f *ExportForm) View() string {
+	var b strings.Builder
+
+	b.WriteString(sectionTitleStyle.Render("Export Connections"))
+	b.WriteString("\n\n")
+
+	scope := fmt.Sprintf("All connections (%d)", f.total)
+	if f.onlyOne && f.highlighted != nil {
+		scope = "Only " + truncate(f.highlighted.Name, 40)
+	}
+	b.WriteString(f.renderRow(exportFieldScope, "Export", scope))
+
+	b.WriteString(f.renderRow(exportFieldFormat, "Format", f.Format().String()))
+
+	secrets := "[ ] Strip passwords and passphrases"
+	if f.secrets {
+		secrets = "[x] Include passwords and passphrases"
+	}
+	if !f.Format().SupportsSecrets() {
+		secrets = "[ ] ssh_config cannot hold secrets"
+	}
+	b.WriteString(f.renderRow(exportFieldSecrets, "Secrets", secrets))
+
+	b.WriteString("\n")
+	if f.focus == exportFieldPath {
+		b.WriteString(focusedStyle.Render("File"))
+	} else {
+		b.WriteString(blurredStyle.Render("File"))
+	}
+	b.WriteString("\n")
+	b.WriteString(f.pathInput.View())
+
+	if f.secrets {
+		b.WriteString("\n\n")
+		b.WriteString(lipgloss.NewStyle().Foreground(colorAccent).Render("Secrets are written in plain text. Keep the file safe."))
+	}
+
+	if f.error != "" {
+		b.WriteString("\n\n")
+		b.WriteString(errorStyle.Render(f.error))
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(70).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	availableHeight := max(f.height-3, 0)
+	return lipgloss.Place(f.width, availableHeight, lipgloss.Center, lipgloss.Center, box)
+}
+
+func (f *ExportForm) renderRow(field exportField, label, value string) string {
+	line := fmt.Sprintf("%-8s %s", label+":", value)
+	if f.focus == field {
+		return focusedStyle.Bold(true).Render("> "+line) + "\n"
+	}
+	return blurredStyle.Render("  "+line) + "\n"
+}
+
+func (f *ExportForm) SetSize(width, height int) {
+	f.width = width
+	f.height = height
+}
+
+// Format is the selected export format
+func (f *ExportForm) Format() config.ExportFormat {
+	return config.ExportFormats[f.formatIdx]
+}
+
+// IncludeSecrets reports whether passwords should be written
+func (f *ExportForm) IncludeSecrets() bool {
+	return f.secrets && f.Format().SupportsSecrets()
+}
+
+// IDs returns the connections to export; nil means all of them
+func (f *ExportForm) IDs() []string {
+	if f.onlyOne && f.highlighted != nil {
+		return []string{f.highlighted.ID}
+	}
+	return nil
+}
+
+// Path is the file to write
+func (f *ExportForm) Path() string {
+	return strings.TrimSpace(f.pathInput.Value())
+}
+
+func (f *ExportForm) IsConfirmed() bool {
+	return f.confirmed
+}
+
+func (f *ExportForm) IsCanceled() bool {
+	return f.canceled
+}
@@ -0,0 +1,261 @@
+package components
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+const (
+	propertiesInputMode = iota
+	propertiesInputOwner
+	propertiesInputCount
+)
+
+// FilePropertiesForm shows the details of a file and edits its mode and
+// owner, like chmod and chown
+type FilePropertiesForm struct {
+	file       ssh.FileInfo
+	location   string
+	inputs     []textinput.Model
+	focusIndex int
+	submitted  bool
+	canceled   bool
+	ErrorMsg   string
+	width      int
+	height     int
+}
+
+// NewFilePropertiesForm creates the dialog for file, found at location
+func NewFilePropertiesForm(file ssh.FileInfo, location string) *FilePropertiesForm {
+	inputs := make([]textinput.Model, propertiesInputCount)
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Width = 20
+		inputs[i].Prompt = ""
+		inputs[i].PromptStyle = blurredStyle
+		inputs[i].TextStyle = blurredStyle
+	}
+	inputs[propertiesInputMode].Placeholder = "0644"
+	inputs[propertiesInputMode].CharLimit = 4
+	inputs[propertiesInputMode].SetValue(formatOctalMode(file.ModeBits))
+	inputs[propertiesInputOwner].Placeholder = "uid:gid"
+	if file.Owner != "-" {
+		inputs[propertiesInputOwner].SetValue(file.Owner + ":" + file.Group)
+	}
+
+	f := &FilePropertiesForm{file: file, location: location, inputs: inputs}
+	f.updateFocus()
+	return f
+}
+
+// formatOctalMode renders the permission bits of mode as chmod expects them,
+// setuid, setgid and sticky included
+func formatOctalMode(mode os.FileMode) string {
+	bits := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		bits |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		bits |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		bits |= 01000
+	}
+	return fmt.Sprintf("%04o", bits)
+}
+
+// parseOctalMode is the inverse of formatOctalMode
+func parseOctalMode(s string) (os.FileMode, error) {
+	bits, err := strconv.ParseUint(strings.TrimSpace(s), 8, 32)
+	if err != nil || bits > 07777 {
+		return 0, fmt.Errorf("mode must be octal, e.g. 644 or 0755")
+	}
+	mode := os.FileMode(bits & 0777)
+	if bits&04000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if bits&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if bits&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode, nil
+}
+
+// parseOwner reads "uid:gid" or a lone "uid", keeping the current group
+func parseOwner(s, currentGroup string) (int, int, error) {
+	owner, group, found := strings.Cut(strings.TrimSpace(s), ":")
+	if !found {
+		group = currentGroup
+	}
+	uid, err := strconv.Atoi(owner)
+	if err != nil || uid < 0 {
+		return 0, 0, fmt.Errorf("owner must be a numeric uid, e.g. 1000:1000")
+	}
+	gid, err := strconv.Atoi(group)
+	if err != nil || gid < 0 {
+		return 0, 0, fmt.Errorf("group must be a numeric gid, e.g. 1000:1000")
+	}
+	return uid, gid, nil
+}
+
+func (f *FilePropertiesForm) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (f *FilePropertiesForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		f.SetSize(msg.Width, msg.Height)
+		return f, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			f.canceled = true
+			return f, nil
+		case "enter":
+			if err := f.validate(); err != nil {
+				f.ErrorMsg = err.Error()
+				return f, nil
+			}
+			f.submitted = true
+			return f, nil
+		case "tab", "shift+tab", "up", "down":
+			f.focusIndex = 1 - f.focusIndex
+			f.updateFocus()
+			return f, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	f.inputs[f.focusIndex], cmd = f.inputs[f.focusIndex].Update(msg)
+	return f, cmd
+}
+
+func (f *FilePropertiesForm) updateFocus() {
+	for i := range f.inputs {
+		if i == f.focusIndex {
+			f.inputs[i].Focus()
+			f.inputs[i].PromptStyle = focusedStyle
+			f.inputs[i].TextStyle = focusedStyle
+		} else {
+			f.inputs[i].Blur()
+			f.inputs[i].PromptStyle = blurredStyle
+			f.inputs[i].TextStyle = blurredStyle
+		}
+	}
+}
+
+func (f *FilePropertiesForm) validate() error {
+	if _, err := parseOctalMode(f.inputs[propertiesInputMode].Value()); err != nil {
+		return err
+	}
+	if f.OwnerChanged() {
+		if _, _, err := parseOwner(f.inputs[propertiesInputOwner].Value(), f.file.Group); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *FilePropertiesForm) View() string {
+	var b strings.Builder
+	labelStyle := lipgloss.NewStyle().Foreground(colorSubText).Width(10)
+
+	kind := "File"
+	if f.file.IsDir {
+		kind = "Directory"
+	}
+	row := func(label, value string) {
+		b.WriteString(labelStyle.Render(label))
+		b.WriteString(value)
+		b.WriteString("\n")
+	}
+
+	b.WriteString(sectionTitleStyle.Render(f.file.Name))
+	b.WriteString("\n\n")
+	row("Path", f.location)
+	row("Type", kind)
+	if !f.file.IsDir {
+		row("Size", fmt.Sprintf("%s (%d bytes)", formatSize(f.file.Size), f.file.Size))
+	}
+	row("Modified", f.file.ModTime.Format("2006-01-02 15:04:05 MST"))
+	row("Mode", f.file.Perm)
+	row("Owner", f.file.Owner+":"+f.file.Group)
+	b.WriteString("\n")
+
+	labels := [propertiesInputCount]string{"chmod", "chown"}
+	for i := range f.inputs {
+		b.WriteString(labelStyle.Render(labels[i]))
+		b.WriteString(f.inputs[i].View())
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(colorSubText).
+		Render("tab: switch field | enter: apply | esc: cancel"))
+
+	if f.ErrorMsg != "" {
+		b.WriteString("\n\n")
+		b.WriteString(errorStyle.Render(f.ErrorMsg))
+	}
+
+	formBox := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(70).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	return lipgloss.Place(f.width, f.height, lipgloss.Center, lipgloss.Center, formBox)
+}
+
+func (f *FilePropertiesForm) SetSize(width, height int) {
+	f.width = width
+	f.height = height
+}
+
+func (f *FilePropertiesForm) IsSubmitted() bool {
+	return f.submitted
+}
+
+func (f *FilePropertiesForm) IsCanceled() bool {
+	return f.canceled
+}
+
+// File returns the file being edited
+func (f *FilePropertiesForm) File() ssh.FileInfo {
+	return f.file
+}
+
+// Mode returns the entered mode; only valid once submitted
+func (f *FilePropertiesForm) Mode() os.FileMode {
+	mode, _ := parseOctalMode(f.inputs[propertiesInputMode].Value())
+	return mode
+}
+
+// ModeChanged reports whether the entered mode differs from the file's
+func (f *FilePropertiesForm) ModeChanged() bool {
+	return f.Mode() != f.file.ModeBits&(os.ModePerm|os.ModeSetuid|os.ModeSetgid|os.ModeSticky)
+}
+
+// Owner returns the entered uid and gid; only valid once submitted
+func (f *FilePropertiesForm) Owner() (int, int) {
+	uid, gid, _ := parseOwner(f.inputs[propertiesInputOwner].Value(), f.file.Group)
+	return uid, gid
+}
+
+// OwnerChanged reports whether a different owner or group was entered
+func (f *FilePropertiesForm) OwnerChanged() bool {
+	value := strings.TrimSpace(f.inputs[propertiesInputOwner].Value())
+	return value != "" && value != f.file.Owner+":"+f.file.Group
+}
@@ -0,0 +1,53 @@
+package components
+
+import (
+	"os"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+func TestFileProperties(t *testing.T) {
+	t.Run("Octal modes round-trip", func(t *testing.T) {
+		for _, mode := range []os.FileMode{0644, 0755 | os.ModeSetuid, 0777 | os.ModeSticky, 0750 | os.ModeSetgid} {
+			got, err := parseOctalMode(formatOctalMode(mode))
+			if err != nil || got != mode {
+				t.Errorf("Round-trip of %v gave %v, %v", mode, got, err)
+			}
+		}
+		if got := formatOctalMode(os.ModeDir | os.ModeSetuid | 0755); got != "4755" {
+			t.Errorf("Expected 4755, got %s", got)
+		}
+		for _, bad := range []string{"", "9", "644x", "17777"} {
+			if _, err := parseOctalMode(bad); err == nil {
+				t.Errorf("Expected %q to be rejected", bad)
+			}
+		}
+	})
+
+	t.Run("Owner keeps the group when only a uid is given", func(t *testing.T) {
+		uid, gid, err := parseOwner("1001", "100")
+		if err != nil || uid != 1001 || gid != 100 {
+			t.Errorf("Expected 1001:100, got %d:%d, %v", uid, gid, err)
+		}
+		if _, _, err := parseOwner("root:wheel", "0"); err == nil {
+			t.Error("Expected names to be rejected")
+		}
+	})
+
+	t.Run("Only changed fields are applied", func(t *testing.T) {
+		file := ssh.FileInfo{Name: "run.sh", Perm: "-rwxr-xr-x", Owner: "1000", Group: "1000", ModeBits: 0755}
+		f := NewFilePropertiesForm(file, "/tmp/run.sh")
+		if f.ModeChanged() || f.OwnerChanged() {
+			t.Fatal("Expected an untouched form to have no changes")
+		}
+
+		f.inputs[propertiesInputMode].SetValue("700")
+		f.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		if !f.IsSubmitted() || !f.ModeChanged() || f.Mode() != 0700 || f.OwnerChanged() {
+			t.Errorf("Expected only the mode to change to 0700, got %v", f.Mode())
+		}
+	})
+}
@@ -0,0 +1,197 @@
+package components
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/termimg"
+)
+
+// FileViewerLimit is the most of a file the viewer loads
+const FileViewerLimit = 1 << 20
+
+// ImageViewerLimit is the most of an image the viewer loads
+const ImageViewerLimit = 16 << 20
+
+// viewerLimit returns how much of the file name the viewer loads
+func viewerLimit(name string) int64 {
+	if termimg.IsImage(name) {
+		return ImageViewerLimit
+	}
+	return FileViewerLimit
+}
+
+// FileViewer is a read-only pager for a text file, shown full screen by
+// the SCP manager
+type FileViewer struct {
+	name         string
+	location     string
+	size         int
+	truncated    bool
+	binary       bool
+	image        image.Image
+	protocol     termimg.Protocol
+	rendered     string // Image drawn for renderedSize, as drawing takes a while
+	renderedSize [2]int
+	viewport     viewport.Model
+	closed       bool
+	width        int
+	height       int
+}
+
+// NewFileViewer creates a pager for content read from location, or a
+// preview when it is an image. truncated reports that only the first
+// viewerLimit bytes were read.
+func NewFileViewer(name, location string, content []byte, truncated bool) *FileViewer {
+	v := &FileViewer{
+		name:      name,
+		location:  location,
+		size:      len(content),
+		truncated: truncated,
+		binary:    isBinary(content),
+		viewport:  viewport.New(0, 0),
+	}
+	v.viewport.SetHorizontalStep(8)
+	if termimg.IsImage(name) {
+		if img, err := termimg.Decode(content); err == nil {
+			v.image = img
+			v.protocol = termimg.ParseProtocol(config.CurrentSettings().ImagePreview, os.Getenv)
+			return v
+		}
+	}
+	if v.binary {
+		v.viewport.SetContent(lipgloss.NewStyle().Foreground(colorSubText).
+			Render("  Binary file, not shown"))
+		return v
+	}
+
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\t", "    ")
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	lines = highlightLines(name, lines)
+
+	gutter := len(fmt.Sprint(len(lines)))
+	numberStyle := lipgloss.NewStyle().Foreground(colorInactive)
+	for i, line := range lines {
+		lines[i] = numberStyle.Render(fmt.Sprintf("%*d │ ", gutter, i+1)) + line
+	}
+	v.viewport.SetContent(strings.Join(lines, "\n"))
+	return v
+}
+
+// isBinary treats content with NUL bytes or invalid UTF-8 near the start as
+// binary
+func isBinary(content []byte) bool {
+	head := content[:min(len(content), 8000)]
+	if bytes.IndexByte(head, 0) >= 0 {
+		return true
+	}
+	if len(head) < len(content) {
+		// Drop the last rune, which may be cut in half at the end of head
+		for i := len(head) - 1; i >= 0 && i >= len(head)-utf8.UTFMax; i-- {
+			if utf8.RuneStart(head[i]) {
+				head = head[:i]
+				break
+			}
+		}
+	}
+	return !utf8.Valid(head)
+}
+
+func (v *FileViewer) Init() tea.Cmd {
+	return nil
+}
+
+func (v *FileViewer) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.SetSize(msg.Width, msg.Height)
+		return v, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc":
+			v.closed = true
+			return v, nil
+		case "g", "home":
+			v.viewport.GotoTop()
+			return v, nil
+		case "G", "end":
+			v.viewport.GotoBottom()
+			return v, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	v.viewport, cmd = v.viewport.Update(msg)
+	return v, cmd
+}
+
+func (v *FileViewer) View() string {
+	info := fmt.Sprintf("%s — %s", v.location, formatSize(int64(v.size)))
+	if v.truncated {
+		info += fmt.Sprintf(" (first %s only)", formatSize(viewerLimit(v.name)))
+	}
+	content := v.viewport.View()
+	hint := "↑/↓/pgup/pgdn: scroll | ←/→: scroll sideways | g/G: top/bottom | q/esc: close"
+	switch {
+	case v.image != nil:
+		b := v.image.Bounds()
+		info += fmt.Sprintf(" — %d×%d, %s", b.Dx(), b.Dy(), v.protocol)
+		content = v.renderImage()
+		hint = "q/esc: close"
+	case !v.binary:
+		info += fmt.Sprintf(" — %d%%", int(v.viewport.ScrollPercent()*100))
+	}
+	title := lipgloss.JoinVertical(lipgloss.Left,
+		sectionTitleStyle.Render(v.name),
+		lipgloss.NewStyle().Foreground(colorSubText).Render(info),
+	)
+
+	return scpActivePanelStyle.
+		Width(max(v.width-2, 20)).
+		Render(lipgloss.JoinVertical(lipgloss.Left, title, "", content, "",
+			lipgloss.NewStyle().Foreground(colorSubText).Render(hint)))
+}
+
+// renderImage draws the image in the space of the pager, keeping the
+// empty lines under it so the hint stays at the bottom
+func (v *FileViewer) renderImage() string {
+	size := [2]int{v.viewport.Width, v.viewport.Height}
+	if v.rendered == "" || v.renderedSize != size {
+		v.rendered = termimg.Render(v.image, size[0], size[1], v.protocol)
+		if lines := strings.Count(v.rendered, "\n") + 1; lines < size[1] {
+			v.rendered += strings.Repeat("\n", size[1]-lines)
+		}
+		v.renderedSize = size
+	}
+	return v.rendered
+}
+
+// SetSize sizes the pager to fill width x height, border included
+func (v *FileViewer) SetSize(width, height int) {
+	v.width = width
+	v.height = height
+	// Border and padding take 4 columns and 4 rows; title, hint and spacing 5 rows
+	v.viewport.Width = max(width-8, 10)
+	v.viewport.Height = max(height-9, 1)
+}
+
+// IsImage reports whether the viewer shows an image, which the terminal may
+// keep on screen until it is cleared
+func (v *FileViewer) IsImage() bool {
+	return v.image != nil
+}
+
+// IsClosed reports whether the user closed the pager
+func (v *FileViewer) IsClosed() bool {
+	return v.closed
+}
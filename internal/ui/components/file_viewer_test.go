@@ -0,0 +1,84 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+func TestFileViewer(t *testing.T) {
+	t.Run("Binary content is not shown", func(t *testing.T) {
+		for name, content := range map[string][]byte{
+			"nul":     []byte("ELF\x00\x01\x02"),
+			"invalid": {0xff, 0xfe, 'a', 'b'},
+		} {
+			if !isBinary(content) {
+				t.Errorf("%s: expected content to be treated as binary", name)
+			}
+		}
+		// A multi-byte rune cut at the sniffing boundary is still text
+		text := []byte(strings.Repeat("a", 7999) + "é")
+		if isBinary(text) {
+			t.Error("Expected UTF-8 text cut mid-rune to be treated as text")
+		}
+	})
+
+	t.Run("Lines are numbered and highlighting keeps the text", func(t *testing.T) {
+		content := "# comment\nport: 22\nhost: \"web\"\n"
+		v := NewFileViewer("config.yaml", "/etc/config.yaml", []byte(content), false)
+		v.SetSize(80, 20)
+
+		view := v.View()
+		for _, want := range []string{"1 │ # comment", "2 │ port: 22", `3 │ host: "web"`, "/etc/config.yaml"} {
+			if !strings.Contains(view, want) {
+				t.Errorf("Expected view to contain %q, got:\n%s", want, view)
+			}
+		}
+	})
+
+	t.Run("Unknown file types are left as is", func(t *testing.T) {
+		lines := []string{"plain # text", `"quoted"`}
+		got := highlightLines("README", lines)
+		if got[0] != lines[0] || got[1] != lines[1] {
+			t.Errorf("Expected lines unchanged, got %q", got)
+		}
+	})
+
+	t.Run("q closes the viewer", func(t *testing.T) {
+		v := NewFileViewer("notes.txt", "notes.txt", []byte("hello"), false)
+		v.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+		if !v.IsClosed() {
+			t.Error("Expected q to close the viewer")
+		}
+	})
+
+	t.Run("Images are previewed", func(t *testing.T) {
+		defer config.SetCurrentSettings(config.CurrentSettings())
+		settings := config.DefaultSettings()
+		content := pngBytes(t, whiteImage(80, 40))
+
+		settings.ImagePreview = "ascii"
+		config.SetCurrentSettings(settings)
+		v := NewFileViewer("logo.png", "web:/srv/logo.png", content, false)
+		v.SetSize(60, 20)
+		if !v.IsImage() || !strings.Contains(v.View(), "@@@@@@@@") || !strings.Contains(v.View(), "80×40, ascii") {
+			t.Errorf("Expected the image drawn as ASCII art, got:\n%s", v.View())
+		}
+
+		settings.ImagePreview = "kitty"
+		config.SetCurrentSettings(settings)
+		v = NewFileViewer("logo.png", "web:/srv/logo.png", content, false)
+		v.SetSize(60, 20)
+		if view := v.View(); !strings.Contains(view, "\x1b_Ga=T,f=100,q=2,C=1,c=8,r=2,m=0;") {
+			t.Errorf("Expected the kitty sequence kept whole by the layout, got %q", view)
+		}
+
+		v = NewFileViewer("broken.png", "broken.png", []byte("\x89PNG\x00"), true)
+		v.SetSize(60, 20)
+		if v.IsImage() || !strings.Contains(v.View(), "Binary file") {
+			t.Error("Expected an unreadable image shown as a binary file")
+		}
+	})
+}
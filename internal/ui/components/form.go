@@ -2,14 +2,17 @@ package components
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
 	"github.com/eugeniofciuvasile/ssh-x-term/pkg/sshutil"
 
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -22,6 +25,15 @@ type ConnectionForm struct {
 	editing      bool
 	connection   config.SSHConnection
 	usePassword  bool
+	useMosh      bool
+	forwardAgent bool
+	forwardX11   bool
+	viewOnly     bool
+	dangerLevel  string
+	onConnect    textarea.Model // 14: one on-connect command per line
+	autoRun      bool
+	folders      []config.Folder // Bitwarden personal vault folders, cycled with ctrl+l
+	folderIndex  int
 	submitted    bool
 	canceled     bool
 	width        int
@@ -32,6 +44,11 @@ type ConnectionForm struct {
 	dropdownOpen bool
 	keyList      list.Model
 	allKeys      []string // scanned keys from ~/.ssh
+
+	// Key generation modal (ctrl+g) and a generated key waiting to be
+	// installed on the host once the connection has been saved
+	keyGen         *KeyGenForm
+	pendingInstall *KeyGeneratedMsg
 }
 
 // list item type for key paths
@@ -57,8 +74,10 @@ func NewConnectionForm(conn *config.SSHConnection) *ConnectionForm {
 	}
 
 	// Create text inputs
-	// 0: Name, 1: Host, 2: Port, 3: Username, 4: Key, 5: Password, 6: SudoPassword, 7: ID
-	inputs = make([]textinput.Model, 8)
+	// 0: Name, 1: Host, 2: Port, 3: Username, 4: Key, 5: Password, 6: SudoPassword, 7: ID,
+	// 8: Proxy, 9: ProxyPassword, 10: ProxyCommand, 11: SetEnv, 12: StartupCommand, 13: Shell,
+	// 14: Term, 15: PTY padding, 16: RemoteStartPath, 17: LocalStartPath, 18: Session timeouts
+	inputs = make([]textinput.Model, 19)
 
 	// Helper to init standard inputs
 	initInput := func(i int, placeholder string, width int) {
@@ -95,6 +114,27 @@ func NewConnectionForm(conn *config.SSHConnection) *ConnectionForm {
 	// ID input (hidden from view, used as identifier)
 	initInput(7, "ID (auto-generated)", 40)
 
+	// Proxy inputs
+	initInput(8, "socks5://[user@]host:port or http://[user@]host:port", 50)
+	initInput(9, "Proxy Password (optional)", 40)
+	inputs[9].EchoMode = textinput.EchoPassword
+	inputs[9].EchoCharacter = '•'
+	initInput(10, "ProxyCommand, e.g. cloudflared access ssh --hostname %h", 50)
+
+	// Session inputs
+	initInput(11, `NAME=value pairs, e.g. LANG=C.UTF-8 GREETING="hi there"`, 50)
+	initInput(12, "Startup command, e.g. cd /srv/app", 50)
+	initInput(13, "Shell, e.g. /bin/zsh (default: login shell)", 50)
+	initInput(14, "TERM, e.g. screen-256color (default: "+config.DefaultTerm+")", 50)
+	initInput(15, "Window padding, COLUMNS or COLUMNSxROWS, e.g. 1", 50)
+
+	// File manager inputs
+	initInput(16, "Remote directory, e.g. /var/www/app (default: home)", 50)
+	initInput(17, "Local directory, e.g. ~/projects/app (default: current)", 50)
+
+	// Session timeouts input
+	initInput(18, "IDLE or IDLE/MAX, e.g. 15m or 15m/8h (default: none)", 50)
+
 	// If editing, fill the fields
 	if editing {
 		inputs[0].SetValue(initialConn.Name)
@@ -105,8 +145,29 @@ func NewConnectionForm(conn *config.SSHConnection) *ConnectionForm {
 		inputs[5].SetValue(initialConn.Password)
 		inputs[6].SetValue(initialConn.SudoPassword)
 		inputs[7].SetValue(initialConn.ID)
+		inputs[8].SetValue(initialConn.Proxy)
+		inputs[9].SetValue(initialConn.ProxyPassword)
+		inputs[10].SetValue(initialConn.ProxyCommand)
+		inputs[11].SetValue(config.FormatSetEnv(initialConn.SetEnv))
+		inputs[12].SetValue(initialConn.StartupCommand)
+		inputs[13].SetValue(initialConn.Shell)
+		inputs[14].SetValue(initialConn.Term)
+		inputs[15].SetValue(config.FormatPtyPadding(initialConn.PtyPadColumns, initialConn.PtyPadRows))
+		inputs[16].SetValue(initialConn.RemoteStartPath)
+		inputs[17].SetValue(initialConn.LocalStartPath)
+		inputs[18].SetValue(config.FormatSessionTimeouts(initialConn.IdleMinutes, initialConn.SessionMinutes))
 	}
 
+	// On-connect commands, typed at the first prompts of a session
+	onConnect := textarea.New()
+	onConnect.Placeholder = "One command per line, e.g. cd /var/www && sudo -i"
+	onConnect.ShowLineNumbers = false
+	onConnect.Prompt = "> "
+	onConnect.SetWidth(50)
+	onConnect.SetHeight(3)
+	onConnect.SetValue(strings.Join(initialConn.OnConnect, "\n"))
+	onConnect.Blur()
+
 	// Scan ~/.ssh for private keys (simple scan)
 	keys := sshutil.ScanSSHKeys()
 
@@ -128,12 +189,27 @@ func NewConnectionForm(conn *config.SSHConnection) *ConnectionForm {
 		editing:      editing,
 		connection:   initialConn,
 		usePassword:  initialConn.UsePassword,
+		useMosh:      initialConn.UseMosh,
+		forwardAgent: initialConn.ForwardAgent,
+		forwardX11:   initialConn.ForwardX11,
+		viewOnly:     initialConn.ViewOnly,
+		dangerLevel:  config.ParseDangerLevel(initialConn.DangerLevel),
+		onConnect:    onConnect,
+		autoRun:      initialConn.OnConnectAuto,
 		dropdownOpen: false,
 		keyList:      l,
 		allKeys:      keys,
 	}
 }
 
+// NewConnectionFormFrom creates a form for adding a new connection
+// prefilled from conn, such as a host that was connected to ad hoc
+func NewConnectionFormFrom(conn config.SSHConnection) *ConnectionForm {
+	f := NewConnectionForm(&conn)
+	f.editing = false
+	return f
+}
+
 // Init initializes the form
 func (m *ConnectionForm) Init() tea.Cmd {
 	return textinput.Blink
@@ -143,6 +219,10 @@ func (m *ConnectionForm) Init() tea.Cmd {
 func (m *ConnectionForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	if m.keyGen != nil {
+		return m.updateKeyGen(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.SetSize(msg.Width, msg.Height)
@@ -251,6 +331,17 @@ func (m *ConnectionForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		// The on-connect textarea takes Enter and arrows for itself
+		if m.focusIndex == 19 {
+			switch msg.String() {
+			case "tab", "shift+tab", "esc", "ctrl+g", "ctrl+l", "ctrl+o", "ctrl+p", "ctrl+r", "ctrl+t", "ctrl+x":
+			default:
+				var cmd tea.Cmd
+				m.onConnect, cmd = m.onConnect.Update(msg)
+				return m, cmd
+			}
+		}
+
 		// Standard navigation logic
 		switch msg.String() {
 		case "esc":
@@ -270,10 +361,10 @@ func (m *ConnectionForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.focusIndex += step
 
 				// Handle Wrap-around
-				if m.focusIndex > 8 {
+				if m.focusIndex > 20 {
 					m.focusIndex = 0
 				} else if m.focusIndex < 0 {
-					m.focusIndex = 8
+					m.focusIndex = 20
 				}
 
 				// Check if we should stop at this index
@@ -282,7 +373,13 @@ func (m *ConnectionForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// 5: Always stop (Password/Passphrase)
 				// 6: Always stop (Sudo Password)
 				// 7: Always skip (ID)
-				// 8: Always stop (Submit)
+				// 8-10: Always stop (Proxy, Proxy Password, ProxyCommand)
+				// 11-13: Always stop (Environment, Startup Command, Shell)
+				// 14-15: Always stop (TERM, Window padding)
+				// 16-17: Always stop (Remote and local start directories)
+				// 18: Always stop (Session timeouts)
+				// 19: Always stop (On-connect commands)
+				// 20: Always stop (Submit)
 
 				shouldSkip := false
 				if m.focusIndex == 7 {
@@ -323,10 +420,15 @@ func (m *ConnectionForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.inputs[i].TextStyle = blurredStyle
 				}
 			}
+			if m.focusIndex == 19 {
+				cmds = append(cmds, m.onConnect.Focus())
+			} else {
+				m.onConnect.Blur()
+			}
 
 		case "enter":
-			// Check if we are at the submit button (index 8) OR submitting from a field
-			if m.focusIndex == 8 {
+			// Check if we are at the submit button (index 20) OR submitting from a field
+			if m.focusIndex == 20 {
 				if valid, err := m.validateForm(); valid {
 					m.updateConnection()
 					m.submitted = true
@@ -338,6 +440,52 @@ func (m *ConnectionForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, func() tea.Msg { return tea.KeyMsg{Type: tea.KeyTab} }
 			}
 
+		case "ctrl+g":
+			// Generate a new key pair; installing it is only possible while
+			// we still have a password to log in with
+			m.dropdownOpen = false
+			m.keyGen = NewKeyGenForm(m.inputs[0].Value(), m.usePassword)
+			m.keyGen.SetSize(m.width, m.height)
+			return m, m.keyGen.Init()
+
+		case "ctrl+o":
+			// Toggle starting sessions with mosh
+			m.useMosh = !m.useMosh
+			return m, nil
+
+		case "ctrl+t":
+			// Toggle forwarding the local SSH agent
+			m.forwardAgent = !m.forwardAgent
+			return m, nil
+
+		case "ctrl+x":
+			// Toggle forwarding the local X11 display
+			m.forwardX11 = !m.forwardX11
+			return m, nil
+
+		case "ctrl+y":
+			// Toggle refusing edits, deletion and uploads
+			m.viewOnly = !m.viewOnly
+			return m, nil
+
+		case "ctrl+n":
+			// Cycle the danger level
+			i := slices.Index(config.DangerLevels, m.dangerLevel)
+			m.dangerLevel = config.DangerLevels[(i+1)%len(config.DangerLevels)]
+			return m, nil
+
+		case "ctrl+r":
+			// Toggle running on-connect commands without asking
+			m.autoRun = !m.autoRun
+			return m, nil
+
+		case "ctrl+l":
+			// Move the connection to the next folder
+			if len(m.folders) > 0 {
+				m.folderIndex = (m.folderIndex + 1) % len(m.folders)
+			}
+			return m, nil
+
 		case "ctrl+p":
 			// Toggle between password and key authentication
 			m.usePassword = !m.usePassword
@@ -369,13 +517,53 @@ func (m *ConnectionForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.inputs[m.focusIndex] = newInput
 			cmds = append(cmds, cmd)
 		}
+	} else if m.focusIndex == 19 {
+		var cmd tea.Cmd
+		m.onConnect, cmd = m.onConnect.Update(msg)
+		cmds = append(cmds, cmd)
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
+// updateKeyGen routes messages to the key generation modal and applies its result
+func (m *ConnectionForm) updateKeyGen(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		m.SetSize(size.Width, size.Height)
+	}
+	model, cmd := m.keyGen.Update(msg)
+	m.keyGen = model.(*KeyGenForm)
+
+	if m.keyGen.IsCanceled() {
+		m.keyGen = nil
+		return m, nil
+	}
+	result := m.keyGen.Result()
+	if result == nil {
+		return m, cmd
+	}
+	m.keyGen = nil
+	m.allKeys = append(m.allKeys, result.Key.PrivateKeyPath)
+
+	if result.Install {
+		// Keep password auth for the first login; the model switches the
+		// connection to the new key once it is installed
+		m.pendingInstall = result
+		return m, nil
+	}
+
+	m.usePassword = false
+	m.inputs[4].SetValue(result.Key.PrivateKeyPath)
+	m.inputs[5].SetValue(result.Passphrase)
+	return m, nil
+}
+
 // View renders the form
 func (m *ConnectionForm) View() string {
+	if m.keyGen != nil {
+		return m.keyGen.View()
+	}
+
 	var b strings.Builder
 
 	// Title
@@ -404,17 +592,27 @@ func (m *ConnectionForm) View() string {
 	b.WriteString(label("Username") + "\n")
 	b.WriteString(m.inputs[3].View() + "\n\n")
 
+	if len(m.folders) > 0 {
+		b.WriteString(fmt.Sprintf("%s %s\n\n", label("Folder: "+m.folders[m.folderIndex].Name),
+			lipgloss.NewStyle().Foreground(colorInactive).Render("(Ctrl+L to change)")))
+	}
+
 	// Auth method header
 	authMethod := "Using Password Authentication"
 	if !m.usePassword {
 		authMethod = "Using SSH Key Authentication"
 	}
-	authHint := lipgloss.NewStyle().Foreground(colorInactive).Render("(Ctrl+P to toggle)")
+	authHint := lipgloss.NewStyle().Foreground(colorInactive).Render("(Ctrl+P to toggle, Ctrl+G new key)")
 	b.WriteString(fmt.Sprintf("%s %s\n", label(authMethod), authHint))
 
 	// Render conditional input
 	if m.usePassword {
 		b.WriteString(m.inputs[5].View()) // Password
+		if m.pendingInstall != nil {
+			b.WriteString("\n")
+			b.WriteString(lipgloss.NewStyle().Foreground(colorSecondary).Render(
+				"New key " + truncate(m.pendingInstall.Key.PrivateKeyPath, 36) + " will be installed after saving"))
+		}
 	} else {
 		// SSH Key input
 		b.WriteString(m.inputs[4].View())
@@ -424,7 +622,7 @@ func (m *ConnectionForm) View() string {
 			dropdownBox := lipgloss.NewStyle().
 				MarginLeft(2).
 				Border(lipgloss.RoundedBorder()).
-				BorderForeground(lipgloss.Color("63")).
+				BorderForeground(colorSecondary).
 				Padding(0, 1).
 				Render(m.keyList.View())
 			b.WriteString("\n" + dropdownBox)
@@ -441,9 +639,64 @@ func (m *ConnectionForm) View() string {
 	b.WriteString(label("Sudo / User Password (optional)") + "\n")
 	b.WriteString(m.inputs[6].View() + "\n\n")
 
-	// Render submit button (Index 8)
+	// Session protocol
+	protocol := "Session over SSH"
+	if m.useMosh {
+		protocol = "Session over Mosh (SSH when unavailable)"
+	}
+	b.WriteString(fmt.Sprintf("%s %s\n\n", label(protocol),
+		lipgloss.NewStyle().Foreground(colorInactive).Render("(Ctrl+O to toggle)")))
+
+	// Forwarding checkboxes
+	checkbox := func(checked bool, text, hint string) string {
+		box := "[ ]"
+		if checked {
+			box = "[x]"
+		}
+		return fmt.Sprintf("%s %s\n", label(box+" "+text), lipgloss.NewStyle().Foreground(colorInactive).Render(hint))
+	}
+	b.WriteString(checkbox(m.forwardAgent, "Forward SSH Agent", "(Ctrl+T)"))
+	b.WriteString(checkbox(m.forwardX11, "Forward X11", "(Ctrl+X)"))
+	b.WriteString(checkbox(m.viewOnly, "View Only: no edits, deletion or uploads", "(Ctrl+Y)") + "\n")
+
+	// Danger level
+	danger := "Danger Level: none"
+	switch m.dangerLevel {
+	case config.DangerStaging:
+		danger = "Danger Level: staging (warning header)"
+	case config.DangerProduction:
+		danger = "Danger Level: production (type the host to connect, red header)"
+	}
+	b.WriteString(fmt.Sprintf("%s %s\n\n", label(danger),
+		lipgloss.NewStyle().Foreground(colorInactive).Render("(Ctrl+N to cycle)")))
+
+	// Proxy fields
+	b.WriteString(label("Proxy (optional)") + "\n")
+	b.WriteString(m.inputs[8].View() + "\n")
+	b.WriteString(m.inputs[9].View() + "\n")
+	b.WriteString(m.inputs[10].View() + "\n\n")
+
+	// Session fields
+	b.WriteString(label("Environment (optional, SetEnv)") + "\n")
+	b.WriteString(m.inputs[11].View() + "\n\n")
+	b.WriteString(label("Startup Command / Shell (optional)") + "\n")
+	b.WriteString(m.inputs[12].View() + "\n")
+	b.WriteString(m.inputs[13].View() + "\n\n")
+	b.WriteString(label("Terminal Type / Window Padding (optional)") + "\n")
+	b.WriteString(m.inputs[14].View() + "\n")
+	b.WriteString(m.inputs[15].View() + "\n\n")
+	b.WriteString(label("File Manager Start Directories (optional)") + "\n")
+	b.WriteString(m.inputs[16].View() + "\n")
+	b.WriteString(m.inputs[17].View() + "\n\n")
+	b.WriteString(label("Idle / Session Timeouts (optional)") + "\n")
+	b.WriteString(m.inputs[18].View() + "\n\n")
+	b.WriteString(label("On-Connect Commands (optional, one per line)") + "\n")
+	b.WriteString(m.onConnect.View() + "\n")
+	b.WriteString(checkbox(m.autoRun, "Run Without Asking", "(Ctrl+R)") + "\n")
+
+	// Render submit button (Index 20)
 	button := blurredButton
-	if m.focusIndex == 8 {
+	if m.focusIndex == 20 {
 		button = focusedButton
 	}
 	b.WriteString(button)
@@ -489,11 +742,36 @@ func (m *ConnectionForm) IsSubmitted() bool {
 	return m.submitted
 }
 
+// ResetSubmitted lets the form be submitted again, after going back to it
+func (m *ConnectionForm) ResetSubmitted() {
+	m.submitted = false
+}
+
 // Connection returns the connection from the form
 func (m *ConnectionForm) Connection() config.SSHConnection {
 	return m.connection
 }
 
+// SetFolders lets the connection be put in one of the folders of the
+// personal Bitwarden vault, starting with the folder folderID
+func (m *ConnectionForm) SetFolders(folders []config.Folder, folderID string) {
+	m.folders = folders
+	if !slices.ContainsFunc(folders, func(f config.Folder) bool { return f.ID == "" }) {
+		m.folders = append([]config.Folder{{Name: "No Folder"}}, folders...)
+	}
+	m.folderIndex = max(slices.IndexFunc(m.folders, func(f config.Folder) bool { return f.ID == folderID }), 0)
+}
+
+// PendingKeyInstall returns the generated key to install on the host after
+// the connection is saved, or nil if none was requested
+func (m *ConnectionForm) PendingKeyInstall() *KeyGeneratedMsg {
+	if !m.usePassword {
+		// The user switched to key auth after generating; nothing to log in with
+		return nil
+	}
+	return m.pendingInstall
+}
+
 // validateForm checks if the form inputs are valid
 func (m *ConnectionForm) validateForm() (bool, string) {
 	// Check required fields
@@ -503,6 +781,9 @@ func (m *ConnectionForm) validateForm() (bool, string) {
 	if strings.TrimSpace(m.inputs[1].Value()) == "" {
 		return false, "Host is required"
 	}
+	if _, err := config.ExpandHostRange(strings.TrimSpace(m.inputs[1].Value())); err != nil {
+		return false, err.Error()
+	}
 	if strings.TrimSpace(m.inputs[3].Value()) == "" {
 		return false, "Username is required"
 	}
@@ -520,6 +801,31 @@ func (m *ConnectionForm) validateForm() (bool, string) {
 		return false, "SSH key path is required for key authentication"
 	}
 
+	if proxy := strings.TrimSpace(m.inputs[8].Value()); proxy != "" {
+		if _, err := ssh.ParseProxy(proxy); err != nil {
+			return false, err.Error()
+		}
+		if strings.TrimSpace(m.inputs[10].Value()) != "" {
+			return false, "Use either a proxy or a ProxyCommand, not both"
+		}
+	}
+
+	if _, err := config.ParseSetEnv(m.inputs[11].Value()); err != nil {
+		return false, err.Error()
+	}
+
+	if err := config.ValidateTerm(strings.TrimSpace(m.inputs[14].Value())); err != nil {
+		return false, err.Error()
+	}
+
+	if _, _, err := config.ParsePtyPadding(m.inputs[15].Value()); err != nil {
+		return false, err.Error()
+	}
+
+	if _, _, err := config.ParseSessionTimeouts(m.inputs[18].Value()); err != nil {
+		return false, err.Error()
+	}
+
 	return true, ""
 }
 
@@ -549,6 +855,32 @@ func (m *ConnectionForm) updateConnection() {
 	m.connection.Password = strings.TrimSpace(m.inputs[5].Value())
 	m.connection.SudoPassword = strings.TrimSpace(m.inputs[6].Value())
 	m.connection.UsePassword = m.usePassword
+	m.connection.UseMosh = m.useMosh
+	m.connection.ForwardAgent = m.forwardAgent
+	m.connection.ForwardX11 = m.forwardX11
+	m.connection.ViewOnly = m.viewOnly
+	m.connection.DangerLevel = m.dangerLevel
+	m.connection.Proxy = strings.TrimSpace(m.inputs[8].Value())
+	m.connection.ProxyPassword = strings.TrimSpace(m.inputs[9].Value())
+	m.connection.ProxyCommand = strings.TrimSpace(m.inputs[10].Value())
+	m.connection.SetEnv, _ = config.ParseSetEnv(m.inputs[11].Value())
+	m.connection.StartupCommand = strings.TrimSpace(m.inputs[12].Value())
+	m.connection.Shell = strings.TrimSpace(m.inputs[13].Value())
+	m.connection.Term = strings.TrimSpace(m.inputs[14].Value())
+	m.connection.PtyPadColumns, m.connection.PtyPadRows, _ = config.ParsePtyPadding(m.inputs[15].Value())
+	m.connection.RemoteStartPath = strings.TrimSpace(m.inputs[16].Value())
+	m.connection.LocalStartPath = strings.TrimSpace(m.inputs[17].Value())
+	m.connection.IdleMinutes, m.connection.SessionMinutes, _ = config.ParseSessionTimeouts(m.inputs[18].Value())
+	m.connection.OnConnect = nil
+	for _, line := range strings.Split(m.onConnect.Value(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			m.connection.OnConnect = append(m.connection.OnConnect, line)
+		}
+	}
+	m.connection.OnConnectAuto = m.autoRun
+	if len(m.folders) > 0 {
+		m.connection.FolderID = m.folders[m.folderIndex].ID
+	}
 }
 
 // ---------- Helper functions ----------
@@ -0,0 +1,125 @@
+package components
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// syntax describes just enough of a file type to colour it: how comments
+// start, whether lines are key/value pairs, and whether it is a log
+type syntax struct {
+	comment  []string
+	keyValue bool
+	log      bool
+}
+
+var (
+	highlightCommentStyle lipgloss.Style
+	highlightStringStyle  lipgloss.Style
+	highlightKeyStyle     lipgloss.Style
+	highlightSectionStyle lipgloss.Style
+	highlightErrorStyle   lipgloss.Style
+	highlightWarnStyle    lipgloss.Style
+	highlightInfoStyle    lipgloss.Style
+)
+
+// buildHighlightStyles recreates the highlight styles from the current theme
+func buildHighlightStyles() {
+	highlightCommentStyle = lipgloss.NewStyle().Foreground(colorSubText).Italic(true)
+	highlightStringStyle = lipgloss.NewStyle().Foreground(currentTheme.String)
+	highlightKeyStyle = lipgloss.NewStyle().Foreground(colorSecondary)
+	highlightSectionStyle = lipgloss.NewStyle().Foreground(colorPrimary).Bold(true)
+	highlightErrorStyle = lipgloss.NewStyle().Foreground(colorError).Bold(true)
+	highlightWarnStyle = lipgloss.NewStyle().Foreground(colorAccent).Bold(true)
+	highlightInfoStyle = lipgloss.NewStyle().Foreground(colorSecondary)
+}
+
+var (
+	highlightStringRe   = regexp.MustCompile(`"(?:[^"\\]|\\.)*"|'[^']*'`)
+	highlightKeyRe      = regexp.MustCompile(`^(\s*-?\s*)([\w.\-/@]+)(\s*[:=])`)
+	highlightSectionRe  = regexp.MustCompile(`^\s*\[[^\]]+\]\s*$`)
+	highlightLogLevelRe = regexp.MustCompile(`\b(FATAL|ERROR|ERR|CRIT(?:ICAL)?|WARN(?:ING)?|INFO|NOTICE|DEBUG|TRACE)\b`)
+)
+
+// syntaxFor picks a syntax by file name
+func syntaxFor(name string) syntax {
+	base := strings.ToLower(filepath.Base(name))
+	switch base {
+	case "dockerfile", "makefile", ".bashrc", ".profile", ".zshrc", "authorized_keys", "known_hosts":
+		return syntax{comment: []string{"#"}}
+	case "config", "sshd_config", "ssh_config", ".env", ".gitconfig", "hosts", "fstab", "crontab":
+		return syntax{comment: []string{"#"}, keyValue: true}
+	}
+
+	switch filepath.Ext(base) {
+	case ".go", ".js", ".ts", ".jsx", ".tsx", ".c", ".h", ".cpp", ".cc", ".hpp", ".java", ".rs", ".php", ".swift", ".kt":
+		return syntax{comment: []string{"//"}}
+	case ".sh", ".bash", ".zsh", ".py", ".rb", ".pl", ".tf":
+		return syntax{comment: []string{"#"}}
+	case ".yaml", ".yml", ".toml", ".conf", ".cfg", ".env", ".properties", ".service", ".timer", ".socket":
+		return syntax{comment: []string{"#", ";"}, keyValue: true}
+	case ".ini":
+		return syntax{comment: []string{";", "#"}, keyValue: true}
+	case ".sql", ".lua":
+		return syntax{comment: []string{"--"}}
+	case ".log", ".out", ".err":
+		return syntax{log: true}
+	}
+	if strings.Contains(base, ".log.") || strings.HasSuffix(base, "log") {
+		return syntax{log: true}
+	}
+	return syntax{}
+}
+
+// highlightLines colours lines for display according to name's file type.
+// Lines of unknown types are returned unchanged.
+func highlightLines(name string, lines []string) []string {
+	syn := syntaxFor(name)
+	if len(syn.comment) == 0 && !syn.keyValue && !syn.log {
+		return lines
+	}
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		out[i] = syn.highlight(line)
+	}
+	return out
+}
+
+func (syn syntax) highlight(line string) string {
+	if syn.log {
+		return highlightLogLevelRe.ReplaceAllStringFunc(line, func(level string) string {
+			switch level[0] {
+			case 'F', 'E', 'C':
+				return highlightErrorStyle.Render(level)
+			case 'W':
+				return highlightWarnStyle.Render(level)
+			default:
+				return highlightInfoStyle.Render(level)
+			}
+		})
+	}
+
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range syn.comment {
+		if strings.HasPrefix(trimmed, prefix) {
+			return highlightCommentStyle.Render(line)
+		}
+	}
+	if syn.keyValue && highlightSectionRe.MatchString(line) {
+		return highlightSectionStyle.Render(line)
+	}
+
+	var prefix string
+	if syn.keyValue {
+		if m := highlightKeyRe.FindStringSubmatchIndex(line); m != nil {
+			prefix = line[:m[4]] + highlightKeyStyle.Render(line[m[4]:m[5]]) + line[m[5]:m[6]]
+			line = line[m[6]:]
+		}
+	}
+	return prefix + highlightStringRe.ReplaceAllStringFunc(line, func(str string) string {
+		return highlightStringStyle.Render(str)
+	})
+}
@@ -0,0 +1,174 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+// HostDetailsModal shows the host key fingerprints and negotiated
+// algorithms recorded by the last connection to a host, and whether the key
+// matches ~/.ssh/known_hosts
+type HostDetailsModal struct {
+	conn      config.SSHConnection
+	info      *config.HostInfo
+	status    ssh.KnownHostsStatus
+	statusErr error
+	copied    bool
+	canceled  bool
+	width     int
+	height    int
+}
+
+// NewHostDetailsModal creates the panel for conn; info is nil when the host
+// has not been connected to yet
+func NewHostDetailsModal(conn config.SSHConnection, info *config.HostInfo) *HostDetailsModal {
+	m := &HostDetailsModal{conn: conn, info: info}
+	if info != nil {
+		m.status, m.statusErr = ssh.CheckKnownHosts(*info)
+	}
+	return m
+}
+
+func (m *HostDetailsModal) Init() tea.Cmd {
+	return nil
+}
+
+func (m *HostDetailsModal) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.SetSize(msg.Width, msg.Height)
+		return m, nil
+
+	case resetCopiedMsg:
+		m.copied = false
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "c", "C":
+			if m.info != nil && CopyToClipboard(m.info.FingerprintSHA256) == nil {
+				m.copied = true
+				return m, tea.Tick(time.Second*2, func(t time.Time) tea.Msg {
+					return resetCopiedMsg{}
+				})
+			}
+		case "esc", "enter", "q", "v", "ctrl+c":
+			m.canceled = true
+			return m, nil
+		}
+	}
+
+	return m, nil
+}
+
+func (m *HostDetailsModal) View() string {
+	if m.canceled {
+		return ""
+	}
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colorPrimary).
+		Render("🔍 Host Details")
+
+	labelStyle := lipgloss.NewStyle().Foreground(colorSubText).Width(14)
+	valueStyle := lipgloss.NewStyle().Foreground(colorText)
+	var content strings.Builder
+	row := func(label, value string, style lipgloss.Style) {
+		if value == "" {
+			return
+		}
+		content.WriteString(labelStyle.Render(label) + style.Render(value) + "\n")
+	}
+
+	content.WriteString(title + "\n\n")
+	row("Connection", m.conn.Name, valueStyle)
+	row("Address", fmt.Sprintf("%s:%d", m.conn.Host, m.conn.Port), valueStyle)
+
+	if m.info == nil {
+		content.WriteString("\n" + lipgloss.NewStyle().
+			Foreground(colorInactive).
+			Render("No connection recorded yet. Connect once to see the host key.") + "\n")
+	} else {
+		info := m.info
+		statusStyle := lipgloss.NewStyle().Bold(true)
+		switch m.status {
+		case ssh.KnownHostsMatch:
+			statusStyle = statusStyle.Foreground(colorSuccess)
+		case ssh.KnownHostsMismatch, ssh.KnownHostsRevoked:
+			statusStyle = statusStyle.Foreground(colorError)
+		default:
+			statusStyle = statusStyle.Foreground(colorWarning)
+		}
+		status := m.status.String()
+		if m.statusErr != nil {
+			status = fmt.Sprintf("could not check: %v", m.statusErr)
+		}
+
+		row("Server", info.ServerVersion, valueStyle)
+		row("Seen", info.Seen().Format("2006-01-02 15:04:05"), valueStyle)
+		content.WriteString("\n")
+		row("Key type", info.KeyType, valueStyle)
+		row("SHA256", strings.TrimPrefix(info.FingerprintSHA256, "SHA256:"), lipgloss.NewStyle().Foreground(colorAccent).Bold(true))
+		row("MD5", strings.TrimPrefix(info.FingerprintMD5, "MD5:"), valueStyle)
+		row("known_hosts", status, statusStyle)
+		content.WriteString("\n")
+		row("Kex", info.KeyExchange, valueStyle)
+		row("Cipher", directions(info.CipherClientToServer, info.CipherServerToClient), valueStyle)
+		row("MAC", directions(info.MACClientToServer, info.MACServerToClient), valueStyle)
+	}
+
+	copyStatus := " "
+	if m.copied {
+		copyStatus = lipgloss.NewStyle().
+			Foreground(colorSuccess).
+			Render("✓ SHA256 fingerprint copied!")
+	}
+	content.WriteString("\n" + copyStatus + "\n")
+
+	promptText := "Esc/Enter to close"
+	if m.info != nil {
+		promptText = "Press C to copy the SHA256 fingerprint, Esc/Enter to close"
+	}
+	content.WriteString(lipgloss.NewStyle().Foreground(colorInactive).Render(promptText))
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(80).
+		Render(content.String())
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		box,
+	)
+}
+
+// directions shows a client-to-server and server-to-client algorithm pair,
+// once when both ways agree
+func directions(out, in string) string {
+	if out == in {
+		return out
+	}
+	return fmt.Sprintf("%s (out) / %s (in)", out, in)
+}
+
+func (m *HostDetailsModal) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
+
+func (m *HostDetailsModal) IsCanceled() bool {
+	return m.canceled
+}
@@ -0,0 +1,326 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// importStep is the page the import wizard is showing
+type importStep int
+
+const (
+	importStepSource importStep = iota
+	importStepPath
+	importStepPreview
+)
+
+// importCandidate is an imported connection and whether it will be added
+type importCandidate struct {
+	conn      config.SSHConnection
+	duplicate bool
+	selected  bool
+}
+
+// ImportWizard imports connections from PuTTY, WinSCP or Termius: pick a
+// source, point it at the exported file, then choose which sessions to add
+type ImportWizard struct {
+	existing     []config.SSHConnection
+	step         importStep
+	sourceIdx    int
+	pathInput    textinput.Model
+	candidates   []importCandidate
+	skipped      []string
+	warnings     []string
+	selectedIdx  int
+	scrollOffset int
+	confirmed    bool
+	canceled     bool
+	error        string
+	width        int
+	height       int
+}
+
+// NewImportWizard creates an import wizard. existing is used to flag
+// sessions that are already saved.
+func NewImportWizard(existing []config.SSHConnection) *ImportWizard {
+	input := textinput.New()
+	input.Width = 50
+	input.Prompt = "> "
+	input.PromptStyle = focusedStyle
+	input.TextStyle = focusedStyle
+	return &ImportWizard{existing: existing, pathInput: input}
+}
+
+func (w *ImportWizard) Init() tea.Cmd {
+	return nil
+}
+
+func (w *ImportWizard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		w.SetSize(size.Width, size.Height)
+		return w, nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	switch w.step {
+	case importStepSource:
+		if !ok {
+			return w, nil
+		}
+		switch keyMsg.String() {
+		case "up", "k":
+			if w.sourceIdx > 0 {
+				w.sourceIdx--
+			}
+		case "down", "j":
+			if w.sourceIdx < len(config.ImportSources)-1 {
+				w.sourceIdx++
+			}
+		case "enter":
+			w.pathInput.SetValue(w.source().DefaultPath())
+			w.pathInput.Placeholder = w.source().Description()
+			w.pathInput.CursorEnd()
+			w.pathInput.Focus()
+			w.step = importStepPath
+			return w, textinput.Blink
+		case "esc", "q":
+			w.canceled = true
+		}
+		return w, nil
+
+	case importStepPath:
+		if ok {
+			switch keyMsg.String() {
+			case "esc":
+				w.error = ""
+				w.pathInput.Blur()
+				w.step = importStepSource
+				return w, nil
+			case "enter":
+				w.load()
+				return w, nil
+			}
+		}
+		var cmd tea.Cmd
+		w.pathInput, cmd = w.pathInput.Update(msg)
+		return w, cmd
+	}
+
+	if !ok {
+		return w, nil
+	}
+	switch keyMsg.String() {
+	case "up", "k":
+		if w.selectedIdx > 0 {
+			w.selectedIdx--
+		}
+	case "down", "j":
+		if w.selectedIdx < len(w.candidates)-1 {
+			w.selectedIdx++
+		}
+	case " ", "x":
+		if len(w.candidates) > 0 {
+			w.candidates[w.selectedIdx].selected = !w.candidates[w.selectedIdx].selected
+		}
+	case "a":
+		// Select all new sessions, or clear the selection if they already are
+		all := true
+		for _, c := range w.candidates {
+			if !c.duplicate && !c.selected {
+				all = false
+			}
+		}
+		for i := range w.candidates {
+			w.candidates[i].selected = !all && !w.candidates[i].duplicate
+		}
+	case "enter":
+		if len(w.Selected()) == 0 {
+			w.error = "Nothing selected to import."
+			return w, nil
+		}
+		w.confirmed = true
+	case "esc":
+		w.error = ""
+		w.step = importStepPath
+		w.pathInput.Focus()
+		return w, textinput.Blink
+	}
+	return w, nil
+}
+
+func (w *ImportWizard) source() config.ImportSource {
+	return config.ImportSources[w.sourceIdx]
+}
+
+// load reads the chosen file and moves on to the preview
+func (w *ImportWizard) load() {
+	path := strings.TrimSpace(w.pathInput.Value())
+	if path == "" {
+		w.error = "Enter the path of the file to import."
+		return
+	}
+	result, err := config.ImportConnections(w.source(), path)
+	if err != nil {
+		w.error = fmt.Sprintf("Failed to read %s: %s", path, err)
+		return
+	}
+	if len(result.Connections) == 0 {
+		w.error = fmt.Sprintf("No SSH sessions found in %s.", path)
+		return
+	}
+
+	w.candidates = w.candidates[:0]
+	for _, conn := range result.Connections {
+		duplicate := config.IsDuplicateImport(w.existing, conn)
+		w.candidates = append(w.candidates, importCandidate{conn: conn, duplicate: duplicate, selected: !duplicate})
+	}
+	w.skipped = result.Skipped
+	w.warnings = result.Warnings
+	w.selectedIdx, w.scrollOffset = 0, 0
+	w.error = ""
+	w.pathInput.Blur()
+	w.step = importStepPreview
+}
+
+func (w *ImportWizard) View() string {
+	var b strings.Builder
+	labelStyle := lipgloss.NewStyle().Foreground(colorSubText)
+	hintStyle := lipgloss.NewStyle().Foreground(colorInactive)
+
+	switch w.step {
+	case importStepSource:
+		b.WriteString(sectionTitleStyle.Render("Import Connections"))
+		b.WriteString("\n\n")
+		for i, source := range config.ImportSources {
+			if i == w.sourceIdx {
+				b.WriteString(focusedStyle.Bold(true).Render("> " + source.String()))
+			} else {
+				b.WriteString(blurredStyle.Render("  " + source.String()))
+			}
+			b.WriteString("\n")
+			b.WriteString(hintStyle.Render("    " + source.Description()))
+			b.WriteString("\n")
+		}
+
+	case importStepPath:
+		b.WriteString(sectionTitleStyle.Render("Import from " + w.source().String()))
+		b.WriteString("\n\n")
+		b.WriteString(labelStyle.Render(w.source().Description()))
+		b.WriteString("\n")
+		b.WriteString(w.pathInput.View())
+		if w.source() == config.ImportPuTTY {
+			b.WriteString("\n\n")
+			b.WriteString(hintStyle.Render(`On Windows: reg export HKCU\Software\SimonTatham\PuTTY\Sessions putty.reg`))
+		}
+
+	case importStepPreview:
+		b.WriteString(sectionTitleStyle.Render(fmt.Sprintf("Import from %s: %d of %d selected",
+			w.source(), len(w.Selected()), len(w.candidates))))
+		b.WriteString("\n\n")
+		b.WriteString(w.renderCandidates())
+		if len(w.skipped) > 0 {
+			b.WriteString("\n")
+			b.WriteString(hintStyle.Render(truncate("Skipped: "+strings.Join(w.skipped, ", "), 70)))
+		}
+		for _, warning := range w.warnings {
+			b.WriteString("\n")
+			b.WriteString(lipgloss.NewStyle().Foreground(colorAccent).Render(truncate(warning, 70)))
+		}
+	}
+
+	if w.error != "" {
+		b.WriteString("\n\n")
+		b.WriteString(errorStyle.Render(w.error))
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(80).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	availableHeight := max(w.height-3, 0)
+	return lipgloss.Place(w.width, availableHeight, lipgloss.Center, lipgloss.Center, box)
+}
+
+// visibleRows is the number of candidates shown at once in the preview
+func (w *ImportWizard) visibleRows() int {
+	return max(w.height-16, 3)
+}
+
+func (w *ImportWizard) renderCandidates() string {
+	return renderImportCandidates(w.candidates, w.selectedIdx, &w.scrollOffset, w.visibleRows())
+}
+
+// renderImportCandidates lists rows candidates around the selected one,
+// scrolling scrollOffset to keep it visible
+func renderImportCandidates(candidates []importCandidate, selectedIdx int, scrollOffset *int, rows int) string {
+	if selectedIdx < *scrollOffset {
+		*scrollOffset = selectedIdx
+	}
+	if selectedIdx >= *scrollOffset+rows {
+		*scrollOffset = selectedIdx - rows + 1
+	}
+
+	var b strings.Builder
+	end := min(*scrollOffset+rows, len(candidates))
+	for i := *scrollOffset; i < end; i++ {
+		c := candidates[i]
+		checkbox := "[ ]"
+		if c.selected {
+			checkbox = "[x]"
+		}
+		target := fmt.Sprintf("%s@%s:%d", c.conn.Username, c.conn.Host, c.conn.Port)
+		line := fmt.Sprintf("%s %-22s %s", checkbox, truncate(c.conn.Name, 22), truncate(target, 34))
+		if c.duplicate {
+			line += " (exists)"
+		}
+		switch {
+		case i == selectedIdx:
+			b.WriteString(focusedStyle.Bold(true).Render("> " + line))
+		case c.duplicate:
+			b.WriteString(lipgloss.NewStyle().Foreground(colorInactive).Render("  " + line))
+		default:
+			b.WriteString(blurredStyle.Render("  " + line))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func (w *ImportWizard) SetSize(width, height int) {
+	w.width = width
+	w.height = height
+}
+
+// Selected returns the connections chosen for import
+func (w *ImportWizard) Selected() []config.SSHConnection {
+	var conns []config.SSHConnection
+	for _, c := range w.candidates {
+		if c.selected {
+			conns = append(conns, c.conn)
+		}
+	}
+	return conns
+}
+
+// IsPreviewing reports whether the session selection page is showing
+func (w *ImportWizard) IsPreviewing() bool {
+	return w.step == importStepPreview
+}
+
+func (w *ImportWizard) IsConfirmed() bool {
+	return w.confirmed
+}
+
+func (w *ImportWizard) IsCanceled() bool {
+	return w.canceled
+}
@@ -0,0 +1,216 @@
+package components
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// Input indices for the KeePassXC config form
+const (
+	keePassInputDatabase = iota
+	keePassInputKeyFile
+	keePassInputPassword
+	keePassInputGroup
+	keePassInputCount
+)
+
+var keePassInputLabels = [keePassInputCount]string{
+	"Database (.kdbx)",
+	"Key File (optional)",
+	"Password",
+	"Group",
+}
+
+type KeePassXCConfigForm struct {
+	inputs     []textinput.Model
+	focusIndex int
+	submitted  bool
+	canceled   bool
+	ErrorMsg   string
+	width      int
+	height     int
+}
+
+// NewKeePassXCConfigForm creates a form to open a KeePass database
+func NewKeePassXCConfigForm() *KeePassXCConfigForm {
+	inputs := make([]textinput.Model, keePassInputCount)
+	placeholders := [keePassInputCount]string{
+		"~/Passwords.kdbx",
+		"~/Passwords.keyx",
+		"Database password",
+		"SSH",
+	}
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Placeholder = placeholders[i]
+		inputs[i].Width = 50
+		inputs[i].Prompt = ""
+		inputs[i].PromptStyle = blurredStyle
+		inputs[i].TextStyle = blurredStyle
+	}
+	inputs[keePassInputPassword].EchoMode = textinput.EchoPassword
+
+	f := &KeePassXCConfigForm{inputs: inputs}
+	f.updateFocus()
+	return f
+}
+
+func (f *KeePassXCConfigForm) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (f *KeePassXCConfigForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		f.SetSize(msg.Width, msg.Height)
+		return f, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			f.canceled = true
+			return f, nil
+		case "tab", "down", "enter":
+			// Enter submits from the password field or the Submit button
+			if msg.String() == "enter" && (f.focusIndex == keePassInputCount || f.focusIndex == keePassInputPassword) {
+				if valid, err := f.validateForm(); valid {
+					f.submitted = true
+				} else {
+					f.ErrorMsg = err
+				}
+				return f, nil
+			}
+
+			f.focusIndex++
+			if f.focusIndex > keePassInputCount {
+				f.focusIndex = 0
+			}
+			f.updateFocus()
+			return f, nil
+		case "shift+tab", "up":
+			f.focusIndex--
+			if f.focusIndex < 0 {
+				f.focusIndex = keePassInputCount
+			}
+			f.updateFocus()
+			return f, nil
+		}
+	}
+
+	if f.focusIndex < keePassInputCount {
+		var cmd tea.Cmd
+		f.inputs[f.focusIndex], cmd = f.inputs[f.focusIndex].Update(msg)
+		return f, cmd
+	}
+	return f, nil
+}
+
+func (f *KeePassXCConfigForm) updateFocus() {
+	for i := range f.inputs {
+		if i == f.focusIndex {
+			f.inputs[i].Focus()
+			f.inputs[i].PromptStyle = focusedStyle
+			f.inputs[i].TextStyle = focusedStyle
+		} else {
+			f.inputs[i].Blur()
+			f.inputs[i].PromptStyle = blurredStyle
+			f.inputs[i].TextStyle = blurredStyle
+		}
+	}
+}
+
+func (f *KeePassXCConfigForm) View() string {
+	var b strings.Builder
+
+	labelStyle := lipgloss.NewStyle().Foreground(colorSubText)
+
+	b.WriteString(sectionTitleStyle.Render("KeePassXC Database"))
+	b.WriteString("\n\n")
+
+	for i := range f.inputs {
+		b.WriteString(labelStyle.Render(keePassInputLabels[i]))
+		b.WriteString("\n")
+		b.WriteString(f.inputs[i].View())
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(labelStyle.Render("Connections are read and written with keepassxc-cli."))
+	b.WriteString("\n\n")
+
+	button := blurredButton
+	if f.focusIndex == keePassInputCount {
+		button = focusedButton
+	}
+	b.WriteString(button)
+
+	if f.ErrorMsg != "" {
+		b.WriteString("\n\n")
+		b.WriteString(errorStyle.Render(f.ErrorMsg))
+	}
+
+	formBox := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(60).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	availableHeight := max(f.height-3, 0)
+
+	return lipgloss.Place(
+		f.width,
+		availableHeight,
+		lipgloss.Center,
+		lipgloss.Center,
+		formBox,
+	)
+}
+
+func (f *KeePassXCConfigForm) SetSize(width, height int) {
+	f.width = width
+	f.height = height
+}
+
+func (f *KeePassXCConfigForm) IsSubmitted() bool {
+	return f.submitted
+}
+
+func (f *KeePassXCConfigForm) IsCanceled() bool {
+	return f.canceled
+}
+
+// ResetSubmitted allows the form to be submitted again after a failed unlock
+func (f *KeePassXCConfigForm) ResetSubmitted() {
+	f.submitted = false
+}
+
+func (f *KeePassXCConfigForm) SetError(msg string) {
+	f.ErrorMsg = msg
+}
+
+// Config returns the database settings entered in the form
+func (f *KeePassXCConfigForm) Config() *config.KeePassXCConfig {
+	value := func(i int) string { return strings.TrimSpace(f.inputs[i].Value()) }
+	return &config.KeePassXCConfig{
+		DatabasePath: value(keePassInputDatabase),
+		KeyFile:      value(keePassInputKeyFile),
+		Password:     f.inputs[keePassInputPassword].Value(),
+		Group:        value(keePassInputGroup),
+	}
+}
+
+func (f *KeePassXCConfigForm) validateForm() (bool, string) {
+	if strings.TrimSpace(f.inputs[keePassInputDatabase].Value()) == "" {
+		return false, "Database path is required."
+	}
+	if f.inputs[keePassInputPassword].Value() == "" && strings.TrimSpace(f.inputs[keePassInputKeyFile].Value()) == "" {
+		return false, "Enter a password, a key file, or both."
+	}
+	return true, ""
+}
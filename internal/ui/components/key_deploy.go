@@ -0,0 +1,145 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/pkg/sshutil"
+)
+
+// KeyDeployPicker selects a local public key to deploy to a connection's host
+type KeyDeployPicker struct {
+	connection  config.SSHConnection
+	keys        []localKey // only keys with a readable .pub file
+	selectedIdx int
+	selected    *localKey
+	canceled    bool
+	width       int
+	height      int
+}
+
+// NewKeyDeployPicker lists the key pairs in ~/.ssh, preselecting the
+// connection's current key file if it has one
+func NewKeyDeployPicker(conn config.SSHConnection) *KeyDeployPicker {
+	p := &KeyDeployPicker{connection: conn}
+	for _, path := range sshutil.ScanSSHKeys() {
+		info, err := sshutil.ReadPublicKeyInfo(config.ExpandPath(path))
+		if err != nil {
+			continue
+		}
+		if conn.KeyFile != "" && config.ExpandPath(conn.KeyFile) == config.ExpandPath(path) {
+			p.selectedIdx = len(p.keys)
+		}
+		p.keys = append(p.keys, localKey{Path: path, Info: info})
+	}
+	return p
+}
+
+func (p *KeyDeployPicker) Init() tea.Cmd {
+	return nil
+}
+
+func (p *KeyDeployPicker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if p.selected != nil || p.canceled {
+		return p, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.SetSize(msg.Width, msg.Height)
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if p.selectedIdx > 0 {
+				p.selectedIdx--
+			}
+		case "down", "j":
+			if p.selectedIdx < len(p.keys)-1 {
+				p.selectedIdx++
+			}
+		case "enter":
+			if len(p.keys) > 0 {
+				p.selected = &p.keys[p.selectedIdx]
+			}
+		case "esc", "ctrl+c", "q":
+			p.canceled = true
+		}
+	}
+	return p, nil
+}
+
+func (p *KeyDeployPicker) View() string {
+	var b strings.Builder
+
+	b.WriteString(sectionTitleStyle.Render("Deploy Public Key"))
+	b.WriteString("\n\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(colorSubText).Render(
+		fmt.Sprintf("Append to %s@%s:~/.ssh/authorized_keys", p.connection.Username, p.connection.Host)))
+	b.WriteString("\n\n")
+
+	if len(p.keys) == 0 {
+		b.WriteString(blurredStyle.Render("No key pairs found in ~/.ssh."))
+		b.WriteString("\n")
+		b.WriteString(blurredStyle.Render("Generate one from the key manager (m)."))
+	}
+	for i, key := range p.keys {
+		line := fmt.Sprintf("%s  %s", truncate(key.Path, 28), key.Info.Type)
+		if i == p.selectedIdx {
+			b.WriteString(focusedStyle.Bold(true).Render("> " + line))
+		} else {
+			b.WriteString(blurredStyle.Render("  " + line))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(p.keys) > 0 {
+		b.WriteString("\n")
+		b.WriteString(lipgloss.NewStyle().Foreground(colorInactive).Render(
+			"The connection switches to this key once it is installed."))
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(60).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	availableHeight := max(p.height-3, 0)
+	return lipgloss.Place(
+		p.width,
+		availableHeight,
+		lipgloss.Center,
+		lipgloss.Center,
+		box,
+	)
+}
+
+func (p *KeyDeployPicker) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Connection returns the connection the key is deployed to
+func (p *KeyDeployPicker) Connection() config.SSHConnection {
+	return p.connection
+}
+
+// SelectedKey returns the chosen private key path and its authorized_keys
+// line, or empty strings if nothing has been selected yet
+func (p *KeyDeployPicker) SelectedKey() (keyFile, authorizedKey string) {
+	if p.selected == nil {
+		return "", ""
+	}
+	return p.selected.Path, p.selected.Info.AuthorizedKey
+}
+
+func (p *KeyDeployPicker) IsCanceled() bool {
+	return p.canceled
+}
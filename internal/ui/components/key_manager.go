@@ -0,0 +1,241 @@
+package components
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/pkg/sshutil"
+)
+
+// localKey is a private key found in ~/.ssh with its public key details
+type localKey struct {
+	Path string // as shown to the user, e.g. ~/.ssh/id_ed25519
+	Info *sshutil.PublicKeyInfo
+}
+
+// KeyManager lists local SSH keys and generates, copies and deletes them
+type KeyManager struct {
+	keys          []localKey
+	selectedIdx   int
+	scrollOffset  int
+	keyGen        *KeyGenForm
+	confirmDelete bool
+	status        string
+	error         string
+	finished      bool
+	width         int
+	height        int
+}
+
+// NewKeyManager creates a key manager for the keys in ~/.ssh
+func NewKeyManager() *KeyManager {
+	k := &KeyManager{}
+	k.reload()
+	return k
+}
+
+func (k *KeyManager) reload() {
+	k.keys = k.keys[:0]
+	for _, path := range sshutil.ScanSSHKeys() {
+		info, _ := sshutil.ReadPublicKeyInfo(config.ExpandPath(path))
+		k.keys = append(k.keys, localKey{Path: path, Info: info})
+	}
+	if k.selectedIdx >= len(k.keys) {
+		k.selectedIdx = max(len(k.keys)-1, 0)
+	}
+	k.status = fmt.Sprintf("%d keys in ~/.ssh", len(k.keys))
+}
+
+func (k *KeyManager) Init() tea.Cmd {
+	return nil
+}
+
+func (k *KeyManager) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		k.SetSize(size.Width, size.Height)
+		return k, nil
+	}
+
+	if k.keyGen != nil {
+		model, cmd := k.keyGen.Update(msg)
+		k.keyGen = model.(*KeyGenForm)
+		if k.keyGen.IsCanceled() {
+			k.keyGen = nil
+			return k, nil
+		}
+		if result := k.keyGen.Result(); result != nil {
+			k.keyGen = nil
+			k.reload()
+			k.status = fmt.Sprintf("Generated %s successfully (%s)", result.Key.PrivateKeyPath, result.Key.Fingerprint)
+		}
+		return k, cmd
+	}
+
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		if k.confirmDelete {
+			switch msg.String() {
+			case "y", "Y":
+				k.confirmDelete = false
+				k.deleteSelected()
+			case "n", "N", "esc":
+				k.confirmDelete = false
+				k.status = "Cancelled"
+			}
+			return k, nil
+		}
+
+		switch msg.String() {
+		case "esc", "q":
+			k.finished = true
+		case "up", "k":
+			if k.selectedIdx > 0 {
+				k.selectedIdx--
+			}
+		case "down", "j":
+			if k.selectedIdx < len(k.keys)-1 {
+				k.selectedIdx++
+			}
+		case "g":
+			k.keyGen = NewKeyGenForm("", false)
+			k.keyGen.SetSize(k.width, k.height)
+			return k, k.keyGen.Init()
+		case "y", "c":
+			if key := k.selected(); key != nil {
+				if key.Info == nil {
+					k.error = "No public key found for " + key.Path
+				} else if err := CopyToClipboard(key.Info.AuthorizedKey); err != nil {
+					k.error = fmt.Sprintf("Failed to copy: %s", err)
+				} else {
+					k.status = "Public key copied to clipboard successfully"
+				}
+			}
+		case "d", "D":
+			if k.selected() != nil {
+				k.confirmDelete = true
+			}
+		case "r", "ctrl+l":
+			k.reload()
+		}
+	}
+	return k, nil
+}
+
+func (k *KeyManager) selected() *localKey {
+	if k.selectedIdx < 0 || k.selectedIdx >= len(k.keys) {
+		return nil
+	}
+	return &k.keys[k.selectedIdx]
+}
+
+func (k *KeyManager) deleteSelected() {
+	key := k.selected()
+	if key == nil {
+		return
+	}
+	path := config.ExpandPath(key.Path)
+	if err := os.Remove(path); err != nil {
+		k.error = fmt.Sprintf("Failed to delete %s: %s", key.Path, err)
+		return
+	}
+	if err := os.Remove(path + ".pub"); err != nil && !os.IsNotExist(err) {
+		k.error = fmt.Sprintf("Deleted private key but not %s.pub: %s", key.Path, err)
+	}
+	deleted := key.Path
+	k.reload()
+	k.status = fmt.Sprintf("Deleted %s successfully", deleted)
+}
+
+// listHeight is the number of key rows that fit on screen
+func (k *KeyManager) listHeight() int {
+	// header + column header + status line
+	return max(k.height-3, 1)
+}
+
+func (k *KeyManager) View() string {
+	if k.finished {
+		return ""
+	}
+	if k.keyGen != nil {
+		return k.keyGen.View()
+	}
+
+	header := scpHeaderStyle.Width(k.width).Render("SSH Keys - ~/.ssh")
+	return lipgloss.JoinVertical(lipgloss.Left, header, k.renderTable(), k.renderStatus())
+}
+
+func (k *KeyManager) renderTable() string {
+	height := k.listHeight()
+	if k.selectedIdx < k.scrollOffset {
+		k.scrollOffset = k.selectedIdx
+	}
+	if k.selectedIdx >= k.scrollOffset+height {
+		k.scrollOffset = k.selectedIdx - height + 1
+	}
+
+	// FILE(30) TYPE(20) FINGERPRINT(52) COMMENT(rest)
+	format := "%-30s %-20s %-52s %s"
+	lines := []string{headerStyle.Render(fmt.Sprintf(format, "FILE", "TYPE", "FINGERPRINT", "COMMENT"))}
+
+	end := min(k.scrollOffset+height, len(k.keys))
+	for i := k.scrollOffset; i < end; i++ {
+		key := k.keys[i]
+		keyType, fingerprint, comment := "?", "(no .pub file)", ""
+		if key.Info != nil {
+			keyType, fingerprint, comment = key.Info.Type, key.Info.Fingerprint, key.Info.Comment
+		}
+		line := fmt.Sprintf(format, truncate(key.Path, 30), truncate(keyType, 20), fingerprint, comment)
+		if i == k.selectedIdx {
+			line = scpSelectedStyle.Width(k.width).Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(k.keys) == 0 {
+		lines = append(lines, "  (no keys found, press g to generate one)")
+	}
+	for len(lines) < height+1 {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (k *KeyManager) renderStatus() string {
+	containerStyle := scpStatusStyle.Width(k.width).Align(lipgloss.Center)
+	errStyle := lipgloss.NewStyle().Foreground(colorError).Bold(true)
+	successStyle := lipgloss.NewStyle().Foreground(colorSuccess).Bold(true)
+
+	switch {
+	case k.error != "":
+		text := errStyle.Render(k.error)
+		k.error = ""
+		return containerStyle.Render(text)
+	case k.confirmDelete:
+		return containerStyle.Render(errStyle.Render(fmt.Sprintf(
+			"Delete %s and its public key? (y/n)", k.selected().Path,
+		)))
+	case strings.Contains(k.status, "successfully"):
+		return containerStyle.Render(successStyle.Render(k.status))
+	}
+	return containerStyle.Render(k.status)
+}
+
+func (k *KeyManager) SetSize(width, height int) {
+	k.width = width
+	k.height = height
+	if k.keyGen != nil {
+		k.keyGen.SetSize(width, height)
+	}
+}
+
+func (k *KeyManager) IsFinished() bool {
+	return k.finished
+}
+
+// IsGenerating reports whether the key generation form is showing
+func (k *KeyManager) IsGenerating() bool {
+	return k.keyGen != nil
+}
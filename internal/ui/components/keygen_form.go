@@ -0,0 +1,324 @@
+package components
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/pkg/sshutil"
+)
+
+// KeyGeneratedMsg reports the outcome of generating a key pair
+type KeyGeneratedMsg struct {
+	Key        *sshutil.GeneratedKey
+	Passphrase string
+	Install    bool // install the public key on the connection's host
+	Err        error
+}
+
+// Input indices for the key generation form
+const (
+	keyGenInputPath = iota
+	keyGenInputComment
+	keyGenInputPassphrase
+	keyGenInputConfirm
+	keyGenInputCount
+)
+
+var keyGenInputLabels = [keyGenInputCount]string{
+	"Private Key File",
+	"Comment",
+	"Passphrase (optional)",
+	"Confirm Passphrase",
+}
+
+// KeyGenForm collects the options for a new SSH key pair and generates it
+type KeyGenForm struct {
+	inputs     []textinput.Model
+	keyType    sshutil.KeyType
+	suffix     string // appended to the default file name, e.g. the connection name
+	canInstall bool
+	install    bool
+	focusIndex int
+	generating bool
+	result     *KeyGeneratedMsg
+	canceled   bool
+	ErrorMsg   string
+	width      int
+	height     int
+}
+
+// NewKeyGenForm creates a key generation form. name, if set, is used to
+// suggest a per-connection file name. canInstall enables the option to
+// install the public key on the host after the next password login.
+func NewKeyGenForm(name string, canInstall bool) *KeyGenForm {
+	inputs := make([]textinput.Model, keyGenInputCount)
+	placeholders := [keyGenInputCount]string{
+		"~/.ssh/id_ed25519",
+		"user@host",
+		"Leave empty for no passphrase",
+		"",
+	}
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Placeholder = placeholders[i]
+		inputs[i].Width = 50
+		inputs[i].Prompt = "> "
+		inputs[i].PromptStyle = blurredStyle
+		inputs[i].TextStyle = blurredStyle
+	}
+	inputs[keyGenInputPassphrase].EchoMode = textinput.EchoPassword
+	inputs[keyGenInputPassphrase].EchoCharacter = '•'
+	inputs[keyGenInputConfirm].EchoMode = textinput.EchoPassword
+	inputs[keyGenInputConfirm].EchoCharacter = '•'
+
+	f := &KeyGenForm{
+		inputs:     inputs,
+		keyType:    sshutil.KeyTypeEd25519,
+		suffix:     keyFileSuffix(name),
+		canInstall: canInstall,
+		install:    canInstall,
+	}
+	inputs[keyGenInputPath].SetValue(f.defaultPath())
+	inputs[keyGenInputComment].SetValue(defaultKeyComment())
+	f.updateFocus()
+	return f
+}
+
+// keyFileSuffix turns a connection name into a safe file name suffix
+func keyFileSuffix(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		case r == ' ' || r == '.':
+			return '_'
+		}
+		return -1
+	}, strings.TrimSpace(name))
+	if name == "" {
+		return ""
+	}
+	return "_" + strings.ToLower(name)
+}
+
+func defaultKeyComment() string {
+	username := "sxt"
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return username
+	}
+	return username + "@" + host
+}
+
+func (f *KeyGenForm) defaultPath() string {
+	return "~/.ssh/" + f.keyType.DefaultKeyName() + f.suffix
+}
+
+func (f *KeyGenForm) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (f *KeyGenForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		f.SetSize(msg.Width, msg.Height)
+		return f, nil
+
+	case KeyGeneratedMsg:
+		f.generating = false
+		if msg.Err != nil {
+			f.ErrorMsg = msg.Err.Error()
+			return f, nil
+		}
+		f.result = &msg
+		return f, nil
+
+	case tea.KeyMsg:
+		if f.generating {
+			return f, nil
+		}
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			f.canceled = true
+			return f, nil
+		case "ctrl+t":
+			// Switch algorithm, keeping a custom path if the user typed one
+			wasDefault := f.inputs[keyGenInputPath].Value() == f.defaultPath()
+			if f.keyType == sshutil.KeyTypeEd25519 {
+				f.keyType = sshutil.KeyTypeRSA
+			} else {
+				f.keyType = sshutil.KeyTypeEd25519
+			}
+			if wasDefault {
+				f.inputs[keyGenInputPath].SetValue(f.defaultPath())
+			}
+			return f, nil
+		case "ctrl+o":
+			if f.canInstall {
+				f.install = !f.install
+			}
+			return f, nil
+		case "tab", "down", "enter":
+			if msg.String() == "enter" && f.focusIndex == keyGenInputCount {
+				if valid, err := f.validateForm(); !valid {
+					f.ErrorMsg = err
+					return f, nil
+				}
+				f.ErrorMsg = ""
+				f.generating = true
+				return f, f.generate()
+			}
+			f.focusIndex = (f.focusIndex + 1) % (keyGenInputCount + 1)
+			f.updateFocus()
+			return f, nil
+		case "shift+tab", "up":
+			f.focusIndex--
+			if f.focusIndex < 0 {
+				f.focusIndex = keyGenInputCount
+			}
+			f.updateFocus()
+			return f, nil
+		}
+	}
+
+	if f.focusIndex < keyGenInputCount {
+		newInput, cmd := f.inputs[f.focusIndex].Update(msg)
+		f.inputs[f.focusIndex] = newInput
+		return f, cmd
+	}
+	return f, nil
+}
+
+func (f *KeyGenForm) generate() tea.Cmd {
+	keyType := f.keyType
+	path := config.ExpandPath(strings.TrimSpace(f.inputs[keyGenInputPath].Value()))
+	comment := strings.TrimSpace(f.inputs[keyGenInputComment].Value())
+	passphrase := f.inputs[keyGenInputPassphrase].Value()
+	install := f.canInstall && f.install
+	return func() tea.Msg {
+		key, err := sshutil.GenerateKeyPair(keyType, path, comment, passphrase)
+		return KeyGeneratedMsg{Key: key, Passphrase: passphrase, Install: install, Err: err}
+	}
+}
+
+func (f *KeyGenForm) validateForm() (bool, string) {
+	path := strings.TrimSpace(f.inputs[keyGenInputPath].Value())
+	if path == "" {
+		return false, "Key file path is required."
+	}
+	if strings.HasSuffix(path, ".pub") {
+		return false, "Enter the private key path, not the .pub file."
+	}
+	if f.inputs[keyGenInputPassphrase].Value() != f.inputs[keyGenInputConfirm].Value() {
+		return false, "Passphrases do not match."
+	}
+	return true, ""
+}
+
+func (f *KeyGenForm) updateFocus() {
+	for i := range f.inputs {
+		if i == f.focusIndex {
+			f.inputs[i].Focus()
+			f.inputs[i].PromptStyle = focusedStyle
+			f.inputs[i].TextStyle = focusedStyle
+		} else {
+			f.inputs[i].Blur()
+			f.inputs[i].PromptStyle = blurredStyle
+			f.inputs[i].TextStyle = blurredStyle
+		}
+	}
+}
+
+func (f *KeyGenForm) View() string {
+	var b strings.Builder
+
+	labelStyle := lipgloss.NewStyle().Foreground(colorSubText)
+
+	b.WriteString(sectionTitleStyle.Render("Generate SSH Key"))
+	b.WriteString("\n\n")
+
+	var types []string
+	for _, t := range []sshutil.KeyType{sshutil.KeyTypeEd25519, sshutil.KeyTypeRSA} {
+		if t == f.keyType {
+			types = append(types, focusedStyle.Bold(true).Render("● "+t.String()))
+		} else {
+			types = append(types, blurredStyle.Render("○ "+t.String()))
+		}
+	}
+	b.WriteString(labelStyle.Render("Key Type (ctrl+t to change)"))
+	b.WriteString("\n")
+	b.WriteString(strings.Join(types, "   "))
+	b.WriteString("\n\n")
+
+	for i := range f.inputs {
+		b.WriteString(labelStyle.Render(keyGenInputLabels[i]))
+		b.WriteString("\n")
+		b.WriteString(f.inputs[i].View())
+		b.WriteString("\n\n")
+	}
+
+	if f.canInstall {
+		checkbox := "[ ]"
+		if f.install {
+			checkbox = "[x]"
+		}
+		b.WriteString(labelStyle.Render(checkbox + " Install on host after saving (ctrl+o)"))
+		b.WriteString("\n\n")
+	}
+
+	switch {
+	case f.generating:
+		b.WriteString(labelStyle.Render("Generating key..."))
+	case f.focusIndex == keyGenInputCount:
+		b.WriteString(focusedStyle.Render("[ Generate ]"))
+	default:
+		b.WriteString(fmt.Sprintf("[ %s ]", blurredStyle.Render("Generate")))
+	}
+
+	if f.ErrorMsg != "" {
+		b.WriteString("\n\n")
+		b.WriteString(errorStyle.Render(f.ErrorMsg))
+	}
+
+	formBox := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(60).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	availableHeight := max(f.height-3, 0)
+
+	return lipgloss.Place(
+		f.width,
+		availableHeight,
+		lipgloss.Center,
+		lipgloss.Center,
+		formBox,
+	)
+}
+
+func (f *KeyGenForm) SetSize(width, height int) {
+	f.width = width
+	f.height = height
+}
+
+func (f *KeyGenForm) IsCanceled() bool {
+	return f.canceled
+}
+
+// Result returns the generated key once generation has succeeded
+func (f *KeyGenForm) Result() *KeyGeneratedMsg {
+	return f.result
+}
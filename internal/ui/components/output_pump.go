@@ -0,0 +1,196 @@
+package components
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// maxPendingOutput is how much output the pump reads ahead of the
+	// terminal before it stops reading, leaving the rest to SSH flow control
+	maxPendingOutput = 1 << 20
+	// outputFrameInterval caps the batches, and so the renders, at 60 a
+	// second; output arriving in between waits for the next batch
+	outputFrameInterval = time.Second / 60
+	// outputSkipQuiet is how long output has to pause to end a skip
+	outputSkipQuiet = 300 * time.Millisecond
+)
+
+// outputBatch is the output handed to the terminal at once
+type outputBatch struct {
+	Data    []byte
+	Skipped int64 // bytes dropped by a skip that just ended
+}
+
+// outputPump reads a session in the background and hands the output over
+// in batches: everything that arrived while the previous batch was being
+// written and rendered, and at most one batch per frame. Fast output such
+// as `yes` or a large cat then costs one render per frame instead of one
+// per read, without losing any of it.
+type outputPump struct {
+	mutex     sync.Mutex
+	pending   []byte
+	err       error
+	lastBatch time.Time
+	skipping  bool // dropping output until it pauses
+	skipped   int64
+	lastSkip  time.Time
+	ready     chan struct{} // signaled when output or an error is pending
+	drained   chan struct{} // signaled when a batch is taken
+	stop      chan struct{}
+	once      sync.Once
+}
+
+func newOutputPump(r io.Reader) *outputPump {
+	p := &outputPump{
+		ready:   make(chan struct{}, 1),
+		drained: make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+	}
+	go p.run(r)
+	return p
+}
+
+func (p *outputPump) run(r io.Reader) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		p.mutex.Lock()
+		if p.skipping {
+			p.skipped += int64(n)
+			p.lastSkip = time.Now()
+		} else {
+			p.pending = append(p.pending, buf[:n]...)
+		}
+		if err != nil {
+			p.err = err
+		}
+		p.mutex.Unlock()
+		notify(p.ready)
+		if err != nil {
+			return
+		}
+		if !p.waitForRoom() {
+			return
+		}
+	}
+}
+
+// waitForRoom blocks while the terminal is behind, reporting false once
+// the pump is closed
+func (p *outputPump) waitForRoom() bool {
+	for {
+		p.mutex.Lock()
+		full := len(p.pending) >= maxPendingOutput
+		p.mutex.Unlock()
+		if !full {
+			return true
+		}
+		select {
+		case <-p.drained:
+		case <-p.stop:
+			return false
+		}
+	}
+}
+
+// Next blocks until output is pending and returns all of it, waiting for
+// the next frame when the previous batch was taken less than a frame ago.
+// A skip ends with a batch carrying only the count of dropped bytes. The
+// error that ended the stream is returned once the output before it was
+// taken.
+func (p *outputPump) Next() (outputBatch, error) {
+	for {
+		p.mutex.Lock()
+		if p.skipping && (p.err != nil || time.Since(p.lastSkip) >= outputSkipQuiet) {
+			batch := outputBatch{Skipped: p.skipped}
+			p.skipping, p.skipped = false, 0
+			p.mutex.Unlock()
+			return batch, nil
+		}
+		if len(p.pending) > 0 {
+			if wait := outputFrameInterval - time.Since(p.lastBatch); wait > 0 {
+				p.mutex.Unlock()
+				if !p.sleep(wait) {
+					return outputBatch{}, io.EOF
+				}
+				continue
+			}
+			batch := outputBatch{Data: p.pending}
+			p.pending = nil
+			p.lastBatch = time.Now()
+			p.mutex.Unlock()
+			notify(p.drained)
+			return batch, nil
+		}
+		err, skipping := p.err, p.skipping
+		p.mutex.Unlock()
+		if err != nil {
+			return outputBatch{}, err
+		}
+
+		var quiet <-chan time.Time
+		if skipping {
+			quiet = time.After(outputSkipQuiet)
+		}
+		select {
+		case <-p.ready:
+		case <-quiet:
+		case <-p.stop:
+			return outputBatch{}, io.EOF
+		}
+	}
+}
+
+// sleep waits for d, reporting false if the pump is closed meanwhile
+func (p *outputPump) sleep(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-p.stop:
+		return false
+	}
+}
+
+// Backlog returns how much output is waiting for the terminal
+func (p *outputPump) Backlog() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.pending)
+}
+
+// Skip drops the output waiting for the terminal and everything that
+// follows until the output pauses, to jump to the end of a long dump
+func (p *outputPump) Skip() {
+	p.mutex.Lock()
+	p.skipping = true
+	p.skipped += int64(len(p.pending))
+	p.pending = nil
+	p.lastSkip = time.Now()
+	p.mutex.Unlock()
+	notify(p.drained)
+	notify(p.ready)
+}
+
+// IsSkipping reports whether output is being skipped
+func (p *outputPump) IsSkipping() bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.skipping
+}
+
+// Close stops a pump waiting for the terminal to catch up
+func (p *outputPump) Close() {
+	p.once.Do(func() { close(p.stop) })
+}
+
+// notify wakes up a waiter without blocking when one is already due
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
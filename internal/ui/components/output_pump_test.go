@@ -0,0 +1,81 @@
+package components
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+// chunkReader returns its chunks one read at a time, then err
+type chunkReader struct {
+	chunks []string
+	err    error
+	wait   chan struct{} // closed to let the reads go
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	<-r.wait
+	if len(r.chunks) == 0 {
+		return 0, r.err
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	return n, nil
+}
+
+func TestOutputPumpBatches(t *testing.T) {
+	reader := &chunkReader{chunks: []string{"a", "b", "c"}, err: io.EOF, wait: make(chan struct{})}
+	pump := newOutputPump(reader)
+	defer pump.Close()
+
+	close(reader.wait)
+	var got string
+	for {
+		batch, err := pump.Next()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("Expected EOF at the end, got %v", err)
+			}
+			break
+		}
+		got += string(batch.Data)
+	}
+	if got != "abc" {
+		t.Errorf("Expected all output before the error, got %q", got)
+	}
+}
+
+func TestOutputPumpSkip(t *testing.T) {
+	reader := &chunkReader{chunks: []string{"abc", "def"}, err: io.EOF, wait: make(chan struct{})}
+	pump := newOutputPump(reader)
+	defer pump.Close()
+
+	pump.Skip()
+	if !pump.IsSkipping() {
+		t.Fatal("Expected the pump to be skipping")
+	}
+	close(reader.wait)
+	batch, err := pump.Next()
+	if err != nil {
+		t.Fatalf("Expected the skip to end before the error, got %v", err)
+	}
+	if len(batch.Data) != 0 || batch.Skipped != 6 {
+		t.Errorf("Expected 6 skipped bytes and no data, got %d and %q", batch.Skipped, batch.Data)
+	}
+	if pump.IsSkipping() {
+		t.Error("Expected the skip to have ended")
+	}
+	if _, err := pump.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected EOF after the skip, got %v", err)
+	}
+}
+
+func TestOutputPumpClose(t *testing.T) {
+	reader := &chunkReader{err: io.EOF, wait: make(chan struct{})}
+	defer close(reader.wait)
+	pump := newOutputPump(reader)
+	pump.Close()
+	if _, err := pump.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("Expected EOF from a closed pump, got %v", err)
+	}
+}
@@ -123,7 +123,7 @@ func (m *PasswordModal) View() string {
 			pass = "(empty)"
 		}
 
-		passStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("212")).Bold(true)
+		passStyle := lipgloss.NewStyle().Foreground(colorAccent).Bold(true)
 		if i != m.index && len(m.entries) > 1 {
 			passStyle = lipgloss.NewStyle().Foreground(colorInactive)
 		}
@@ -134,7 +134,7 @@ func (m *PasswordModal) View() string {
 	copyStatus := " "
 	if m.copied {
 		copyStatus = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("42")). // Green
+			Foreground(colorSuccess). // Green
 			Render("✓ Copied to clipboard!")
 	}
 	content.WriteString("\n" + copyStatus + "\n")
@@ -0,0 +1,491 @@
+package components
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+const (
+	portDialTimeout    = 3 * time.Second
+	portWatchInterval  = 2 * time.Second
+	portCheckerHeaders = 4 // header, input, blank line, column header
+)
+
+// PortChecker message types
+type (
+	PortCheckMsg struct {
+		RunID   int
+		Index   int
+		Latency time.Duration
+		Err     error
+	}
+
+	ListeningPortsMsg struct {
+		Sockets []ssh.ListeningSocket
+		Err     error
+	}
+
+	portWatchTickMsg struct {
+		RunID int
+	}
+)
+
+// portCheck is the state of one target of a check
+type portCheck struct {
+	target  ssh.PortTarget
+	done    bool
+	latency time.Duration
+	err     error
+}
+
+// PortChecker checks whether ports are reachable from a remote host, by
+// dialing them through the SSH connection, and shows which process listens
+// on them according to ss or netstat
+type PortChecker struct {
+	connection    config.SSHConnection
+	client        *ssh.Client
+	input         textinput.Model
+	editing       bool
+	checks        []portCheck
+	runID         int
+	listening     []ssh.ListeningSocket
+	showListening bool
+	watching      bool
+	selectedIdx   int
+	scrollOffset  int
+	status        string
+	error         string
+	loading       bool
+	finished      bool
+	width         int
+	height        int
+}
+
+// NewPortChecker creates a new remote port checker component
+func NewPortChecker(conn config.SSHConnection) *PortChecker {
+	input := textinput.New()
+	input.Placeholder = "22, 80 443, 8000-8010, db:5432"
+	input.Prompt = "Ports: "
+	input.CharLimit = 512
+	return &PortChecker{
+		connection: conn,
+		input:      input,
+		status:     connectingStatus(conn),
+		loading:    true,
+	}
+}
+
+func (p *PortChecker) Init() tea.Cmd {
+	return connectSSHClient(p.connection)
+}
+
+func (p *PortChecker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.SetSize(msg.Width, msg.Height)
+		return p, nil
+
+	case SSHClientMsg:
+		p.loading = false
+		if msg.Err != nil {
+			p.error = fmt.Sprintf("Failed to connect: %s", msg.Err)
+			p.status = "Connection failed"
+			return p, nil
+		}
+		p.client = msg.Client
+		p.status = "Enter the ports to check"
+		p.editing = true
+		return p, tea.Batch(p.input.Focus(), p.loadListening())
+
+	case SSHPassphraseRequiredMsg:
+		return p, func() tea.Msg { return msg }
+
+	case SSHPasswordRequiredMsg:
+		return p, func() tea.Msg { return msg }
+
+	case ListeningPortsMsg:
+		if msg.Err != nil {
+			// Dialing still works without ss or netstat
+			p.listening = nil
+			p.error = fmt.Sprintf("Failed to list listening ports: %s", msg.Err)
+			return p, nil
+		}
+		p.listening = msg.Sockets
+		return p, nil
+
+	case PortCheckMsg:
+		// Results of an earlier check arriving after a recheck are dropped
+		if msg.RunID != p.runID || msg.Index >= len(p.checks) {
+			return p, nil
+		}
+		check := &p.checks[msg.Index]
+		check.done = true
+		check.latency = msg.Latency
+		check.err = msg.Err
+		p.updateStatus()
+		if p.watching && p.checksDone() {
+			runID := p.runID
+			return p, tea.Tick(portWatchInterval, func(time.Time) tea.Msg {
+				return portWatchTickMsg{RunID: runID}
+			})
+		}
+		return p, nil
+
+	case portWatchTickMsg:
+		if msg.RunID == p.runID && p.watching && !p.finished {
+			return p, p.recheck()
+		}
+		return p, nil
+
+	case tea.KeyMsg:
+		if p.editing {
+			return p.handleInputKey(msg)
+		}
+		return p.handleKey(msg)
+	}
+
+	if p.editing {
+		var cmd tea.Cmd
+		p.input, cmd = p.input.Update(msg)
+		return p, cmd
+	}
+	return p, nil
+}
+
+func (p *PortChecker) handleInputKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		if p.checks == nil {
+			p.close()
+			return p, nil
+		}
+		p.editing = false
+		p.input.Blur()
+		return p, nil
+	case "enter":
+		targets, err := ssh.ParsePortTargets(p.input.Value())
+		if err != nil {
+			p.error = err.Error()
+			return p, nil
+		}
+		p.editing = false
+		p.input.Blur()
+		p.checks = make([]portCheck, len(targets))
+		for i, target := range targets {
+			p.checks[i] = portCheck{target: target}
+		}
+		p.selectedIdx = 0
+		return p, p.recheck()
+	}
+	var cmd tea.Cmd
+	p.input, cmd = p.input.Update(msg)
+	return p, cmd
+}
+
+func (p *PortChecker) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		if p.showListening {
+			p.showListening = false
+			p.selectedIdx = 0
+			return p, nil
+		}
+		p.close()
+		return p, nil
+	case "up", "k":
+		if p.selectedIdx > 0 {
+			p.selectedIdx--
+		}
+	case "down", "j":
+		if p.selectedIdx < p.rowCount()-1 {
+			p.selectedIdx++
+		}
+	case "pgup":
+		p.selectedIdx = max(p.selectedIdx-p.listHeight(), 0)
+	case "pgdown":
+		p.selectedIdx = max(min(p.selectedIdx+p.listHeight(), p.rowCount()-1), 0)
+	case "e", "/":
+		if p.client != nil {
+			p.editing = true
+			p.showListening = false
+			return p, p.input.Focus()
+		}
+	case "enter", "r", "ctrl+l":
+		if p.client != nil && p.checks != nil {
+			return p, p.recheck()
+		}
+	case "w":
+		if p.client == nil || p.checks == nil {
+			return p, nil
+		}
+		p.watching = !p.watching
+		if p.watching && p.checksDone() {
+			return p, p.recheck()
+		}
+		p.updateStatus()
+	case "l":
+		if p.client != nil {
+			p.showListening = !p.showListening
+			p.selectedIdx = 0
+			if p.showListening {
+				return p, p.loadListening()
+			}
+		}
+	}
+	return p, nil
+}
+
+func (p *PortChecker) close() {
+	p.finished = true
+	p.watching = false
+	if p.client != nil {
+		p.client.Close()
+	}
+}
+
+// recheck dials every target again and refreshes the listening sockets
+func (p *PortChecker) recheck() tea.Cmd {
+	p.runID++
+	runID, client := p.runID, p.client
+	cmds := []tea.Cmd{p.loadListening()}
+	for i := range p.checks {
+		p.checks[i] = portCheck{target: p.checks[i].target}
+		target, index := p.checks[i].target, i
+		cmds = append(cmds, func() tea.Msg {
+			latency, err := client.DialRemotePort(target, portDialTimeout)
+			return PortCheckMsg{RunID: runID, Index: index, Latency: latency, Err: err}
+		})
+	}
+	p.updateStatus()
+	return tea.Batch(cmds...)
+}
+
+func (p *PortChecker) loadListening() tea.Cmd {
+	client := p.client
+	return func() tea.Msg {
+		sockets, err := client.ListListeningPorts()
+		return ListeningPortsMsg{Sockets: sockets, Err: err}
+	}
+}
+
+func (p *PortChecker) checksDone() bool {
+	return !slices.ContainsFunc(p.checks, func(c portCheck) bool { return !c.done })
+}
+
+func (p *PortChecker) updateStatus() {
+	open, done := 0, 0
+	for _, check := range p.checks {
+		if check.done {
+			done++
+			if check.err == nil {
+				open++
+			}
+		}
+	}
+	if done < len(p.checks) {
+		p.status = fmt.Sprintf("Checking %d/%d ports...", done, len(p.checks))
+		return
+	}
+	p.status = fmt.Sprintf("%d of %d ports open", open, len(p.checks))
+	if p.watching {
+		p.status += fmt.Sprintf(" | watching every %s", portWatchInterval)
+	}
+}
+
+// portState names the outcome of a check
+func portState(check portCheck) (string, lipgloss.Style) {
+	switch {
+	case !check.done:
+		return "checking", lipgloss.NewStyle().Foreground(colorSubText)
+	case check.err == nil:
+		return "open", lipgloss.NewStyle().Foreground(colorSuccess)
+	case strings.Contains(check.err.Error(), "refused"):
+		return "closed", lipgloss.NewStyle().Foreground(colorError).Bold(true)
+	case strings.Contains(check.err.Error(), "timed out"):
+		return "timeout", lipgloss.NewStyle().Foreground(colorWarning)
+	default:
+		return "error", lipgloss.NewStyle().Foreground(colorWarning)
+	}
+}
+
+// listenersOf describes the sockets listening on the port of a target on
+// the remote host itself
+func (p *PortChecker) listenersOf(target ssh.PortTarget) string {
+	switch target.Host {
+	case "", "localhost", "127.0.0.1", "::1":
+	default:
+		return ""
+	}
+	var listeners []string
+	for _, s := range p.listening {
+		if s.Port != target.Port {
+			continue
+		}
+		listener := s.Address
+		if s.Process != "" {
+			listener += " (" + s.Process + ")"
+		}
+		if !slices.Contains(listeners, listener) {
+			listeners = append(listeners, listener)
+		}
+	}
+	return strings.Join(listeners, ", ")
+}
+
+func (p *PortChecker) rowCount() int {
+	if p.showListening {
+		return len(p.listening)
+	}
+	return len(p.checks)
+}
+
+func (p *PortChecker) listHeight() int {
+	// header, input, blank line and column header above, status line below
+	return max(p.height-portCheckerHeaders-1, 1)
+}
+
+func (p *PortChecker) View() string {
+	if p.finished {
+		return ""
+	}
+
+	title := "Port Check"
+	if p.showListening {
+		title = "Listening Ports"
+	}
+	headerText := fmt.Sprintf(
+		"%s@%s:%d - %s - %s",
+		p.connection.Username, p.connection.Host, p.connection.Port, p.connection.Name, title,
+	)
+	header := scpHeaderStyle.Width(p.width).Render(truncate(headerText, max(p.width-2, 10)))
+
+	var body string
+	if p.loading {
+		body = lipgloss.NewStyle().
+			Width(p.width).
+			Height(p.listHeight()+portCheckerHeaders-1).
+			Align(lipgloss.Center, lipgloss.Center).
+			Render(p.status)
+	} else {
+		inputLine := p.input.View()
+		if !p.editing {
+			inputLine = blurredStyle.Render(inputLine)
+		}
+		rows := p.renderChecks()
+		if p.showListening {
+			rows = p.renderListening()
+		}
+		body = inputLine + "\n\n" + rows
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, body, p.renderStatus())
+}
+
+// visibleRange scrolls the selection into view and returns the rows to show
+func (p *PortChecker) visibleRange(rows int) (int, int) {
+	height := p.listHeight()
+	if p.selectedIdx < p.scrollOffset {
+		p.scrollOffset = p.selectedIdx
+	}
+	if p.selectedIdx >= p.scrollOffset+height {
+		p.scrollOffset = p.selectedIdx - height + 1
+	}
+	return p.scrollOffset, min(p.scrollOffset+height, rows)
+}
+
+func (p *PortChecker) renderChecks() string {
+	targetWidth := 28
+	detailWidth := max(p.width-targetWidth-22, 10)
+	format := "%-*s %-9s %-9s %s"
+	lines := []string{headerStyle.Render(fmt.Sprintf(format, targetWidth, "TARGET", "STATE", "LATENCY", "LISTENING / DETAILS"))}
+
+	start, end := p.visibleRange(len(p.checks))
+	for i := start; i < end; i++ {
+		check := p.checks[i]
+		state, style := portState(check)
+		latency := ""
+		if check.done && check.err == nil {
+			latency = check.latency.Round(time.Millisecond).String()
+		}
+		details := p.listenersOf(check.target)
+		if check.err != nil && state != "closed" {
+			details = check.err.Error()
+		}
+		line := fmt.Sprintf(format, targetWidth, truncate(check.target.String(), targetWidth+1), state, latency, truncate(details, detailWidth+1))
+		if i == p.selectedIdx && !p.editing {
+			line = scpSelectedStyle.Width(p.width).Render(line)
+		} else {
+			line = style.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(p.checks) == 0 {
+		lines = append(lines, "  (type ports and press enter)")
+	}
+	return padLines(lines, p.listHeight()+1)
+}
+
+func (p *PortChecker) renderListening() string {
+	format := "%-40s %-7s %s"
+	lines := []string{headerStyle.Render(fmt.Sprintf(format, "ADDRESS", "PORT", "PROCESS"))}
+
+	start, end := p.visibleRange(len(p.listening))
+	for i := start; i < end; i++ {
+		s := p.listening[i]
+		line := fmt.Sprintf(format, truncate(s.Address, 41), fmt.Sprint(s.Port), s.Process)
+		if i == p.selectedIdx {
+			line = scpSelectedStyle.Width(p.width).Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(p.listening) == 0 {
+		lines = append(lines, "  (no listening sockets found, or ss and netstat are missing)")
+	}
+	return padLines(lines, p.listHeight()+1)
+}
+
+// padLines joins lines, padded with empty ones to height
+func padLines(lines []string, height int) string {
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (p *PortChecker) renderStatus() string {
+	containerStyle := scpStatusStyle.Width(p.width).Align(lipgloss.Center)
+	errStyle := lipgloss.NewStyle().Foreground(colorError).Bold(true)
+
+	if p.error != "" {
+		text := errStyle.Render(p.error)
+		p.error = ""
+		return containerStyle.Render(text)
+	}
+	if p.showListening {
+		return containerStyle.Render(fmt.Sprintf("%d listening sockets", len(p.listening)))
+	}
+	return containerStyle.Render(p.status)
+}
+
+func (p *PortChecker) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+	p.input.Width = max(width-12, 20)
+}
+
+func (p *PortChecker) IsFinished() bool {
+	return p.finished
+}
+
+// IsTyping reports whether keys go to the ports input
+func (p *PortChecker) IsTyping() bool {
+	return p.editing
+}
@@ -0,0 +1,171 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+// ForwardsChangedMsg asks for a connection whose LocalForward was moved to
+// free ports to be saved. View-only connections keep the move to the session.
+type ForwardsChangedMsg struct {
+	Connection config.SSHConnection
+}
+
+// PortConflictPrompt tells which LocalForward ports of a connection are
+// taken, and by what, before it connects, and offers the next free ports
+type PortConflictPrompt struct {
+	connection config.SSHConnection
+	conflicts  []ssh.LocalForwardConflict
+	moved      bool // Connect with the forwards on the free ports
+	kept       bool // Connect with the forwards as they are
+	canceled   bool
+	width      int
+	height     int
+}
+
+func NewPortConflictPrompt(conn config.SSHConnection, conflicts []ssh.LocalForwardConflict) *PortConflictPrompt {
+	return &PortConflictPrompt{connection: conn, conflicts: conflicts}
+}
+
+func (m *PortConflictPrompt) Init() tea.Cmd {
+	return nil
+}
+
+func (m *PortConflictPrompt) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.SetSize(msg.Width, msg.Height)
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "y", "Y", "enter":
+			if m.canMove() {
+				m.moved = true
+			}
+		case "n", "N":
+			m.kept = true
+		case "esc", "ctrl+c":
+			m.canceled = true
+		}
+	}
+	return m, nil
+}
+
+// canMove reports whether a free port was found for any of the forwards
+func (m *PortConflictPrompt) canMove() bool {
+	for _, c := range m.conflicts {
+		if c.Replacement != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Connection returns the connection with the taken forwards moved to the
+// free ports found for them
+func (m *PortConflictPrompt) Connection() config.SSHConnection {
+	conn := m.connection
+	conn.LocalForward = movedForwards(conn.LocalForward, m.conflicts)
+	return conn
+}
+
+// movedForwards returns forwards with each conflicting one replaced by its
+// replacement, when there is one
+func movedForwards(forwards []string, conflicts []ssh.LocalForwardConflict) []string {
+	moved := make([]string, len(forwards))
+	for i, spec := range forwards {
+		moved[i] = spec
+		for _, c := range conflicts {
+			if c.Spec == spec && c.Replacement != "" {
+				moved[i] = c.Replacement
+			}
+		}
+	}
+	return moved
+}
+
+func (m *PortConflictPrompt) View() string {
+	if m.canceled {
+		return ""
+	}
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colorWarning).
+		Render("⚠ Local Ports in Use")
+
+	var lines []string
+	for _, c := range m.conflicts {
+		line := fmt.Sprintf("%s: %s", c.Spec, c.Err)
+		if c.Replacement != "" {
+			line += fmt.Sprintf("\n  → %s", c.Replacement)
+		} else {
+			line += "\n  → no free port nearby"
+		}
+		lines = append(lines, line)
+	}
+	details := lipgloss.NewStyle().
+		Foreground(colorSubText).
+		Align(lipgloss.Left).
+		Render(strings.Join(lines, "\n"))
+
+	keys := "n: connect anyway (the forward fails) • Esc: cancel"
+	if m.canMove() {
+		if m.connection.ViewOnly {
+			keys = "y: use the free ports this time • " + keys
+		} else {
+			keys = "y: use the free ports and save them • " + keys
+		}
+	}
+	prompt := lipgloss.NewStyle().
+		Foreground(colorInactive).
+		Render(keys)
+
+	content := lipgloss.JoinVertical(lipgloss.Center,
+		title,
+		"\n",
+		details,
+		"\n",
+		prompt,
+	)
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorWarning).
+		Padding(1, 3).
+		Width(70).
+		Align(lipgloss.Center).
+		Render(content)
+
+	return lipgloss.Place(
+		m.width,
+		m.height,
+		lipgloss.Center,
+		lipgloss.Center,
+		box,
+	)
+}
+
+func (m *PortConflictPrompt) SetSize(width, height int) {
+	m.width = width
+	m.height = height
+}
+
+// IsMoved reports whether the forwards are to move to the free ports
+func (m *PortConflictPrompt) IsMoved() bool {
+	return m.moved
+}
+
+// IsKept reports whether to connect with the forwards as they are
+func (m *PortConflictPrompt) IsKept() bool {
+	return m.kept
+}
+
+func (m *PortConflictPrompt) IsCanceled() bool {
+	return m.canceled
+}
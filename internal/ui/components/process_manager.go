@@ -0,0 +1,393 @@
+package components
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+// ProcessManager message types
+type (
+	ProcessListMsg struct {
+		Processes []ssh.Process
+		Err       error
+	}
+
+	ProcessSignalMsg struct {
+		PID    int
+		Signal string
+		Err    error
+	}
+)
+
+// ProcessSortKey selects the column the process list is ordered by
+type ProcessSortKey int
+
+const (
+	SortByCPU ProcessSortKey = iota
+	SortByMem
+	SortByPID
+)
+
+func (k ProcessSortKey) String() string {
+	switch k {
+	case SortByMem:
+		return "MEM"
+	case SortByPID:
+		return "PID"
+	default:
+		return "CPU"
+	}
+}
+
+// ProcessManager lists remote processes and sends signals to them
+type ProcessManager struct {
+	connection    config.SSHConnection
+	client        *ssh.Client
+	processes     []ssh.Process
+	visible       []ssh.Process // processes after filtering and sorting
+	selectedIdx   int
+	scrollOffset  int
+	sortKey       ProcessSortKey
+	searching     bool
+	query         string
+	pendingSignal string // signal awaiting y/n confirmation
+	pendingTarget *ssh.Process
+	status        string
+	error         string
+	loading       bool
+	finished      bool
+	width         int
+	height        int
+}
+
+// NewProcessManager creates a new remote process manager component
+func NewProcessManager(conn config.SSHConnection) *ProcessManager {
+	return &ProcessManager{
+		connection: conn,
+		status:     connectingStatus(conn),
+		loading:    true,
+		sortKey:    SortByCPU,
+	}
+}
+
+func (p *ProcessManager) Init() tea.Cmd {
+	return connectSSHClient(p.connection)
+}
+
+func (p *ProcessManager) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.SetSize(msg.Width, msg.Height)
+		return p, nil
+
+	case SSHClientMsg:
+		if msg.Err != nil {
+			p.loading = false
+			p.error = fmt.Sprintf("Failed to connect: %s", msg.Err)
+			p.status = "Connection failed"
+			return p, nil
+		}
+		p.client = msg.Client
+		p.status = "Loading processes..."
+		return p, p.refresh()
+
+	case SSHPassphraseRequiredMsg:
+		return p, func() tea.Msg { return msg }
+
+	case SSHPasswordRequiredMsg:
+		return p, func() tea.Msg { return msg }
+
+	case ProcessListMsg:
+		p.loading = false
+		if msg.Err != nil {
+			p.error = fmt.Sprintf("Failed to list processes: %s", msg.Err)
+			return p, nil
+		}
+		p.processes = msg.Processes
+		p.applyView()
+		p.status = fmt.Sprintf("%d processes", len(p.processes))
+		return p, nil
+
+	case ProcessSignalMsg:
+		if msg.Err != nil {
+			p.error = fmt.Sprintf("Sending SIG%s to %d failed: %s", msg.Signal, msg.PID, msg.Err)
+			return p, nil
+		}
+		p.status = fmt.Sprintf("Sent SIG%s to %d successfully", msg.Signal, msg.PID)
+		return p, p.refresh()
+
+	case tea.KeyMsg:
+		return p.handleKey(msg)
+	}
+	return p, nil
+}
+
+func (p *ProcessManager) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Signal confirmation
+	if p.pendingSignal != "" {
+		switch msg.String() {
+		case "y", "Y":
+			target, signal := *p.pendingTarget, p.pendingSignal
+			p.pendingSignal = ""
+			p.pendingTarget = nil
+			p.status = fmt.Sprintf("Sending SIG%s to %d...", signal, target.PID)
+			return p, p.sendSignal(target.PID, signal)
+		case "n", "N", "esc":
+			p.pendingSignal = ""
+			p.pendingTarget = nil
+			p.status = "Cancelled"
+		}
+		return p, nil
+	}
+
+	// Search input
+	if p.searching {
+		switch msg.Type {
+		case tea.KeyEnter:
+			p.searching = false
+		case tea.KeyEsc:
+			p.searching = false
+			p.query = ""
+			p.applyView()
+		case tea.KeyBackspace:
+			if len(p.query) > 0 {
+				p.query = p.query[:len(p.query)-1]
+				p.applyView()
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			p.query += string(msg.Runes)
+			p.applyView()
+		}
+		return p, nil
+	}
+
+	switch msg.String() {
+	case "esc", "q":
+		if p.query != "" && msg.String() == "esc" {
+			p.query = ""
+			p.applyView()
+			return p, nil
+		}
+		p.finished = true
+		if p.client != nil {
+			p.client.Close()
+		}
+		return p, nil
+	case "up", "k":
+		if p.selectedIdx > 0 {
+			p.selectedIdx--
+		}
+	case "down", "j":
+		if p.selectedIdx < len(p.visible)-1 {
+			p.selectedIdx++
+		}
+	case "pgup":
+		p.selectedIdx = max(p.selectedIdx-p.listHeight(), 0)
+	case "pgdown":
+		p.selectedIdx = max(min(p.selectedIdx+p.listHeight(), len(p.visible)-1), 0)
+	case "home", "g":
+		p.selectedIdx = 0
+	case "end", "G":
+		p.selectedIdx = max(len(p.visible)-1, 0)
+	case "/":
+		p.searching = true
+	case "c":
+		p.sortKey = SortByCPU
+		p.applyView()
+	case "m":
+		p.sortKey = SortByMem
+		p.applyView()
+	case "p":
+		p.sortKey = SortByPID
+		p.applyView()
+	case "r", "ctrl+l":
+		if p.client != nil {
+			p.status = "Refreshing..."
+			return p, p.refresh()
+		}
+	case "t":
+		p.confirmSignal("TERM")
+	case "K":
+		p.confirmSignal("KILL")
+	case "H":
+		p.confirmSignal("HUP")
+	}
+	return p, nil
+}
+
+func (p *ProcessManager) confirmSignal(signal string) {
+	if p.selectedIdx < 0 || p.selectedIdx >= len(p.visible) {
+		return
+	}
+	proc := p.visible[p.selectedIdx]
+	p.pendingTarget = &proc
+	p.pendingSignal = signal
+}
+
+// applyView filters by the search query and sorts by the selected column
+func (p *ProcessManager) applyView() {
+	query := strings.ToLower(p.query)
+	p.visible = p.visible[:0]
+	for _, proc := range p.processes {
+		if query == "" ||
+			strings.Contains(strings.ToLower(proc.Command), query) ||
+			strings.Contains(strings.ToLower(proc.User), query) ||
+			strings.Contains(fmt.Sprint(proc.PID), query) {
+			p.visible = append(p.visible, proc)
+		}
+	}
+	sort.SliceStable(p.visible, func(i, j int) bool {
+		a, b := p.visible[i], p.visible[j]
+		switch p.sortKey {
+		case SortByMem:
+			return a.Mem > b.Mem
+		case SortByPID:
+			return a.PID < b.PID
+		default:
+			return a.CPU > b.CPU
+		}
+	})
+	if p.selectedIdx >= len(p.visible) {
+		p.selectedIdx = max(len(p.visible)-1, 0)
+	}
+}
+
+func (p *ProcessManager) refresh() tea.Cmd {
+	client := p.client
+	return func() tea.Msg {
+		procs, err := client.ListProcesses()
+		return ProcessListMsg{Processes: procs, Err: err}
+	}
+}
+
+func (p *ProcessManager) sendSignal(pid int, signal string) tea.Cmd {
+	client := p.client
+	return func() tea.Msg {
+		return ProcessSignalMsg{PID: pid, Signal: signal, Err: client.SignalProcess(pid, signal)}
+	}
+}
+
+// listHeight is the number of process rows that fit on screen
+func (p *ProcessManager) listHeight() int {
+	// header + column header + status line
+	return max(p.height-3, 1)
+}
+
+func (p *ProcessManager) View() string {
+	if p.finished {
+		return ""
+	}
+
+	headerText := fmt.Sprintf(
+		"%s@%s:%d - %s - Processes (sort: %s)",
+		p.connection.Username, p.connection.Host, p.connection.Port, p.connection.Name, p.sortKey,
+	)
+	header := scpHeaderStyle.Width(p.width).Render(headerText)
+
+	var body string
+	if p.loading {
+		body = lipgloss.NewStyle().
+			Width(p.width).
+			Height(p.listHeight()+1).
+			Align(lipgloss.Center, lipgloss.Center).
+			Render(p.status)
+	} else {
+		body = p.renderTable()
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, body, p.renderStatus())
+}
+
+func (p *ProcessManager) renderTable() string {
+	height := p.listHeight()
+	if p.selectedIdx < p.scrollOffset {
+		p.scrollOffset = p.selectedIdx
+	}
+	if p.selectedIdx >= p.scrollOffset+height {
+		p.scrollOffset = p.selectedIdx - height + 1
+	}
+
+	// USER(10) PID(8) CPU(6) MEM(6) RSS(9) STAT(6) COMMAND(rest)
+	cmdWidth := max(p.width-52, 10)
+	format := "%-10s %8s %6s %6s %9s %-6s %s"
+
+	columnHeader := headerStyle.Render(fmt.Sprintf(format, "USER", "PID", "%CPU", "%MEM", "RSS", "STAT", "COMMAND"))
+
+	lines := []string{columnHeader}
+	end := min(p.scrollOffset+height, len(p.visible))
+	for i := p.scrollOffset; i < end; i++ {
+		proc := p.visible[i]
+		user := proc.User
+		if len(user) > 10 {
+			user = user[:9] + "…"
+		}
+		command := proc.Command
+		if len([]rune(command)) > cmdWidth {
+			command = string([]rune(command)[:cmdWidth-1]) + "…"
+		}
+		line := fmt.Sprintf(format,
+			user,
+			fmt.Sprint(proc.PID),
+			fmt.Sprintf("%.1f", proc.CPU),
+			fmt.Sprintf("%.1f", proc.Mem),
+			formatSize(proc.RSS*1024),
+			proc.Stat,
+			command,
+		)
+		if i == p.selectedIdx {
+			line = scpSelectedStyle.Width(p.width).Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(p.visible) == 0 {
+		lines = append(lines, "  (no matching processes)")
+	}
+	for len(lines) < height+1 {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (p *ProcessManager) renderStatus() string {
+	containerStyle := scpStatusStyle.Width(p.width).Align(lipgloss.Center)
+	errStyle := lipgloss.NewStyle().Foreground(colorError).Bold(true)
+	successStyle := lipgloss.NewStyle().Foreground(colorSuccess).Bold(true)
+
+	switch {
+	case p.error != "":
+		text := errStyle.Render(p.error)
+		p.error = ""
+		return containerStyle.Render(text)
+	case p.pendingSignal != "":
+		return containerStyle.Render(errStyle.Render(fmt.Sprintf(
+			"Send SIG%s to %d (%s)? (y/n)", p.pendingSignal, p.pendingTarget.PID, truncate(p.pendingTarget.Command, 40),
+		)))
+	case p.searching:
+		return containerStyle.Render("Search: " + p.query)
+	case p.query != "":
+		return containerStyle.Render(fmt.Sprintf("%s | filter: %q (%d matches)", p.status, p.query, len(p.visible)))
+	case strings.Contains(p.status, "successfully"):
+		return containerStyle.Render(successStyle.Render(p.status))
+	}
+	return containerStyle.Render(p.status)
+}
+
+func (p *ProcessManager) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+func (p *ProcessManager) IsFinished() bool {
+	return p.finished
+}
+
+// IsTyping reports whether keys go to the search input
+func (p *ProcessManager) IsTyping() bool {
+	return p.searching
+}
@@ -0,0 +1,177 @@
+package components
+
+import (
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// QuickConnectForm asks for an ad-hoc user@host:port to connect to without
+// saving it. After the session it is reused to offer saving the host.
+type QuickConnectForm struct {
+	textInput   textinput.Model
+	usePassword bool
+	connection  config.SSHConnection
+	offerSave   bool
+	submitted   bool
+	save        bool
+	canceled    bool
+	ErrorMsg    string
+	width       int
+	height      int
+}
+
+func NewQuickConnectForm() *QuickConnectForm {
+	ti := textinput.New()
+	ti.Placeholder = "user@host:port or ssh://user@host:port"
+	ti.Focus()
+	ti.CharLimit = 255
+	ti.Width = 44
+	ti.Prompt = "> "
+	ti.PromptStyle = focusedStyle
+	ti.TextStyle = focusedStyle
+
+	return &QuickConnectForm{textInput: ti}
+}
+
+// NewQuickConnectSavePrompt asks whether to save conn, the host of an
+// ad-hoc session that just ended
+func NewQuickConnectSavePrompt(conn config.SSHConnection) *QuickConnectForm {
+	return &QuickConnectForm{connection: conn, offerSave: true}
+}
+
+func (f *QuickConnectForm) Init() tea.Cmd {
+	if f.offerSave {
+		return nil
+	}
+	return textinput.Blink
+}
+
+func (f *QuickConnectForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		f.SetSize(msg.Width, msg.Height)
+		return f, nil
+
+	case tea.KeyMsg:
+		if f.offerSave {
+			switch msg.String() {
+			case "y", "Y":
+				f.save = true
+			case "n", "N", "esc", "ctrl+c":
+				f.canceled = true
+			}
+			return f, nil
+		}
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			f.canceled = true
+			return f, nil
+		case "ctrl+p":
+			f.usePassword = !f.usePassword
+			return f, nil
+		case "enter":
+			conn, err := config.ParseTarget(f.textInput.Value())
+			if err != nil {
+				f.ErrorMsg = err.Error()
+				return f, nil
+			}
+			conn.UsePassword = f.usePassword
+			f.connection = conn
+			f.ErrorMsg = ""
+			f.submitted = true
+			return f, nil
+		}
+	}
+
+	if f.offerSave {
+		return f, nil
+	}
+	var cmd tea.Cmd
+	f.textInput, cmd = f.textInput.Update(msg)
+	return f, cmd
+}
+
+func (f *QuickConnectForm) View() string {
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colorPrimary).
+		Render("⚡ Quick Connect")
+	hint := lipgloss.NewStyle().Foreground(colorInactive)
+
+	var content string
+	if f.offerSave {
+		content = lipgloss.JoinVertical(lipgloss.Center,
+			title,
+			"\n",
+			lipgloss.NewStyle().Foreground(colorText).Render("Save "+f.connection.Name+" as a connection?"),
+			"\n",
+			hint.Render("Press y to save, n or Esc to skip"),
+		)
+	} else {
+		auth := "Agent / default key"
+		if f.usePassword {
+			auth = "Password"
+		}
+		parts := []string{
+			title,
+			"\n",
+			f.textInput.View(),
+			"\n",
+			lipgloss.NewStyle().Foreground(colorSubText).Render("Auth: " + auth + " (ctrl+p to change)"),
+			"\n",
+			hint.Render("Press Enter to connect without saving, Esc to cancel"),
+		}
+		if f.ErrorMsg != "" {
+			parts = append(parts, "\n", errorStyle.Render(f.ErrorMsg))
+		}
+		content = lipgloss.JoinVertical(lipgloss.Center, parts...)
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(60).
+		Align(lipgloss.Center).
+		Render(content)
+
+	return lipgloss.Place(
+		f.width,
+		max(f.height-3, 0),
+		lipgloss.Center,
+		lipgloss.Center,
+		box,
+	)
+}
+
+func (f *QuickConnectForm) SetSize(width, height int) {
+	f.width = width
+	f.height = height
+}
+
+// IsSubmitted reports whether a valid target was entered
+func (f *QuickConnectForm) IsSubmitted() bool {
+	return f.submitted
+}
+
+// IsSaveRequested reports whether the user chose to save the ad-hoc host
+func (f *QuickConnectForm) IsSaveRequested() bool {
+	return f.save
+}
+
+// IsOfferingSave reports whether the form is the save prompt
+func (f *QuickConnectForm) IsOfferingSave() bool {
+	return f.offerSave
+}
+
+func (f *QuickConnectForm) IsCanceled() bool {
+	return f.canceled
+}
+
+// Connection returns the parsed target, or the host offered for saving
+func (f *QuickConnectForm) Connection() config.SSHConnection {
+	return f.connection
+}
@@ -0,0 +1,54 @@
+package components
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+func TestQuickConnectForm(t *testing.T) {
+	typeText := func(f *QuickConnectForm, text string) {
+		for _, r := range text {
+			f.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	t.Run("Invalid targets are rejected", func(t *testing.T) {
+		f := NewQuickConnectForm()
+		typeText(f, "me@host:99999")
+		f.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		if f.IsSubmitted() || f.ErrorMsg == "" {
+			t.Error("Expected an invalid port to be rejected")
+		}
+	})
+
+	t.Run("A valid target is parsed with the chosen auth", func(t *testing.T) {
+		f := NewQuickConnectForm()
+		typeText(f, "deploy@web:2222")
+		f.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+		f.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		if !f.IsSubmitted() {
+			t.Fatalf("Expected the form to submit, got error %q", f.ErrorMsg)
+		}
+		conn := f.Connection()
+		if conn.Host != "web" || conn.Port != 2222 || conn.Username != "deploy" || !conn.UsePassword || conn.ID != "" {
+			t.Errorf("Unexpected connection %+v", conn)
+		}
+	})
+
+	t.Run("The save prompt answers y or n", func(t *testing.T) {
+		conn := config.SSHConnection{Name: "deploy@web", Host: "web", Port: 22, Username: "deploy"}
+		f := NewQuickConnectSavePrompt(conn)
+		f.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+		if !f.IsSaveRequested() || f.Connection().Host != "web" {
+			t.Error("Expected y to request saving the host")
+		}
+		f = NewQuickConnectSavePrompt(conn)
+		f.Update(tea.KeyMsg{Type: tea.KeyEsc})
+		if f.IsSaveRequested() || !f.IsCanceled() {
+			t.Error("Expected esc to skip saving")
+		}
+	})
+}
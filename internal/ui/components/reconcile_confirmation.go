@@ -0,0 +1,137 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// maxReconcileLines caps how many planned changes are listed in the modal
+const maxReconcileLines = 12
+
+// ReconcileConfirmation asks before applying a declarative file to the backend
+type ReconcileConfirmation struct {
+	path      string
+	plan      *config.ReconcilePlan
+	confirmed bool
+	canceled  bool
+	width     int
+	height    int
+}
+
+func NewReconcileConfirmation(path string, plan *config.ReconcilePlan) *ReconcileConfirmation {
+	return &ReconcileConfirmation{
+		path: path,
+		plan: plan,
+	}
+}
+
+func (r *ReconcileConfirmation) Init() tea.Cmd {
+	return nil
+}
+
+func (r *ReconcileConfirmation) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if r.confirmed || r.canceled {
+		return r, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		r.SetSize(msg.Width, msg.Height)
+		return r, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "y", "Y":
+			r.confirmed = true
+		case "n", "N", "esc", "ctrl+c":
+			r.canceled = true
+		}
+	}
+
+	return r, nil
+}
+
+func (r *ReconcileConfirmation) View() string {
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colorPrimary).
+		Render("Sync Declared Connections")
+
+	source := lipgloss.NewStyle().
+		Foreground(colorSubText).
+		Render(truncate(r.path, 52))
+
+	summary := fmt.Sprintf("%d to create, %d to update, %d to remove",
+		len(r.plan.Create), len(r.plan.Update), len(r.plan.Prune))
+
+	createStyle := lipgloss.NewStyle().Foreground(colorSuccess)
+	updateStyle := lipgloss.NewStyle().Foreground(colorAccent)
+	pruneStyle := lipgloss.NewStyle().Foreground(colorError)
+
+	var lines []string
+	for _, c := range r.plan.Create {
+		lines = append(lines, createStyle.Render("+ "+truncate(c.Name+" ("+c.Host+")", 48)))
+	}
+	for _, c := range r.plan.Update {
+		lines = append(lines, updateStyle.Render("~ "+truncate(c.Name+" ("+c.Host+")", 48)))
+	}
+	for _, c := range r.plan.Prune {
+		lines = append(lines, pruneStyle.Render("- "+truncate(c.Name+" ("+c.Host+")", 48)))
+	}
+	if len(lines) > maxReconcileLines {
+		more := len(lines) - maxReconcileLines
+		lines = append(lines[:maxReconcileLines], blurredStyle.Render(fmt.Sprintf("  ... and %d more", more)))
+	}
+
+	prompt := lipgloss.NewStyle().
+		Foreground(colorInactive).
+		Render("Press Y to apply, N or Esc to skip")
+
+	content := lipgloss.JoinVertical(lipgloss.Left,
+		title,
+		source,
+		"",
+		summary,
+		"",
+		strings.Join(lines, "\n"),
+		"",
+		prompt,
+	)
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(60).
+		Render(content)
+
+	availableHeight := max(r.height-3, 0)
+	return lipgloss.Place(
+		r.width,
+		availableHeight,
+		lipgloss.Center,
+		lipgloss.Center,
+		box,
+	)
+}
+
+func (r *ReconcileConfirmation) SetSize(width, height int) {
+	r.width = width
+	r.height = height
+}
+
+func (r *ReconcileConfirmation) Plan() *config.ReconcilePlan {
+	return r.plan
+}
+
+func (r *ReconcileConfirmation) IsConfirmed() bool {
+	return r.confirmed
+}
+
+func (r *ReconcileConfirmation) IsCanceled() bool {
+	return r.canceled
+}
@@ -0,0 +1,138 @@
+package components
+
+import (
+	"fmt"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/transfer"
+)
+
+// A packs the selected remote files into a tar.gz or zip archive on the
+// server and downloads it, which is much faster than fetching many small
+// files one by one. X unpacks a remote archive, e.g. one just uploaded.
+
+// SCPArchiveMsg reports an archive made on the server
+type SCPArchiveMsg struct {
+	Archive string
+	Size    int64
+	Err     error
+}
+
+// promptArchive asks the name of the archive to make of the selected
+// remote files; its extension picks the format
+func (s *SCPManager) promptArchive() {
+	if s.activePanel == 0 {
+		s.error = "Archives are made on the server: select remote files"
+		return
+	}
+	if s.sftpClient == nil || s.queue == nil {
+		s.error = "Not connected to remote server"
+		return
+	}
+	files := s.selectedFiles()
+	if len(files) == 0 {
+		s.error = "No file selected"
+		return
+	}
+	name := filepath.Base(s.remotePanel.Path)
+	what := fmt.Sprintf("%d items", len(files))
+	if len(files) == 1 {
+		name = files[0].Name
+		what = "'" + files[0].Name + "'"
+	}
+	s.pendingTransfer = files
+	s.inputMode = ModeArchive
+	s.inputBuffer = name + ".tar.gz"
+	s.status = fmt.Sprintf("Archive %s as (.tar.gz, .tar or .zip), then download: ", what)
+}
+
+// executeArchive makes the archive named in the prompt on the server
+func (s *SCPManager) executeArchive() (tea.Model, tea.Cmd) {
+	files := s.pendingTransfer
+	name := filepath.Base(s.inputBuffer)
+	s.inputMode = ModeNormal
+	s.inputBuffer = ""
+	s.pendingTransfer = nil
+	if name == "." || len(files) == 0 {
+		s.error = "Archive name cannot be empty"
+		return s, nil
+	}
+	if format := ssh.ArchiveFormat(name); format != "tar.gz" && format != "tar" && format != "zip" {
+		s.error = "Name the archive .tar.gz, .tar or .zip"
+		return s, nil
+	}
+
+	dir := s.remotePanel.Path
+	archive := filepath.ToSlash(filepath.Join(dir, name))
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = file.Name
+	}
+	sftpClient := s.sftpClient
+	s.remotePanel.Marked = nil
+	s.operationInProgress = true
+	s.status = fmt.Sprintf("Creating %s on the server...", name)
+
+	return s, func() tea.Msg {
+		size, err := sftpClient.CreateArchive(dir, names, archive)
+		return SCPArchiveMsg{Archive: archive, Size: size, Err: err}
+	}
+}
+
+// handleArchive queues the download of the archive made on the server
+func (s *SCPManager) handleArchive(msg SCPArchiveMsg) tea.Cmd {
+	s.operationInProgress = false
+	if msg.Err != nil {
+		s.error = fmt.Sprintf("Archive failed: %s", msg.Err.Error())
+		return nil
+	}
+	name := filepath.Base(msg.Archive)
+	s.queue.Add(transfer.Item{
+		Name:      name,
+		Source:    msg.Archive,
+		Target:    filepath.Join(s.localPanel.Path, name),
+		Direction: transfer.Download,
+		Size:      msg.Size,
+		RateLimit: s.rateLimit,
+	})
+	s.status = fmt.Sprintf("Created %s (%s), downloading (q: view queue)", name, formatSize(msg.Size))
+	return s.listRemoteFiles()
+}
+
+// extractSelection unpacks the highlighted remote archive next to it
+func (s *SCPManager) extractSelection() tea.Cmd {
+	if s.activePanel == 0 {
+		s.error = "Archives are extracted on the server: upload it and select it there"
+		return nil
+	}
+	panel := &s.remotePanel
+	if panel.SelectedIdx < 0 || panel.SelectedIdx >= len(panel.Files) {
+		return nil
+	}
+	file := panel.Files[panel.SelectedIdx]
+	if file.IsDir || ssh.ArchiveFormat(file.Name) == "" {
+		s.error = fmt.Sprintf("%s is not a .tar.gz, .tar.bz2, .tar.xz, .tar or .zip archive", file.Name)
+		return nil
+	}
+	sftpClient := s.sftpClient
+	if sftpClient == nil {
+		s.error = "Not connected to remote server"
+		return nil
+	}
+	if s.refuseRemoteChange() {
+		return nil
+	}
+	archive := filepath.ToSlash(filepath.Join(panel.Path, file.Name))
+	s.operationInProgress = true
+	s.status = fmt.Sprintf("Extracting %s on the server...", file.Name)
+
+	return func() tea.Msg {
+		if err := sftpClient.ExtractArchive(archive); err != nil {
+			return SCPOperationMsg{Operation: "Extract", Success: false, Err: err}
+		}
+		return SCPOperationMsg{Operation: "Extract", Success: true}
+	}
+}
@@ -0,0 +1,38 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+func TestSCPManagerArchiveChecks(t *testing.T) {
+	s := NewSCPManager(config.SSHConnection{Name: "web"})
+	s.loading = false
+	s.Update(SCPListFilesMsg{Path: "/srv", Files: []ssh.FileInfo{
+		{Name: "notes.txt"},
+		{Name: "site.tar.gz"},
+	}})
+	key := func(k string) tea.Cmd {
+		_, cmd := s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		return cmd
+	}
+
+	key("A")
+	if !strings.Contains(s.error, "made on the server") || s.inputMode != ModeNormal {
+		t.Errorf("Expected archiving local files to be refused, got %q", s.error)
+	}
+
+	s.activePanel = 1
+	s.remotePanel.SelectedIdx = 0
+	if cmd := key("X"); cmd != nil || !strings.Contains(s.error, "not a .tar.gz") {
+		t.Errorf("Expected extracting a plain file to be refused, got %q", s.error)
+	}
+	s.remotePanel.SelectedIdx = 1
+	if cmd := key("X"); cmd != nil || !strings.Contains(s.error, "Not connected") {
+		t.Errorf("Expected extracting to need a connection, got %q", s.error)
+	}
+}
@@ -0,0 +1,53 @@
+package components
+
+import (
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// b opens the bookmarks of the connection: local and remote directories to
+// jump to, kept in its metadata so they follow it across machines sharing
+// the same storage backend.
+
+// SCPBookmarksMsg asks to save the bookmarks of a connection
+type SCPBookmarksMsg struct {
+	ConnectionID string
+	Bookmarks    []string
+}
+
+// openBookmarks opens the bookmark picker on the directory of the active
+// panel
+func (s *SCPManager) openBookmarks() {
+	current := config.Bookmark{Local: s.activePanel == 0, Path: s.getActivePanel().Path}
+	s.bookmarks = NewBookmarkPicker(s.connection.Bookmarks, current)
+	s.bookmarks.SetSize(s.width, max(s.height-5, 0))
+}
+
+// closeBookmarks saves the bookmarks if they were changed and goes to the
+// chosen one, if any
+func (s *SCPManager) closeBookmarks() tea.Cmd {
+	picker := s.bookmarks
+	s.bookmarks = nil
+	s.status = "Connected"
+
+	var cmds []tea.Cmd
+	if picker.IsChanged() {
+		s.connection.Bookmarks = picker.Bookmarks()
+		msg := SCPBookmarksMsg{ConnectionID: s.connection.ID, Bookmarks: slices.Clone(s.connection.Bookmarks)}
+		cmds = append(cmds, func() tea.Msg { return msg })
+	}
+	if bookmark, ok := picker.Chosen(); ok {
+		if bookmark.Local {
+			s.activePanel = 0
+		} else {
+			s.activePanel = 1
+		}
+		s.inputBuffer = bookmark.Path
+		_, cmd := s.executeChangeDir()
+		cmds = append(cmds, cmd)
+	}
+	return tea.Batch(cmds...)
+}
@@ -0,0 +1,84 @@
+package components
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// runCmd runs cmd and the commands it batches, returning their messages
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var msgs []tea.Msg
+		for _, c := range batch {
+			msgs = append(msgs, runCmd(c)...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
+
+func TestSCPManagerBookmarks(t *testing.T) {
+	dir := t.TempDir()
+	s := NewSCPManager(config.SSHConnection{ID: "web-1", Name: "web", Host: "web", Bookmarks: []string{"remote:/var/www"}})
+	s.loading = false
+	s.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	key := func(k string) tea.Cmd {
+		_, cmd := s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		return cmd
+	}
+
+	// Bookmark the directory of the local panel
+	s.localPanel.Path = dir
+	key("b")
+	if s.bookmarks == nil || !s.IsTyping() {
+		t.Fatal("Expected b to open the bookmarks")
+	}
+	key("a")
+	key("a")
+	if s.bookmarks.error == "" {
+		t.Error("Expected a directory to be bookmarked once")
+	}
+	_, cmd := s.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	msgs := runCmd(cmd)
+	want := []string{"remote:/var/www", "local:" + dir}
+	if s.bookmarks != nil || !slices.Equal(s.connection.Bookmarks, want) {
+		t.Fatalf("Bookmarks = %q, want %q", s.connection.Bookmarks, want)
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Expected the bookmarks to be saved, got %#v", msgs)
+	}
+	if saved, ok := msgs[0].(SCPBookmarksMsg); !ok || saved.ConnectionID != "web-1" || !slices.Equal(saved.Bookmarks, want) {
+		t.Errorf("Save message = %#v", msgs[0])
+	}
+
+	// Jump to it from the remote panel
+	s.activePanel = 1
+	s.localPanel.Path = "/"
+	key("b")
+	msgs = runCmd(key("2"))
+	if s.bookmarks != nil || s.activePanel != 0 {
+		t.Fatal("Expected a local bookmark to close the picker on the local panel")
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("Expected only a change of directory, got %#v", msgs)
+	}
+	if list, ok := msgs[0].(SCPListFilesMsg); !ok || !list.IsLocal || list.Path != dir || s.localPanel.Path != dir {
+		t.Errorf("Expected the local panel to go to %s, got %#v", dir, msgs[0])
+	}
+
+	// Delete it
+	key("b")
+	s.bookmarks.Update(tea.KeyMsg{Type: tea.KeyDown})
+	key("d")
+	msgs = runCmd(key("q"))
+	if !slices.Equal(s.connection.Bookmarks, want[:1]) || len(msgs) != 1 {
+		t.Errorf("Bookmarks after delete = %q, messages %#v", s.connection.Bookmarks, msgs)
+	}
+}
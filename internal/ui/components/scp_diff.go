@@ -0,0 +1,86 @@
+package components
+
+import (
+	"fmt"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+// = compares the highlighted local file with the highlighted remote one,
+// e.g. a config with its deployed copy.
+
+// SCPDiffMsg carries the two files read for the diff viewer
+type SCPDiffMsg struct {
+	LocalName  string
+	RemoteName string
+	Local      []byte
+	Remote     []byte
+	Truncated  bool
+	Err        error
+}
+
+// diffSelection reads the highlighted file of both panels for the diff
+// viewer
+func (s *SCPManager) diffSelection() tea.Cmd {
+	if s.sftpClient == nil {
+		s.error = "Not connected to remote server"
+		return nil
+	}
+	local, ok := highlightedFile(&s.localPanel)
+	remote, ok2 := highlightedFile(&s.remotePanel)
+	if !ok || !ok2 || local.IsDir || remote.IsDir {
+		s.error = "Highlight a local and a remote file to compare"
+		return nil
+	}
+	localPath := filepath.Join(s.localPanel.Path, local.Name)
+	remotePath := filepath.Join(s.remotePanel.Path, remote.Name)
+	remoteName := fmt.Sprintf("%s:%s", s.connection.Host, remotePath)
+	sftpClient := s.sftpClient
+
+	s.operationInProgress = true
+	s.status = fmt.Sprintf("Comparing %s with %s...", local.Name, remote.Name)
+
+	return func() tea.Msg {
+		msg := SCPDiffMsg{LocalName: localPath, RemoteName: remoteName}
+		var localTruncated, remoteTruncated bool
+		if msg.Local, localTruncated, msg.Err = ssh.ReadLocalFile(localPath, FileViewerLimit); msg.Err != nil {
+			return msg
+		}
+		if msg.Remote, remoteTruncated, msg.Err = sftpClient.ReadFile(remotePath, FileViewerLimit); msg.Err != nil {
+			return msg
+		}
+		msg.Truncated = localTruncated || remoteTruncated
+		return msg
+	}
+}
+
+// handleDiff opens the diff viewer on the files read
+func (s *SCPManager) handleDiff(msg SCPDiffMsg) {
+	s.operationInProgress = false
+	switch {
+	case msg.Err != nil:
+		s.error = fmt.Sprintf("Diff failed: %s", msg.Err.Error())
+		return
+	case isBinary(msg.Local) || isBinary(msg.Remote):
+		if string(msg.Local) == string(msg.Remote) {
+			s.status = "The binary files are identical"
+		} else {
+			s.error = "The binary files differ"
+		}
+		return
+	}
+	s.diffViewer = NewDiffViewer(msg.LocalName, msg.RemoteName, msg.Local, msg.Remote, msg.Truncated)
+	s.diffViewer.SetSize(s.width, max(s.height-5, 0))
+	s.status = "Comparing " + filepath.Base(msg.LocalName)
+}
+
+// highlightedFile returns the file under the cursor of panel
+func highlightedFile(panel *Panel) (ssh.FileInfo, bool) {
+	if panel.SelectedIdx < 0 || panel.SelectedIdx >= len(panel.Files) {
+		return ssh.FileInfo{}, false
+	}
+	return panel.Files[panel.SelectedIdx], true
+}
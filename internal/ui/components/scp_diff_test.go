@@ -0,0 +1,68 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+func TestSCPManagerDiff(t *testing.T) {
+	s := NewSCPManager(config.SSHConnection{Name: "web"})
+	s.loading = false
+	s.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	key := func(k string) {
+		s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+
+	key("=")
+	if !strings.Contains(s.error, "Not connected") || s.diffViewer != nil {
+		t.Errorf("Expected a diff to need a connection, got %q", s.error)
+	}
+
+	s.operationInProgress = true
+	s.Update(SCPDiffMsg{
+		LocalName:  "/home/me/app.conf",
+		RemoteName: "web:/etc/app.conf",
+		Local:      []byte("port = 80\nhost = a\nlog = on\n"),
+		Remote:     []byte("port = 8080\nhost = a\nlog = on\n"),
+	})
+	if s.diffViewer == nil || s.operationInProgress {
+		t.Fatal("Expected the diff viewer to open")
+	}
+	if !s.IsTyping() {
+		t.Error("Expected keys to go to the diff viewer")
+	}
+	view := s.View()
+	for _, want := range []string{"1 change", "-port = 80", "+port = 8080", "web:/etc/app.conf"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected the unified view to show %q", want)
+		}
+	}
+
+	key("s")
+	view = s.View()
+	if strings.Contains(view, "-port = 80") || !strings.Contains(view, "port = 8080") {
+		t.Error("Expected s to switch to the side by side view")
+	}
+
+	key("q")
+	if s.diffViewer != nil {
+		t.Error("Expected q to close the diff viewer")
+	}
+
+	s.operationInProgress = true
+	s.Update(SCPDiffMsg{Local: []byte("a\x00b"), Remote: []byte("a\x00c")})
+	if s.diffViewer != nil || !strings.Contains(s.error, "binary files differ") {
+		t.Errorf("Expected binary files not to be compared, got %q", s.error)
+	}
+}
+
+func TestDiffViewerIdentical(t *testing.T) {
+	v := NewDiffViewer("a", "b", []byte("same\n"), []byte("same\r\n"), false)
+	v.SetSize(80, 20)
+	if !v.IsIdentical() || !strings.Contains(v.View(), "identical") {
+		t.Error("Expected files differing only in line endings to be identical")
+	}
+}
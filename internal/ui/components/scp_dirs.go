@@ -0,0 +1,84 @@
+package components
+
+import (
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// The file manager opens in the start directories of the connection, or
+// where it was left when the remember_dirs setting is on, falling back to
+// the working directory locally and the login directory on the remote.
+
+// startDirs returns the directories the file manager of conn opens in,
+// as the user wrote them
+func startDirs(conn config.SSHConnection) config.FileDirs {
+	dirs := config.FileDirs{Local: conn.LocalStartPath, Remote: conn.RemoteStartPath}
+	if !config.CurrentSettings().RememberFileDirs || conn.ID == "" {
+		return dirs
+	}
+	if last, ok := config.LastFileDirs(conn.ID); ok {
+		if last.Local != "" {
+			dirs.Local = last.Local
+		}
+		if last.Remote != "" {
+			dirs.Remote = last.Remote
+		}
+	}
+	return dirs
+}
+
+// localStartDir resolves the local start directory, reporting false when
+// it isn't a directory and the working directory is used instead
+func localStartDir(dir string) (string, bool) {
+	wd := "."
+	if abs, err := filepath.Abs("."); err == nil {
+		wd = abs
+	}
+	if dir == "" {
+		return wd, true
+	}
+	dir = config.ExpandPath(dir)
+	if dir == "~" {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = home
+		}
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return wd, false
+	}
+	return dir, true
+}
+
+// remoteStartDir resolves the remote start directory against the login
+// directory home: "~" and relative paths are taken from there
+func remoteStartDir(home, dir string) string {
+	switch {
+	case dir == "" || dir == "~":
+		return home
+	case strings.HasPrefix(dir, "~/"):
+		return path.Join(home, dir[2:])
+	case path.IsAbs(dir):
+		return path.Clean(dir)
+	}
+	return path.Join(home, dir)
+}
+
+// rememberDirs saves the directories the panels show for the next time the
+// file manager of the connection opens, when the setting is on
+func (s *SCPManager) rememberDirs() {
+	if !config.CurrentSettings().RememberFileDirs || s.connection.ID == "" || s.sftpClient == nil {
+		return
+	}
+	dirs := config.FileDirs{Local: s.localPanel.Path, Remote: s.remotePanel.Path}
+	if err := config.RecordFileDirs(s.connection.ID, dirs); err != nil {
+		log.Printf("Failed to remember the file manager directories: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package components
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+func TestRemoteStartDir(t *testing.T) {
+	tests := []struct {
+		dir, want string
+	}{
+		{"", "/home/deploy"},
+		{"~", "/home/deploy"},
+		{"~/projects/app", "/home/deploy/projects/app"},
+		{"/var/www/app/", "/var/www/app"},
+		{"releases/current", "/home/deploy/releases/current"},
+	}
+	for _, tt := range tests {
+		if got := remoteStartDir("/home/deploy", tt.dir); got != tt.want {
+			t.Errorf("remoteStartDir(%q) = %q, want %q", tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestSCPManagerStartDirs(t *testing.T) {
+	dir := t.TempDir()
+	s := NewSCPManager(config.SSHConnection{Name: "web", LocalStartPath: dir, RemoteStartPath: "/var/www/app"})
+	if s.localPanel.Path != dir || s.remoteStart != "/var/www/app" || s.error != "" {
+		t.Errorf("Expected the panels to start in the connection's directories, got %q and %q (%s)",
+			s.localPanel.Path, s.remoteStart, s.error)
+	}
+
+	missing := filepath.Join(dir, "missing")
+	s = NewSCPManager(config.SSHConnection{Name: "web", LocalStartPath: missing})
+	if wd, _ := filepath.Abs("."); s.localPanel.Path != wd || s.error == "" {
+		t.Errorf("Expected a missing start directory to fall back to the working directory with an error, got %q (%s)",
+			s.localPanel.Path, s.error)
+	}
+}
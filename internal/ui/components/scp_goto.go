@@ -0,0 +1,167 @@
+package components
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+// The go to path input (c or :) starts from the panel's directory and
+// completes directory names with tab, like a shell: a single match is
+// filled in, several are filled in up to their common prefix and listed.
+
+// maxPathCompletions is how many candidates the prompt lists
+const maxPathCompletions = 8
+
+// SCPPathCompletionMsg carries the directories that complete Input
+type SCPPathCompletionMsg struct {
+	Input   string
+	Dir     string // Input up to its last separator
+	Matches []string
+	Err     error
+}
+
+// startGoto opens the go to path input on the active panel's directory
+func (s *SCPManager) startGoto() {
+	panel := s.getActivePanel()
+	s.inputMode = ModeChangeDir
+	s.inputBuffer = panel.Path
+	if !strings.HasSuffix(s.inputBuffer, s.pathSeparator()) {
+		s.inputBuffer += s.pathSeparator()
+	}
+	s.pathCompletions = nil
+	s.status = "Go to (tab completes): "
+}
+
+// pathSeparator is the separator of the active panel's paths
+func (s *SCPManager) pathSeparator() string {
+	if s.activePanel == 0 {
+		return string(filepath.Separator)
+	}
+	return "/"
+}
+
+// completePath lists the directories completing the go to path input
+func (s *SCPManager) completePath() tea.Cmd {
+	input := s.inputBuffer
+	base := s.getActivePanel().Path
+	local := s.activePanel == 0
+	sftpClient := s.sftpClient
+	if !local && sftpClient == nil {
+		s.error = "Not connected to remote server"
+		return nil
+	}
+
+	return func() tea.Msg {
+		msg := SCPPathCompletionMsg{Input: input}
+		i := strings.LastIndex(input, "/")
+		if local && runtime.GOOS == "windows" {
+			i = max(i, strings.LastIndex(input, `\`))
+		}
+		msg.Dir = input[:i+1]
+		prefix := input[i+1:]
+
+		var files []ssh.FileInfo
+		if local {
+			files, msg.Err = ssh.ListLocalFiles(resolveGotoPath(msg.Dir, base, localHome(), false))
+		} else {
+			home := ""
+			if strings.HasPrefix(msg.Dir, "~") {
+				home, _ = sftpClient.GetWorkingDir()
+			}
+			files, msg.Err = sftpClient.ListFiles(resolveGotoPath(msg.Dir, base, home, true))
+		}
+		for _, file := range files {
+			if !file.IsDir && file.ModeBits&os.ModeSymlink == 0 {
+				continue
+			}
+			// Dot directories only when asked for, as in a shell
+			if strings.HasPrefix(file.Name, ".") && !strings.HasPrefix(prefix, ".") {
+				continue
+			}
+			if strings.HasPrefix(file.Name, prefix) {
+				msg.Matches = append(msg.Matches, file.Name)
+			}
+		}
+		return msg
+	}
+}
+
+// handlePathCompletion fills in the completion of the go to path input,
+// unless it was edited meanwhile
+func (s *SCPManager) handlePathCompletion(msg SCPPathCompletionMsg) {
+	if s.inputMode != ModeChangeDir || s.inputBuffer != msg.Input {
+		return
+	}
+	s.pathCompletions = nil
+	switch {
+	case msg.Err != nil:
+		s.error = fmt.Sprintf("Cannot complete: %s", msg.Err.Error())
+	case len(msg.Matches) == 0:
+		s.error = "No matching directory"
+	case len(msg.Matches) == 1:
+		s.inputBuffer = msg.Dir + msg.Matches[0] + s.pathSeparator()
+	default:
+		s.inputBuffer = msg.Dir + commonPrefix(msg.Matches)
+		s.pathCompletions = msg.Matches
+	}
+}
+
+// pathCompletionsView lists the candidates of the last ambiguous completion
+func (s *SCPManager) pathCompletionsView() string {
+	if len(s.pathCompletions) == 0 {
+		return ""
+	}
+	shown := s.pathCompletions[:min(len(s.pathCompletions), maxPathCompletions)]
+	view := "  [" + strings.Join(shown, "  ")
+	if more := len(s.pathCompletions) - len(shown); more > 0 {
+		view += fmt.Sprintf("  +%d", more)
+	}
+	return view + "]"
+}
+
+// resolveGotoPath makes input absolute: "~" is taken from home and relative
+// paths from base
+func resolveGotoPath(input, base, home string, remote bool) string {
+	tilde := input == "~" || strings.HasPrefix(input, "~/") || (!remote && strings.HasPrefix(input, `~\`))
+	if remote {
+		switch {
+		case tilde:
+			return path.Join(home, input[1:])
+		case path.IsAbs(input):
+			return path.Clean(input)
+		}
+		return path.Join(base, input)
+	}
+	switch {
+	case tilde:
+		return filepath.Join(home, input[1:])
+	case filepath.IsAbs(input):
+		return filepath.Clean(input)
+	}
+	return filepath.Join(base, input)
+}
+
+// commonPrefix returns the longest prefix shared by names
+func commonPrefix(names []string) string {
+	prefix := names[0]
+	for _, name := range names[1:] {
+		for !strings.HasPrefix(name, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
+
+// localHome returns the local home directory, empty when unknown
+func localHome() string {
+	home, _ := os.UserHomeDir()
+	return home
+}
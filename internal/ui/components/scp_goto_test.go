@@ -0,0 +1,82 @@
+package components
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+func TestResolveGotoPath(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"/var/log/", "/var/log"},
+		{"~", "/home/deploy"},
+		{"~/app/../releases", "/home/deploy/releases"},
+		{"current/public", "/srv/app/current/public"},
+		{"..", "/srv"},
+	}
+	for _, tt := range tests {
+		if got := resolveGotoPath(tt.input, "/srv/app", "/home/deploy", true); got != tt.want {
+			t.Errorf("resolveGotoPath(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSCPManagerGotoCompletion(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"alpha", "alps", "beta", ".alcove"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "alarm.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSCPManager(config.SSHConnection{Name: "web"})
+	s.loading = false
+	s.localPanel.Path = dir
+	key := func(k string) tea.Cmd {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		if k == "tab" || k == "enter" {
+			msg = tea.KeyMsg{Type: map[string]tea.KeyType{"tab": tea.KeyTab, "enter": tea.KeyEnter}[k]}
+		}
+		_, cmd := s.Update(msg)
+		return cmd
+	}
+	complete := func() {
+		cmd := key("tab")
+		if cmd == nil {
+			t.Fatal("Expected tab to complete the path")
+		}
+		s.Update(cmd())
+	}
+
+	key(":")
+	if s.inputMode != ModeChangeDir || s.inputBuffer != dir+string(filepath.Separator) {
+		t.Fatalf("Expected the input to start at the panel's directory, got %q", s.inputBuffer)
+	}
+	key("a")
+	complete()
+	if want := filepath.Join(dir, "alp"); s.inputBuffer != want || len(s.pathCompletions) != 2 {
+		t.Fatalf("Expected %q with alpha and alps listed, got %q %v", want, s.inputBuffer, s.pathCompletions)
+	}
+	key("h")
+	complete()
+	if want := filepath.Join(dir, "alpha") + string(filepath.Separator); s.inputBuffer != want || s.pathCompletions != nil {
+		t.Fatalf("Expected %q, got %q %v", want, s.inputBuffer, s.pathCompletions)
+	}
+
+	cmd := key("enter")
+	if cmd == nil {
+		t.Fatal("Expected enter to change directory")
+	}
+	msg, ok := cmd().(SCPListFilesMsg)
+	if !ok || msg.Err != nil || msg.Path != filepath.Join(dir, "alpha") {
+		t.Errorf("Expected to list %s, got %+v", filepath.Join(dir, "alpha"), msg)
+	}
+}
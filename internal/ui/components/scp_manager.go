@@ -11,6 +11,8 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/transfer"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/trash"
 )
 
 // Panel represents either local or remote file panel
@@ -19,6 +21,13 @@ type Panel struct {
 	Files        []ssh.FileInfo
 	SelectedIdx  int
 	ScrollOffset int
+	Marked       map[string]bool  // Names marked with space for a batch transfer
+	ShowHidden   bool             // List dotfiles
+	Sort         string           // One of config.FileSorts
+	DirsFirst    bool             // List directories before files
+	DirSizes     map[string]int64 // Sizes of the directories measured with s, by path
+
+	all []ssh.FileInfo // Every file of the directory, Files lists those shown
 }
 
 // SCPManagerMsg types
@@ -39,7 +48,22 @@ type (
 	SCPConnectionMsg struct {
 		Client     *ssh.SFTPClient
 		WorkingDir string
-		Err        error
+		// MissingStart is a remote start directory that wasn't found, in
+		// which case WorkingDir is the login directory
+		MissingStart string
+		Err          error
+	}
+
+	// SCPQueueMsg is sent whenever an item in the transfer queue changes state
+	SCPQueueMsg struct{}
+
+	// SCPFileContentMsg carries a file read for the viewer
+	SCPFileContentMsg struct {
+		Name      string
+		Location  string
+		Content   []byte
+		Truncated bool
+		Err       error
 	}
 )
 
@@ -52,12 +76,18 @@ const (
 	ModeCreateFile
 	ModeRename
 	ModeChangeDir
-	ModeConfirmDelete
+	ModeConfirmResume
+	ModeCopyTo
+	ModeMoveTo
+	ModeRateLimit
+	ModeArchive
 )
 
 // SCPManager represents the SCP file manager component
 type SCPManager struct {
 	connection          config.SSHConnection
+	sharedClient        *ssh.Client // Connection of a terminal session to open SFTP on, nil to connect
+	remoteStart         string      // Directory the remote panel opens in, the login directory when empty
 	sftpClient          *ssh.SFTPClient
 	localPanel          Panel
 	remotePanel         Panel
@@ -74,35 +104,81 @@ type SCPManager struct {
 	operationInProgress bool
 	inputMode           InputMode
 	inputBuffer         string
+	pathCompletions     []string       // Candidates of the last ambiguous tab completion of a path
 	searchMatches       []int          // Indices of matching files in current panel
 	searchSelectedIdx   int            // Current position in search results
 	recursiveResults    []ssh.FileInfo // Files found in recursive search
 	originalFiles       []ssh.FileInfo // Original file list before search
-	deleteTarget        *ssh.FileInfo  // File to delete (pending confirmation)
+	search              *searchRun     // Search walk in progress
+	searchGen           int            // Query count, to tell stale search results apart
+	searchStopped       string         // Limit that ended the last search walk early
+	sizes               *sizeRun       // Directory size calculation in progress
+	sizeGen             int            // Calculation count, to tell stale reports apart
+	showDiskSpace       bool           // Show the free space of the remote filesystem (F)
+	diskSpace           *SCPDiskSpaceMsg
+	syncScan            *syncScan       // Scan of both trees for a sync (S)
+	syncGen             int             // Scan count, to tell stale scans apart
+	syncPlan            *SyncPlanView   // Sync plan waiting for confirmation
+	syncRoots           [2]string       // Local and remote directories of the sync plan
+	bookmarks           *BookmarkPicker // Bookmarks of the connection, opened with b
+	watching            *watchRun       // Local directory uploaded as it changes (W)
+	watchLog            []string        // Activity log of the watch
+	opened              []*openedFile   // Remote files open in a local application (e)
+	pendingTransfer     []ssh.FileInfo  // Files waiting for a resume answer or a copy/move destination
+	queue               *transfer.Queue
+	queueBusy           bool // Queue had pending or active items at the last update
+	queueCompleted      int  // Completed items at the last update, to refresh panels
+	showQueue           bool
+	queueSelectedIdx    int
+	queueScrollOffset   int
+	rateLimit           int                 // KB/s for newly queued transfers, 0 = unlimited
+	rateLimitItem       int                 // Queue item whose limit is being edited, 0 for new transfers
+	viewer              *FileViewer         // Pager for the file opened with v
+	diffViewer          *DiffViewer         // Comparison opened with =
+	properties          *FilePropertiesForm // chmod/chown dialog opened with p
+	deleteConfirm       *DeleteConfirmation // Dialog opened with d
+	deleteFiles         []ssh.FileInfo      // Files the delete dialog asks about
+	deleteCounting      chan struct{}       // Closed to stop counting the files to delete
+	deleteGen           int                 // Dialog count, to tell stale counts apart
+	trashed             []trash.Item        // Files last moved to the trash, put back with z
+	trashedLocal        bool
+	trashedUntil        time.Time
 }
 
 // NewSCPManager creates a new SCP file manager component
 func NewSCPManager(conn config.SSHConnection) *SCPManager {
-	homeDir := "."
-	// Try to get home directory
-	if h, err := filepath.Abs("."); err == nil {
-		homeDir = h
+	dirs := startDirs(conn)
+	localDir, found := localStartDir(dirs.Local)
+	errorMsg := ""
+	if !found {
+		errorMsg = fmt.Sprintf("Local start directory %s not found", dirs.Local)
 	}
 
 	return &SCPManager{
 		connection:     conn,
-		localPanel:     Panel{Path: homeDir, Files: []ssh.FileInfo{}, SelectedIdx: 0},
-		remotePanel:    Panel{Path: ".", Files: []ssh.FileInfo{}, SelectedIdx: 0},
+		remoteStart:    dirs.Remote,
+		error:          errorMsg,
+		localPanel:     newPanel(localDir),
+		remotePanel:    newPanel("."),
 		activePanel:    0, // Start with local panel active
-		status:         "Connecting...",
+		status:         connectingStatus(conn),
 		loading:        true,
-		escTimeoutSecs: 2.0,
+		escTimeoutSecs: config.CurrentSettings().EscTimeoutSecs(),
 		inputMode:      ModeNormal,
 		inputBuffer:    "",
 		searchMatches:  []int{},
+		rateLimit:      ssh.DefaultRateLimit(),
 	}
 }
 
+// NewSCPManagerOn creates an SCP file manager working over the established
+// connection of a terminal session instead of logging in again
+func NewSCPManagerOn(conn config.SSHConnection, client *ssh.Client) *SCPManager {
+	s := NewSCPManager(conn)
+	s.sharedClient = client
+	return s
+}
+
 // Init initializes the component
 func (s *SCPManager) Init() tea.Cmd {
 	return tea.Batch(
@@ -113,6 +189,11 @@ func (s *SCPManager) Init() tea.Cmd {
 
 // Update handles component updates
 func (s *SCPManager) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	// Queue updates arrive regardless of other operations so the listener is re-armed
+	if _, ok := msg.(SCPQueueMsg); ok {
+		return s, s.handleQueueUpdate()
+	}
+
 	if s.operationInProgress {
 		// Don't process input while operation is in progress
 		switch msg := msg.(type) {
@@ -126,6 +207,37 @@ func (s *SCPManager) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return s, tea.Batch(s.listLocalFiles(), s.listRemoteFiles())
 			}
 			return s, nil
+		case SCPFileContentMsg:
+			s.operationInProgress = false
+			if msg.Err != nil {
+				s.error = fmt.Sprintf("View failed: %s", msg.Err.Error())
+				return s, nil
+			}
+			s.viewer = NewFileViewer(msg.Name, msg.Location, msg.Content, msg.Truncated)
+			s.viewer.SetSize(s.width, max(s.height-5, 0))
+			s.status = "Viewing " + msg.Name
+			return s, nil
+		case SCPTrashMsg:
+			return s, s.handleTrashed(msg)
+		case SCPSearchMsg:
+			return s, s.handleSearchResults(msg)
+		case SCPDirSizeMsg:
+			return s, s.handleDirSize(msg)
+		case SCPArchiveMsg:
+			return s, s.handleArchive(msg)
+		case SCPDiffMsg:
+			s.handleDiff(msg)
+			return s, nil
+		case SCPSyncScanMsg:
+			s.handleSyncScan(msg)
+			return s, nil
+		case SCPWatchMsg:
+			return s, s.handleWatch(msg)
+		case SCPOpenMsg:
+			return s, s.handleOpen(msg)
+		case SCPDiskSpaceMsg:
+			s.handleDiskSpace(msg)
+			return s, nil
 		case tea.WindowSizeMsg:
 			s.width = msg.Width
 			s.height = msg.Height
@@ -138,6 +250,24 @@ func (s *SCPManager) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		s.width = msg.Width
 		s.height = msg.Height
+		if s.viewer != nil {
+			s.viewer.SetSize(s.width, max(s.height-5, 0))
+		}
+		if s.diffViewer != nil {
+			s.diffViewer.SetSize(s.width, max(s.height-5, 0))
+		}
+		if s.properties != nil {
+			s.properties.SetSize(s.width, max(s.height-5, 0))
+		}
+		if s.deleteConfirm != nil {
+			s.deleteConfirm.SetSize(s.width, max(s.height-5, 0))
+		}
+		if s.syncPlan != nil {
+			s.syncPlan.SetSize(s.width, max(s.height-5, 0))
+		}
+		if s.bookmarks != nil {
+			s.bookmarks.SetSize(s.width, max(s.height-5, 0))
+		}
 		return s, nil
 
 	case SCPConnectionMsg:
@@ -150,8 +280,12 @@ func (s *SCPManager) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		s.sftpClient = msg.Client
 		s.status = "Connected"
 		s.remotePanel.Path = msg.WorkingDir
+		if msg.MissingStart != "" {
+			s.error = fmt.Sprintf("Remote start directory %s not found", msg.MissingStart)
+		}
+		s.queue = transfer.New(transfer.ConcurrencyFromEnv(), s.runTransfer)
 
-		return s, s.listRemoteFiles()
+		return s, tea.Batch(s.listRemoteFiles(), s.waitForQueue())
 
 	case SSHPassphraseRequiredMsg:
 		return s, func() tea.Msg {
@@ -169,20 +303,49 @@ func (s *SCPManager) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return s, nil
 		}
 		if msg.IsLocal {
-			s.localPanel.Files = msg.Files
+			s.localPanel.setFiles(msg.Files)
 			s.localPanel.Path = msg.Path
 			if s.localPanel.SelectedIdx >= len(s.localPanel.Files) {
 				s.localPanel.SelectedIdx = max(0, len(s.localPanel.Files)-1)
 			}
 		} else {
-			s.remotePanel.Files = msg.Files
+			s.remotePanel.setFiles(msg.Files)
 			s.remotePanel.Path = msg.Path
 			if s.remotePanel.SelectedIdx >= len(s.remotePanel.Files) {
 				s.remotePanel.SelectedIdx = max(0, len(s.remotePanel.Files)-1)
 			}
+			return s, s.fetchDiskSpace()
 		}
 		return s, nil
 
+	case SCPPathCompletionMsg:
+		s.handlePathCompletion(msg)
+		return s, nil
+
+	case SCPSearchMsg:
+		return s, s.handleSearchResults(msg)
+
+	case SCPDirSizeMsg:
+		return s, s.handleDirSize(msg)
+
+	case SCPDeleteCountMsg:
+		s.handleDeleteCount(msg)
+		return s, nil
+
+	case SCPSyncScanMsg:
+		s.handleSyncScan(msg)
+		return s, nil
+
+	case SCPWatchMsg:
+		return s, s.handleWatch(msg)
+
+	case SCPOpenMsg:
+		return s, s.handleOpen(msg)
+
+	case SCPDiskSpaceMsg:
+		s.handleDiskSpace(msg)
+		return s, nil
+
 	case SCPOperationMsg:
 		s.operationInProgress = false
 		if msg.Err != nil {
@@ -212,15 +375,18 @@ func (s *SCPManager) View() string {
 		"%s@%s:%d - %s",
 		s.connection.Username, s.connection.Host, s.connection.Port, s.connection.Name,
 	)
+	if s.connection.ViewOnly {
+		headerText += " (view only)"
+	}
 	header := scpHeaderStyle.Width(s.width).Render(headerText)
 
 	// Build status/footer with input prompt if in input mode
 	var statusText string
 
 	// Styles for status messages
-	successStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("42")).Bold(true) // Green
-	errStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)      // Red
-	normalStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("241"))            // Grey/Normal
+	successStyle := lipgloss.NewStyle().Foreground(colorSuccess).Bold(true) // Green
+	errStyle := lipgloss.NewStyle().Foreground(colorError).Bold(true)       // Red
+	normalStyle := lipgloss.NewStyle().Foreground(colorMuted)               // Grey/Normal
 
 	// Container style ensures it is centered and full width
 	containerStyle := scpStatusStyle.Width(s.width).Align(lipgloss.Center)
@@ -233,8 +399,11 @@ func (s *SCPManager) View() string {
 	} else if s.inputMode != ModeNormal {
 		// Show input prompt
 		prompt := s.status + s.inputBuffer
-		if s.inputMode == ModeSearch && len(s.searchMatches) > 0 {
-			prompt += fmt.Sprintf(" [%d/%d matches]", s.searchSelectedIdx+1, len(s.searchMatches))
+		if s.inputMode == ModeChangeDir {
+			prompt += s.pathCompletionsView()
+		}
+		if s.inputMode == ModeSearch {
+			prompt += s.searchBadge()
 		}
 		statusText = containerStyle.Render(prompt)
 	} else {
@@ -254,8 +423,37 @@ func (s *SCPManager) View() string {
 	// 2 lines for header + 1 line filter + 2 line for footer = 5 lines reserved
 	contentHeight := max(s.height-5, 0)
 
-	// Build content with split panels
-	content := s.renderPanels(contentHeight)
+	// Build content with split panels, the file viewer or the transfer queue
+	var content string
+	if s.viewer != nil {
+		content = s.viewer.View()
+	} else if s.diffViewer != nil {
+		content = s.diffViewer.View()
+	} else if s.properties != nil {
+		content = s.properties.View()
+	} else if s.deleteConfirm != nil {
+		content = s.deleteConfirm.View()
+	} else if s.syncPlan != nil {
+		content = s.syncPlan.View()
+	} else if s.bookmarks != nil {
+		content = s.bookmarks.View()
+	} else if s.showQueue {
+		content = s.renderQueue(contentHeight)
+	} else {
+		// The disk space line and the watch log go under the panels
+		var below []string
+		if s.showDiskSpace && !s.loading {
+			below = append(below, s.renderDiskSpace())
+		}
+		if s.watching != nil && !s.loading {
+			below = append(below, s.renderWatchLog())
+		}
+		panelsHeight := contentHeight
+		for _, part := range below {
+			panelsHeight -= lipgloss.Height(part)
+		}
+		content = lipgloss.JoinVertical(lipgloss.Left, append([]string{s.renderPanels(max(panelsHeight, 0))}, below...)...)
+	}
 
 	return lipgloss.JoinVertical(lipgloss.Left, header, content, statusText)
 }
@@ -267,7 +465,7 @@ func (s *SCPManager) renderPanels(availableHeight int) string {
 			Height(availableHeight).
 			Width(s.width).
 			Align(lipgloss.Center, lipgloss.Center).
-			Render("Connecting to remote server...")
+			Render(strings.Replace(s.status, "Connecting...", "Connecting to remote server...", 1))
 	}
 
 	// Calculate panel dimensions
@@ -278,7 +476,7 @@ func (s *SCPManager) renderPanels(availableHeight int) string {
 	panelHeight := max(availableHeight-5, 0)
 
 	// Render local panel
-	localTitle := "Local: " + s.localPanel.Path
+	localTitle := panelTitle("Local: ", &s.localPanel)
 	localContent := s.renderPanelContent(&s.localPanel, panelHeight, panelWidth)
 
 	var localPanel string
@@ -297,7 +495,7 @@ func (s *SCPManager) renderPanels(availableHeight int) string {
 		))
 
 	// Render remote panel
-	remoteTitle := "Remote: " + s.remotePanel.Path
+	remoteTitle := panelTitle("Remote: ", &s.remotePanel)
 	remoteContent := s.renderPanelContent(&s.remotePanel, panelHeight, panelWidth)
 
 	var remotePanel string
@@ -319,6 +517,14 @@ func (s *SCPManager) renderPanels(availableHeight int) string {
 	return lipgloss.JoinHorizontal(lipgloss.Top, localPanel, remotePanel)
 }
 
+// panelTitle names the panel's directory and how it is listed
+func panelTitle(prefix string, panel *Panel) string {
+	if label := panel.listingLabel(); label != "" {
+		return prefix + panel.Path + " [" + label + "]"
+	}
+	return prefix + panel.Path
+}
+
 // renderPanelContent renders the file list for a panel
 func (s *SCPManager) renderPanelContent(panel *Panel, maxHeight int, maxWidth int) string {
 	if len(panel.Files) == 0 {
@@ -357,12 +563,18 @@ func (s *SCPManager) renderPanelContent(panel *Panel, maxHeight int, maxWidth in
 
 		// Format Name with strict truncation
 		name := file.Name
+		marked := panel.Marked[file.Name]
+		if marked {
+			name = "+ " + name
+		}
 		if len(name) > nameWidth {
 			name = name[:nameWidth-1] + "…"
 		}
 
 		var nameRendered string
-		if file.IsDir {
+		if marked {
+			nameRendered = scpMarkedStyle.Width(nameWidth).Render(name)
+		} else if file.IsDir {
 			nameRendered = scpDirStyle.Width(nameWidth).Render(name)
 		} else {
 			nameRendered = nameStyle.Width(nameWidth).Render(name)
@@ -370,6 +582,9 @@ func (s *SCPManager) renderPanelContent(panel *Panel, maxHeight int, maxWidth in
 
 		// Format Metadata
 		sizeStr := formatSize(file.Size)
+		if size, ok := panel.DirSizes[filepath.Join(panel.Path, file.Name)]; ok && file.IsDir {
+			sizeStr = formatSize(size)
+		}
 		dateStr := file.ModTime.Format("Jan 02 15:04")
 		permStr := file.Perm
 		ownerStr := fmt.Sprintf("%s:%s", file.Owner, file.Group)
@@ -494,24 +709,120 @@ func (s *SCPManager) getFileIcon(file ssh.FileInfo) string {
 
 // handleKey handles keyboard input
 func (s *SCPManager) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// The file viewer takes all keys until it is closed
+	if s.viewer != nil {
+		_, cmd := s.viewer.Update(msg)
+		if s.viewer.IsClosed() {
+			// Graphics drawn by the terminal stay until the screen is cleared
+			if s.viewer.IsImage() {
+				cmd = tea.Batch(cmd, tea.ClearScreen)
+			}
+			s.viewer = nil
+			s.status = "Connected"
+		}
+		return s, cmd
+	}
+
+	// So does the diff viewer
+	if s.diffViewer != nil {
+		_, cmd := s.diffViewer.Update(msg)
+		if s.diffViewer.IsClosed() {
+			s.diffViewer = nil
+			s.status = "Connected"
+		}
+		return s, cmd
+	}
+
+	// And the properties dialog
+	if s.properties != nil {
+		_, cmd := s.properties.Update(msg)
+		switch {
+		case s.properties.IsCanceled():
+			s.properties = nil
+			s.status = "Cancelled"
+		case s.properties.IsSubmitted():
+			form := s.properties
+			s.properties = nil
+			return s, s.applyProperties(form)
+		}
+		return s, cmd
+	}
+
+	// And the delete confirmation
+	if s.deleteConfirm != nil {
+		_, cmd := s.deleteConfirm.Update(msg)
+		switch {
+		case s.deleteConfirm.IsCanceled():
+			s.stopDeleteCount()
+			s.deleteConfirm = nil
+			s.deleteFiles = nil
+			s.status = "Delete cancelled"
+		case s.deleteConfirm.IsConfirmed():
+			files, permanent := s.deleteFiles, s.deleteConfirm.IsPermanent()
+			s.deleteConfirm = nil
+			s.deleteFiles = nil
+			return s, s.deleteSelection(files, permanent)
+		}
+		return s, cmd
+	}
+
+	// And the sync plan
+	if s.syncPlan != nil {
+		_, cmd := s.syncPlan.Update(msg)
+		switch plan := s.syncPlan; {
+		case plan.IsCanceled():
+			s.syncPlan = nil
+			s.status = "Sync cancelled"
+		case plan.NeedsRescan():
+			s.syncPlan = nil
+			return s, s.startSync(plan.Options(), plan.Checksum())
+		case plan.IsConfirmed():
+			s.syncPlan = nil
+			return s, s.executeSync(plan.Plan())
+		}
+		return s, cmd
+	}
+
+	// And the bookmarks
+	if s.bookmarks != nil {
+		_, cmd := s.bookmarks.Update(msg)
+		if s.bookmarks.IsDone() {
+			return s, tea.Batch(cmd, s.closeBookmarks())
+		}
+		return s, cmd
+	}
+
 	// Handle input modes first - route ALL input modes to handleInputMode
 	switch s.inputMode {
-	case ModeSearch, ModeCreateFile, ModeRename, ModeChangeDir, ModeConfirmDelete:
+	case ModeSearch, ModeCreateFile, ModeRename, ModeChangeDir, ModeConfirmResume, ModeCopyTo, ModeMoveTo, ModeRateLimit, ModeArchive:
 		return s.handleInputMode(msg)
 	}
 
+	if s.showQueue {
+		return s.handleQueueKey(msg)
+	}
+
 	// Normal mode key handling
 	switch msg.String() {
 	case "esc":
+		// Stop measuring directories first
+		if s.sizes != nil {
+			s.cancelSizes()
+			s.status = "Size calculation stopped"
+			return s, nil
+		}
+		if s.syncScan != nil {
+			s.cancelSync()
+			s.status = "Sync scan stopped"
+			return s, nil
+		}
+
 		// Double ESC to exit
 		now := time.Now()
 		timeSinceLastEsc := now.Sub(s.lastEscTime).Seconds()
 
 		if s.escPressCount > 0 && timeSinceLastEsc <= s.escTimeoutSecs {
-			s.finished = true
-			if s.sftpClient != nil {
-				s.sftpClient.Close()
-			}
+			s.Close()
 			s.escPressCount = 0
 			s.lastEscTime = time.Time{}
 			return s, nil
@@ -519,6 +830,9 @@ func (s *SCPManager) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 		s.escPressCount = 1
 		s.lastEscTime = now
+		if s.queueBusy {
+			s.status = "Transfers in progress will be cancelled. Press esc again to exit"
+		}
 		return s, nil
 
 	case "tab":
@@ -551,21 +865,114 @@ func (s *SCPManager) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return s, s.goUpDirectory()
 
 	case "g":
-		// Get file (download from remote to local)
+		// Get files (download from remote to local)
 		if s.activePanel == 1 {
-			return s, s.downloadFile()
+			s.transferSelection()
 		}
 		return s, nil
 
 	case "u":
-		// Upload file (from local to remote)
-		if s.activePanel == 0 {
-			return s, s.uploadFile()
+		// Upload files (from local to remote)
+		if s.activePanel == 0 && !s.refuseRemoteChange() {
+			s.transferSelection()
+		}
+		return s, nil
+
+	case " ":
+		// Mark or unmark the highlighted file for a batch transfer
+		panel := s.getActivePanel()
+		if panel.SelectedIdx >= 0 && panel.SelectedIdx < len(panel.Files) {
+			name := panel.Files[panel.SelectedIdx].Name
+			if panel.Marked[name] {
+				delete(panel.Marked, name)
+			} else {
+				if panel.Marked == nil {
+					panel.Marked = make(map[string]bool)
+				}
+				panel.Marked[name] = true
+			}
+			if panel.SelectedIdx < len(panel.Files)-1 {
+				panel.SelectedIdx++
+			}
+		}
+		return s, nil
+
+	case "q":
+		// Show the transfer queue
+		s.showQueue = true
+		return s, nil
+
+	case "B":
+		// Set the bandwidth limit for transfers queued from now on
+		s.promptRateLimit(0, s.rateLimit)
+		return s, nil
+
+	case "b":
+		// Jump to a bookmarked directory, or bookmark this one
+		s.openBookmarks()
+		return s, nil
+
+	case "v":
+		// View the highlighted file
+		return s, s.viewFile()
+
+	case "e":
+		// Open the highlighted file with the default application
+		return s, s.openSelection()
+
+	case "C", "M":
+		// Copy or move files to another remote directory, on the server itself
+		if s.activePanel != 1 {
+			s.error = "Copy and move work on the remote panel"
+			return s, nil
+		}
+		if s.sftpClient == nil {
+			s.error = "Not connected to remote server"
+			return s, nil
+		}
+		if s.refuseRemoteChange() {
+			return s, nil
+		}
+		files := s.selectedFiles()
+		if len(files) == 0 {
+			s.error = "No file selected"
+			return s, nil
+		}
+		s.pendingTransfer = files
+		s.inputBuffer = s.remotePanel.Path
+		what := "'" + files[0].Name + "'"
+		if len(files) > 1 {
+			what = fmt.Sprintf("%d items", len(files))
+		}
+		if msg.String() == "C" {
+			s.inputMode = ModeCopyTo
+			s.status = fmt.Sprintf("Copy %s to: ", what)
+		} else {
+			s.inputMode = ModeMoveTo
+			s.status = fmt.Sprintf("Move %s to: ", what)
+		}
+		return s, nil
+
+	case "p":
+		// Show details and edit permissions/owner of the highlighted file
+		panel := s.getActivePanel()
+		if panel.SelectedIdx >= 0 && panel.SelectedIdx < len(panel.Files) {
+			file := panel.Files[panel.SelectedIdx]
+			location := filepath.Join(panel.Path, file.Name)
+			if s.activePanel == 1 {
+				location = fmt.Sprintf("%s:%s", s.connection.Host, location)
+			}
+			s.properties = NewFilePropertiesForm(file, location)
+			s.properties.SetSize(s.width, max(s.height-5, 0))
+			return s, s.properties.Init()
 		}
 		return s, nil
 
 	case "n":
 		// Create new file
+		if s.activePanel == 1 && s.refuseRemoteChange() {
+			return s, nil
+		}
 		s.inputMode = ModeCreateFile
 		s.inputBuffer = ""
 		s.status = "Create file (use / for directories): "
@@ -574,6 +981,9 @@ func (s *SCPManager) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	case "r":
 		// Rename file
 		panel := s.getActivePanel()
+		if s.activePanel == 1 && s.refuseRemoteChange() {
+			return s, nil
+		}
 		if panel.SelectedIdx >= 0 && panel.SelectedIdx < len(panel.Files) {
 			s.inputMode = ModeRename
 			s.inputBuffer = panel.Files[panel.SelectedIdx].Name
@@ -582,25 +992,55 @@ func (s *SCPManager) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return s, nil
 
 	case "d", "x":
-		// Delete file - show confirmation first
-		panel := s.getActivePanel()
-		if panel.SelectedIdx >= 0 && panel.SelectedIdx < len(panel.Files) {
-			file := panel.Files[panel.SelectedIdx]
-			s.deleteTarget = &file
-			s.inputMode = ModeConfirmDelete
-			typeStr := "file"
-			if file.IsDir {
-				typeStr = "directory"
-			}
-			s.status = fmt.Sprintf("Delete %s '%s'? (y/n): ", typeStr, file.Name)
+		// Delete the marked or highlighted files - show confirmation first
+		if s.activePanel == 1 && s.refuseRemoteChange() {
+			return s, nil
+		}
+		return s, s.confirmDelete()
+
+	case "z":
+		// Put back the files last moved to the trash
+		return s, s.undoTrash()
+
+	case "s":
+		// Measure the selected directories
+		return s, s.measureSelection()
+
+	case "F":
+		// Show or hide the free space of the remote filesystem
+		return s, s.toggleDiskSpace()
+
+	case "A":
+		// Pack the selected remote files on the server and download them
+		if s.refuseRemoteChange() {
+			return s, nil
 		}
+		s.promptArchive()
 		return s, nil
 
-	case "c":
-		// Change directory - cd command
-		s.inputMode = ModeChangeDir
-		s.inputBuffer = ""
-		s.status = "Change directory (absolute path): "
+	case "X":
+		// Unpack the highlighted remote archive on the server
+		return s, s.extractSelection()
+
+	case "=":
+		// Compare the highlighted local and remote files
+		return s, s.diffSelection()
+
+	case "W":
+		// Upload the files changed in the local directory as they change
+		return s, s.toggleWatch()
+
+	case "S":
+		// Sync the directories of both panels, from the active one by default
+		mode := transfer.MirrorUp
+		if s.activePanel == 1 {
+			mode = transfer.MirrorDown
+		}
+		return s, s.startSync(transfer.SyncOptions{Mode: mode}, false)
+
+	case "c", ":":
+		// Go to a path typed with tab completion
+		s.startGoto()
 		return s, nil
 
 	case "/":
@@ -612,6 +1052,21 @@ func (s *SCPManager) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		s.status = "Recursive search: "
 		return s, nil
 
+	case ".":
+		// Show or hide dotfiles
+		s.toggleHidden()
+		return s, nil
+
+	case "o":
+		// Order by the next of name, size, mtime and extension
+		s.cycleSort()
+		return s, nil
+
+	case "O":
+		// List directories before files or among them
+		s.toggleDirsFirst()
+		return s, nil
+
 	case "ctrl+l":
 		// Refresh current panel
 		if s.activePanel == 0 {
@@ -631,6 +1086,16 @@ func (s *SCPManager) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 // Helper functions
 
+// refuseRemoteChange reports, with an error shown, whether the connection
+// is view-only and so the remote files must not be changed
+func (s *SCPManager) refuseRemoteChange() bool {
+	if !s.connection.ViewOnly {
+		return false
+	}
+	s.error = "View-only connection: remote files can't be changed"
+	return true
+}
+
 func (s *SCPManager) getActivePanel() *Panel {
 	if s.activePanel == 0 {
 		return &s.localPanel
@@ -640,9 +1105,14 @@ func (s *SCPManager) getActivePanel() *Panel {
 
 // handleInputMode handles key input when in search, create, or rename mode
 func (s *SCPManager) handleInputMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	// Special handling for delete confirmation
-	if s.inputMode == ModeConfirmDelete {
-		return s.handleDeleteConfirmation(msg)
+	if s.inputMode == ModeConfirmResume {
+		return s.handleResumeConfirmation(msg)
+	}
+	if s.inputMode == ModeChangeDir {
+		if msg.String() == "tab" {
+			return s, s.completePath()
+		}
+		s.pathCompletions = nil
 	}
 
 	switch msg.String() {
@@ -655,11 +1125,12 @@ func (s *SCPManager) handleInputMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			panel.SelectedIdx = 0
 			panel.ScrollOffset = 0
 		}
+		s.cancelSearch()
 		s.inputMode = ModeNormal
 		s.inputBuffer = ""
 		s.searchMatches = []int{}
 		s.recursiveResults = []ssh.FileInfo{}
-		s.deleteTarget = nil
+		s.pendingTransfer = nil
 		s.status = "Cancelled"
 		return s, nil
 
@@ -674,6 +1145,12 @@ func (s *SCPManager) handleInputMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return s.executeRename()
 		case ModeChangeDir:
 			return s.executeChangeDir()
+		case ModeCopyTo, ModeMoveTo:
+			return s.executeRemoteCopy(s.inputMode == ModeMoveTo)
+		case ModeRateLimit:
+			return s.executeRateLimit()
+		case ModeArchive:
+			return s.executeArchive()
 		}
 		return s, nil
 
@@ -684,7 +1161,7 @@ func (s *SCPManager) handleInputMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		// Update search results in real-time
 		if s.inputMode == ModeSearch {
-			s.updateSearchResults()
+			return s, s.updateSearchResults()
 		}
 		return s, nil
 
@@ -692,7 +1169,7 @@ func (s *SCPManager) handleInputMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Clear input buffer
 		s.inputBuffer = ""
 		if s.inputMode == ModeSearch {
-			s.searchMatches = []int{}
+			return s, s.updateSearchResults()
 		}
 		return s, nil
 
@@ -720,111 +1197,15 @@ func (s *SCPManager) handleInputMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			s.inputBuffer += msg.String()
 			// Update search results in real-time
 			if s.inputMode == ModeSearch {
-				s.updateSearchResults()
+				return s, s.updateSearchResults()
 			}
 		}
 		return s, nil
 	}
 }
 
-// updateSearchResults performs recursive fuzzy search from current directory
-func (s *SCPManager) updateSearchResults() {
-	panel := s.getActivePanel()
-	s.recursiveResults = []ssh.FileInfo{}
-	s.searchMatches = []int{}
-	s.searchSelectedIdx = 0
-
-	if s.inputBuffer == "" {
-		// Restore original files if search is cleared
-		if len(s.originalFiles) > 0 {
-			panel.Files = s.originalFiles
-			s.originalFiles = nil
-		}
-		return
-	}
-
-	// Store original files if not already stored
-	if len(s.originalFiles) == 0 {
-		s.originalFiles = make([]ssh.FileInfo, len(panel.Files))
-		copy(s.originalFiles, panel.Files)
-	}
-
-	query := strings.ToLower(s.inputBuffer)
-
-	// Start recursive search
-	s.recursiveSearchDir(panel.Path, query, "")
-
-	// Update panel with search results
-	if len(s.recursiveResults) > 0 {
-		panel.Files = s.recursiveResults
-		panel.SelectedIdx = 0
-		panel.ScrollOffset = 0
-		// All results are matches, so searchMatches contains all indices
-		for i := range s.recursiveResults {
-			s.searchMatches = append(s.searchMatches, i)
-		}
-	} else {
-		// No results, show empty
-		panel.Files = []ssh.FileInfo{}
-	}
-}
-
-// recursiveSearchDir recursively searches for files matching the query
-func (s *SCPManager) recursiveSearchDir(basePath, query, relativePath string) {
-	var files []ssh.FileInfo
-	var err error
-
-	currentPath := filepath.Join(basePath, relativePath)
-
-	if s.activePanel == 0 {
-		// Local search
-		files, err = ssh.ListLocalFiles(currentPath)
-	} else {
-		// Remote search
-		if s.sftpClient == nil {
-			return
-		}
-		files, err = s.sftpClient.ListFiles(currentPath)
-	}
-
-	if err != nil {
-		// Silently skip directories that cannot be accessed during search
-		return
-	}
-
-	for _, file := range files {
-		// Skip . and ..
-		if file.Name == "." || file.Name == ".." {
-			continue
-		}
-
-		fullRelPath := filepath.Join(relativePath, file.Name)
-
-		// Check if filename matches
-		if s.fuzzyMatch(query, strings.ToLower(file.Name)) {
-			// Create a new FileInfo with relative path in name for display
-			displayFile := ssh.FileInfo{
-				Name:    fullRelPath,
-				Size:    file.Size,
-				IsDir:   file.IsDir,
-				Mode:    file.Mode,
-				ModTime: file.ModTime,
-				Perm:    file.Perm,
-				Owner:   file.Owner,
-				Group:   file.Group,
-			}
-			s.recursiveResults = append(s.recursiveResults, displayFile)
-		}
-
-		// Recurse into directories (limit depth to prevent infinite loops)
-		if file.IsDir && len(strings.Split(fullRelPath, string(filepath.Separator))) < 10 {
-			s.recursiveSearchDir(basePath, query, fullRelPath)
-		}
-	}
-}
-
 // fuzzyMatch performs a simple fuzzy match
-func (s *SCPManager) fuzzyMatch(query, target string) bool {
+func fuzzyMatch(query, target string) bool {
 	if query == "" {
 		return true
 	}
@@ -842,9 +1223,9 @@ func (s *SCPManager) fuzzyMatch(query, target string) bool {
 func (s *SCPManager) executeSearch() (tea.Model, tea.Cmd) {
 	panel := s.getActivePanel()
 
-	if len(s.recursiveResults) > 0 {
-		// Keep the search results displayed
-		s.status = fmt.Sprintf("Found %d matches (ESC to restore)", len(s.recursiveResults))
+	if s.search != nil || len(s.recursiveResults) > 0 {
+		// Keep the search results displayed, and coming while the walk goes on
+		s.status = s.searchSummary()
 	} else {
 		s.status = "No matches found"
 		// Restore original files
@@ -967,76 +1348,121 @@ func (s *SCPManager) executeRename() (tea.Model, tea.Cmd) {
 	}
 }
 
-// handleDeleteConfirmation handles the delete confirmation prompt
-func (s *SCPManager) handleDeleteConfirmation(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+// handleResumeConfirmation asks whether partial targets are resumed or
+// transferred again from the start
+func (s *SCPManager) handleResumeConfirmation(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
-	case "y", "Y":
-		// Confirm delete
-		if s.deleteTarget == nil {
-			s.inputMode = ModeNormal
-			s.status = "No file to delete"
+	case "r", "R", "o", "O":
+		files := s.pendingTransfer
+		s.inputMode = ModeNormal
+		s.pendingTransfer = nil
+		if len(files) == 0 {
+			s.status = "No file to transfer"
 			return s, nil
 		}
+		s.enqueue(files, msg.String() == "r" || msg.String() == "R")
+		return s, nil
 
-		file := *s.deleteTarget
-		panel := s.getActivePanel()
-		filePath := filepath.Join(panel.Path, file.Name)
-
-		s.operationInProgress = true
+	case "esc":
 		s.inputMode = ModeNormal
-		s.deleteTarget = nil
-		s.status = fmt.Sprintf("Deleting %s...", file.Name)
+		s.pendingTransfer = nil
+		s.status = "Transfer cancelled"
+		return s, nil
 
-		return s, func() tea.Msg {
-			var err error
+	default:
+		// Ignore other keys
+		return s, nil
+	}
+}
 
-			if s.activePanel == 0 {
-				// Local file
-				err = ssh.DeleteLocalFile(filePath, file.IsDir)
-			} else {
-				// Remote file
-				if s.sftpClient == nil {
-					return SCPOperationMsg{Operation: "Delete", Success: false, Err: fmt.Errorf("not connected")}
-				}
-				err = s.sftpClient.DeleteFile(filePath, file.IsDir)
+// partialTarget reports whether targets holds a smaller, non-empty file of
+// the same name as file, i.e. an earlier transfer that was interrupted
+func partialTarget(file ssh.FileInfo, targets []ssh.FileInfo) (ssh.FileInfo, bool) {
+	if file.IsDir {
+		return ssh.FileInfo{}, false
+	}
+	for _, target := range targets {
+		if target.Name == file.Name {
+			return target, !target.IsDir && target.Size > 0 && target.Size < file.Size
+		}
+	}
+	return ssh.FileInfo{}, false
+}
+
+// executeRemoteCopy copies or moves the pending files to the entered remote
+// path. An existing directory receives the files under their own names; a
+// new path renames a single file or directory.
+func (s *SCPManager) executeRemoteCopy(move bool) (tea.Model, tea.Cmd) {
+	files := s.pendingTransfer
+	dest := strings.TrimSpace(s.inputBuffer)
+	s.inputMode = ModeNormal
+	s.inputBuffer = ""
+	s.pendingTransfer = nil
+	if dest == "" || len(files) == 0 {
+		s.error = "Destination cannot be empty"
+		return s, nil
+	}
+	if !filepath.IsAbs(dest) {
+		dest = filepath.Join(s.remotePanel.Path, dest)
+	}
+	dest = filepath.Clean(dest)
+	srcDir := s.remotePanel.Path
+	s.remotePanel.Marked = nil
+
+	operation := "Copy"
+	if move {
+		operation = "Move"
+	}
+	s.operationInProgress = true
+	s.status = fmt.Sprintf("%s to %s...", operation, dest)
+
+	return s, func() tea.Msg {
+		isDir, err := s.sftpClient.IsDir(dest)
+		if err != nil {
+			isDir = false
+			if len(files) > 1 {
+				return SCPOperationMsg{Operation: operation, Success: false, Err: fmt.Errorf("destination directory %s does not exist", dest)}
 			}
+		}
 
+		for _, file := range files {
+			src := filepath.Join(srcDir, file.Name)
+			target := dest
+			if isDir {
+				target = filepath.Join(dest, file.Name)
+			}
+			if target == src {
+				return SCPOperationMsg{Operation: operation, Success: false, Err: fmt.Errorf("%s is already there", file.Name)}
+			}
+			if strings.HasPrefix(target, src+"/") {
+				return SCPOperationMsg{Operation: operation, Success: false, Err: fmt.Errorf("cannot put %s inside itself", file.Name)}
+			}
+			if move {
+				err = s.sftpClient.MoveRemote(src, target)
+			} else {
+				err = s.sftpClient.CopyRemote(src, target)
+			}
 			if err != nil {
-				return SCPOperationMsg{Operation: "Delete", Success: false, Err: err}
+				return SCPOperationMsg{Operation: operation, Success: false, Err: err}
 			}
-			return SCPOperationMsg{Operation: "Delete", Success: true}
 		}
-
-	case "n", "N", "esc":
-		// Cancel delete
-		s.inputMode = ModeNormal
-		s.deleteTarget = nil
-		s.status = "Delete cancelled"
-		return s, nil
-
-	default:
-		// Ignore other keys
-		return s, nil
+		return SCPOperationMsg{Operation: operation, Success: true}
 	}
 }
 
-// executeChangeDir changes to the specified directory
+// executeChangeDir changes to the specified directory, "~" being the home
+// directory and relative paths taken from the panel's
 func (s *SCPManager) executeChangeDir() (tea.Model, tea.Cmd) {
-	if s.inputBuffer == "" {
+	input := strings.TrimSpace(s.inputBuffer)
+	if input == "" {
 		s.error = "Path cannot be empty"
 		s.inputMode = ModeNormal
 		return s, nil
 	}
 
 	panel := s.getActivePanel()
-	newPath := s.inputBuffer
-
-	// Clean up the path
-	if !filepath.IsAbs(newPath) {
-		// If relative path, make it absolute from current directory
-		newPath = filepath.Join(panel.Path, newPath)
-	}
-	newPath = filepath.Clean(newPath)
+	local := s.activePanel == 0
+	base := panel.Path
 
 	s.inputMode = ModeNormal
 	s.inputBuffer = ""
@@ -1047,28 +1473,36 @@ func (s *SCPManager) executeChangeDir() (tea.Model, tea.Cmd) {
 	return s, func() tea.Msg {
 		var files []ssh.FileInfo
 		var err error
+		var newPath string
 
-		if s.activePanel == 0 {
+		if local {
+			newPath = resolveGotoPath(input, base, localHome(), false)
 			files, err = ssh.ListLocalFiles(newPath)
 		} else {
 			if s.sftpClient == nil {
-				return SCPListFilesMsg{IsLocal: s.activePanel == 0, Err: fmt.Errorf("not connected")}
+				return SCPListFilesMsg{IsLocal: false, Err: fmt.Errorf("not connected")}
 			}
+			home := ""
+			if strings.HasPrefix(input, "~") {
+				home, _ = s.sftpClient.GetWorkingDir()
+			}
+			newPath = resolveGotoPath(input, base, home, true)
 			files, err = s.sftpClient.ListFiles(newPath)
 		}
 
 		if err != nil {
 			s.operationInProgress = false
-			return SCPOperationMsg{Operation: "Change directory", Success: false, Err: fmt.Errorf("directory does not exist or is inaccessible")}
+			return SCPOperationMsg{Operation: "Change directory", Success: false, Err: fmt.Errorf("%s does not exist or is inaccessible", newPath)}
 		}
 
 		// Directory is valid, update panel
 		panel.Path = newPath
+		panel.Marked = nil
 		panel.SelectedIdx = 0
 		panel.ScrollOffset = 0
 		s.operationInProgress = false
 
-		return SCPListFilesMsg{IsLocal: s.activePanel == 0, Files: files, Path: newPath}
+		return SCPListFilesMsg{IsLocal: local, Files: files, Path: newPath}
 	}
 }
 
@@ -1087,7 +1521,13 @@ func (s *SCPManager) createRemoteDirAndFile(dir, filePath string) error {
 
 func (s *SCPManager) connectSFTP() tea.Cmd {
 	return func() tea.Msg {
-		client, err := ssh.NewSFTPClient(s.connection)
+		var client *ssh.SFTPClient
+		var err error
+		if s.sharedClient != nil {
+			client, err = ssh.NewSFTPClientOn(s.sharedClient)
+		} else {
+			client, err = ssh.NewSFTPClient(s.connection)
+		}
 		if err != nil {
 			var passphraseErr *ssh.PassphraseRequiredError
 			if errors.As(err, &passphraseErr) {
@@ -1102,7 +1542,7 @@ func (s *SCPManager) connectSFTP() tea.Cmd {
 					Connection: s.connection,
 				}
 			}
-			return SCPConnectionMsg{nil, "", err}
+			return SCPConnectionMsg{Err: err}
 		}
 
 		// Fetch remote WD
@@ -1110,8 +1550,14 @@ func (s *SCPManager) connectSFTP() tea.Cmd {
 		if err != nil {
 			wd = "." // Fallback
 		}
-
-		return SCPConnectionMsg{client, wd, nil}
+		if s.remoteStart == "" {
+			return SCPConnectionMsg{Client: client, WorkingDir: wd}
+		}
+		start := remoteStartDir(wd, s.remoteStart)
+		if isDir, err := client.IsDir(start); err != nil || !isDir {
+			return SCPConnectionMsg{Client: client, WorkingDir: wd, MissingStart: s.remoteStart}
+		}
+		return SCPConnectionMsg{Client: client, WorkingDir: start}
 	}
 }
 
@@ -1175,6 +1621,7 @@ func (s *SCPManager) enterDirectory() tea.Cmd {
 
 		// Update panel path
 		panel.Path = newPath
+		panel.Marked = nil
 		panel.SelectedIdx = 0
 		panel.ScrollOffset = 0
 		s.operationInProgress = false
@@ -1215,6 +1662,7 @@ func (s *SCPManager) goUpDirectory() tea.Cmd {
 
 		// Update panel path
 		panel.Path = parent
+		panel.Marked = nil
 		panel.SelectedIdx = 0
 		panel.ScrollOffset = 0
 		s.operationInProgress = false
@@ -1223,67 +1671,115 @@ func (s *SCPManager) goUpDirectory() tea.Cmd {
 	}
 }
 
-func (s *SCPManager) downloadFile() tea.Cmd {
-	if s.sftpClient == nil {
-		s.error = "Not connected to remote server"
+// viewFile reads the highlighted file of the active panel for the viewer
+func (s *SCPManager) viewFile() tea.Cmd {
+	panel := s.getActivePanel()
+	if panel.SelectedIdx < 0 || panel.SelectedIdx >= len(panel.Files) {
+		s.error = "No file selected"
 		return nil
 	}
-
-	if s.remotePanel.SelectedIdx >= len(s.remotePanel.Files) {
-		s.error = "No file selected"
+	file := panel.Files[panel.SelectedIdx]
+	if file.IsDir {
+		s.error = "Cannot view a directory"
+		return nil
+	}
+	isLocal := s.activePanel == 0
+	if !isLocal && s.sftpClient == nil {
+		s.error = "Not connected to remote server"
 		return nil
 	}
 
-	file := s.remotePanel.Files[s.remotePanel.SelectedIdx]
-
-	s.operationInProgress = true
-	if file.IsDir {
-		s.status = "Downloading directory " + file.Name + " (recursive)..."
-	} else {
-		s.status = "Downloading " + file.Name + "..."
+	filePath := filepath.Join(panel.Path, file.Name)
+	location := filePath
+	if !isLocal {
+		location = fmt.Sprintf("%s:%s", s.connection.Host, filePath)
 	}
 
-	remotePath := filepath.Join(s.remotePanel.Path, file.Name)
-	localPath := filepath.Join(s.localPanel.Path, file.Name)
+	s.operationInProgress = true
+	s.status = "Opening " + file.Name + "..."
 
 	return func() tea.Msg {
-		err := s.sftpClient.DownloadFile(remotePath, localPath)
-		if err != nil {
-			return SCPOperationMsg{Operation: "Download", Success: false, Err: err}
+		var content []byte
+		var truncated bool
+		var err error
+		if isLocal {
+			content, truncated, err = ssh.ReadLocalFile(filePath, viewerLimit(file.Name))
+		} else {
+			content, truncated, err = s.sftpClient.ReadFile(filePath, viewerLimit(file.Name))
 		}
-		return SCPOperationMsg{Operation: "Download", Success: true}
+		return SCPFileContentMsg{Name: file.Name, Location: location, Content: content, Truncated: truncated, Err: err}
 	}
 }
 
-func (s *SCPManager) uploadFile() tea.Cmd {
-	if s.sftpClient == nil {
+// applyProperties runs chmod and/or chown for the submitted dialog
+func (s *SCPManager) applyProperties(form *FilePropertiesForm) tea.Cmd {
+	file := form.File()
+	panel := s.getActivePanel()
+	filePath := filepath.Join(panel.Path, file.Name)
+	isLocal := s.activePanel == 0
+	modeChanged, ownerChanged := form.ModeChanged(), form.OwnerChanged()
+	if !modeChanged && !ownerChanged {
+		s.status = "No changes"
+		return nil
+	}
+	if !isLocal && s.sftpClient == nil {
 		s.error = "Not connected to remote server"
 		return nil
 	}
-
-	if s.localPanel.SelectedIdx >= len(s.localPanel.Files) {
-		s.error = "No file selected"
+	if !isLocal && s.refuseRemoteChange() {
 		return nil
 	}
 
-	file := s.localPanel.Files[s.localPanel.SelectedIdx]
-
+	mode := form.Mode()
+	uid, gid := form.Owner()
 	s.operationInProgress = true
-	if file.IsDir {
-		s.status = "Uploading directory " + file.Name + " (recursive)..."
-	} else {
-		s.status = "Uploading " + file.Name + "..."
-	}
-
-	localPath := filepath.Join(s.localPanel.Path, file.Name)
-	remotePath := filepath.Join(s.remotePanel.Path, file.Name)
+	s.status = fmt.Sprintf("Updating %s...", file.Name)
 
 	return func() tea.Msg {
-		err := s.sftpClient.UploadFile(localPath, remotePath)
+		var err error
+		if modeChanged {
+			if isLocal {
+				err = ssh.ChmodLocal(filePath, mode)
+			} else {
+				err = s.sftpClient.Chmod(filePath, mode)
+			}
+		}
+		if err == nil && ownerChanged {
+			if isLocal {
+				err = ssh.ChownLocal(filePath, uid, gid)
+			} else {
+				err = s.sftpClient.Chown(filePath, uid, gid)
+			}
+		}
 		if err != nil {
-			return SCPOperationMsg{Operation: "Upload", Success: false, Err: err}
+			return SCPOperationMsg{Operation: "Change properties", Success: false, Err: err}
 		}
-		return SCPOperationMsg{Operation: "Upload", Success: true}
+		return SCPOperationMsg{Operation: "Change properties", Success: true}
+	}
+}
+
+// IsTyping reports whether keys go to a prompt, the file or diff viewer or
+// the properties, delete and sync dialogs rather than the file panels
+func (s *SCPManager) IsTyping() bool {
+	return s.inputMode != ModeNormal || s.viewer != nil || s.diffViewer != nil || s.properties != nil || s.deleteConfirm != nil || s.syncPlan != nil || s.bookmarks != nil
+}
+
+// Close remembers the directories, stops the transfer queue and closes
+// the SFTP connection
+func (s *SCPManager) Close() {
+	s.finished = true
+	s.cancelSearch()
+	s.cancelSizes()
+	s.stopDeleteCount()
+	s.cancelSync()
+	s.stopWatch()
+	s.closeOpened()
+	s.rememberDirs()
+	if s.queue != nil {
+		s.queue.Close()
+	}
+	if s.sftpClient != nil {
+		s.sftpClient.Close()
 	}
 }
 
@@ -0,0 +1,37 @@
+package components
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+func TestSCPManagerViewOnly(t *testing.T) {
+	s := NewSCPManager(config.SSHConnection{Name: "prod", ViewOnly: true})
+	s.loading = false
+	s.Update(SCPListFilesMsg{IsLocal: false, Path: "/srv", Files: []ssh.FileInfo{{Name: "app.conf", Size: 10}}})
+	key := func(k string) {
+		s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+
+	s.activePanel = 1
+	for _, k := range []string{"n", "r", "d", "A"} {
+		s.error = ""
+		key(k)
+		if !strings.Contains(s.error, "View-only") || s.IsTyping() {
+			t.Errorf("Expected %s to be refused on the remote panel, got error %q", k, s.error)
+		}
+		s.inputMode = ModeNormal
+	}
+
+	// The local files can still be changed
+	s.activePanel = 0
+	s.error = ""
+	key("n")
+	if s.error != "" || s.inputMode != ModeCreateFile {
+		t.Errorf("Expected n to create a local file, got error %q", s.error)
+	}
+}
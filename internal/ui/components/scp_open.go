@@ -0,0 +1,168 @@
+package components
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/transfer"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/watch"
+)
+
+// e opens the highlighted file with the default application of the local
+// system, e.g. an image viewer or an office suite. A remote file is first
+// downloaded to a temporary directory only the user can read, and uploaded
+// back each time it is saved there, until the file manager is closed. The
+// files of view-only connections are opened without uploading them back.
+
+// openWithDefaultApp opens a file with the default handler of the platform;
+// a variable so tests don't start applications
+var openWithDefaultApp = config.OpenBrowser
+
+// SCPOpenMsg carries a remote file downloaded to be opened locally
+type SCPOpenMsg struct {
+	RemotePath string
+	LocalPath  string
+	Err        error
+}
+
+// openedFile is a remote file open in a local application
+type openedFile struct {
+	watcher    *watch.Watcher // Watches the temporary directory of the copy, nil when not uploaded back
+	remotePath string
+	localPath  string
+}
+
+// openSelection opens the highlighted file with the default application
+func (s *SCPManager) openSelection() tea.Cmd {
+	panel := s.getActivePanel()
+	file, ok := highlightedFile(panel)
+	if !ok || file.IsDir {
+		s.error = "Highlight a file to open"
+		return nil
+	}
+	if s.activePanel == 0 {
+		path := filepath.Join(panel.Path, file.Name)
+		if err := openWithDefaultApp(path); err != nil {
+			s.error = fmt.Sprintf("Cannot open %s: %s", file.Name, err.Error())
+			return nil
+		}
+		s.status = "Opened " + file.Name
+		return nil
+	}
+
+	sftpClient := s.sftpClient
+	if sftpClient == nil {
+		s.error = "Not connected to remote server"
+		return nil
+	}
+	remotePath := filepath.ToSlash(filepath.Join(panel.Path, file.Name))
+	s.operationInProgress = true
+	s.status = fmt.Sprintf("Downloading %s to open it...", file.Name)
+
+	return func() tea.Msg {
+		msg := SCPOpenMsg{RemotePath: remotePath}
+		dir, err := os.MkdirTemp("", "sxt-open-")
+		if err != nil {
+			msg.Err = err
+			return msg
+		}
+		msg.LocalPath = filepath.Join(dir, file.Name)
+		if msg.Err = sftpClient.DownloadFile(remotePath, msg.LocalPath); msg.Err != nil {
+			os.RemoveAll(dir)
+		}
+		return msg
+	}
+}
+
+// handleOpen opens the downloaded copy and watches it for changes to
+// upload
+func (s *SCPManager) handleOpen(msg SCPOpenMsg) tea.Cmd {
+	s.operationInProgress = false
+	name := filepath.Base(msg.RemotePath)
+	if msg.Err != nil {
+		s.error = fmt.Sprintf("Cannot open %s: %s", name, msg.Err.Error())
+		return nil
+	}
+	dir := filepath.Dir(msg.LocalPath)
+	if s.finished {
+		os.RemoveAll(dir)
+		return nil
+	}
+	if err := openWithDefaultApp(msg.LocalPath); err != nil {
+		os.RemoveAll(dir)
+		s.error = fmt.Sprintf("Cannot open %s: %s", name, err.Error())
+		return nil
+	}
+	if s.connection.ViewOnly {
+		// Removed with the others when the file manager closes
+		s.opened = append(s.opened, &openedFile{remotePath: msg.RemotePath, localPath: msg.LocalPath})
+		s.status = fmt.Sprintf("Opened %s; changes to it stay local on a view-only connection", name)
+		return nil
+	}
+	w, err := watch.New(dir, nil, watch.DefaultInterval)
+	if err != nil {
+		s.error = fmt.Sprintf("Opened %s, but changes to it won't be uploaded: %s", name, err.Error())
+		return nil
+	}
+	s.opened = append(s.opened, &openedFile{watcher: w, remotePath: msg.RemotePath, localPath: msg.LocalPath})
+	s.status = fmt.Sprintf("Opened %s; saving it uploads it back", name)
+	return listenForWatch(w)
+}
+
+// openedFileOf returns the open file watched by w, if any
+func (s *SCPManager) openedFileOf(w *watch.Watcher) *openedFile {
+	for _, f := range s.opened {
+		if f.watcher == w {
+			return f
+		}
+	}
+	return nil
+}
+
+// handleOpenedChange uploads an open file saved by its application
+func (s *SCPManager) handleOpenedChange(f *openedFile, msg SCPWatchMsg) tea.Cmd {
+	name := filepath.Base(f.localPath)
+	if msg.Closed {
+		s.opened = slices.DeleteFunc(s.opened, func(o *openedFile) bool { return o == f })
+		s.error = fmt.Sprintf("Stopped uploading %s: its copy can no longer be read", name)
+		return nil
+	}
+	for _, change := range msg.Changes {
+		// Applications also write lock and backup files next to it
+		if change.Removed || change.Path != name {
+			continue
+		}
+		var size int64
+		if info, err := os.Stat(f.localPath); err == nil {
+			size = info.Size()
+		}
+		if s.queue != nil {
+			s.queue.Add(transfer.Item{
+				Name:      name,
+				Source:    f.localPath,
+				Target:    f.remotePath,
+				Direction: transfer.Upload,
+				Size:      size,
+				RateLimit: s.rateLimit,
+			})
+			s.status = fmt.Sprintf("Uploading the changes to %s", name)
+		}
+	}
+	return listenForWatch(f.watcher)
+}
+
+// closeOpened stops watching the open files and removes their copies
+func (s *SCPManager) closeOpened() {
+	for _, f := range s.opened {
+		if f.watcher != nil {
+			f.watcher.Close()
+		}
+		os.RemoveAll(filepath.Dir(f.localPath))
+	}
+	s.opened = nil
+}
@@ -0,0 +1,69 @@
+package components
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/transfer"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/watch"
+)
+
+func TestSCPManagerOpenWithDefaultApp(t *testing.T) {
+	var opened []string
+	defer func(open func(string) error) { openWithDefaultApp = open }(openWithDefaultApp)
+	openWithDefaultApp = func(path string) error {
+		opened = append(opened, path)
+		return nil
+	}
+
+	s := NewSCPManager(config.SSHConnection{Name: "web", Host: "web"})
+	s.loading = false
+	s.sftpClient = &ssh.SFTPClient{}
+	s.queue = transfer.New(1, func(transfer.Item) error { return nil })
+	defer s.queue.Close()
+
+	// Local files open in place
+	s.localPanel.Path = "/home/me"
+	s.localPanel.Files = []ssh.FileInfo{{Name: "photo.png"}}
+	s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	if len(opened) != 1 || opened[0] != filepath.Join("/home/me", "photo.png") {
+		t.Fatalf("Expected the local file opened in place, got %q", opened)
+	}
+
+	// Remote files open from a downloaded copy, uploaded back when saved
+	dir := t.TempDir()
+	copyPath := filepath.Join(dir, "report.docx")
+	if err := os.WriteFile(copyPath, []byte("v1"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cmd := s.handleOpen(SCPOpenMsg{RemotePath: "/srv/docs/report.docx", LocalPath: copyPath})
+	if cmd == nil || len(s.opened) != 1 || opened[1] != copyPath {
+		t.Fatalf("Expected the copy opened and watched, got %q", opened)
+	}
+	w := s.opened[0].watcher
+
+	s.Update(SCPWatchMsg{Watcher: w, Changes: []watch.Change{
+		{Path: ".~lock.report.docx#"},
+		{Path: "report.docx"},
+	}})
+	items := s.queue.Items()
+	if len(items) != 1 || items[0].Source != copyPath || items[0].Target != "/srv/docs/report.docx" || items[0].Direction != transfer.Upload {
+		t.Fatalf("Expected only the saved file queued for upload, got %+v", items)
+	}
+	if s.watching != nil || strings.Contains(s.View(), "Watching") {
+		t.Error("Expected an open file not to show the watch log")
+	}
+
+	s.Close()
+	if len(s.opened) != 0 {
+		t.Error("Expected Close to stop watching the open files")
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("Expected Close to remove the copy, got %v", err)
+	}
+}
@@ -0,0 +1,381 @@
+package components
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/hooks"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/metrics"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/transfer"
+)
+
+// targetPanel returns the panel files from the active panel are copied to
+func (s *SCPManager) targetPanel() *Panel {
+	if s.activePanel == 0 {
+		return &s.remotePanel
+	}
+	return &s.localPanel
+}
+
+// selectedFiles returns the marked files of the active panel, or the
+// highlighted one when nothing is marked
+func (s *SCPManager) selectedFiles() []ssh.FileInfo {
+	panel := s.getActivePanel()
+	var files []ssh.FileInfo
+	for _, file := range panel.Files {
+		if panel.Marked[file.Name] {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 && panel.SelectedIdx >= 0 && panel.SelectedIdx < len(panel.Files) {
+		files = append(files, panel.Files[panel.SelectedIdx])
+	}
+	return files
+}
+
+// transferSelection queues the selected files for upload from the local
+// panel or download from the remote one, asking first when some of them
+// were already partially transferred
+func (s *SCPManager) transferSelection() {
+	if s.sftpClient == nil || s.queue == nil {
+		s.error = "Not connected to remote server"
+		return
+	}
+
+	files := s.selectedFiles()
+	if len(files) == 0 {
+		s.error = "No file selected"
+		return
+	}
+
+	verb := "uploaded"
+	if s.activePanel == 1 {
+		verb = "downloaded"
+	}
+	target := s.targetPanel()
+	var partials []ssh.FileInfo
+	for _, file := range files {
+		if partial, ok := partialTarget(file, target.Files); ok {
+			partials = append(partials, partial)
+		}
+	}
+	if len(partials) == 0 {
+		s.enqueue(files, false)
+		return
+	}
+
+	s.pendingTransfer = files
+	s.inputMode = ModeConfirmResume
+	if len(files) == 1 {
+		s.status = fmt.Sprintf("'%s' is partially %s (%s of %s). Resume? (r: resume, o: overwrite, esc: cancel): ",
+			files[0].Name, verb, formatSize(partials[0].Size), formatSize(files[0].Size))
+	} else {
+		s.status = fmt.Sprintf("%d of %d files are partially %s. Resume them? (r: resume, o: overwrite, esc: cancel): ",
+			len(partials), len(files), verb)
+	}
+}
+
+// enqueue adds files to the transfer queue. With resume set, files that
+// have a partial target continue from where they stopped.
+func (s *SCPManager) enqueue(files []ssh.FileInfo, resume bool) {
+	source, target := s.getActivePanel(), s.targetPanel()
+	direction := transfer.Upload
+	if s.activePanel == 1 {
+		direction = transfer.Download
+	}
+
+	items := make([]transfer.Item, 0, len(files))
+	for _, file := range files {
+		_, partial := partialTarget(file, target.Files)
+		items = append(items, transfer.Item{
+			Name:      file.Name,
+			Source:    filepath.Join(source.Path, file.Name),
+			Target:    filepath.Join(target.Path, file.Name),
+			Direction: direction,
+			IsDir:     file.IsDir,
+			Size:      file.Size,
+			Resume:    resume && partial,
+			RateLimit: s.rateLimit,
+		})
+	}
+	s.queue.Add(items...)
+	source.Marked = nil
+
+	if len(items) == 1 {
+		s.status = fmt.Sprintf("Queued %s of %s (q: view queue)", strings.ToLower(direction.String()), items[0].Name)
+	} else {
+		s.status = fmt.Sprintf("Queued %d %ss (q: view queue)", len(items), strings.ToLower(direction.String()))
+	}
+}
+
+// runTransfer performs one queued transfer, counts its result in the
+// metrics and fires the hooks of a long one; it runs on a queue worker
+func (s *SCPManager) runTransfer(item transfer.Item) error {
+	started := time.Now()
+	err := s.performTransfer(item)
+	metrics.TransferDone(s.connection.Host, err)
+	what := fmt.Sprintf("%s of %s", strings.ToLower(item.Direction.String()), item.Name)
+	hooks.TransferFinished(s.connection, what, time.Since(started), err)
+	return err
+}
+
+func (s *SCPManager) performTransfer(item transfer.Item) error {
+	if s.sftpClient == nil {
+		return fmt.Errorf("not connected")
+	}
+	client := s.sftpClient.WithRateLimit(item.RateLimit)
+	switch {
+	case item.Sync && item.Direction == transfer.Download:
+		return client.SyncDownload(item.Source, item.Target)
+	case item.Sync:
+		return client.SyncUpload(item.Source, item.Target)
+	case item.Direction == transfer.Download && item.Resume:
+		return client.ResumeDownload(item.Source, item.Target)
+	case item.Direction == transfer.Download:
+		return client.DownloadFile(item.Source, item.Target)
+	case item.Resume:
+		return client.ResumeUpload(item.Source, item.Target)
+	default:
+		return client.UploadFile(item.Source, item.Target)
+	}
+}
+
+// waitForQueue delivers the next queue update as an SCPQueueMsg
+func (s *SCPManager) waitForQueue() tea.Cmd {
+	updates := s.queue.Updates()
+	return func() tea.Msg {
+		if _, ok := <-updates; !ok {
+			return nil
+		}
+		return SCPQueueMsg{}
+	}
+}
+
+// handleQueueUpdate refreshes the panels as transfers complete and reports
+// on the queue once it drains
+func (s *SCPManager) handleQueueUpdate() tea.Cmd {
+	if s.queue == nil {
+		return nil
+	}
+	cmds := []tea.Cmd{s.waitForQueue()}
+
+	counts := s.queue.Counts()
+	if counts[transfer.Completed] > s.queueCompleted {
+		cmds = append(cmds, s.listLocalFiles(), s.listRemoteFiles())
+	}
+	s.queueCompleted = counts[transfer.Completed]
+
+	busy := counts[transfer.Pending]+counts[transfer.Active] > 0
+	switch {
+	case busy:
+		s.status = fmt.Sprintf("Transfers: %d active, %d pending, %d completed, %d failed",
+			counts[transfer.Active], counts[transfer.Pending], counts[transfer.Completed], counts[transfer.Failed])
+	case s.queueBusy && counts[transfer.Failed] > 0:
+		s.error = fmt.Sprintf("%d transfers failed (q: view queue to retry)", counts[transfer.Failed])
+	case s.queueBusy:
+		s.status = "Transfers completed successfully"
+	}
+	s.queueBusy = busy
+
+	items := s.queue.Items()
+	s.logWatchTransfers(items)
+	if s.queueSelectedIdx >= len(items) {
+		s.queueSelectedIdx = max(0, len(items)-1)
+	}
+	return tea.Batch(cmds...)
+}
+
+// handleQueueKey handles keys while the transfer queue is shown
+func (s *SCPManager) handleQueueKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if s.queue == nil {
+		s.showQueue = false
+		return s, nil
+	}
+	items := s.queue.Items()
+
+	switch msg.String() {
+	case "q", "esc", "tab":
+		s.showQueue = false
+
+	case "up", "k":
+		if s.queueSelectedIdx > 0 {
+			s.queueSelectedIdx--
+		}
+
+	case "down", "j":
+		if s.queueSelectedIdx < len(items)-1 {
+			s.queueSelectedIdx++
+		}
+
+	case "r":
+		if s.queueSelectedIdx < len(items) {
+			item := items[s.queueSelectedIdx]
+			if s.queue.Retry(item.ID) {
+				s.status = "Retrying " + item.Name
+			} else {
+				s.error = "Only failed transfers can be retried"
+			}
+		}
+
+	case "R":
+		if n := s.queue.RetryFailed(); n > 0 {
+			s.status = fmt.Sprintf("Retrying %d failed transfers", n)
+		} else {
+			s.status = "No failed transfers"
+		}
+
+	case "c":
+		s.queue.ClearCompleted()
+		s.queueCompleted = 0
+		s.queueSelectedIdx = 0
+		s.queueScrollOffset = 0
+
+	case "b":
+		if s.queueSelectedIdx < len(items) {
+			item := items[s.queueSelectedIdx]
+			if item.State == transfer.Pending || item.State == transfer.Failed {
+				s.promptRateLimit(item.ID, item.RateLimit)
+			} else {
+				s.error = "Only transfers that have not started can be limited"
+			}
+		}
+
+	case "+", "=":
+		s.queue.SetConcurrency(s.queue.Concurrency() + 1)
+		s.status = fmt.Sprintf("Running up to %d transfers at once", s.queue.Concurrency())
+
+	case "-":
+		s.queue.SetConcurrency(s.queue.Concurrency() - 1)
+		s.status = fmt.Sprintf("Running up to %d transfers at once", s.queue.Concurrency())
+	}
+	return s, nil
+}
+
+// promptRateLimit asks for a bandwidth limit for queue item id, or for new
+// transfers when id is 0
+func (s *SCPManager) promptRateLimit(id, current int) {
+	s.rateLimitItem = id
+	s.inputMode = ModeRateLimit
+	s.inputBuffer = strconv.Itoa(current)
+	if id == 0 {
+		s.status = "Bandwidth limit for new transfers in KB/s (0 = unlimited): "
+	} else {
+		s.status = "Bandwidth limit for this transfer in KB/s (0 = unlimited): "
+	}
+}
+
+// executeRateLimit applies the entered bandwidth limit
+func (s *SCPManager) executeRateLimit() (tea.Model, tea.Cmd) {
+	id := s.rateLimitItem
+	s.inputMode = ModeNormal
+	s.rateLimitItem = 0
+	kbps, err := strconv.Atoi(strings.TrimSpace(s.inputBuffer))
+	s.inputBuffer = ""
+	if err != nil || kbps < 0 {
+		s.error = "Bandwidth limit must be a number of KB/s"
+		return s, nil
+	}
+
+	if id == 0 {
+		s.rateLimit = kbps
+		s.status = "New transfers limited to " + ssh.FormatRateLimit(kbps)
+		return s, nil
+	}
+	if s.queue == nil || !s.queue.SetRateLimit(id, kbps) {
+		s.error = "The transfer has already started"
+		return s, nil
+	}
+	s.status = "Transfer limited to " + ssh.FormatRateLimit(kbps)
+	return s, nil
+}
+
+// renderQueue renders the transfer queue in place of the file panels
+func (s *SCPManager) renderQueue(availableHeight int) string {
+	width := max(s.width-2, 20)
+	style := scpActivePanelStyle.Width(width).Height(max(availableHeight-2, 0))
+	if s.queue == nil {
+		return style.Render("Not connected")
+	}
+
+	items := s.queue.Items()
+	counts := s.queue.Counts()
+	title := fmt.Sprintf("Transfer queue — %d active, %d pending, %d completed, %d failed — up to %d at once",
+		counts[transfer.Active], counts[transfer.Pending], counts[transfer.Completed], counts[transfer.Failed], s.queue.Concurrency())
+	hint := lipgloss.NewStyle().Foreground(colorSubText).
+		Render("↑/↓: navigate | r: retry | R: retry all failed | b: limit | c: clear completed | +/-: concurrency | q: back")
+
+	// Title, blank line, rows, blank line and hint inside the padded border
+	maxRows := max(availableHeight-9, 1)
+	if s.queueSelectedIdx < s.queueScrollOffset {
+		s.queueScrollOffset = s.queueSelectedIdx
+	}
+	if s.queueSelectedIdx >= s.queueScrollOffset+maxRows {
+		s.queueScrollOffset = s.queueSelectedIdx - maxRows + 1
+	}
+
+	var lines []string
+	if len(items) == 0 {
+		lines = append(lines, "  (no transfers — mark files with space, then g/u)")
+	}
+	stateStyle := lipgloss.NewStyle().Width(10)
+	sizeStyle := lipgloss.NewStyle().Width(8).Align(lipgloss.Right).Foreground(colorSubText)
+	for i := s.queueScrollOffset; i < min(s.queueScrollOffset+maxRows, len(items)); i++ {
+		item := items[i]
+
+		var stateRendered string
+		switch item.State {
+		case transfer.Active:
+			stateRendered = stateStyle.Foreground(colorSecondary).Bold(true).Render(item.State.String())
+		case transfer.Completed:
+			stateRendered = stateStyle.Foreground(colorSuccess).Render(item.State.String())
+		case transfer.Failed:
+			stateRendered = stateStyle.Foreground(colorError).Bold(true).Render(item.State.String())
+		default:
+			stateRendered = stateStyle.Foreground(colorInactive).Render(item.State.String())
+		}
+
+		arrow := "↑"
+		if item.Direction == transfer.Download {
+			arrow = "↓"
+		}
+		name := item.Name
+		if item.IsDir {
+			name += "/"
+		}
+		if item.Resume {
+			name += " (resume)"
+		}
+		if item.RateLimit > 0 {
+			name += " @ " + ssh.FormatRateLimit(item.RateLimit)
+		}
+		size := ""
+		if !item.IsDir {
+			size = formatSize(item.Size)
+		}
+
+		line := lipgloss.JoinHorizontal(lipgloss.Bottom,
+			stateRendered, " ", arrow, " ", sizeStyle.Render(size), "  ", name)
+		if item.Err != nil {
+			line += "  " + lipgloss.NewStyle().Foreground(colorError).Render(item.Err.Error())
+		}
+		line = lipgloss.NewStyle().MaxWidth(width - 4).Render(line)
+		if i == s.queueSelectedIdx {
+			line = scpSelectedStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	return style.Render(lipgloss.JoinVertical(lipgloss.Left,
+		title,
+		"",
+		strings.Join(lines, "\n"),
+		"",
+		hint,
+	))
+}
@@ -0,0 +1,232 @@
+package components
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+// The recursive search walks the tree in the background, nearest
+// directories first, and streams its matches into the panel. Each keystroke
+// cancels the walk of the previous query and starts a new one.
+
+const (
+	searchMaxDepth   = 10               // Directories below the panel's searched at most
+	searchMaxResults = 1000             // Matches after which the walk stops
+	searchTimeout    = 30 * time.Second // Time after which the walk stops
+	searchBatch      = 200              // Matches delivered in one message at most
+)
+
+// SCPSearchMsg carries the matches a search walk found since the last one
+type SCPSearchMsg struct {
+	Gen     int
+	Files   []ssh.FileInfo
+	Done    bool
+	Stopped string // Limit that ended the walk early, if any
+}
+
+// searchRun is a search walk in progress
+type searchRun struct {
+	gen     int
+	local   bool // Searching the local panel
+	found   chan ssh.FileInfo
+	done    chan struct{} // Closed to cancel the walk
+	stopped string        // Set before found is closed
+}
+
+// updateSearchResults restarts the recursive fuzzy search from the current
+// directory for the new query
+func (s *SCPManager) updateSearchResults() tea.Cmd {
+	panel := s.getActivePanel()
+	s.cancelSearch()
+	s.recursiveResults = []ssh.FileInfo{}
+	s.searchMatches = []int{}
+	s.searchSelectedIdx = 0
+	s.searchStopped = ""
+
+	if s.inputBuffer == "" {
+		// Restore original files if search is cleared
+		if len(s.originalFiles) > 0 {
+			panel.Files = s.originalFiles
+			s.originalFiles = nil
+		}
+		return nil
+	}
+	if s.activePanel == 1 && s.sftpClient == nil {
+		return nil
+	}
+
+	// Store original files if not already stored
+	if len(s.originalFiles) == 0 {
+		s.originalFiles = make([]ssh.FileInfo, len(panel.Files))
+		copy(s.originalFiles, panel.Files)
+	}
+	panel.Files = []ssh.FileInfo{}
+	panel.SelectedIdx = 0
+	panel.ScrollOffset = 0
+
+	s.searchGen++
+	run := &searchRun{
+		gen:   s.searchGen,
+		local: s.activePanel == 0,
+		found: make(chan ssh.FileInfo, searchBatch),
+		done:  make(chan struct{}),
+	}
+	s.search = run
+	list := ssh.ListLocalFiles
+	if !run.local {
+		list = s.sftpClient.ListFiles
+	}
+	go run.walk(list, panel.Path, strings.ToLower(s.inputBuffer))
+	return listenForSearch(run)
+}
+
+// cancelSearch stops the search walk in progress, if any
+func (s *SCPManager) cancelSearch() {
+	if s.search != nil {
+		close(s.search.done)
+		s.search = nil
+	}
+}
+
+// walk lists the directories under root breadth first, sending the files
+// whose name matches query with their path relative to root
+func (r *searchRun) walk(list func(string) ([]ssh.FileInfo, error), root, query string) {
+	defer close(r.found)
+	deadline := time.Now().Add(searchTimeout)
+	count := 0
+	dirs := []string{""}
+	for len(dirs) > 0 {
+		relativePath := dirs[0]
+		dirs = dirs[1:]
+		if time.Now().After(deadline) {
+			r.stopped = "time limit"
+			return
+		}
+		select {
+		case <-r.done:
+			return
+		default:
+		}
+
+		files, err := list(filepath.Join(root, relativePath))
+		if err != nil {
+			// Silently skip directories that cannot be accessed during search
+			continue
+		}
+		for _, file := range files {
+			if file.Name == "." || file.Name == ".." {
+				continue
+			}
+			fullRelPath := filepath.Join(relativePath, file.Name)
+			if fuzzyMatch(query, strings.ToLower(file.Name)) {
+				// Keep the relative path in the name for display
+				file.Name = fullRelPath
+				select {
+				case r.found <- file:
+				case <-r.done:
+					return
+				}
+				if count++; count == searchMaxResults {
+					r.stopped = "result limit"
+					return
+				}
+			}
+			if file.IsDir && strings.Count(fullRelPath, string(filepath.Separator)) < searchMaxDepth-1 {
+				dirs = append(dirs, fullRelPath)
+			}
+		}
+	}
+}
+
+// listenForSearch waits for the next matches of a search walk, taking
+// whatever else is ready along with the first
+func listenForSearch(run *searchRun) tea.Cmd {
+	return func() tea.Msg {
+		msg := SCPSearchMsg{Gen: run.gen}
+		for len(msg.Files) < searchBatch {
+			var file ssh.FileInfo
+			var ok bool
+			if len(msg.Files) == 0 {
+				file, ok = <-run.found
+			} else {
+				select {
+				case file, ok = <-run.found:
+				default:
+					return msg
+				}
+			}
+			if !ok {
+				msg.Done = true
+				msg.Stopped = run.stopped
+				return msg
+			}
+			msg.Files = append(msg.Files, file)
+		}
+		return msg
+	}
+}
+
+// handleSearchResults adds the matches of the current search to the panel
+// and waits for more
+func (s *SCPManager) handleSearchResults(msg SCPSearchMsg) tea.Cmd {
+	if s.search == nil || msg.Gen != s.search.gen {
+		return nil
+	}
+	panel := &s.remotePanel
+	if s.search.local {
+		panel = &s.localPanel
+	}
+	for _, file := range msg.Files {
+		s.searchMatches = append(s.searchMatches, len(s.recursiveResults))
+		s.recursiveResults = append(s.recursiveResults, file)
+	}
+	panel.Files = s.recursiveResults
+	if !msg.Done {
+		return listenForSearch(s.search)
+	}
+	s.search = nil
+	s.searchStopped = msg.Stopped
+	if s.inputMode != ModeNormal {
+		return nil
+	}
+	s.status = s.searchSummary()
+	if len(s.recursiveResults) == 0 && len(s.originalFiles) > 0 {
+		s.status = "No matches found"
+		panel.Files = s.originalFiles
+		s.originalFiles = nil
+	}
+	return nil
+}
+
+// searchBadge counts the matches of the search for the prompt
+func (s *SCPManager) searchBadge() string {
+	switch {
+	case s.search != nil:
+		return fmt.Sprintf(" [%d found, searching...]", len(s.searchMatches))
+	case len(s.searchMatches) == 0:
+		if s.inputBuffer != "" {
+			return " [no matches]"
+		}
+		return ""
+	case s.searchStopped != "":
+		return fmt.Sprintf(" [%d/%d matches, stopped at the %s]", s.searchSelectedIdx+1, len(s.searchMatches), s.searchStopped)
+	}
+	return fmt.Sprintf(" [%d/%d matches]", s.searchSelectedIdx+1, len(s.searchMatches))
+}
+
+// searchSummary describes the results kept once the search is closed
+func (s *SCPManager) searchSummary() string {
+	switch {
+	case s.search != nil:
+		return fmt.Sprintf("Searching... %d matches so far", len(s.recursiveResults))
+	case s.searchStopped != "":
+		return fmt.Sprintf("Found %d matches, stopped at the %s", len(s.recursiveResults), s.searchStopped)
+	}
+	return fmt.Sprintf("Found %d matches", len(s.recursiveResults))
+}
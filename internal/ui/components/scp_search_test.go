@@ -0,0 +1,58 @@
+package components
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+func TestSCPManagerSearch(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"src/app/main.go", "src/app/main_test.go", "docs/manual.md", "README.md"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewSCPManager(config.SSHConnection{Name: "web"})
+	s.loading = false
+	s.localPanel.Path = dir
+	key := func(k string) tea.Cmd {
+		_, cmd := s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		return cmd
+	}
+
+	key("/")
+	stale := key("m")
+	if stale == nil {
+		t.Fatal("Expected typing to start a search")
+	}
+	cmd := key("a")
+	if _, next := s.Update(stale()); next != nil {
+		t.Error("Expected the results of the previous query to be dropped")
+	}
+	for cmd != nil {
+		_, cmd = s.Update(cmd())
+	}
+
+	// "ma" matches main.go, main_test.go and manual.md, nearest first
+	got := panelNames(&s.localPanel)
+	if len(got) != 3 || got[0] != filepath.Join("docs", "manual.md") {
+		t.Fatalf("Expected 3 matches, nearest first, got %v", got)
+	}
+	if badge := s.searchBadge(); badge != " [1/3 matches]" {
+		t.Errorf("Expected a match count badge, got %q", badge)
+	}
+
+	s.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if s.inputMode != ModeNormal || s.status != "Found 3 matches" || len(s.localPanel.Files) != 3 {
+		t.Errorf("Expected the matches to be kept, got %q %v", s.status, panelNames(&s.localPanel))
+	}
+}
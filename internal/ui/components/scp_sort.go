@@ -0,0 +1,148 @@
+package components
+
+import (
+	"cmp"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+// Each panel can hide dotfiles and be ordered on its own; the last choice
+// becomes the default in the settings.
+
+// newPanel creates a panel at path listed as the settings say
+func newPanel(path string) Panel {
+	settings := config.CurrentSettings()
+	return Panel{
+		Path:       path,
+		Files:      []ssh.FileInfo{},
+		ShowHidden: settings.ShowHiddenFiles,
+		Sort:       settings.FileSort,
+		DirsFirst:  settings.DirsFirst,
+	}
+}
+
+// setFiles lists files, all the files of the panel's directory
+func (p *Panel) setFiles(files []ssh.FileInfo) {
+	p.all = files
+	p.arrange()
+}
+
+// arrange filters and orders the files of the directory for the listing
+func (p *Panel) arrange() {
+	files := make([]ssh.FileInfo, 0, len(p.all))
+	for _, file := range p.all {
+		if p.ShowHidden || !strings.HasPrefix(file.Name, ".") {
+			files = append(files, file)
+		}
+	}
+	sortFiles(files, p.Sort, p.DirsFirst)
+	p.Files = files
+}
+
+// rearrange lists the files again after the options changed, keeping the
+// highlighted file when it is still listed
+func (p *Panel) rearrange() {
+	selected := ""
+	if p.SelectedIdx >= 0 && p.SelectedIdx < len(p.Files) {
+		selected = p.Files[p.SelectedIdx].Name
+	}
+	p.arrange()
+	p.SelectedIdx = max(0, slices.IndexFunc(p.Files, func(f ssh.FileInfo) bool { return f.Name == selected }))
+}
+
+// listingLabel describes how the listing differs from plain name order
+// with everything shown, e.g. "by size, dotfiles hidden"
+func (p *Panel) listingLabel() string {
+	var parts []string
+	if p.Sort != "" && p.Sort != "name" {
+		parts = append(parts, "by "+p.Sort)
+	}
+	if !p.DirsFirst {
+		parts = append(parts, "dirs mixed")
+	}
+	if !p.ShowHidden {
+		parts = append(parts, "dotfiles hidden")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sortFiles orders files by name, largest first, newest first or by
+// extension, ties broken by name
+func sortFiles(files []ssh.FileInfo, by string, dirsFirst bool) {
+	slices.SortStableFunc(files, func(a, b ssh.FileInfo) int {
+		if dirsFirst && a.IsDir != b.IsDir {
+			if a.IsDir {
+				return -1
+			}
+			return 1
+		}
+		var c int
+		switch by {
+		case "size":
+			c = cmp.Compare(b.Size, a.Size)
+		case "mtime":
+			c = b.ModTime.Compare(a.ModTime)
+		case "extension":
+			c = cmp.Compare(strings.ToLower(filepath.Ext(a.Name)), strings.ToLower(filepath.Ext(b.Name)))
+		}
+		if c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+}
+
+// toggleHidden shows or hides the dotfiles of the active panel
+func (s *SCPManager) toggleHidden() {
+	panel := s.getActivePanel()
+	panel.ShowHidden = !panel.ShowHidden
+	panel.rearrange()
+	s.status = "Dotfiles hidden"
+	if panel.ShowHidden {
+		s.status = "Dotfiles shown"
+	}
+	s.saveListingDefaults(panel)
+}
+
+// cycleSort orders the active panel by the next of config.FileSorts
+func (s *SCPManager) cycleSort() {
+	panel := s.getActivePanel()
+	i := slices.Index(config.FileSorts, panel.Sort)
+	panel.Sort = config.FileSorts[(i+1)%len(config.FileSorts)]
+	panel.rearrange()
+	s.status = "Sorted by " + panel.Sort
+	s.saveListingDefaults(panel)
+}
+
+// toggleDirsFirst lists directories before files in the active panel, or
+// among them
+func (s *SCPManager) toggleDirsFirst() {
+	panel := s.getActivePanel()
+	panel.DirsFirst = !panel.DirsFirst
+	panel.rearrange()
+	s.status = "Directories mixed with files"
+	if panel.DirsFirst {
+		s.status = "Directories first"
+	}
+	s.saveListingDefaults(panel)
+}
+
+// saveListingDefaults makes the options of panel the default of the
+// panels opened from now on
+func (s *SCPManager) saveListingDefaults(panel *Panel) {
+	settings := config.CurrentSettings()
+	settings.ShowHiddenFiles = panel.ShowHidden
+	settings.FileSort = panel.Sort
+	settings.DirsFirst = panel.DirsFirst
+	config.SetCurrentSettings(settings)
+	if settings.Path == "" {
+		return
+	}
+	if err := settings.Save(); err != nil {
+		s.error = "Failed to save the file list settings: " + err.Error()
+	}
+}
@@ -0,0 +1,64 @@
+package components
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+func panelNames(p *Panel) []string {
+	var names []string
+	for _, f := range p.Files {
+		names = append(names, f.Name)
+	}
+	return names
+}
+
+func TestSCPManagerListing(t *testing.T) {
+	defer config.SetCurrentSettings(config.CurrentSettings())
+	config.SetCurrentSettings(config.DefaultSettings())
+
+	now := time.Now()
+	s := NewSCPManager(config.SSHConnection{Name: "web"})
+	s.loading = false
+	s.Update(SCPListFilesMsg{IsLocal: true, Path: "/srv", Files: []ssh.FileInfo{
+		{Name: ".env", Size: 10, ModTime: now.Add(-time.Hour)},
+		{Name: "app.tar.gz", Size: 5000, ModTime: now.Add(-2 * time.Hour)},
+		{Name: "logs", IsDir: true, ModTime: now.Add(-3 * time.Hour)},
+		{Name: "README.md", Size: 300, ModTime: now},
+	}})
+	panel := &s.localPanel
+	if got := panelNames(panel); len(got) != 4 || got[0] != "logs" || got[1] != ".env" {
+		t.Fatalf("Expected directories first, then files by name, got %v", got)
+	}
+
+	key := func(k string) { s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}) }
+	panel.SelectedIdx = 3 // app.tar.gz
+	key(".")
+	if got := panelNames(panel); len(got) != 3 || panel.Files[panel.SelectedIdx].Name != "app.tar.gz" {
+		t.Errorf("Expected dotfiles to be hidden with the selection kept, got %v", got)
+	}
+	key("o")
+	if got := panelNames(panel); panel.Sort != "size" || got[1] != "app.tar.gz" || got[2] != "README.md" {
+		t.Errorf("Expected files largest first, got %v", got)
+	}
+	key("o")
+	key("O")
+	if got := panelNames(panel); panel.Sort != "mtime" || got[0] != "README.md" || got[2] != "logs" {
+		t.Errorf("Expected newest first with directories among files, got %v", got)
+	}
+	if s.remotePanel.Sort != "name" {
+		t.Error("Expected the other panel to keep its order")
+	}
+
+	settings := config.CurrentSettings()
+	if settings.ShowHiddenFiles || settings.FileSort != "mtime" || settings.DirsFirst {
+		t.Errorf("Expected the choices to become the defaults, got %+v", settings)
+	}
+	if p := newPanel("/"); p.Sort != "mtime" || p.ShowHidden {
+		t.Errorf("Expected new panels to use the defaults, got %+v", p)
+	}
+}
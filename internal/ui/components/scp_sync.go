@@ -0,0 +1,187 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/transfer"
+)
+
+// S syncs the directories open in the two panels, like a basic rsync: both
+// trees are scanned in the background (esc stops it), then the plan of
+// uploads, downloads and deletions is shown to confirm. Copies go through
+// the transfer queue; deletions go to the trash when there is one.
+
+// SCPSyncScanMsg carries the trees scanned for a sync
+type SCPSyncScanMsg struct {
+	Gen    int
+	Local  map[string]transfer.SyncEntry
+	Remote map[string]transfer.SyncEntry
+	Err    error
+}
+
+// syncScan is a scan of both trees in progress
+type syncScan struct {
+	gen        int
+	localRoot  string
+	remoteRoot string
+	opts       transfer.SyncOptions
+	checksum   bool
+	done       chan struct{} // Closed to cancel the scan
+}
+
+// startSync scans the directories of both panels for a sync with opts,
+// comparing the files of the same size by checksum when checksum is set
+func (s *SCPManager) startSync(opts transfer.SyncOptions, checksum bool) tea.Cmd {
+	sftpClient := s.sftpClient
+	if sftpClient == nil || s.queue == nil {
+		s.error = "Not connected to remote server"
+		return nil
+	}
+	if opts.Mode != transfer.MirrorDown && s.refuseRemoteChange() {
+		return nil
+	}
+	s.cancelSync()
+	s.syncGen++
+	scan := &syncScan{
+		gen:        s.syncGen,
+		localRoot:  s.localPanel.Path,
+		remoteRoot: s.remotePanel.Path,
+		opts:       opts,
+		checksum:   checksum,
+		done:       make(chan struct{}),
+	}
+	s.syncScan = scan
+	s.status = fmt.Sprintf("Comparing %s with %s... (esc to stop)", scan.localRoot, scan.remoteRoot)
+
+	return func() tea.Msg {
+		msg := SCPSyncScanMsg{Gen: scan.gen}
+		localTree, err := ssh.LocalTree(scan.localRoot, scan.done)
+		if err != nil {
+			msg.Err = err
+			return msg
+		}
+		remoteTree, err := sftpClient.Tree(scan.remoteRoot, scan.done)
+		if err != nil {
+			msg.Err = err
+			return msg
+		}
+		msg.Local, msg.Remote = syncEntries(localTree), syncEntries(remoteTree)
+		if !checksum {
+			return msg
+		}
+
+		var same []string
+		for p, l := range msg.Local {
+			if r, ok := msg.Remote[p]; ok && !l.IsDir && !r.IsDir && l.Size == r.Size {
+				same = append(same, p)
+			}
+		}
+		localSums, err := ssh.LocalChecksums(scan.localRoot, same, scan.done)
+		if err != nil {
+			msg.Err = err
+			return msg
+		}
+		remoteSums, err := sftpClient.Checksums(scan.remoteRoot, same, scan.done)
+		if err != nil {
+			msg.Err = err
+			return msg
+		}
+		for _, p := range same {
+			l, r := msg.Local[p], msg.Remote[p]
+			l.Sum, r.Sum = localSums[p], remoteSums[p]
+			msg.Local[p], msg.Remote[p] = l, r
+		}
+		return msg
+	}
+}
+
+func syncEntries(tree map[string]ssh.FileInfo) map[string]transfer.SyncEntry {
+	entries := make(map[string]transfer.SyncEntry, len(tree))
+	for p, file := range tree {
+		entries[p] = transfer.SyncEntry{Size: file.Size, ModTime: file.ModTime, IsDir: file.IsDir}
+	}
+	return entries
+}
+
+// cancelSync stops the scan in progress, if any
+func (s *SCPManager) cancelSync() {
+	if s.syncScan != nil {
+		close(s.syncScan.done)
+		s.syncScan = nil
+	}
+}
+
+// handleSyncScan shows the plan of the scanned trees
+func (s *SCPManager) handleSyncScan(msg SCPSyncScanMsg) {
+	scan := s.syncScan
+	if scan == nil || msg.Gen != scan.gen {
+		return
+	}
+	s.syncScan = nil
+	if msg.Err != nil {
+		if !errors.Is(msg.Err, ssh.ErrUsageCanceled) {
+			s.error = fmt.Sprintf("Sync scan failed: %s", msg.Err.Error())
+		}
+		return
+	}
+	remoteRoot := fmt.Sprintf("%s:%s", s.connection.Host, scan.remoteRoot)
+	s.syncPlan = NewSyncPlanView(scan.localRoot, remoteRoot, msg.Local, msg.Remote, scan.opts, scan.checksum)
+	s.syncPlan.SetSize(s.width, max(s.height-5, 0))
+	s.syncRoots = [2]string{scan.localRoot, scan.remoteRoot}
+	s.status = "Review the sync plan"
+}
+
+// executeSync queues the copies of a confirmed plan and deletes what it
+// deletes
+func (s *SCPManager) executeSync(plan transfer.SyncPlan) tea.Cmd {
+	if s.sftpClient == nil || s.queue == nil {
+		s.error = "Not connected to remote server"
+		return nil
+	}
+	localRoot, remoteRoot := s.syncRoots[0], s.syncRoots[1]
+	var items []transfer.Item
+	var deletes []ssh.FileInfo
+	deleteLocal := false
+	for _, a := range plan.Actions {
+		localPath := filepath.Join(localRoot, filepath.FromSlash(a.Path))
+		remotePath := filepath.ToSlash(filepath.Join(remoteRoot, a.Path))
+		if a.Delete {
+			deleteLocal = a.Direction == transfer.Download
+			deletes = append(deletes, ssh.FileInfo{Name: filepath.FromSlash(a.Path), IsDir: a.IsDir})
+			continue
+		}
+		item := transfer.Item{
+			Name:      a.Path,
+			Source:    localPath,
+			Target:    remotePath,
+			Direction: a.Direction,
+			IsDir:     a.IsDir,
+			Size:      a.Size,
+			RateLimit: s.rateLimit,
+			Sync:      true,
+		}
+		if a.Direction == transfer.Download {
+			item.Source, item.Target = remotePath, localPath
+		}
+		items = append(items, item)
+	}
+
+	s.queue.Add(items...)
+	s.status = fmt.Sprintf("Queued %d sync transfers (q: view queue)", len(items))
+	if len(deletes) == 0 {
+		return nil
+	}
+	// Mirrors only delete on their target, so all deletions are on one side
+	dir := remoteRoot
+	permanent := config.CurrentSettings().RemoteTrashDir == ""
+	if deleteLocal {
+		dir, permanent = localRoot, false
+	}
+	return s.removeFiles(deleteLocal, dir, deletes, permanent)
+}
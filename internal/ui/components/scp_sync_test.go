@@ -0,0 +1,89 @@
+package components
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/transfer"
+)
+
+func TestSCPManagerSyncPlan(t *testing.T) {
+	s := NewSCPManager(config.SSHConnection{Name: "web", Host: "web"})
+	s.loading = false
+	s.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	key := func(k string) {
+		s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+
+	key("S")
+	if !strings.Contains(s.error, "Not connected") || s.syncScan != nil {
+		t.Errorf("Expected a sync to need a connection, got %q", s.error)
+	}
+
+	// Connected, with a queue that copies nothing
+	s.sftpClient = &ssh.SFTPClient{}
+	s.queue = transfer.New(1, func(transfer.Item) error { return nil })
+	defer s.queue.Close()
+	s.localPanel.Path, s.remotePanel.Path = "/home/me/site", "/var/www"
+	s.error = ""
+	key("S")
+	if s.syncScan == nil || s.syncScan.opts.Mode != transfer.MirrorUp {
+		t.Fatal("Expected S on the local panel to scan for a mirror to the server")
+	}
+
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.Update(SCPSyncScanMsg{Gen: s.syncGen - 1})
+	if s.syncPlan != nil {
+		t.Fatal("Expected a stale scan to be ignored")
+	}
+	s.Update(SCPSyncScanMsg{
+		Gen: s.syncGen,
+		Local: map[string]transfer.SyncEntry{
+			"index.html":  {Size: 10, ModTime: old.Add(time.Hour)},
+			"css":         {IsDir: true},
+			"css/app.css": {Size: 20, ModTime: old},
+		},
+		Remote: map[string]transfer.SyncEntry{
+			"index.html": {Size: 10, ModTime: old},
+			"old.html":   {Size: 5, ModTime: old},
+		},
+	})
+	if s.syncPlan == nil || s.syncScan != nil || !s.IsTyping() {
+		t.Fatal("Expected the sync plan to open once scanned")
+	}
+	view := s.View()
+	for _, want := range []string{"local → remote", "2 uploads, 0 downloads, 0 deletions", "css/app.css", "web:/var/www"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected the plan to show %q", want)
+		}
+	}
+
+	key("x")
+	if !strings.Contains(s.View(), "1 deletions") {
+		t.Error("Expected x to add the deletion of old.html")
+	}
+	key("x")
+	key("m")
+	if !strings.Contains(s.View(), "remote → local") {
+		t.Error("Expected m to switch to a mirror from the server")
+	}
+	key("m")
+	key("m")
+
+	s.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if s.syncPlan != nil {
+		t.Fatal("Expected enter to run the plan")
+	}
+	items := s.queue.Items()
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 queued uploads, got %d", len(items))
+	}
+	css := items[0]
+	if !css.Sync || css.Direction != transfer.Upload || css.Source != "/home/me/site/css/app.css" || css.Target != "/var/www/css/app.css" {
+		t.Errorf("Unexpected sync item %+v", css)
+	}
+}
@@ -0,0 +1,292 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/trash"
+)
+
+// Deleted files go to the trash unless D is pressed in the confirmation:
+// local ones to the trash of the operating system and remote ones to the
+// remote_trash directory. The last files trashed can be put back with z for
+// a while. Before remote directories are deleted, the dialog counts the
+// files they hold and their size, so two look-alike entries aren't mixed
+// up.
+
+// deleteCountLimit is how many entries are counted in the remote
+// directories to delete before giving up with "more than"
+const deleteCountLimit = 10000
+
+// undoWindow is how long z puts back the files last moved to the trash
+const undoWindow = 30 * time.Second
+
+// SCPTrashMsg reports the files moved to the trash, before the error that
+// stopped the rest if any
+type SCPTrashMsg struct {
+	Local bool
+	Items []trash.Item
+	Err   error
+}
+
+// SCPDeleteCountMsg carries what the remote files the delete dialog asks
+// about hold
+type SCPDeleteCountMsg struct {
+	Gen   int
+	Usage ssh.DirUsage
+	Err   error
+}
+
+// confirmDelete asks before deleting the selected files of the active
+// panel, counting what remote directories hold first
+func (s *SCPManager) confirmDelete() tea.Cmd {
+	files := s.selectedFiles()
+	if len(files) == 0 {
+		return nil
+	}
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = file.Name
+		if file.IsDir {
+			names[i] += "/"
+		}
+	}
+	location := "this computer"
+	canTrash := true
+	if s.activePanel == 1 {
+		location = s.connection.Host
+		canTrash = config.CurrentSettings().RemoteTrashDir != ""
+	}
+	s.deleteFiles = files
+	s.deleteConfirm = NewFileDeleteConfirmation(names, location, canTrash)
+	s.deleteConfirm.SetSize(s.width, max(s.height-5, 0))
+	if s.activePanel == 1 && s.sftpClient != nil && slices.ContainsFunc(files, func(f ssh.FileInfo) bool { return f.IsDir }) {
+		return s.countDeleteContents(files)
+	}
+	return nil
+}
+
+// countDeleteContents walks the remote directories among files for the
+// delete dialog, up to deleteCountLimit entries in all
+func (s *SCPManager) countDeleteContents(files []ssh.FileInfo) tea.Cmd {
+	s.stopDeleteCount()
+	s.deleteGen++
+	gen, dir, sftpClient := s.deleteGen, s.remotePanel.Path, s.sftpClient
+	done := make(chan struct{})
+	s.deleteCounting = done
+	s.deleteConfirm.CountContents()
+
+	return func() tea.Msg {
+		msg := SCPDeleteCountMsg{Gen: gen}
+		total := &msg.Usage
+		for _, file := range files {
+			if !file.IsDir {
+				total.Files++
+				total.Bytes += file.Size
+				continue
+			}
+			total.Dirs++
+			usage, err := sftpClient.CountTree(filepath.ToSlash(filepath.Join(dir, file.Name)), deleteCountLimit-total.Files-total.Dirs, done, func(ssh.DirUsage) {})
+			if err != nil {
+				msg.Err = err
+				return msg
+			}
+			total.Files += usage.Files
+			total.Dirs += usage.Dirs
+			total.Bytes += usage.Bytes
+			if usage.Capped || total.Files+total.Dirs >= deleteCountLimit {
+				total.Capped = true
+				return msg
+			}
+		}
+		return msg
+	}
+}
+
+// handleDeleteCount shows what the files to delete hold in the dialog
+func (s *SCPManager) handleDeleteCount(msg SCPDeleteCountMsg) {
+	if s.deleteConfirm == nil || msg.Gen != s.deleteGen {
+		return
+	}
+	s.deleteCounting = nil
+	if msg.Err != nil {
+		if errors.Is(msg.Err, ssh.ErrUsageCanceled) {
+			return
+		}
+		s.deleteConfirm.SetContents("Could not count the contents: " + msg.Err.Error())
+		return
+	}
+	s.deleteConfirm.SetContents(deleteContents(msg.Usage))
+}
+
+// stopDeleteCount stops counting the files to delete when the dialog closes
+func (s *SCPManager) stopDeleteCount() {
+	if s.deleteCounting != nil {
+		close(s.deleteCounting)
+		s.deleteCounting = nil
+	}
+}
+
+// deleteContents describes what is about to be deleted, e.g. "2.1 GB in
+// 1204 files and 37 directories"
+func deleteContents(usage ssh.DirUsage) string {
+	if usage.Capped {
+		return fmt.Sprintf("More than %d files and directories, at least %s", deleteCountLimit, formatSize(usage.Bytes))
+	}
+	return usageLabel(usage)
+}
+
+// deleteSelection deletes files from the active panel, or moves them to the
+// trash unless permanent
+func (s *SCPManager) deleteSelection(files []ssh.FileInfo, permanent bool) tea.Cmd {
+	panel := s.getActivePanel()
+	panel.Marked = nil
+	return s.removeFiles(s.activePanel == 0, panel.Path, files, permanent)
+}
+
+// removeFiles deletes files from the local or remote directory dir, or
+// moves them to the trash unless permanent. Their names can hold
+// subdirectories.
+func (s *SCPManager) removeFiles(local bool, dir string, files []ssh.FileInfo, permanent bool) tea.Cmd {
+	sftpClient := s.sftpClient
+	if !local && sftpClient == nil {
+		s.error = "Not connected to remote server"
+		return nil
+	}
+
+	s.operationInProgress = true
+	s.trashed = nil
+	verb := "Moving to the trash"
+	if permanent {
+		verb = "Deleting"
+	}
+	s.status = fmt.Sprintf("%s %s...", verb, filesLabel(files))
+
+	if permanent {
+		return func() tea.Msg {
+			for _, file := range files {
+				var err error
+				if local {
+					err = ssh.DeleteLocalFile(filepath.Join(dir, file.Name), file.IsDir)
+				} else {
+					err = sftpClient.DeleteFile(filepath.Join(dir, file.Name), file.IsDir)
+				}
+				if err != nil {
+					return SCPOperationMsg{Operation: "Delete", Success: false, Err: err}
+				}
+			}
+			return SCPOperationMsg{Operation: "Delete", Success: true}
+		}
+	}
+
+	trashDir := config.CurrentSettings().RemoteTrashDir
+	return func() tea.Msg {
+		msg := SCPTrashMsg{Local: local}
+		if !local {
+			home, err := sftpClient.GetWorkingDir()
+			if err != nil {
+				msg.Err = err
+				return msg
+			}
+			trashDir = remoteStartDir(home, trashDir)
+		}
+		for _, file := range files {
+			path := filepath.Join(dir, file.Name)
+			var item trash.Item
+			if local {
+				item, msg.Err = trash.Move(path)
+			} else {
+				item.Path = filepath.ToSlash(path)
+				item.Trashed, msg.Err = sftpClient.MoveToTrash(item.Path, trashDir)
+			}
+			if msg.Err != nil {
+				return msg
+			}
+			msg.Items = append(msg.Items, item)
+		}
+		return msg
+	}
+}
+
+// handleTrashed reports the files moved to the trash and keeps them for z
+func (s *SCPManager) handleTrashed(msg SCPTrashMsg) tea.Cmd {
+	s.operationInProgress = false
+	if msg.Err != nil {
+		s.error = fmt.Sprintf("Move to the trash failed: %s", msg.Err.Error())
+	}
+	if len(msg.Items) > 0 {
+		what := fmt.Sprintf("'%s'", filepath.Base(msg.Items[0].Path))
+		if len(msg.Items) > 1 {
+			what = fmt.Sprintf("%d files", len(msg.Items))
+		}
+		if msg.Local && msg.Items[0].Trashed == "" {
+			s.status = fmt.Sprintf("Moved %s to the %s", what, trashName())
+		} else {
+			s.trashed = msg.Items
+			s.trashedLocal = msg.Local
+			s.trashedUntil = time.Now().Add(undoWindow)
+			s.status = fmt.Sprintf("Moved %s to the trash, z to undo", what)
+		}
+	}
+	return tea.Batch(s.listLocalFiles(), s.listRemoteFiles())
+}
+
+// undoTrash puts back the files last moved to the trash, if it was less
+// than undoWindow ago
+func (s *SCPManager) undoTrash() tea.Cmd {
+	if len(s.trashed) == 0 || time.Now().After(s.trashedUntil) {
+		s.error = "Nothing to undo"
+		return nil
+	}
+	items, local := s.trashed, s.trashedLocal
+	sftpClient := s.sftpClient
+	if !local && sftpClient == nil {
+		s.error = "Not connected to remote server"
+		return nil
+	}
+	s.trashed = nil
+	s.operationInProgress = true
+	s.status = "Restoring from the trash..."
+
+	return func() tea.Msg {
+		for _, item := range items {
+			var err error
+			if local {
+				err = trash.Restore(item)
+			} else {
+				err = sftpClient.RenameFile(item.Trashed, item.Path)
+			}
+			if errors.Is(err, trash.ErrNoRestore) {
+				err = fmt.Errorf("restore %s from the %s", filepath.Base(item.Path), trashName())
+			}
+			if err != nil {
+				return SCPOperationMsg{Operation: "Undo delete", Success: false, Err: err}
+			}
+		}
+		return SCPOperationMsg{Operation: "Undo delete", Success: true}
+	}
+}
+
+// filesLabel names a single file or counts several
+func filesLabel(files []ssh.FileInfo) string {
+	if len(files) == 1 {
+		return files[0].Name
+	}
+	return fmt.Sprintf("%d files", len(files))
+}
+
+// trashName is what the operating system calls its trash
+func trashName() string {
+	if runtime.GOOS == "windows" {
+		return "Recycle Bin"
+	}
+	return "trash"
+}
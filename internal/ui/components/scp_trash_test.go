@@ -0,0 +1,116 @@
+//go:build !windows && !darwin
+
+package components
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+func TestSCPManagerTrash(t *testing.T) {
+	defer config.SetCurrentSettings(config.CurrentSettings())
+	config.SetCurrentSettings(config.DefaultSettings())
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(path, []byte("keep me"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewSCPManager(config.SSHConnection{Name: "web"})
+	s.loading = false
+	s.Update(SCPListFilesMsg{IsLocal: true, Path: dir, Files: []ssh.FileInfo{{Name: "notes.txt", Size: 7}}})
+	key := func(k string) tea.Cmd {
+		_, cmd := s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		return cmd
+	}
+
+	key("d")
+	if s.deleteConfirm == nil || !s.IsTyping() {
+		t.Fatal("Expected d to ask before deleting")
+	}
+	key("n")
+	if s.deleteConfirm != nil {
+		t.Fatal("Expected n to cancel the delete")
+	}
+
+	key("d")
+	cmd := key("y")
+	if cmd == nil {
+		t.Fatal("Expected y to move the file to the trash")
+	}
+	msg, ok := cmd().(SCPTrashMsg)
+	if !ok || msg.Err != nil || len(msg.Items) != 1 {
+		t.Fatalf("Expected the file in the trash, got %+v", msg)
+	}
+	s.Update(msg)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Expected the file to be gone, got %v", err)
+	}
+
+	cmd = key("z")
+	if cmd == nil {
+		t.Fatalf("Expected z to restore the file, error %q", s.error)
+	}
+	op, ok := cmd().(SCPOperationMsg)
+	if !ok || op.Err != nil {
+		t.Fatalf("Expected the file to be restored, got %+v", op)
+	}
+	s.Update(op)
+	if data, err := os.ReadFile(path); err != nil || string(data) != "keep me" {
+		t.Errorf("Expected the file back, got %q, %v", data, err)
+	}
+
+	key("d")
+	if cmd := key("D"); cmd == nil {
+		t.Fatal("Expected D to delete the file")
+	} else if op, ok := cmd().(SCPOperationMsg); !ok || op.Err != nil {
+		t.Fatalf("Expected the file to be deleted, got %+v", op)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected the file to be deleted, got %v", err)
+	}
+}
+
+func TestSCPManagerDeleteCount(t *testing.T) {
+	s := NewSCPManager(config.SSHConnection{Name: "web", Host: "web.example.com"})
+	s.loading = false
+	s.activePanel = 1
+	s.remotePanel.Path = "/srv"
+	s.remotePanel.setFiles([]ssh.FileInfo{{Name: "releases", IsDir: true}})
+	s.deleteFiles = s.selectedFiles()
+	s.deleteConfirm = NewFileDeleteConfirmation([]string{"releases/"}, "web.example.com", true)
+	// Not run: there is no server to walk
+	s.countDeleteContents(s.deleteFiles)
+	key := func(k string) {
+		s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+
+	key("y")
+	if s.deleteConfirm == nil || !s.deleteConfirm.IsCounting() {
+		t.Fatal("Expected the delete to wait for the contents to be counted")
+	}
+	s.Update(SCPDeleteCountMsg{Gen: s.deleteGen - 1, Usage: ssh.DirUsage{Files: 1}})
+	if !s.deleteConfirm.IsCounting() {
+		t.Fatal("Expected a stale count to be ignored")
+	}
+	s.Update(SCPDeleteCountMsg{Gen: s.deleteGen, Usage: ssh.DirUsage{Bytes: 3 << 20, Files: 1204, Dirs: 37}})
+	if view := s.deleteConfirm.View(); !strings.Contains(view, "3.0 MB in 1204 files") {
+		t.Errorf("Expected the contents in the dialog, got:\n%s", view)
+	}
+	key("n")
+	if s.deleteConfirm != nil || s.deleteCounting != nil {
+		t.Fatal("Expected n to cancel the delete")
+	}
+
+	if got := deleteContents(ssh.DirUsage{Bytes: 1 << 30, Files: 9000, Dirs: 1000, Capped: true}); got != "More than 10000 files and directories, at least 1.0 GB" {
+		t.Errorf("Capped contents = %q", got)
+	}
+}
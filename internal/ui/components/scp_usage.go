@@ -0,0 +1,229 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+// s measures the selected directories in the background, showing the
+// count so far in the status line and the result in the size column; esc
+// stops it. F shows the free space of the remote filesystem under the
+// panels.
+
+// SCPDirSizeMsg reports the progress or the result of measuring a
+// directory
+type SCPDirSizeMsg struct {
+	Gen   int
+	Local bool
+	Path  string
+	Usage ssh.DirUsage
+	Done  bool // Usage is the size of Path, or Err why there is none
+	Last  bool // Path is the last directory measured
+	Err   error
+}
+
+// SCPDiskSpaceMsg carries the free space of the remote filesystem holding
+// Path
+type SCPDiskSpaceMsg struct {
+	Path  string
+	Space ssh.DiskSpace
+	Err   error
+}
+
+// sizeRun is a directory size calculation in progress
+type sizeRun struct {
+	gen     int
+	updates chan SCPDirSizeMsg
+	done    chan struct{} // Closed to cancel the calculation
+}
+
+// measureSelection computes the size of the selected directories of the
+// active panel
+func (s *SCPManager) measureSelection() tea.Cmd {
+	panel := s.getActivePanel()
+	var dirs []string
+	for _, file := range s.selectedFiles() {
+		if file.IsDir {
+			dirs = append(dirs, filepath.Join(panel.Path, file.Name))
+		}
+	}
+	if len(dirs) == 0 {
+		s.error = "No directory selected"
+		return nil
+	}
+	local := s.activePanel == 0
+	sftpClient := s.sftpClient
+	if !local && sftpClient == nil {
+		s.error = "Not connected to remote server"
+		return nil
+	}
+
+	s.cancelSizes()
+	s.sizeGen++
+	run := &sizeRun{
+		gen:     s.sizeGen,
+		updates: make(chan SCPDirSizeMsg, 1),
+		done:    make(chan struct{}),
+	}
+	s.sizes = run
+	s.status = fmt.Sprintf("Calculating the size of %s... (esc to stop)", filepath.Base(dirs[0]))
+
+	go func() {
+		defer close(run.updates)
+		for i, dir := range dirs {
+			send := func(msg SCPDirSizeMsg) bool {
+				msg.Gen, msg.Local, msg.Path = run.gen, local, dir
+				select {
+				case run.updates <- msg:
+					return true
+				case <-run.done:
+					return false
+				}
+			}
+			progress := func(usage ssh.DirUsage) {
+				// Drop the report rather than wait while the last one is unread
+				select {
+				case run.updates <- SCPDirSizeMsg{Gen: run.gen, Local: local, Path: dir, Usage: usage}:
+				default:
+				}
+			}
+			var usage ssh.DirUsage
+			var err error
+			if local {
+				usage, err = ssh.LocalDirUsage(dir, run.done, progress)
+			} else {
+				usage, err = sftpClient.DirUsage(dir, run.done, progress)
+			}
+			if !send(SCPDirSizeMsg{Usage: usage, Done: true, Last: i == len(dirs)-1, Err: err}) || errors.Is(err, ssh.ErrUsageCanceled) {
+				return
+			}
+		}
+	}()
+	return listenForSizes(run)
+}
+
+// listenForSizes waits for the next report of a size calculation
+func listenForSizes(run *sizeRun) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-run.updates
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// cancelSizes stops the size calculation in progress, if any
+func (s *SCPManager) cancelSizes() {
+	if s.sizes != nil {
+		close(s.sizes.done)
+		s.sizes = nil
+	}
+}
+
+// handleDirSize shows the progress of the size calculation, and its
+// result in the size column of the directory
+func (s *SCPManager) handleDirSize(msg SCPDirSizeMsg) tea.Cmd {
+	if s.sizes == nil || msg.Gen != s.sizes.gen {
+		return nil
+	}
+	name := filepath.Base(msg.Path)
+	switch {
+	case !msg.Done:
+		s.status = fmt.Sprintf("Calculating the size of %s: %d files, %s so far... (esc to stop)",
+			name, msg.Usage.Files, formatSize(msg.Usage.Bytes))
+	case msg.Err != nil:
+		s.error = fmt.Sprintf("Size of %s failed: %s", name, msg.Err.Error())
+	default:
+		panel := &s.remotePanel
+		if msg.Local {
+			panel = &s.localPanel
+		}
+		if panel.DirSizes == nil {
+			panel.DirSizes = make(map[string]int64)
+		}
+		panel.DirSizes[msg.Path] = msg.Usage.Bytes
+		s.status = name + ": " + usageLabel(msg.Usage)
+	}
+	if msg.Last {
+		s.sizes = nil
+		return nil
+	}
+	return listenForSizes(s.sizes)
+}
+
+// usageLabel describes the size of a directory, e.g. "1.2 GB in 3412
+// files and 97 directories"
+func usageLabel(usage ssh.DirUsage) string {
+	if usage.DU {
+		return formatSize(usage.Bytes) + " on disk"
+	}
+	return fmt.Sprintf("%s in %d files and %d directories", formatSize(usage.Bytes), usage.Files, usage.Dirs)
+}
+
+// toggleDiskSpace shows or hides the free space of the remote filesystem
+func (s *SCPManager) toggleDiskSpace() tea.Cmd {
+	s.showDiskSpace = !s.showDiskSpace
+	s.diskSpace = nil
+	if !s.showDiskSpace {
+		return nil
+	}
+	return s.fetchDiskSpace()
+}
+
+// fetchDiskSpace asks the free space of the filesystem of the remote
+// panel's directory
+func (s *SCPManager) fetchDiskSpace() tea.Cmd {
+	sftpClient := s.sftpClient
+	if !s.showDiskSpace || sftpClient == nil {
+		return nil
+	}
+	dir := s.remotePanel.Path
+	return func() tea.Msg {
+		space, err := sftpClient.DiskSpace(dir)
+		return SCPDiskSpaceMsg{Path: dir, Space: space, Err: err}
+	}
+}
+
+// handleDiskSpace keeps the free space to show, unless the remote panel
+// moved meanwhile
+func (s *SCPManager) handleDiskSpace(msg SCPDiskSpaceMsg) {
+	if !s.showDiskSpace || msg.Path != s.remotePanel.Path {
+		return
+	}
+	s.diskSpace = &msg
+}
+
+// renderDiskSpace renders the free space line shown under the panels, e.g.
+// "Remote disk /var/www: 12.5 GB free of 50.0 GB  ██████░░ 75% used"
+func (s *SCPManager) renderDiskSpace() string {
+	line := lipgloss.NewStyle().Width(s.width)
+	style := lipgloss.NewStyle().Foreground(colorSubText)
+	switch {
+	case s.diskSpace == nil:
+		return line.Render(style.Render(" Remote disk: checking..."))
+	case s.diskSpace.Err != nil:
+		return line.Render(style.Render(" Remote disk: " + s.diskSpace.Err.Error()))
+	}
+	space := s.diskSpace.Space
+	used := 0
+	if space.Total > 0 {
+		used = int((space.Total - space.Free) * 100 / space.Total)
+	}
+	const barWidth = 20
+	filled := used * barWidth / 100
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", barWidth-filled)
+	barStyle := lipgloss.NewStyle().Foreground(colorSuccess)
+	if used >= 90 {
+		barStyle = barStyle.Foreground(colorError)
+	}
+	text := fmt.Sprintf(" Remote disk %s: %s free of %s  ", s.diskSpace.Path, formatSize(int64(space.Avail)), formatSize(int64(space.Total)))
+	return line.Render(style.Render(text) + barStyle.Render(bar) + style.Render(fmt.Sprintf(" %d%% used", used)))
+}
@@ -0,0 +1,53 @@
+package components
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+func TestSCPManagerDirSize(t *testing.T) {
+	dir := t.TempDir()
+	for name, size := range map[string]int{"logs/a.log": 1000, "logs/old/b.log": 2000, "notes.txt": 10} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := NewSCPManager(config.SSHConnection{Name: "web"})
+	s.loading = false
+	s.Update(tea.WindowSizeMsg{Width: 160, Height: 30})
+	s.Update(SCPListFilesMsg{IsLocal: true, Path: dir, Files: []ssh.FileInfo{
+		{Name: "logs", IsDir: true, Size: 4096},
+		{Name: "notes.txt", Size: 10},
+	}})
+
+	s.localPanel.SelectedIdx = 1
+	if _, cmd := s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")}); cmd != nil || s.error == "" {
+		t.Fatal("Expected s on a file to be refused")
+	}
+
+	s.localPanel.SelectedIdx = 0
+	_, cmd := s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	for cmd != nil {
+		_, cmd = s.Update(cmd())
+	}
+	if got := s.localPanel.DirSizes[filepath.Join(dir, "logs")]; got != 3000 {
+		t.Errorf("Expected logs to add up to 3000 bytes, got %d", got)
+	}
+	if !strings.Contains(s.status, "in 2 files and 1 directories") || s.sizes != nil {
+		t.Errorf("Expected the result in the status, got %q", s.status)
+	}
+	if view := s.View(); !strings.Contains(view, formatSize(3000)) {
+		t.Error("Expected the size of logs in its size column")
+	}
+}
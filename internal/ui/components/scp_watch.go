@@ -0,0 +1,195 @@
+package components
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/transfer"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/watch"
+)
+
+// W watches the directory of the local panel and uploads the files that
+// change in it to the directory of the remote panel, for deploying while
+// developing. The activity log under the panels shows what was uploaded;
+// W again stops. Files removed locally are kept on the server.
+
+const (
+	// watchLogLines is how many lines of the activity log are shown
+	watchLogLines = 5
+	// watchLogKeep is how many lines of the activity log are kept
+	watchLogKeep = 100
+)
+
+// SCPWatchMsg carries the changes found in the watched directory
+type SCPWatchMsg struct {
+	Watcher *watch.Watcher
+	Changes []watch.Change
+	Closed  bool // The directory can no longer be read
+}
+
+// watchRun is a directory being watched
+type watchRun struct {
+	watcher    *watch.Watcher
+	remoteRoot string
+	queued     map[int]string // Uploads in the queue, by ID, to log once done
+}
+
+// toggleWatch starts watching the directory of the local panel, or stops
+func (s *SCPManager) toggleWatch() tea.Cmd {
+	if s.watching != nil {
+		s.stopWatch()
+		s.status = "Stopped watching"
+		return nil
+	}
+	if s.sftpClient == nil || s.queue == nil {
+		s.error = "Not connected to remote server"
+		return nil
+	}
+	if s.refuseRemoteChange() {
+		return nil
+	}
+	ignore := watch.ParsePatterns(config.CurrentSettings().WatchIgnore)
+	w, err := watch.New(s.localPanel.Path, ignore, watch.DefaultInterval)
+	if err != nil {
+		s.error = fmt.Sprintf("Cannot watch %s: %s", s.localPanel.Path, err.Error())
+		return nil
+	}
+	s.watching = &watchRun{
+		watcher:    w,
+		remoteRoot: s.remotePanel.Path,
+		queued:     make(map[int]string),
+	}
+	s.logWatch("Watching %s → %s:%s", w.Root(), s.connection.Host, s.remotePanel.Path)
+	s.status = "Watching for changes to upload (W to stop)"
+	return listenForWatch(w)
+}
+
+// stopWatch stops watching, if a directory is watched
+func (s *SCPManager) stopWatch() {
+	if s.watching != nil {
+		s.watching.watcher.Close()
+		s.watching = nil
+	}
+}
+
+// listenForWatch waits for the next changes found by w
+func listenForWatch(w *watch.Watcher) tea.Cmd {
+	return func() tea.Msg {
+		changes, ok := <-w.Changes()
+		return SCPWatchMsg{Watcher: w, Changes: changes, Closed: !ok}
+	}
+}
+
+// handleWatch queues the upload of the changed files
+func (s *SCPManager) handleWatch(msg SCPWatchMsg) tea.Cmd {
+	if f := s.openedFileOf(msg.Watcher); f != nil {
+		return s.handleOpenedChange(f, msg)
+	}
+	run := s.watching
+	if run == nil || msg.Watcher != run.watcher {
+		return nil
+	}
+	if msg.Closed {
+		s.watching = nil
+		s.error = fmt.Sprintf("Stopped watching: %s can no longer be read", msg.Watcher.Root())
+		return nil
+	}
+
+	var items []transfer.Item
+	for _, change := range msg.Changes {
+		if change.Removed {
+			s.logWatch("%s removed locally, kept on the server", change.Path)
+			continue
+		}
+		if run.isQueued(change.Path) {
+			continue
+		}
+		localPath := filepath.Join(run.watcher.Root(), filepath.FromSlash(change.Path))
+		var size int64
+		if info, err := os.Stat(localPath); err == nil {
+			size = info.Size()
+		}
+		items = append(items, transfer.Item{
+			Name:      change.Path,
+			Source:    localPath,
+			Target:    filepath.ToSlash(filepath.Join(run.remoteRoot, change.Path)),
+			Direction: transfer.Upload,
+			Size:      size,
+			RateLimit: s.rateLimit,
+			Sync:      true,
+		})
+	}
+	if s.queue != nil && len(items) > 0 {
+		for i, id := range s.queue.Add(items...) {
+			run.queued[id] = items[i].Name
+		}
+	}
+	return listenForWatch(run.watcher)
+}
+
+// isQueued reports whether the upload of path is waiting in the queue
+func (r *watchRun) isQueued(path string) bool {
+	for _, queued := range r.queued {
+		if queued == path {
+			return true
+		}
+	}
+	return false
+}
+
+// logWatchTransfers logs the uploads of the watch that finished
+func (s *SCPManager) logWatchTransfers(items []transfer.Item) {
+	if s.watching == nil {
+		return
+	}
+	for _, item := range items {
+		name, ok := s.watching.queued[item.ID]
+		if !ok {
+			continue
+		}
+		switch item.State {
+		case transfer.Completed:
+			s.logWatch("↑ %s (%s)", name, formatSize(item.Size))
+		case transfer.Failed:
+			s.logWatch("✗ %s: %v", name, item.Err)
+		default:
+			continue
+		}
+		delete(s.watching.queued, item.ID)
+	}
+}
+
+// logWatch adds a timestamped line to the activity log
+func (s *SCPManager) logWatch(format string, args ...any) {
+	line := time.Now().Format("15:04:05") + " " + fmt.Sprintf(format, args...)
+	s.watchLog = append(s.watchLog, line)
+	if len(s.watchLog) > watchLogKeep {
+		s.watchLog = s.watchLog[len(s.watchLog)-watchLogKeep:]
+	}
+}
+
+// renderWatchLog renders the last lines of the activity log under the
+// panels
+func (s *SCPManager) renderWatchLog() string {
+	style := lipgloss.NewStyle().Foreground(colorSubText)
+	title := lipgloss.NewStyle().Foreground(colorPrimary).Bold(true).
+		Render(fmt.Sprintf(" Watching %s (W to stop)", s.watching.watcher.Root()))
+
+	lines := []string{title}
+	recent := s.watchLog[max(len(s.watchLog)-watchLogLines, 0):]
+	for i := range watchLogLines {
+		text := ""
+		if i < len(recent) {
+			text = runewidth.Truncate(" "+recent[i], max(s.width, 10), "…")
+		}
+		lines = append(lines, style.Render(text))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
@@ -0,0 +1,74 @@
+package components
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/transfer"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/watch"
+)
+
+func TestSCPManagerWatch(t *testing.T) {
+	s := NewSCPManager(config.SSHConnection{Name: "web", Host: "web"})
+	s.loading = false
+	s.Update(tea.WindowSizeMsg{Width: 120, Height: 40})
+	key := func(k string) tea.Cmd {
+		_, cmd := s.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		return cmd
+	}
+
+	key("W")
+	if !strings.Contains(s.error, "Not connected") || s.watching != nil {
+		t.Errorf("Expected watching to need a connection, got %q", s.error)
+	}
+
+	// Connected, with a queue that uploads app.js and fails on the rest
+	s.sftpClient = &ssh.SFTPClient{}
+	s.queue = transfer.New(1, func(item transfer.Item) error {
+		if item.Name == "src/app.js" {
+			return nil
+		}
+		return errors.New("permission denied")
+	})
+	defer s.queue.Close()
+	root := t.TempDir()
+	s.localPanel.Path, s.remotePanel.Path = root, "/var/www"
+	s.error = ""
+	if cmd := key("W"); cmd == nil || s.watching == nil {
+		t.Fatal("Expected W to start watching the local directory")
+	}
+	defer s.stopWatch()
+
+	s.Update(SCPWatchMsg{Watcher: s.watching.watcher, Changes: []watch.Change{
+		{Path: "src/app.js"},
+		{Path: "locked.txt"},
+		{Path: "old.css", Removed: true},
+	}})
+	items := s.queue.Items()
+	if len(items) != 2 || items[0].Source != filepath.Join(root, "src", "app.js") || items[0].Target != "/var/www/src/app.js" || !items[0].Sync {
+		t.Fatalf("Expected the changed files queued for upload, got %+v", items)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(s.watching.queued) > 0 && time.Now().Before(deadline) {
+		s.Update(SCPQueueMsg{})
+		time.Sleep(10 * time.Millisecond)
+	}
+	view := s.View()
+	for _, want := range []string{"Watching " + root, "↑ src/app.js", "✗ locked.txt: permission denied", "old.css removed locally"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("Expected the activity log to show %q", want)
+		}
+	}
+
+	key("W")
+	if s.watching != nil || strings.Contains(s.View(), "Watching "+root) {
+		t.Error("Expected W to stop watching")
+	}
+}
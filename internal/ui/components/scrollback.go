@@ -0,0 +1,132 @@
+package components
+
+import "strings"
+
+// scrollbackLine is a line that scrolled off the screen, packed to keep
+// long sessions small: the characters as a string without the trailing
+// blanks, and the attributes only where they change
+type scrollbackLine struct {
+	text    string
+	spans   []attrSpan // empty when the whole line has the default attributes
+	wrapped bool       // the text continues on the next line
+}
+
+// attrSpan starts a run of cells with the same attributes at column col
+type attrSpan struct {
+	col   int32
+	attrs cellAttrs
+}
+
+// scrollback is a ring of packed lines holding at most max lines. The
+// oldest line is overwritten once it is full, without moving the others.
+type scrollback struct {
+	lines []scrollbackLine
+	start int // index of the oldest line once the ring is full
+	max   int
+}
+
+func newScrollback(max int) *scrollback {
+	return &scrollback{max: max}
+}
+
+// Len returns the number of lines kept
+func (s *scrollback) Len() int {
+	return len(s.lines)
+}
+
+// Push packs line and adds it as the newest line
+func (s *scrollback) Push(line []cell, defaults cellAttrs) {
+	if s.max <= 0 {
+		return
+	}
+	s.push(packLine(line, defaults))
+}
+
+// PushText adds text with the default attributes as the newest line
+func (s *scrollback) PushText(text string) {
+	if s.max <= 0 {
+		return
+	}
+	s.push(scrollbackLine{text: text})
+}
+
+func (s *scrollback) push(packed scrollbackLine) {
+	if len(s.lines) < s.max {
+		s.lines = append(s.lines, packed)
+		return
+	}
+	s.lines[s.start] = packed
+	s.start = (s.start + 1) % s.max
+}
+
+// Text returns the characters of line i, 0 being the oldest
+func (s *scrollback) Text(i int) string {
+	return s.lines[(s.start+i)%len(s.lines)].text
+}
+
+// Line unpacks line i, 0 being the oldest, into width cells. Lines wider
+// than width are cut.
+func (s *scrollback) Line(i, width int, defaults cellAttrs) []cell {
+	return unpackLine(s.lines[(s.start+i)%len(s.lines)], width, defaults)
+}
+
+// packLine drops the trailing blanks of line and stores the attribute runs
+func packLine(line []cell, defaults cellAttrs) scrollbackLine {
+	end := len(line)
+	for end > 0 && line[end-1].char == ' ' && line[end-1].attrs == defaults {
+		end--
+	}
+	var text strings.Builder
+	var spans []attrSpan
+	current := defaults
+	for i, c := range line[:end] {
+		writeCellText(&text, c)
+		if c.attrs != current {
+			spans = append(spans, attrSpan{col: int32(i), attrs: c.attrs})
+			current = c.attrs
+		}
+	}
+	wrapped := len(line) > 0 && line[len(line)-1].wrap
+	return scrollbackLine{text: text.String(), spans: spans, wrapped: wrapped}
+}
+
+// unpackLine expands a packed line into width cells, laying double-width
+// and combining characters out again by their width
+func unpackLine(packed scrollbackLine, width int, defaults cellAttrs) []cell {
+	line := make([]cell, width)
+	attrs := defaults
+	next := 0
+	col := 0
+	for _, r := range packed.text {
+		w := runeWidth(r)
+		if w == 0 {
+			if col > 0 {
+				head := col - 1
+				if line[head].isWideTail() {
+					head--
+				}
+				line[head].comb += string(r)
+			}
+			continue
+		}
+		if col+w > width {
+			break
+		}
+		for next < len(packed.spans) && int(packed.spans[next].col) <= col {
+			attrs = packed.spans[next].attrs
+			next++
+		}
+		line[col] = cell{char: r, attrs: attrs}
+		if w == 2 {
+			line[col+1] = cell{char: 0, attrs: attrs}
+		}
+		col += w
+	}
+	for ; col < width; col++ {
+		line[col] = cell{char: ' ', attrs: defaults}
+	}
+	if width > 0 {
+		line[width-1].wrap = packed.wrapped
+	}
+	return line
+}
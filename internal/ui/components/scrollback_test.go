@@ -0,0 +1,108 @@
+package components
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func scrollbackText(s *scrollback, width int) []string {
+	defaults := cellAttrs{fgColor: -1, bgColor: -1}
+	var lines []string
+	for i := range s.Len() {
+		var b strings.Builder
+		for _, c := range s.Line(i, width, defaults) {
+			writeCellText(&b, c)
+		}
+		lines = append(lines, strings.TrimRight(b.String(), " "))
+	}
+	return lines
+}
+
+func TestScrollbackRing(t *testing.T) {
+	defaults := cellAttrs{fgColor: -1, bgColor: -1}
+	vt := NewVTerminal(20, 2)
+	s := newScrollback(3)
+	for i := range 5 {
+		vt.clearInternal()
+		vt.Write(fmt.Appendf(nil, "line %d", i))
+		s.Push(vt.buffer[0], defaults)
+	}
+	want := []string{"line 2", "line 3", "line 4"}
+	if got := scrollbackText(s, 20); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the newest lines %v, got %v", want, got)
+	}
+
+	disabled := newScrollback(0)
+	disabled.Push(vt.buffer[0], defaults)
+	if disabled.Len() != 0 {
+		t.Errorf("Expected no lines kept with a size of 0, got %d", disabled.Len())
+	}
+}
+
+func TestScrollbackPacking(t *testing.T) {
+	defaults := cellAttrs{fgColor: -1, bgColor: -1}
+	vt := NewVTerminal(40, 2)
+	vt.Write([]byte("plain \x1b[1;31mred bold\x1b[0m and ✓ done"))
+	line := vt.buffer[0]
+
+	packed := packLine(line, defaults)
+	if packed.text != "plain red bold and ✓ done" {
+		t.Errorf("Expected trailing blanks to be dropped, got %q", packed.text)
+	}
+	if len(packed.spans) != 2 {
+		t.Errorf("Expected a span where the attributes change and one back, got %+v", packed.spans)
+	}
+	if got := unpackLine(packed, 40, defaults); !reflect.DeepEqual(got, line) {
+		t.Errorf("Expected the line to unpack unchanged, got %+v", got)
+	}
+
+	narrow := unpackLine(packed, 5, defaults)
+	if len(narrow) != 5 || narrow[4].char != 'n' {
+		t.Errorf("Expected the line cut to 5 cells, got %+v", narrow)
+	}
+
+	vt.Write([]byte("\r\n\x1b[7m中\x1b[0me\u0301 文"))
+	wide := vt.buffer[1]
+	packed = packLine(wide, defaults)
+	if packed.text != "中e\u0301 文" {
+		t.Errorf("Expected wide and combining characters packed as text, got %q", packed.text)
+	}
+	if got := unpackLine(packed, 40, defaults); !reflect.DeepEqual(got, wide) {
+		t.Errorf("Expected the wide line to unpack unchanged, got %+v", got)
+	}
+	if cut := unpackLine(packed, 5, defaults); cut[4].char != ' ' || cut[4].isWideTail() {
+		t.Errorf("Expected a wide character not fitting to be cut whole, got %+v", cut)
+	}
+
+	blank := packLine(vt.blankLine(), defaults)
+	if blank.text != "" || blank.spans != nil {
+		t.Errorf("Expected a blank line to pack to nothing, got %+v", blank)
+	}
+}
+
+func TestVTerminalHistory(t *testing.T) {
+	vt := NewVTerminal(20, 3)
+	vt.RestoreHistory([]string{"earlier 1", "earlier 2", "── previous ──"})
+	vt.Write([]byte("one\r\ntwo\r\nthree\r\nfour"))
+
+	want := []string{"earlier 1", "earlier 2", "── previous ──", "one", "two", "three", "four"}
+	if got := vt.History(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	// The restored lines survive the window being resized
+	vt.Resize(30, 4)
+	if got := scrollbackText(vt.scrollback, 30); !reflect.DeepEqual(got, want[:4]) {
+		t.Errorf("Expected the scrollback to be kept on resize, got %q", got)
+	}
+
+	small := NewVTerminal(20, 3)
+	small.maxScrollback = 2
+	small.scrollback = newScrollback(2)
+	small.RestoreHistory([]string{"a", "b", "c"})
+	if got := small.History(); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("Expected only the newest restored lines, got %q", got)
+	}
+}
@@ -0,0 +1,439 @@
+package components
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// Fields of the settings form, in display order
+const (
+	settingsFieldTheme = iota
+	settingsFieldStorage
+	settingsFieldConnectionSort
+	settingsFieldEscTimeout
+	settingsFieldScrollback
+	settingsFieldPersistScrollback
+	settingsFieldPersistScrollbackMB
+	settingsFieldKeepalive
+	settingsFieldResourceMonitor
+	settingsFieldConfirmDelete
+	settingsFieldRememberFileDirs
+	settingsFieldShowHiddenFiles
+	settingsFieldFileSort
+	settingsFieldDirsFirst
+	settingsFieldRemoteTrash
+	settingsFieldWatchIgnore
+	settingsFieldImagePreview
+	settingsFieldMultiplexer
+	settingsFieldMultiplexerOpenNew
+	settingsFieldMultiplexerPlacement
+	settingsFieldMultiplexerName
+	settingsFieldBitwardenSessionTTL
+	settingsFieldBitwardenSyncInterval
+	settingsFieldBitwardenMirror
+	settingsFieldLogEnabled
+	settingsFieldLogFile
+	settingsFieldMetricsAddress
+	settingsFieldStatsFile
+	settingsFieldStatsInterval
+	settingsFieldHookConnected
+	settingsFieldHookDisconnected
+	settingsFieldHookTransferDone
+	settingsFieldHookNotify
+	settingsFieldHookTransferMin
+	settingsFieldCount
+)
+
+var settingsFieldLabels = [settingsFieldCount]string{
+	"Theme",
+	"Default Storage",
+	"Connection Order (pinned always first)",
+	"Double ESC Timeout (ms)",
+	"Scrollback Lines",
+	"Keep Scrollback Across Sessions (H on a connection purges it)",
+	"Saved Scrollback Size Cap, All Connections (MB)",
+	"Keepalive Interval (seconds, 0 = off)",
+	"Host Resource Monitor Interval (seconds, 0 = off, alt+m toggles)",
+	"Confirm Before Deleting",
+	"Reopen the File Manager Where It Was Left",
+	"Show Dotfiles in the File Manager (. toggles)",
+	"File Manager Order (o cycles)",
+	"Directories Before Files (O toggles)",
+	"Remote Trash Directory (empty = delete for good)",
+	"Skipped When Watching a Directory (comma-separated patterns)",
+	"Image Preview in the File Viewer (auto = what the terminal supports)",
+	"Multiplexer (auto = the one sxt runs in)",
+	"Open Connections in the Multiplexer by Default",
+	"Multiplexer Placement",
+	"Window/Pane Name ({name}, {user}, {host}, {port})",
+	"Keep Bitwarden Unlocked Across Launches (minutes, 0 = off)",
+	"Bitwarden Background Sync (minutes, 0 = off)",
+	"Mirror Bitwarden Connections to ~/.ssh/config",
+	"Debug Log",
+	"Log File (empty = ~/.config/ssh-x-term/sxt.log)",
+	"Metrics Endpoint (host:port serving /metrics, empty = off)",
+	"Stats File (JSON counts of sessions and transfers, empty = off)",
+	"Stats File Interval (seconds)",
+	"Command When a Session Connects ($SXT_NAME, $SXT_HOST, ...)",
+	"Command When a Session Drops Unexpectedly",
+	"Command When a Long Transfer Ends ($SXT_MESSAGE, $SXT_STATUS)",
+	"Desktop Notifications for These Events",
+	"Long Transfer (seconds)",
+}
+
+// settingsStorageChoices are the default storage options; "" asks on startup
+var settingsStorageChoices = append([]string{""}, config.StorageBackends...)
+
+// SettingsForm edits the global preferences and saves them to settings.toml
+type SettingsForm struct {
+	settings   config.Settings
+	themes     []string
+	inputs     map[int]*textinput.Model
+	focusIndex int
+	submitted  bool
+	canceled   bool
+	ErrorMsg   string
+	width      int
+	height     int
+}
+
+// NewSettingsForm creates a form editing a copy of settings
+func NewSettingsForm(settings config.Settings) *SettingsForm {
+	f := &SettingsForm{
+		settings: settings,
+		themes:   settings.ThemeNames(),
+		inputs:   make(map[int]*textinput.Model),
+	}
+	values := map[int]string{
+		settingsFieldEscTimeout:            strconv.Itoa(settings.DoubleEscTimeoutMs),
+		settingsFieldScrollback:            strconv.Itoa(settings.ScrollbackLines),
+		settingsFieldPersistScrollbackMB:   strconv.Itoa(settings.PersistScrollbackMB),
+		settingsFieldKeepalive:             strconv.Itoa(settings.KeepaliveSeconds),
+		settingsFieldResourceMonitor:       strconv.Itoa(settings.ResourceMonitorSeconds),
+		settingsFieldRemoteTrash:           settings.RemoteTrashDir,
+		settingsFieldWatchIgnore:           settings.WatchIgnore,
+		settingsFieldMultiplexerName:       settings.MultiplexerName,
+		settingsFieldBitwardenSessionTTL:   strconv.Itoa(settings.BitwardenSessionTTLMinutes),
+		settingsFieldBitwardenSyncInterval: strconv.Itoa(settings.BitwardenSyncIntervalMinutes),
+		settingsFieldLogFile:               settings.LogFile,
+		settingsFieldMetricsAddress:        settings.MetricsAddress,
+		settingsFieldStatsFile:             settings.StatsFile,
+		settingsFieldStatsInterval:         strconv.Itoa(settings.StatsIntervalSeconds),
+		settingsFieldHookConnected:         settings.HookConnected,
+		settingsFieldHookDisconnected:      settings.HookDisconnected,
+		settingsFieldHookTransferDone:      settings.HookTransferDone,
+		settingsFieldHookTransferMin:       strconv.Itoa(settings.HookTransferMinSeconds),
+	}
+	for field, value := range values {
+		input := textinput.New()
+		input.Width = 40
+		input.Prompt = "> "
+		input.SetValue(value)
+		f.inputs[field] = &input
+	}
+	f.inputs[settingsFieldLogFile].Width = 50
+	f.inputs[settingsFieldStatsFile].Width = 50
+	for _, field := range []int{settingsFieldHookConnected, settingsFieldHookDisconnected, settingsFieldHookTransferDone} {
+		f.inputs[field].Width = 50
+	}
+	f.updateFocus()
+	return f
+}
+
+func (f *SettingsForm) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (f *SettingsForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		f.SetSize(msg.Width, msg.Height)
+		return f, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			f.canceled = true
+			return f, nil
+		case "ctrl+s":
+			f.save()
+			return f, nil
+		case "enter":
+			if f.focusIndex == settingsFieldCount {
+				f.save()
+				return f, nil
+			}
+			f.focusIndex++
+			f.updateFocus()
+			return f, nil
+		case "tab", "down":
+			f.focusIndex = (f.focusIndex + 1) % (settingsFieldCount + 1)
+			f.updateFocus()
+			return f, nil
+		case "shift+tab", "up":
+			f.focusIndex--
+			if f.focusIndex < 0 {
+				f.focusIndex = settingsFieldCount
+			}
+			f.updateFocus()
+			return f, nil
+		case "left", "right", " ":
+			if f.cycle(msg.String() == "left") {
+				return f, nil
+			}
+		}
+	}
+
+	if input, ok := f.inputs[f.focusIndex]; ok {
+		newInput, cmd := input.Update(msg)
+		*input = newInput
+		return f, cmd
+	}
+	return f, nil
+}
+
+// cycle changes the focused choice field, reporting whether it was one
+func (f *SettingsForm) cycle(backwards bool) bool {
+	step := 1
+	if backwards {
+		step = -1
+	}
+	next := func(choices []string, current string) string {
+		i := slices.Index(choices, current)
+		return choices[(i+step+len(choices))%len(choices)]
+	}
+	switch f.focusIndex {
+	case settingsFieldTheme:
+		f.settings.Theme = next(f.themes, f.settings.Theme)
+	case settingsFieldStorage:
+		f.settings.DefaultStorage = next(settingsStorageChoices, f.settings.DefaultStorage)
+	case settingsFieldConnectionSort:
+		f.settings.ConnectionSort = next(config.ConnectionSorts, f.settings.ConnectionSort)
+	case settingsFieldPersistScrollback:
+		f.settings.PersistScrollback = !f.settings.PersistScrollback
+	case settingsFieldConfirmDelete:
+		f.settings.ConfirmDelete = !f.settings.ConfirmDelete
+	case settingsFieldRememberFileDirs:
+		f.settings.RememberFileDirs = !f.settings.RememberFileDirs
+	case settingsFieldShowHiddenFiles:
+		f.settings.ShowHiddenFiles = !f.settings.ShowHiddenFiles
+	case settingsFieldFileSort:
+		f.settings.FileSort = next(config.FileSorts, f.settings.FileSort)
+	case settingsFieldDirsFirst:
+		f.settings.DirsFirst = !f.settings.DirsFirst
+	case settingsFieldImagePreview:
+		f.settings.ImagePreview = next(config.ImagePreviews, f.settings.ImagePreview)
+	case settingsFieldMultiplexer:
+		f.settings.Multiplexer = next(config.Multiplexers, f.settings.Multiplexer)
+	case settingsFieldMultiplexerOpenNew:
+		f.settings.MultiplexerOpenNew = !f.settings.MultiplexerOpenNew
+	case settingsFieldMultiplexerPlacement:
+		f.settings.MultiplexerPlacement = next(config.MultiplexerPlacements, f.settings.MultiplexerPlacement)
+	case settingsFieldBitwardenMirror:
+		f.settings.BitwardenMirrorSSHConfig = !f.settings.BitwardenMirrorSSHConfig
+	case settingsFieldLogEnabled:
+		f.settings.LogEnabled = !f.settings.LogEnabled
+	case settingsFieldHookNotify:
+		f.settings.HookNotify = !f.settings.HookNotify
+	default:
+		return false
+	}
+	return true
+}
+
+// save validates the form and writes the settings file
+func (f *SettingsForm) save() {
+	settings := f.settings
+	numbers := []struct {
+		field int
+		value *int
+	}{
+		{settingsFieldEscTimeout, &settings.DoubleEscTimeoutMs},
+		{settingsFieldScrollback, &settings.ScrollbackLines},
+		{settingsFieldPersistScrollbackMB, &settings.PersistScrollbackMB},
+		{settingsFieldKeepalive, &settings.KeepaliveSeconds},
+		{settingsFieldResourceMonitor, &settings.ResourceMonitorSeconds},
+		{settingsFieldBitwardenSessionTTL, &settings.BitwardenSessionTTLMinutes},
+		{settingsFieldBitwardenSyncInterval, &settings.BitwardenSyncIntervalMinutes},
+		{settingsFieldStatsInterval, &settings.StatsIntervalSeconds},
+		{settingsFieldHookTransferMin, &settings.HookTransferMinSeconds},
+	}
+	for _, n := range numbers {
+		value, err := strconv.Atoi(strings.TrimSpace(f.inputs[n.field].Value()))
+		if err != nil {
+			f.ErrorMsg = fmt.Sprintf("%s must be a number.", settingsFieldLabels[n.field])
+			f.focusIndex = n.field
+			f.updateFocus()
+			return
+		}
+		*n.value = value
+	}
+	settings.RemoteTrashDir = strings.TrimSpace(f.inputs[settingsFieldRemoteTrash].Value())
+	settings.WatchIgnore = strings.TrimSpace(f.inputs[settingsFieldWatchIgnore].Value())
+	settings.MultiplexerName = strings.TrimSpace(f.inputs[settingsFieldMultiplexerName].Value())
+	settings.LogFile = strings.TrimSpace(f.inputs[settingsFieldLogFile].Value())
+	settings.MetricsAddress = strings.TrimSpace(f.inputs[settingsFieldMetricsAddress].Value())
+	settings.StatsFile = strings.TrimSpace(f.inputs[settingsFieldStatsFile].Value())
+	settings.HookConnected = strings.TrimSpace(f.inputs[settingsFieldHookConnected].Value())
+	settings.HookDisconnected = strings.TrimSpace(f.inputs[settingsFieldHookDisconnected].Value())
+	settings.HookTransferDone = strings.TrimSpace(f.inputs[settingsFieldHookTransferDone].Value())
+
+	if err := settings.Save(); err != nil {
+		f.ErrorMsg = fmt.Sprintf("Failed to save settings: %v", err)
+		return
+	}
+	f.settings = settings
+	f.ErrorMsg = ""
+	f.submitted = true
+}
+
+func (f *SettingsForm) updateFocus() {
+	for field, input := range f.inputs {
+		if field == f.focusIndex {
+			input.Focus()
+			input.PromptStyle = focusedStyle
+			input.TextStyle = focusedStyle
+		} else {
+			input.Blur()
+			input.PromptStyle = blurredStyle
+			input.TextStyle = blurredStyle
+		}
+	}
+}
+
+// choiceView renders the options of a choice field, marking the current one
+func (f *SettingsForm) choiceView(field int, choices []string, current string, label func(string) string) string {
+	style := blurredStyle
+	if f.focusIndex == field {
+		style = focusedStyle
+	}
+	var parts []string
+	for _, choice := range choices {
+		if choice == current {
+			parts = append(parts, style.Bold(true).Render("● "+label(choice)))
+		} else {
+			parts = append(parts, blurredStyle.Render("○ "+label(choice)))
+		}
+	}
+	return strings.Join(parts, "  ")
+}
+
+func (f *SettingsForm) View() string {
+	var b strings.Builder
+
+	labelStyle := lipgloss.NewStyle().Foreground(colorSubText)
+	same := func(s string) string { return s }
+	onOff := func(s string) string {
+		if s == "true" {
+			return "On"
+		}
+		return "Off"
+	}
+
+	b.WriteString(sectionTitleStyle.Render("Settings"))
+	b.WriteString("\n\n")
+
+	for field := range settingsFieldCount {
+		b.WriteString(labelStyle.Render(settingsFieldLabels[field]))
+		b.WriteString("\n")
+		switch field {
+		case settingsFieldTheme:
+			b.WriteString(f.choiceView(field, f.themes, f.settings.Theme, same))
+		case settingsFieldStorage:
+			b.WriteString(f.choiceView(field, settingsStorageChoices, f.settings.DefaultStorage, func(s string) string {
+				if s == "" {
+					return "ask"
+				}
+				return s
+			}))
+		case settingsFieldConnectionSort:
+			b.WriteString(f.choiceView(field, config.ConnectionSorts, f.settings.ConnectionSort, same))
+		case settingsFieldPersistScrollback:
+			b.WriteString(f.choiceView(field, []string{"true", "false"}, strconv.FormatBool(f.settings.PersistScrollback), onOff))
+		case settingsFieldConfirmDelete:
+			b.WriteString(f.choiceView(field, []string{"true", "false"}, strconv.FormatBool(f.settings.ConfirmDelete), onOff))
+		case settingsFieldRememberFileDirs:
+			b.WriteString(f.choiceView(field, []string{"true", "false"}, strconv.FormatBool(f.settings.RememberFileDirs), onOff))
+		case settingsFieldShowHiddenFiles:
+			b.WriteString(f.choiceView(field, []string{"true", "false"}, strconv.FormatBool(f.settings.ShowHiddenFiles), onOff))
+		case settingsFieldFileSort:
+			b.WriteString(f.choiceView(field, config.FileSorts, f.settings.FileSort, same))
+		case settingsFieldDirsFirst:
+			b.WriteString(f.choiceView(field, []string{"true", "false"}, strconv.FormatBool(f.settings.DirsFirst), onOff))
+		case settingsFieldImagePreview:
+			b.WriteString(f.choiceView(field, config.ImagePreviews, f.settings.ImagePreview, same))
+		case settingsFieldMultiplexer:
+			b.WriteString(f.choiceView(field, config.Multiplexers, f.settings.Multiplexer, same))
+		case settingsFieldMultiplexerOpenNew:
+			b.WriteString(f.choiceView(field, []string{"true", "false"}, strconv.FormatBool(f.settings.MultiplexerOpenNew), onOff))
+		case settingsFieldMultiplexerPlacement:
+			b.WriteString(f.choiceView(field, config.MultiplexerPlacements, f.settings.MultiplexerPlacement, same))
+		case settingsFieldBitwardenMirror:
+			b.WriteString(f.choiceView(field, []string{"true", "false"}, strconv.FormatBool(f.settings.BitwardenMirrorSSHConfig), onOff))
+		case settingsFieldLogEnabled:
+			b.WriteString(f.choiceView(field, []string{"true", "false"}, strconv.FormatBool(f.settings.LogEnabled), onOff))
+		case settingsFieldHookNotify:
+			b.WriteString(f.choiceView(field, []string{"true", "false"}, strconv.FormatBool(f.settings.HookNotify), onOff))
+		default:
+			b.WriteString(f.inputs[field].View())
+		}
+		b.WriteString("\n\n")
+	}
+
+	if f.focusIndex == settingsFieldCount {
+		b.WriteString(focusedStyle.Render("[ Save ]"))
+	} else {
+		b.WriteString(fmt.Sprintf("[ %s ]", blurredStyle.Render("Save")))
+	}
+	b.WriteString("\n\n")
+	b.WriteString(labelStyle.Render("Logging and metrics changes apply on the next start."))
+
+	if f.ErrorMsg != "" {
+		b.WriteString("\n\n")
+		b.WriteString(errorStyle.Render(f.ErrorMsg))
+	}
+
+	formBox := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(70).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	availableHeight := max(f.height-3, 0)
+
+	return lipgloss.Place(
+		f.width,
+		availableHeight,
+		lipgloss.Center,
+		lipgloss.Center,
+		formBox,
+	)
+}
+
+func (f *SettingsForm) SetSize(width, height int) {
+	f.width = width
+	f.height = height
+}
+
+func (f *SettingsForm) IsSubmitted() bool {
+	return f.submitted
+}
+
+func (f *SettingsForm) IsCanceled() bool {
+	return f.canceled
+}
+
+// Settings returns the saved settings once the form has been submitted
+func (f *SettingsForm) Settings() config.Settings {
+	return f.settings
+}
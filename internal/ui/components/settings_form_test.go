@@ -0,0 +1,75 @@
+package components
+
+import (
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+func TestSettingsForm(t *testing.T) {
+	settings := config.DefaultSettings()
+	settings.Path = filepath.Join(t.TempDir(), "settings.toml")
+
+	key := func(f *SettingsForm, k tea.KeyType) {
+		f.Update(tea.KeyMsg{Type: k})
+	}
+	typeText := func(f *SettingsForm, text string) {
+		for _, r := range text {
+			f.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	t.Run("Options cycle and numbers are validated", func(t *testing.T) {
+		f := NewSettingsForm(settings)
+		key(f, tea.KeyRight) // theme: auto -> dark
+		key(f, tea.KeyTab)
+		key(f, tea.KeyLeft) // storage: ask -> keepassxc
+		key(f, tea.KeyTab)
+		key(f, tea.KeyRight) // connection order: manual -> recent
+		key(f, tea.KeyTab)
+		key(f, tea.KeyTab)
+		key(f, tea.KeyTab)
+		key(f, tea.KeySpace) // keep scrollback: off -> on
+		key(f, tea.KeyTab)
+		key(f, tea.KeyTab)
+		typeText(f, "x")
+		key(f, tea.KeyCtrlS)
+		if f.IsSubmitted() || f.ErrorMsg == "" {
+			t.Fatal("Expected a non-numeric keepalive to be rejected")
+		}
+		if f.focusIndex != settingsFieldKeepalive {
+			t.Errorf("Expected focus on the invalid field, got %d", f.focusIndex)
+		}
+
+		key(f, tea.KeyBackspace)
+		typeText(f, "5")
+		key(f, tea.KeyTab)
+		key(f, tea.KeyTab)
+		key(f, tea.KeySpace) // confirm delete: on -> off
+		key(f, tea.KeyCtrlS)
+		if !f.IsSubmitted() {
+			t.Fatalf("Expected the form to save, got error %q", f.ErrorMsg)
+		}
+
+		saved, err := config.LoadSettings(settings.Path)
+		if err != nil {
+			t.Fatalf("Failed to load saved settings: %v", err)
+		}
+		if saved.Theme != "dark" || saved.DefaultStorage != "keepassxc" || saved.ConnectionSort != "recent" ||
+			saved.KeepaliveSeconds != 5 || saved.ConfirmDelete || !saved.PersistScrollback {
+			t.Errorf("Unexpected saved settings %+v", saved)
+		}
+	})
+
+	t.Run("Escape discards changes", func(t *testing.T) {
+		f := NewSettingsForm(settings)
+		key(f, tea.KeyRight)
+		key(f, tea.KeyEsc)
+		if !f.IsCanceled() || f.IsSubmitted() {
+			t.Error("Expected the form to be canceled")
+		}
+	})
+}
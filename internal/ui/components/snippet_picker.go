@@ -0,0 +1,394 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// snippetPickerMode is the screen the snippet picker is showing
+type snippetPickerMode int
+
+const (
+	snippetModeList snippetPickerMode = iota
+	snippetModeEdit
+	snippetModeParams
+	snippetModeDelete
+)
+
+// SnippetPicker lists the command snippets for a connection, lets the user
+// manage them, and prompts for placeholder values before sending one
+type SnippetPicker struct {
+	store        *config.SnippetStore
+	connectionID string
+	snippets     []config.Snippet
+	selectedIdx  int
+	mode         snippetPickerMode
+
+	// Edit form
+	editing     config.Snippet // zero ID when adding
+	editInputs  []textinput.Model
+	editGlobal  bool
+	editFocus   int
+	placeholder []string // placeholder names of the snippet being sent
+	paramInputs []textinput.Model
+	paramFocus  int
+	runAfter    bool // send Enter after the command
+
+	command  string
+	run      bool
+	done     bool
+	canceled bool
+	error    string
+	width    int
+	height   int
+}
+
+// NewSnippetPicker opens the snippet library for a connection
+func NewSnippetPicker(connectionID string) *SnippetPicker {
+	p := &SnippetPicker{connectionID: connectionID}
+	store, err := config.NewSnippetStore()
+	if err != nil {
+		p.error = fmt.Sprintf("Failed to open snippets: %s", err)
+		return p
+	}
+	if err := store.Load(); err != nil {
+		p.error = fmt.Sprintf("Failed to load snippets: %s", err)
+	}
+	p.store = store
+	p.reload()
+	return p
+}
+
+func (p *SnippetPicker) reload() {
+	p.snippets = p.store.ForConnection(p.connectionID)
+	if p.selectedIdx >= len(p.snippets) {
+		p.selectedIdx = max(len(p.snippets)-1, 0)
+	}
+}
+
+func (p *SnippetPicker) Init() tea.Cmd {
+	return nil
+}
+
+func (p *SnippetPicker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		p.SetSize(size.Width, size.Height)
+		return p, nil
+	}
+
+	keyMsg, isKey := msg.(tea.KeyMsg)
+	switch p.mode {
+	case snippetModeEdit:
+		return p.updateEdit(msg)
+	case snippetModeParams:
+		return p.updateParams(msg)
+	case snippetModeDelete:
+		if isKey {
+			switch keyMsg.String() {
+			case "y", "Y":
+				if err := p.store.DeleteSnippet(p.snippets[p.selectedIdx].ID); err != nil {
+					p.error = fmt.Sprintf("Failed to delete snippet: %s", err)
+				}
+				p.reload()
+				p.mode = snippetModeList
+			case "n", "N", "esc":
+				p.mode = snippetModeList
+			}
+		}
+		return p, nil
+	}
+
+	if !isKey {
+		return p, nil
+	}
+	p.error = ""
+	switch keyMsg.String() {
+	case "esc", "q", "alt+s":
+		p.canceled = true
+	case "up", "k":
+		if p.selectedIdx > 0 {
+			p.selectedIdx--
+		}
+	case "down", "j":
+		if p.selectedIdx < len(p.snippets)-1 {
+			p.selectedIdx++
+		}
+	case "enter", "tab":
+		if len(p.snippets) > 0 {
+			return p, p.choose(p.snippets[p.selectedIdx], keyMsg.String() == "enter")
+		}
+	case "a":
+		if p.store != nil {
+			return p, p.startEdit(config.Snippet{ConnectionID: p.connectionID})
+		}
+	case "e":
+		if len(p.snippets) > 0 {
+			return p, p.startEdit(p.snippets[p.selectedIdx])
+		}
+	case "d":
+		if len(p.snippets) > 0 {
+			p.mode = snippetModeDelete
+		}
+	}
+	return p, nil
+}
+
+// choose sends a snippet, prompting for its placeholders first
+func (p *SnippetPicker) choose(snippet config.Snippet, run bool) tea.Cmd {
+	p.runAfter = run
+	p.placeholder = config.SnippetPlaceholders(snippet.Command)
+	if len(p.placeholder) == 0 {
+		p.command, p.run, p.done = snippet.Command, run, true
+		return nil
+	}
+
+	p.editing = snippet
+	p.paramInputs = make([]textinput.Model, len(p.placeholder))
+	for i, name := range p.placeholder {
+		p.paramInputs[i] = newSnippetInput(name)
+	}
+	p.paramFocus = 0
+	p.focusInputs(p.paramInputs, p.paramFocus)
+	p.mode = snippetModeParams
+	return textinput.Blink
+}
+
+func (p *SnippetPicker) updateParams(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			p.mode = snippetModeList
+			return p, nil
+		case "tab", "down":
+			p.paramFocus = (p.paramFocus + 1) % len(p.paramInputs)
+			p.focusInputs(p.paramInputs, p.paramFocus)
+			return p, nil
+		case "shift+tab", "up":
+			p.paramFocus = (p.paramFocus - 1 + len(p.paramInputs)) % len(p.paramInputs)
+			p.focusInputs(p.paramInputs, p.paramFocus)
+			return p, nil
+		case "enter":
+			if p.paramFocus < len(p.paramInputs)-1 {
+				p.paramFocus++
+				p.focusInputs(p.paramInputs, p.paramFocus)
+				return p, nil
+			}
+			values := make(map[string]string, len(p.placeholder))
+			for i, name := range p.placeholder {
+				values[name] = p.paramInputs[i].Value()
+			}
+			p.command = config.ExpandSnippet(p.editing.Command, values)
+			p.run, p.done = p.runAfter, true
+			return p, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	p.paramInputs[p.paramFocus], cmd = p.paramInputs[p.paramFocus].Update(msg)
+	return p, cmd
+}
+
+func (p *SnippetPicker) startEdit(snippet config.Snippet) tea.Cmd {
+	p.editing = snippet
+	p.editGlobal = snippet.IsGlobal()
+	p.editInputs = []textinput.Model{newSnippetInput("Name"), newSnippetInput("Command, e.g. tail -f {{file}}")}
+	p.editInputs[0].SetValue(snippet.Name)
+	p.editInputs[1].SetValue(snippet.Command)
+	p.editInputs[1].CharLimit = 1000
+	p.editFocus = 0
+	p.focusInputs(p.editInputs, p.editFocus)
+	p.mode = snippetModeEdit
+	return textinput.Blink
+}
+
+func (p *SnippetPicker) updateEdit(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.String() {
+		case "esc":
+			p.mode = snippetModeList
+			p.error = ""
+			return p, nil
+		case "ctrl+g":
+			p.editGlobal = !p.editGlobal
+			return p, nil
+		case "tab", "down", "shift+tab", "up":
+			p.editFocus = 1 - p.editFocus
+			p.focusInputs(p.editInputs, p.editFocus)
+			return p, nil
+		case "enter":
+			if p.editFocus == 0 {
+				p.editFocus = 1
+				p.focusInputs(p.editInputs, p.editFocus)
+				return p, nil
+			}
+			p.saveEdit()
+			return p, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	p.editInputs[p.editFocus], cmd = p.editInputs[p.editFocus].Update(msg)
+	return p, cmd
+}
+
+func (p *SnippetPicker) saveEdit() {
+	snippet := p.editing
+	snippet.Name = strings.TrimSpace(p.editInputs[0].Value())
+	snippet.Command = p.editInputs[1].Value()
+	snippet.ConnectionID = ""
+	if !p.editGlobal {
+		snippet.ConnectionID = p.connectionID
+	}
+
+	var err error
+	if snippet.ID == "" {
+		_, err = p.store.AddSnippet(snippet)
+	} else {
+		err = p.store.EditSnippet(snippet)
+	}
+	if err != nil {
+		p.error = err.Error()
+		return
+	}
+	p.error = ""
+	p.reload()
+	p.mode = snippetModeList
+}
+
+func newSnippetInput(placeholder string) textinput.Model {
+	input := textinput.New()
+	input.Placeholder = placeholder
+	input.Width = 60
+	input.Prompt = "> "
+	return input
+}
+
+func (p *SnippetPicker) focusInputs(inputs []textinput.Model, focus int) {
+	for i := range inputs {
+		if i == focus {
+			inputs[i].Focus()
+			inputs[i].PromptStyle = focusedStyle
+			inputs[i].TextStyle = focusedStyle
+		} else {
+			inputs[i].Blur()
+			inputs[i].PromptStyle = blurredStyle
+			inputs[i].TextStyle = blurredStyle
+		}
+	}
+}
+
+func (p *SnippetPicker) View() string {
+	var b strings.Builder
+	labelStyle := lipgloss.NewStyle().Foreground(colorSubText)
+	hintStyle := lipgloss.NewStyle().Foreground(colorInactive)
+
+	switch p.mode {
+	case snippetModeEdit:
+		title := "Add Snippet"
+		if p.editing.ID != "" {
+			title = "Edit Snippet"
+		}
+		b.WriteString(sectionTitleStyle.Render(title))
+		b.WriteString("\n\n")
+		for i, label := range []string{"Name", "Command"} {
+			b.WriteString(labelStyle.Render(label))
+			b.WriteString("\n")
+			b.WriteString(p.editInputs[i].View())
+			b.WriteString("\n\n")
+		}
+		scope := "[ ] Available for all connections (ctrl+g)"
+		if p.editGlobal {
+			scope = "[x] Available for all connections (ctrl+g)"
+		}
+		b.WriteString(labelStyle.Render(scope))
+		b.WriteString("\n\n")
+		b.WriteString(hintStyle.Render("Use {{name}} for values to fill in when sending."))
+		b.WriteString("\n")
+		b.WriteString(hintStyle.Render("enter: save | esc: back"))
+
+	case snippetModeParams:
+		b.WriteString(sectionTitleStyle.Render(p.editing.Name))
+		b.WriteString("\n\n")
+		b.WriteString(labelStyle.Render(truncate(p.editing.Command, 70)))
+		b.WriteString("\n\n")
+		for i, name := range p.placeholder {
+			b.WriteString(labelStyle.Render(name))
+			b.WriteString("\n")
+			b.WriteString(p.paramInputs[i].View())
+			b.WriteString("\n\n")
+		}
+		action := "enter: send"
+		if p.runAfter {
+			action = "enter: run"
+		}
+		b.WriteString(hintStyle.Render(action + " | tab: next | esc: back"))
+
+	default:
+		b.WriteString(sectionTitleStyle.Render("Snippets"))
+		b.WriteString("\n\n")
+		if len(p.snippets) == 0 {
+			b.WriteString(blurredStyle.Render("No snippets yet. Press a to add one."))
+			b.WriteString("\n")
+		}
+		for i, snippet := range p.snippets {
+			scope := "     "
+			if !snippet.IsGlobal() {
+				scope = "host "
+			}
+			line := fmt.Sprintf("%s%-20s %s", scope, truncate(snippet.Name, 20), truncate(snippet.Command, 44))
+			if i == p.selectedIdx {
+				b.WriteString(focusedStyle.Bold(true).Render("> " + line))
+			} else {
+				b.WriteString(blurredStyle.Render("  " + line))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+		if p.mode == snippetModeDelete {
+			b.WriteString(errorStyle.Render(fmt.Sprintf("Delete snippet %q? (y/n)", p.snippets[p.selectedIdx].Name)))
+		} else {
+			b.WriteString(hintStyle.Render("enter: run | tab: type without running | a: add | e: edit | d: delete | esc: close"))
+		}
+	}
+
+	if p.error != "" {
+		b.WriteString("\n\n")
+		b.WriteString(errorStyle.Render(p.error))
+	}
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(80).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	return lipgloss.Place(p.width, p.height, lipgloss.Center, lipgloss.Center, box)
+}
+
+func (p *SnippetPicker) SetSize(width, height int) {
+	p.width = width
+	p.height = height
+}
+
+// Command returns the expanded command to send and whether it should be
+// run (followed by Enter) once the picker is done
+func (p *SnippetPicker) Command() (string, bool) {
+	return p.command, p.run
+}
+
+// IsDone reports whether a snippet has been chosen
+func (p *SnippetPicker) IsDone() bool {
+	return p.done
+}
+
+func (p *SnippetPicker) IsCanceled() bool {
+	return p.canceled
+}
@@ -0,0 +1,71 @@
+package components
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+func TestSnippetPicker(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	store, err := config.NewSnippetStore()
+	if err != nil {
+		t.Fatalf("Failed to create snippet store: %v", err)
+	}
+	if _, err := store.AddSnippet(config.Snippet{Name: "logs", Command: "journalctl -u {{unit}} -n {{lines}}"}); err != nil {
+		t.Fatalf("Failed to add snippet: %v", err)
+	}
+	if _, err := store.AddSnippet(config.Snippet{Name: "uptime", Command: "uptime", ConnectionID: "sxt-other"}); err != nil {
+		t.Fatalf("Failed to add snippet: %v", err)
+	}
+
+	typeText := func(p *SnippetPicker, text string) {
+		for _, r := range text {
+			p.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+	}
+
+	t.Run("Only global and own snippets are listed", func(t *testing.T) {
+		p := NewSnippetPicker("sxt-web")
+		if len(p.snippets) != 1 || p.snippets[0].Name != "logs" {
+			t.Errorf("Expected only the global snippet, got %+v", p.snippets)
+		}
+	})
+
+	t.Run("Placeholders are prompted before sending", func(t *testing.T) {
+		p := NewSnippetPicker("sxt-web")
+		p.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		if p.IsDone() || p.mode != snippetModeParams {
+			t.Fatal("Expected placeholder prompts before sending")
+		}
+
+		typeText(p, "nginx")
+		p.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		typeText(p, "50")
+		p.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		command, run := p.Command()
+		if !p.IsDone() || !run || command != "journalctl -u nginx -n 50" {
+			t.Errorf("Expected expanded command to run, got %q (run=%v)", command, run)
+		}
+	})
+
+	t.Run("Added snippets are saved for the connection", func(t *testing.T) {
+		p := NewSnippetPicker("sxt-web")
+		p.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+		typeText(p, "disk")
+		p.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		typeText(p, "df -h")
+		p.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+		if p.mode != snippetModeList || p.error != "" {
+			t.Fatalf("Expected to return to the list, got mode %d error %q", p.mode, p.error)
+		}
+		if len(p.snippets) != 2 || p.snippets[0].Name != "disk" || p.snippets[0].ConnectionID != "sxt-web" {
+			t.Errorf("Expected the new per-connection snippet first, got %+v", p.snippets)
+		}
+	})
+}
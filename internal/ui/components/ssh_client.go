@@ -0,0 +1,49 @@
+package components
+
+import (
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+// SSHClientMsg carries an established exec-capable SSH client
+type SSHClientMsg struct {
+	Client *ssh.Client
+	Err    error
+}
+
+// connectingStatus is the status shown while dialing conn, asking for a
+// touch when it authenticates with a security key
+func connectingStatus(conn config.SSHConnection) string {
+	if ssh.UsesSecurityKey(conn) {
+		return "Connecting... " + ssh.SecurityKeyNotice
+	}
+	return "Connecting..."
+}
+
+// connectSSHClient dials the connection in the background, translating
+// missing credentials into the messages the model uses to prompt for them.
+func connectSSHClient(conn config.SSHConnection) tea.Cmd {
+	return func() tea.Msg {
+		client, err := ssh.Connect(conn)
+		if err != nil {
+			var passphraseErr *ssh.PassphraseRequiredError
+			if errors.As(err, &passphraseErr) {
+				return SSHPassphraseRequiredMsg{
+					Connection: conn,
+					KeyFile:    passphraseErr.KeyFile,
+				}
+			}
+			var passwordErr *ssh.PasswordRequiredError
+			if errors.As(err, &passwordErr) {
+				return SSHPasswordRequiredMsg{
+					Connection: conn,
+				}
+			}
+			return SSHClientMsg{Err: err}
+		}
+		return SSHClientMsg{Client: client}
+	}
+}
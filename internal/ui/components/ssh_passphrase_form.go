@@ -2,6 +2,7 @@ package components
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -9,9 +10,26 @@ import (
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
 )
 
+// PassphraseSave is what happens to a key passphrase once it was entered
+type PassphraseSave int
+
+const (
+	// PassphraseSaveNone asks again on the next connection
+	PassphraseSaveNone PassphraseSave = iota
+	// PassphraseSaveKeyring stores the passphrase in the OS keyring
+	PassphraseSaveKeyring
+	// PassphraseSaveAgent adds the decrypted key to ssh-agent instead
+	PassphraseSaveAgent
+	passphraseSaveCount
+)
+
+var passphraseSaveLabels = [passphraseSaveCount]string{"Ask every time", "Save in keyring", "Add to ssh-agent"}
+
 type SSHPassphraseForm struct {
 	textInput  textinput.Model
 	Connection config.SSHConnection
+	keyFile    string
+	save       PassphraseSave
 	submitted  bool
 	canceled   bool
 	width      int
@@ -50,6 +68,15 @@ func (f *SSHPassphraseForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter":
 			f.submitted = true
 			return f, nil
+		case "tab", "shift+tab":
+			if f.keyFile != "" {
+				step := PassphraseSave(1)
+				if msg.String() == "shift+tab" {
+					step = passphraseSaveCount - 1
+				}
+				f.save = (f.save + step) % passphraseSaveCount
+			}
+			return f, nil
 		}
 	}
 
@@ -64,17 +91,32 @@ func (f *SSHPassphraseForm) View() string {
 	// Create a centered box
 	title := fmt.Sprintf("Authentication Required for '%s'", f.Connection.Name)
 	desc := fmt.Sprintf("Enter passphrase for key:\n%s", f.Connection.KeyFile)
-	if f.Connection.KeyFile == "" {
+	if f.keyFile != "" {
+		desc = fmt.Sprintf("Enter passphrase for key:\n%s", f.keyFile)
+	} else if f.Connection.KeyFile == "" {
 		desc = fmt.Sprintf("Enter password for user '%s@%s':", f.Connection.Username, f.Connection.Host)
 	}
 
-	content = lipgloss.JoinVertical(lipgloss.Center,
+	parts := []string{
 		sectionTitleStyle.Render(title),
 		"\n",
 		lipgloss.NewStyle().Foreground(colorSubText).Render(desc),
 		"\n",
 		f.textInput.View(),
-	)
+	}
+	if f.keyFile != "" {
+		var choices []string
+		for save, label := range passphraseSaveLabels {
+			if PassphraseSave(save) == f.save {
+				choices = append(choices, focusedStyle.Bold(true).Render("● "+label))
+			} else {
+				choices = append(choices, blurredStyle.Render("○ "+label))
+			}
+		}
+		parts = append(parts, "\n", strings.Join(choices, "  "),
+			lipgloss.NewStyle().Foreground(colorSubText).Render("tab: change"))
+	}
+	content = lipgloss.JoinVertical(lipgloss.Center, parts...)
 
 	// Border box
 	formBox := lipgloss.NewStyle().
@@ -111,3 +153,20 @@ func (f *SSHPassphraseForm) IsCanceled() bool {
 func (f *SSHPassphraseForm) Value() string {
 	return f.textInput.Value()
 }
+
+// AskForKey switches the form to the passphrase of an encrypted key file,
+// offering to keep it in the keyring or ssh-agent
+func (f *SSHPassphraseForm) AskForKey(keyFile string) {
+	f.keyFile = keyFile
+	f.textInput.Placeholder = "Key Passphrase"
+}
+
+// KeyFile returns the key the passphrase is for, empty for passwords
+func (f *SSHPassphraseForm) KeyFile() string {
+	return f.keyFile
+}
+
+// Save returns where the passphrase should be kept
+func (f *SSHPassphraseForm) Save() PassphraseSave {
+	return f.save
+}
@@ -1,20 +1,17 @@
 package components
 
 import (
+	"slices"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
 )
 
-type StorageBackend int
-
-const (
-	StorageLocal StorageBackend = iota
-	StorageBitwarden
-)
-
+// StorageSelect offers the backends registered with
+// config.RegisterStorageBackend
 type StorageSelect struct {
-	options       []string
-	descriptions  []string
+	backends      []config.StorageBackendInfo
 	selectedIndex int
 	chosen        bool
 	canceled      bool
@@ -23,15 +20,35 @@ type StorageSelect struct {
 }
 
 func NewStorageSelect() *StorageSelect {
-	return &StorageSelect{
-		options: []string{"Local Storage", "Bitwarden"},
-		descriptions: []string{
-			"Local SSH config",
-			"Sync with Bitwarden vault",
-		},
-	}
+	return &StorageSelect{backends: config.RegisteredStorageBackends()}
+}
+
+// storageSymbols are the card pictures of the backends by name, a padlock
+// for backends without one
+var storageSymbols = map[string]string{
+	"local": `   _______
+  /      /|
+ /______/ |
+ |      | /
+ |______|/`,
+	"bitwarden": `   .--.
+  /.-. '----------.
+  \'-' .--"--""-"-'
+   '--'
+      `,
+	"vault": ` _________
+ |  ___  |
+ | ( o ) |
+ |  '-'  |
+ |_______|`,
 }
 
+const defaultStorageSymbol = `   .---.
+  /  _  \
+ _|_(_)_|_
+ |   o   |
+ |___|___|`
+
 func (s *StorageSelect) Init() tea.Cmd {
 	return nil
 }
@@ -50,7 +67,7 @@ func (s *StorageSelect) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				s.selectedIndex--
 			}
 		case "right", "l", "down", "j":
-			if s.selectedIndex < len(s.options)-1 {
+			if s.selectedIndex < len(s.backends)-1 {
 				s.selectedIndex++
 			}
 		case "enter":
@@ -99,11 +116,10 @@ func (s *StorageSelect) View() string {
 
 	var cards []string
 
-	for i, option := range s.options {
+	for i, backend := range s.backends {
 		var currentCardStyle lipgloss.Style
 		var currentTitleStyle lipgloss.Style
 		var symbolColor lipgloss.Color
-		var symbol string
 
 		if i == s.selectedIndex {
 			currentCardStyle = activeCardStyle
@@ -115,20 +131,9 @@ func (s *StorageSelect) View() string {
 			symbolColor = colorInactive
 		}
 
-		if i == 0 { // Local (Box)
-			symbol =
-				`   _______
-  /      /|
- /______/ |
- |      | /
- |______|/`
-		} else { // Secure (Key)
-			symbol =
-				`   .--.
-  /.-. '----------.
-  \'-' .--"--""-"-'
-   '--'
-      `
+		symbol, ok := storageSymbols[backend.Name]
+		if !ok {
+			symbol = defaultStorageSymbol
 		}
 
 		// Apply color to symbol
@@ -136,8 +141,8 @@ func (s *StorageSelect) View() string {
 
 		content := lipgloss.JoinVertical(lipgloss.Center,
 			renderedSymbol,
-			currentTitleStyle.Render(option),
-			descStyle.Render(s.descriptions[i]),
+			currentTitleStyle.Render(backend.Title),
+			descStyle.Render(backend.Description),
 		)
 
 		cards = append(cards, currentCardStyle.Render(content))
@@ -145,12 +150,17 @@ func (s *StorageSelect) View() string {
 
 	// --- Layout ---
 
-	// Join cards horizontally with a larger gap
-	ui := lipgloss.JoinHorizontal(lipgloss.Center,
-		cards[0],
-		"      ", // 6 spaces gap
-		cards[1],
-	)
+	// Join cards horizontally with a larger gap, wrapping into rows of two
+	// when the terminal is too narrow for all of them
+	perRow := len(cards)
+	if lipgloss.Width(joinCards(cards)) > s.width {
+		perRow = 2
+	}
+	var rows []string
+	for i := 0; i < len(cards); i += perRow {
+		rows = append(rows, joinCards(cards[i:min(i+perRow, len(cards))]))
+	}
+	ui := lipgloss.JoinVertical(lipgloss.Center, rows...)
 
 	// Center vertically and horizontally in the full available space
 	return lipgloss.Place(
@@ -162,8 +172,23 @@ func (s *StorageSelect) View() string {
 	)
 }
 
-func (s *StorageSelect) SelectedBackend() StorageBackend {
-	return StorageBackend(s.selectedIndex)
+func joinCards(cards []string) string {
+	var row []string
+	for i, card := range cards {
+		if i > 0 {
+			row = append(row, "      ") // 6 spaces gap
+		}
+		row = append(row, card)
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Center, row...)
+}
+
+// SelectedBackend returns the name of the highlighted backend
+func (s *StorageSelect) SelectedBackend() string {
+	if s.selectedIndex >= len(s.backends) {
+		return ""
+	}
+	return s.backends[s.selectedIndex].Name
 }
 
 func (s *StorageSelect) IsChosen() bool {
@@ -180,3 +205,15 @@ func (s *StorageSelect) SetSize(width, height int) {
 	s.width = width
 	s.height = height
 }
+
+// ChooseByName picks the backend named as in config.StorageBackends, as if
+// the user had selected it. It reports false for unknown names.
+func (s *StorageSelect) ChooseByName(name string) bool {
+	i := slices.IndexFunc(s.backends, func(b config.StorageBackendInfo) bool { return b.Name == name })
+	if i < 0 {
+		return false
+	}
+	s.selectedIndex = i
+	s.chosen = true
+	return true
+}
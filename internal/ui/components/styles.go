@@ -7,60 +7,73 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-// --- Palette based on the Gopher Bubble Tea Image ---
 var (
-	// The vibrant purple from the window header and straw
-	colorPrimary = lipgloss.Color("#974FD7")
-	// The cyan/blue from the Gopher's skin and "ssh" text
-	colorSecondary = lipgloss.Color("#00ADD8")
-	// The cream/beige from the tea drink (used for headers and highlights)
-	colorAccent = lipgloss.Color("#F0D8B2")
-	// Standard text colors
-	colorText     = lipgloss.Color("#FAFAFA")
-	colorSubText  = lipgloss.Color("#7D7D7D")
-	colorError    = lipgloss.Color("#FF5555")
-	colorInactive = lipgloss.Color("#4D4D4D")
+	titleStyle          lipgloss.Style
+	sectionTitleStyle   lipgloss.Style
+	containerStyle      lipgloss.Style
+	paginationStyle     lipgloss.Style
+	helpStyle           lipgloss.Style
+	headerStyle         lipgloss.Style
+	itemStyle           lipgloss.Style
+	selectedItemStyle   lipgloss.Style
+	focusedStyle        lipgloss.Style
+	blurredStyle        lipgloss.Style
+	noStyle             lipgloss.Style
+	focusedButton       string
+	blurredButton       string
+	errorStyle          lipgloss.Style
+	scpHeaderStyle      lipgloss.Style
+	scpPanelStyle       lipgloss.Style
+	scpActivePanelStyle lipgloss.Style
+	scpDirStyle         lipgloss.Style
+	scpSelectedStyle    lipgloss.Style
+	scpMarkedStyle      lipgloss.Style
+	scpStatusStyle      lipgloss.Style
+	terminalHeaderStyle lipgloss.Style
+	terminalErrorStyle  lipgloss.Style
+	securityKeyStyle    lipgloss.Style
 )
 
-var (
+// buildStyles recreates the styles from the current colors
+func buildStyles() {
 	// --- General Layout Styles ---
 
 	// Main View Titles
 	titleStyle = lipgloss.NewStyle().
-			MarginLeft(2).
-			MarginTop(1).
-			Foreground(colorPrimary). // Purple
-			Bold(true)
+		MarginLeft(2).
+		MarginTop(1).
+		Foreground(colorPrimary).
+		Bold(true)
 
 	// Standard bold header for sections
 	sectionTitleStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(colorPrimary).
-				MarginBottom(1)
+		Bold(true).
+		Foreground(colorPrimary).
+		MarginBottom(1)
 
 	// Generic container padding
 	containerStyle = lipgloss.NewStyle().
-			Padding(1, 2)
+		Padding(1, 2)
 
 	// --- List & Table Styles ---
 
 	paginationStyle = list.DefaultStyles().PaginationStyle.PaddingLeft(4)
-	helpStyle       = list.DefaultStyles().HelpStyle.PaddingLeft(4).Align(lipgloss.Center).PaddingBottom(1)
+	helpStyle = list.DefaultStyles().HelpStyle.PaddingLeft(4).Align(lipgloss.Center).PaddingBottom(1)
 
 	// Table column headers
 	headerStyle = lipgloss.NewStyle().
-			Foreground(colorAccent).
-			Bold(true)
+		Foreground(colorAccent).
+		Bold(true)
 
 	// Standard row item
 	itemStyle = lipgloss.NewStyle().PaddingLeft(2)
 
 	// Selected row item
 	selectedItemStyle = lipgloss.NewStyle().
-				Border(lipgloss.NormalBorder(), false, false, false, true).
-				BorderForeground(colorPrimary). // Purple border
-				Foreground(colorPrimary).       // Purple text
-				PaddingLeft(1)
+		Border(lipgloss.NormalBorder(), false, false, false, true).
+		BorderForeground(colorPrimary).
+		Foreground(colorPrimary).
+		PaddingLeft(1)
 
 	// --- Form & Input Styles (Consolidated) ---
 
@@ -68,7 +81,7 @@ var (
 	focusedStyle = lipgloss.NewStyle().Foreground(colorPrimary)
 	// Blurred/Inactive input fields
 	blurredStyle = lipgloss.NewStyle().Foreground(colorInactive)
-	noStyle      = lipgloss.NewStyle()
+	noStyle = lipgloss.NewStyle()
 
 	// Buttons
 	focusedButton = focusedStyle.Render("[ Submit ]")
@@ -76,68 +89,80 @@ var (
 
 	// Error messages
 	errorStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(colorError).
-			Padding(0, 2)
+		Bold(true).
+		Foreground(colorError).
+		Padding(0, 2)
 
 	// --- SCP / File Manager Styles ---
 
 	// Header for file panels
 	scpHeaderStyle = lipgloss.NewStyle().
-			Bold(true).
-			Background(colorPrimary). // Purple background
-			Foreground(colorText).
-			Align(lipgloss.Center).
-			Padding(0, 1)
+		Bold(true).
+		Background(colorPrimary).
+		Foreground(colorText).
+		Align(lipgloss.Center).
+		Padding(0, 1)
 
 	// Inactive file panel
 	scpPanelStyle = lipgloss.NewStyle().
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(colorInactive).
-			Padding(1, 2)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorInactive).
+		Padding(1, 2)
 
 	// Active file panel
 	scpActivePanelStyle = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(colorSecondary). // Blue border for active focus
-				Padding(1, 2)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorSecondary).
+		Padding(1, 2)
 
 	// Directory names
 	scpDirStyle = lipgloss.NewStyle().
-			Foreground(colorSecondary). // Blue text
-			Bold(true)
+		Foreground(colorSecondary).
+		Bold(true)
 
 	// Selected file in list
 	scpSelectedStyle = lipgloss.NewStyle().
-				Background(lipgloss.Color("237")).
-				Foreground(colorAccent).
-				Bold(true)
+		Background(currentTheme.Selection).
+		Foreground(colorAccent).
+		Bold(true)
+
+	// Files marked for a batch transfer
+	scpMarkedStyle = lipgloss.NewStyle().
+		Foreground(colorAccent).
+		Bold(true)
 
 	scpStatusStyle = lipgloss.NewStyle().
-			Foreground(colorSubText).
-			Background(lipgloss.Color("235")).
-			Padding(0, 2)
+		Foreground(colorSubText).
+		Background(currentTheme.Bar).
+		Padding(0, 2)
 
 	// --- Terminal Styles ---
 
 	terminalHeaderStyle = lipgloss.NewStyle().
-				Bold(true).
-				Background(colorPrimary). // Purple background
-				Foreground(colorText).
-				Align(lipgloss.Center).
-				Padding(0, 1)
+		Bold(true).
+		Background(colorPrimary).
+		Foreground(colorText).
+		Align(lipgloss.Center).
+		Padding(0, 1)
 
 	terminalErrorStyle = lipgloss.NewStyle().
-				Bold(true).
-				Foreground(colorError).
-				Align(lipgloss.Center).
-				Padding(1, 0)
-)
+		Bold(true).
+		Foreground(colorError).
+		Align(lipgloss.Center).
+		Padding(1, 0)
+
+	securityKeyStyle = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(colorAccent)
+
+	formStyle = containerStyle
+	formTitleStyle = sectionTitleStyle
+}
 
 // --- Re-export aliases for backward compatibility ---
 
 var (
 	// Generic Form aliases
-	formStyle      = containerStyle
-	formTitleStyle = sectionTitleStyle
+	formStyle      lipgloss.Style
+	formTitleStyle lipgloss.Style
 )
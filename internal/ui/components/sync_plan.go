@@ -0,0 +1,197 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/transfer"
+)
+
+// SyncPlanView lists what a directory sync would copy and delete, and
+// lets the mode and options be changed before it runs
+type SyncPlanView struct {
+	localRoot  string
+	remoteRoot string // host:path
+	local      map[string]transfer.SyncEntry
+	remote     map[string]transfer.SyncEntry
+	opts       transfer.SyncOptions
+	checksum   bool // The trees were scanned with checksums
+	plan       transfer.SyncPlan
+	viewport   viewport.Model
+	confirmed  bool
+	canceled   bool
+	rescan     bool // Checksums were toggled, so the trees need scanning again
+	width      int
+	height     int
+}
+
+// NewSyncPlanView plans the sync of the scanned local and remote trees
+func NewSyncPlanView(localRoot, remoteRoot string, local, remote map[string]transfer.SyncEntry, opts transfer.SyncOptions, checksum bool) *SyncPlanView {
+	v := &SyncPlanView{
+		localRoot:  localRoot,
+		remoteRoot: remoteRoot,
+		local:      local,
+		remote:     remote,
+		opts:       opts,
+		checksum:   checksum,
+		viewport:   viewport.New(0, 0),
+	}
+	v.replan()
+	return v
+}
+
+// replan computes the plan for the current options
+func (v *SyncPlanView) replan() {
+	v.plan = transfer.PlanSync(v.local, v.remote, v.opts)
+
+	if len(v.plan.Actions) == 0 && len(v.plan.Conflicts) == 0 {
+		v.viewport.SetContent(lipgloss.NewStyle().Foreground(colorSubText).Render("  Nothing to do, the directories are in sync"))
+		return
+	}
+	reasonStyle := lipgloss.NewStyle().Foreground(colorSubText)
+	rows := make([]string, 0, len(v.plan.Actions)+len(v.plan.Conflicts))
+	for _, a := range v.plan.Actions {
+		var marker string
+		var style lipgloss.Style
+		switch {
+		case a.Delete && a.Direction == transfer.Upload:
+			marker, style = "✗ remote", lipgloss.NewStyle().Foreground(colorError)
+		case a.Delete:
+			marker, style = "✗ local ", lipgloss.NewStyle().Foreground(colorError)
+		case a.Direction == transfer.Upload:
+			marker, style = "↑ up    ", lipgloss.NewStyle().Foreground(colorSuccess)
+		default:
+			marker, style = "↓ down  ", lipgloss.NewStyle().Foreground(colorPrimary)
+		}
+		name := a.Path
+		if a.IsDir {
+			name += "/"
+		}
+		size := ""
+		if !a.Delete && !a.IsDir {
+			size = " " + formatSize(a.Size)
+		}
+		rows = append(rows, style.Render(marker)+"  "+name+reasonStyle.Render(fmt.Sprintf("  (%s%s)", a.Reason, size)))
+	}
+	conflictStyle := lipgloss.NewStyle().Foreground(colorWarning)
+	for _, p := range v.plan.Conflicts {
+		rows = append(rows, conflictStyle.Render("! skip  ")+"  "+p+reasonStyle.Render("  (changed on both sides or file vs directory)"))
+	}
+	v.viewport.SetContent(strings.Join(rows, "\n"))
+}
+
+func (v *SyncPlanView) Init() tea.Cmd {
+	return nil
+}
+
+func (v *SyncPlanView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if v.confirmed || v.canceled || v.rescan {
+		return v, nil
+	}
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		v.SetSize(msg.Width, msg.Height)
+		return v, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter", "y":
+			if len(v.plan.Actions) > 0 {
+				v.confirmed = true
+			}
+			return v, nil
+		case "esc", "n", "q":
+			v.canceled = true
+			return v, nil
+		case "m":
+			v.opts.Mode = (v.opts.Mode + 1) % 3
+			v.replan()
+			return v, nil
+		case "x":
+			v.opts.Delete = !v.opts.Delete
+			v.replan()
+			return v, nil
+		case "c":
+			v.checksum = !v.checksum
+			v.rescan = true
+			return v, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	v.viewport, cmd = v.viewport.Update(msg)
+	return v, cmd
+}
+
+func (v *SyncPlanView) View() string {
+	uploads, downloads, deletes, bytes := v.plan.Counts()
+	summary := fmt.Sprintf("%d uploads, %d downloads, %d deletions, %s to copy", uploads, downloads, deletes, formatSize(bytes))
+	if n := len(v.plan.Conflicts); n > 0 {
+		summary += fmt.Sprintf(", %d skipped", n)
+	}
+	onOff := func(on bool) string {
+		if on {
+			return "on"
+		}
+		return "off"
+	}
+	deletions := onOff(v.opts.Delete)
+	if v.opts.Mode == transfer.TwoWay {
+		deletions = "never both ways"
+	}
+	subText := lipgloss.NewStyle().Foreground(colorSubText)
+	header := lipgloss.JoinVertical(lipgloss.Left,
+		sectionTitleStyle.Render("Sync — "+v.opts.Mode.String()),
+		subText.Render(fmt.Sprintf("local  %s\nremote %s", v.localRoot, v.remoteRoot)),
+		subText.Render(fmt.Sprintf("delete extras: %s | compare checksums: %s", deletions, onOff(v.checksum))),
+		"",
+		lipgloss.NewStyle().Bold(true).Render(summary),
+	)
+	hint := subText.Render("enter: run | m: mode | x: delete extras | c: checksums | ↑/↓: scroll | esc: cancel")
+
+	return scpActivePanelStyle.
+		Width(max(v.width-2, 20)).
+		Render(lipgloss.JoinVertical(lipgloss.Left, header, "", v.viewport.View(), "", hint))
+}
+
+// SetSize sizes the view to fill width x height, border included
+func (v *SyncPlanView) SetSize(width, height int) {
+	v.width = width
+	v.height = height
+	// Border and padding take 4 columns and 4 rows; header, hint and spacing 9 rows
+	v.viewport.Width = max(width-8, 10)
+	v.viewport.Height = max(height-13, 1)
+}
+
+// Plan returns the plan for the chosen options
+func (v *SyncPlanView) Plan() transfer.SyncPlan {
+	return v.plan
+}
+
+// Options returns the chosen mode and deletion setting
+func (v *SyncPlanView) Options() transfer.SyncOptions {
+	return v.opts
+}
+
+// Checksum reports whether files are to be compared by checksum
+func (v *SyncPlanView) Checksum() bool {
+	return v.checksum
+}
+
+func (v *SyncPlanView) IsConfirmed() bool {
+	return v.confirmed
+}
+
+func (v *SyncPlanView) IsCanceled() bool {
+	return v.canceled
+}
+
+// NeedsRescan reports whether checksums were toggled, so the trees must be
+// scanned again with the new setting
+func (v *SyncPlanView) NeedsRescan() bool {
+	return v.rescan
+}
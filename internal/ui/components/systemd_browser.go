@@ -0,0 +1,471 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+const (
+	journalLines         = 500
+	journalFollowRefresh = 2 * time.Second
+)
+
+// SystemdBrowser message types
+type (
+	SystemdUnitsMsg struct {
+		Units []ssh.SystemdUnit
+		Err   error
+	}
+
+	SystemdActionMsg struct {
+		Action string
+		Unit   string
+		Err    error
+	}
+
+	SystemdJournalMsg struct {
+		Unit   string
+		Output string
+		Err    error
+	}
+
+	systemdJournalTickMsg struct {
+		Unit string
+	}
+)
+
+// SystemdBrowser lists remote systemd services and controls them
+type SystemdBrowser struct {
+	connection    config.SSHConnection
+	client        *ssh.Client
+	units         []ssh.SystemdUnit
+	visible       []ssh.SystemdUnit
+	selectedIdx   int
+	scrollOffset  int
+	searching     bool
+	query         string
+	pendingAction string
+	pendingUnit   string
+	journalUnit   string // non-empty while the journal view is open
+	journal       []string
+	journalScroll int // lines scrolled up from the bottom
+	following     bool
+	status        string
+	error         string
+	loading       bool
+	finished      bool
+	width         int
+	height        int
+}
+
+// NewSystemdBrowser creates a new systemd service browser component
+func NewSystemdBrowser(conn config.SSHConnection) *SystemdBrowser {
+	return &SystemdBrowser{
+		connection: conn,
+		status:     connectingStatus(conn),
+		loading:    true,
+	}
+}
+
+func (b *SystemdBrowser) Init() tea.Cmd {
+	return connectSSHClient(b.connection)
+}
+
+func (b *SystemdBrowser) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		b.SetSize(msg.Width, msg.Height)
+		return b, nil
+
+	case SSHClientMsg:
+		if msg.Err != nil {
+			b.loading = false
+			b.error = fmt.Sprintf("Failed to connect: %s", msg.Err)
+			b.status = "Connection failed"
+			return b, nil
+		}
+		b.client = msg.Client
+		b.status = "Loading units..."
+		return b, b.refresh()
+
+	case SSHPassphraseRequiredMsg:
+		return b, func() tea.Msg { return msg }
+
+	case SSHPasswordRequiredMsg:
+		return b, func() tea.Msg { return msg }
+
+	case SystemdUnitsMsg:
+		b.loading = false
+		if msg.Err != nil {
+			b.error = fmt.Sprintf("Failed to list units: %s", msg.Err)
+			return b, nil
+		}
+		b.units = msg.Units
+		b.applyFilter()
+		b.status = fmt.Sprintf("%d service units", len(b.units))
+		return b, nil
+
+	case SystemdActionMsg:
+		if msg.Err != nil {
+			b.error = fmt.Sprintf("%s %s failed: %s", msg.Action, msg.Unit, msg.Err)
+			return b, nil
+		}
+		b.status = fmt.Sprintf("%s %s completed successfully", msg.Action, msg.Unit)
+		return b, b.refresh()
+
+	case SystemdJournalMsg:
+		if msg.Unit != b.journalUnit {
+			return b, nil // Journal view was closed or switched
+		}
+		if msg.Err != nil {
+			b.error = fmt.Sprintf("Failed to read journal: %s", msg.Err)
+		} else {
+			b.journal = strings.Split(strings.TrimRight(msg.Output, "\n"), "\n")
+		}
+		if b.following {
+			unit := msg.Unit
+			return b, tea.Tick(journalFollowRefresh, func(time.Time) tea.Msg {
+				return systemdJournalTickMsg{Unit: unit}
+			})
+		}
+		return b, nil
+
+	case systemdJournalTickMsg:
+		if msg.Unit == b.journalUnit && b.following {
+			return b, b.loadJournal(msg.Unit)
+		}
+		return b, nil
+
+	case tea.KeyMsg:
+		if b.journalUnit != "" {
+			return b.handleJournalKey(msg)
+		}
+		return b.handleKey(msg)
+	}
+	return b, nil
+}
+
+func (b *SystemdBrowser) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if b.pendingAction != "" {
+		switch msg.String() {
+		case "y", "Y":
+			action, unit := b.pendingAction, b.pendingUnit
+			b.pendingAction, b.pendingUnit = "", ""
+			b.status = fmt.Sprintf("Running systemctl %s %s...", action, unit)
+			return b, b.runAction(action, unit)
+		case "n", "N", "esc":
+			b.pendingAction, b.pendingUnit = "", ""
+			b.status = "Cancelled"
+		}
+		return b, nil
+	}
+
+	if b.searching {
+		switch msg.Type {
+		case tea.KeyEnter:
+			b.searching = false
+		case tea.KeyEsc:
+			b.searching = false
+			b.query = ""
+			b.applyFilter()
+		case tea.KeyBackspace:
+			if len(b.query) > 0 {
+				b.query = b.query[:len(b.query)-1]
+				b.applyFilter()
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			b.query += string(msg.Runes)
+			b.applyFilter()
+		}
+		return b, nil
+	}
+
+	switch msg.String() {
+	case "esc", "q":
+		if b.query != "" && msg.String() == "esc" {
+			b.query = ""
+			b.applyFilter()
+			return b, nil
+		}
+		b.finished = true
+		if b.client != nil {
+			b.client.Close()
+		}
+		return b, nil
+	case "up", "k":
+		if b.selectedIdx > 0 {
+			b.selectedIdx--
+		}
+	case "down", "j":
+		if b.selectedIdx < len(b.visible)-1 {
+			b.selectedIdx++
+		}
+	case "pgup":
+		b.selectedIdx = max(b.selectedIdx-b.listHeight(), 0)
+	case "pgdown":
+		b.selectedIdx = max(min(b.selectedIdx+b.listHeight(), len(b.visible)-1), 0)
+	case "/":
+		b.searching = true
+	case "r", "ctrl+l":
+		if b.client != nil {
+			b.status = "Refreshing..."
+			return b, b.refresh()
+		}
+	case "s":
+		b.confirmAction("start")
+	case "S":
+		b.confirmAction("stop")
+	case "R":
+		b.confirmAction("restart")
+	case "e":
+		b.confirmAction("enable")
+	case "E":
+		b.confirmAction("disable")
+	case "enter", "l":
+		if unit := b.selectedUnit(); unit != nil && b.client != nil {
+			b.journalUnit = unit.Unit
+			b.journal = nil
+			b.journalScroll = 0
+			b.following = true
+			return b, b.loadJournal(unit.Unit)
+		}
+	}
+	return b, nil
+}
+
+func (b *SystemdBrowser) handleJournalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	maxScroll := max(len(b.journal)-b.listHeight(), 0)
+	switch msg.String() {
+	case "esc", "q":
+		b.journalUnit = ""
+		b.journal = nil
+		b.following = false
+	case "up", "k":
+		b.journalScroll = min(b.journalScroll+1, maxScroll)
+		b.following = false
+	case "down", "j":
+		b.journalScroll = max(b.journalScroll-1, 0)
+	case "pgup":
+		b.journalScroll = min(b.journalScroll+b.listHeight(), maxScroll)
+		b.following = false
+	case "pgdown":
+		b.journalScroll = max(b.journalScroll-b.listHeight(), 0)
+	case "f", "end", "G":
+		// Jump to the end and resume following
+		b.journalScroll = 0
+		if !b.following {
+			b.following = true
+			return b, b.loadJournal(b.journalUnit)
+		}
+	}
+	return b, nil
+}
+
+func (b *SystemdBrowser) selectedUnit() *ssh.SystemdUnit {
+	if b.selectedIdx < 0 || b.selectedIdx >= len(b.visible) {
+		return nil
+	}
+	return &b.visible[b.selectedIdx]
+}
+
+func (b *SystemdBrowser) confirmAction(action string) {
+	if unit := b.selectedUnit(); unit != nil {
+		b.pendingAction = action
+		b.pendingUnit = unit.Unit
+	}
+}
+
+func (b *SystemdBrowser) applyFilter() {
+	query := strings.ToLower(b.query)
+	b.visible = b.visible[:0]
+	for _, u := range b.units {
+		if query == "" ||
+			strings.Contains(strings.ToLower(u.Unit), query) ||
+			strings.Contains(strings.ToLower(u.Description), query) ||
+			strings.Contains(u.Active, query) {
+			b.visible = append(b.visible, u)
+		}
+	}
+	if b.selectedIdx >= len(b.visible) {
+		b.selectedIdx = max(len(b.visible)-1, 0)
+	}
+}
+
+func (b *SystemdBrowser) refresh() tea.Cmd {
+	client := b.client
+	return func() tea.Msg {
+		units, err := client.ListSystemdUnits()
+		return SystemdUnitsMsg{Units: units, Err: err}
+	}
+}
+
+func (b *SystemdBrowser) runAction(action, unit string) tea.Cmd {
+	client, conn := b.client, b.connection
+	return func() tea.Msg {
+		return SystemdActionMsg{Action: action, Unit: unit, Err: client.SystemctlAction(action, unit, conn)}
+	}
+}
+
+func (b *SystemdBrowser) loadJournal(unit string) tea.Cmd {
+	client := b.client
+	return func() tea.Msg {
+		out, err := client.UnitJournal(unit, journalLines)
+		return SystemdJournalMsg{Unit: unit, Output: out, Err: err}
+	}
+}
+
+func (b *SystemdBrowser) listHeight() int {
+	// header + column header + status line
+	return max(b.height-3, 1)
+}
+
+func (b *SystemdBrowser) View() string {
+	if b.finished {
+		return ""
+	}
+
+	title := "Services"
+	if b.journalUnit != "" {
+		title = "Journal: " + b.journalUnit
+		if b.following {
+			title += " (following)"
+		}
+	}
+	headerText := fmt.Sprintf(
+		"%s@%s:%d - %s - %s",
+		b.connection.Username, b.connection.Host, b.connection.Port, b.connection.Name, title,
+	)
+	header := scpHeaderStyle.Width(b.width).Render(headerText)
+
+	var body string
+	switch {
+	case b.loading:
+		body = lipgloss.NewStyle().
+			Width(b.width).
+			Height(b.listHeight()+1).
+			Align(lipgloss.Center, lipgloss.Center).
+			Render(b.status)
+	case b.journalUnit != "":
+		body = b.renderJournal()
+	default:
+		body = b.renderUnits()
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, body, b.renderStatus())
+}
+
+// unitStateStyle colors a unit by its active state
+func unitStateStyle(active string) lipgloss.Style {
+	switch active {
+	case "active":
+		return lipgloss.NewStyle().Foreground(colorSuccess)
+	case "failed":
+		return lipgloss.NewStyle().Foreground(colorError).Bold(true)
+	case "activating", "deactivating", "reloading":
+		return lipgloss.NewStyle().Foreground(colorWarning)
+	default:
+		return lipgloss.NewStyle().Foreground(colorSubText)
+	}
+}
+
+func (b *SystemdBrowser) renderUnits() string {
+	height := b.listHeight()
+	if b.selectedIdx < b.scrollOffset {
+		b.scrollOffset = b.selectedIdx
+	}
+	if b.selectedIdx >= b.scrollOffset+height {
+		b.scrollOffset = b.selectedIdx - height + 1
+	}
+
+	unitWidth := 40
+	descWidth := max(b.width-unitWidth-32, 10)
+	format := "%-*s %-10s %-10s %-10s %s"
+
+	lines := []string{headerStyle.Render(fmt.Sprintf(format, unitWidth, "UNIT", "LOAD", "ACTIVE", "SUB", "DESCRIPTION"))}
+	end := min(b.scrollOffset+height, len(b.visible))
+	for i := b.scrollOffset; i < end; i++ {
+		u := b.visible[i]
+		line := fmt.Sprintf(format, unitWidth,
+			truncate(u.Unit, unitWidth+1), u.Load, u.Active, u.Sub, truncate(u.Description, descWidth+1))
+		if i == b.selectedIdx {
+			line = scpSelectedStyle.Width(b.width).Render(line)
+		} else {
+			line = unitStateStyle(u.Active).Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(b.visible) == 0 {
+		lines = append(lines, "  (no matching units)")
+	}
+	for len(lines) < height+1 {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (b *SystemdBrowser) renderJournal() string {
+	height := b.listHeight() + 1
+	end := max(len(b.journal)-b.journalScroll, 0)
+	start := max(end-height, 0)
+
+	lines := make([]string, 0, height)
+	for _, line := range b.journal[start:end] {
+		lines = append(lines, truncate(line, b.width+1))
+	}
+	if len(b.journal) == 0 {
+		lines = append(lines, "  (loading journal...)")
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (b *SystemdBrowser) renderStatus() string {
+	containerStyle := scpStatusStyle.Width(b.width).Align(lipgloss.Center)
+	errStyle := lipgloss.NewStyle().Foreground(colorError).Bold(true)
+	successStyle := lipgloss.NewStyle().Foreground(colorSuccess).Bold(true)
+
+	switch {
+	case b.error != "":
+		text := errStyle.Render(b.error)
+		b.error = ""
+		return containerStyle.Render(text)
+	case b.pendingAction != "":
+		return containerStyle.Render(errStyle.Render(fmt.Sprintf("systemctl %s %s? (y/n)", b.pendingAction, b.pendingUnit)))
+	case b.searching:
+		return containerStyle.Render("Search: " + b.query)
+	case b.journalUnit != "":
+		return containerStyle.Render(fmt.Sprintf("%d lines", len(b.journal)))
+	case b.query != "":
+		return containerStyle.Render(fmt.Sprintf("%s | filter: %q (%d matches)", b.status, b.query, len(b.visible)))
+	case strings.Contains(b.status, "successfully"):
+		return containerStyle.Render(successStyle.Render(b.status))
+	}
+	return containerStyle.Render(b.status)
+}
+
+func (b *SystemdBrowser) SetSize(width, height int) {
+	b.width = width
+	b.height = height
+}
+
+func (b *SystemdBrowser) IsFinished() bool {
+	return b.finished
+}
+
+// IsTyping reports whether keys go to the search input
+func (b *SystemdBrowser) IsTyping() bool {
+	return b.searching
+}
+
+// IsShowingJournal reports whether the journal view is open
+func (b *SystemdBrowser) IsShowingJournal() bool {
+	return b.journalUnit != ""
+}
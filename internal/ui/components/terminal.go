@@ -4,18 +4,25 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
+	"net/url"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/hooks"
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/zmodem"
 )
 
 // SSHOutputMsg contains output from the SSH session
 type SSHOutputMsg struct {
-	Data []byte
+	Data    []byte
+	Skipped int64 // Bytes dropped by a skip that just ended
 }
 
 // SSHErrorMsg contains an error from the SSH session
@@ -34,6 +41,44 @@ type SSHPasswordRequiredMsg struct {
 	Connection config.SSHConnection
 }
 
+// onConnectTickMsg fires a moment after session output; if nothing was
+// printed since, the screen has settled and may show a prompt
+type onConnectTickMsg struct {
+	seq int
+}
+
+// onConnectSettleDelay is how long output must pause before the cursor line
+// is checked for a shell prompt
+const onConnectSettleDelay = 300 * time.Millisecond
+
+// hostStatsTickMsg asks for the next sample of the host's resource usage.
+// It carries the session it was scheduled for, so a tick outliving its
+// terminal or a toggled off monitor is ignored.
+type hostStatsTickMsg struct {
+	session *ssh.BubbleTeaSession
+	seq     int
+}
+
+// hostStatsMsg carries a sample of the host's resource usage
+type hostStatsMsg struct {
+	session *ssh.BubbleTeaSession
+	seq     int
+	stats   *ssh.HostStats
+	err     error
+}
+
+// multiClickInterval is how soon a click has to follow the previous one
+// at the same spot to count as a double or triple click
+const multiClickInterval = 400 * time.Millisecond
+
+// defaultHostStatsInterval is used when alt+m turns the monitor on while
+// the settings leave it off
+const defaultHostStatsInterval = 5 * time.Second
+
+// outputBehindThreshold is how much output has to wait for the terminal
+// before the header offers to skip it
+const outputBehindThreshold = 256 * 1024
+
 // SSHSessionMsg is a message containing an SSH session
 type SSHSessionMsg struct {
 	Session *ssh.BubbleTeaSession
@@ -44,6 +89,10 @@ type SSHSessionMsg struct {
 type TerminalComponent struct {
 	connection     config.SSHConnection
 	session        *ssh.BubbleTeaSession
+	pty            terminalIO // The session, or the local shell, that output comes from and keys go to
+	local          bool       // Runs the local shell at shellPath instead of an SSH session
+	shellPath      string
+	output         *outputPump // Batches the session output between renders
 	vterm          *VTerminal
 	status         string
 	error          error
@@ -56,17 +105,74 @@ type TerminalComponent struct {
 	sessionStarted bool      // Track if session has been initiated
 	lastEscTime    time.Time // Track when ESC was last pressed
 	escPressCount  int       // Track number of ESC presses
-	escTimeoutSecs float64   // Timeout window for double ESC (default 2 seconds)
+	escTimeoutSecs float64   // Timeout window for double ESC (from the settings)
+	linkPicker     []string  // Visible hyperlinks while the link picker is open
+	linkIdx        int
+	linkNotice     string    // Shown in the header after trying to open a link
+	outputNotice   string    // Shown in the header after output was skipped
+	zmodemStatus   string    // Progress or outcome of a ZMODEM transfer for the header
+	pendingPaste   string    // Multi-line paste waiting for confirmation
+	lastClick      time.Time // Counts clicks in a row to select words and lines
+	lastClickPos   position
+	clickCount     int
+	snippets       *SnippetPicker
+	history        *config.ScrollbackStore // Keeps the scrollback for the next session, nil when off
+	historySaved   bool
+	previous       []string // Output of the session ended before a reconnect
+
+	// How the remote ended the session, nil while it runs
+	ended         *ssh.SessionExit
+	viewingOutput bool // The last output is shown instead of the end screen
+
+	// The file manager sharing the screen and the session's connection
+	files        *SCPManager
+	filesFocused bool
+	filesBelow   bool // Stacked under the shell instead of beside it
+
+	// Files the remote sends with ZMODEM
+	zmodem      *zmodem.Receiver // Receiving a transfer, nil otherwise
+	zmodemOffer []byte           // Output from the start of a transfer not accepted yet
+	zmodemHeld  []byte           // End of the output that may be a cut transfer start
+
+	// On-connect commands are typed one at a time, each at the next prompt
+	onConnect        []string // Commands not typed yet
+	onConnectRan     int
+	onConnectConfirm bool // Asking before typing the first command
+	onConnectSkipped bool
+	outputSeq        int // Counts output, so a stale tick can be ignored
+
+	// The resource monitor samples the host over a second channel
+	statsEvery time.Duration // 0 while the monitor is off
+	statsSeq   int           // Bumped on every toggle to drop stale samples
+	hostStats  *ssh.HostStats
+	statsError string
+
+	// Idle and session time limits, counted from the last key and from
+	// connecting
+	lastInput       time.Time
+	sessionDeadline time.Time // Zero without a session time limit
+	timedOut        string    // Why a limit disconnected the session, for the end screen
 }
 
 // NewTerminalComponent creates a new terminal component
 func NewTerminalComponent(conn config.SSHConnection) *TerminalComponent {
-	return &TerminalComponent{
+	settings := config.CurrentSettings()
+	t := &TerminalComponent{
 		connection:     conn,
-		status:         "Connecting...",
+		onConnect:      conn.OnConnect,
+		status:         connectingStatus(conn),
 		loading:        true,
-		escTimeoutSecs: 2.0, // Default 2 second timeout for double ESC
+		escTimeoutSecs: settings.EscTimeoutSecs(),
 	}
+	// Unsaved connections have no ID to keep their scrollback under
+	if settings.PersistScrollback && conn.ID != "" {
+		store, err := config.NewScrollbackStore(int64(settings.PersistScrollbackMB) << 20)
+		if err != nil {
+			log.Printf("Failed to open the scrollback store: %v", err)
+		}
+		t.history = store
+	}
+	return t
 }
 
 // Init initializes the component
@@ -86,6 +192,9 @@ func (t *TerminalComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// If session not started yet, start it now with proper dimensions
 		if !t.sessionStarted && !t.finished && t.error == nil {
 			t.sessionStarted = true
+			if t.local {
+				return t, t.startLocalShell(t.width, t.height)
+			}
 			return t, t.startSession(t.connection, t.width, t.height)
 		}
 
@@ -102,9 +211,21 @@ func (t *TerminalComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		t.session = msg.Session
 		t.status = "Connected"
+		hooks.Fire(hooks.Event{
+			Kind:       hooks.Connected,
+			Connection: t.connection,
+			Message:    fmt.Sprintf("connected to %s@%s:%d", t.connection.Username, t.connection.Host, t.connection.Port),
+		})
 
 		t.createAndStartVTerminal()
-		return t, t.listenForSSHOutput()
+		if seconds := config.CurrentSettings().ResourceMonitorSeconds; seconds > 0 {
+			t.statsEvery = time.Duration(seconds) * time.Second
+			return t, tea.Batch(t.listenForSSHOutput(), t.sampleHostStats(), t.startTimeouts())
+		}
+		return t, tea.Batch(t.listenForSSHOutput(), t.startTimeouts())
+
+	case localShellMsg:
+		return t, t.handleLocalShell(msg)
 
 	case SSHPassphraseRequiredMsg:
 		return t, func() tea.Msg {
@@ -117,15 +238,62 @@ func (t *TerminalComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case SSHOutputMsg:
-		if len(msg.Data) > 0 {
-			t.writeToVTerminal(msg.Data)
+		zmodemCmd := t.handleOutput(msg.Data)
+		if msg.Skipped > 0 {
+			t.outputNotice = "skipped " + formatSize(msg.Skipped)
+		}
+		if len(t.onConnect) > 0 && !t.onConnectConfirm {
+			t.outputSeq++
+			seq := t.outputSeq
+			return t, tea.Batch(t.listenForSSHOutput(), zmodemCmd, tea.Tick(onConnectSettleDelay, func(time.Time) tea.Msg {
+				return onConnectTickMsg{seq: seq}
+			}))
+		}
+		return t, tea.Batch(t.listenForSSHOutput(), zmodemCmd) // Continue listening
+
+	case zmodemProgressMsg:
+		return t, t.handleZmodemProgress(msg)
+
+	case zmodemDoneMsg:
+		return t, t.handleZmodemDone(msg)
+
+	case sessionTimeoutTickMsg:
+		return t, t.handleTimeoutTick(msg)
+
+	case onConnectTickMsg:
+		t.checkOnConnectPrompt(msg.seq)
+		return t, nil
+
+	case hostStatsTickMsg:
+		if msg.session != t.session || msg.seq != t.statsSeq || t.statsEvery == 0 || t.IsSessionClosed() {
+			return t, nil
 		}
-		return t, t.listenForSSHOutput() // Continue listening
+		return t, t.sampleHostStats()
+
+	case hostStatsMsg:
+		if msg.session != t.session || msg.seq != t.statsSeq || t.statsEvery == 0 {
+			return t, nil
+		}
+		if msg.err != nil {
+			// Not a Linux host or no exec channels allowed, retrying won't help
+			t.statsEvery = 0
+			t.hostStats = nil
+			t.statsError = "monitor: " + msg.err.Error()
+			return t, nil
+		}
+		t.hostStats = msg.stats
+		return t, tea.Tick(t.statsEvery, func(time.Time) tea.Msg {
+			return hostStatsTickMsg{session: msg.session, seq: msg.seq}
+		})
 
 	case SSHErrorMsg:
 		t.handleSessionError(msg.Err)
 		return t, nil
 
+	case sessionEndedMsg:
+		t.handleSessionEnd(msg)
+		return t, nil
+
 	case tea.KeyMsg:
 		return t.handleKey(msg)
 
@@ -134,7 +302,17 @@ func (t *TerminalComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return t, nil
 	}
 
-	return t, nil
+	// Anything else is for the file manager, or lets the snippet picker's
+	// text inputs blink
+	var cmds []tea.Cmd
+	if t.files != nil {
+		cmds = append(cmds, t.updateFiles(msg))
+	}
+	if t.snippets != nil {
+		_, cmd := t.updateSnippets(msg)
+		cmds = append(cmds, cmd)
+	}
+	return t, tea.Batch(cmds...)
 }
 
 // View renders the component.
@@ -143,8 +321,16 @@ func (t *TerminalComponent) View() string {
 		return ""
 	}
 
+	if t.local && (t.loading || t.error != nil) {
+		return t.localStatusView()
+	}
+
 	if t.loading {
-		return fmt.Sprintf("\nConnecting to %s@%s:%d...\n", t.connection.Username, t.connection.Host, t.connection.Port)
+		connecting := fmt.Sprintf("\nConnecting to %s@%s:%d...\n", t.connection.Username, t.connection.Host, t.connection.Port)
+		if ssh.UsesSecurityKey(t.connection) {
+			connecting += "\n" + securityKeyStyle.Render("🔑 "+ssh.SecurityKeyNotice) + "\n"
+		}
+		return connecting
 	}
 
 	if t.error != nil {
@@ -160,19 +346,92 @@ func (t *TerminalComponent) View() string {
 		"SSH: %s@%s:%d - %s",
 		t.connection.Username, t.connection.Host, t.connection.Port, t.connection.Name,
 	)
+	if t.local {
+		headerText = "Local: " + t.connection.Name
+	}
 
 	// Include scroll indicator if applicable
 	if t.vterm != nil && t.vterm.IsScrolledBack() {
 		headerText += " [SCROLL]"
 	}
 
-	header := terminalHeaderStyle.Width(t.width).Render(headerText)
+	if status := t.onConnectStatus(); status != "" {
+		headerText += " [" + status + "]"
+	}
+
+	if t.linkNotice != "" {
+		headerText += " [" + t.linkNotice + "]"
+	}
+
+	if t.zmodemStatus != "" {
+		headerText += " [" + t.zmodemStatus + "]"
+	}
+
+	if status := t.outputStatus(); status != "" {
+		headerText += " [" + status + "]"
+	}
+
+	if stats := t.hostStatsStatus(); stats != "" {
+		headerText += " [" + stats + "]"
+	}
+
+	timeout := t.timeoutStatus()
+	if timeout != "" {
+		headerText += " [" + timeout + "]"
+	}
+
+	if t.ended != nil && t.viewingOutput {
+		if t.local {
+			headerText += " [" + t.endTitle() + ", R to restart]"
+		} else {
+			headerText += " [" + t.endTitle() + ", R to reconnect]"
+		}
+	}
+
+	if t.FilesFocused() {
+		headerText += " [files, Alt+W for the shell]"
+	} else if t.files != nil {
+		headerText += " [shell, Alt+W for the files]"
+	}
+
+	// Production and staging sessions keep a red or warning header
+	headerStyle := terminalHeaderStyle
+	switch t.connection.DangerLevel {
+	case config.DangerProduction:
+		headerText = "PRODUCTION " + headerText
+		headerStyle = headerStyle.Background(colorError)
+	case config.DangerStaging:
+		headerText = "STAGING " + headerText
+		headerStyle = headerStyle.Background(colorWarning)
+	default:
+		if timeout != "" {
+			headerStyle = headerStyle.Background(colorWarning)
+		}
+	}
+
+	// The content below is sized for a one-line header
+	header := headerStyle.Width(t.width).MaxHeight(1).Render(headerText)
 
 	// Get terminal content
 	content := ""
-	if t.vterm != nil {
+	if t.ended != nil && !t.viewingOutput {
+		content = t.renderEndScreen()
+	} else if t.zmodemOffer != nil {
+		content = t.renderZmodemOffer()
+	} else if t.snippets != nil {
+		content = t.snippets.View()
+	} else if t.onConnectConfirm {
+		content = t.renderOnConnectConfirmation()
+	} else if t.pendingPaste != "" {
+		content = t.renderPasteConfirmation()
+	} else if t.linkPicker != nil {
+		content = t.renderLinkPicker()
+	} else if t.vterm != nil {
 		content = t.vterm.Render()
 	}
+	if t.files != nil {
+		content = t.splitView(content)
+	}
 
 	// Combine header and content (footer is now handled by main view)
 	return lipgloss.JoinVertical(lipgloss.Left, header, content)
@@ -181,19 +440,34 @@ func (t *TerminalComponent) View() string {
 // Utility: Calculate content height
 func (t *TerminalComponent) contentHeight() int {
 	// Terminal now receives the content area size directly from the main view
-	// We only need to subtract the terminal's header (1 line)
+	// We only need to subtract the terminal's header (1 line) and the file
+	// manager when it shares the screen
 	// Footer is now handled by the main view
-	return t.height - 1
+	_, height, _, _ := t.paneSizes()
+	return height
+}
+
+// Utility: Calculate the width of the shell, less the file manager when
+// it shares the screen
+func (t *TerminalComponent) termWidth() int {
+	width, _, _, _ := t.paneSizes()
+	return width
 }
 
 // Utility: Resize terminal components dynamically
 func (t *TerminalComponent) resizeTerminal() {
-	contentHeight := t.contentHeight()
+	width, contentHeight, filesWidth, filesHeight := t.paneSizes()
+	if t.snippets != nil {
+		t.snippets.SetSize(width, contentHeight)
+	}
 	if t.vterm != nil {
-		t.vterm.Resize(t.width, contentHeight)
+		t.vterm.Resize(width, contentHeight)
+	}
+	if t.pty != nil {
+		t.pty.Resize(width, contentHeight)
 	}
-	if t.session != nil {
-		t.session.Resize(t.width, contentHeight)
+	if t.files != nil {
+		t.files.Update(tea.WindowSizeMsg{Width: filesWidth, Height: filesHeight})
 	}
 }
 
@@ -230,31 +504,102 @@ func (t *TerminalComponent) startSession(conn config.SSHConnection, width, heigh
 
 // Utility: Create and start the virtual terminal
 func (t *TerminalComponent) createAndStartVTerminal() {
-	t.vterm = NewVTerminal(t.width, t.contentHeight())
+	t.vterm = NewVTerminal(t.termWidth(), t.contentHeight())
+	t.restoreHistory()
+	t.pty = t.session
+	t.output = newOutputPump(t.pty)
 	if err := t.session.Start(); err != nil {
 		t.error = err
 		t.status = fmt.Sprintf("Error: %s", err)
 	}
 }
 
-// Utility: Continuously listen for SSH output
+// Utility: Put the output of the session before a reconnect, or else the
+// scrollback saved by the connection's earlier sessions, above the output
+// of this one
+func (t *TerminalComponent) restoreHistory() {
+	if t.previous != nil {
+		t.vterm.RestoreHistory(append(t.previous, "──── reconnected ────"))
+		t.previous = nil
+		return
+	}
+	if t.history == nil {
+		return
+	}
+	saved, err := t.history.Load(t.connection.ID)
+	if err != nil {
+		log.Printf("Failed to load the scrollback of %s: %v", t.connection.ID, err)
+		return
+	}
+	if saved == nil || len(saved.Lines) == 0 {
+		return
+	}
+	marker := fmt.Sprintf("──── previous session, saved %s ────", saved.Saved.Local().Format("2006-01-02 15:04"))
+	t.vterm.RestoreHistory(append(saved.Lines, marker))
+}
+
+// Utility: Save the scrollback for the connection's next session, once
+// the session ends
+func (t *TerminalComponent) saveHistory() {
+	if t.history == nil || t.historySaved || t.vterm == nil {
+		return
+	}
+	t.historySaved = true
+	if err := t.history.Save(t.connection.ID, t.vterm.History()); err != nil {
+		log.Printf("Failed to save the scrollback of %s: %v", t.connection.ID, err)
+	}
+}
+
+// Utility: Continuously listen for SSH output, taking everything that
+// arrived since the last message at once
 func (t *TerminalComponent) listenForSSHOutput() tea.Cmd {
+	output, pty := t.output, t.pty
 	return func() tea.Msg {
-		buf := make([]byte, 32*1024)
-		n, err := t.session.Read(buf)
+		batch, err := output.Next()
 		if err != nil {
 			if err == io.EOF {
-				return SSHErrorMsg{fmt.Errorf("session closed")}
+				return sessionEndedMsg{pty: pty, exit: pty.ExitStatus()}
 			}
-			return SSHErrorMsg{err}
+			return sessionEndedMsg{pty: pty, exit: ssh.SessionExit{Err: err}}
 		}
-		return SSHOutputMsg{Data: buf[:n]}
+		return SSHOutputMsg{Data: batch.Data, Skipped: batch.Skipped}
+	}
+}
+
+// Utility: Drop the output the terminal is behind on and jump to the end
+// of it once the remote pauses
+func (t *TerminalComponent) skipOutput() {
+	if t.output == nil || t.IsSessionClosed() {
+		return
 	}
+	t.output.Skip()
+	t.vterm.AbortSequence()
+	t.vterm.ScrollToBottom()
+}
+
+// outputStatus tells in the header that output is being skipped, that
+// the terminal is falling behind, or how much the last skip dropped
+func (t *TerminalComponent) outputStatus() string {
+	if t.output == nil {
+		return ""
+	}
+	if t.output.IsSkipping() {
+		return "skipping output..."
+	}
+	if t.output.Backlog() >= outputBehindThreshold {
+		return "output behind, Alt+E to skip"
+	}
+	return t.outputNotice
 }
 
 // Utility: Write data to virtual terminal
 func (t *TerminalComponent) writeToVTerminal(data []byte) {
 	t.vterm.Write(data)
+	// Programs asking for the cursor position or the terminal type wait
+	// for the answer
+	if replies := t.vterm.TakeReplies(); len(replies) > 0 && t.pty != nil {
+		t.pty.Write(replies)
+	}
 	if !t.vterm.IsScrolledBack() {
 		t.vterm.ScrollToBottom()
 	}
@@ -265,6 +610,9 @@ func (t *TerminalComponent) handleSessionError(err error) {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
 	t.sessionClosed = true
+	t.saveHistory()
+	t.closeFiles()
+	t.stopZmodem()
 	if err.Error() != "session closed" {
 		t.error = err
 		t.status = fmt.Sprintf("Error: %s", err)
@@ -273,6 +621,63 @@ func (t *TerminalComponent) handleSessionError(err error) {
 
 // Utility: Handle key input
 func (t *TerminalComponent) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	t.linkNotice = ""
+	t.outputNotice = ""
+	if t.zmodem == nil && t.zmodemOffer == nil {
+		t.zmodemStatus = ""
+	}
+	if t.ended != nil {
+		if handled, cmd := t.handleEndKey(msg.String()); handled {
+			return t, cmd
+		}
+	}
+	if msg.String() == "alt+t" && t.extendSession() {
+		return t, nil
+	}
+	t.lastInput = time.Now()
+	if handled, cmd := t.handleSplitKey(msg.String()); handled {
+		return t, cmd
+	}
+	if t.FilesFocused() {
+		return t, t.updateFiles(msg)
+	}
+	if handled, cmd := t.handleZmodemKey(msg.String()); handled {
+		return t, cmd
+	}
+	if t.snippets != nil {
+		return t.updateSnippets(msg)
+	}
+	if t.onConnectConfirm {
+		switch msg.String() {
+		case "y", "Y", "enter":
+			t.onConnectConfirm = false
+			t.typeOnConnectCommand()
+		case "n", "N", "esc":
+			t.onConnectConfirm = false
+			t.onConnectSkipped = true
+			t.onConnect = nil
+		}
+		return t, nil
+	}
+	if t.pendingPaste != "" {
+		switch msg.String() {
+		case "y", "Y", "enter":
+			t.sendPaste(t.pendingPaste)
+			t.pendingPaste = ""
+		case "n", "N", "esc":
+			t.pendingPaste = ""
+		}
+		return t, nil
+	}
+	if t.linkPicker != nil {
+		t.handleLinkPickerKey(msg)
+		return t, nil
+	}
+	if msg.Paste {
+		t.handlePaste(string(msg.Runes))
+		return t, nil
+	}
+
 	switch msg.String() {
 	case "esc":
 		// Double ESC logic:
@@ -285,10 +690,7 @@ func (t *TerminalComponent) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 		// If session already closed (logout/exit/Ctrl+D), allow single ESC
 		if sessionClosed {
-			t.finished = true
-			if t.session != nil {
-				t.session.Close()
-			}
+			t.closeSession()
 			return t, nil
 		}
 
@@ -298,10 +700,7 @@ func (t *TerminalComponent) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 		// If within timeout window and this is the second ESC, close the session
 		if t.escPressCount > 0 && timeSinceLastEsc <= t.escTimeoutSecs {
-			t.finished = true
-			if t.session != nil {
-				t.session.Close()
-			}
+			t.closeSession()
 			// Reset ESC tracking
 			t.escPressCount = 0
 			t.lastEscTime = time.Time{}
@@ -339,6 +738,35 @@ func (t *TerminalComponent) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		t.vterm.ScrollDown(10) // Handle scrolling down
 		return t, nil
 
+	case "alt+o":
+		// List the links in the selection, or else the ones on screen
+		if t.vterm != nil {
+			links, where := t.vterm.VisibleLinks(), "on screen"
+			if t.vterm.HasSelection() {
+				links, where = t.vterm.SelectedLinks(), "in the selection"
+			}
+			if len(links) > 0 {
+				t.linkPicker = links
+				t.linkIdx = 0
+			} else {
+				t.linkNotice = "no links " + where
+			}
+		}
+		return t, nil
+
+	case "alt+m":
+		return t, t.toggleHostStats()
+
+	case "alt+e":
+		t.skipOutput()
+		return t, nil
+
+	case "alt+s":
+		// Open the command snippet library
+		t.snippets = NewSnippetPicker(t.connection.ID)
+		t.snippets.SetSize(t.termWidth(), t.contentHeight())
+		return t, nil
+
 	case "ctrl+home":
 		// Scroll to top
 		if t.vterm != nil {
@@ -370,6 +798,18 @@ func (t *TerminalComponent) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return t, nil
 }
 
+// Utility: Leave the terminal, saving the scrollback and closing the session
+func (t *TerminalComponent) closeSession() {
+	t.finished = true
+	t.saveHistory()
+	t.closeFiles()
+	t.stopZmodem()
+	if t.pty != nil {
+		t.output.Close()
+		t.pty.Close()
+	}
+}
+
 // Utility: Forward keys to SSH session
 func (t *TerminalComponent) forwardKeyToSession(key string) {
 	var data []byte
@@ -510,13 +950,18 @@ func (t *TerminalComponent) forwardKeyToSession(key string) {
 		// For regular characters, just send them as-is
 		data = []byte(key)
 	}
-	if t.session != nil {
-		t.session.Write(data)
+	if t.pty != nil {
+		t.pty.Write(data)
 	}
 }
 
 // Utility: Handle mouse events
 func (t *TerminalComponent) handleMouse(msg tea.MouseMsg) {
+	// The file manager beside the shell doesn't use the mouse
+	if msg.X >= t.termWidth() {
+		return
+	}
+
 	// Handle mouse wheel scrolling
 	if msg.Button == tea.MouseButtonWheelUp {
 		t.vterm.ScrollUp(3)
@@ -538,17 +983,39 @@ func (t *TerminalComponent) handleMouse(msg tea.MouseMsg) {
 
 	switch msg.Action {
 	case tea.MouseActionPress:
+		if msg.Button == tea.MouseButtonLeft && msg.Ctrl {
+			// Ctrl+click opens the hyperlink under the mouse
+			if uri := t.vterm.LinkAt(msg.X, adjustedY); uri != "" {
+				t.openLink(uri)
+			}
+			return
+		}
 		if msg.Button == tea.MouseButtonLeft {
-			// Start selection
-			t.vterm.StartSelection(msg.X, adjustedY)
+			switch t.countClick(msg.X, adjustedY) {
+			case 2:
+				// Double click selects a word
+				t.vterm.SelectWord(msg.X, adjustedY)
+				t.vterm.CopySelection()
+			case 3:
+				// Triple click selects a line
+				t.vterm.SelectLine(adjustedY)
+				t.vterm.CopySelection()
+			default:
+				if msg.Alt {
+					// Alt+drag selects a rectangle
+					t.vterm.StartBlockSelection(msg.X, adjustedY)
+				} else {
+					t.vterm.StartSelection(msg.X, adjustedY)
+				}
+			}
 		}
 	case tea.MouseActionMotion:
-		if msg.Button == tea.MouseButtonLeft {
+		if msg.Button == tea.MouseButtonLeft && t.clickCount == 1 {
 			// Update selection while dragging
 			t.vterm.UpdateSelection(msg.X, adjustedY)
 		}
 	case tea.MouseActionRelease:
-		if msg.Button == tea.MouseButtonLeft {
+		if msg.Button == tea.MouseButtonLeft && t.clickCount == 1 {
 			// Finalize selection and copy to clipboard
 			t.vterm.UpdateSelection(msg.X, adjustedY)
 			if t.vterm.HasSelection() {
@@ -560,6 +1027,308 @@ func (t *TerminalComponent) handleMouse(msg tea.MouseMsg) {
 	}
 }
 
+// countClick returns how many clicks in a row, up to 3, the click at
+// (x, y) makes
+func (t *TerminalComponent) countClick(x, y int) int {
+	now := time.Now()
+	pos := position{x: x, y: y}
+	if pos == t.lastClickPos && now.Sub(t.lastClick) <= multiClickInterval && t.clickCount < 3 {
+		t.clickCount++
+	} else {
+		t.clickCount = 1
+	}
+	t.lastClick, t.lastClickPos = now, pos
+	return t.clickCount
+}
+
+// updateSnippets forwards input to the snippet picker and sends the chosen
+// snippet to the session
+func (t *TerminalComponent) updateSnippets(msg tea.Msg) (tea.Model, tea.Cmd) {
+	_, cmd := t.snippets.Update(msg)
+	if t.snippets.IsCanceled() {
+		t.snippets = nil
+		return t, nil
+	}
+	if t.snippets.IsDone() {
+		command, run := t.snippets.Command()
+		t.snippets = nil
+		if run {
+			command += "\r"
+		}
+		if t.pty != nil {
+			t.pty.Write([]byte(command))
+		}
+		return t, nil
+	}
+	return t, cmd
+}
+
+// checkOnConnectPrompt types the next on-connect command once output has
+// settled on a shell prompt. The first one is confirmed unless the
+// connection runs them automatically.
+func (t *TerminalComponent) checkOnConnectPrompt(seq int) {
+	if seq != t.outputSeq || len(t.onConnect) == 0 || t.onConnectConfirm || t.vterm == nil {
+		return
+	}
+	if !looksLikePrompt(t.vterm.TextBeforeCursor()) {
+		// Maybe a password or host key question; wait for more output
+		return
+	}
+	if t.onConnectRan == 0 && !t.connection.OnConnectAuto {
+		t.onConnectConfirm = true
+		return
+	}
+	t.typeOnConnectCommand()
+}
+
+// typeOnConnectCommand sends the next on-connect command as if typed
+func (t *TerminalComponent) typeOnConnectCommand() {
+	if len(t.onConnect) == 0 || t.pty == nil {
+		return
+	}
+	command := t.onConnect[0]
+	t.onConnect = t.onConnect[1:]
+	t.onConnectRan++
+	t.pty.Write([]byte(command + "\r"))
+}
+
+// looksLikePrompt reports whether the text before the cursor ends like a
+// shell prompt, e.g. "user@host:~$ " or "❯ "
+func looksLikePrompt(text string) bool {
+	text = strings.TrimRight(text, " ")
+	if text == "" {
+		return false
+	}
+	last, _ := utf8.DecodeLastRuneInString(text)
+	return strings.ContainsRune("$#%>❯➜λ»", last)
+}
+
+// onConnectStatus shows the progress of the on-connect commands in the
+// header, so it is clear that they were typed by sxt
+func (t *TerminalComponent) onConnectStatus() string {
+	total := len(t.connection.OnConnect)
+	switch {
+	case total == 0:
+		return ""
+	case t.onConnectSkipped:
+		return "on-connect skipped"
+	case t.onConnectConfirm:
+		return "on-connect waiting"
+	case t.onConnectRan == total:
+		return fmt.Sprintf("⚡ on-connect ran %d", total)
+	case t.onConnectRan > 0:
+		return fmt.Sprintf("⚡ on-connect %d/%d", t.onConnectRan, total)
+	}
+	return ""
+}
+
+// toggleHostStats turns the resource monitor on or off
+func (t *TerminalComponent) toggleHostStats() tea.Cmd {
+	t.statsSeq++
+	t.statsError = ""
+	if t.statsEvery > 0 {
+		t.statsEvery = 0
+		t.hostStats = nil
+		return nil
+	}
+	if t.session == nil || t.IsSessionClosed() {
+		return nil
+	}
+	t.statsEvery = defaultHostStatsInterval
+	if seconds := config.CurrentSettings().ResourceMonitorSeconds; seconds > 0 {
+		t.statsEvery = time.Duration(seconds) * time.Second
+	}
+	return t.sampleHostStats()
+}
+
+// sampleHostStats reads the host's resource usage in the background
+func (t *TerminalComponent) sampleHostStats() tea.Cmd {
+	session, seq, prev := t.session, t.statsSeq, t.hostStats
+	return func() tea.Msg {
+		stats, err := session.Client().HostStats(prev)
+		return hostStatsMsg{session: session, seq: seq, stats: stats, err: err}
+	}
+}
+
+// hostStatsStatus shows the last sample in the header. The CPU usage needs
+// a second sample, so it is blank at first.
+func (t *TerminalComponent) hostStatsStatus() string {
+	if t.statsError != "" {
+		return t.statsError
+	}
+	if t.statsEvery == 0 {
+		return ""
+	}
+	stats := t.hostStats
+	if stats == nil {
+		return "monitor starting"
+	}
+	cpu := "--"
+	if stats.CPU >= 0 {
+		cpu = fmt.Sprintf("%.0f%%", stats.CPU)
+	}
+	text := fmt.Sprintf("CPU %s  MEM %s/%s  LOAD %.2f %.2f %.2f", cpu,
+		formatSize(stats.MemUsed*1024), formatSize(stats.MemTotal*1024), stats.Load1, stats.Load5, stats.Load15)
+	if stats.Disk >= 0 {
+		text += fmt.Sprintf("  DISK %d%%", stats.Disk)
+	}
+	return text
+}
+
+func (t *TerminalComponent) renderOnConnectConfirmation() string {
+	var b strings.Builder
+	b.WriteString(sectionTitleStyle.Render("On-Connect Commands"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("Type these commands into %s?", t.connection.Name))
+	b.WriteString("\n\n")
+	for _, command := range t.onConnect {
+		b.WriteString(blurredStyle.Render("  $ " + truncate(command, 70)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(colorInactive).Render("y/enter: run | n/esc: skip"))
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(80).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	return lipgloss.Place(t.termWidth(), t.contentHeight(), lipgloss.Center, lipgloss.Center, box)
+}
+
+// handlePaste sends pasted text to the session, asking first if it spans
+// several lines since each newline would run a command
+func (t *TerminalComponent) handlePaste(text string) {
+	if text == "" {
+		return
+	}
+	if t.vterm != nil && t.vterm.HasSelection() {
+		t.vterm.ClearSelection()
+	}
+	if strings.ContainsAny(text, "\r\n") {
+		t.pendingPaste = text
+		return
+	}
+	t.sendPaste(text)
+}
+
+// sendPaste writes pasted text to the session, wrapping it in bracketed
+// paste markers when the remote application asked for them
+func (t *TerminalComponent) sendPaste(text string) {
+	if t.pty == nil {
+		return
+	}
+	// Terminals send CR for Enter, and a pasted end marker must not be able
+	// to terminate the bracketed paste early
+	text = strings.ReplaceAll(text, "\r\n", "\r")
+	text = strings.ReplaceAll(text, "\n", "\r")
+	text = strings.ReplaceAll(text, "\x1b[201~", "")
+	if t.vterm != nil && t.vterm.BracketedPaste() {
+		text = "\x1b[200~" + text + "\x1b[201~"
+	}
+	t.pty.Write([]byte(text))
+}
+
+func (t *TerminalComponent) renderPasteConfirmation() string {
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(t.pendingPaste, "\r\n", "\n"), "\r\n"), "\n")
+
+	var b strings.Builder
+	b.WriteString(sectionTitleStyle.Render("Confirm Paste"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("Paste %d lines into the terminal?", len(lines)))
+	b.WriteString("\n\n")
+	for i, line := range lines {
+		if i == 5 {
+			b.WriteString(blurredStyle.Render(fmt.Sprintf("  … %d more", len(lines)-i)))
+			b.WriteString("\n")
+			break
+		}
+		b.WriteString(blurredStyle.Render("  " + truncate(line, 70)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(colorInactive).Render("y/enter: paste | n/esc: cancel"))
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(80).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	return lipgloss.Place(t.termWidth(), t.contentHeight(), lipgloss.Center, lipgloss.Center, box)
+}
+
+// handleLinkPickerKey navigates the hyperlink picker
+func (t *TerminalComponent) handleLinkPickerKey(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "up", "k":
+		if t.linkIdx > 0 {
+			t.linkIdx--
+		}
+	case "down", "j":
+		if t.linkIdx < len(t.linkPicker)-1 {
+			t.linkIdx++
+		}
+	case "enter":
+		t.openLink(t.linkPicker[t.linkIdx])
+		t.linkPicker = nil
+	case "esc", "q", "alt+o":
+		t.linkPicker = nil
+	}
+}
+
+// openLink opens a hyperlink in the local browser. Only web and mail links
+// are opened, since the target comes from the remote host.
+func (t *TerminalComponent) openLink(uri string) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		t.linkNotice = "invalid link"
+		return
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https", "mailto":
+	default:
+		t.linkNotice = fmt.Sprintf("won't open %s links", u.Scheme)
+		return
+	}
+	if err := config.OpenBrowser(uri); err != nil {
+		t.linkNotice = fmt.Sprintf("failed to open link: %s", err)
+		return
+	}
+	t.linkNotice = "opened " + truncate(uri, 40)
+}
+
+func (t *TerminalComponent) renderLinkPicker() string {
+	var b strings.Builder
+	b.WriteString(sectionTitleStyle.Render("Open Link"))
+	b.WriteString("\n\n")
+	for i, link := range t.linkPicker {
+		if i == t.linkIdx {
+			b.WriteString(focusedStyle.Bold(true).Render("> " + truncate(link, 70)))
+		} else {
+			b.WriteString(blurredStyle.Render("  " + truncate(link, 70)))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(colorInactive).Render("enter: open | esc: close"))
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(80).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	return lipgloss.Place(t.termWidth(), t.contentHeight(), lipgloss.Center, lipgloss.Center, box)
+}
+
 // IsFinished returns whether the terminal session is finished
 func (t *TerminalComponent) IsFinished() bool {
 	return t.finished
@@ -570,6 +1339,11 @@ func (t *TerminalComponent) IsSessionClosed() bool {
 	return t.sessionClosed
 }
 
+// IsConnected returns whether the SSH session was ever established
+func (t *TerminalComponent) IsConnected() bool {
+	return t.session != nil
+}
+
 // IsScrolledBack returns whether the terminal is scrolled back in history
 func (t *TerminalComponent) IsScrolledBack() bool {
 	if t.vterm != nil {
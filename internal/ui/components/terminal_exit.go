@@ -0,0 +1,182 @@
+package components
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/hooks"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/metrics"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+// When the remote ends the session, the terminal tells how it ended and
+// offers to reconnect (R), to look at the last output (V) or to return
+// to the list (Esc).
+
+// sessionEndedMsg reports that the output of a session or local shell
+// has ended
+type sessionEndedMsg struct {
+	pty  terminalIO
+	exit ssh.SessionExit
+}
+
+// endLines is how much of the last output the end screen shows
+const endLines = 3
+
+// handleSessionEnd keeps how the session ended for the end screen
+func (t *TerminalComponent) handleSessionEnd(msg sessionEndedMsg) {
+	if msg.pty != t.pty || t.ended != nil {
+		return // A session replaced by a reconnect, or disconnected by a timeout
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.sessionClosed = true
+	t.saveHistory()
+	t.closeFiles()
+	t.stopZmodem()
+	t.ended = &msg.exit
+	t.viewingOutput = false
+	t.status = t.endTitle()
+	if t.droppedUnexpectedly() {
+		hooks.Fire(hooks.Event{Kind: hooks.Disconnected, Connection: t.connection, Message: t.status, Failed: true})
+	}
+}
+
+// droppedUnexpectedly reports whether the ended session went without the
+// user or sxt closing it: the connection dropped or the shell was killed
+func (t *TerminalComponent) droppedUnexpectedly() bool {
+	if t.local || t.finished || t.timedOut != "" {
+		return false
+	}
+	return t.ended.Err != nil || t.ended.Signal != ""
+}
+
+// endTitle describes how the session ended, e.g. "connection closed by
+// remote (exit 1)"
+func (t *TerminalComponent) endTitle() string {
+	if t.timedOut != "" {
+		return t.timedOut
+	}
+	if t.local {
+		return fmt.Sprintf("shell exited (%s)", t.ended)
+	}
+	return fmt.Sprintf("connection closed by remote (%s)", t.ended)
+}
+
+// handleEndKey handles the keys of the end screen and of the last output
+// behind it, reporting whether key was one of them
+func (t *TerminalComponent) handleEndKey(key string) (bool, tea.Cmd) {
+	switch key {
+	case "r", "R":
+		return true, t.reconnect()
+	case "v", "V":
+		t.viewingOutput = !t.viewingOutput
+		return true, nil
+	case "enter", "q":
+		if !t.viewingOutput {
+			t.closeSession()
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// reconnect opens a new session, or starts the local shell again, in
+// place of the ended one, keeping its output above the new one
+func (t *TerminalComponent) reconnect() tea.Cmd {
+	if t.pty != nil {
+		t.output.Close()
+		t.pty.Close()
+	}
+	t.stopZmodem()
+	// The saved scrollback holds it otherwise
+	if t.history == nil && t.vterm != nil {
+		t.previous = t.vterm.History()
+	}
+	t.session = nil
+	t.pty = nil
+	t.output = nil
+	t.ended = nil
+	t.viewingOutput = false
+	t.sessionClosed = false
+	t.historySaved = false
+	t.error = nil
+	t.loading = true
+	t.status = connectingStatus(t.connection)
+	t.onConnect = t.connection.OnConnect
+	t.onConnectRan = 0
+	t.onConnectSkipped = false
+	t.statsEvery = 0
+	t.statsSeq++
+	t.hostStats = nil
+	t.timedOut = ""
+	t.sessionDeadline = time.Time{}
+	t.sessionStarted = true
+	if t.local {
+		return t.startLocalShell(t.width, t.height)
+	}
+	metrics.Reconnected(t.connection.Host)
+	return t.startSession(t.connection, t.width, t.height)
+}
+
+// renderEndScreen tells how the session ended, with the last lines of
+// its output and the ways on
+func (t *TerminalComponent) renderEndScreen() string {
+	var b strings.Builder
+	title := fmt.Sprintf("Connection to %s@%s:%d closed by remote (%s)",
+		t.connection.Username, t.connection.Host, t.connection.Port, t.ended)
+	again := "reconnect"
+	if t.local {
+		title = fmt.Sprintf("%s exited (%s)", t.connection.Name, t.ended)
+		again = "restart"
+	} else if t.timedOut != "" {
+		title = fmt.Sprintf("%s@%s:%d %s", t.connection.Username, t.connection.Host, t.connection.Port, t.timedOut)
+	}
+	if t.ended.Failed() {
+		b.WriteString(terminalErrorStyle.Render(title))
+	} else {
+		b.WriteString(sectionTitleStyle.Render(title))
+	}
+	b.WriteString("\n\n")
+	if last := t.lastOutput(endLines); len(last) > 0 {
+		for _, line := range last {
+			b.WriteString(blurredStyle.Render("  " + truncate(line, 70)))
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(lipgloss.NewStyle().Foreground(colorInactive).Render("r: " + again + " | v: view last output | esc/enter: back to list"))
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(80).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	return lipgloss.Place(t.termWidth(), t.contentHeight(), lipgloss.Center, lipgloss.Center, box)
+}
+
+// lastOutput returns up to n of the last lines of output that aren't blank
+func (t *TerminalComponent) lastOutput(n int) []string {
+	if t.vterm == nil {
+		return nil
+	}
+	var last []string
+	history := t.vterm.History()
+	for i := len(history) - 1; i >= 0 && len(last) < n; i-- {
+		if line := strings.TrimRight(history[i], " "); line != "" {
+			last = append([]string{line}, last...)
+		}
+	}
+	return last
+}
+
+// IsEnded reports whether the remote ended the session
+func (t *TerminalComponent) IsEnded() bool {
+	return t.ended != nil
+}
@@ -0,0 +1,108 @@
+package components
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// The file manager can share the screen with the shell, working over the
+// session's SSH connection. Alt+X opens and closes it, Alt+W moves the
+// focus between the two and Alt+V stacks them or puts them side by side.
+
+// toggleFiles opens the file manager next to the shell, or closes it
+func (t *TerminalComponent) toggleFiles() tea.Cmd {
+	if t.files != nil {
+		t.closeFiles()
+		return nil
+	}
+	if t.session == nil || t.IsSessionClosed() {
+		return nil
+	}
+	t.files = NewSCPManagerOn(t.connection, t.session.Client())
+	t.filesFocused = true
+	t.resizeTerminal()
+	return t.files.Init()
+}
+
+// closeFiles closes the file manager and gives its room back to the shell
+func (t *TerminalComponent) closeFiles() {
+	if t.files == nil {
+		return
+	}
+	if !t.files.IsFinished() {
+		t.files.Close()
+	}
+	t.files = nil
+	t.filesFocused = false
+	t.resizeTerminal()
+}
+
+// handleSplitKey handles the keys managing the split, reporting whether
+// key was one of them. The focus and layout keys reach the remote while
+// the file manager is closed.
+func (t *TerminalComponent) handleSplitKey(key string) (bool, tea.Cmd) {
+	switch {
+	case key == "alt+x":
+		return true, t.toggleFiles()
+	case key == "alt+w" && t.files != nil:
+		t.filesFocused = !t.filesFocused
+		return true, nil
+	case key == "alt+v" && t.files != nil:
+		t.filesBelow = !t.filesBelow
+		t.resizeTerminal()
+		return true, nil
+	}
+	return false, nil
+}
+
+// updateFiles passes msg to the file manager, closing it once it is done
+func (t *TerminalComponent) updateFiles(msg tea.Msg) tea.Cmd {
+	_, cmd := t.files.Update(msg)
+	if t.files.IsFinished() {
+		t.closeFiles()
+	}
+	return cmd
+}
+
+// paneSizes returns the size of the shell and of the file manager below
+// the header, leaving a line between them
+func (t *TerminalComponent) paneSizes() (termWidth, termHeight, filesWidth, filesHeight int) {
+	height := t.height - 1
+	if t.files == nil {
+		return t.width, height, 0, 0
+	}
+	if t.filesBelow {
+		filesHeight = height / 2
+		return t.width, max(height-filesHeight-1, 1), t.width, filesHeight
+	}
+	filesWidth = t.width / 2
+	return max(t.width-filesWidth-1, 1), height, filesWidth, height
+}
+
+// splitView puts the rendered shell and the file manager side by side or
+// on top of each other
+func (t *TerminalComponent) splitView(term string) string {
+	termWidth, termHeight, filesWidth, filesHeight := t.paneSizes()
+	term = lipgloss.NewStyle().Width(termWidth).Height(termHeight).MaxHeight(termHeight).
+		Render(strings.TrimSuffix(term, "\n"))
+	files := lipgloss.NewStyle().Width(filesWidth).Height(filesHeight).MaxHeight(filesHeight).
+		Render(t.files.View())
+	if t.filesBelow {
+		return lipgloss.JoinVertical(lipgloss.Left, term, blurredStyle.Render(strings.Repeat("─", t.width)), files)
+	}
+	divider := blurredStyle.Render(strings.TrimSuffix(strings.Repeat("│\n", termHeight), "\n"))
+	return lipgloss.JoinHorizontal(lipgloss.Top, term, divider, files)
+}
+
+// FilesFocused reports whether keys go to the file manager sharing the
+// screen with the shell
+func (t *TerminalComponent) FilesFocused() bool {
+	return t.files != nil && t.filesFocused
+}
+
+// IsSplit reports whether the file manager shares the screen with the shell
+func (t *TerminalComponent) IsSplit() bool {
+	return t.files != nil
+}
@@ -0,0 +1,101 @@
+package components
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/localshell"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+// The user's local shell runs in the same terminal as the SSH sessions,
+// for quick commands such as ping, dig or git without leaving the app.
+
+// terminalIO is what a terminal shows the output of and types keys into:
+// an SSH session or a local shell
+type terminalIO interface {
+	io.Reader
+	io.Writer
+	Resize(width, height int) error
+	Close() error
+	// ExitStatus tells how the shell ended, once its output has
+	ExitStatus() ssh.SessionExit
+}
+
+// localShellMsg carries the local shell once it started
+type localShellMsg struct {
+	shell *localShell
+	err   error
+}
+
+// localShell is a local shell as the terminal drives it
+type localShell struct {
+	*localshell.Shell
+}
+
+// ExitStatus waits for the shell to exit, which it has once its output
+// ended
+func (s *localShell) ExitStatus() ssh.SessionExit {
+	return ssh.SessionExit{Status: s.Wait()}
+}
+
+// NewLocalTerminalComponent creates a terminal for the user's local shell
+func NewLocalTerminalComponent() *TerminalComponent {
+	shellPath := localshell.DefaultShell()
+	t := NewTerminalComponent(config.SSHConnection{Name: filepath.Base(shellPath)})
+	t.local = true
+	t.shellPath = shellPath
+	t.status = "Starting " + t.connection.Name + "..."
+	return t
+}
+
+// startLocalShell starts the shell in the background
+func (t *TerminalComponent) startLocalShell(width, height int) tea.Cmd {
+	shellPath := t.shellPath
+	return func() tea.Msg {
+		if width <= 0 {
+			width = 80
+		}
+		if height <= 0 {
+			height = 24
+		}
+		// Only subtract 1 for the terminal header
+		shell, err := localshell.Start(shellPath, width, height-1)
+		if err != nil {
+			return localShellMsg{err: err}
+		}
+		return localShellMsg{shell: &localShell{shell}}
+	}
+}
+
+// handleLocalShell shows the output of the started shell
+func (t *TerminalComponent) handleLocalShell(msg localShellMsg) tea.Cmd {
+	t.loading = false
+	if msg.err != nil {
+		t.error = msg.err
+		t.status = fmt.Sprintf("Error: %s", msg.err)
+		return nil
+	}
+	t.pty = msg.shell
+	t.status = "Started"
+	t.vterm = NewVTerminal(t.termWidth(), t.contentHeight())
+	t.restoreHistory()
+	t.output = newOutputPump(t.pty)
+	return t.listenForSSHOutput()
+}
+
+// localStatusView tells that the shell is starting or failed to
+func (t *TerminalComponent) localStatusView() string {
+	if t.error != nil {
+		return fmt.Sprintf("\nFailed to start %s\n\n%s\n", t.shellPath, terminalErrorStyle.Render(t.error.Error()))
+	}
+	return fmt.Sprintf("\nStarting %s...\n", t.shellPath)
+}
+
+// IsLocal reports whether the terminal runs the local shell
+func (t *TerminalComponent) IsLocal() bool {
+	return t.local
+}
@@ -1,11 +1,17 @@
 package components
 
 import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/zmodem"
 )
 
 func TestTerminalComponent_DoubleEscBehavior(t *testing.T) {
@@ -223,3 +229,289 @@ func TestTerminalComponent_NewTerminalComponent(t *testing.T) {
 		}
 	})
 }
+
+func TestTerminalComponent_Paste(t *testing.T) {
+	conn := config.SSHConnection{Name: "test", Host: "localhost", Port: 22, Username: "user"}
+
+	t.Run("Multi-line paste waits for confirmation", func(t *testing.T) {
+		tc := NewTerminalComponent(conn)
+		_, _ = tc.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("ls\nrm -rf tmp\n"), Paste: true})
+
+		if tc.pendingPaste != "ls\nrm -rf tmp\n" {
+			t.Errorf("Expected paste to be held for confirmation, got %q", tc.pendingPaste)
+		}
+
+		_, _ = tc.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+		if tc.pendingPaste != "" {
+			t.Error("Expected 'n' to discard the pending paste")
+		}
+	})
+
+	t.Run("Single-line paste is not held", func(t *testing.T) {
+		tc := NewTerminalComponent(conn)
+		_, _ = tc.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("echo hi"), Paste: true})
+
+		if tc.pendingPaste != "" {
+			t.Errorf("Expected single-line paste to go straight through, got pending %q", tc.pendingPaste)
+		}
+	})
+}
+
+func TestTerminalComponent_OnConnect(t *testing.T) {
+	conn := config.SSHConnection{Name: "test", Host: "localhost", Port: 22, Username: "user", OnConnect: []string{"cd /var/www", "sudo -i"}}
+
+	newTerminal := func(screen string) *TerminalComponent {
+		tc := NewTerminalComponent(conn)
+		tc.vterm = NewVTerminal(80, 24)
+		tc.vterm.Write([]byte(screen))
+		tc.outputSeq = 1
+		return tc
+	}
+
+	t.Run("Waits for a settled prompt and asks first", func(t *testing.T) {
+		tc := newTerminal("Welcome to Ubuntu\r\nPassword: ")
+		tc.checkOnConnectPrompt(1)
+		if tc.onConnectConfirm {
+			t.Error("Expected no commands to be offered at a password prompt")
+		}
+
+		tc = newTerminal("Welcome to Ubuntu\r\nuser@web:~$ ")
+		tc.checkOnConnectPrompt(0)
+		if tc.onConnectConfirm {
+			t.Error("Expected a stale tick to be ignored")
+		}
+		tc.checkOnConnectPrompt(1)
+		if !tc.onConnectConfirm || tc.onConnectStatus() != "on-connect waiting" {
+			t.Errorf("Expected confirmation at the shell prompt, got status %q", tc.onConnectStatus())
+		}
+
+		_, _ = tc.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+		if tc.onConnectConfirm || len(tc.onConnect) != 0 || tc.onConnectStatus() != "on-connect skipped" {
+			t.Errorf("Expected 'n' to skip the commands, got status %q", tc.onConnectStatus())
+		}
+	})
+
+	t.Run("Recognizes common prompts", func(t *testing.T) {
+		for _, prompt := range []string{"user@web:~$ ", "root@web:~# ", "web% ", "PS C:\\> ", "❯ "} {
+			if !looksLikePrompt(prompt) {
+				t.Errorf("Expected %q to look like a prompt", prompt)
+			}
+		}
+		for _, text := range []string{"", "   ", "Password:", "Continue? [y/N] "} {
+			if looksLikePrompt(text) {
+				t.Errorf("Expected %q not to look like a prompt", text)
+			}
+		}
+	})
+}
+
+func TestTerminalComponent_FilesSplit(t *testing.T) {
+	tc := NewTerminalComponent(config.SSHConnection{Name: "test", Host: "localhost", Port: 22, Username: "user"})
+	tc.width = 101
+	tc.height = 31
+
+	if handled, _ := tc.handleSplitKey("alt+w"); handled {
+		t.Error("Expected Alt+W to reach the remote without a file manager open")
+	}
+	if w, h := tc.termWidth(), tc.contentHeight(); w != 101 || h != 30 {
+		t.Errorf("Expected the shell to take the whole screen, got %dx%d", w, h)
+	}
+
+	tc.files = NewSCPManager(tc.connection)
+	tc.filesFocused = true
+	termWidth, termHeight, filesWidth, filesHeight := tc.paneSizes()
+	if termWidth != 50 || filesWidth != 50 || termHeight != 30 || filesHeight != 30 {
+		t.Errorf("Expected side by side panes of 50 columns, got %dx%d and %dx%d", termWidth, termHeight, filesWidth, filesHeight)
+	}
+
+	if handled, _ := tc.handleSplitKey("alt+v"); !handled || !tc.filesBelow {
+		t.Fatal("Expected Alt+V to stack the panes")
+	}
+	termWidth, termHeight, filesWidth, filesHeight = tc.paneSizes()
+	if termWidth != 101 || filesWidth != 101 || termHeight != 14 || filesHeight != 15 {
+		t.Errorf("Expected stacked panes with a line between, got %dx%d and %dx%d", termWidth, termHeight, filesWidth, filesHeight)
+	}
+
+	tc.handleSplitKey("alt+w")
+	if tc.FilesFocused() {
+		t.Error("Expected Alt+W to move the focus to the shell")
+	}
+	tc.handleSplitKey("alt+x")
+	if tc.IsSplit() || tc.contentHeight() != 30 {
+		t.Error("Expected Alt+X to close the file manager and give the room back")
+	}
+}
+
+func TestTerminalComponent_SessionEnd(t *testing.T) {
+	tc := NewTerminalComponent(config.SSHConnection{Name: "test", Host: "localhost", Port: 22, Username: "user"})
+	tc.width = 100
+	tc.height = 30
+	tc.loading = false
+	tc.vterm = NewVTerminal(tc.termWidth(), tc.contentHeight())
+	tc.vterm.Write([]byte("logout\r\n"))
+
+	tc.Update(sessionEndedMsg{exit: ssh.SessionExit{Status: 1}})
+	if !tc.IsEnded() || !tc.IsSessionClosed() {
+		t.Fatal("Expected the session to be ended")
+	}
+	view := tc.View()
+	if !strings.Contains(view, "closed by remote (exit 1)") || !strings.Contains(view, "logout") {
+		t.Errorf("Expected the end screen with the exit status and the last output, got:\n%s", view)
+	}
+
+	tc.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("v")})
+	if !tc.viewingOutput || !strings.Contains(tc.View(), "R to reconnect") {
+		t.Error("Expected V to show the last output")
+	}
+	if tc.handleKey(tea.KeyMsg{Type: tea.KeyEnter}); tc.finished {
+		t.Error("Expected Enter to stay on the last output")
+	}
+
+	if _, cmd := tc.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")}); cmd == nil {
+		t.Fatal("Expected R to start a new session")
+	}
+	if tc.IsEnded() || tc.IsSessionClosed() || !tc.loading {
+		t.Error("Expected R to reset the terminal for the new session")
+	}
+	if len(tc.previous) == 0 || tc.previous[0] != "logout" {
+		t.Errorf("Expected the output to be kept for the new session, got %q", tc.previous)
+	}
+}
+
+func TestTerminalComponent_DroppedUnexpectedly(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		exit  ssh.SessionExit
+		setup func(*TerminalComponent)
+		want  bool
+	}{
+		{"exit status", ssh.SessionExit{Status: 1}, nil, false},
+		{"connection lost", ssh.SessionExit{Err: errors.New("EOF")}, nil, true},
+		{"killed", ssh.SessionExit{Signal: "KILL"}, nil, true},
+		{"timed out", ssh.SessionExit{Err: errSessionTimedOut}, func(tc *TerminalComponent) { tc.timedOut = "disconnected" }, false},
+		{"local shell", ssh.SessionExit{Signal: "KILL"}, func(tc *TerminalComponent) { tc.local = true }, false},
+	} {
+		tc := NewTerminalComponent(config.SSHConnection{Name: "test", Host: "localhost", Port: 22, Username: "user"})
+		if tt.setup != nil {
+			tt.setup(tc)
+		}
+		tc.ended = &tt.exit
+		if got := tc.droppedUnexpectedly(); got != tt.want {
+			t.Errorf("%s: droppedUnexpectedly = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTerminalComponent_LocalShell(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("no /bin/sh")
+	}
+	tc := NewLocalTerminalComponent()
+	tc.shellPath = "/bin/sh"
+	tc.width = 80
+	tc.height = 24
+	if !tc.IsLocal() {
+		t.Fatal("Expected a local terminal")
+	}
+
+	_, cmd := tc.Update(tc.startLocalShell(tc.width, tc.height)())
+	if tc.error != nil {
+		t.Fatalf("Failed to start the shell: %v", tc.error)
+	}
+	tc.sendPaste("echo local-$((40+2)); exit 3\n")
+	for i := 0; cmd != nil && !tc.IsEnded() && i < 100; i++ {
+		_, cmd = tc.Update(cmd())
+	}
+	if !tc.IsEnded() || tc.endTitle() != "shell exited (exit 3)" {
+		t.Fatalf("Expected the shell to exit with 3, got ended=%v", tc.ended)
+	}
+	if history := tc.vterm.History(); !strings.HasSuffix(history[len(history)-1], "local-42") {
+		t.Errorf("Expected the output of the command, got %q", tc.vterm.History())
+	}
+}
+
+// recordingIO is a session that keeps what the terminal typed into it
+type recordingIO struct {
+	bytes.Buffer
+}
+
+func (r *recordingIO) Read([]byte) (int, error)    { select {} }
+func (r *recordingIO) Resize(int, int) error       { return nil }
+func (r *recordingIO) Close() error                { return nil }
+func (r *recordingIO) ExitStatus() ssh.SessionExit { return ssh.SessionExit{} }
+
+func TestTerminalComponent_Zmodem(t *testing.T) {
+	tc := NewTerminalComponent(config.SSHConnection{Name: "test", Host: "localhost", Port: 22, Username: "user"})
+	tc.width = 100
+	tc.height = 30
+	tc.loading = false
+	tc.vterm = NewVTerminal(tc.termWidth(), tc.contentHeight())
+	typed := &recordingIO{}
+	tc.pty = typed
+
+	// The start of sz is cut by the end of a read
+	tc.handleOutput([]byte("$ sz notes.txt\r\n**\x18B"))
+	if tc.zmodemOffer != nil || !strings.Contains(tc.vterm.Render(), "sz notes.txt") {
+		t.Fatal("Expected the output before the cut start to be shown")
+	}
+	tc.handleOutput([]byte("00000000000000\r\x8a\x11"))
+	if tc.zmodemOffer == nil || !strings.Contains(tc.View(), "ZMODEM Download") {
+		t.Fatal("Expected to be asked to receive the files")
+	}
+	if strings.Contains(tc.vterm.Render(), "B00") {
+		t.Error("Expected the transfer not to be shown as output")
+	}
+
+	tc.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if tc.zmodemOffer != nil || !bytes.Equal(typed.Bytes(), zmodem.Abort) {
+		t.Errorf("Expected N to cancel the transfer on the remote, typed %q", typed.Bytes())
+	}
+	if !strings.Contains(tc.View(), "ZMODEM transfer refused") {
+		t.Error("Expected the header to tell the transfer was refused")
+	}
+
+	// rz can't be served here
+	typed.Reset()
+	tc.handleOutput([]byte("rz waiting to receive.**\x18B0100000023be50\r\x8a\x11"))
+	if !bytes.Equal(typed.Bytes(), zmodem.Abort) || !strings.Contains(tc.zmodemStatus, "uploads aren't supported") {
+		t.Errorf("Expected rz to be cancelled with a notice, got %q", tc.zmodemStatus)
+	}
+}
+
+func TestTerminalComponent_SessionTimeouts(t *testing.T) {
+	tc := NewTerminalComponent(config.SSHConnection{Name: "test", Host: "localhost", Port: 22, Username: "user", IdleMinutes: 15, SessionMinutes: 60})
+	tc.width = 100
+	tc.height = 30
+	tc.loading = false
+	tc.vterm = NewVTerminal(tc.termWidth(), tc.contentHeight())
+	tc.pty = &recordingIO{}
+	tc.output = newOutputPump(tc.pty)
+
+	if tc.startTimeouts() == nil || tc.timeoutStatus() != "" {
+		t.Fatal("Expected the limits to be counted without a countdown yet")
+	}
+
+	tc.lastInput = time.Now().Add(-15*time.Minute + 30*time.Second)
+	if status := tc.timeoutStatus(); !strings.HasPrefix(status, "idle logout in 0:") {
+		t.Fatalf("Expected an idle countdown, got %q", status)
+	}
+	tc.handleKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t"), Alt: true})
+	if status := tc.timeoutStatus(); status != "" || len(tc.pty.(*recordingIO).Bytes()) != 0 {
+		t.Fatalf("Expected Alt+T to restart the idle count without typing, got %q", status)
+	}
+
+	tc.sessionDeadline = time.Now().Add(20 * time.Second)
+	if status := tc.timeoutStatus(); !strings.HasPrefix(status, "session ends in 0:") {
+		t.Fatalf("Expected a session countdown, got %q", status)
+	}
+	tc.extendSession()
+	if tc.timeoutStatus() != "" {
+		t.Error("Expected Alt+T to extend the session")
+	}
+
+	tc.lastInput = time.Now().Add(-16 * time.Minute)
+	tc.handleTimeoutTick(sessionTimeoutTickMsg{pty: tc.pty})
+	if !tc.IsEnded() || tc.endTitle() != "disconnected after 15m without input" {
+		t.Errorf("Expected the idle session to be disconnected, got ended=%v %q", tc.ended, tc.endTitle())
+	}
+}
@@ -0,0 +1,139 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
+)
+
+// Connections can limit how long a session goes without input and how
+// long it stays connected. The header counts down the last minute before
+// either limit, when Alt+T extends the session; once a limit is reached
+// the session is disconnected and the end screen tells why.
+
+// sessionTimeoutWarning is how long before a timeout the header counts down
+const sessionTimeoutWarning = time.Minute
+
+// sessionExtension is how much Alt+T adds to the session time limit
+const sessionExtension = 15 * time.Minute
+
+// errSessionTimedOut ends a session disconnected by one of its limits
+var errSessionTimedOut = errors.New("session timed out")
+
+// sessionTimeoutTickMsg checks the limits of the session it was scheduled
+// for, so a tick outliving a reconnect is ignored
+type sessionTimeoutTickMsg struct {
+	pty terminalIO
+}
+
+// startTimeouts starts counting the limits of a session that just opened
+func (t *TerminalComponent) startTimeouts() tea.Cmd {
+	t.timedOut = ""
+	t.sessionDeadline = time.Time{}
+	if t.local || (t.connection.IdleLimit() == 0 && t.connection.SessionLimit() == 0) {
+		return nil
+	}
+	now := time.Now()
+	t.lastInput = now
+	if limit := t.connection.SessionLimit(); limit > 0 {
+		t.sessionDeadline = now.Add(limit)
+	}
+	return t.timeoutTick()
+}
+
+// timeoutDeadline returns when the session is disconnected and whether it
+// is for being idle, a zero time without limits
+func (t *TerminalComponent) timeoutDeadline() (deadline time.Time, idle bool) {
+	if limit := t.connection.IdleLimit(); limit > 0 && !t.local {
+		deadline, idle = t.lastInput.Add(limit), true
+	}
+	if !t.sessionDeadline.IsZero() && (deadline.IsZero() || t.sessionDeadline.Before(deadline)) {
+		deadline, idle = t.sessionDeadline, false
+	}
+	return deadline, idle
+}
+
+// timeoutTick schedules the next check of the limits: when the countdown
+// starts, then every second of it
+func (t *TerminalComponent) timeoutTick() tea.Cmd {
+	deadline, _ := t.timeoutDeadline()
+	if deadline.IsZero() {
+		return nil
+	}
+	wait := time.Until(deadline) - sessionTimeoutWarning
+	if wait < time.Second {
+		wait = time.Second
+	}
+	pty := t.pty
+	return tea.Tick(wait, func(time.Time) tea.Msg {
+		return sessionTimeoutTickMsg{pty: pty}
+	})
+}
+
+// handleTimeoutTick disconnects the session once a limit is reached and
+// keeps counting otherwise
+func (t *TerminalComponent) handleTimeoutTick(msg sessionTimeoutTickMsg) tea.Cmd {
+	if msg.pty != t.pty || t.pty == nil || t.IsSessionClosed() {
+		return nil
+	}
+	deadline, idle := t.timeoutDeadline()
+	if deadline.IsZero() {
+		return nil
+	}
+	if time.Now().Before(deadline) {
+		return t.timeoutTick()
+	}
+
+	if idle {
+		t.timedOut = "disconnected after " + config.FormatSessionTimeouts(t.connection.IdleMinutes, 0) + " without input"
+	} else {
+		t.timedOut = "disconnected at the " + config.FormatSessionTimeouts(t.connection.SessionMinutes, 0) + " session limit"
+	}
+	pty := t.pty
+	t.handleSessionEnd(sessionEndedMsg{pty: pty, exit: ssh.SessionExit{Err: errSessionTimedOut}})
+	t.output.Close()
+	pty.Close()
+	return nil
+}
+
+// extendSession restarts the idle count and pushes the session time limit
+// back, reporting whether the session has limits to extend
+func (t *TerminalComponent) extendSession() bool {
+	deadline, _ := t.timeoutDeadline()
+	if deadline.IsZero() || t.IsSessionClosed() {
+		return false
+	}
+	t.lastInput = time.Now()
+	if !t.sessionDeadline.IsZero() {
+		t.sessionDeadline = t.sessionDeadline.Add(sessionExtension)
+	}
+	return true
+}
+
+// timeoutStatus counts down the last minute before a limit for the header
+func (t *TerminalComponent) timeoutStatus() string {
+	deadline, idle := t.timeoutDeadline()
+	if deadline.IsZero() || t.pty == nil || t.IsSessionClosed() {
+		return ""
+	}
+	remaining := max(time.Until(deadline).Round(time.Second), 0)
+	if remaining > sessionTimeoutWarning {
+		return ""
+	}
+	countdown := fmt.Sprintf("%d:%02d", int(remaining.Minutes()), int(remaining.Seconds())%60)
+	if idle {
+		return "idle logout in " + countdown + ", Alt+T to stay"
+	}
+	return "session ends in " + countdown + ", Alt+T to extend"
+}
+
+// HasTimeouts reports whether the session is counting an idle or session
+// time limit
+func (t *TerminalComponent) HasTimeouts() bool {
+	deadline, _ := t.timeoutDeadline()
+	return !deadline.IsZero() && !t.IsSessionClosed()
+}
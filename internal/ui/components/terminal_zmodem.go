@@ -0,0 +1,214 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/zmodem"
+)
+
+// Files sent with ZMODEM (`sz` on the remote) are received right in the
+// terminal: the start of the transfer is spotted in the output, the user
+// is asked before anything is saved and the header shows the progress.
+// Uploads (`rz`) aren't supported, so they open the file manager instead.
+
+// zmodemProgressMsg reports how far a ZMODEM transfer is
+type zmodemProgressMsg struct {
+	receiver *zmodem.Receiver
+	progress zmodem.Progress
+}
+
+// zmodemDoneMsg reports the end of a ZMODEM transfer
+type zmodemDoneMsg struct {
+	receiver *zmodem.Receiver
+	files    []string
+	err      error
+}
+
+// handleOutput shows output of the session, unless it belongs to a
+// ZMODEM transfer
+func (t *TerminalComponent) handleOutput(data []byte) tea.Cmd {
+	if t.zmodem != nil {
+		t.zmodem.Feed(data)
+		return nil
+	}
+	if t.zmodemOffer != nil {
+		t.zmodemOffer = append(t.zmodemOffer, data...)
+		return nil
+	}
+	if len(t.zmodemHeld) > 0 {
+		data = append(t.zmodemHeld, data...)
+		t.zmodemHeld = nil
+	}
+
+	at, kind := zmodem.Detect(data)
+	switch kind {
+	case zmodem.Send:
+		t.writeOutput(data[:at])
+		t.zmodemOffer = append([]byte(nil), data[at:]...)
+		return nil
+	case zmodem.Receive:
+		t.writeOutput(data[:at])
+		return t.refuseUpload()
+	}
+	// Keep back what may be the start of a transfer cut by the read
+	n := zmodem.PartialStart(data)
+	t.zmodemHeld = append([]byte(nil), data[len(data)-n:]...)
+	t.writeOutput(data[:len(data)-n])
+	return nil
+}
+
+// writeOutput writes data to the virtual terminal, if there is any
+func (t *TerminalComponent) writeOutput(data []byte) {
+	if len(data) > 0 {
+		t.writeToVTerminal(data)
+	}
+}
+
+// handleZmodemKey handles the keys while a transfer is offered or runs,
+// reporting whether key was taken
+func (t *TerminalComponent) handleZmodemKey(key string) (bool, tea.Cmd) {
+	if t.zmodemOffer != nil {
+		switch key {
+		case "y", "Y", "enter":
+			return true, t.acceptZmodem()
+		case "n", "N", "esc":
+			t.pty.Write(zmodem.Abort)
+			t.zmodemOffer = nil
+			t.zmodemStatus = "ZMODEM transfer refused"
+		}
+		return true, nil
+	}
+	if t.zmodem != nil {
+		// Keys typed now would end up in the middle of the transfer
+		if key == "esc" || key == "ctrl+c" {
+			t.zmodem.Cancel()
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// acceptZmodem receives the offered files into the downloads folder
+func (t *TerminalComponent) acceptZmodem() tea.Cmd {
+	r := zmodem.NewReceiver(downloadsDir(), t.pty)
+	r.Feed(t.zmodemOffer)
+	t.zmodemOffer = nil
+	t.zmodem = r
+	t.zmodemStatus = "ZMODEM: waiting for the sender, Esc to cancel"
+	run := func() tea.Msg {
+		files, err := r.Run()
+		return zmodemDoneMsg{receiver: r, files: files, err: err}
+	}
+	return tea.Batch(run, listenForZmodemProgress(r))
+}
+
+// listenForZmodemProgress waits for the next progress of r
+func listenForZmodemProgress(r *zmodem.Receiver) tea.Cmd {
+	return func() tea.Msg {
+		p, ok := <-r.Progress()
+		if !ok {
+			return nil
+		}
+		return zmodemProgressMsg{receiver: r, progress: p}
+	}
+}
+
+// handleZmodemProgress shows the progress in the header
+func (t *TerminalComponent) handleZmodemProgress(msg zmodemProgressMsg) tea.Cmd {
+	if msg.receiver != t.zmodem {
+		return nil
+	}
+	p := msg.progress
+	t.zmodemStatus = fmt.Sprintf("ZMODEM: %s %s", p.Name, formatSize(p.Received))
+	if p.Size > 0 {
+		t.zmodemStatus += fmt.Sprintf(" of %s (%d%%)", formatSize(p.Size), p.Received*100/p.Size)
+	}
+	t.zmodemStatus += ", Esc to cancel"
+	return listenForZmodemProgress(msg.receiver)
+}
+
+// handleZmodemDone tells how the transfer went and shows the output that
+// followed it
+func (t *TerminalComponent) handleZmodemDone(msg zmodemDoneMsg) tea.Cmd {
+	if msg.receiver != t.zmodem {
+		return nil
+	}
+	t.zmodem = nil
+	switch {
+	case errors.Is(msg.err, zmodem.ErrCancelled):
+		t.zmodemStatus = "ZMODEM transfer cancelled"
+	case msg.err != nil:
+		t.zmodemStatus = "ZMODEM failed: " + msg.err.Error()
+	case len(msg.files) == 1:
+		t.zmodemStatus = "received " + msg.files[0]
+	default:
+		t.zmodemStatus = fmt.Sprintf("received %d files into %s", len(msg.files), msg.receiver.Dir)
+	}
+	return t.handleOutput(msg.receiver.Rest())
+}
+
+// refuseUpload stops an `rz` on the remote and opens the file manager
+// beside the shell to upload with instead
+func (t *TerminalComponent) refuseUpload() tea.Cmd {
+	t.pty.Write(zmodem.Abort)
+	if t.session == nil {
+		t.zmodemStatus = "ZMODEM uploads aren't supported"
+		return nil
+	}
+	t.zmodemStatus = "ZMODEM uploads aren't supported, upload with the file manager"
+	if t.files != nil {
+		t.filesFocused = true
+		return nil
+	}
+	return t.toggleFiles()
+}
+
+// stopZmodem cancels a transfer offered or running, as the session ends
+func (t *TerminalComponent) stopZmodem() {
+	if t.zmodem != nil {
+		t.zmodem.Cancel()
+		t.zmodem = nil
+	}
+	t.zmodemOffer = nil
+	t.zmodemHeld = nil
+	t.zmodemStatus = ""
+}
+
+// renderZmodemOffer asks whether to receive the files the remote sends
+func (t *TerminalComponent) renderZmodemOffer() string {
+	var b strings.Builder
+	b.WriteString(sectionTitleStyle.Render("ZMODEM Download"))
+	b.WriteString("\n\n")
+	b.WriteString("The remote is sending files with ZMODEM (sz).\n")
+	b.WriteString(fmt.Sprintf("Receive them into %s?", downloadsDir()))
+	b.WriteString("\n\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(colorInactive).Render("y/enter: receive | n/esc: cancel"))
+
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(80).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	return lipgloss.Place(t.termWidth(), t.contentHeight(), lipgloss.Center, lipgloss.Center, box)
+}
+
+// downloadsDir returns ~/Downloads, or the home directory without one
+func downloadsDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	if info, err := os.Stat(filepath.Join(home, "Downloads")); err == nil && info.IsDir() {
+		return filepath.Join(home, "Downloads")
+	}
+	return home
+}
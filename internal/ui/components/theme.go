@@ -0,0 +1,226 @@
+package components
+
+import (
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// Theme is a named color palette for the whole UI
+type Theme struct {
+	Name string
+	// Primary is used for titles, focus and selection
+	Primary lipgloss.Color
+	// Secondary marks directories, keys and the active panel
+	Secondary lipgloss.Color
+	// Accent is used for headers and highlights
+	Accent lipgloss.Color
+	// Text is drawn on top of Primary backgrounds and in active cards
+	Text     lipgloss.Color
+	SubText  lipgloss.Color
+	Error    lipgloss.Color
+	Inactive lipgloss.Color
+	// Bar is the background of the header, footer and status lines
+	Bar lipgloss.Color
+	// Selection is the background of the selected file
+	Selection lipgloss.Color
+	// HeaderText is the color of the title in the header bar
+	HeaderText lipgloss.Color
+	// String colors quoted values in highlighted files
+	String lipgloss.Color
+	// Success, Warning and Muted color status messages
+	Success lipgloss.Color
+	Warning lipgloss.Color
+	Muted   lipgloss.Color
+}
+
+// themes are the built-in palettes, keyed by the names in config.Themes
+var themes = map[string]Theme{
+	// Palette based on the Gopher Bubble Tea Image
+	"dark": {
+		Primary:    lipgloss.Color("#974FD7"), // The vibrant purple from the window header and straw
+		Secondary:  lipgloss.Color("#00ADD8"), // The cyan/blue from the Gopher's skin and "ssh" text
+		Accent:     lipgloss.Color("#F0D8B2"), // The cream/beige from the tea drink
+		Text:       lipgloss.Color("#FAFAFA"),
+		SubText:    lipgloss.Color("#7D7D7D"),
+		Error:      lipgloss.Color("#FF5555"),
+		Inactive:   lipgloss.Color("#4D4D4D"),
+		Bar:        lipgloss.Color("235"),
+		Selection:  lipgloss.Color("237"),
+		HeaderText: lipgloss.Color("170"),
+		String:     lipgloss.Color("#A6E22E"),
+		Success:    lipgloss.Color("42"),
+		Warning:    lipgloss.Color("214"),
+		Muted:      lipgloss.Color("241"),
+	},
+	// For terminals with a light background
+	"light": {
+		Primary:    lipgloss.Color("#B58BE8"),
+		Secondary:  lipgloss.Color("#00739A"),
+		Accent:     lipgloss.Color("#9A5B00"),
+		Text:       lipgloss.Color("#262626"),
+		SubText:    lipgloss.Color("#6C6C6C"),
+		Error:      lipgloss.Color("#C62828"),
+		Inactive:   lipgloss.Color("#B2B2B2"),
+		Bar:        lipgloss.Color("254"),
+		Selection:  lipgloss.Color("252"),
+		HeaderText: lipgloss.Color("91"),
+		String:     lipgloss.Color("#2E7D32"),
+		Success:    lipgloss.Color("28"),
+		Warning:    lipgloss.Color("130"),
+		Muted:      lipgloss.Color("244"),
+	},
+	// Solarized dark, https://ethanschoonover.com/solarized/
+	"solarized": {
+		Primary:    lipgloss.Color("#6C71C4"),
+		Secondary:  lipgloss.Color("#268BD2"),
+		Accent:     lipgloss.Color("#B58900"),
+		Text:       lipgloss.Color("#FDF6E3"),
+		SubText:    lipgloss.Color("#839496"),
+		Error:      lipgloss.Color("#DC322F"),
+		Inactive:   lipgloss.Color("#586E75"),
+		Bar:        lipgloss.Color("#073642"),
+		Selection:  lipgloss.Color("#586E75"),
+		HeaderText: lipgloss.Color("#D33682"),
+		String:     lipgloss.Color("#2AA198"),
+		Success:    lipgloss.Color("#859900"),
+		Warning:    lipgloss.Color("#CB4B16"),
+		Muted:      lipgloss.Color("#657B83"),
+	},
+	// Dracula, https://draculatheme.com/contribute
+	"dracula": {
+		Primary:    lipgloss.Color("#BD93F9"),
+		Secondary:  lipgloss.Color("#8BE9FD"),
+		Accent:     lipgloss.Color("#F1FA8C"),
+		Text:       lipgloss.Color("#F8F8F2"),
+		SubText:    lipgloss.Color("#6272A4"),
+		Error:      lipgloss.Color("#FF5555"),
+		Inactive:   lipgloss.Color("#44475A"),
+		Bar:        lipgloss.Color("#282A36"),
+		Selection:  lipgloss.Color("#44475A"),
+		HeaderText: lipgloss.Color("#FF79C6"),
+		String:     lipgloss.Color("#F1FA8C"),
+		Success:    lipgloss.Color("#50FA7B"),
+		Warning:    lipgloss.Color("#FFB86C"),
+		Muted:      lipgloss.Color("#6272A4"),
+	},
+	// Plain ANSI colors with strong contrast
+	"high-contrast": {
+		Primary:    lipgloss.Color("13"),
+		Secondary:  lipgloss.Color("14"),
+		Accent:     lipgloss.Color("11"),
+		Text:       lipgloss.Color("15"),
+		SubText:    lipgloss.Color("250"),
+		Error:      lipgloss.Color("9"),
+		Inactive:   lipgloss.Color("244"),
+		Bar:        lipgloss.Color("0"),
+		Selection:  lipgloss.Color("4"),
+		HeaderText: lipgloss.Color("15"),
+		String:     lipgloss.Color("10"),
+		Success:    lipgloss.Color("10"),
+		Warning:    lipgloss.Color("11"),
+		Muted:      lipgloss.Color("250"),
+	},
+}
+
+// currentTheme is the palette the styles were last built from
+var currentTheme Theme
+
+// Colors of the current theme, read by the styles and by views that build
+// their styles while rendering
+var (
+	colorPrimary   lipgloss.Color
+	colorSecondary lipgloss.Color
+	colorAccent    lipgloss.Color
+	colorText      lipgloss.Color
+	colorSubText   lipgloss.Color
+	colorError     lipgloss.Color
+	colorInactive  lipgloss.Color
+	colorSuccess   lipgloss.Color
+	colorWarning   lipgloss.Color
+	colorMuted     lipgloss.Color
+)
+
+func init() {
+	// Detecting the background queries the terminal, which is left to
+	// ApplyTheme("auto") once the program starts
+	setTheme("dark", themes["dark"])
+}
+
+// ApplyTheme switches the UI to a built-in theme or to a custom theme from
+// the current settings. "auto" picks dark or light from the terminal's
+// background, and unknown names fall back to dark.
+func ApplyTheme(name string) {
+	if name == "auto" {
+		if lipgloss.HasDarkBackground() {
+			name = "dark"
+		} else {
+			name = "light"
+		}
+	}
+
+	if theme, ok := themes[name]; ok {
+		setTheme(name, theme)
+		return
+	}
+	if custom, ok := config.CurrentSettings().CustomThemes[name]; ok {
+		setTheme(name, customTheme(custom))
+		return
+	}
+	setTheme("dark", themes["dark"])
+}
+
+// customTheme overlays a user-defined palette on its base theme
+func customTheme(custom config.CustomTheme) Theme {
+	base := custom.Base
+	if base == "" {
+		base = "dark"
+	}
+	theme := themes[base]
+	fields := map[string]*lipgloss.Color{
+		"primary":     &theme.Primary,
+		"secondary":   &theme.Secondary,
+		"accent":      &theme.Accent,
+		"text":        &theme.Text,
+		"subtext":     &theme.SubText,
+		"error":       &theme.Error,
+		"inactive":    &theme.Inactive,
+		"bar":         &theme.Bar,
+		"selection":   &theme.Selection,
+		"header_text": &theme.HeaderText,
+		"string":      &theme.String,
+		"success":     &theme.Success,
+		"warning":     &theme.Warning,
+		"muted":       &theme.Muted,
+	}
+	for key, color := range custom.Colors {
+		if field, ok := fields[key]; ok {
+			*field = lipgloss.Color(color)
+		}
+	}
+	return theme
+}
+
+func setTheme(name string, theme Theme) {
+	theme.Name = name
+	currentTheme = theme
+
+	colorPrimary = theme.Primary
+	colorSecondary = theme.Secondary
+	colorAccent = theme.Accent
+	colorText = theme.Text
+	colorSubText = theme.SubText
+	colorError = theme.Error
+	colorInactive = theme.Inactive
+	colorSuccess = theme.Success
+	colorWarning = theme.Warning
+	colorMuted = theme.Muted
+
+	buildStyles()
+	buildHighlightStyles()
+}
+
+// CurrentTheme returns the palette in use
+func CurrentTheme() Theme {
+	return currentTheme
+}
@@ -0,0 +1,53 @@
+package components
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+func TestBuiltinThemesMatchSettings(t *testing.T) {
+	for _, name := range config.Themes {
+		if _, ok := themes[name]; !ok && name != "auto" {
+			t.Errorf("Theme %q accepted by the settings has no palette", name)
+		}
+	}
+	for name := range themes {
+		if !slices.Contains(config.Themes, name) {
+			t.Errorf("Palette %q cannot be selected in the settings", name)
+		}
+	}
+}
+
+func TestApplyCustomTheme(t *testing.T) {
+	previous := config.CurrentSettings()
+	defer func() {
+		config.SetCurrentSettings(previous)
+		ApplyTheme("dark")
+	}()
+
+	settings := config.DefaultSettings()
+	settings.CustomThemes = map[string]config.CustomTheme{
+		"nord": {Base: "solarized", Colors: map[string]string{"primary": "#88C0D0"}},
+	}
+	config.SetCurrentSettings(settings)
+
+	ApplyTheme("nord")
+	if got := CurrentTheme(); got.Name != "nord" || got.Primary != lipgloss.Color("#88C0D0") {
+		t.Errorf("Expected the custom primary color, got %+v", got)
+	}
+	if colorPrimary != lipgloss.Color("#88C0D0") {
+		t.Errorf("Expected styles to use the custom theme, got %q", colorPrimary)
+	}
+	if colorError != themes["solarized"].Error {
+		t.Errorf("Expected unset colors to come from the base theme, got %q", colorError)
+	}
+
+	ApplyTheme("missing")
+	if CurrentTheme().Name != "dark" {
+		t.Errorf("Expected an unknown theme to fall back to dark, got %q", CurrentTheme().Name)
+	}
+}
@@ -0,0 +1,150 @@
+package components
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// urlPattern finds web addresses printed as plain text, such as the links
+// in CI logs and install scripts
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"'` + "`" + `{}|\\^]+`)
+
+// textURL is a web address in the text of the view and the cells it
+// covers, one span per row when the text wraps
+type textURL struct {
+	uri   string
+	spans []urlSpan
+}
+
+// urlSpan covers columns from to to, inclusive, of view row y
+type urlSpan struct {
+	y, from, to int
+}
+
+// findURLs returns the start and end offsets of the web addresses in s
+func findURLs(s string) [][2]int {
+	var found [][2]int
+	for _, m := range urlPattern.FindAllStringIndex(s, -1) {
+		end := m[0] + trimURL(s[m[0]:m[1]])
+		if !strings.HasSuffix(s[m[0]:end], "://") {
+			found = append(found, [2]int{m[0], end})
+		}
+	}
+	return found
+}
+
+// trimURL returns the length of u without the punctuation that ends the
+// sentence around it or closes a bracket opened before it
+func trimURL(u string) int {
+	for len(u) > 0 {
+		switch last := u[len(u)-1]; {
+		case strings.IndexByte(".,;:!?*", last) >= 0:
+		case last == ')' && strings.Count(u, "(") < strings.Count(u, ")"):
+		case last == ']' && strings.Count(u, "[") < strings.Count(u, "]"):
+		default:
+			return len(u)
+		}
+		u = u[:len(u)-1]
+	}
+	return 0
+}
+
+// urlsIn returns the distinct web addresses in text, in order
+func urlsIn(text string) []string {
+	var urls []string
+	for _, m := range findURLs(text) {
+		if uri := text[m[0]:m[1]]; !slices.Contains(urls, uri) {
+			urls = append(urls, uri)
+		}
+	}
+	return urls
+}
+
+// viewURLs finds the web addresses in the text of the view, following
+// rows the text wrapped across. OSC 8 hyperlinks are left to LinkAt.
+func (vt *VTerminal) viewURLs() []textURL {
+	var urls []textURL
+	var text strings.Builder
+	var offsets []int // Offset in text of each cell in cells
+	var cells []position
+
+	flush := func() {
+		for _, m := range findURLs(text.String()) {
+			u := textURL{uri: text.String()[m[0]:m[1]]}
+			for i, offset := range offsets {
+				if offset < m[0] || offset >= m[1] {
+					continue
+				}
+				p := cells[i]
+				if n := len(u.spans); n > 0 && u.spans[n-1].y == p.y {
+					u.spans[n-1].to = p.x
+				} else {
+					u.spans = append(u.spans, urlSpan{y: p.y, from: p.x, to: p.x})
+				}
+			}
+			urls = append(urls, u)
+		}
+		text.Reset()
+		offsets = offsets[:0]
+		cells = cells[:0]
+	}
+
+	for y := 0; y < vt.height; y++ {
+		line := vt.viewLine(y)
+		for x, c := range line {
+			if c.isWideTail() {
+				// The span of the head reaches over it below
+				if n := len(cells); n > 0 && cells[n-1].y == y && cells[n-1].x == x-1 {
+					offsets = append(offsets, offsets[n-1])
+					cells = append(cells, position{x: x, y: y})
+				}
+				continue
+			}
+			offsets = append(offsets, text.Len())
+			cells = append(cells, position{x: x, y: y})
+			if c.attrs.link != 0 {
+				text.WriteByte(' ')
+			} else {
+				writeCellText(&text, c)
+			}
+		}
+		if len(line) == 0 || !line[len(line)-1].wrap {
+			flush()
+		}
+	}
+	flush()
+	return urls
+}
+
+// covers reports whether the address covers column x of view row y
+func (u textURL) covers(x, y int) bool {
+	for _, span := range u.spans {
+		if span.y == y && x >= span.from && x <= span.to {
+			return true
+		}
+	}
+	return false
+}
+
+// urlColumns indexes the first and last columns the addresses cover by
+// view row
+func urlColumns(urls []textURL) map[int][][2]int {
+	columns := make(map[int][][2]int)
+	for _, u := range urls {
+		for _, span := range u.spans {
+			columns[span.y] = append(columns[span.y], [2]int{span.from, span.to})
+		}
+	}
+	return columns
+}
+
+// inURL reports whether column x falls in one of the ranges of columns
+func inURL(columns [][2]int, x int) bool {
+	for _, c := range columns {
+		if x >= c[0] && x <= c[1] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,293 @@
+package components
+
+import (
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+)
+
+// Input indices for the Vault config form
+const (
+	vaultInputAddress = iota
+	vaultInputNamespace
+	vaultInputMount
+	vaultInputPath
+	vaultInputToken
+	vaultInputRoleID
+	vaultInputSecretID
+	vaultInputOIDCRole
+	vaultInputCount
+)
+
+var vaultInputLabels = [vaultInputCount]string{
+	"Vault Address",
+	"Namespace (optional)",
+	"KV v2 Mount",
+	"Path Prefix",
+	"Token",
+	"Role ID",
+	"Secret ID",
+	"OIDC Role (optional)",
+}
+
+type VaultConfigForm struct {
+	inputs     []textinput.Model
+	authMethod config.VaultAuthMethod
+	focusIndex int
+	submitted  bool
+	canceled   bool
+	ErrorMsg   string
+	width      int
+	height     int
+}
+
+// NewVaultConfigForm creates a new Vault config form prefilled from VAULT_* env vars
+func NewVaultConfigForm() *VaultConfigForm {
+	inputs := make([]textinput.Model, vaultInputCount)
+	placeholders := [vaultInputCount]string{
+		"https://vault.example.com:8200",
+		"admin/team",
+		"secret",
+		"ssh-x-term",
+		"hvs.…",
+		"role_id",
+		"secret_id",
+		"default",
+	}
+	for i := range inputs {
+		inputs[i] = textinput.New()
+		inputs[i].Placeholder = placeholders[i]
+		inputs[i].Width = 50
+		inputs[i].Prompt = ""
+		inputs[i].PromptStyle = blurredStyle
+		inputs[i].TextStyle = blurredStyle
+	}
+	inputs[vaultInputToken].EchoMode = textinput.EchoPassword
+	inputs[vaultInputSecretID].EchoMode = textinput.EchoPassword
+
+	inputs[vaultInputAddress].SetValue(os.Getenv("VAULT_ADDR"))
+	inputs[vaultInputNamespace].SetValue(os.Getenv("VAULT_NAMESPACE"))
+	inputs[vaultInputToken].SetValue(os.Getenv("VAULT_TOKEN"))
+
+	f := &VaultConfigForm{
+		inputs:     inputs,
+		authMethod: config.VaultAuthToken,
+	}
+	f.updateFocus()
+	return f
+}
+
+func (f *VaultConfigForm) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// visibleInputs returns the indices of the inputs used by the current auth method
+func (f *VaultConfigForm) visibleInputs() []int {
+	visible := []int{vaultInputAddress, vaultInputNamespace, vaultInputMount, vaultInputPath}
+	switch f.authMethod {
+	case config.VaultAuthToken:
+		visible = append(visible, vaultInputToken)
+	case config.VaultAuthAppRole:
+		visible = append(visible, vaultInputRoleID, vaultInputSecretID)
+	case config.VaultAuthOIDC:
+		visible = append(visible, vaultInputOIDCRole)
+	}
+	return visible
+}
+
+func (f *VaultConfigForm) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	visible := f.visibleInputs()
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		f.SetSize(msg.Width, msg.Height)
+		return f, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			f.canceled = true
+			return f, nil
+		case "ctrl+t":
+			// Cycle through the available auth methods
+			f.authMethod = (f.authMethod + 1) % 3
+			f.focusIndex = min(f.focusIndex, len(f.visibleInputs()))
+			f.ErrorMsg = ""
+			f.updateFocus()
+			return f, nil
+		case "tab", "down", "enter":
+			// Special handling for Enter on the Submit button
+			if msg.String() == "enter" && f.focusIndex == len(visible) {
+				if valid, err := f.validateForm(); valid {
+					f.submitted = true
+				} else {
+					f.ErrorMsg = err
+				}
+				return f, nil
+			}
+
+			f.focusIndex++
+			if f.focusIndex > len(visible) {
+				f.focusIndex = 0
+			}
+			f.updateFocus()
+			return f, nil
+		case "shift+tab", "up":
+			f.focusIndex--
+			if f.focusIndex < 0 {
+				f.focusIndex = len(visible)
+			}
+			f.updateFocus()
+			return f, nil
+		}
+	}
+
+	if f.focusIndex < len(visible) {
+		idx := visible[f.focusIndex]
+		newInput, cmd := f.inputs[idx].Update(msg)
+		f.inputs[idx] = newInput
+		cmds = append(cmds, cmd)
+	}
+
+	return f, tea.Batch(cmds...)
+}
+
+func (f *VaultConfigForm) updateFocus() {
+	visible := f.visibleInputs()
+	for i := range f.inputs {
+		f.inputs[i].Blur()
+		f.inputs[i].PromptStyle = blurredStyle
+		f.inputs[i].TextStyle = blurredStyle
+	}
+	if f.focusIndex < len(visible) {
+		idx := visible[f.focusIndex]
+		f.inputs[idx].Focus()
+		f.inputs[idx].PromptStyle = focusedStyle
+		f.inputs[idx].TextStyle = focusedStyle
+	}
+}
+
+func (f *VaultConfigForm) View() string {
+	var b strings.Builder
+
+	labelStyle := lipgloss.NewStyle().Foreground(colorSubText)
+
+	b.WriteString(sectionTitleStyle.Render("HashiCorp Vault Setup"))
+	b.WriteString("\n\n")
+
+	// Auth method selector
+	var methods []string
+	for _, m := range []config.VaultAuthMethod{config.VaultAuthToken, config.VaultAuthAppRole, config.VaultAuthOIDC} {
+		if m == f.authMethod {
+			methods = append(methods, focusedStyle.Bold(true).Render("● "+m.String()))
+		} else {
+			methods = append(methods, blurredStyle.Render("○ "+m.String()))
+		}
+	}
+	b.WriteString(labelStyle.Render("Auth Method (ctrl+t to change)"))
+	b.WriteString("\n")
+	b.WriteString(strings.Join(methods, "   "))
+	b.WriteString("\n\n")
+
+	for _, idx := range f.visibleInputs() {
+		b.WriteString(labelStyle.Render(vaultInputLabels[idx]))
+		b.WriteString("\n")
+		b.WriteString(f.inputs[idx].View())
+		b.WriteString("\n\n")
+	}
+
+	if f.authMethod == config.VaultAuthOIDC {
+		b.WriteString(labelStyle.Render("A browser window will open to complete the login."))
+		b.WriteString("\n\n")
+	}
+
+	button := blurredButton
+	if f.focusIndex == len(f.visibleInputs()) {
+		button = focusedButton
+	}
+	b.WriteString(button)
+
+	if f.ErrorMsg != "" {
+		b.WriteString("\n\n")
+		b.WriteString(errorStyle.Render(f.ErrorMsg))
+	}
+
+	formBox := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorPrimary).
+		Padding(1, 3).
+		Width(60).
+		Align(lipgloss.Left).
+		Render(b.String())
+
+	availableHeight := max(f.height-3, 0)
+
+	return lipgloss.Place(
+		f.width,
+		availableHeight,
+		lipgloss.Center,
+		lipgloss.Center,
+		formBox,
+	)
+}
+
+func (f *VaultConfigForm) SetSize(width, height int) {
+	f.width = width
+	f.height = height
+}
+
+func (f *VaultConfigForm) IsSubmitted() bool {
+	return f.submitted
+}
+
+func (f *VaultConfigForm) IsCanceled() bool {
+	return f.canceled
+}
+
+// ResetSubmitted allows the form to be submitted again after a failed login
+func (f *VaultConfigForm) ResetSubmitted() {
+	f.submitted = false
+}
+
+func (f *VaultConfigForm) SetError(msg string) {
+	f.ErrorMsg = msg
+}
+
+// Config returns the Vault configuration entered in the form
+func (f *VaultConfigForm) Config() *config.VaultConfig {
+	value := func(i int) string { return strings.TrimSpace(f.inputs[i].Value()) }
+	return &config.VaultConfig{
+		Address:    value(vaultInputAddress),
+		Namespace:  value(vaultInputNamespace),
+		Mount:      value(vaultInputMount),
+		Path:       value(vaultInputPath),
+		AuthMethod: f.authMethod,
+		Token:      value(vaultInputToken),
+		RoleID:     value(vaultInputRoleID),
+		SecretID:   value(vaultInputSecretID),
+		OIDCRole:   value(vaultInputOIDCRole),
+	}
+}
+
+func (f *VaultConfigForm) validateForm() (bool, string) {
+	if strings.TrimSpace(f.inputs[vaultInputAddress].Value()) == "" {
+		return false, "Vault address is required."
+	}
+	switch f.authMethod {
+	case config.VaultAuthToken:
+		if strings.TrimSpace(f.inputs[vaultInputToken].Value()) == "" {
+			return false, "Token is required."
+		}
+	case config.VaultAuthAppRole:
+		if strings.TrimSpace(f.inputs[vaultInputRoleID].Value()) == "" {
+			return false, "Role ID is required."
+		}
+	}
+	return true, ""
+}
@@ -3,19 +3,22 @@ package components
 import (
 	"bytes"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"unicode/utf8"
 
 	"github.com/atotto/clipboard"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/mattn/go-runewidth"
 )
 
 // VTerminal represents a virtual terminal emulator that can render ANSI/VT100 sequences
 type VTerminal struct {
 	width         int
 	height        int
-	buffer        [][]cell // Terminal buffer [row][col]
-	scrollback    [][]cell // Scrollback buffer for scrolling
+	buffer        [][]cell    // Terminal buffer [row][col]
+	scrollback    *scrollback // Lines scrolled off the top, packed
 	cursorX       int
 	cursorY       int
 	scrollOffset  int // How many lines scrolled back
@@ -33,10 +36,38 @@ type VTerminal struct {
 	// Mouse selection support
 	selectionStart *position
 	selectionEnd   *position
+	selectionBlock bool // Rectangular selection of the same columns on every row
 	// Terminal modes
 	autoWrap      bool // Auto-wrap mode (DECAWM)
 	cursorVisible bool // Cursor visibility
 	pendingWrap   bool // Pending wrap state - cursor is past last column, wrap on next char
+	bracketPaste  bool // Bracketed paste mode (?2004)
+	// Scrolling region set by DECSTBM (0-based, inclusive)
+	scrollTop    int
+	scrollBottom int
+	// Replies to status and attribute queries, waiting to be sent back to
+	// the remote (see TakeReplies)
+	replies []byte
+	// OSC 8 hyperlink targets, referenced by cellAttrs.link
+	links   []string
+	linkIDs map[string]int
+	// Kitty graphics image split over several sequences, until the last
+	kittyParams map[string]string
+	kittyData   []byte
+	// Columns of the plain-text web addresses by view row, found on
+	// every render
+	urlColumns map[int][][2]int
+	// Rendered screen rows by their first cell. Rows keep their cells when
+	// the screen scrolls, so only new or changed rows are rendered again.
+	renderCache      map[*cell]renderedLine
+	renderCacheSpare map[*cell]renderedLine
+}
+
+// renderedLine is a screen row as rendered, and the cells it showed then
+type renderedLine struct {
+	cells []cell
+	urls  [][2]int
+	text  string
 }
 
 type position struct {
@@ -44,17 +75,89 @@ type position struct {
 	y int
 }
 
+// cellAttrs holds the rendition of a cell. Colors are -1 for the terminal
+// default, 0-255 for palette entries, or a 24-bit RGB value tagged with
+// colorRGB (see rgbColor), which keeps cellAttrs comparable with ==.
 type cellAttrs struct {
-	fgColor int
-	bgColor int
-	bold    bool
-	reverse bool
+	fgColor       int
+	bgColor       int
+	bold          bool
+	italic        bool
+	underline     bool
+	strikethrough bool
+	reverse       bool
+	link          int // 1-based index into VTerminal.links, 0 for none
+}
+
+// isPlain reports whether attrs render as the terminal default
+func (a cellAttrs) isPlain() bool {
+	return a == cellAttrs{fgColor: -1, bgColor: -1}
+}
+
+// colorRGB marks a color value as 24-bit RGB rather than a palette index
+const colorRGB = 1 << 24
+
+// rgbColor packs an RGB triple into a cellAttrs color value
+func rgbColor(r, g, b int) int {
+	clamp := func(v int) int { return min(max(v, 0), 255) }
+	return colorRGB | clamp(r)<<16 | clamp(g)<<8 | clamp(b)
+}
+
+// colorSGR returns the SGR parameters selecting color as foreground
+// (base 30) or background (base 40)
+func colorSGR(color, base int) string {
+	switch {
+	case color == -1:
+		return fmt.Sprintf("%d", base+9)
+	case color&colorRGB != 0:
+		return fmt.Sprintf("%d;2;%d;%d;%d", base+8, color>>16&0xFF, color>>8&0xFF, color&0xFF)
+	case color < 8:
+		return fmt.Sprintf("%d", base+color)
+	case color < 16:
+		return fmt.Sprintf("%d", base+60+color-8)
+	default:
+		return fmt.Sprintf("%d;5;%d", base+8, color)
+	}
 }
 
-// cell represents a single terminal cell with character and attributes
+// cell represents a single terminal cell with character and attributes.
+// A double-width character takes two cells, the second one holding char 0.
 type cell struct {
 	char  rune
+	comb  string // Zero-width combining marks following char
 	attrs cellAttrs
+	wrap  bool // Set on the last cell of a row the text wrapped from
+}
+
+// isWideTail reports whether c is the right half of a double-width character
+func (c cell) isWideTail() bool {
+	return c.char == 0
+}
+
+// maxCombining caps the combining marks kept on a cell, so a stream of
+// them can't grow it without bound
+const maxCombining = 32
+
+// textWriter is what a cell's text is written to
+type textWriter interface {
+	WriteRune(r rune) (int, error)
+	WriteString(s string) (int, error)
+}
+
+// writeCellText writes the characters of c, nothing for the right half of
+// a double-width character
+func writeCellText(w textWriter, c cell) {
+	if c.isWideTail() {
+		return
+	}
+	w.WriteRune(c.char)
+	w.WriteString(c.comb)
+}
+
+// runeWidth returns the number of cells r takes: 0 for combining marks
+// and other zero-width characters, 2 for wide CJK and emoji
+func runeWidth(r rune) int {
+	return runewidth.RuneWidth(r)
 }
 
 // NewVTerminal creates a new virtual terminal with specified dimensions
@@ -70,7 +173,7 @@ func NewVTerminal(width, height int) *VTerminal {
 	vt := &VTerminal{
 		width:         width,
 		height:        height,
-		maxScrollback: 10000,
+		maxScrollback: config.CurrentSettings().ScrollbackLines,
 		utf8Buf:       make([]byte, 4), // UTF-8 characters can be up to 4 bytes
 		defaultAttrs: cellAttrs{
 			fgColor: -1,
@@ -81,6 +184,8 @@ func NewVTerminal(width, height int) *VTerminal {
 	}
 	vt.attrs = vt.defaultAttrs
 	vt.initBuffer()
+	vt.scrollback = newScrollback(vt.maxScrollback)
+	vt.resetScrollRegion()
 	return vt
 }
 
@@ -92,7 +197,6 @@ func (vt *VTerminal) initBuffer() {
 			vt.buffer[i][j] = cell{char: ' ', attrs: vt.defaultAttrs}
 		}
 	}
-	vt.scrollback = make([][]cell, 0, vt.maxScrollback)
 }
 
 // Resize changes the terminal dimensions
@@ -107,6 +211,7 @@ func (vt *VTerminal) Resize(width, height int) {
 	vt.width = width
 	vt.height = height
 	vt.initBuffer()
+	vt.resetScrollRegion()
 	vt.cursorX = 0
 	vt.cursorY = 0
 	vt.scrollOffset = 0
@@ -123,6 +228,16 @@ func (vt *VTerminal) Write(data []byte) (int, error) {
 	return len(data), nil
 }
 
+// AbortSequence drops a partly received escape or UTF-8 sequence, so
+// output resuming after a skip doesn't continue it
+func (vt *VTerminal) AbortSequence() {
+	vt.mutex.Lock()
+	defer vt.mutex.Unlock()
+	vt.inEscapeSeq = false
+	vt.escapeSeq = nil
+	vt.utf8BufSize = 0
+}
+
 func (vt *VTerminal) processByte(b byte) {
 	// Handle escape sequences (these take priority over UTF-8 decoding)
 	if vt.inEscapeSeq {
@@ -236,20 +351,52 @@ func (vt *VTerminal) putChar(r rune) {
 		vt.cursorY = vt.height - 1
 	}
 
+	width := runeWidth(r)
+	if width == 0 {
+		vt.combineChar(r)
+		return
+	}
+	if vt.width < 2 {
+		width = 1
+	}
+
 	// Handle pending wrap - if we're in pending wrap state, wrap now before writing
 	if vt.pendingWrap && vt.autoWrap {
+		vt.markWrapped()
 		vt.newLine()
 		vt.cursorX = 0
 		vt.pendingWrap = false
 	}
 
+	// A double-width character doesn't fit in the last column: it wraps,
+	// leaving the column blank, or overwrites the column before it
+	if width == 2 && vt.cursorX == vt.width-1 {
+		if vt.autoWrap {
+			if vt.cursorY < len(vt.buffer) {
+				vt.splitWide(vt.buffer[vt.cursorY], vt.cursorX)
+				vt.buffer[vt.cursorY][vt.cursorX] = cell{char: ' ', attrs: vt.defaultAttrs}
+			}
+			vt.markWrapped()
+			vt.newLine()
+			vt.cursorX = 0
+		} else {
+			vt.cursorX--
+		}
+	}
+
 	// Write character at current cursor position
-	if vt.cursorY < len(vt.buffer) && vt.cursorX < len(vt.buffer[vt.cursorY]) {
-		vt.buffer[vt.cursorY][vt.cursorX] = cell{char: r, attrs: vt.attrs}
+	if vt.cursorY < len(vt.buffer) && vt.cursorX+width <= len(vt.buffer[vt.cursorY]) {
+		line := vt.buffer[vt.cursorY]
+		vt.splitWide(line, vt.cursorX)
+		vt.splitWide(line, vt.cursorX+width-1)
+		line[vt.cursorX] = cell{char: r, attrs: vt.attrs}
+		if width == 2 {
+			line[vt.cursorX+1] = cell{char: 0, attrs: vt.attrs}
+		}
 	}
 
 	// Move cursor forward
-	vt.cursorX++
+	vt.cursorX += width
 
 	// Handle cursor after write
 	if vt.cursorX >= vt.width {
@@ -264,22 +411,124 @@ func (vt *VTerminal) putChar(r rune) {
 	}
 }
 
+// markWrapped records that the cursor's row continues on the next one, so
+// a copy joins them back into one line
+func (vt *VTerminal) markWrapped() {
+	if vt.cursorY < len(vt.buffer) && len(vt.buffer[vt.cursorY]) > 0 {
+		vt.buffer[vt.cursorY][len(vt.buffer[vt.cursorY])-1].wrap = true
+	}
+}
+
+// combineChar adds a zero-width character to the one written last, or
+// drops it when nothing precedes it on the line
+func (vt *VTerminal) combineChar(r rune) {
+	if vt.cursorY >= len(vt.buffer) {
+		return
+	}
+	line := vt.buffer[vt.cursorY]
+	x := vt.cursorX - 1
+	if vt.pendingWrap {
+		x = vt.cursorX
+	}
+	if x >= 0 && x < len(line) && line[x].isWideTail() {
+		x--
+	}
+	if x < 0 || x >= len(line) || len(line[x].comb)+utf8.RuneLen(r) > maxCombining {
+		return
+	}
+	line[x].comb += string(r)
+}
+
+// splitWide blanks both halves of a double-width character covering
+// column x, before x is overwritten, erased or moved without its other half
+func (vt *VTerminal) splitWide(line []cell, x int) {
+	if x < 0 || x >= len(line) {
+		return
+	}
+	if line[x].isWideTail() {
+		x--
+	} else if x+1 >= len(line) || !line[x+1].isWideTail() {
+		return
+	}
+	if x >= 0 {
+		line[x] = cell{char: ' ', attrs: vt.defaultAttrs}
+	}
+	line[x+1] = cell{char: ' ', attrs: vt.defaultAttrs}
+}
+
 func (vt *VTerminal) newLine() {
-	vt.cursorY++
-	if vt.cursorY >= vt.height {
-		// Scroll: move first line to scrollback
-		if len(vt.scrollback) >= vt.maxScrollback {
-			vt.scrollback = vt.scrollback[1:]
-		}
-		vt.scrollback = append(vt.scrollback, vt.buffer[0])
+	switch {
+	case vt.cursorY == vt.scrollBottom:
+		vt.scrollRegionUp(1)
+	case vt.cursorY < vt.height-1:
+		vt.cursorY++
+	}
+}
+
+// reverseIndex moves the cursor up, scrolling the region down at its top margin
+func (vt *VTerminal) reverseIndex() {
+	switch {
+	case vt.cursorY == vt.scrollTop:
+		vt.scrollRegionDown(1)
+	case vt.cursorY > 0:
+		vt.cursorY--
+	}
+}
+
+// resetScrollRegion makes the whole screen the scrolling region
+func (vt *VTerminal) resetScrollRegion() {
+	vt.scrollTop = 0
+	vt.scrollBottom = vt.height - 1
+}
+
+// setScrollRegion handles DECSTBM; top and bottom are 1-based, 0 means default
+func (vt *VTerminal) setScrollRegion(top, bottom int) {
+	if top < 1 {
+		top = 1
+	}
+	if bottom < 1 || bottom > vt.height {
+		bottom = vt.height
+	}
+	if top >= bottom {
+		return
+	}
+	vt.scrollTop = top - 1
+	vt.scrollBottom = bottom - 1
+	// DECSTBM homes the cursor
+	vt.cursorX = 0
+	vt.cursorY = 0
+	vt.pendingWrap = false
+}
 
-		// Shift buffer up
-		copy(vt.buffer, vt.buffer[1:])
-		vt.buffer[vt.height-1] = make([]cell, vt.width)
-		for i := range vt.buffer[vt.height-1] {
-			vt.buffer[vt.height-1][i] = cell{char: ' ', attrs: vt.defaultAttrs}
+func (vt *VTerminal) blankLine() []cell {
+	line := make([]cell, vt.width)
+	for i := range line {
+		line[i] = cell{char: ' ', attrs: vt.defaultAttrs}
+	}
+	return line
+}
+
+// scrollRegionUp scrolls the scrolling region up by n lines. Lines leaving a region
+// that starts at the top of the screen are kept in the scrollback.
+func (vt *VTerminal) scrollRegionUp(n int) {
+	top, bottom := vt.scrollTop, vt.scrollBottom
+	n = min(n, bottom-top+1)
+	for i := 0; i < n; i++ {
+		if top == 0 {
+			vt.scrollback.Push(vt.buffer[top], vt.defaultAttrs)
 		}
-		vt.cursorY = vt.height - 1
+		copy(vt.buffer[top:bottom], vt.buffer[top+1:bottom+1])
+		vt.buffer[bottom] = vt.blankLine()
+	}
+}
+
+// scrollRegionDown scrolls the scrolling region down by n lines
+func (vt *VTerminal) scrollRegionDown(n int) {
+	top, bottom := vt.scrollTop, vt.scrollBottom
+	n = min(n, bottom-top+1)
+	for i := 0; i < n; i++ {
+		copy(vt.buffer[top+1:bottom+1], vt.buffer[top:bottom])
+		vt.buffer[top] = vt.blankLine()
 	}
 }
 
@@ -300,18 +549,24 @@ func (vt *VTerminal) isEscapeComplete() bool {
 				return true
 			}
 		}
-		// Prevent infinite growth
-		if len(vt.escapeSeq) > 1000 {
+		// Prevent infinite growth (OSC 8 URIs can run to a couple of KB)
+		if len(vt.escapeSeq) > 4096 && !vt.isGraphicsSequence() || len(vt.escapeSeq) > maxGraphicsSequence {
 			return true
 		}
 		return false
 	}
 
-	// CSI sequences: ESC [ ... [a-zA-Z]
+	// DCS and APC strings, ended by ST (ESC \)
+	if vt.escapeSeq[1] == 'P' || vt.escapeSeq[1] == '_' {
+		n := len(vt.escapeSeq)
+		return n > 2 && vt.escapeSeq[n-2] == 0x1B && vt.escapeSeq[n-1] == '\\' || n > maxGraphicsSequence
+	}
+
+	// CSI sequences: ESC [ ... final byte in @-~, such as a letter or @ (ICH)
 	if len(vt.escapeSeq) >= 2 && vt.escapeSeq[1] == '[' {
 		lastByte := vt.escapeSeq[len(vt.escapeSeq)-1]
-		// Check if it's a letter (terminator)
-		if (lastByte >= 'A' && lastByte <= 'Z') || (lastByte >= 'a' && lastByte <= 'z') {
+		// Check if it's a final byte (terminator)
+		if len(vt.escapeSeq) > 2 && lastByte >= '@' && lastByte <= '~' {
 			return true
 		}
 		// Prevent infinite growth
@@ -339,9 +594,10 @@ func (vt *VTerminal) handleEscapeSequence() {
 		return
 	}
 
-	// OSC sequences - just ignore them (e.g., window title changes)
+	// OSC sequences - only hyperlinks are handled, the rest (e.g. window
+	// title changes) are ignored
 	if vt.escapeSeq[1] == ']' {
-		// Silently ignore OSC sequences
+		vt.handleOSC()
 		return
 	}
 
@@ -351,6 +607,16 @@ func (vt *VTerminal) handleEscapeSequence() {
 		return
 	}
 
+	// DCS and APC strings - only images are handled
+	switch vt.escapeSeq[1] {
+	case 'P':
+		vt.handleDCS()
+		return
+	case '_':
+		vt.handleAPC()
+		return
+	}
+
 	// Character set designation sequences: ESC ( <char>, ESC ) <char>, ESC * <char>, ESC + <char>
 	// These are used to switch between character sets (ASCII, line drawing, etc.)
 	// For example: ESC(B = select ASCII for G0, ESC(0 = select line drawing for G0
@@ -367,10 +633,8 @@ func (vt *VTerminal) handleEscapeSequence() {
 
 	// Simple escape sequences
 	switch vt.escapeSeq[1] {
-	case 'M': // Reverse index (move up)
-		if vt.cursorY > 0 {
-			vt.cursorY--
-		}
+	case 'M': // Reverse index (move up, scroll if at top) - RI
+		vt.reverseIndex()
 		vt.pendingWrap = false
 	case '7': // Save cursor position (DECSC)
 		vt.savedCursorX = vt.cursorX
@@ -393,6 +657,45 @@ func (vt *VTerminal) handleEscapeSequence() {
 	}
 }
 
+// handleOSC processes an operating system command. Only OSC 8 hyperlinks
+// are supported: ESC ] 8 ; params ; URI ST starts a link and an empty URI
+// ends it; and iTerm2 inline images, OSC 1337.
+func (vt *VTerminal) handleOSC() {
+	body := vt.escapeSeq[2:]
+	switch {
+	case bytes.HasSuffix(body, []byte{0x07}):
+		body = body[:len(body)-1]
+	case bytes.HasSuffix(body, []byte{0x1B, '\\'}):
+		body = body[:len(body)-2]
+	default:
+		return // truncated
+	}
+
+	if arg, ok := bytes.CutPrefix(body, []byte("1337;")); ok {
+		vt.handleITermImage(string(arg))
+		return
+	}
+	parts := strings.SplitN(string(body), ";", 3)
+	if len(parts) != 3 || parts[0] != "8" {
+		return
+	}
+	uri := parts[2]
+	if uri == "" {
+		vt.attrs.link = 0
+		return
+	}
+	id, ok := vt.linkIDs[uri]
+	if !ok {
+		if vt.linkIDs == nil {
+			vt.linkIDs = make(map[string]int)
+		}
+		vt.links = append(vt.links, uri)
+		id = len(vt.links)
+		vt.linkIDs[uri] = id
+	}
+	vt.attrs.link = id
+}
+
 func (vt *VTerminal) handleCSI() {
 	if len(vt.escapeSeq) < 3 {
 		return
@@ -564,15 +867,27 @@ func (vt *VTerminal) handleCSI() {
 		if len(args) > 0 && args[0] > 0 {
 			n = args[0]
 		}
-		// Scroll the screen up by n lines
-		for i := 0; i < n; i++ {
-			vt.newLine()
-		}
+		vt.scrollRegionUp(n)
 
 	case 'T': // Scroll down (SD)
-		// Scroll down is rarely used and complex to implement
-		// Just ignore for now
-		_ = args // Suppress unused variable warning
+		n := 1
+		if len(args) > 0 && args[0] > 0 {
+			n = args[0]
+		}
+		vt.scrollRegionDown(n)
+
+	case 'r': // Set scrolling region (DECSTBM)
+		if params != "" && params[0] == '?' {
+			break // CSI ? r restores DEC private modes, not supported
+		}
+		top, bottom := 0, 0
+		if len(args) > 0 {
+			top = args[0]
+		}
+		if len(args) > 1 {
+			bottom = args[1]
+		}
+		vt.setScrollRegion(top, bottom)
 
 	case 'h': // Set mode
 		// Handle various DEC private modes with '?' prefix
@@ -586,6 +901,8 @@ func (vt *VTerminal) handleCSI() {
 					vt.autoWrap = true
 				case 25: // DECTCEM - Cursor visible
 					vt.cursorVisible = true
+				case 2004: // Bracketed paste
+					vt.bracketPaste = true
 					// Other modes like ?1 (application cursor keys), ?1049 (alt screen)
 					// are not fully implemented but won't cause errors
 				}
@@ -604,20 +921,63 @@ func (vt *VTerminal) handleCSI() {
 					vt.autoWrap = false
 				case 25: // DECTCEM - Cursor visible
 					vt.cursorVisible = false
+				case 2004: // Bracketed paste
+					vt.bracketPaste = false
 				}
 			}
 		}
 
 	case 'm': // SGR - Select Graphic Rendition
-		vt.handleSGR(args)
+		vt.handleSGR(parseSGRParams(params))
+
+	case 'n': // Device status report (DSR)
+		vt.reportStatus(params)
+
+	case 'c': // Device attributes (DA)
+		vt.reportAttributes(params)
+	}
+}
+
+// reportStatus answers DSR: CSI 5n asks whether the terminal is ok and
+// CSI 6n, or CSI ? 6n, where the cursor is
+func (vt *VTerminal) reportStatus(params string) {
+	switch params {
+	case "5":
+		vt.replies = append(vt.replies, "\x1b[0n"...)
+	case "6":
+		vt.replies = fmt.Appendf(vt.replies, "\x1b[%d;%dR", vt.cursorY+1, vt.cursorX+1)
+	case "?6":
+		vt.replies = fmt.Appendf(vt.replies, "\x1b[?%d;%dR", vt.cursorY+1, vt.cursorX+1)
 	}
 }
 
+// reportAttributes answers the primary DA (CSI c) as a VT220 with ANSI
+// color, and the secondary DA (CSI > c) with a VT220 terminal type
+func (vt *VTerminal) reportAttributes(params string) {
+	switch params {
+	case "", "0":
+		vt.replies = append(vt.replies, "\x1b[?62;22c"...)
+	case ">", ">0":
+		vt.replies = append(vt.replies, "\x1b[>1;10;0c"...)
+	}
+}
+
+// TakeReplies returns the replies to the queries in the output written so
+// far, to be sent back to the remote, and forgets them
+func (vt *VTerminal) TakeReplies() []byte {
+	vt.mutex.Lock()
+	defer vt.mutex.Unlock()
+	replies := vt.replies
+	vt.replies = nil
+	return replies
+}
+
 func (vt *VTerminal) eraseDisplay(mode int) {
 	switch mode {
 	case 0: // Erase from cursor to end of screen
 		// Clear current line from cursor to end
 		if vt.cursorY < len(vt.buffer) {
+			vt.splitWide(vt.buffer[vt.cursorY], vt.cursorX)
 			for x := vt.cursorX; x < vt.width; x++ {
 				if x < len(vt.buffer[vt.cursorY]) {
 					vt.buffer[vt.cursorY][x] = cell{char: ' ', attrs: vt.defaultAttrs}
@@ -643,6 +1003,7 @@ func (vt *VTerminal) eraseDisplay(mode int) {
 		}
 		// Clear current line from start to cursor
 		if vt.cursorY < len(vt.buffer) {
+			vt.splitWide(vt.buffer[vt.cursorY], vt.cursorX)
 			for x := 0; x <= vt.cursorX; x++ {
 				if x < len(vt.buffer[vt.cursorY]) {
 					vt.buffer[vt.cursorY][x] = cell{char: ' ', attrs: vt.defaultAttrs}
@@ -671,12 +1032,14 @@ func (vt *VTerminal) eraseLine(mode int) {
 
 	switch mode {
 	case 0: // Erase from cursor to end of line
+		vt.splitWide(vt.buffer[vt.cursorY], vt.cursorX)
 		for x := vt.cursorX; x < vt.width; x++ {
 			if x < len(vt.buffer[vt.cursorY]) {
 				vt.buffer[vt.cursorY][x] = cell{char: ' ', attrs: vt.defaultAttrs}
 			}
 		}
 	case 1: // Erase from start of line to cursor
+		vt.splitWide(vt.buffer[vt.cursorY], vt.cursorX)
 		for x := 0; x <= vt.cursorX; x++ {
 			if x < len(vt.buffer[vt.cursorY]) {
 				vt.buffer[vt.cursorY][x] = cell{char: ' ', attrs: vt.defaultAttrs}
@@ -706,26 +1069,26 @@ func (vt *VTerminal) handleSGR(args []int) {
 			vt.attrs.bold = true
 		case 2: // Dim/faint (treat as normal for now)
 			// Not commonly supported
-		case 3: // Italic (ignore for now)
-			// Not commonly supported in terminals
-		case 4: // Underline (ignore for now)
-			// Would require additional attribute tracking
+		case 3: // Italic
+			vt.attrs.italic = true
+		case 4: // Underline
+			vt.attrs.underline = true
 		case 5, 6: // Blink slow/rapid (ignore)
 			// Not supported in TUI
 		case 7: // Reverse
 			vt.attrs.reverse = true
 		case 8: // Conceal/hidden (ignore)
 			// Not commonly used
-		case 9: // Crossed out (ignore)
-			// Not commonly supported
-		case 21: // Double underline (ignore)
-			// Not commonly supported
+		case 9: // Crossed out
+			vt.attrs.strikethrough = true
+		case 21: // Double underline (rendered as single)
+			vt.attrs.underline = true
 		case 22: // Not bold, not dim
 			vt.attrs.bold = false
 		case 23: // Not italic
-			// Ignore
+			vt.attrs.italic = false
 		case 24: // Not underlined
-			// Ignore
+			vt.attrs.underline = false
 		case 25: // Not blinking
 			// Ignore
 		case 27: // Not reverse
@@ -733,23 +1096,15 @@ func (vt *VTerminal) handleSGR(args []int) {
 		case 28: // Not concealed
 			// Ignore
 		case 29: // Not crossed out
-			// Ignore
+			vt.attrs.strikethrough = false
 		// Foreground colors 30-37 (standard colors)
 		case 30, 31, 32, 33, 34, 35, 36, 37:
 			vt.attrs.fgColor = arg - 30
 		// Extended foreground color (256-color or RGB)
 		case 38:
-			if i+1 < len(args) {
-				if args[i+1] == 5 && i+2 < len(args) {
-					// 256-color: ESC[38;5;Nm
-					vt.attrs.fgColor = args[i+2]
-					i += 2
-				} else if args[i+1] == 2 && i+4 < len(args) {
-					// RGB color: ESC[38;2;R;G;Bm
-					// For simplicity, map to closest standard color
-					// In a full implementation, we'd track RGB values
-					i += 4
-				}
+			if color, n := parseExtendedColor(args[i+1:]); n > 0 {
+				vt.attrs.fgColor = color
+				i += n
 			}
 		case 39: // Default foreground
 			vt.attrs.fgColor = -1
@@ -758,15 +1113,9 @@ func (vt *VTerminal) handleSGR(args []int) {
 			vt.attrs.bgColor = arg - 40
 		// Extended background color (256-color or RGB)
 		case 48:
-			if i+1 < len(args) {
-				if args[i+1] == 5 && i+2 < len(args) {
-					// 256-color: ESC[48;5;Nm
-					vt.attrs.bgColor = args[i+2]
-					i += 2
-				} else if args[i+1] == 2 && i+4 < len(args) {
-					// RGB color: ESC[48;2;R;G;Bm
-					i += 4
-				}
+			if color, n := parseExtendedColor(args[i+1:]); n > 0 {
+				vt.attrs.bgColor = color
+				i += n
 			}
 		case 49: // Default background
 			vt.attrs.bgColor = -1
@@ -781,64 +1130,55 @@ func (vt *VTerminal) handleSGR(args []int) {
 	}
 }
 
-// insertLines inserts n blank lines at cursor position, scrolling down
-func (vt *VTerminal) insertLines(n int) {
-	if vt.cursorY >= vt.height || n <= 0 {
-		return
-	}
-
-	// Limit n to available space
-	if vt.cursorY+n > vt.height {
-		n = vt.height - vt.cursorY
+// parseExtendedColor decodes the arguments following SGR 38/48:
+// 5;N for a palette index or 2;R;G;B for truecolor. It returns the color
+// and the number of arguments consumed, or 0 if the arguments are invalid.
+func parseExtendedColor(args []int) (int, int) {
+	if len(args) == 0 {
+		return 0, 0
 	}
-
-	// Move existing lines down
-	for i := vt.height - 1; i >= vt.cursorY+n; i-- {
-		if i < len(vt.buffer) && i-n < len(vt.buffer) && i-n >= 0 {
-			copy(vt.buffer[i], vt.buffer[i-n])
+	switch args[0] {
+	case 5: // ESC[38;5;Nm
+		if len(args) < 2 {
+			return 0, 0
 		}
-	}
-
-	// Clear the inserted lines
-	for i := 0; i < n && vt.cursorY+i < vt.height; i++ {
-		if vt.cursorY+i < len(vt.buffer) {
-			for x := 0; x < vt.width; x++ {
-				if x < len(vt.buffer[vt.cursorY+i]) {
-					vt.buffer[vt.cursorY+i][x] = cell{char: ' ', attrs: vt.defaultAttrs}
-				}
-			}
+		return min(max(args[1], 0), 255), 2
+	case 2: // ESC[38;2;R;G;Bm
+		if len(args) < 4 {
+			return 0, 0
 		}
+		return rgbColor(args[1], args[2], args[3]), 4
 	}
+	return 0, 0
 }
 
-// deleteLines deletes n lines at cursor position, scrolling up
-func (vt *VTerminal) deleteLines(n int) {
-	if vt.cursorY >= vt.height || n <= 0 {
+// insertLines inserts n blank lines at the cursor, pushing the lines below
+// it down within the scrolling region (IL)
+func (vt *VTerminal) insertLines(n int) {
+	if vt.cursorY < vt.scrollTop || vt.cursorY > vt.scrollBottom || n <= 0 {
 		return
 	}
+	top := vt.scrollTop
+	vt.scrollTop = vt.cursorY
+	vt.scrollRegionDown(n)
+	vt.scrollTop = top
+	vt.cursorX = 0
+	vt.pendingWrap = false
+}
 
-	// Limit n to available lines
-	if vt.cursorY+n > vt.height {
-		n = vt.height - vt.cursorY
-	}
-
-	// Move lines up
-	for i := vt.cursorY; i < vt.height-n; i++ {
-		if i < len(vt.buffer) && i+n < len(vt.buffer) {
-			copy(vt.buffer[i], vt.buffer[i+n])
-		}
+// deleteLines deletes n lines at the cursor, pulling the lines below it up
+// within the scrolling region (DL)
+func (vt *VTerminal) deleteLines(n int) {
+	if vt.cursorY < vt.scrollTop || vt.cursorY > vt.scrollBottom || n <= 0 {
+		return
 	}
-
-	// Clear the bottom lines
-	for i := vt.height - n; i < vt.height; i++ {
-		if i >= 0 && i < len(vt.buffer) {
-			for x := 0; x < vt.width; x++ {
-				if x < len(vt.buffer[i]) {
-					vt.buffer[i][x] = cell{char: ' ', attrs: vt.defaultAttrs}
-				}
-			}
-		}
+	n = min(n, vt.scrollBottom-vt.cursorY+1)
+	for i := 0; i < n; i++ {
+		copy(vt.buffer[vt.cursorY:vt.scrollBottom], vt.buffer[vt.cursorY+1:vt.scrollBottom+1])
+		vt.buffer[vt.scrollBottom] = vt.blankLine()
 	}
+	vt.cursorX = 0
+	vt.pendingWrap = false
 }
 
 // deleteChars deletes n characters at cursor position, shifting line left
@@ -857,6 +1197,10 @@ func (vt *VTerminal) deleteChars(n int) {
 		n = len(line) - vt.cursorX
 	}
 
+	// Double-width characters cut by the deletion go as a whole
+	vt.splitWide(line, vt.cursorX)
+	vt.splitWide(line, vt.cursorX+n-1)
+
 	// Shift characters left
 	for i := vt.cursorX; i < len(line)-n; i++ {
 		line[i] = line[i+n]
@@ -884,6 +1228,13 @@ func (vt *VTerminal) insertChars(n int) {
 		n = len(line) - vt.cursorX
 	}
 
+	// Double-width characters cut by the insertion or pushed half off the
+	// end of the line go as a whole
+	if line[vt.cursorX].isWideTail() {
+		vt.splitWide(line, vt.cursorX)
+	}
+	vt.splitWide(line, len(line)-n)
+
 	// Shift characters right
 	for i := len(line) - 1; i >= vt.cursorX+n; i-- {
 		line[i] = line[i-n]
@@ -902,6 +1253,8 @@ func (vt *VTerminal) eraseChars(n int) {
 	}
 
 	line := vt.buffer[vt.cursorY]
+	vt.splitWide(line, vt.cursorX)
+	vt.splitWide(line, vt.cursorX+n-1)
 
 	// Erase up to n characters or end of line
 	for i := 0; i < n && vt.cursorX+i < len(line); i++ {
@@ -926,13 +1279,38 @@ func parseCSIParams(params string) []int {
 	return result
 }
 
+// parseSGRParams parses SGR parameters, flattening the ITU T.416 colon
+// form (38:2::R:G:B, 38:2:R:G:B, 38:5:N) into the semicolon form that
+// handleSGR understands.
+func parseSGRParams(params string) []int {
+	if !strings.Contains(params, ":") {
+		return parseCSIParams(params)
+	}
+
+	var result []int
+	for _, part := range strings.Split(params, ";") {
+		sub := parseCSIParams(strings.ReplaceAll(part, ":", ";"))
+		if len(sub) == 0 {
+			result = append(result, 0)
+			continue
+		}
+		// 38:2:<colorspace>:R:G:B carries an extra colorspace id
+		if (sub[0] == 38 || sub[0] == 48) && len(sub) >= 6 && sub[1] == 2 {
+			sub = append(sub[:2], sub[3:6]...)
+		}
+		result = append(result, sub...)
+	}
+	return result
+}
+
 // Render returns the visible terminal content as a string
 func (vt *VTerminal) Render() string {
-	vt.mutex.RLock()
-	defer vt.mutex.RUnlock()
+	vt.mutex.Lock()
+	defer vt.mutex.Unlock()
 
 	var buf bytes.Buffer
 	linesRendered := 0
+	vt.urlColumns = urlColumns(vt.viewURLs())
 
 	startLine := 0
 	endLine := vt.height
@@ -942,7 +1320,7 @@ func (vt *VTerminal) Render() string {
 
 	// If scrolled back, show scrollback content
 	if vt.scrollOffset > 0 {
-		scrollbackLen := len(vt.scrollback)
+		scrollbackLen := vt.scrollback.Len()
 		if vt.scrollOffset > scrollbackLen {
 			vt.scrollOffset = scrollbackLen
 		}
@@ -951,7 +1329,7 @@ func (vt *VTerminal) Render() string {
 		scrollbackStart := scrollbackLen - vt.scrollOffset
 		for i := scrollbackStart; i < scrollbackLen && (i-scrollbackStart) < vt.height; i++ {
 			lineY := i - scrollbackStart
-			vt.renderLine(&buf, vt.scrollback[i], false, -1, lineY)
+			vt.renderLine(&buf, vt.scrollback.Line(i, vt.width, vt.defaultAttrs), false, -1, lineY)
 			buf.WriteRune('\n')
 			linesRendered++
 		}
@@ -965,20 +1343,31 @@ func (vt *VTerminal) Render() string {
 			linesRendered++
 		}
 	} else {
+		// Rows left of the last render are dropped
+		cache := vt.renderCacheSpare
+		if cache == nil {
+			cache = make(map[*cell]renderedLine, vt.height)
+		}
+		clear(cache)
+
 		// Show current buffer with cursor
 		for i := startLine; i < endLine && i < len(vt.buffer); i++ {
 			line := vt.buffer[i]
 
 			// Render line with visual cursor if this is the cursor line
-			if showCursor && i == vt.cursorY {
+			switch {
+			case showCursor && i == vt.cursorY:
 				vt.renderLine(&buf, line, true, vt.cursorX, i)
-			} else {
+			case vt.isLineSelected(i) || len(line) == 0:
 				vt.renderLine(&buf, line, false, -1, i)
+			default:
+				vt.renderCachedLine(&buf, line, i, cache)
 			}
 
 			buf.WriteRune('\n')
 			linesRendered++
 		}
+		vt.renderCache, vt.renderCacheSpare = cache, vt.renderCache
 	}
 
 	// Fill any remaining lines with empty space to ensure we use the full height
@@ -991,6 +1380,33 @@ func (vt *VTerminal) Render() string {
 	return buf.String()
 }
 
+// renderCachedLine writes row y without cursor or selection, reusing its
+// last rendering when none of its cells or addresses changed since
+func (vt *VTerminal) renderCachedLine(buf *bytes.Buffer, line []cell, y int, cache map[*cell]renderedLine) {
+	key := &line[0]
+	urls := vt.urlColumns[y]
+	entry, ok := vt.renderCache[key]
+	if !ok || !slices.Equal(entry.cells, line) || !slices.Equal(entry.urls, urls) {
+		var lineBuf bytes.Buffer
+		vt.renderLine(&lineBuf, line, false, -1, y)
+		entry = renderedLine{cells: append(entry.cells[:0], line...), urls: urls, text: lineBuf.String()}
+	}
+	cache[key] = entry
+	buf.WriteString(entry.text)
+}
+
+// isLineSelected reports whether the selection covers part of row y
+func (vt *VTerminal) isLineSelected(y int) bool {
+	if vt.selectionStart == nil || vt.selectionEnd == nil {
+		return false
+	}
+	top, bottom := vt.selectionStart.y, vt.selectionEnd.y
+	if top > bottom {
+		top, bottom = bottom, top
+	}
+	return y >= top && y <= bottom
+}
+
 // renderLine renders a single line with color attributes and selection highlighting
 func (vt *VTerminal) renderLine(buf *bytes.Buffer, line []cell, showCursor bool, cursorX int, lineY int) {
 	var currentAttrs cellAttrs
@@ -998,41 +1414,8 @@ func (vt *VTerminal) renderLine(buf *bytes.Buffer, line []cell, showCursor bool,
 	currentAttrs.bgColor = -1
 
 	// Calculate selection range for this line (if any)
-	var hasSelection bool
-	var selStartX, selEndX int
-
-	if vt.selectionStart != nil && vt.selectionEnd != nil {
-		startY, startX := vt.selectionStart.y, vt.selectionStart.x
-		endY, endX := vt.selectionEnd.y, vt.selectionEnd.x
-
-		// Normalize selection (ensure start is before end)
-		if startY > endY || (startY == endY && startX > endX) {
-			startY, endY = endY, startY
-			startX, endX = endX, startX
-		}
-
-		// Check if this line has selection
-		if lineY >= startY && lineY <= endY {
-			hasSelection = true
-			if lineY == startY && lineY == endY {
-				// Single line selection
-				selStartX = startX
-				selEndX = endX
-			} else if lineY == startY {
-				// First line of multi-line selection
-				selStartX = startX
-				selEndX = vt.width - 1
-			} else if lineY == endY {
-				// Last line of multi-line selection
-				selStartX = 0
-				selEndX = endX
-			} else {
-				// Middle line of multi-line selection
-				selStartX = 0
-				selEndX = vt.width - 1
-			}
-		}
-	}
+	selStartX, selEndX, hasSelection := vt.selectionColumns(lineY)
+	urls := vt.urlColumns[lineY]
 
 	for j := 0; j < vt.width; j++ {
 		var c cell
@@ -1042,8 +1425,9 @@ func (vt *VTerminal) renderLine(buf *bytes.Buffer, line []cell, showCursor bool,
 			c = cell{char: ' ', attrs: vt.defaultAttrs}
 		}
 
-		// Check if this is the cursor position
-		isCursor := showCursor && j == cursorX
+		// Check if this is the cursor position, which may be the right
+		// half of a double-width character
+		isCursor := showCursor && (j == cursorX || j+1 == cursorX && j+1 < len(line) && line[j+1].isWideTail())
 
 		// Check if this character is selected
 		isSelected := hasSelection && j >= selStartX && j <= selEndX
@@ -1062,12 +1446,16 @@ func (vt *VTerminal) renderLine(buf *bytes.Buffer, line []cell, showCursor bool,
 			attrs = c.attrs
 		}
 
+		// Hyperlinks and web addresses are shown underlined
+		if attrs.link != 0 || inURL(urls, j) {
+			attrs.underline = true
+		}
+
 		// Apply attributes if they changed
-		if attrs.fgColor != currentAttrs.fgColor || attrs.bgColor != currentAttrs.bgColor ||
-			attrs.bold != currentAttrs.bold || attrs.reverse != currentAttrs.reverse {
+		if attrs != currentAttrs {
 
 			// Reset to default if needed
-			if attrs.fgColor == -1 && attrs.bgColor == -1 && !attrs.bold && !attrs.reverse {
+			if attrs.isPlain() {
 				buf.WriteString("\x1B[0m")
 			} else {
 				// Build SGR sequence
@@ -1091,30 +1479,18 @@ func (vt *VTerminal) renderLine(buf *bytes.Buffer, line []cell, showCursor bool,
 					}
 				}
 
+				sgr = appendFlagSGR(sgr, attrs.italic, currentAttrs.italic, "3", "23")
+				sgr = appendFlagSGR(sgr, attrs.underline, currentAttrs.underline, "4", "24")
+				sgr = appendFlagSGR(sgr, attrs.strikethrough, currentAttrs.strikethrough, "9", "29")
+
 				// Handle foreground color
 				if attrs.fgColor != currentAttrs.fgColor {
-					if attrs.fgColor == -1 {
-						sgr = append(sgr, "39")
-					} else if attrs.fgColor < 8 {
-						sgr = append(sgr, fmt.Sprintf("%d", 30+attrs.fgColor))
-					} else if attrs.fgColor < 16 {
-						sgr = append(sgr, fmt.Sprintf("%d", 90+attrs.fgColor-8))
-					} else {
-						sgr = append(sgr, fmt.Sprintf("38;5;%d", attrs.fgColor))
-					}
+					sgr = append(sgr, colorSGR(attrs.fgColor, 30))
 				}
 
 				// Handle background color
 				if attrs.bgColor != currentAttrs.bgColor {
-					if attrs.bgColor == -1 {
-						sgr = append(sgr, "49")
-					} else if attrs.bgColor < 8 {
-						sgr = append(sgr, fmt.Sprintf("%d", 40+attrs.bgColor))
-					} else if attrs.bgColor < 16 {
-						sgr = append(sgr, fmt.Sprintf("%d", 100+attrs.bgColor-8))
-					} else {
-						sgr = append(sgr, fmt.Sprintf("48;5;%d", attrs.bgColor))
-					}
+					sgr = append(sgr, colorSGR(attrs.bgColor, 40))
 				}
 
 				if len(sgr) > 0 {
@@ -1125,16 +1501,107 @@ func (vt *VTerminal) renderLine(buf *bytes.Buffer, line []cell, showCursor bool,
 			currentAttrs = attrs
 		}
 
-		// Write the character
-		buf.WriteRune(c.char)
+		// Write the character; a double-width one covers the next cell too
+		writeCellText(buf, c)
 	}
 
 	// Reset attributes at end of line
-	if currentAttrs.fgColor != -1 || currentAttrs.bgColor != -1 || currentAttrs.bold || currentAttrs.reverse {
+	if !currentAttrs.isPlain() {
 		buf.WriteString("\x1B[0m")
 	}
 }
 
+// appendFlagSGR appends the on or off code when a boolean attribute changes
+func appendFlagSGR(sgr []string, want, have bool, on, off string) []string {
+	if want == have {
+		return sgr
+	}
+	if want {
+		return append(sgr, on)
+	}
+	return append(sgr, off)
+}
+
+// viewLine returns the cells shown on row y of the current view, taking
+// scrollback into account, or nil if y is outside the view
+func (vt *VTerminal) viewLine(y int) []cell {
+	if y < 0 || y >= vt.height {
+		return nil
+	}
+	scrollOffset := min(vt.scrollOffset, vt.scrollback.Len())
+	if y < scrollOffset {
+		return vt.scrollback.Line(vt.scrollback.Len()-scrollOffset+y, vt.width, vt.defaultAttrs)
+	}
+	if y-scrollOffset < len(vt.buffer) {
+		return vt.buffer[y-scrollOffset]
+	}
+	return nil
+}
+
+// LinkAt returns the hyperlink target or web address under view position
+// (x, y), or ""
+func (vt *VTerminal) LinkAt(x, y int) string {
+	vt.mutex.RLock()
+	defer vt.mutex.RUnlock()
+
+	line := vt.viewLine(y)
+	if x < 0 || x >= len(line) {
+		return ""
+	}
+	if line[x].attrs.link != 0 {
+		return vt.links[line[x].attrs.link-1]
+	}
+	for _, u := range vt.viewURLs() {
+		if u.covers(x, y) {
+			return u.uri
+		}
+	}
+	return ""
+}
+
+// VisibleLinks returns the distinct hyperlink targets and web addresses
+// currently on screen, top to bottom
+func (vt *VTerminal) VisibleLinks() []string {
+	vt.mutex.RLock()
+	defer vt.mutex.RUnlock()
+
+	urls := vt.viewURLs()
+	var links []string
+	add := func(uri string) {
+		if !slices.Contains(links, uri) {
+			links = append(links, uri)
+		}
+	}
+	for y := 0; y < vt.height; y++ {
+		for x, c := range vt.viewLine(y) {
+			if c.attrs.link != 0 {
+				add(vt.links[c.attrs.link-1])
+			}
+			// Addresses are listed where they start
+			for len(urls) > 0 && urls[0].spans[0].y == y && urls[0].spans[0].from == x {
+				add(urls[0].uri)
+				urls = urls[1:]
+			}
+		}
+	}
+	return links
+}
+
+// SelectedLinks returns the distinct web addresses in the selected text
+func (vt *VTerminal) SelectedLinks() []string {
+	vt.mutex.RLock()
+	defer vt.mutex.RUnlock()
+	return urlsIn(vt.selectedText())
+}
+
+// BracketedPaste reports whether the remote application enabled bracketed
+// paste mode, so pasted text should be wrapped in ESC[200~ / ESC[201~
+func (vt *VTerminal) BracketedPaste() bool {
+	vt.mutex.RLock()
+	defer vt.mutex.RUnlock()
+	return vt.bracketPaste
+}
+
 // GetCursorPosition returns the current cursor position
 func (vt *VTerminal) GetCursorPosition() (int, int) {
 	vt.mutex.RLock()
@@ -1142,13 +1609,62 @@ func (vt *VTerminal) GetCursorPosition() (int, int) {
 	return vt.cursorX, vt.cursorY
 }
 
+// TextBeforeCursor returns the text on the cursor's line up to the cursor,
+// such as a shell prompt waiting for input
+func (vt *VTerminal) TextBeforeCursor() string {
+	vt.mutex.RLock()
+	defer vt.mutex.RUnlock()
+
+	if vt.cursorY < 0 || vt.cursorY >= len(vt.buffer) {
+		return ""
+	}
+	line := vt.buffer[vt.cursorY]
+	var b strings.Builder
+	for x := 0; x < vt.cursorX && x < len(line); x++ {
+		writeCellText(&b, line[x])
+	}
+	return b.String()
+}
+
+// History returns the text of the scrollback and of the screen down to
+// its last non-blank row, oldest line first
+func (vt *VTerminal) History() []string {
+	vt.mutex.RLock()
+	defer vt.mutex.RUnlock()
+
+	lines := make([]string, 0, vt.scrollback.Len()+vt.height)
+	for i := range vt.scrollback.Len() {
+		lines = append(lines, vt.scrollback.Text(i))
+	}
+	screen := make([]string, 0, vt.height)
+	for _, row := range vt.buffer {
+		screen = append(screen, packLine(row, vt.defaultAttrs).text)
+	}
+	for len(screen) > 0 && strings.TrimSpace(screen[len(screen)-1]) == "" {
+		screen = screen[:len(screen)-1]
+	}
+	return append(lines, screen...)
+}
+
+// RestoreHistory adds lines to the scrollback as plain text, such as the
+// output of an earlier session, keeping the newest ones when there are
+// more than it holds
+func (vt *VTerminal) RestoreHistory(lines []string) {
+	vt.mutex.Lock()
+	defer vt.mutex.Unlock()
+
+	for _, line := range lines[max(len(lines)-vt.maxScrollback, 0):] {
+		vt.scrollback.PushText(line)
+	}
+}
+
 // ScrollUp scrolls the view up by n lines
 func (vt *VTerminal) ScrollUp(n int) {
 	vt.mutex.Lock()
 	defer vt.mutex.Unlock()
 
 	vt.scrollOffset += n
-	maxScroll := len(vt.scrollback)
+	maxScroll := vt.scrollback.Len()
 	if vt.scrollOffset > maxScroll {
 		vt.scrollOffset = maxScroll
 	}
@@ -1183,7 +1699,7 @@ func (vt *VTerminal) ScrollToBottom() {
 func (vt *VTerminal) ScrollToTop() {
 	vt.mutex.Lock()
 	defer vt.mutex.Unlock()
-	vt.scrollOffset = len(vt.scrollback)
+	vt.scrollOffset = vt.scrollback.Len()
 }
 
 // Clear clears the terminal buffer
@@ -1196,6 +1712,8 @@ func (vt *VTerminal) Clear() {
 // clearInternal clears the terminal buffer without locking (for internal use)
 func (vt *VTerminal) clearInternal() {
 	vt.initBuffer()
+	vt.scrollback = newScrollback(vt.maxScrollback)
+	vt.resetScrollRegion()
 	vt.cursorX = 0
 	vt.cursorY = 0
 	vt.scrollOffset = 0
@@ -1207,6 +1725,17 @@ func (vt *VTerminal) StartSelection(x, y int) {
 	defer vt.mutex.Unlock()
 	vt.selectionStart = &position{x: x, y: y}
 	vt.selectionEnd = nil
+	vt.selectionBlock = false
+}
+
+// StartBlockSelection begins a rectangular selection at the given
+// position, covering the same columns on every row it spans
+func (vt *VTerminal) StartBlockSelection(x, y int) {
+	vt.mutex.Lock()
+	defer vt.mutex.Unlock()
+	vt.selectionStart = &position{x: x, y: y}
+	vt.selectionEnd = nil
+	vt.selectionBlock = true
 }
 
 // UpdateSelection updates the selection end position
@@ -1218,69 +1747,151 @@ func (vt *VTerminal) UpdateSelection(x, y int) {
 	}
 }
 
+// SelectWord selects the word under view position (x, y): a run of
+// characters without blanks or brackets and quotes, so paths and URLs
+// are selected whole
+func (vt *VTerminal) SelectWord(x, y int) {
+	vt.mutex.Lock()
+	defer vt.mutex.Unlock()
+
+	line := vt.viewLine(y)
+	if x < 0 || x >= len(line) {
+		return
+	}
+	if line[x].isWideTail() && x > 0 {
+		x--
+	}
+	start, end := x, x
+	if isWordCell(line[x]) {
+		for start > 0 && isWordCell(line[start-1]) {
+			start--
+		}
+		for end+1 < len(line) && isWordCell(line[end+1]) {
+			end++
+		}
+	}
+	if end+1 < len(line) && line[end+1].isWideTail() {
+		end++
+	}
+	vt.selectionStart = &position{x: start, y: y}
+	vt.selectionEnd = &position{x: end, y: y}
+	vt.selectionBlock = false
+}
+
+// isWordCell reports whether c is part of a word for SelectWord
+func isWordCell(c cell) bool {
+	return c.isWideTail() || !strings.ContainsRune(" \t\"'`()[]{}<>|,;", c.char)
+}
+
+// SelectLine selects the line at view row y, with the rows it wrapped
+// from and onto
+func (vt *VTerminal) SelectLine(y int) {
+	vt.mutex.Lock()
+	defer vt.mutex.Unlock()
+
+	if vt.viewLine(y) == nil {
+		return
+	}
+	top, bottom := y, y
+	for top > 0 && vt.rowWrapped(top-1) {
+		top--
+	}
+	for bottom < vt.height-1 && vt.rowWrapped(bottom) {
+		bottom++
+	}
+	vt.selectionStart = &position{x: 0, y: top}
+	vt.selectionEnd = &position{x: vt.width - 1, y: bottom}
+	vt.selectionBlock = false
+}
+
+// rowWrapped reports whether view row y continues on the next row
+func (vt *VTerminal) rowWrapped(y int) bool {
+	line := vt.viewLine(y)
+	return len(line) > 0 && line[len(line)-1].wrap
+}
+
 // ClearSelection clears the current selection
 func (vt *VTerminal) ClearSelection() {
 	vt.mutex.Lock()
 	defer vt.mutex.Unlock()
 	vt.selectionStart = nil
 	vt.selectionEnd = nil
+	vt.selectionBlock = false
 }
 
-// CopySelection copies the selected text to the clipboard
-func (vt *VTerminal) CopySelection() error {
-	vt.mutex.RLock()
-	defer vt.mutex.RUnlock()
-
+// selectionColumns returns the first and last selected column of view
+// row y, and false when the selection doesn't reach the row
+func (vt *VTerminal) selectionColumns(y int) (int, int, bool) {
 	if vt.selectionStart == nil || vt.selectionEnd == nil {
-		return fmt.Errorf("no selection")
+		return 0, 0, false
 	}
+	start, end := *vt.selectionStart, *vt.selectionEnd
+	if start.y > end.y || (start.y == end.y && start.x > end.x) {
+		start, end = end, start
+	}
+	if y < start.y || y > end.y {
+		return 0, 0, false
+	}
+	if vt.selectionBlock {
+		return min(start.x, end.x), max(start.x, end.x), true
+	}
+	from, to := 0, vt.width-1
+	if y == start.y {
+		from = start.x
+	}
+	if y == end.y {
+		to = end.x
+	}
+	return from, to, true
+}
 
-	// Normalize selection (ensure start is before end)
-	startY, startX := vt.selectionStart.y, vt.selectionStart.x
-	endY, endX := vt.selectionEnd.y, vt.selectionEnd.x
-
-	if startY > endY || (startY == endY && startX > endX) {
-		startY, endY = endY, startY
-		startX, endX = endX, startX
+// selectedText returns the selected text. Trailing blanks are dropped
+// from every line, and rows the text wrapped across are joined back into
+// one line unless the selection is rectangular.
+func (vt *VTerminal) selectedText() string {
+	if vt.selectionStart == nil || vt.selectionEnd == nil {
+		return ""
 	}
+	top, bottom := min(vt.selectionStart.y, vt.selectionEnd.y), max(vt.selectionStart.y, vt.selectionEnd.y)
 
 	var text strings.Builder
-
-	// Single line selection
-	if startY == endY {
-		if startY < len(vt.buffer) {
-			for x := startX; x <= endX && x < len(vt.buffer[startY]); x++ {
-				text.WriteRune(vt.buffer[startY][x].char)
-			}
+	for y := top; y <= bottom; y++ {
+		line := vt.viewLine(y)
+		from, to, ok := vt.selectionColumns(y)
+		if line == nil || !ok {
+			continue
 		}
-	} else {
-		// Multi-line selection
-		// First line
-		if startY < len(vt.buffer) {
-			for x := startX; x < len(vt.buffer[startY]); x++ {
-				text.WriteRune(vt.buffer[startY][x].char)
-			}
-			text.WriteRune('\n')
+		// A double-width character cut by the selection is copied whole
+		from = max(from, 0)
+		if from > 0 && from < len(line) && line[from].isWideTail() {
+			from--
 		}
-
-		// Middle lines
-		for y := startY + 1; y < endY && y < len(vt.buffer); y++ {
-			for x := 0; x < len(vt.buffer[y]); x++ {
-				text.WriteRune(vt.buffer[y][x].char)
-			}
-			text.WriteRune('\n')
+		var row strings.Builder
+		for x := from; x <= to && x < len(line); x++ {
+			writeCellText(&row, line[x])
 		}
-
-		// Last line
-		if endY < len(vt.buffer) {
-			for x := 0; x <= endX && x < len(vt.buffer[endY]); x++ {
-				text.WriteRune(vt.buffer[endY][x].char)
-			}
+		joined := !vt.selectionBlock && y < bottom && to >= len(line)-1 && line[len(line)-1].wrap
+		if joined {
+			text.WriteString(row.String())
+			continue
+		}
+		text.WriteString(strings.TrimRight(row.String(), " \t"))
+		if y < bottom {
+			text.WriteByte('\n')
 		}
 	}
+	return text.String()
+}
+
+// CopySelection copies the selected text to the clipboard
+func (vt *VTerminal) CopySelection() error {
+	vt.mutex.RLock()
+	defer vt.mutex.RUnlock()
 
-	selectedText := strings.TrimRight(text.String(), " \n")
-	return clipboard.WriteAll(selectedText)
+	if vt.selectionStart == nil || vt.selectionEnd == nil {
+		return fmt.Errorf("no selection")
+	}
+	return clipboard.WriteAll(strings.TrimRight(vt.selectedText(), "\n"))
 }
 
 // HasSelection returns true if there is an active selection
@@ -236,4 +236,68 @@ func TestVTerminalMixedColorText(t *testing.T) {
 			t.Errorf("Expected red color code for error, got: %q", output)
 		}
 	})
+
+	t.Run("Truecolor foreground and background", func(t *testing.T) {
+		vt := NewVTerminal(80, 24)
+		vt.Write([]byte("\x1B[38;2;255;128;0;48;2;10;20;30mRGB\x1B[0m"))
+		output := vt.Render()
+
+		if !strings.Contains(output, "38;2;255;128;0") {
+			t.Errorf("Expected truecolor foreground 38;2;255;128;0 in output, got: %q", output)
+		}
+		if !strings.Contains(output, "48;2;10;20;30") {
+			t.Errorf("Expected truecolor background 48;2;10;20;30 in output, got: %q", output)
+		}
+	})
+
+	t.Run("Truecolor colon syntax", func(t *testing.T) {
+		vt := NewVTerminal(80, 24)
+		// ITU T.416 form with empty colorspace id, as emitted by some tools
+		vt.Write([]byte("\x1B[38:2::1:2:3mA\x1B[48:5:208mB\x1B[0m"))
+		output := vt.Render()
+
+		if !strings.Contains(output, "38;2;1;2;3") {
+			t.Errorf("Expected colon truecolor to render as 38;2;1;2;3, got: %q", output)
+		}
+		if !strings.Contains(output, "48;5;208") {
+			t.Errorf("Expected colon 256-color background 48;5;208, got: %q", output)
+		}
+	})
+
+	t.Run("Truecolor followed by other attributes", func(t *testing.T) {
+		vt := NewVTerminal(80, 24)
+		// Bold must still apply after the five RGB arguments are consumed
+		vt.Write([]byte("\x1B[38;2;1;2;3;1mX"))
+
+		c := vt.buffer[0][0]
+		if c.attrs.fgColor != rgbColor(1, 2, 3) {
+			t.Errorf("Expected RGB foreground, got %#x", c.attrs.fgColor)
+		}
+		if !c.attrs.bold {
+			t.Error("Expected bold after truecolor sequence")
+		}
+	})
+
+	t.Run("Italic underline and strikethrough", func(t *testing.T) {
+		vt := NewVTerminal(80, 24)
+		vt.Write([]byte("\x1B[3;4;9mA\x1B[23;24mB\x1B[29mC"))
+
+		a, b, c := vt.buffer[0][0].attrs, vt.buffer[0][1].attrs, vt.buffer[0][2].attrs
+		if !a.italic || !a.underline || !a.strikethrough {
+			t.Errorf("Expected italic, underline and strikethrough on A, got %+v", a)
+		}
+		if b.italic || b.underline || !b.strikethrough {
+			t.Errorf("Expected only strikethrough on B, got %+v", b)
+		}
+		if !c.isPlain() {
+			t.Errorf("Expected plain attributes on C, got %+v", c)
+		}
+
+		output := vt.Render()
+		for _, code := range []string{"\x1B[3;4;9m", "\x1B[23;24m", "\x1B[0m"} {
+			if !strings.Contains(output, code) {
+				t.Errorf("Expected %q in output, got: %q", code, output)
+			}
+		}
+	})
 }
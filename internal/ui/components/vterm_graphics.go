@@ -0,0 +1,217 @@
+package components
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/termimg"
+)
+
+// Images drawn inline by programs in a session, with sixel (img2sixel), the
+// kitty graphics protocol (kitty +kitten icat) or iTerm2 inline images
+// (imgcat), are drawn in the cells as ASCII art, as the screen is rendered
+// as text.
+
+// maxGraphicsSequence bounds the escape sequences carrying an image
+const maxGraphicsSequence = 16 << 20
+
+// isGraphicsSequence reports whether the escape sequence being received
+// may carry an image: a DCS or APC string, or an iTerm2 file
+func (vt *VTerminal) isGraphicsSequence() bool {
+	switch vt.escapeSeq[1] {
+	case 'P', '_':
+		return true
+	case ']':
+		return bytes.HasPrefix(vt.escapeSeq[2:], []byte("1337;File="))
+	}
+	return false
+}
+
+// handleDCS draws sixel images: ESC P params q data ST. Other device
+// control strings are ignored.
+func (vt *VTerminal) handleDCS() {
+	body, ok := bytes.CutSuffix(vt.escapeSeq[2:], []byte{0x1B, '\\'})
+	if !ok {
+		return // truncated
+	}
+	i := bytes.IndexFunc(body, func(r rune) bool { return (r < '0' || r > '9') && r != ';' })
+	if i < 0 || body[i] != 'q' {
+		return
+	}
+	img, err := termimg.DecodeSixel(body[i+1:])
+	if err != nil {
+		return
+	}
+	// Like a terminal, the cursor goes under the image
+	x := vt.cursorX
+	vt.drawImage(img, 0, 0)
+	vt.newLine()
+	vt.cursorX = x
+}
+
+// handleAPC draws kitty graphics: ESC _ G key=value,... ; base64 data ST,
+// the data being split over several sequences with m=1 on all but the
+// last. Only images sent in the sequences and shown right away (a=T) are
+// drawn, as files named by the remote are not on this machine.
+func (vt *VTerminal) handleAPC() {
+	body, ok := bytes.CutSuffix(vt.escapeSeq[2:], []byte{0x1B, '\\'})
+	if !ok || len(body) == 0 || body[0] != 'G' {
+		return
+	}
+	control, payload, _ := strings.Cut(string(body[1:]), ";")
+	params := make(map[string]string)
+	for _, kv := range strings.Split(control, ",") {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			params[k] = v
+		}
+	}
+
+	// Chunks after the first carry only m, the rest is in the first
+	more := params["m"] == "1"
+	if vt.kittyParams == nil {
+		vt.kittyParams = params
+	}
+	vt.kittyData = append(vt.kittyData, payload...)
+	if len(vt.kittyData) > maxGraphicsSequence {
+		vt.kittyParams, vt.kittyData = nil, nil
+		return
+	}
+	if more {
+		return
+	}
+	params, data := vt.kittyParams, string(vt.kittyData)
+	vt.kittyParams, vt.kittyData = nil, nil
+
+	if params["a"] == "q" {
+		// A query for support, answered so icat goes on to send the image
+		vt.replyKitty(params, nil)
+		return
+	}
+	if params["a"] != "T" || (params["t"] != "" && params["t"] != "d") {
+		return
+	}
+	img, err := decodeKittyImage(params, data)
+	if err == nil {
+		cols, _ := strconv.Atoi(params["c"])
+		rows, _ := strconv.Atoi(params["r"])
+		x, y := vt.cursorX, vt.cursorY
+		vt.drawImage(img, cols, rows)
+		if params["C"] == "1" {
+			vt.cursorX, vt.cursorY = x, y
+		}
+	}
+	vt.replyKitty(params, err)
+}
+
+// replyKitty answers a kitty graphics command that has an image ID, unless
+// the command asked for no answers
+func (vt *VTerminal) replyKitty(params map[string]string, err error) {
+	id := params["i"]
+	if id == "" || params["q"] == "2" || (err == nil && params["q"] == "1") {
+		return
+	}
+	status := "OK"
+	if err != nil {
+		status = "EINVAL:" + err.Error()
+	}
+	vt.replies = fmt.Appendf(vt.replies, "\x1b_Gi=%s;%s\x1b\\", id, status)
+}
+
+// decodeKittyImage reads the base64 data of a kitty graphics command: PNG
+// (f=100) or raw RGB (f=24) or RGBA (f=32, the default) pixels of s × v,
+// zlib-compressed with o=z
+func decodeKittyImage(params map[string]string, data string) (image.Image, error) {
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+	if params["o"] == "z" {
+		r, err := zlib.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+		if raw, err = io.ReadAll(io.LimitReader(r, maxGraphicsSequence)); err != nil {
+			return nil, err
+		}
+	}
+	switch params["f"] {
+	case "100":
+		return termimg.Decode(raw)
+	case "24", "32", "":
+		channels := 4
+		if params["f"] == "24" {
+			channels = 3
+		}
+		w, _ := strconv.Atoi(params["s"])
+		h, _ := strconv.Atoi(params["v"])
+		if w <= 0 || h <= 0 || len(raw) < w*h*channels {
+			return nil, errors.New("pixel data does not match the size")
+		}
+		img := image.NewNRGBA(image.Rect(0, 0, w, h))
+		for i := range w * h {
+			p := raw[i*channels:]
+			c := color.NRGBA{R: p[0], G: p[1], B: p[2], A: 255}
+			if channels == 4 {
+				c.A = p[3]
+			}
+			img.SetNRGBA(i%w, i/w, c)
+		}
+		return img, nil
+	}
+	return nil, errors.New("unsupported format " + params["f"])
+}
+
+// handleITermImage draws an iTerm2 inline image: OSC 1337 ; File=args :
+// base64 data. Files sent for download (without inline=1) are ignored.
+func (vt *VTerminal) handleITermImage(arg string) {
+	args, data, ok := strings.Cut(strings.TrimPrefix(arg, "File="), ":")
+	if !ok || !strings.Contains(";"+args+";", ";inline=1;") {
+		return
+	}
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return
+	}
+	img, err := termimg.Decode(raw)
+	if err != nil {
+		return
+	}
+	vt.drawImage(img, 0, 0)
+}
+
+// drawImage draws img as ASCII art at the cursor, in cols × rows cells or,
+// when they are 0, as large as it fits on the screen without being
+// enlarged. The cursor is left after the last row of the image.
+func (vt *VTerminal) drawImage(img image.Image, cols, rows int) {
+	maxCols, maxRows := vt.width-vt.cursorX, vt.height
+	if cols <= 0 || cols > maxCols {
+		cols = maxCols
+	}
+	if rows <= 0 || rows > maxRows {
+		rows = maxRows
+	}
+	b := img.Bounds()
+	cols, rows = termimg.Fit(b.Dx(), b.Dy(), cols, rows)
+	if cols == 0 {
+		return
+	}
+	x := vt.cursorX
+	for i, line := range termimg.ASCIIArt(img, cols, rows) {
+		if i > 0 {
+			vt.newLine()
+			vt.cursorX = x
+		}
+		vt.pendingWrap = false
+		for _, r := range fmt.Sprintf("%-*s", cols, line) {
+			vt.putChar(r)
+		}
+	}
+}
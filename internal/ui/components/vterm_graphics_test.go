@@ -0,0 +1,104 @@
+package components
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/termimg"
+)
+
+// whiteImage is an opaque white image of w × h pixels
+func whiteImage(w, h int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 255
+	}
+	return img
+}
+
+func pngBytes(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func pngBase64(t *testing.T, img image.Image) string {
+	return base64.StdEncoding.EncodeToString(pngBytes(t, img))
+}
+
+func TestVTerminalInlineImages(t *testing.T) {
+	t.Run("Sixel", func(t *testing.T) {
+		vt := NewVTerminal(40, 10)
+		// Without the cursor save and restore around it, like img2sixel
+		sixel := termimg.EncodeSixel(whiteImage(40, 40), 4, 2)
+		sixel = strings.TrimSuffix(strings.TrimPrefix(sixel, "\x1b7"), "\x1b8")
+		vt.Write([]byte(sixel + "after"))
+		for y, want := range []string{"@@@@", "@@@@", "after"} {
+			if got := lineText(vt, y); got != want {
+				t.Errorf("Row %d = %q, want %q", y, got, want)
+			}
+		}
+	})
+
+	t.Run("Kitty in chunks", func(t *testing.T) {
+		vt := NewVTerminal(40, 10)
+		data := pngBase64(t, whiteImage(60, 40))
+		half := len(data) / 2
+		vt.Write([]byte("\x1b_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\x1b\\"))
+		vt.Write([]byte("\x1b_Ga=T,f=100,i=7,c=6,r=2,m=1;" + data[:half] + "\x1b\\"))
+		vt.Write([]byte("\x1b_Gm=0;" + data[half:] + "\x1b\\"))
+		for y := range 2 {
+			if got := lineText(vt, y); got != "@@@@@@" {
+				t.Errorf("Row %d = %q, want the image fit in 6×2 cells", y, got)
+			}
+		}
+		if got := string(vt.TakeReplies()); got != "\x1b_Gi=31;OK\x1b\\\x1b_Gi=7;OK\x1b\\" {
+			t.Errorf("Replies = %q", got)
+		}
+	})
+
+	t.Run("Kitty raw pixels", func(t *testing.T) {
+		vt := NewVTerminal(40, 10)
+		pixels := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{255, 255, 255}, 20*20))
+		vt.Write([]byte(fmt.Sprintf("\x1b_Ga=T,f=24,s=20,v=20,q=2;%s\x1b\\", pixels)))
+		if got := lineText(vt, 0); got != "@@" || len(vt.TakeReplies()) != 0 {
+			t.Errorf("Expected a 2×1 image and no reply, got %q", got)
+		}
+	})
+
+	t.Run("iTerm2", func(t *testing.T) {
+		vt := NewVTerminal(40, 10)
+		data := pngBase64(t, whiteImage(20, 20))
+		vt.Write([]byte("\x1b]1337;File=name=eC5wbmc=;inline=1:" + data + "\x07"))
+		vt.Write([]byte("\x1b]1337;File=name=eS5wbmc=:" + data + "\x07"))
+		if got := lineText(vt, 0); got != "@@" || lineText(vt, 1) != "" {
+			t.Errorf("Expected only the inline image drawn, got %q", got)
+		}
+	})
+
+	t.Run("Other strings are swallowed", func(t *testing.T) {
+		vt := NewVTerminal(40, 10)
+		vt.Write([]byte("a\x1bP$qm\x1b\\b\x1b_Xignored\x1b\\c"))
+		if got := lineText(vt, 0); got != "abc" {
+			t.Errorf("Expected DCS and APC strings not to be printed, got %q", got)
+		}
+	})
+
+	// Transparent pixels are left blank
+	img := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	img.SetNRGBA(0, 0, color.NRGBA{A: 0})
+	vt := NewVTerminal(40, 10)
+	vt.Write([]byte(termimg.EncodeSixel(img, 2, 1) + "x"))
+	if got := lineText(vt, 0); got != "x" {
+		t.Errorf("Expected an empty image to draw nothing, got %q", got)
+	}
+}
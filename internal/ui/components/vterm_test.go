@@ -2,6 +2,7 @@ package components
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -708,3 +709,413 @@ func TestVTerminalCursorPositioning(t *testing.T) {
 		}
 	})
 }
+
+// lineText returns the trimmed text of a buffer row
+func lineText(vt *VTerminal, y int) string {
+	var b strings.Builder
+	for _, c := range vt.buffer[y] {
+		b.WriteRune(c.char)
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+func TestVTerminalScrollRegion(t *testing.T) {
+	// fill writes one labelled line per row without scrolling
+	fill := func(vt *VTerminal) {
+		for y := 0; y < vt.height; y++ {
+			vt.Write([]byte(fmt.Sprintf("\x1B[%d;1Hrow%d", y+1, y)))
+		}
+	}
+
+	t.Run("Line feed scrolls only inside the region", func(t *testing.T) {
+		vt := NewVTerminal(20, 6)
+		fill(vt)
+		vt.Write([]byte("\x1B[2;4r"))   // rows 1-3 (0-based) scroll
+		vt.Write([]byte("\x1B[4;1H\n")) // LF at the bottom margin
+
+		want := []string{"row0", "row2", "row3", "", "row4", "row5"}
+		for y, w := range want {
+			if got := lineText(vt, y); got != w {
+				t.Errorf("row %d: expected %q, got %q", y, w, got)
+			}
+		}
+		if vt.scrollback.Len() != 0 {
+			t.Errorf("Expected no scrollback for a region below the top, got %d lines", vt.scrollback.Len())
+		}
+	})
+
+	t.Run("DECSTBM homes the cursor", func(t *testing.T) {
+		vt := NewVTerminal(20, 6)
+		vt.Write([]byte("\x1B[5;5H\x1B[2;4r"))
+		if x, y := vt.GetCursorPosition(); x != 0 || y != 0 {
+			t.Errorf("Expected cursor at (0, 0), got (%d, %d)", x, y)
+		}
+	})
+
+	t.Run("Reverse index scrolls down at the top margin", func(t *testing.T) {
+		vt := NewVTerminal(20, 6)
+		fill(vt)
+		vt.Write([]byte("\x1B[2;4r\x1B[2;1H\x1BM"))
+
+		want := []string{"row0", "", "row1", "row2", "row4", "row5"}
+		for y, w := range want {
+			if got := lineText(vt, y); got != w {
+				t.Errorf("row %d: expected %q, got %q", y, w, got)
+			}
+		}
+	})
+
+	t.Run("Insert and delete lines stay inside the region", func(t *testing.T) {
+		vt := NewVTerminal(20, 6)
+		fill(vt)
+		vt.Write([]byte("\x1B[2;4r\x1B[2;1H\x1B[L"))
+		want := []string{"row0", "", "row1", "row2", "row4", "row5"}
+		for y, w := range want {
+			if got := lineText(vt, y); got != w {
+				t.Errorf("after IL row %d: expected %q, got %q", y, w, got)
+			}
+		}
+
+		vt.Write([]byte("\x1B[2M"))
+		want = []string{"row0", "row2", "", "", "row4", "row5"}
+		for y, w := range want {
+			if got := lineText(vt, y); got != w {
+				t.Errorf("after DL row %d: expected %q, got %q", y, w, got)
+			}
+		}
+	})
+
+	t.Run("Reset restores the full screen region", func(t *testing.T) {
+		vt := NewVTerminal(20, 6)
+		vt.Write([]byte("\x1B[2;4r\x1B[r"))
+		if vt.scrollTop != 0 || vt.scrollBottom != 5 {
+			t.Errorf("Expected region 0-5, got %d-%d", vt.scrollTop, vt.scrollBottom)
+		}
+	})
+}
+
+func TestVTerminalHyperlinks(t *testing.T) {
+	t.Run("OSC 8 links cells until closed", func(t *testing.T) {
+		vt := NewVTerminal(40, 5)
+		vt.Write([]byte("see \x1B]8;;https://example.com\x07docs\x1B]8;;\x07 now"))
+
+		if got := vt.LinkAt(4, 0); got != "https://example.com" {
+			t.Errorf("Expected link at (4, 0), got %q", got)
+		}
+		if got := vt.LinkAt(7, 0); got != "https://example.com" {
+			t.Errorf("Expected link at (7, 0), got %q", got)
+		}
+		if got := vt.LinkAt(3, 0); got != "" {
+			t.Errorf("Expected no link before the text, got %q", got)
+		}
+		if got := vt.LinkAt(9, 0); got != "" {
+			t.Errorf("Expected no link after the closing sequence, got %q", got)
+		}
+	})
+
+	t.Run("ST terminator and link params", func(t *testing.T) {
+		vt := NewVTerminal(40, 5)
+		vt.Write([]byte("\x1B]8;id=1;https://a.test\x1B\\A\x1B]8;;\x1B\\"))
+		if got := vt.LinkAt(0, 0); got != "https://a.test" {
+			t.Errorf("Expected link with ST terminator, got %q", got)
+		}
+	})
+
+	t.Run("Visible links are distinct and ordered", func(t *testing.T) {
+		vt := NewVTerminal(40, 5)
+		vt.Write([]byte("\x1B]8;;https://b.test\x07B\x1B]8;;\x07\r\n"))
+		vt.Write([]byte("\x1B]8;;https://a.test\x07A\x1B]8;;\x07 "))
+		vt.Write([]byte("\x1B]8;;https://b.test\x07B\x1B]8;;\x07"))
+
+		links := vt.VisibleLinks()
+		if len(links) != 2 || links[0] != "https://b.test" || links[1] != "https://a.test" {
+			t.Errorf("Expected [https://b.test https://a.test], got %v", links)
+		}
+	})
+
+	t.Run("Other OSC sequences are ignored", func(t *testing.T) {
+		vt := NewVTerminal(40, 5)
+		vt.Write([]byte("\x1B]0;window title\x07X"))
+		if vt.buffer[0][0].char != 'X' || vt.LinkAt(0, 0) != "" {
+			t.Errorf("Expected plain X after title change, got %q", vt.buffer[0][0].char)
+		}
+	})
+
+	t.Run("Linked text renders underlined", func(t *testing.T) {
+		vt := NewVTerminal(40, 5)
+		vt.Write([]byte("\x1B]8;;https://a.test\x07A\x1B]8;;\x07"))
+		if output := vt.Render(); !strings.Contains(output, "\x1B[4mA") {
+			t.Errorf("Expected underlined link text, got: %q", output)
+		}
+	})
+
+	t.Run("Plain-text addresses are detected", func(t *testing.T) {
+		vt := NewVTerminal(40, 5)
+		vt.Write([]byte("see https://ci.test/run/1. or (http://a.test/x_(y))\r\n"))
+		vt.Write([]byte("\x1B]8;;https://b.test\x07docs\x1B]8;;\x07 https://ci.test/run/1"))
+
+		want := []string{"https://ci.test/run/1", "http://a.test/x_(y)", "https://b.test"}
+		if links := vt.VisibleLinks(); !slices.Equal(links, want) {
+			t.Errorf("Expected %q, got %q", want, links)
+		}
+		if got := vt.LinkAt(10, 0); got != "https://ci.test/run/1" {
+			t.Errorf("Expected the address under (10, 0), got %q", got)
+		}
+		if got := vt.LinkAt(25, 0); got != "" {
+			t.Errorf("Expected the trailing period to be left out, got %q", got)
+		}
+		if output := vt.Render(); !strings.Contains(output, "\x1B[4mhttps://ci.test/run/1\x1B[0m.") {
+			t.Errorf("Expected the address underlined, got: %q", output)
+		}
+	})
+
+	t.Run("Addresses continue on wrapped rows", func(t *testing.T) {
+		vt := NewVTerminal(20, 5)
+		vt.Write([]byte("go https://example.test/very/long/path now"))
+		if got := vt.LinkAt(3, 1); got != "https://example.test/very/long/path" {
+			t.Errorf("Expected the whole address from its second row, got %q", got)
+		}
+		if got := vt.LinkAt(18, 1); got != "" {
+			t.Errorf("Expected no address after it, got %q", got)
+		}
+	})
+
+	t.Run("Addresses in the selection", func(t *testing.T) {
+		vt := NewVTerminal(40, 5)
+		vt.Write([]byte("a https://one.test\r\nb https://two.test"))
+		vt.StartSelection(0, 1)
+		vt.UpdateSelection(39, 1)
+		if links := vt.SelectedLinks(); !slices.Equal(links, []string{"https://two.test"}) {
+			t.Errorf("Expected only the selected address, got %q", links)
+		}
+	})
+}
+
+func TestVTerminalBracketedPasteMode(t *testing.T) {
+	vt := NewVTerminal(40, 5)
+	if vt.BracketedPaste() {
+		t.Error("Expected bracketed paste to be off by default")
+	}
+	vt.Write([]byte("\x1B[?2004h"))
+	if !vt.BracketedPaste() {
+		t.Error("Expected ?2004h to enable bracketed paste")
+	}
+	vt.Write([]byte("\x1B[?2004l"))
+	if vt.BracketedPaste() {
+		t.Error("Expected ?2004l to disable bracketed paste")
+	}
+}
+
+func TestVTerminalRenderCache(t *testing.T) {
+	vt := NewVTerminal(30, 4)
+	uncached := func() string {
+		vt.renderCache, vt.renderCacheSpare = nil, nil
+		return vt.Render()
+	}
+
+	steps := []string{
+		"one\r\ntwo\r\n\x1B[31mthree\x1B[0m",
+		"\r\nfour\r\nfive",     // scrolls the cached rows up
+		"\x1B[1;1Hxx",          // changes a row in place
+		"\x1B[2J\x1B[Hcleared", // clears the screen
+	}
+	for _, step := range steps {
+		vt.Render() // cache the rows before the change
+		vt.Write([]byte(step))
+		got := vt.Render()
+		if want := uncached(); got != want {
+			t.Errorf("After %q expected the cached render\n%q\nto match\n%q", step, got, want)
+		}
+	}
+
+	if len(vt.renderCache) != vt.height-1 {
+		t.Errorf("Expected every row but the cursor row cached, got %d", len(vt.renderCache))
+	}
+}
+
+// screenRow returns the text of screen row y without trailing blanks
+func screenRow(vt *VTerminal, y int) string {
+	var b strings.Builder
+	for _, c := range vt.buffer[y] {
+		writeCellText(&b, c)
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+func TestVTerminalWideCharacters(t *testing.T) {
+	t.Run("Wide characters take two columns", func(t *testing.T) {
+		vt := NewVTerminal(20, 3)
+		vt.Write([]byte("中文😀x"))
+		if x, _ := vt.GetCursorPosition(); x != 7 {
+			t.Errorf("Expected the cursor at column 7, got %d", x)
+		}
+		if !vt.buffer[0][1].isWideTail() || vt.buffer[0][6].char != 'x' {
+			t.Errorf("Expected each wide character followed by its right half, got %+v", vt.buffer[0][:7])
+		}
+		if got := screenRow(vt, 0); got != "中文😀x" {
+			t.Errorf("Expected the row to read back unchanged, got %q", got)
+		}
+	})
+
+	t.Run("Combining marks join the previous character", func(t *testing.T) {
+		vt := NewVTerminal(20, 3)
+		vt.Write([]byte("é中́a"))
+		if x, _ := vt.GetCursorPosition(); x != 4 {
+			t.Errorf("Expected combining marks to take no column, got cursor at %d", x)
+		}
+		if vt.buffer[0][0].comb != "́" || vt.buffer[0][1].comb != "́" {
+			t.Errorf("Expected the marks on the base characters, got %+v", vt.buffer[0][:4])
+		}
+		if got := screenRow(vt, 0); got != "é中́a" {
+			t.Errorf("Expected the row to read back unchanged, got %q", got)
+		}
+	})
+
+	t.Run("Wide character wraps from the last column", func(t *testing.T) {
+		vt := NewVTerminal(5, 3)
+		vt.Write([]byte("abcd中"))
+		if got := screenRow(vt, 0); got != "abcd" {
+			t.Errorf("Expected the last column left blank, got %q", got)
+		}
+		if got := screenRow(vt, 1); got != "中" {
+			t.Errorf("Expected the wide character on the next row, got %q", got)
+		}
+	})
+
+	t.Run("Overwriting half of a wide character blanks the other half", func(t *testing.T) {
+		vt := NewVTerminal(20, 3)
+		vt.Write([]byte("中文\x1b[1;2Hx"))
+		if got := screenRow(vt, 0); got != " x文" {
+			t.Errorf("Expected the left half blanked, got %q", got)
+		}
+		vt.Write([]byte("\x1b[1;3Hy"))
+		if got := screenRow(vt, 0); got != " xy" {
+			t.Errorf("Expected the right half blanked, got %q", got)
+		}
+	})
+
+	t.Run("Erase, delete and insert treat wide characters as a whole", func(t *testing.T) {
+		vt := NewVTerminal(20, 3)
+		vt.Write([]byte("a中b\x1b[1;3H\x1b[K"))
+		if got := screenRow(vt, 0); got != "a" {
+			t.Errorf("Expected erasing from the right half to erase the character, got %q", got)
+		}
+
+		vt.Write([]byte("\x1b[2;1Ha中b\x1b[2;2H\x1b[P"))
+		if got := screenRow(vt, 1); got != "a b" {
+			t.Errorf("Expected deleting the left half to blank the right one, got %q", got)
+		}
+
+		vt.Write([]byte("\x1b[3;1Ha中b\x1b[3;3H\x1b[@"))
+		if got := screenRow(vt, 2); got != "a   b" {
+			t.Errorf("Expected inserting into the character to blank it, got %q", got)
+		}
+		for x, c := range vt.buffer[2] {
+			if c.isWideTail() {
+				t.Errorf("Expected no right half left at column %d", x)
+			}
+		}
+	})
+
+	t.Run("Insert pushing a wide character half off the line blanks it", func(t *testing.T) {
+		vt := NewVTerminal(5, 3)
+		vt.Write([]byte("abc中\x1b[1;1H\x1b[@"))
+		if got := screenRow(vt, 0); got != " abc" {
+			t.Errorf("Expected the cut character dropped, got %q", got)
+		}
+	})
+}
+
+func TestVTerminalReports(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"Status", "\x1b[5n", "\x1b[0n"},
+		{"Cursor position", "ab\r\n中x\x1b[6n", "\x1b[2;4R"},
+		{"DEC cursor position", "\x1b[3;7H\x1b[?6n", "\x1b[?3;7R"},
+		{"Primary device attributes", "\x1b[c", "\x1b[?62;22c"},
+		{"Secondary device attributes", "\x1b[>c", "\x1b[>1;10;0c"},
+		{"Several queries in order", "\x1b[5n\x1b[6n", "\x1b[0n\x1b[1;1R"},
+		{"Unknown query", "\x1b[99n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vt := NewVTerminal(20, 5)
+			vt.Write([]byte(tt.input))
+			if got := string(vt.TakeReplies()); got != tt.want {
+				t.Errorf("Expected reply %q, got %q", tt.want, got)
+			}
+			if got := vt.TakeReplies(); got != nil {
+				t.Errorf("Expected replies to be taken once, got %q", got)
+			}
+		})
+	}
+}
+
+func TestVTerminalSelection(t *testing.T) {
+	t.Run("Trailing blanks are trimmed from every line", func(t *testing.T) {
+		vt := NewVTerminal(20, 4)
+		vt.Write([]byte("one   \r\ntwo"))
+		vt.StartSelection(0, 0)
+		vt.UpdateSelection(19, 1)
+		if got := vt.selectedText(); got != "one\ntwo" {
+			t.Errorf("Expected trimmed lines, got %q", got)
+		}
+	})
+
+	t.Run("Wrapped rows are joined", func(t *testing.T) {
+		vt := NewVTerminal(5, 4)
+		vt.Write([]byte("abcdefgh\r\nnext"))
+		vt.StartSelection(0, 0)
+		vt.UpdateSelection(4, 2)
+		if got := vt.selectedText(); got != "abcdefgh\nnext" {
+			t.Errorf("Expected the wrapped line joined, got %q", got)
+		}
+	})
+
+	t.Run("Word selection", func(t *testing.T) {
+		vt := NewVTerminal(40, 2)
+		vt.Write([]byte(`cat "/var/log/syslog" | less`))
+		vt.SelectWord(8, 0)
+		if got := vt.selectedText(); got != "/var/log/syslog" {
+			t.Errorf("Expected the path selected, got %q", got)
+		}
+	})
+
+	t.Run("Line selection spans wrapped rows", func(t *testing.T) {
+		vt := NewVTerminal(5, 4)
+		vt.Write([]byte("first\r\nabcdefgh\r\nlast"))
+		vt.SelectLine(2)
+		if got := vt.selectedText(); got != "abcdefgh" {
+			t.Errorf("Expected the whole wrapped line, got %q", got)
+		}
+	})
+
+	t.Run("Block selection", func(t *testing.T) {
+		vt := NewVTerminal(20, 4)
+		vt.Write([]byte("a1 b1 c1\r\na2 b2 c2\r\na3 b3 c3"))
+		vt.StartBlockSelection(4, 2)
+		vt.UpdateSelection(3, 0)
+		if got := vt.selectedText(); got != "b1\nb2\nb3" {
+			t.Errorf("Expected the same columns of each row, got %q", got)
+		}
+		vt.StartSelection(3, 0)
+		vt.UpdateSelection(4, 1)
+		if got := vt.selectedText(); got != "b1 c1\na2 b2" {
+			t.Errorf("Expected a new selection to flow across rows, got %q", got)
+		}
+	})
+
+	t.Run("Scrollback keeps rows joined", func(t *testing.T) {
+		vt := NewVTerminal(5, 2)
+		vt.Write([]byte("abcdefgh\r\nx\r\ny"))
+		vt.ScrollUp(2)
+		vt.SelectLine(0)
+		if got := vt.selectedText(); got != "abcdefgh" {
+			t.Errorf("Expected the wrapped line from the scrollback, got %q", got)
+		}
+	})
+}
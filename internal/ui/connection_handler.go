@@ -2,14 +2,18 @@ package ui
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"runtime"
 	"strconv"
+	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/mux"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/ui/components"
 	"github.com/zalando/go-keyring"
 )
@@ -19,7 +23,14 @@ const keyringService = "ssh-x-term" // Keyring service name
 func (m *Model) handleConnectionList(model tea.Model) tea.Cmd {
 	m.connectionList = model.(*components.ConnectionList)
 	if conn := m.connectionList.SelectedConnection(); conn != nil {
-		return m.handleSelectedConnection(conn)
+		// Sessions in a new window run `sxt connect`, which records them
+		openInNewWindow := m.connectionList.OpenInNewTerminal() &&
+			(config.ActiveMultiplexer != "" || runtime.GOOS == "windows")
+		cmd := m.handleSelectedConnection(conn)
+		if m.storageBackend == nil || openInNewWindow {
+			return cmd
+		}
+		return tea.Batch(cmd, recordConnectionCmd(*conn))
 	}
 	return nil
 }
@@ -45,27 +56,37 @@ func (m *Model) handleSelectedConnection(conn *config.SSHConnection) tea.Cmd {
 	isWindows := runtime.GOOS == "windows"
 	keyPath, err := m.prepareKeyFileIfNeeded(conn)
 	if err != nil {
-		m.errorMessage = fmt.Sprintf("Failed to write key file: %s", err)
+		m.errorMessage = fmt.Sprintf("Failed to prepare key file: %s", err)
 		return nil
 	}
 
-	// Update connection's KeyFile to point to the xterm_keys path if we created one
+	// Update connection's KeyFile to point to the key file if we created one
 	if keyPath != "" {
 		conn.KeyFile = keyPath
-		// Clear Password field since it contained the key content, not a passphrase
-		// If a passphrase is needed, the SSH client will trigger the passphrase form
-		conn.Password = ""
+		if conn.KeyAttachment == "" {
+			// Clear Password field since it contained the key content, not a passphrase
+			// If a passphrase is needed, the SSH client will trigger the passphrase form
+			conn.Password = ""
+		}
 	}
 
 	sshArgs := m.prepareSSHArgs(conn, keyPath)
 	userHost := fmt.Sprintf("%s@%s", conn.Username, conn.Host)
 
+	if openInNewWindow && config.ActiveMultiplexer != "" {
+		m.launchMultiplexer(conn)
+		m.state = StateConnectionList
+		m.connectionList.Reset()
+		return nil
+	}
 	if !isWindows {
-		if openInNewWindow {
-			m.launchTmuxWindow(conn, sshArgs)
-			m.state = StateConnectionList
+		if conn.UseMosh && conn.Proxy == "" && conn.ProxyCommand == "" && conn.ProxyJump == "" && ssh.MoshClientAvailable() {
+			// mosh-client draws on the real terminal, so the TUI steps aside
+			// until the session ends
 			m.connectionList.Reset()
-			return nil
+			return tea.Exec(&interactiveSession{conn: *conn, challenges: m.challenges}, func(err error) tea.Msg {
+				return MoshSessionEndedMsg{Err: err}
+			})
 		}
 		m.terminal = components.NewTerminalComponent(*conn)
 		m.state = StateSSHTerminal
@@ -103,8 +124,39 @@ func (m *Model) handleSelectedConnection(conn *config.SSHConnection) tea.Cmd {
 	return tea.Batch(initCmd, sizeCmd)
 }
 
+// startAdHocSession opens a terminal to a host entered in the quick-connect
+// form. It always runs in the TUI: new windows reconnect by saved ID.
+func (m *Model) startAdHocSession(conn config.SSHConnection) tea.Cmd {
+	m.adHocConn = &conn
+	if conn.UsePassword {
+		// There is no keyring entry to look in, so ask right away
+		m.state = StateConnectionList
+		return func() tea.Msg {
+			return components.SSHPasswordRequiredMsg{Connection: conn}
+		}
+	}
+	m.terminal = components.NewTerminalComponent(conn)
+	m.state = StateSSHTerminal
+
+	initCmd := m.terminal.Init()
+	contentHeight := max(m.height-headerHeight-footerHeight, 12)
+	_, sizeCmd := m.terminal.Update(tea.WindowSizeMsg{Width: m.width, Height: contentHeight})
+	return tea.Batch(initCmd, sizeCmd)
+}
+
 func (m *Model) prepareKeyFileIfNeeded(conn *config.SSHConnection) (string, error) {
-	if !conn.UsePassword && conn.Password != "" {
+	if conn.UsePassword {
+		return "", nil
+	}
+	if store, ok := m.storageBackend.(config.KeyAttachmentStorage); ok && conn.KeyAttachment != "" && m.storageBackend.Capabilities().Attachments {
+		keyPath, err := store.DownloadKeyAttachment(*conn)
+		if err != nil {
+			return "", err
+		}
+		m.tempKeyFiles = append(m.tempKeyFiles, keyPath)
+		return keyPath, nil
+	}
+	if conn.Password != "" {
 		return getKeyFile(*conn)
 	}
 	return "", nil
@@ -123,7 +175,10 @@ func (m *Model) prepareSSHArgs(conn *config.SSHConnection, keyPath string) []str
 	return args
 }
 
-func (m *Model) launchTmuxWindow(conn *config.SSHConnection, sshArgs []string) {
+// launchMultiplexer opens conn in a new window or split pane of the active
+// multiplexer, as set in the multiplexer settings, running `sxt connect <id>`
+// there
+func (m *Model) launchMultiplexer(conn *config.SSHConnection) {
 	// Get the path to sxt executable
 	execPath, err := os.Executable()
 	if err != nil {
@@ -131,22 +186,35 @@ func (m *Model) launchTmuxWindow(conn *config.SSHConnection, sshArgs []string) {
 		execPath = "sxt" // Fallback to assuming it's in PATH
 	}
 
-	// Build command that preserves SSH_AUTH_SOCK
-	sshAuthSock := os.Getenv("SSH_AUTH_SOCK")
-	var sxtCommand string
-	if sshAuthSock != "" {
-		// Export SSH_AUTH_SOCK in the new window's environment
-		sxtCommand = fmt.Sprintf("export SSH_AUTH_SOCK='%s' && %s -c %s", sshAuthSock, execPath, conn.ID)
-	} else {
-		sxtCommand = fmt.Sprintf("%s -c %s", execPath, conn.ID)
+	// The new pane may get the multiplexer server's environment, so
+	// SSH_AUTH_SOCK is passed on explicitly. A production host was
+	// confirmed in the list already, hence --yes.
+	argv := []string{execPath, "connect", "--yes", config.InstanceRef(*conn)}
+	if sshAuthSock := os.Getenv("SSH_AUTH_SOCK"); sshAuthSock != "" && runtime.GOOS != "windows" {
+		argv = append([]string{"env", "SSH_AUTH_SOCK=" + sshAuthSock}, argv...)
 	}
 
-	windowName := fmt.Sprintf("%s@%s:%d - %s", conn.Username, conn.Host, conn.Port, conn.Name)
+	settings := config.CurrentSettings()
+	name := sessionName(settings.MultiplexerName, *conn)
+	if err := mux.Open(config.ActiveMultiplexer, settings.MultiplexerPlacement, name, argv); err != nil {
+		log.Printf("Error launching %s %s: %v", config.ActiveMultiplexer, settings.MultiplexerPlacement, err)
+		m.errorMessage = fmt.Sprintf("Failed to open %s %s: %s", config.ActiveMultiplexer, settings.MultiplexerPlacement, err)
+	}
+}
 
-	cmd := exec.Command("tmux", "new-window", "-n", windowName, sxtCommand)
-	if err := cmd.Start(); err != nil {
-		log.Printf("Error launching tmux window: %v", err)
+// sessionName expands the {name}, {user}, {host} and {port} placeholders of
+// the multiplexer name template
+func sessionName(template string, conn config.SSHConnection) string {
+	port := conn.Port
+	if port == 0 {
+		port = 22
 	}
+	return strings.NewReplacer(
+		"{name}", conn.Name,
+		"{user}", conn.Username,
+		"{host}", conn.Host,
+		"{port}", strconv.Itoa(port),
+	).Replace(template)
 }
 
 func (m *Model) launchWindowsTerminal(conn *config.SSHConnection, sshArgs []string, keyPath, userHost string) {
@@ -160,8 +228,8 @@ func (m *Model) launchWindowsTerminal(conn *config.SSHConnection, sshArgs []stri
 	// Build command that preserves SSH_AUTH_SOCK (for WSL integration scenarios)
 	sshAuthSock := os.Getenv("SSH_AUTH_SOCK")
 
-	// Use sxt -c with connection ID
-	cmd := exec.Command("cmd", "/C", "start", "", execPath, "-c", conn.ID)
+	// Use sxt connect with connection ID, confirmed in the list already
+	cmd := exec.Command("cmd", "/C", "start", "", execPath, "connect", "--yes", config.InstanceRef(*conn))
 
 	// If SSH_AUTH_SOCK is set, pass it to the new process
 	if sshAuthSock != "" {
@@ -172,3 +240,34 @@ func (m *Model) launchWindowsTerminal(conn *config.SSHConnection, sshArgs []stri
 		log.Printf("Error launching terminal: %v", err)
 	}
 }
+
+// interactiveSession runs ssh.ConnectInteractive on the terminal while the
+// TUI is suspended, which is how mosh sessions are started
+type interactiveSession struct {
+	conn       config.SSHConnection
+	challenges chan *challengeRequest
+}
+
+func (s *interactiveSession) Run() error {
+	// The TUI cannot show the challenge form while suspended, so prompts
+	// are answered on the terminal for the duration of the session
+	ssh.SetChallengeHandler(ssh.TerminalChallenge)
+	defer ssh.SetChallengeHandler(challengeHandler(s.challenges))
+	return ssh.ConnectInteractive(s.conn)
+}
+
+// ConnectInteractive uses the process's own stdio
+func (s *interactiveSession) SetStdin(io.Reader)  {}
+func (s *interactiveSession) SetStdout(io.Writer) {}
+func (s *interactiveSession) SetStderr(io.Writer) {}
+
+// startLocalShell opens the user's own shell in the built-in terminal
+func (m *Model) startLocalShell() tea.Cmd {
+	m.terminal = components.NewLocalTerminalComponent()
+	m.state = StateSSHTerminal
+
+	initCmd := m.terminal.Init()
+	contentHeight := max(m.height-headerHeight-footerHeight, 12)
+	_, sizeCmd := m.terminal.Update(tea.WindowSizeMsg{Width: m.width, Height: contentHeight})
+	return tea.Batch(initCmd, sizeCmd)
+}
@@ -0,0 +1,217 @@
+package ui
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/cursor"
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/zalando/go-keyring"
+)
+
+var update = flag.Bool("update", false, "rewrite the View snapshots in testdata")
+
+const (
+	driverWidth  = 100
+	driverHeight = 30
+	// settleQuiet is how long a command may run before it is taken as a
+	// background one, such as a timer, that the screen doesn't wait for
+	settleQuiet = 150 * time.Millisecond
+	settleLimit = 10 * time.Second
+)
+
+// driver runs the Model without a terminal: it sends messages through
+// Update, runs the commands Update returns and feeds their messages back
+// in, as the Bubble Tea program would
+type driver struct {
+	t     *testing.T
+	model *Model
+	msgs  chan tea.Msg
+	quit  bool
+
+	mu      sync.Mutex
+	nextID  int
+	running map[int]time.Time // start of each command not yet finished
+}
+
+// newDriver starts a Model sized driverWidth by driverHeight, with its
+// home directory and keyring private to the test
+func newDriver(t *testing.T) *driver {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("BW_SESSION", "")
+	keyring.MockInit()
+
+	d := &driver{t: t, model: NewModel(), msgs: make(chan tea.Msg, 64), running: make(map[int]time.Time)}
+	d.run(d.model.Init())
+	d.send(tea.WindowSizeMsg{Width: driverWidth, Height: driverHeight})
+	d.settle()
+	return d
+}
+
+// send passes msg to Update and runs the command it returns
+func (d *driver) send(msg tea.Msg) {
+	d.t.Helper()
+	model, cmd := d.model.Update(msg)
+	d.model = model.(*Model)
+	d.run(cmd)
+}
+
+// run runs cmd in the background. Commands that never finish, such as the
+// wait for keyboard-interactive prompts, are left running.
+func (d *driver) run(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	d.mu.Lock()
+	id := d.nextID
+	d.nextID++
+	d.running[id] = time.Now()
+	d.mu.Unlock()
+	go func() {
+		msg := cmd()
+		d.msgs <- msg
+		d.mu.Lock()
+		delete(d.running, id)
+		d.mu.Unlock()
+	}()
+}
+
+// busy reports whether a command started less than settleQuiet ago is
+// still running
+func (d *driver) busy() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, started := range d.running {
+		if time.Since(started) < settleQuiet {
+			return true
+		}
+	}
+	return false
+}
+
+// settle feeds the messages of finished commands back into the model
+// until only background commands are left running
+func (d *driver) settle() {
+	d.t.Helper()
+	limit := time.After(settleLimit)
+	poll := time.NewTicker(5 * time.Millisecond)
+	defer poll.Stop()
+	for {
+		select {
+		case msg := <-d.msgs:
+			d.dispatch(msg)
+		case <-poll.C:
+			if len(d.msgs) == 0 && !d.busy() {
+				return
+			}
+		case <-limit:
+			d.t.Fatalf("the model kept receiving messages for %s", settleLimit)
+		}
+	}
+}
+
+func (d *driver) dispatch(msg tea.Msg) {
+	d.t.Helper()
+	switch msg := msg.(type) {
+	case nil:
+	case tea.BatchMsg:
+		for _, cmd := range msg {
+			d.run(cmd)
+		}
+	case tea.QuitMsg:
+		d.quit = true
+	case spinner.TickMsg, cursor.BlinkMsg:
+		// Animations would keep the model busy forever
+	default:
+		d.send(msg)
+	}
+}
+
+// keyNames are the keys press takes by name; anything else is typed
+var keyNames = map[string]tea.KeyType{
+	"enter":     tea.KeyEnter,
+	"esc":       tea.KeyEsc,
+	"tab":       tea.KeyTab,
+	"shift+tab": tea.KeyShiftTab,
+	"up":        tea.KeyUp,
+	"down":      tea.KeyDown,
+	"left":      tea.KeyLeft,
+	"right":     tea.KeyRight,
+	"backspace": tea.KeyBackspace,
+	"space":     tea.KeySpace,
+	"ctrl+c":    tea.KeyCtrlC,
+	"ctrl+s":    tea.KeyCtrlS,
+}
+
+// press sends each key, by name or as a single character, and settles
+func (d *driver) press(keys ...string) {
+	d.t.Helper()
+	for _, k := range keys {
+		if keyType, ok := keyNames[k]; ok {
+			d.send(tea.KeyMsg{Type: keyType})
+		} else {
+			d.send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+		}
+		d.settle()
+	}
+}
+
+// typeText types text one character at a time
+func (d *driver) typeText(text string) {
+	d.t.Helper()
+	for _, r := range text {
+		d.send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	d.settle()
+}
+
+// expectState fails the test unless the model is on state
+func (d *driver) expectState(state AppState) {
+	d.t.Helper()
+	if d.model.state != state {
+		d.t.Fatalf("state = %d, want %d; screen:\n%s", d.model.state, state, d.screen())
+	}
+}
+
+var ansiSequence = regexp.MustCompile(`\x1b\[[0-9;?]*[a-zA-Z]`)
+
+// screen renders the View as plain text without trailing spaces
+func (d *driver) screen() string {
+	lines := strings.Split(ansiSequence.ReplaceAllString(d.model.View(), ""), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// snapshot compares the screen with testdata/<name>.golden, rewriting the
+// file instead with -update
+func (d *driver) snapshot(name string) {
+	d.t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	got := d.screen()
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			d.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			d.t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		d.t.Fatalf("no snapshot %s (run go test -update to write it): %v", path, err)
+	}
+	if got != string(want) {
+		d.t.Errorf("screen differs from %s (run go test -update to accept it)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
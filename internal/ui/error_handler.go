@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"log"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ui/components"
+)
+
+// showError replaces the current screen with the error view. retry, when
+// set, tries again what failed once the screen the error came from is back.
+func (m *Model) showError(title string, err error, retry func() tea.Cmd) {
+	log.Printf("%s: %v", title, err)
+	m.loading = false
+	m.errorReturnState = m.state
+	switch m.state {
+	case StateBitwardenConfig, StateBitwardenLogin, StateBitwardenUnlock, StateVaultConfig, StateKeePassXCConfig:
+		// Their forms are gone once submitted, so start over
+		m.errorReturnState = StateSelectStorage
+	}
+	m.errorRetry = retry
+	m.errorView = components.NewErrorView(title, err, retry != nil)
+	m.errorView.SetSize(m.width, m.height)
+	m.state = StateError
+}
+
+// handleErrorView retries or goes back once the error view is answered
+func (m *Model) handleErrorView(model tea.Model, cmd tea.Cmd) tea.Cmd {
+	m.errorView = model.(*components.ErrorView)
+	if !m.errorView.IsRetry() && !m.errorView.IsBack() {
+		return cmd
+	}
+	retry := m.errorRetry
+	if !m.errorView.IsRetry() {
+		retry = nil
+	}
+	m.state = m.errorReturnState
+	m.errorView = nil
+	m.errorRetry = nil
+	if retry != nil {
+		return retry()
+	}
+	// Lists would go on with the choice that failed
+	switch m.state {
+	case StateSelectStorage:
+		m.storageSelect = components.NewStorageSelect()
+		m.storageSelect.SetSize(m.width, m.height)
+		return m.storageSelect.Init()
+	case StateOrganizationSelect:
+		m.bitwardenOrganizationList.Reset()
+	case StateCollectionSelect:
+		m.bitwardenCollectionList.Reset()
+	case StateFolderSelect:
+		m.bitwardenFolderList.Reset()
+	}
+	return nil
+}
+
+// retryStorage opens the chosen storage backend again
+func (m *Model) retryStorage() tea.Cmd {
+	return m.handleComponentResult(m.storageSelect, nil)
+}
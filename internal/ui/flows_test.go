@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// openLocal picks Local Storage on the storage screen
+func openLocal(d *driver) {
+	d.t.Helper()
+	d.expectState(StateSelectStorage)
+	d.press("enter")
+	d.expectState(StateConnectionList)
+}
+
+// addConnection fills in and submits the add form from the list
+func addConnection(d *driver, name, host, user string) {
+	d.t.Helper()
+	d.press("a")
+	d.expectState(StateAddConnection)
+	d.typeText(name)
+	d.press("tab")
+	d.typeText(host)
+	d.press("tab", "tab")
+	d.typeText(user)
+	// Back up from Username, past Name, to Submit
+	d.press("shift+tab", "shift+tab", "shift+tab", "shift+tab", "enter")
+	d.expectState(StateConnectionList)
+}
+
+func TestAddConnectionFlow(t *testing.T) {
+	d := newDriver(t)
+	d.snapshot("storage_select")
+	openLocal(d)
+	d.snapshot("empty_list")
+
+	addConnection(d, "web", "web.example.com", "deploy")
+	d.snapshot("list_after_add")
+
+	data, err := os.ReadFile(filepath.Join(os.Getenv("HOME"), ".ssh", "config"))
+	if err != nil {
+		t.Fatalf("reading ~/.ssh/config: %v", err)
+	}
+	if !strings.Contains(string(data), "web.example.com") {
+		t.Errorf("~/.ssh/config lacks the new host:\n%s", data)
+	}
+}
+
+func TestAddConnectionValidation(t *testing.T) {
+	d := newDriver(t)
+	openLocal(d)
+	d.press("a", "shift+tab", "enter")
+	d.expectState(StateAddConnection)
+	if screen := d.screen(); !strings.Contains(screen, "required") {
+		t.Errorf("empty form submitted without an error:\n%s", screen)
+	}
+
+	d.press("esc")
+	d.expectState(StateConnectionList)
+}
+
+func TestDeleteConfirmFlow(t *testing.T) {
+	d := newDriver(t)
+	openLocal(d)
+	addConnection(d, "web", "web.example.com", "deploy")
+	addConnection(d, "db", "db.example.com", "postgres")
+
+	d.press("d")
+	d.snapshot("delete_confirm")
+
+	// Declining keeps the connection
+	d.press("n")
+	if n := len(d.model.storageBackend.ListConnections()); n != 2 {
+		t.Fatalf("%d connections after declining, want 2", n)
+	}
+
+	d.press("d", "y")
+	d.expectState(StateConnectionList)
+	d.snapshot("list_after_delete")
+	if n := len(d.model.storageBackend.ListConnections()); n != 1 {
+		t.Errorf("%d connections after deleting, want 1", n)
+	}
+}
+
+// fakeBw puts a bw script first in PATH whose vault is locked until it is
+// unlocked with "hunter2"
+func fakeBw(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake bw is a shell script")
+	}
+	dir := t.TempDir()
+	script := `#!/bin/sh
+state="` + dir + `/unlocked"
+case "$1" in
+status)
+	if [ -f "$state" ]; then echo '{"status":"unlocked"}'; else echo '{"status":"locked"}'; fi ;;
+unlock)
+	if [ "$2" = "hunter2" ]; then touch "$state"; echo "session-key"; else echo "Invalid master password." >&2; exit 1; fi ;;
+sync)
+	;;
+list)
+	case "$2" in
+	organizations) echo '[{"id":"org-1","name":"Acme"}]' ;;
+	*) echo '[]' ;;
+	esac ;;
+*)
+	echo "unexpected bw $*" >&2; exit 1 ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "bw"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestBitwardenUnlockFlow(t *testing.T) {
+	fakeBw(t)
+	d := newDriver(t)
+	d.press("right", "enter")
+	d.expectState(StateBitwardenUnlock)
+	d.snapshot("bitwarden_unlock")
+
+	d.typeText("wrong")
+	d.press("enter")
+	d.expectState(StateBitwardenUnlock)
+	d.snapshot("bitwarden_unlock_failed")
+
+	d.typeText("hunter2")
+	d.press("enter")
+	d.expectState(StateOrganizationSelect)
+	d.snapshot("bitwarden_organizations")
+}
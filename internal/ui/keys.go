@@ -0,0 +1,506 @@
+package ui
+
+import (
+	"slices"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// helpColumnHeight is how many bindings the help overlay puts in a column
+const helpColumnHeight = 8
+
+// binding defines a key with the text shown for it in the footer and the
+// help overlay
+func binding(helpKey, desc string, keys ...string) key.Binding {
+	return key.NewBinding(key.WithKeys(keys...), key.WithHelp(helpKey, desc))
+}
+
+var (
+	helpBinding = binding("?", "help", "?", "f1")
+	quitBinding = binding("ctrl+c", "quit", "ctrl+c")
+)
+
+// keyMap is the help of one screen: short bindings go in the footer and the
+// overlay lists them followed by the rest
+type keyMap struct {
+	short []key.Binding
+	more  []key.Binding
+}
+
+// newKeyMap builds a keyMap; short bindings go in the footer and the rest
+// only in the help overlay
+func newKeyMap(short []key.Binding, more ...key.Binding) keyMap {
+	return keyMap{short: short, more: more}
+}
+
+func (k keyMap) ShortHelp() []key.Binding {
+	return k.short
+}
+
+func (k keyMap) FullHelp() [][]key.Binding {
+	all := append(append([]key.Binding{}, k.short...), k.more...)
+	var columns [][]key.Binding
+	for len(all) > 0 {
+		n := min(helpColumnHeight, len(all))
+		columns = append(columns, all[:n])
+		all = all[n:]
+	}
+	return columns
+}
+
+// connectionListKeyMap holds the keys handled on the connection list
+type connectionListKeyMap struct {
+	Connect     key.Binding
+	Add         key.Binding
+	Edit        key.Binding
+	Duplicate   key.Binding
+	Mark        key.Binding
+	BulkEdit    key.Binding
+	Delete      key.Binding
+	Rename      key.Binding
+	Password    key.Binding
+	Copy        key.Binding
+	Details     key.Binding
+	Pin         key.Binding
+	MoveUp      key.Binding
+	MoveDown    key.Binding
+	Sort        key.Binding
+	Purge       key.Binding
+	SCP         key.Binding
+	Processes   key.Binding
+	Services    key.Binding
+	Ports       key.Binding
+	Run         key.Binding
+	DeployKey   key.Binding
+	Keys        key.Binding
+	Import      key.Binding
+	CloudImport key.Binding
+	Export      key.Binding
+	Settings    key.Binding
+	Filter      key.Binding
+	NewTerminal key.Binding
+	Placement   key.Binding
+	Merge       key.Binding
+	Quick       key.Binding
+	Local       key.Binding
+	Discover    key.Binding
+	Lock        key.Binding
+	Mirror      key.Binding
+	Collection  key.Binding
+	Back        key.Binding
+}
+
+var connectionListKeys = connectionListKeyMap{
+	Connect:     binding("enter", "connect", "enter"),
+	Add:         binding("a", "add", "a"),
+	Edit:        binding("e", "edit", "e"),
+	Duplicate:   binding("y", "duplicate", "y"),
+	Mark:        binding("space", "select", " "),
+	BulkEdit:    binding("B", "bulk edit selected", "B"),
+	Delete:      binding("d", "delete", "d", "D"),
+	Rename:      binding("r", "rename", "r"),
+	Password:    binding("p", "show password", "p", "P"),
+	Copy:        binding("c", "copy password", "c", "C"),
+	Details:     binding("v", "host key details", "v"),
+	Pin:         binding("f", "pin", "f"),
+	MoveUp:      binding("K", "move up", "K"),
+	MoveDown:    binding("J", "move down", "J"),
+	Sort:        binding("S", "sort: manual/recent/frequent", "S"),
+	Purge:       binding("H", "purge saved scrollback", "H"),
+	SCP:         binding("s", "scp", "s"),
+	Processes:   binding("t", "processes", "t"),
+	Services:    binding("u", "services", "u"),
+	Ports:       binding("T", "check ports", "T"),
+	Run:         binding("R", "run on hosts", "R"),
+	DeployKey:   binding("i", "deploy key", "i"),
+	Keys:        binding("m", "ssh keys", "m"),
+	Import:      binding("I", "import", "I"),
+	CloudImport: binding("W", "import from cloud", "W"),
+	Export:      binding("x", "export", "x"),
+	Settings:    binding(",", "settings", ","),
+	Filter:      binding("/", "filter", "/"),
+	NewTerminal: binding("o", "toggle new terminal", "o"),
+	Placement:   binding("O", "multiplexer window/split", "O"),
+	Merge:       binding("+", "add storage to list", "+"),
+	Quick:       binding("n", "quick connect", "n"),
+	Local:       binding("l", "local shell", "l"),
+	Discover:    binding("N", "discover LAN hosts", "N"),
+	Lock:        binding("L", "lock vault", "L"),
+	Mirror:      binding("M", "mirror to ~/.ssh/config", "M"),
+	Collection:  binding("g", "switch collection", "g"),
+	Back:        binding("esc", "change storage", "esc"),
+}
+
+func (k connectionListKeyMap) keyMap() keyMap {
+	return newKeyMap(
+		[]key.Binding{k.Connect, k.Add, k.Edit, k.Delete, k.SCP, k.Filter, helpBinding, k.Settings, k.Run, quitBinding},
+		k.Rename, k.Duplicate, k.Mark, k.BulkEdit, k.Password, k.Copy, k.Details, k.Pin, k.Sort, k.MoveUp, k.MoveDown, k.Purge,
+		k.Processes, k.Services, k.Ports, k.DeployKey, k.Keys, k.Import, k.CloudImport, k.Export,
+		k.Merge, k.Quick, k.Local, k.Discover, k.NewTerminal, k.Placement, k.Back,
+	)
+}
+
+// Bindings shared by several screens
+var (
+	navigateBinding  = binding("↑/↓", "navigate", "up", "down")
+	selectBinding    = binding("enter", "select", "enter")
+	backBinding      = binding("esc", "back", "esc")
+	cancelBinding    = binding("esc", "cancel", "esc")
+	nextFieldBinding = binding("tab", "next field", "tab")
+	searchBinding    = binding("/", "search", "/")
+	refreshBinding   = binding("r", "refresh", "r")
+)
+
+// Keys of the file manager sharing the screen with a terminal session
+var (
+	splitFocusBinding  = binding("alt+w", "switch pane", "alt+w")
+	splitLayoutBinding = binding("alt+v", "stack/side by side", "alt+v")
+)
+
+// scpKeyMap returns the keys of the file manager, on its own or beside a
+// terminal session
+func scpKeyMap() keyMap {
+	return newKeyMap(
+		[]key.Binding{
+			navigateBinding,
+			binding("enter", "open", "enter"),
+			binding("tab", "switch panel", "tab"),
+			binding("space", "mark", " "),
+			binding("g", "get", "g"),
+			binding("u", "upload", "u"),
+			binding("q", "queue", "q"),
+			helpBinding,
+			binding("esc esc", "exit", "esc"),
+		},
+		binding("backspace", "parent", "backspace"),
+		binding("v", "view", "v"),
+		binding("e", "open with app", "e"),
+		binding("p", "permissions", "p"),
+		binding("C", "remote copy", "C"),
+		binding("M", "remote move", "M"),
+		binding("B", "bandwidth", "B"),
+		binding("b", "bookmarks", "b"),
+		binding("d", "delete", "d"),
+		binding("z", "undo delete", "z"),
+		binding("s", "directory size", "s"),
+		binding("F", "remote disk space", "F"),
+		binding("A", "archive and download", "A"),
+		binding("X", "extract on server", "X"),
+		binding("=", "diff local/remote", "="),
+		binding("S", "sync directories", "S"),
+		binding("W", "watch and upload", "W"),
+		binding("n", "create", "n"),
+		binding("r", "rename", "r"),
+		binding("c/:", "go to path", "c", ":"),
+		binding(".", "dotfiles", "."),
+		binding("o", "sort", "o"),
+		binding("O", "dirs first", "O"),
+		searchBinding,
+	)
+}
+
+// keyMap returns the keys of the current screen
+func (m *Model) keyMap() help.KeyMap {
+	switch m.state {
+	case StateConnectionList:
+		keys := connectionListKeys.keyMap()
+		if m.bitwardenManager != nil && m.storageBackend == m.bitwardenManager {
+			keys.more = append(keys.more, connectionListKeys.Collection, connectionListKeys.Lock, connectionListKeys.Mirror)
+		}
+		return keys
+	case StateSSHTerminal:
+		if m.terminal != nil && m.terminal.IsEnded() {
+			again := "reconnect"
+			if m.terminal.IsLocal() {
+				again = "restart"
+			}
+			return newKeyMap([]key.Binding{
+				binding("esc", "return", "esc"),
+				binding("r", again, "r"),
+				binding("v", "last output", "v"),
+				binding("pgup/pgdn", "scroll", "pgup", "pgdown"),
+			})
+		}
+		if m.terminal != nil && m.terminal.IsSessionClosed() {
+			return newKeyMap([]key.Binding{binding("esc", "return", "esc")})
+		}
+		if m.terminal != nil && m.terminal.FilesFocused() {
+			// The help overlay isn't available while a session is open
+			keys := scpKeyMap()
+			keys.short = slices.DeleteFunc(keys.short, func(b key.Binding) bool { return b.Help() == helpBinding.Help() })
+			keys.short = append([]key.Binding{splitFocusBinding, splitLayoutBinding}, keys.short...)
+			return keys
+		}
+		if m.terminal != nil && m.terminal.IsLocal() {
+			return newKeyMap([]key.Binding{
+				binding("esc esc", "exit", "esc"),
+				binding("ctrl+d", "EOF", "ctrl+d"),
+				binding("pgup/pgdn", "scroll", "pgup", "pgdown"),
+				binding("alt+s", "snippets", "alt+s"),
+				binding("alt+o/ctrl+click", "open link", "alt+o"),
+				binding("mouse", "copy text", "mouse"),
+			})
+		}
+		if m.terminal != nil && m.terminal.IsSplit() {
+			return newKeyMap([]key.Binding{
+				binding("esc esc", "exit", "esc"),
+				splitFocusBinding,
+				splitLayoutBinding,
+				binding("alt+x", "close files", "alt+x"),
+				binding("pgup/pgdn", "scroll", "pgup", "pgdown"),
+			})
+		}
+		keys := newKeyMap([]key.Binding{
+			binding("esc esc", "exit", "esc"),
+			binding("ctrl+d", "EOF", "ctrl+d"),
+			binding("pgup/pgdn", "scroll", "pgup", "pgdown"),
+			binding("alt+s", "snippets", "alt+s"),
+			binding("alt+m", "host monitor", "alt+m"),
+			binding("alt+e", "skip output", "alt+e"),
+			binding("alt+x", "files beside the shell", "alt+x"),
+			binding("tab", "complete command", "tab"),
+			binding("alt+o/ctrl+click", "open link", "alt+o"),
+			binding("mouse", "copy text", "mouse"),
+		})
+		if m.terminal != nil && m.terminal.HasTimeouts() {
+			keys.short = append(keys.short, binding("alt+t", "extend session", "alt+t"))
+		}
+		return keys
+	case StateSCPFileManager:
+		return scpKeyMap()
+	case StateSelectStorage:
+		return newKeyMap([]key.Binding{binding("←/→", "navigate", "left", "right"), selectBinding, helpBinding, quitBinding})
+	case StateBitwardenConfig:
+		return newKeyMap([]key.Binding{nextFieldBinding, binding("ctrl+t", "login method", "ctrl+t"), binding("enter", "confirm", "enter"), backBinding})
+	case StateBitwardenLogin:
+		return newKeyMap([]key.Binding{nextFieldBinding, binding("enter", "login", "enter"), backBinding})
+	case StateBitwardenUnlock:
+		return newKeyMap([]key.Binding{binding("enter", "unlock", "enter"), backBinding})
+	case StateOrganizationSelect:
+		return newKeyMap([]key.Binding{navigateBinding, binding("o", "personal vault", "o"), selectBinding, backBinding, helpBinding}, binding("/", "filter", "/"))
+	case StateCollectionSelect:
+		return newKeyMap(
+			[]key.Binding{navigateBinding, selectBinding, backBinding, helpBinding},
+			binding("/", "filter", "/"),
+			binding("n", "new collection", "n"),
+			binding("r", "rename collection", "r"),
+		)
+	case StateFolderSelect:
+		return newKeyMap([]key.Binding{navigateBinding, selectBinding, backBinding, helpBinding}, binding("/", "filter", "/"))
+	case StateAddConnection, StateEditConnection:
+		return newKeyMap(
+			[]key.Binding{nextFieldBinding, binding("enter", "save", "enter"), cancelBinding},
+			binding("ctrl+p", "toggle auth", "ctrl+p"),
+			binding("ctrl+o", "mosh", "ctrl+o"),
+			binding("ctrl+t", "agent forwarding", "ctrl+t"),
+			binding("ctrl+x", "X11 forwarding", "ctrl+x"),
+			binding("ctrl+y", "view only", "ctrl+y"),
+			binding("ctrl+n", "danger level", "ctrl+n"),
+			binding("ctrl+r", "run on-connect without asking", "ctrl+r"),
+			binding("ctrl+g", "generate key", "ctrl+g"),
+			binding("ctrl+l", "Bitwarden folder", "ctrl+l"),
+		)
+	case StateSSHPassphrase:
+		if m.sshPassphraseForm != nil && m.sshPassphraseForm.KeyFile() != "" {
+			return newKeyMap([]key.Binding{binding("enter", "submit", "enter"), binding("tab", "keyring/ssh-agent", "tab"), cancelBinding})
+		}
+		return newKeyMap([]key.Binding{binding("enter", "submit", "enter"), cancelBinding})
+	case StateVaultConfig:
+		return newKeyMap([]key.Binding{nextFieldBinding, binding("ctrl+t", "auth method", "ctrl+t"), binding("enter", "login", "enter"), backBinding})
+	case StateKeePassXCConfig:
+		return newKeyMap([]key.Binding{nextFieldBinding, binding("enter", "open database", "enter"), backBinding})
+	case StateProcessManager:
+		return newKeyMap(
+			[]key.Binding{navigateBinding, searchBinding, binding("t", "TERM", "t"), binding("K", "KILL", "K"), refreshBinding, helpBinding, backBinding},
+			binding("c", "sort by cpu", "c"),
+			binding("m", "sort by memory", "m"),
+			binding("p", "sort by pid", "p"),
+			binding("H", "HUP", "H"),
+		)
+	case StateSystemdBrowser:
+		if m.systemdBrowser != nil && m.systemdBrowser.IsShowingJournal() {
+			return newKeyMap([]key.Binding{binding("↑/↓/pgup/pgdn", "scroll", "up", "down", "pgup", "pgdown"), binding("f", "follow", "f"), helpBinding, binding("esc", "back to units", "esc")})
+		}
+		return newKeyMap(
+			[]key.Binding{navigateBinding, searchBinding, binding("enter", "journal", "enter"), binding("R", "restart", "R"), refreshBinding, helpBinding, backBinding},
+			binding("s", "start", "s"),
+			binding("S", "stop", "S"),
+			binding("e", "enable", "e"),
+			binding("E", "disable", "E"),
+		)
+	case StatePortChecker:
+		if m.portChecker != nil && m.portChecker.IsTyping() {
+			return newKeyMap([]key.Binding{binding("enter", "check", "enter"), binding("esc", "stop editing", "esc")})
+		}
+		return newKeyMap(
+			[]key.Binding{navigateBinding, binding("e", "edit ports", "e", "/"), binding("w", "watch", "w"), binding("l", "listening ports", "l"), refreshBinding, helpBinding, backBinding},
+		)
+	case StateCommandRunner:
+		if m.commandRunner != nil && m.commandRunner.ShowingResults() {
+			return newKeyMap(
+				[]key.Binding{navigateBinding, binding("enter", "expand output", "enter"), binding("r", "rerun", "r"), binding("e", "export", "e"), helpBinding, backBinding},
+				binding("E", "expand all", "E"),
+			)
+		}
+		return newKeyMap([]key.Binding{
+			binding("tab", "command/hosts", "tab"),
+			binding("space", "select host", " "),
+			binding("a", "select all", "a"),
+			binding("enter", "run", "enter"),
+			backBinding,
+		})
+	case StateSettings:
+		return newKeyMap([]key.Binding{
+			binding("tab/↑/↓", "next field", "tab", "up", "down"),
+			binding("←/→/space", "change option", "left", "right", " "),
+			binding("enter/ctrl+s", "save", "ctrl+s"),
+			cancelBinding,
+		})
+	case StateQuickConnect:
+		if m.quickConnectForm != nil && m.quickConnectForm.IsOfferingSave() {
+			return newKeyMap([]key.Binding{binding("y", "save connection", "y"), binding("n/esc", "skip", "n", "esc")})
+		}
+		return newKeyMap([]key.Binding{
+			binding("enter", "connect", "enter"),
+			binding("ctrl+p", "toggle auth", "ctrl+p"),
+			cancelBinding,
+		})
+	case StateReconcile:
+		return newKeyMap([]key.Binding{binding("y", "apply changes", "y"), binding("n/esc", "skip", "n", "esc"), helpBinding})
+	case StateEditConflict:
+		return newKeyMap([]key.Binding{
+			binding("r", "reload theirs", "r"),
+			binding("o", "overwrite", "o"),
+			binding("m", "merge", "m"),
+			binding("esc", "back to form", "esc"),
+			helpBinding,
+		})
+	case StateKeyDeploy:
+		return newKeyMap([]key.Binding{binding("↑/↓", "select key", "up", "down"), binding("enter", "deploy", "enter"), cancelBinding, helpBinding})
+	case StateImport:
+		if m.importWizard != nil && m.importWizard.IsPreviewing() {
+			return newKeyMap([]key.Binding{
+				navigateBinding,
+				binding("space", "toggle", " "),
+				binding("a", "toggle all", "a"),
+				binding("enter", "import", "enter"),
+				backBinding,
+			})
+		}
+		return newKeyMap([]key.Binding{binding("↑/↓", "select", "up", "down"), binding("enter", "next", "enter"), backBinding})
+	case StateExport:
+		return newKeyMap([]key.Binding{
+			binding("tab/↑/↓", "field", "tab", "up", "down"),
+			binding("space/←/→", "change", " ", "left", "right"),
+			binding("enter", "export", "enter"),
+			cancelBinding,
+		})
+	case StateBulkEdit:
+		return newKeyMap([]key.Binding{binding("tab", "field", "tab", "shift+tab"), binding("enter", "apply", "enter"), cancelBinding})
+	case StateDiscovery:
+		if m.discovery != nil && m.discovery.IsTyping() {
+			return newKeyMap([]key.Binding{binding("enter", "done", "enter", "esc", "tab")})
+		}
+		return newKeyMap([]key.Binding{
+			navigateBinding,
+			binding("enter/c", "connect", "enter", "c"),
+			binding("s", "save as connection", "s"),
+			binding("u", "username", "u"),
+			binding("p", "password/key auth", "p"),
+			binding("r", "scan again", "r"),
+			backBinding,
+			helpBinding,
+		})
+	case StateCloudImport:
+		switch {
+		case m.cloudImport != nil && m.cloudImport.IsPreviewing():
+			return newKeyMap([]key.Binding{
+				navigateBinding,
+				binding("space", "toggle", " "),
+				binding("a", "toggle all", "a"),
+				binding("enter", "import", "enter"),
+				binding("c", "connect without saving", "c"),
+				refreshBinding,
+				backBinding,
+			})
+		case m.cloudImport != nil && m.cloudImport.IsEditingMapping():
+			return newKeyMap([]key.Binding{
+				binding("tab/↑/↓", "field", "tab", "up", "down"),
+				binding("space", "toggle private addresses", " "),
+				binding("enter", "list instances", "enter"),
+				backBinding,
+			})
+		}
+		return newKeyMap([]key.Binding{binding("↑/↓", "select", "up", "down"), binding("enter", "next", "enter"), backBinding})
+	case StateError:
+		keys := []key.Binding{binding("l", "open log", "l"), binding("esc", "back", "esc", "enter"), helpBinding}
+		if m.errorView != nil && m.errorView.CanRetry() {
+			keys = append([]key.Binding{binding("r", "retry", "r")}, keys...)
+		}
+		return newKeyMap(keys)
+	case StateChallenge:
+		return newKeyMap([]key.Binding{binding("tab", "next prompt", "tab"), binding("enter", "submit", "enter"), binding("esc", "cancel login", "esc")})
+	case StateKeyManager:
+		if m.keyManager != nil && m.keyManager.IsGenerating() {
+			return newKeyMap([]key.Binding{nextFieldBinding, binding("ctrl+t", "key type", "ctrl+t"), binding("enter", "generate", "enter"), cancelBinding})
+		}
+		return newKeyMap([]key.Binding{
+			navigateBinding,
+			binding("g", "generate", "g"),
+			binding("y", "copy public key", "y"),
+			binding("d", "delete", "d"),
+			refreshBinding,
+			helpBinding,
+			backBinding,
+		})
+	default:
+		return newKeyMap([]key.Binding{quitBinding})
+	}
+}
+
+// helpKeyAvailable reports whether ? opens the help overlay rather than
+// being typed into the current screen. F1 works on every screen but the
+// terminal, which passes all keys to the remote host.
+func (m *Model) helpKeyAvailable() bool {
+	switch m.state {
+	case StateConnectionList:
+		if m.connectionList == nil || m.connectionList.IsShowingDeleteConfirm() ||
+			m.connectionList.IsShowingPasswordModal() || m.connectionList.IsShowingRenameModal() ||
+			m.connectionList.IsShowingHostDetails() || m.connectionList.IsShowingConnectConfirm() ||
+			m.connectionList.IsShowingPortConflict() {
+			return false
+		}
+		return !isFiltering(m.connectionList.List())
+	case StateSCPFileManager:
+		return m.scpManager != nil && !m.scpManager.IsTyping()
+	case StateProcessManager:
+		return m.processManager != nil && !m.processManager.IsTyping()
+	case StateSystemdBrowser:
+		return m.systemdBrowser != nil && !m.systemdBrowser.IsTyping()
+	case StatePortChecker:
+		return m.portChecker != nil && !m.portChecker.IsTyping()
+	case StateCommandRunner:
+		return m.commandRunner != nil && !m.commandRunner.IsTyping()
+	case StateKeyManager:
+		return m.keyManager != nil && !m.keyManager.IsGenerating()
+	case StateDiscovery:
+		return m.discovery != nil && !m.discovery.IsTyping()
+	case StateOrganizationSelect:
+		return m.bitwardenOrganizationList != nil && !isFiltering(m.bitwardenOrganizationList.List())
+	case StateCollectionSelect:
+		return m.bitwardenCollectionList != nil && !isFiltering(m.bitwardenCollectionList.List()) &&
+			!m.bitwardenCollectionList.IsShowingCollectionModal()
+	case StateFolderSelect:
+		return m.bitwardenFolderList != nil && !isFiltering(m.bitwardenFolderList.List())
+	case StateSelectStorage, StateReconcile, StateEditConflict, StateKeyDeploy, StateError:
+		return true
+	}
+	return false
+}
+
+// isFiltering reports whether keys go to a list's filter input
+func isFiltering(l *list.Model) bool {
+	return l != nil && l.FilterState() == list.Filtering
+}
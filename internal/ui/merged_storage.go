@@ -0,0 +1,74 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ui/components"
+)
+
+// With + on the connection list, another backend is opened the usual way
+// and its connections join the list, as a config.MergedStorage of all the
+// backends opened so far.
+
+// storageSources returns the backends of the connection list, with their
+// labels
+func (m *Model) storageSources() []config.StorageSource {
+	if merged, ok := m.storageBackend.(*config.MergedStorage); ok {
+		return merged.Sources()
+	}
+	label := m.storageSelect.SelectedBackend()
+	if info, ok := config.LookupStorageBackend(label); ok {
+		label = info.Label
+	}
+	if labeler, ok := m.storageBackend.(config.StorageLabeler); ok {
+		label = labeler.StorageLabel()
+	}
+	return []config.StorageSource{{Label: label, Storage: m.storageBackend}}
+}
+
+// startMerge opens the storage selection to add a backend to the list
+func (m *Model) startMerge() tea.Cmd {
+	m.mergeSources = m.storageSources()
+	m.storageSelect = components.NewStorageSelect()
+	m.storageSelect.SetSize(m.width, m.height)
+	m.state = StateSelectStorage
+	return m.storageSelect.Init()
+}
+
+// mergeLoaded adds the backend that just loaded to the backends of the
+// list when one is being added, returning the connections to list
+func (m *Model) mergeLoaded(conns []config.SSHConnection) []config.SSHConnection {
+	if m.mergeSources == nil {
+		return conns
+	}
+	sources := m.mergeSources
+	m.mergeSources = nil
+	added := m.storageSources()[0]
+	merged := config.NewMergedStorage(sources...)
+	if merged.Contains(added.Storage) {
+		m.errorMessage = added.Label + " is already in the list"
+	} else {
+		merged = config.NewMergedStorage(append(sources, added)...)
+	}
+	m.storageBackend = merged
+	return merged.ListConnections()
+}
+
+// cancelMerge returns to the list without adding a backend
+func (m *Model) cancelMerge() tea.Cmd {
+	sources := m.mergeSources
+	m.mergeSources = nil
+	if len(sources) == 1 {
+		m.storageBackend = sources[0].Storage
+	} else {
+		m.storageBackend = config.NewMergedStorage(sources...)
+	}
+	if m.connectionList == nil {
+		m.connectionList = components.NewConnectionList(nil)
+	}
+	m.connectionList.SetConnections(m.storageBackend.ListConnections())
+	m.connectionList.SetSize(m.width, m.listHeight())
+	m.state = StateConnectionList
+	return nil
+}
@@ -1,19 +1,25 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/user"
 	"path/filepath"
 	"regexp"
+	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/zalando/go-keyring"
 
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/mux"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/ui/components"
 )
 
@@ -36,6 +42,13 @@ type (
 		Collections []config.Collection
 		Err         error
 	}
+	BitwardenCollectionSavedMsg struct {
+		Err error
+	}
+	BitwardenLoadFoldersMsg struct {
+		Folders []config.Folder
+		Err     error
+	}
 	BitwardenLoadConnectionsByCollectionMsg struct {
 		Connections []config.SSHConnection
 		Err         error
@@ -44,6 +57,20 @@ type (
 		Success bool
 		Err     error
 	}
+	BitwardenLockedMsg struct {
+		Err error
+	}
+	BitwardenSyncTickMsg struct{}
+	BitwardenSyncedMsg   struct {
+		Changed int
+		Err     error
+	}
+	BitwardenMirroredMsg struct {
+		Count int
+		Path  string
+		Err   error
+		Auto  bool
+	}
 	BitwardenUnlockResultMsg struct {
 		Success bool
 		Err     error
@@ -54,6 +81,12 @@ type (
 	DeleteConnectionResultMsg struct {
 		Err error
 	}
+	ConnectionRecordedMsg struct {
+		Err error
+	}
+	BookmarksSavedMsg struct {
+		Err error
+	}
 	SSHAuthRetryMsg struct {
 		Connection config.SSHConnection
 	}
@@ -61,6 +94,43 @@ type (
 		Connection config.SSHConnection
 		KeyFile    string
 	}
+	VaultLoginResultMsg struct {
+		Err error
+	}
+	KeePassXCOpenResultMsg struct {
+		Err error
+	}
+	ReconcilePlanMsg struct {
+		Path string
+		Plan *config.ReconcilePlan
+		Err  error
+	}
+	ReconcileResultMsg struct {
+		Err error
+	}
+	KeyInstallResultMsg struct {
+		Connection config.SSHConnection
+		Err        error
+	}
+	ImportResultMsg struct {
+		Added int
+		Err   error
+	}
+	BulkEditResultMsg struct {
+		Updated int
+		Err     error
+	}
+	ExportResultMsg struct {
+		Path  string
+		Count int
+		Err   error
+	}
+	ChallengeMsg struct {
+		Request *challengeRequest
+	}
+	MoshSessionEndedMsg struct {
+		Err error
+	}
 )
 
 // AppState type
@@ -78,7 +148,28 @@ const (
 	StateBitwardenUnlock
 	StateOrganizationSelect
 	StateCollectionSelect
+	StateFolderSelect
 	StateSSHPassphrase
+	StateVaultConfig
+	StateKeePassXCConfig
+	StateProcessManager
+	StateSystemdBrowser
+	StatePortChecker
+	StateReconcile
+	StateEditConflict
+	StateKeyManager
+	StateKeyDeploy
+	StateImport
+	StateExport
+	StateChallenge
+	StateBulkEdit
+	StateCloudImport
+	StateDiscovery
+	StateCommandRunner
+	StateSettings
+	StateQuickConnect
+	StateError
+	StateCollectionPicker
 
 	// Layout constants for full-screen UI
 	headerHeight  = 1 // Header line at top
@@ -92,6 +183,7 @@ type Model struct {
 	state                     AppState
 	storageSelect             *components.StorageSelect
 	storageBackend            config.Storage
+	mergeSources              []config.StorageSource // The list's backends while + adds another
 	sshConfigManager          *config.SSHConfigManager
 	width                     int
 	height                    int
@@ -106,28 +198,113 @@ type Model struct {
 	bitwardenUnlockForm       *components.BitwardenUnlockForm
 	bitwardenOrganizationList *components.BitwardenOrganizationList
 	bitwardenCollectionList   *components.BitwardenCollectionList
+	bitwardenFolderList       *components.BitwardenFolderList
 	sshPassphraseForm         *components.SSHPassphraseForm
+	vaultForm                 *components.VaultConfigForm
+	processManager            *components.ProcessManager
+	systemdBrowser            *components.SystemdBrowser
+	portChecker               *components.PortChecker
+	commandRunner             *components.CommandRunner
+	settingsForm              *components.SettingsForm
+	quickConnectForm          *components.QuickConnectForm
+	adHocConn                 *config.SSHConnection // unsaved host of the current quick-connect session
+	tempKeyFiles              []string              // keys downloaded from Bitwarden, removed on exit
+	bitwardenSyncScheduled    bool                  // a background sync tick is pending
+	vaultManager              *config.VaultManager
+	keePassForm               *components.KeePassXCConfigForm
+	keePassManager            *config.KeePassXCManager
+	reconcileConfirm          *components.ReconcileConfirmation
+	editConflict              *components.EditConflictDialog
+	reconcileChecked          bool // declarative file is only reconciled once per session
+	keyManager                *components.KeyManager
+	keyDeployPicker           *components.KeyDeployPicker
+	pendingDeploy             *keyDeployment
+	importWizard              *components.ImportWizard
+	exportForm                *components.ExportForm
+	challenges                chan *challengeRequest
+	challengeForm             *components.ChallengeForm
+	bulkEditForm              *components.BulkEditForm
+	cloudImport               *components.CloudImport
+	discovery                 *components.DiscoveryView
+	pendingChallenge          *challengeRequest
+	challengeReturnState      AppState
+	challengeWasLoading       bool
 	pendingAction             string
+	errorView                 *components.ErrorView
+	errorRetry                func() tea.Cmd // tries again what the error view shows failing, nil when it can't
+	errorReturnState          AppState
+	collectionPicker          *components.CollectionPicker
 	spinner                   spinner.Model
+	help                      help.Model
+	showHelp                  bool // the ? overlay listing the current screen's keys
 	loading                   bool
 	formHasError              bool
 }
 
 func NewModel() *Model {
 	s := spinner.New()
-	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
 	s.Spinner = spinner.Dot
+	applyTheme(config.CurrentSettings().Theme)
+	s.Style = lipgloss.NewStyle().Foreground(components.CurrentTheme().Primary)
 	return &Model{
 		state:         StateSelectStorage,
 		storageSelect: components.NewStorageSelect(),
 		width:         defaultWidth,
 		height:        defaultHeight,
 		spinner:       s,
+		help:          newHelpModel(),
+		challenges:    make(chan *challengeRequest),
 	}
 }
 
 func (m *Model) Init() tea.Cmd {
-	return m.spinner.Tick
+	// Keyboard-interactive prompts arrive on connection goroutines; route
+	// them to the UI instead of the terminal Bubble Tea owns
+	ssh.SetChallengeHandler(challengeHandler(m.challenges))
+	cmds := []tea.Cmd{m.spinner.Tick, waitForChallengeCmd(m.challenges)}
+
+	// Open the default storage backend straight away when one is set
+	if name := config.CurrentSettings().DefaultStorage; name != "" && m.storageSelect.ChooseByName(name) {
+		cmds = append(cmds, m.handleComponentResult(m.storageSelect, nil))
+	}
+	return tea.Batch(cmds...)
+}
+
+// challengeRequest is a keyboard-interactive prompt waiting for answers.
+// A nil reply cancels the login.
+type challengeRequest struct {
+	conn        config.SSHConnection
+	name        string
+	instruction string
+	questions   []string
+	echos       []bool
+	reply       chan []string
+}
+
+func challengeHandler(requests chan<- *challengeRequest) ssh.ChallengeHandler {
+	return func(conn config.SSHConnection, name, instruction string, questions []string, echos []bool) ([]string, error) {
+		req := &challengeRequest{
+			conn:        conn,
+			name:        name,
+			instruction: instruction,
+			questions:   questions,
+			echos:       echos,
+			reply:       make(chan []string, 1),
+		}
+		requests <- req
+		answers := <-req.reply
+		if answers == nil {
+			return nil, errors.New("authentication canceled")
+		}
+		return answers, nil
+	}
+}
+
+// waitForChallengeCmd delivers the next keyboard-interactive prompt
+func waitForChallengeCmd(requests <-chan *challengeRequest) tea.Cmd {
+	return func() tea.Msg {
+		return ChallengeMsg{Request: <-requests}
+	}
 }
 
 func (m *Model) listHeight() int {
@@ -153,34 +330,11 @@ func (m *Model) loadPersonalVaultConnections() tea.Cmd {
 
 func loadConnectionsCmd(backend config.Storage) tea.Cmd {
 	return func() tea.Msg {
-		switch b := backend.(type) {
-		case *config.SSHConfigManager:
-			if err := b.Load(); err != nil {
-				log.Printf("LoadConnectionsFinishedMsg: error loading SSH config manager: %v", err)
-				return LoadConnectionsFinishedMsg{Err: err}
-			}
-			return LoadConnectionsFinishedMsg{Connections: b.ListConnections()}
-		case *config.BitwardenManager:
-			var err error
-			if b.IsPersonalVault() {
-				err = b.Load()
-			} else {
-				coll := b.GetSelectedCollection()
-				if coll == nil {
-					log.Printf("LoadConnectionsFinishedMsg: no selected collection in Bitwarden")
-					return LoadConnectionsFinishedMsg{Err: fmt.Errorf("no selected collection")}
-				}
-				err = b.LoadConnectionsByCollectionId(coll.ID)
-			}
-			if err != nil {
-				log.Printf("LoadConnectionsFinishedMsg: error loading bitwarden: %v", err)
-				return LoadConnectionsFinishedMsg{Err: err}
-			}
-			return LoadConnectionsFinishedMsg{Connections: b.ListConnections()}
-		default:
-			log.Printf("LoadConnectionsFinishedMsg: unknown storage backend")
-			return LoadConnectionsFinishedMsg{Err: fmt.Errorf("unknown storage backend")}
+		if err := config.LoadStorage(backend); err != nil {
+			log.Printf("LoadConnectionsFinishedMsg: error loading %T: %v", backend, err)
+			return LoadConnectionsFinishedMsg{Err: err}
 		}
+		return LoadConnectionsFinishedMsg{Connections: backend.ListConnections()}
 	}
 }
 
@@ -198,6 +352,54 @@ func loadBitwardenStatusCmd(bw *config.BitwardenManager) tea.Cmd {
 	}
 }
 
+func lockBitwardenCmd(bw *config.BitwardenManager) tea.Cmd {
+	return func() tea.Msg {
+		return BitwardenLockedMsg{Err: bw.Lock()}
+	}
+}
+
+// scheduleBitwardenSync starts the background sync of the Bitwarden vault,
+// unless a tick is already pending or the interval is 0
+func (m *Model) scheduleBitwardenSync() tea.Cmd {
+	if _, ok := m.storageBackend.(*config.BitwardenManager); !ok || m.bitwardenSyncScheduled {
+		return nil
+	}
+	minutes := config.CurrentSettings().BitwardenSyncIntervalMinutes
+	if minutes <= 0 {
+		return nil
+	}
+	m.bitwardenSyncScheduled = true
+	return tea.Tick(time.Duration(minutes)*time.Minute, func(time.Time) tea.Msg {
+		return BitwardenSyncTickMsg{}
+	})
+}
+
+func syncBitwardenCmd(bw *config.BitwardenManager) tea.Cmd {
+	return func() tea.Msg {
+		changed, err := bw.Refresh()
+		return BitwardenSyncedMsg{Changed: changed, Err: err}
+	}
+}
+
+// mirrorBitwardenCmd writes the Bitwarden connections to ~/.ssh/config,
+// auto is set when it follows a load rather than the M key
+func mirrorBitwardenCmd(bw *config.BitwardenManager, auto bool) tea.Cmd {
+	return func() tea.Msg {
+		count, path, err := bw.MirrorToSSHConfig()
+		return BitwardenMirroredMsg{Count: count, Path: path, Err: err, Auto: auto}
+	}
+}
+
+// autoMirrorBitwarden mirrors the vault after it loaded or changed, when
+// turned on in the settings
+func (m *Model) autoMirrorBitwarden() tea.Cmd {
+	bw, ok := m.storageBackend.(*config.BitwardenManager)
+	if !ok || !config.CurrentSettings().BitwardenMirrorSSHConfig {
+		return nil
+	}
+	return mirrorBitwardenCmd(bw, true)
+}
+
 func loadBitwardenOrganizationsCmd(bw *config.BitwardenManager) tea.Cmd {
 	return func() tea.Msg {
 		if err := bw.LoadOrganizations(); err != nil {
@@ -218,6 +420,28 @@ func loadBitwardenCollectionsCmd(bw *config.BitwardenManager, orgID string) tea.
 	}
 }
 
+func saveBitwardenCollectionCmd(bw *config.BitwardenManager, orgID string, msg components.SaveCollectionMsg) tea.Cmd {
+	return func() tea.Msg {
+		var err error
+		if msg.Collection != nil {
+			err = bw.RenameCollection(orgID, msg.Collection.ID, msg.Name)
+		} else {
+			err = bw.CreateCollection(orgID, msg.Name)
+		}
+		return BitwardenCollectionSavedMsg{Err: err}
+	}
+}
+
+func loadBitwardenFoldersCmd(bw *config.BitwardenManager) tea.Cmd {
+	return func() tea.Msg {
+		if err := bw.LoadFolders(); err != nil {
+			log.Printf("BitwardenLoadFoldersMsg: error loading folders: %v", err)
+			return BitwardenLoadFoldersMsg{Err: err}
+		}
+		return BitwardenLoadFoldersMsg{Folders: bw.ListFolders()}
+	}
+}
+
 func loadBitwardenConnectionsByCollectionCmd(bw *config.BitwardenManager, collectionID string) tea.Cmd {
 	return func() tea.Msg {
 		if err := bw.LoadConnectionsByCollectionId(collectionID); err != nil {
@@ -248,37 +472,151 @@ func unlockBitwardenCmd(bw *config.BitwardenManager, password string) tea.Cmd {
 	}
 }
 
-func saveConnectionCmd(
-	backend config.Storage,
-	bitwardenManager *config.BitwardenManager,
-	storageSelect *components.StorageSelect,
-	bitwardenCollectionList *components.BitwardenCollectionList,
-	bitwardenOrganizationList *components.BitwardenOrganizationList,
-	conn config.SSHConnection,
-	isEdit bool,
-) tea.Cmd {
+func loginVaultCmd(vm *config.VaultManager) tea.Cmd {
+	return func() tea.Msg {
+		if err := vm.Login(); err != nil {
+			log.Printf("VaultLoginResultMsg: error logging in: %v", err)
+			return VaultLoginResultMsg{Err: err}
+		}
+		return VaultLoginResultMsg{}
+	}
+}
+
+// openKeePassXCCmd checks the database credentials by reading it once
+func openKeePassXCCmd(km *config.KeePassXCManager) tea.Cmd {
+	return func() tea.Msg {
+		if err := km.Load(); err != nil {
+			log.Printf("KeePassXCOpenResultMsg: error opening database: %v", err)
+			return KeePassXCOpenResultMsg{Err: err}
+		}
+		return KeePassXCOpenResultMsg{}
+	}
+}
+
+// planReconcileCmd loads the declarative connections file, if any, and
+// computes the changes needed to bring the backend in line with it
+func planReconcileCmd(path string, existing []config.SSHConnection) tea.Cmd {
+	return func() tea.Msg {
+		declared, err := config.LoadDeclarativeFile(path)
+		if err != nil {
+			log.Printf("ReconcilePlanMsg: error loading declarative file: %v", err)
+			return ReconcilePlanMsg{Path: path, Err: err}
+		}
+		return ReconcilePlanMsg{Path: path, Plan: config.PlanReconcile(declared, existing)}
+	}
+}
+
+func applyReconcileCmd(backend config.Storage, plan *config.ReconcilePlan) tea.Cmd {
+	return func() tea.Msg {
+		return ReconcileResultMsg{Err: config.ApplyReconcilePlan(backend, plan)}
+	}
+}
+
+func importConnectionsCmd(backend config.Storage, conns []config.SSHConnection) tea.Cmd {
+	return func() tea.Msg {
+		added, err := config.AddImportedConnections(backend, conns)
+		return ImportResultMsg{Added: added, Err: err}
+	}
+}
+
+func bulkEditCmd(backend config.Storage, conns []config.SSHConnection, field config.BulkField, value string) tea.Cmd {
+	return func() tea.Msg {
+		ids := make([]string, len(conns))
+		for i, conn := range conns {
+			ids[i] = conn.ID
+		}
+		updated, err := config.BulkEdit(backend, ids, field, value)
+		return BulkEditResultMsg{Updated: updated, Err: err}
+	}
+}
+
+func exportConnectionsCmd(backend config.Storage, ids []string, format config.ExportFormat, includeSecrets bool, path string) tea.Cmd {
+	return func() tea.Msg {
+		conns := config.ConnectionsForExport(backend, ids, includeSecrets)
+		err := config.ExportToFile(path, conns, format, includeSecrets)
+		return ExportResultMsg{Path: path, Count: len(conns), Err: err}
+	}
+}
+
+// keyDeployment is a public key waiting to be installed on a connection's
+// host, after which the connection switches to that key
+type keyDeployment struct {
+	conn          config.SSHConnection
+	keyFile       string
+	authorizedKey string
+	passphrase    string          // passphrase of the deployed key, if any
+	knownIDs      map[string]bool // IDs stored before conn was added, nil when conn is edited
+}
+
+// storedID finds the ID conn was added under among those not in knownIDs,
+// as backends such as Bitwarden and KeePassXC give their own
+func storedID(backend config.Storage, conn config.SSHConnection, knownIDs map[string]bool) (string, bool) {
+	for _, stored := range backend.ListConnections() {
+		if !knownIDs[stored.ID] && stored.Name == conn.Name && stored.Host == conn.Host {
+			return stored.ID, true
+		}
+	}
+	return "", false
+}
+
+// deployKeyCmd logs in with the connection's current auth method, appends
+// the public key to authorized_keys and switches the connection to key auth
+func deployKeyCmd(backend config.Storage, d keyDeployment) tea.Cmd {
+	return func() tea.Msg {
+		conn := d.conn
+		client, err := ssh.NewClient(conn)
+		if err != nil {
+			log.Printf("KeyInstallResultMsg: error connecting: %v", err)
+			return KeyInstallResultMsg{Connection: conn, Err: err}
+		}
+		defer client.Close()
+
+		if err := client.InstallPublicKey(d.authorizedKey); err != nil {
+			log.Printf("KeyInstallResultMsg: error installing key: %v", err)
+			return KeyInstallResultMsg{Connection: conn, Err: err}
+		}
+
+		conn.UsePassword = false
+		conn.KeyFile = d.keyFile
+		conn.Password = d.passphrase
+		if err := backend.EditConnection(conn); err != nil {
+			log.Printf("KeyInstallResultMsg: error updating connection: %v", err)
+			return KeyInstallResultMsg{Connection: conn, Err: err}
+		}
+		return KeyInstallResultMsg{Connection: conn}
+	}
+}
+
+func saveConnectionCmd(backend config.Storage, conn config.SSHConnection, isEdit bool) tea.Cmd {
 	return func() tea.Msg {
-		var err error
 		if isEdit {
-			err = backend.EditConnection(conn)
-		} else if storageSelect.IsChosen() {
-			switch storageSelect.SelectedBackend() {
-			case components.StorageLocal:
-				err = backend.AddConnection(conn)
-			case components.StorageBitwarden:
-				if bitwardenManager.IsPersonalVault() {
-					err = backend.AddConnection(conn)
-				} else {
-					var collectionID, organizationID string
-					if selCol, selOrg := bitwardenCollectionList.SelectedCollection(), bitwardenOrganizationList.SelectedOrganization(); selCol != nil && selOrg != nil {
-						collectionID = selCol.ID
-						organizationID = selOrg.ID
-					}
-					err = bitwardenManager.AddConnectionInCollectionAndOrganization(conn, organizationID, collectionID)
-				}
-			}
+			return SaveConnectionResultMsg{Err: backend.EditConnection(conn)}
 		}
-		return SaveConnectionResultMsg{Err: err}
+		return SaveConnectionResultMsg{Err: backend.AddConnection(conn)}
+	}
+}
+
+// overwriteConnectionCmd saves conn over a Bitwarden item that was changed
+// since it was loaded, once the user chose to
+func overwriteConnectionCmd(bw *config.BitwardenManager, conn config.SSHConnection) tea.Cmd {
+	return func() tea.Msg {
+		return SaveConnectionResultMsg{Err: bw.OverwriteConnection(conn)}
+	}
+}
+
+// recordConnectionCmd updates the usage stats of a connection in the
+// connection usage file, leaving the storage backend alone
+func recordConnectionCmd(conn config.SSHConnection) tea.Cmd {
+	return func() tea.Msg {
+		return ConnectionRecordedMsg{Err: config.RecordUsage(conn, time.Now())}
+	}
+}
+
+// saveBookmarksCmd saves the file manager bookmarks of a connection in the
+// background
+func saveBookmarksCmd(backend config.Storage, id string, bookmarks []string) tea.Cmd {
+	return func() tea.Msg {
+		return BookmarksSavedMsg{Err: config.SetBookmarks(backend, id, bookmarks)}
 	}
 }
 
@@ -289,6 +627,16 @@ func deleteConnectionCmd(backend config.Storage, id string) tea.Cmd {
 	}
 }
 
+// RemoveTempKeyFiles deletes the private keys downloaded for sessions
+func (m *Model) RemoveTempKeyFiles() {
+	for _, path := range m.tempKeyFiles {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("Failed to remove temporary key file %s: %v", path, err)
+		}
+	}
+	m.tempKeyFiles = nil
+}
+
 // Helpers
 func sanitizeFileName(name string) string {
 	return regexp.MustCompile(`[^a-zA-Z0-9]`).ReplaceAllString(name, "_")
@@ -328,6 +676,8 @@ func (m *Model) getActiveComponent() tea.Model {
 		return m.bitwardenOrganizationList
 	case StateCollectionSelect:
 		return m.bitwardenCollectionList
+	case StateFolderSelect:
+		return m.bitwardenFolderList
 	case StateConnectionList:
 		return m.connectionList
 	case StateAddConnection, StateEditConnection:
@@ -338,6 +688,46 @@ func (m *Model) getActiveComponent() tea.Model {
 		return m.scpManager
 	case StateSSHPassphrase:
 		return m.sshPassphraseForm
+	case StateVaultConfig:
+		return m.vaultForm
+	case StateKeePassXCConfig:
+		return m.keePassForm
+	case StateProcessManager:
+		return m.processManager
+	case StateSystemdBrowser:
+		return m.systemdBrowser
+	case StatePortChecker:
+		return m.portChecker
+	case StateCommandRunner:
+		return m.commandRunner
+	case StateSettings:
+		return m.settingsForm
+	case StateQuickConnect:
+		return m.quickConnectForm
+	case StateReconcile:
+		return m.reconcileConfirm
+	case StateEditConflict:
+		return m.editConflict
+	case StateKeyManager:
+		return m.keyManager
+	case StateKeyDeploy:
+		return m.keyDeployPicker
+	case StateImport:
+		return m.importWizard
+	case StateExport:
+		return m.exportForm
+	case StateChallenge:
+		return m.challengeForm
+	case StateBulkEdit:
+		return m.bulkEditForm
+	case StateCloudImport:
+		return m.cloudImport
+	case StateDiscovery:
+		return m.discovery
+	case StateError:
+		return m.errorView
+	case StateCollectionPicker:
+		return m.collectionPicker
 	default:
 		return nil
 	}
@@ -348,38 +738,63 @@ func (m *Model) handleComponentResult(model tea.Model, cmd tea.Cmd) tea.Cmd {
 	case StateSelectStorage:
 		m.storageSelect = model.(*components.StorageSelect)
 		if m.storageSelect.IsCanceled() {
+			if m.mergeSources != nil {
+				return m.cancelMerge()
+			}
 			return tea.Quit
 		}
 		if m.storageSelect.IsChosen() {
-			switch m.storageSelect.SelectedBackend() {
-			case components.StorageLocal:
-				m.loading = true
-				scm, err := config.NewSSHConfigManager()
-				if err != nil {
-					m.errorMessage = fmt.Sprintf("Error initializing SSH config: %s", err)
-					m.loading = false
-					return nil
-				}
-				m.storageBackend = scm
-				m.sshConfigManager = scm
-				return tea.Batch(
-					loadConnectionsCmd(scm),
-					m.spinner.Tick,
-				)
-			case components.StorageBitwarden:
-				m.loading = true
-				bwm, err := config.NewBitwardenManager(&config.BitwardenConfig{})
-				if err != nil {
-					m.errorMessage = fmt.Sprintf("Error initializing Bitwarden: %s", err)
-					m.loading = false
-					return nil
-				}
-				m.bitwardenManager = bwm
-				return tea.Batch(
-					loadBitwardenStatusCmd(bwm),
-					m.spinner.Tick,
-				)
+			return m.openStorage(m.storageSelect.SelectedBackend())
+		}
+		return nil
+
+	case StateKeePassXCConfig:
+		m.keePassForm = model.(*components.KeePassXCConfigForm)
+		if m.keePassForm.IsCanceled() {
+			m.keePassForm = nil
+			m.state = StateSelectStorage
+			m.storageSelect = components.NewStorageSelect()
+			m.storageSelect.SetSize(m.width, m.height)
+			return nil
+		}
+		if m.keePassForm.IsSubmitted() {
+			km, err := config.NewKeePassXCManager(m.keePassForm.Config())
+			if err != nil {
+				m.keePassForm.SetError(err.Error())
+				m.keePassForm.ResetSubmitted()
+				return nil
 			}
+			m.keePassManager = km
+			m.loading = true
+			return tea.Batch(
+				openKeePassXCCmd(km),
+				m.spinner.Tick,
+			)
+		}
+		return nil
+
+	case StateVaultConfig:
+		m.vaultForm = model.(*components.VaultConfigForm)
+		if m.vaultForm.IsCanceled() {
+			m.vaultForm = nil
+			m.state = StateSelectStorage
+			m.storageSelect = components.NewStorageSelect()
+			m.storageSelect.SetSize(m.width, m.height)
+			return nil
+		}
+		if m.vaultForm.IsSubmitted() {
+			vm, err := config.NewVaultManager(m.vaultForm.Config())
+			if err != nil {
+				m.vaultForm.SetError(err.Error())
+				m.vaultForm.ResetSubmitted()
+				return nil
+			}
+			m.vaultManager = vm
+			m.loading = true
+			return tea.Batch(
+				loginVaultCmd(vm),
+				m.spinner.Tick,
+			)
 		}
 		return nil
 
@@ -393,14 +808,21 @@ func (m *Model) handleComponentResult(model tea.Model, cmd tea.Cmd) tea.Cmd {
 			return nil
 		}
 		if m.bitwardenForm.IsSubmitted() {
-			serverURL, email := m.bitwardenForm.Config()
-			cfg := &config.BitwardenConfig{ServerURL: serverURL, Email: email}
+			cfg := m.bitwardenForm.Config()
 			bwm, err := config.NewBitwardenManager(cfg)
 			if err != nil {
 				m.bitwardenForm.ErrorMsg = err.Error()
 				return nil
 			}
 			m.bitwardenManager = bwm
+			if cfg.LoginMethod != config.BitwardenLoginPassword {
+				// API key and SSO logins need nothing more before bw runs
+				m.loading = true
+				return tea.Batch(
+					loginBitwardenCmd(m.bitwardenManager, "", ""),
+					m.spinner.Tick,
+				)
+			}
 			m.bitwardenLoginForm = components.NewBitwardenLoginForm()
 			m.bitwardenLoginForm.SetSize(m.width, m.height)
 			m.state = StateBitwardenLogin
@@ -467,6 +889,13 @@ func (m *Model) handleComponentResult(model tea.Model, cmd tea.Cmd) tea.Cmd {
 			)
 		}
 
+	case StateFolderSelect:
+		m.bitwardenFolderList = model.(*components.BitwardenFolderList)
+		if m.bitwardenFolderList.IsChosen() {
+			m.bitwardenManager.SetSelectedFolder(m.bitwardenFolderList.SelectedFolder())
+			return m.loadPersonalVaultConnections()
+		}
+
 	case StateConnectionList:
 		return m.handleConnectionList(model)
 
@@ -481,25 +910,41 @@ func (m *Model) handleComponentResult(model tea.Model, cmd tea.Cmd) tea.Cmd {
 		if m.connectionForm.IsSubmitted() {
 			m.loading = true
 			conn := m.connectionForm.Connection()
+			if key := m.connectionForm.PendingKeyInstall(); key != nil {
+				m.pendingDeploy = &keyDeployment{
+					conn:          conn,
+					keyFile:       key.Key.PrivateKeyPath,
+					authorizedKey: key.Key.AuthorizedKey,
+					passphrase:    key.Passphrase,
+				}
+				if m.state == StateAddConnection {
+					m.pendingDeploy.knownIDs = make(map[string]bool)
+					for _, known := range m.storageBackend.ListConnections() {
+						m.pendingDeploy.knownIDs[known.ID] = true
+					}
+				}
+			}
 			return tea.Batch(
-				saveConnectionCmd(
-					m.storageBackend,
-					m.bitwardenManager,
-					m.storageSelect,
-					m.bitwardenCollectionList,
-					m.bitwardenOrganizationList,
-					conn,
-					m.state == StateEditConnection,
-				),
+				saveConnectionCmd(m.storageBackend, conn, m.state == StateEditConnection),
 				m.spinner.Tick,
 			)
 		}
 	case StateSSHTerminal:
 		m.terminal = model.(*components.TerminalComponent)
 		if m.terminal.IsFinished() {
+			connected := m.terminal.IsConnected()
 			m.terminal = nil
-			m.state = StateConnectionList
 			m.connectionList.Reset()
+			if m.adHocConn != nil && connected {
+				// Offer to keep the host that was connected to ad hoc
+				m.quickConnectForm = components.NewQuickConnectSavePrompt(*m.adHocConn)
+				m.quickConnectForm.SetSize(m.width, m.height)
+				m.adHocConn = nil
+				m.state = StateQuickConnect
+				return nil
+			}
+			m.adHocConn = nil
+			m.state = StateConnectionList
 			return nil
 		}
 	case StateSCPFileManager:
@@ -510,6 +955,276 @@ func (m *Model) handleComponentResult(model tea.Model, cmd tea.Cmd) tea.Cmd {
 			m.connectionList.Reset()
 			return nil
 		}
+	case StateProcessManager:
+		m.processManager = model.(*components.ProcessManager)
+		if m.processManager.IsFinished() {
+			m.processManager = nil
+			m.state = StateConnectionList
+			m.connectionList.Reset()
+			return nil
+		}
+	case StateSystemdBrowser:
+		m.systemdBrowser = model.(*components.SystemdBrowser)
+		if m.systemdBrowser.IsFinished() {
+			m.systemdBrowser = nil
+			m.state = StateConnectionList
+			m.connectionList.Reset()
+			return nil
+		}
+	case StatePortChecker:
+		m.portChecker = model.(*components.PortChecker)
+		if m.portChecker.IsFinished() {
+			m.portChecker = nil
+			m.state = StateConnectionList
+			m.connectionList.Reset()
+			return nil
+		}
+	case StateCommandRunner:
+		m.commandRunner = model.(*components.CommandRunner)
+		if m.commandRunner.IsFinished() {
+			m.commandRunner = nil
+			m.state = StateConnectionList
+			m.connectionList.Reset()
+			return nil
+		}
+	case StateSettings:
+		m.settingsForm = model.(*components.SettingsForm)
+		if m.settingsForm.IsSubmitted() {
+			settings := m.settingsForm.Settings()
+			if settings.Multiplexer != config.CurrentSettings().Multiplexer {
+				config.ActiveMultiplexer = mux.Detect(settings.Multiplexer)
+			}
+			if config.ActiveMultiplexer != "" && settings.MultiplexerOpenNew != m.connectionList.OpenInNewTerminal() &&
+				settings.MultiplexerOpenNew != config.CurrentSettings().MultiplexerOpenNew {
+				m.connectionList.ToggleOpenInNewTerminal()
+			}
+			config.SetCurrentSettings(settings)
+			m.connectionList.SetSortMode(settings.ConnectionSort)
+			applyTheme(settings.Theme)
+			m.spinner.Style = lipgloss.NewStyle().Foreground(components.CurrentTheme().Primary)
+			m.help = newHelpModel()
+		}
+		if m.settingsForm.IsSubmitted() || m.settingsForm.IsCanceled() {
+			m.settingsForm = nil
+			m.state = StateConnectionList
+			m.connectionList.Reset()
+			return nil
+		}
+	case StateQuickConnect:
+		m.quickConnectForm = model.(*components.QuickConnectForm)
+		switch {
+		case m.quickConnectForm.IsCanceled():
+			m.quickConnectForm = nil
+			m.state = StateConnectionList
+			m.connectionList.Reset()
+			return nil
+		case m.quickConnectForm.IsSubmitted():
+			conn := m.quickConnectForm.Connection()
+			m.quickConnectForm = nil
+			return m.startAdHocSession(conn)
+		case m.quickConnectForm.IsSaveRequested():
+			m.connectionForm = components.NewConnectionFormFrom(m.quickConnectForm.Connection())
+			m.connectionForm.SetSize(m.width, m.height)
+			m.offerFolders(false)
+			m.quickConnectForm = nil
+			m.state = StateAddConnection
+			return m.connectionForm.Init()
+		}
+	case StateKeyManager:
+		m.keyManager = model.(*components.KeyManager)
+		if m.keyManager.IsFinished() {
+			m.keyManager = nil
+			m.state = StateConnectionList
+			m.connectionList.Reset()
+			return nil
+		}
+	case StateKeyDeploy:
+		m.keyDeployPicker = model.(*components.KeyDeployPicker)
+		if m.keyDeployPicker.IsCanceled() {
+			m.keyDeployPicker = nil
+			m.state = StateConnectionList
+			m.connectionList.Reset()
+			return nil
+		}
+		if keyFile, authorizedKey := m.keyDeployPicker.SelectedKey(); keyFile != "" {
+			m.pendingDeploy = &keyDeployment{
+				conn:          m.keyDeployPicker.Connection(),
+				keyFile:       keyFile,
+				authorizedKey: authorizedKey,
+			}
+			m.keyDeployPicker = nil
+			m.state = StateConnectionList
+			m.connectionList.Reset()
+			m.loading = true
+			return tea.Batch(
+				deployKeyCmd(m.storageBackend, *m.pendingDeploy),
+				m.spinner.Tick,
+			)
+		}
+	case StateImport:
+		m.importWizard = model.(*components.ImportWizard)
+		if m.importWizard.IsCanceled() {
+			m.importWizard = nil
+			m.state = StateConnectionList
+			m.connectionList.Reset()
+			return nil
+		}
+		if m.importWizard.IsConfirmed() {
+			conns := m.importWizard.Selected()
+			m.importWizard = nil
+			m.state = StateConnectionList
+			m.connectionList.Reset()
+			m.loading = true
+			return tea.Batch(
+				importConnectionsCmd(m.storageBackend, conns),
+				m.spinner.Tick,
+			)
+		}
+	case StateChallenge:
+		m.challengeForm = model.(*components.ChallengeForm)
+		if m.challengeForm.IsCanceled() || m.challengeForm.IsSubmitted() {
+			var answers []string
+			if m.challengeForm.IsSubmitted() {
+				answers = m.challengeForm.Answers()
+			}
+			m.pendingChallenge.reply <- answers
+			m.challengeForm = nil
+			m.pendingChallenge = nil
+			m.state = m.challengeReturnState
+			m.loading = m.challengeWasLoading
+			return tea.Batch(cmd, waitForChallengeCmd(m.challenges))
+		}
+	case StateBulkEdit:
+		m.bulkEditForm = model.(*components.BulkEditForm)
+		if m.bulkEditForm.IsCanceled() {
+			m.bulkEditForm = nil
+			m.state = StateConnectionList
+			return nil
+		}
+		if m.bulkEditForm.IsSubmitted() {
+			form := m.bulkEditForm
+			m.bulkEditForm = nil
+			m.connectionList.ClearMarks()
+			m.state = StateConnectionList
+			m.loading = true
+			return tea.Batch(
+				bulkEditCmd(m.storageBackend, form.Connections(), form.Field(), form.Value()),
+				m.spinner.Tick,
+			)
+		}
+	case StateCloudImport:
+		m.cloudImport = model.(*components.CloudImport)
+		if m.cloudImport.IsCanceled() {
+			m.cloudImport = nil
+			m.state = StateConnectionList
+			m.connectionList.Reset()
+			return nil
+		}
+		if conn := m.cloudImport.ConnectTarget(); conn != nil {
+			// Connect without saving; the session ends with an offer to save
+			m.cloudImport = nil
+			m.connectionList.Reset()
+			return m.startAdHocSession(*conn)
+		}
+		if m.cloudImport.IsConfirmed() {
+			conns := m.cloudImport.Selected()
+			m.cloudImport = nil
+			m.state = StateConnectionList
+			m.connectionList.Reset()
+			m.loading = true
+			return tea.Batch(
+				importConnectionsCmd(m.storageBackend, conns),
+				m.spinner.Tick,
+			)
+		}
+	case StateDiscovery:
+		m.discovery = model.(*components.DiscoveryView)
+		switch {
+		case m.discovery.IsCanceled():
+			m.discovery = nil
+			m.state = StateConnectionList
+			m.connectionList.Reset()
+			return nil
+		case m.discovery.ConnectTarget() != nil:
+			conn := *m.discovery.ConnectTarget()
+			m.discovery = nil
+			m.connectionList.Reset()
+			return m.startAdHocSession(conn)
+		case m.discovery.SaveTarget() != nil:
+			m.connectionForm = components.NewConnectionFormFrom(*m.discovery.SaveTarget())
+			m.connectionForm.SetSize(m.width, m.height)
+			m.offerFolders(false)
+			m.discovery = nil
+			m.state = StateAddConnection
+			return m.connectionForm.Init()
+		}
+	case StateError:
+		return m.handleErrorView(model, cmd)
+	case StateCollectionPicker:
+		return m.handleCollectionPicker(model)
+	case StateExport:
+		m.exportForm = model.(*components.ExportForm)
+		if m.exportForm.IsCanceled() {
+			m.exportForm = nil
+			m.state = StateConnectionList
+			m.connectionList.Reset()
+			return nil
+		}
+		if m.exportForm.IsConfirmed() {
+			form := m.exportForm
+			m.exportForm = nil
+			m.state = StateConnectionList
+			m.connectionList.Reset()
+			return exportConnectionsCmd(m.storageBackend, form.IDs(), form.Format(), form.IncludeSecrets(), form.Path())
+		}
+	case StateReconcile:
+		m.reconcileConfirm = model.(*components.ReconcileConfirmation)
+		if m.reconcileConfirm.IsCanceled() {
+			m.reconcileConfirm = nil
+			m.state = StateConnectionList
+			m.connectionList.Reset()
+			return nil
+		}
+		if m.reconcileConfirm.IsConfirmed() {
+			plan := m.reconcileConfirm.Plan()
+			m.reconcileConfirm = nil
+			m.loading = true
+			return tea.Batch(
+				applyReconcileCmd(m.storageBackend, plan),
+				m.spinner.Tick,
+			)
+		}
+	case StateEditConflict:
+		m.editConflict = model.(*components.EditConflictDialog)
+		conflict := m.editConflict.Conflict()
+		switch m.editConflict.Resolution() {
+		case components.ConflictBack:
+			m.editConflict = nil
+			m.connectionForm.ResetSubmitted()
+			m.state = StateEditConnection
+			return nil
+		case components.ConflictReload:
+			m.editConflict = nil
+			m.connectionForm = nil
+			m.pendingDeploy = nil
+			m.state = StateConnectionList
+			m.loading = true
+			return tea.Batch(
+				loadConnectionsCmd(m.storageBackend),
+				m.spinner.Tick,
+			)
+		case components.ConflictOverwrite, components.ConflictMerge:
+			conn := conflict.Mine
+			if m.editConflict.Resolution() == components.ConflictMerge {
+				conn = conflict.Merged()
+			}
+			m.editConflict = nil
+			m.loading = true
+			return tea.Batch(
+				overwriteConnectionCmd(m.bitwardenManager, conn),
+				m.spinner.Tick,
+			)
+		}
 	case StateSSHPassphrase:
 		m.sshPassphraseForm = model.(*components.SSHPassphraseForm)
 		if m.sshPassphraseForm.IsCanceled() {
@@ -517,6 +1232,8 @@ func (m *Model) handleComponentResult(model tea.Model, cmd tea.Cmd) tea.Cmd {
 			m.connectionList.SetSize(m.width, m.listHeight())
 			m.sshPassphraseForm = nil
 			m.pendingAction = ""
+			m.pendingDeploy = nil
+			m.adHocConn = nil
 			return nil
 		}
 		if m.sshPassphraseForm.IsSubmitted() {
@@ -524,8 +1241,13 @@ func (m *Model) handleComponentResult(model tea.Model, cmd tea.Cmd) tea.Cmd {
 			updatedConn := m.sshPassphraseForm.Connection
 			updatedConn.Password = m.sshPassphraseForm.Value()
 
-			// Save the password to keyring for future use
-			if updatedConn.UsePassword && updatedConn.Password != "" {
+			if m.adHocConn != nil {
+				m.adHocConn = &updatedConn
+			}
+
+			// Save the password to keyring for future use; ad-hoc hosts
+			// have no ID to store it under
+			if updatedConn.UsePassword && updatedConn.Password != "" && updatedConn.ID != "" {
 				if err := keyring.Set(keyringService, updatedConn.ID, updatedConn.Password); err != nil {
 					log.Printf("Failed to save password to keyring: %v", err)
 					m.errorMessage = fmt.Sprintf("Warning: Password not saved to keyring: %s", err)
@@ -533,12 +1255,30 @@ func (m *Model) handleComponentResult(model tea.Model, cmd tea.Cmd) tea.Cmd {
 					log.Printf("Password saved to keyring for connection ID: %s", updatedConn.ID)
 				}
 			}
+			if keyFile := m.sshPassphraseForm.KeyFile(); keyFile != "" && updatedConn.Password != "" {
+				m.keepPassphrase(updatedConn, keyFile, m.sshPassphraseForm.Save())
+			}
 
 			action := m.pendingAction
 			m.sshPassphraseForm = nil
 			m.pendingAction = ""
 
-			if action == "scp" {
+			switch action {
+			case "deploykey":
+				m.state = StateConnectionList
+				m.pendingDeploy.conn = updatedConn
+				m.loading = true
+				return tea.Batch(
+					deployKeyCmd(m.storageBackend, *m.pendingDeploy),
+					m.spinner.Tick,
+				)
+			case "processes":
+				return m.openProcessManager(updatedConn)
+			case "systemd":
+				return m.openSystemdBrowser(updatedConn)
+			case "ports":
+				return m.openPortChecker(updatedConn)
+			case "scp":
 				// Launch SCP manager with the passphrase
 				m.scpManager = components.NewSCPManager(updatedConn)
 				m.state = StateSCPFileManager
@@ -551,7 +1291,7 @@ func (m *Model) handleComponentResult(model tea.Model, cmd tea.Cmd) tea.Cmd {
 				}
 				_, sizeCmd := m.scpManager.Update(sizeMsg)
 				return tea.Batch(initCmd, sizeCmd)
-			} else {
+			default:
 				// Default to terminal
 				m.terminal = components.NewTerminalComponent(updatedConn)
 				m.state = StateSSHTerminal
@@ -570,35 +1310,166 @@ func (m *Model) handleComponentResult(model tea.Model, cmd tea.Cmd) tea.Cmd {
 	return cmd
 }
 
+// keepPassphrase stores a key passphrase typed into the passphrase form in
+// the keyring, or adds the key to ssh-agent, as chosen on the form
+func (m *Model) keepPassphrase(conn config.SSHConnection, keyFile string, save components.PassphraseSave) {
+	switch save {
+	case components.PassphraseSaveKeyring:
+		if err := ssh.CachePassphrase(conn.ID, keyFile, conn.Password); err != nil {
+			log.Printf("Failed to save passphrase to keyring: %v", err)
+			m.errorMessage = fmt.Sprintf("Warning: Passphrase not saved to keyring: %s", err)
+		}
+	case components.PassphraseSaveAgent:
+		if err := ssh.AddKeyToAgent(keyFile, conn.Password, 0); err != nil {
+			log.Printf("Failed to add %s to ssh-agent: %v", keyFile, err)
+			m.errorMessage = fmt.Sprintf("Warning: Key not added to ssh-agent: %s", err)
+		} else {
+			m.errorMessage = fmt.Sprintf("Added %s to ssh-agent", keyFile)
+		}
+	}
+}
+
+// openProcessManager switches to the remote process manager for conn
+func (m *Model) openProcessManager(conn config.SSHConnection) tea.Cmd {
+	m.processManager = components.NewProcessManager(conn)
+	m.processManager.SetSize(m.width, max(m.height-headerHeight-footerHeight, 12))
+	m.state = StateProcessManager
+	return m.processManager.Init()
+}
+
+// confirmConnectionAction opens what action needs for conn, once the host
+// name of a production connection is typed, as for connecting
+func (m *Model) confirmConnectionAction(action components.ConnectionAction, conn config.SSHConnection) tea.Cmd {
+	if conn.DangerLevel == config.DangerProduction {
+		return m.connectionList.ConfirmProduction(conn, action)
+	}
+	return m.openConnectionAction(action, conn)
+}
+
+// openConnectionAction switches to the view of action for conn
+func (m *Model) openConnectionAction(action components.ConnectionAction, conn config.SSHConnection) tea.Cmd {
+	switch action {
+	case components.ActionSCP:
+		m.scpManager = components.NewSCPManager(conn)
+		m.state = StateSCPFileManager
+		m.connectionList.Reset()
+
+		// Send initial size to SCP manager
+		initCmd := m.scpManager.Init()
+		contentHeight := max(m.height-headerHeight-footerHeight, 12)
+		_, sizeCmd := m.scpManager.Update(tea.WindowSizeMsg{Width: m.width, Height: contentHeight})
+		return tea.Batch(initCmd, sizeCmd)
+	case components.ActionProcesses:
+		m.connectionList.Reset()
+		return m.openProcessManager(conn)
+	case components.ActionServices:
+		m.connectionList.Reset()
+		return m.openSystemdBrowser(conn)
+	case components.ActionPorts:
+		m.connectionList.Reset()
+		return m.openPortChecker(conn)
+	case components.ActionDeployKey:
+		m.keyDeployPicker = components.NewKeyDeployPicker(m.fullConnection(conn))
+		m.keyDeployPicker.SetSize(m.width, m.height)
+		m.state = StateKeyDeploy
+	}
+	return nil
+}
+
+// openPortChecker switches to the remote port checker for conn
+func (m *Model) openPortChecker(conn config.SSHConnection) tea.Cmd {
+	m.portChecker = components.NewPortChecker(conn)
+	m.portChecker.SetSize(m.width, max(m.height-headerHeight-footerHeight, 12))
+	m.state = StatePortChecker
+	return m.portChecker.Init()
+}
+
+// fullConnection returns conn with the secrets the list doesn't carry.
+// Instances of a host range keep their own name and host.
+func (m *Model) fullConnection(conn config.SSHConnection) config.SSHConnection {
+	if m.storageBackend == nil {
+		return conn
+	}
+	full, ok := m.storageBackend.GetConnection(conn.ID)
+	if !ok {
+		return conn
+	}
+	if conn.HostRange != "" {
+		full.Name, full.Host, full.HostRange = conn.Name, conn.Host, conn.HostRange
+	}
+	return full
+}
+
+// openSystemdBrowser switches to the remote systemd service browser for conn
+func (m *Model) openSystemdBrowser(conn config.SSHConnection) tea.Cmd {
+	// Unit actions may need the sudo password, which only the full connection carries
+	if m.storageBackend != nil && conn.SudoPassword == "" {
+		if fullConn, ok := m.storageBackend.GetConnection(conn.ID); ok {
+			conn.SudoPassword = fullConn.SudoPassword
+		}
+	}
+	m.systemdBrowser = components.NewSystemdBrowser(conn)
+	m.systemdBrowser.SetSize(m.width, max(m.height-headerHeight-footerHeight, 12))
+	m.state = StateSystemdBrowser
+	return m.systemdBrowser.Init()
+}
+
+// refuseViewOnly reports, with an error message, whether some of conns
+// are view-only, or the storage read-only, and so can't be changed from
+// the list
+func (m *Model) refuseViewOnly(conns ...config.SSHConnection) bool {
+	if m.refuseReadOnly() {
+		return true
+	}
+	var names []string
+	for _, conn := range conns {
+		if conn.ViewOnly {
+			names = append(names, conn.Name)
+		}
+	}
+	if len(names) == 0 {
+		return false
+	}
+	m.errorMessage = "View-only connections can't be changed here: " + strings.Join(names, ", ")
+	return true
+}
+
+// refuseReadOnly reports, with an error message, whether the storage in
+// use is read-only
+func (m *Model) refuseReadOnly() bool {
+	if m.storageBackend == nil || !m.storageBackend.Capabilities().ReadOnly {
+		return false
+	}
+	m.errorMessage = "This storage is read-only"
+	return true
+}
+
 // State reset helpers
 func (m *Model) resetConnectionState() {
 	if m.connectionList != nil {
 		m.connectionList.Reset()
 	}
-	switch m.storageSelect.SelectedBackend() {
-	case components.StorageLocal:
+	// Backends browsed by collection go back to where it was picked
+	if m.storageBackend == nil || !m.storageBackend.Capabilities().Collections {
 		m.state = StateSelectStorage
 		m.storageSelect = components.NewStorageSelect()
 		m.storageSelect.SetSize(m.width, m.height)
-	case components.StorageBitwarden:
-		if m.bitwardenCollectionList != nil {
-			m.bitwardenCollectionList.Reset()
-		}
-		if m.bitwardenCollectionList == nil {
-			if m.bitwardenOrganizationList != nil {
-				m.bitwardenOrganizationList.Reset()
-			}
-			m.bitwardenManager.SetPersonalVault(false)
-			m.state = StateOrganizationSelect
-		} else if m.bitwardenManager.IsPersonalVault() {
-			if m.bitwardenOrganizationList != nil {
-				m.bitwardenOrganizationList.Reset()
-			}
-			m.bitwardenManager.SetPersonalVault(false)
-			m.state = StateOrganizationSelect
-		} else {
-			m.state = StateCollectionSelect
+		return
+	}
+	if m.bitwardenCollectionList != nil {
+		m.bitwardenCollectionList.Reset()
+	}
+	if m.bitwardenManager.IsPersonalVault() && m.bitwardenFolderList != nil {
+		m.bitwardenFolderList.Reset()
+		m.state = StateFolderSelect
+	} else if m.bitwardenCollectionList == nil || m.bitwardenManager.IsPersonalVault() {
+		if m.bitwardenOrganizationList != nil {
+			m.bitwardenOrganizationList.Reset()
 		}
+		m.bitwardenManager.SetPersonalVault(false)
+		m.state = StateOrganizationSelect
+	} else {
+		m.state = StateCollectionSelect
 	}
 }
 
@@ -612,6 +1483,31 @@ func (m *Model) resetCollectionState() {
 	m.state = StateOrganizationSelect
 }
 
+// offerFolders lets the connection form put the connection in a folder when
+// browsing the personal Bitwarden vault. New connections start in the
+// folder being browsed.
+func (m *Model) offerFolders(editing bool) {
+	bw, ok := m.storageBackend.(*config.BitwardenManager)
+	if !ok || !bw.IsPersonalVault() {
+		return
+	}
+	folderID := m.connectionForm.Connection().FolderID
+	if folder := bw.GetSelectedFolder(); folder != nil && !editing {
+		folderID = folder.ID
+	}
+	m.connectionForm.SetFolders(bw.ListFolders(), folderID)
+}
+
+func (m *Model) resetFolderState() {
+	m.bitwardenFolderList = nil
+	m.bitwardenManager.SetSelectedFolder(nil)
+	m.bitwardenManager.SetPersonalVault(false)
+	if m.bitwardenOrganizationList != nil {
+		m.bitwardenOrganizationList.Reset()
+	}
+	m.state = StateOrganizationSelect
+}
+
 func (m *Model) resetOrganizationState() {
 	if m.bitwardenOrganizationList != nil {
 		m.bitwardenOrganizationList.Reset()
@@ -0,0 +1,83 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ui/components"
+)
+
+// storageOpener starts opening a backend chosen on the storage selection
+// screen: it asks for what the backend needs, or loads its connections
+type storageOpener func(m *Model) tea.Cmd
+
+// storageOpeners open the backends registered with
+// config.RegisterStorageBackend, by name
+var storageOpeners = map[string]storageOpener{}
+
+// registerStorageOpener sets how the backend named name is opened
+func registerStorageOpener(name string, open storageOpener) {
+	storageOpeners[name] = open
+}
+
+func init() {
+	registerStorageOpener("local", (*Model).openLocalStorage)
+	registerStorageOpener("bitwarden", (*Model).openBitwardenStorage)
+	registerStorageOpener("vault", (*Model).openVaultStorage)
+	registerStorageOpener("keepassxc", (*Model).openKeePassXCStorage)
+}
+
+// openStorage opens the backend chosen on the storage selection screen
+func (m *Model) openStorage(name string) tea.Cmd {
+	open, ok := storageOpeners[name]
+	if !ok {
+		m.errorMessage = "No way to open the " + name + " storage"
+		m.storageSelect = components.NewStorageSelect()
+		m.storageSelect.SetSize(m.width, m.height)
+		return nil
+	}
+	return open(m)
+}
+
+func (m *Model) openLocalStorage() tea.Cmd {
+	m.loading = true
+	scm, err := config.NewSSHConfigManager()
+	if err != nil {
+		m.showError("Cannot open ~/.ssh/config", err, m.retryStorage)
+		return nil
+	}
+	m.storageBackend = scm
+	m.sshConfigManager = scm
+	return tea.Batch(
+		loadConnectionsCmd(scm),
+		m.spinner.Tick,
+	)
+}
+
+func (m *Model) openBitwardenStorage() tea.Cmd {
+	m.loading = true
+	bwm, err := config.NewBitwardenManager(&config.BitwardenConfig{})
+	if err != nil {
+		m.showError("Cannot start Bitwarden", err, m.retryStorage)
+		return nil
+	}
+	m.bitwardenManager = bwm
+	return tea.Batch(
+		loadBitwardenStatusCmd(bwm),
+		m.spinner.Tick,
+	)
+}
+
+func (m *Model) openVaultStorage() tea.Cmd {
+	m.vaultForm = components.NewVaultConfigForm()
+	m.vaultForm.SetSize(m.width, m.height)
+	m.state = StateVaultConfig
+	return m.vaultForm.Init()
+}
+
+func (m *Model) openKeePassXCStorage() tea.Cmd {
+	m.keePassForm = components.NewKeePassXCConfigForm()
+	m.keePassForm.SetSize(m.width, m.height)
+	m.state = StateKeePassXCConfig
+	return m.keePassForm.Init()
+}
@@ -1,13 +1,17 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"slices"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ssh"
 	"github.com/eugeniofciuvasile/ssh-x-term/internal/ui/components"
 )
 
@@ -25,10 +29,11 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case BitwardenStatusMsg:
 		m.loading = false
 		if msg.Err != nil {
-			m.errorMessage = msg.Err.Error()
 			m.state = StateSelectStorage
-			// Ensure size is set if falling back
-			m.storageSelect.SetSize(m.width, m.height)
+			m.showError("Cannot reach Bitwarden", msg.Err, func() tea.Cmd {
+				m.loading = true
+				return tea.Batch(loadBitwardenStatusCmd(m.bitwardenManager), m.spinner.Tick)
+			})
 			return m, nil
 		}
 		if !msg.LoggedIn {
@@ -51,7 +56,10 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case BitwardenLoadOrganizationsMsg:
 		m.loading = false
 		if msg.Err != nil {
-			m.errorMessage = msg.Err.Error()
+			m.showError("Cannot load the Bitwarden organizations", msg.Err, func() tea.Cmd {
+				m.loading = true
+				return tea.Batch(loadBitwardenOrganizationsCmd(m.bitwardenManager), m.spinner.Tick)
+			})
 			return m, nil
 		}
 		m.bitwardenOrganizationList = components.NewBitwardenOrganizationList(msg.Organizations)
@@ -62,27 +70,176 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case BitwardenLoadCollectionsMsg:
 		m.loading = false
 		if msg.Err != nil {
-			m.errorMessage = msg.Err.Error()
+			var retry func() tea.Cmd
+			if org := m.bitwardenOrganizationList.SelectedOrganization(); org != nil {
+				retry = func() tea.Cmd {
+					m.loading = true
+					return tea.Batch(loadBitwardenCollectionsCmd(m.bitwardenManager, org.ID), m.spinner.Tick)
+				}
+			}
+			m.showError("Cannot load the Bitwarden collections", msg.Err, retry)
 			return m, nil
 		}
 		m.bitwardenCollectionList = components.NewBitwardenCollectionList(msg.Collections)
 		m.bitwardenCollectionList.SetSize(m.width, m.listHeight())
+		if org := m.bitwardenOrganizationList.SelectedOrganization(); org != nil {
+			m.bitwardenCollectionList.SetOrganization(org.Name)
+		}
 		m.state = StateCollectionSelect
 		return m, nil
 
+	case components.SaveCollectionMsg:
+		if org := m.bitwardenOrganizationList.SelectedOrganization(); org != nil {
+			m.loading = true
+			return m, tea.Batch(
+				saveBitwardenCollectionCmd(m.bitwardenManager, org.ID, msg),
+				m.spinner.Tick,
+			)
+		}
+		return m, nil
+
+	case BitwardenCollectionSavedMsg:
+		org := m.bitwardenOrganizationList.SelectedOrganization()
+		if msg.Err != nil || org == nil {
+			m.loading = false
+			if msg.Err != nil {
+				m.showError("Cannot save the collection", msg.Err, nil)
+			}
+			return m, nil
+		}
+		// Reload the collections to show the change
+		return m, loadBitwardenCollectionsCmd(m.bitwardenManager, org.ID)
+
+	case BitwardenLoadFoldersMsg:
+		m.loading = false
+		if msg.Err != nil {
+			m.showError("Cannot load the Bitwarden folders", msg.Err, func() tea.Cmd {
+				m.loading = true
+				return tea.Batch(loadBitwardenFoldersCmd(m.bitwardenManager), m.spinner.Tick)
+			})
+			return m, nil
+		}
+		m.bitwardenManager.SetPersonalVault(true)
+		m.bitwardenFolderList = components.NewBitwardenFolderList(msg.Folders)
+		m.bitwardenFolderList.SetSize(m.width, m.listHeight())
+		m.state = StateFolderSelect
+		return m, nil
+
 	case BitwardenLoadConnectionsByCollectionMsg:
 		m.loading = false
 		if msg.Err != nil {
-			m.errorMessage = msg.Err.Error()
+			var retry func() tea.Cmd
+			if collection := m.bitwardenManager.GetSelectedCollection(); collection != nil {
+				retry = func() tea.Cmd {
+					m.loading = true
+					return tea.Batch(loadBitwardenConnectionsByCollectionCmd(m.bitwardenManager, collection.ID), m.spinner.Tick)
+				}
+			}
+			m.showError("Cannot load the connections of the collection", msg.Err, retry)
 			return m, nil
 		}
-		m.connectionList = components.NewConnectionList(msg.Connections)
+		m.recordRecentCollection()
+		m.connectionList = components.NewConnectionList(m.mergeLoaded(msg.Connections))
 		m.connectionList.SetSize(m.width, m.listHeight())
 		m.state = StateConnectionList
+		if !m.reconcileChecked {
+			m.reconcileChecked = true
+			if path := config.FindDeclarativeFile(); path != "" {
+				return m, tea.Batch(planReconcileCmd(path, msg.Connections), m.scheduleBitwardenSync(), m.autoMirrorBitwarden())
+			}
+		}
+		return m, tea.Batch(m.scheduleBitwardenSync(), m.autoMirrorBitwarden())
+
+	case ReconcilePlanMsg:
+		if msg.Err != nil && m.state == StateConnectionList && !m.loading {
+			m.showError("Cannot read the declarative file "+msg.Path, msg.Err, func() tea.Cmd {
+				return planReconcileCmd(msg.Path, m.storageBackend.ListConnections())
+			})
+			return m, nil
+		}
+		if msg.Err != nil {
+			m.errorMessage = fmt.Sprintf("Declarative file: %s", msg.Err)
+			return m, nil
+		}
+		if msg.Plan.IsEmpty() || m.state != StateConnectionList {
+			return m, nil
+		}
+		m.reconcileConfirm = components.NewReconcileConfirmation(msg.Path, msg.Plan)
+		m.reconcileConfirm.SetSize(m.width, m.height)
+		m.state = StateReconcile
 		return m, nil
 
+	case ImportResultMsg:
+		if msg.Err != nil {
+			m.errorMessage = fmt.Sprintf("Imported %d connections, some failed: %s", msg.Added, msg.Err)
+		} else {
+			m.errorMessage = fmt.Sprintf("Imported %d connections", msg.Added)
+		}
+		return m, tea.Batch(
+			loadConnectionsCmd(m.storageBackend),
+			m.spinner.Tick,
+		)
+
+	case BulkEditResultMsg:
+		if msg.Err != nil {
+			m.errorMessage = fmt.Sprintf("Updated %d connections, some failed: %s", msg.Updated, msg.Err)
+		} else {
+			m.errorMessage = fmt.Sprintf("Updated %d connections", msg.Updated)
+		}
+		return m, tea.Batch(
+			loadConnectionsCmd(m.storageBackend),
+			m.spinner.Tick,
+		)
+
+	case ChallengeMsg:
+		// Show the server's prompts over whatever is waiting for the connection
+		req := msg.Request
+		m.pendingChallenge = req
+		m.challengeReturnState = m.state
+		m.challengeWasLoading = m.loading
+		m.loading = false
+		m.challengeForm = components.NewChallengeForm(req.conn, req.name, req.instruction, req.questions, req.echos)
+		m.challengeForm.SetSize(m.width, m.height)
+		m.state = StateChallenge
+		return m, m.challengeForm.Init()
+
+	case ExportResultMsg:
+		if msg.Err != nil {
+			m.errorMessage = fmt.Sprintf("Export failed: %s", msg.Err)
+		} else {
+			m.errorMessage = fmt.Sprintf("Exported %d connections to %s", msg.Count, msg.Path)
+		}
+		return m, nil
+
+	case MoshSessionEndedMsg:
+		if msg.Err != nil {
+			m.errorMessage = fmt.Sprintf("Session failed: %s", msg.Err)
+		}
+		return m, nil
+
+	case ReconcileResultMsg:
+		m.state = StateConnectionList
+		m.loading = true // spinner continues while reloading connections
+		if msg.Err != nil {
+			m.errorMessage = fmt.Sprintf("Failed to apply declarative file: %s", msg.Err)
+		}
+		return m, tea.Batch(
+			loadConnectionsCmd(m.storageBackend),
+			m.spinner.Tick,
+		)
+
 	case BitwardenLoginResultMsg:
 		m.loading = false
+		if (!msg.Success || msg.Err != nil) && m.bitwardenLoginForm == nil {
+			// API key and SSO logins are started from the config form
+			m.bitwardenForm.ErrorMsg = "Login failed"
+			if msg.Err != nil {
+				m.bitwardenForm.ErrorMsg = msg.Err.Error()
+			}
+			m.formHasError = true
+			m.bitwardenForm.ResetSubmitted()
+			return m, nil
+		}
 		if !msg.Success || msg.Err != nil {
 			if msg.Err != nil {
 				m.bitwardenLoginForm.SetError(msg.Err.Error())
@@ -96,26 +253,102 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		m.formHasError = false
+		m.bitwardenLoginForm = nil
+		if !m.bitwardenManager.IsUnlocked() {
+			// API key and SSO logins leave the vault locked
+			m.bitwardenForm = nil
+			m.bitwardenUnlockForm = components.NewBitwardenUnlockForm()
+			m.bitwardenUnlockForm.SetSize(m.width, m.height)
+			m.state = StateBitwardenUnlock
+			return m, nil
+		}
 		m.storageBackend = m.bitwardenManager
 		m.loading = true
-		m.bitwardenLoginForm = nil
 		return m, tea.Batch(
 			loadBitwardenOrganizationsCmd(m.bitwardenManager),
 			m.spinner.Tick,
 		)
 
+	case BitwardenLockedMsg:
+		m.loading = false
+		if msg.Err != nil {
+			m.errorMessage = msg.Err.Error()
+		}
+		// The vault needs the master password again, so start over
+		m.bitwardenManager = nil
+		m.bitwardenOrganizationList = nil
+		m.bitwardenCollectionList = nil
+		m.bitwardenFolderList = nil
+		m.storageBackend = nil
+		m.connectionList = nil
+		m.state = StateSelectStorage
+		m.storageSelect = components.NewStorageSelect()
+		m.storageSelect.SetSize(m.width, m.height)
+		return m, m.storageSelect.Init()
+
+	case BitwardenSyncTickMsg:
+		m.bitwardenSyncScheduled = false
+		bw, ok := m.storageBackend.(*config.BitwardenManager)
+		if !ok {
+			// Locked or switched to another backend since
+			return m, nil
+		}
+		if m.state != StateConnectionList || m.loading || m.connectionList == nil {
+			// Don't reload underneath a form or session, try again later
+			return m, m.scheduleBitwardenSync()
+		}
+		return m, syncBitwardenCmd(bw)
+
+	case BitwardenSyncedMsg:
+		// A failed background sync is logged, the next tick tries again
+		if msg.Err == nil && msg.Changed > 0 && m.storageBackend != nil && m.connectionList != nil {
+			m.connectionList.SetConnections(m.storageBackend.ListConnections())
+			if msg.Changed == 1 {
+				m.errorMessage = "1 item updated in Bitwarden"
+			} else {
+				m.errorMessage = fmt.Sprintf("%d items updated in Bitwarden", msg.Changed)
+			}
+			return m, tea.Batch(m.scheduleBitwardenSync(), m.autoMirrorBitwarden())
+		}
+		return m, m.scheduleBitwardenSync()
+
+	case BitwardenMirroredMsg:
+		if !msg.Auto {
+			m.loading = false
+		}
+		if msg.Err != nil && !msg.Auto && m.state == StateConnectionList {
+			m.showError("Cannot mirror the vault to ~/.ssh/config", msg.Err, func() tea.Cmd {
+				bw, ok := m.storageBackend.(*config.BitwardenManager)
+				if !ok {
+					return nil
+				}
+				m.loading = true
+				return tea.Batch(mirrorBitwardenCmd(bw, false), m.spinner.Tick)
+			})
+			return m, nil
+		}
+		if msg.Err != nil {
+			log.Printf("Failed to mirror Bitwarden to ssh_config: %v", msg.Err)
+			m.errorMessage = fmt.Sprintf("Failed to mirror to ~/.ssh/config: %v", msg.Err)
+			return m, nil
+		}
+		if !msg.Auto {
+			m.errorMessage = fmt.Sprintf("Mirrored %d connections to %s", msg.Count, msg.Path)
+		}
+		return m, nil
+
 	case BitwardenUnlockResultMsg:
 		m.loading = false
 		if !msg.Success || msg.Err != nil {
+			// A fresh form clears the wrong password but keeps the error
+			m.bitwardenUnlockForm = components.NewBitwardenUnlockForm()
+			m.bitwardenUnlockForm.SetSize(m.width, m.height)
 			if msg.Err != nil {
 				m.bitwardenUnlockForm.SetError(msg.Err.Error())
 			} else {
 				m.bitwardenUnlockForm.SetError("Unlock failed")
 			}
 			m.formHasError = true
-			m.bitwardenUnlockForm.ResetSubmitted()
-			m.bitwardenUnlockForm = components.NewBitwardenUnlockForm()
-			m.bitwardenUnlockForm.SetSize(m.width, m.height)
 			return m, nil
 		}
 		m.formHasError = false
@@ -127,12 +360,90 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.spinner.Tick,
 		)
 
+	case VaultLoginResultMsg:
+		m.loading = false
+		if msg.Err != nil {
+			m.vaultForm.SetError(msg.Err.Error())
+			m.vaultForm.ResetSubmitted()
+			return m, nil
+		}
+		m.vaultForm = nil
+		m.storageBackend = m.vaultManager
+		m.loading = true
+		return m, tea.Batch(
+			loadConnectionsCmd(m.vaultManager),
+			m.spinner.Tick,
+		)
+
+	case KeePassXCOpenResultMsg:
+		m.loading = false
+		if msg.Err != nil {
+			m.keePassForm.SetError(msg.Err.Error())
+			m.keePassForm.ResetSubmitted()
+			return m, nil
+		}
+		m.keePassForm = nil
+		m.storageBackend = m.keePassManager
+		m.loading = true
+		return m, tea.Batch(
+			loadConnectionsCmd(m.keePassManager),
+			m.spinner.Tick,
+		)
+
 	case SaveConnectionResultMsg:
+		var conflict *config.EditConflictError
+		if errors.As(msg.Err, &conflict) && m.connectionForm != nil {
+			// Someone else changed the item, ask before clobbering it
+			m.loading = false
+			m.editConflict = components.NewEditConflictDialog(conflict)
+			m.editConflict.SetSize(m.width, m.height)
+			m.state = StateEditConflict
+			return m, nil
+		}
 		m.connectionForm = nil
 		m.state = StateConnectionList
 		m.loading = true // spinner continues while reloading connections
 		if msg.Err != nil {
 			m.errorMessage = fmt.Sprintf("Failed to save connection: %s", msg.Err)
+			m.pendingDeploy = nil
+		}
+		if m.pendingDeploy != nil && m.pendingDeploy.knownIDs != nil {
+			if id, ok := storedID(m.storageBackend, m.pendingDeploy.conn, m.pendingDeploy.knownIDs); ok {
+				m.pendingDeploy.conn.ID = id
+			} else {
+				m.errorMessage = "Saved the connection but could not find it to install the key"
+				m.pendingDeploy = nil
+			}
+		}
+		if m.pendingDeploy != nil {
+			// Use the first password login to install the generated key
+			return m, deployKeyCmd(m.storageBackend, *m.pendingDeploy)
+		}
+		return m, tea.Batch(
+			loadConnectionsCmd(m.storageBackend),
+			m.spinner.Tick,
+		)
+
+	case KeyInstallResultMsg:
+		var passwordErr *ssh.PasswordRequiredError
+		var passphraseErr *ssh.PassphraseRequiredError
+		if m.pendingDeploy != nil && (errors.As(msg.Err, &passwordErr) || errors.As(msg.Err, &passphraseErr)) {
+			// Ask for the missing credential, then retry the deployment
+			m.loading = false
+			m.sshPassphraseForm = components.NewSSHPassphraseForm(msg.Connection)
+			m.sshPassphraseForm.SetSize(m.width, m.height)
+			if passphraseErr != nil {
+				m.sshPassphraseForm.AskForKey(passphraseErr.KeyFile)
+			}
+			m.pendingAction = "deploykey"
+			m.state = StateSSHPassphrase
+			return m, nil
+		}
+		m.pendingDeploy = nil
+		if msg.Err != nil {
+			m.errorMessage = fmt.Sprintf("Failed to install key on %s: %s", msg.Connection.Name, msg.Err)
+		} else {
+			m.errorMessage = fmt.Sprintf("Key installed on %s, now using key authentication", msg.Connection.Name)
 		}
 		return m, tea.Batch(
 			loadConnectionsCmd(m.storageBackend),
@@ -150,6 +461,35 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.spinner.Tick,
 		)
 
+	case ConnectionRecordedMsg:
+		// Usage stats only affect the order, so a failure isn't worth
+		// interrupting the session for
+		if msg.Err != nil {
+			log.Printf("Failed to record connection: %v", msg.Err)
+			return m, nil
+		}
+		if m.storageBackend != nil && m.connectionList != nil {
+			m.connectionList.SetConnections(m.storageBackend.ListConnections())
+		}
+		return m, nil
+
+	case components.SCPBookmarksMsg:
+		if m.storageBackend == nil || msg.ConnectionID == "" {
+			return m, nil
+		}
+		return m, saveBookmarksCmd(m.storageBackend, msg.ConnectionID, msg.Bookmarks)
+
+	case BookmarksSavedMsg:
+		if msg.Err != nil {
+			log.Printf("Failed to save bookmarks: %v", msg.Err)
+			m.errorMessage = fmt.Sprintf("Failed to save bookmarks: %s", msg.Err)
+			return m, nil
+		}
+		if m.storageBackend != nil && m.connectionList != nil {
+			m.connectionList.SetConnections(m.storageBackend.ListConnections())
+		}
+		return m, nil
+
 	case components.DeleteConnectionMsg:
 		// User confirmed deletion - delete the connection
 		if m.storageBackend != nil {
@@ -174,6 +514,22 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case components.ProductionConfirmedMsg:
+		return m, m.openConnectionAction(msg.Action, msg.Connection)
+
+	case components.ForwardsChangedMsg:
+		if m.refuseViewOnly(msg.Connection) {
+			return m, nil
+		}
+		if m.storageBackend != nil {
+			if err := m.storageBackend.EditConnection(msg.Connection); err != nil {
+				m.errorMessage = fmt.Sprintf("Failed to save the new forward ports: %s", err)
+				return m, nil
+			}
+			m.connectionList.SetConnections(m.storageBackend.ListConnections())
+		}
+		return m, nil
+
 	case components.TogglePinnedMsg:
 		if m.storageBackend != nil {
 			msg.Connection.Pinned = !msg.Connection.Pinned
@@ -270,11 +626,11 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.storageBackend != nil {
 			conns := m.storageBackend.ListConnections()
 			currentSorted := m.connectionList.Connections
+			// Instances of a host range share the ID, move from the last one
 			idx := -1
 			for i, c := range currentSorted {
 				if c.ID == msg.Connection.ID {
 					idx = i
-					break
 				}
 			}
 
@@ -328,18 +684,25 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case LoadConnectionsFinishedMsg:
 		m.loading = false // finally stop the spinner here
 		if msg.Err != nil {
-			m.errorMessage = msg.Err.Error()
+			backend := m.storageBackend
+			m.showError("Cannot load the connections", msg.Err, func() tea.Cmd {
+				m.loading = true
+				return tea.Batch(loadConnectionsCmd(backend), m.spinner.Tick)
+			})
 			return m, nil
 		}
-		m.connectionList = components.NewConnectionList(msg.Connections)
+		m.connectionList = components.NewConnectionList(m.mergeLoaded(msg.Connections))
 		m.connectionList.SetSize(m.width, m.listHeight())
 		m.state = StateConnectionList
-		return m, nil
+		return m, tea.Batch(m.scheduleBitwardenSync(), m.autoMirrorBitwarden())
 
 	case components.SSHPassphraseRequiredMsg:
 		// SSH key requires passphrase - show the passphrase form
 		m.sshPassphraseForm = components.NewSSHPassphraseForm(msg.Connection)
 		m.sshPassphraseForm.SetSize(m.width, m.height)
+		if msg.KeyFile != "" {
+			m.sshPassphraseForm.AskForKey(msg.KeyFile)
+		}
 
 		switch m.state {
 		case StateSSHTerminal:
@@ -348,6 +711,15 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case StateSCPFileManager:
 			m.pendingAction = "scp"
 			m.scpManager = nil // Clean up the SCP manager that couldn't connect
+		case StateProcessManager:
+			m.pendingAction = "processes"
+			m.processManager = nil
+		case StateSystemdBrowser:
+			m.pendingAction = "systemd"
+			m.systemdBrowser = nil
+		case StatePortChecker:
+			m.pendingAction = "ports"
+			m.portChecker = nil
 		}
 
 		m.state = StateSSHPassphrase
@@ -365,6 +737,15 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case StateSCPFileManager:
 			m.pendingAction = "scp"
 			m.scpManager = nil // Clean up the SCP manager that couldn't connect
+		case StateProcessManager:
+			m.pendingAction = "processes"
+			m.processManager = nil
+		case StateSystemdBrowser:
+			m.pendingAction = "systemd"
+			m.systemdBrowser = nil
+		case StatePortChecker:
+			m.pendingAction = "ports"
+			m.portChecker = nil
 		}
 
 		m.state = StateSSHPassphrase
@@ -384,7 +765,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if activeComponent := m.getActiveComponent(); activeComponent != nil {
 			// For terminal and SCP manager states, we need to calculate the actual content area
 			// since they need to know the exact dimensions they have to work with
-			if m.state == StateSSHTerminal || m.state == StateSCPFileManager {
+			if m.state == StateSSHTerminal || m.state == StateSCPFileManager || m.state == StateProcessManager || m.state == StateSystemdBrowser || m.state == StatePortChecker || m.state == StateKeyManager || m.state == StateCommandRunner {
 				// The component gets the full content area between header and footer
 				contentHeight := max(m.height-headerHeight-footerHeight,
 					// Minimum viable height
@@ -409,6 +790,19 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		if m.showHelp {
+			// Any key closes the help overlay
+			m.showHelp = false
+			if key.Matches(msg, quitBinding) {
+				return m, tea.Quit
+			}
+			return m, nil
+		}
+		if m.state != StateSSHTerminal && (msg.String() == "f1" || (msg.String() == "?" && m.helpKeyAvailable())) {
+			m.showHelp = true
+			return m, nil
+		}
+
 		if m.formHasError {
 			if key.Matches(msg, key.NewBinding(key.WithKeys("esc"))) {
 				switch m.state {
@@ -432,11 +826,13 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch m.state {
 		case StateConnectionList:
 			if m.connectionList != nil {
-				// If delete confirmation, password modal or rename modal is showing, pass ALL keys to connectionList
-				if m.connectionList.IsShowingDeleteConfirm() || m.connectionList.IsShowingPasswordModal() || m.connectionList.IsShowingRenameModal() {
+				// If delete confirmation, password modal, rename modal or host details are showing, pass ALL keys to connectionList
+				if m.connectionList.IsShowingDeleteConfirm() || m.connectionList.IsShowingPasswordModal() || m.connectionList.IsShowingRenameModal() ||
+					m.connectionList.IsShowingHostDetails() || m.connectionList.IsShowingConnectConfirm() ||
+					m.connectionList.IsShowingPortConflict() {
 					model, cmd := m.connectionList.Update(msg)
-					m.connectionList = model.(*components.ConnectionList)
-					return m, cmd
+					// Typing the host name of a production host connects
+					return m, tea.Batch(cmd, m.handleConnectionList(model))
 				}
 
 				listModel := m.connectionList.List()
@@ -446,7 +842,10 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, cmd
 				}
 				switch {
-				case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
+				case key.Matches(msg, connectionListKeys.Back) && m.connectionList.MarkedCount() > 0:
+					m.connectionList.ClearMarks()
+					return m, nil
+				case key.Matches(msg, connectionListKeys.Back):
 					m.resetConnectionState()
 					if m.state == StateSelectStorage {
 						m.storageSelect = components.NewStorageSelect()
@@ -454,30 +853,86 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						return m, m.storageSelect.Init()
 					}
 					return m, nil
-				case key.Matches(msg, key.NewBinding(key.WithKeys("ctrl+c"))):
+				case key.Matches(msg, quitBinding):
 					return m, tea.Quit
-				case msg.String() == "a":
+				case key.Matches(msg, connectionListKeys.Add):
+					if m.refuseReadOnly() {
+						return m, nil
+					}
 					m.connectionForm = components.NewConnectionForm(nil)
 					m.connectionForm.SetSize(m.width, m.height)
+					m.offerFolders(false)
 					m.state = StateAddConnection
 					return m, m.connectionForm.Init()
-				case msg.String() == "e":
-					if selectedItem := m.connectionList.HighlightedConnection(); selectedItem != nil {
-						m.connectionForm = components.NewConnectionForm(selectedItem)
+				case key.Matches(msg, connectionListKeys.Edit):
+					if selectedItem := m.connectionList.HighlightedConnection(); selectedItem != nil && !m.refuseViewOnly(*selectedItem) {
+						// Instances of a host range edit their template
+						stored := m.connectionList.StoredConnection(*selectedItem)
+						m.connectionForm = components.NewConnectionForm(&stored)
 						m.connectionForm.SetSize(m.width, m.height)
+						m.offerFolders(true)
 						m.state = StateEditConnection
 						return m, m.connectionForm.Init()
 					}
-				case msg.String() == "r":
+				case key.Matches(msg, connectionListKeys.Mark):
+					m.connectionList.ToggleMarked()
+					return m, nil
+				case key.Matches(msg, connectionListKeys.BulkEdit):
+					// Edit the selected connections, or the highlighted one
+					conns := m.connectionList.MarkedConnections()
+					if len(conns) == 0 {
+						if conn := m.connectionList.HighlightedConnection(); conn != nil {
+							conns = []config.SSHConnection{m.connectionList.StoredConnection(*conn)}
+						}
+					}
+					if len(conns) > 0 && !m.refuseViewOnly(conns...) {
+						m.bulkEditForm = components.NewBulkEditForm(conns)
+						m.bulkEditForm.SetSize(m.width, m.height)
+						m.state = StateBulkEdit
+						return m, m.bulkEditForm.Init()
+					}
+				case key.Matches(msg, connectionListKeys.Duplicate):
+					if selectedItem := m.connectionList.HighlightedConnection(); selectedItem != nil && !m.refuseReadOnly() {
+						// Copy the secrets as well, the list only has them masked
+						conn := m.fullConnection(*selectedItem)
+						m.connectionForm = components.NewConnectionFormFrom(config.DuplicateConnection(conn))
+						m.connectionForm.SetSize(m.width, m.height)
+						m.offerFolders(true)
+						m.state = StateAddConnection
+						return m, m.connectionForm.Init()
+					}
+				case key.Matches(msg, connectionListKeys.Rename):
 					// Rename connection
-					m.connectionList.ShowRename()
+					if conn := m.connectionList.HighlightedConnection(); conn != nil && !m.refuseViewOnly(*conn) {
+						m.connectionList.ShowRename()
+					}
 					return m, nil
-				case msg.String() == "d" || msg.String() == "D":
+				case key.Matches(msg, connectionListKeys.Lock):
+					if m.bitwardenManager != nil && m.storageBackend == m.bitwardenManager {
+						m.loading = true
+						return m, tea.Batch(lockBitwardenCmd(m.bitwardenManager), m.spinner.Tick)
+					}
+				case key.Matches(msg, connectionListKeys.Collection):
+					if m.bitwardenManager != nil && m.storageBackend == m.bitwardenManager {
+						return m, m.openCollectionPicker()
+					}
+				case key.Matches(msg, connectionListKeys.Mirror):
+					if m.bitwardenManager != nil && m.storageBackend == m.bitwardenManager {
+						m.loading = true
+						return m, tea.Batch(mirrorBitwardenCmd(m.bitwardenManager, false), m.spinner.Tick)
+					}
+				case key.Matches(msg, connectionListKeys.Details):
+					m.connectionList.ShowHostDetails()
+					return m, nil
+				case key.Matches(msg, connectionListKeys.Delete):
+					if conn := m.connectionList.HighlightedConnection(); conn != nil && m.refuseViewOnly(*conn) {
+						return m, nil
+					}
 					// Pass to connectionList for delete confirmation handling
 					model, cmd := m.connectionList.Update(msg)
 					m.connectionList = model.(*components.ConnectionList)
 					return m, cmd
-				case msg.String() == "p" || msg.String() == "P":
+				case key.Matches(msg, connectionListKeys.Password):
 					// Show password modal for highlighted connection
 					if conn := m.connectionList.HighlightedConnection(); conn != nil {
 						// Fetch full connection with password
@@ -487,16 +942,53 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 							return m, nil
 						}
 					}
-				case msg.String() == "f":
+				case key.Matches(msg, connectionListKeys.Pin):
 					// Pin/Unpin connection
 					return m, m.connectionList.TogglePinned()
-				case msg.String() == "K":
-					// Move connection up
-					return m, m.connectionList.MoveUp()
-				case msg.String() == "J":
-					// Move connection down
+				case key.Matches(msg, connectionListKeys.MoveUp, connectionListKeys.MoveDown):
+					// Moving only changes the manual order
+					if m.connectionList.SortMode() != "manual" {
+						m.errorMessage = "Press S to switch to the manual order before moving connections"
+						return m, nil
+					}
+					if key.Matches(msg, connectionListKeys.MoveUp) {
+						return m, m.connectionList.MoveUp()
+					}
 					return m, m.connectionList.MoveDown()
-				case msg.String() == "c" || msg.String() == "C":
+				case key.Matches(msg, connectionListKeys.Placement):
+					// Cycle where multiplexer sessions open and remember it
+					settings := config.CurrentSettings()
+					i := slices.Index(config.MultiplexerPlacements, settings.MultiplexerPlacement)
+					settings.MultiplexerPlacement = config.MultiplexerPlacements[(i+1)%len(config.MultiplexerPlacements)]
+					config.SetCurrentSettings(settings)
+					if err := settings.Save(); err != nil {
+						m.errorMessage = fmt.Sprintf("Failed to save multiplexer placement: %s", err)
+					}
+					return m, nil
+				case key.Matches(msg, connectionListKeys.Sort):
+					// Cycle manual, recent and frequent order and remember it
+					settings := config.CurrentSettings()
+					settings.ConnectionSort = m.connectionList.CycleSortMode()
+					config.SetCurrentSettings(settings)
+					if err := settings.Save(); err != nil {
+						m.errorMessage = fmt.Sprintf("Failed to save connection order: %s", err)
+					}
+					return m, nil
+				case key.Matches(msg, connectionListKeys.Purge):
+					// Forget the scrollback kept from earlier sessions
+					if conn := m.connectionList.HighlightedConnection(); conn != nil {
+						store, err := config.NewScrollbackStore(0)
+						if err == nil {
+							err = store.Purge(conn.ID)
+						}
+						if err != nil {
+							m.errorMessage = fmt.Sprintf("Failed to purge saved scrollback: %s", err)
+						} else {
+							m.errorMessage = fmt.Sprintf("Saved scrollback of %s purged", conn.Name)
+						}
+						return m, nil
+					}
+				case key.Matches(msg, connectionListKeys.Copy):
 					// Copy password directly or show modal if multiple
 					if conn := m.connectionList.HighlightedConnection(); conn != nil {
 						fullConn, ok := m.storageBackend.GetConnection(conn.ID)
@@ -534,24 +1026,94 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 							}
 						}
 					}
-				case msg.String() == "s":
+				case key.Matches(msg, connectionListKeys.SCP):
 					// Open SCP file manager
 					if selectedItem := m.connectionList.HighlightedConnection(); selectedItem != nil {
-						m.scpManager = components.NewSCPManager(*selectedItem)
-						m.state = StateSCPFileManager
-						m.connectionList.Reset()
-
-						// Send initial size to SCP manager
-						initCmd := m.scpManager.Init()
-						contentHeight := max(m.height-headerHeight-footerHeight, 12)
-						sizeMsg := tea.WindowSizeMsg{
-							Width:  m.width,
-							Height: contentHeight,
+						return m, m.confirmConnectionAction(components.ActionSCP, *selectedItem)
+					}
+				case key.Matches(msg, connectionListKeys.Processes):
+					// Open remote process manager
+					if selectedItem := m.connectionList.HighlightedConnection(); selectedItem != nil {
+						return m, m.confirmConnectionAction(components.ActionProcesses, *selectedItem)
+					}
+				case key.Matches(msg, connectionListKeys.Services):
+					// Open remote systemd service browser
+					if selectedItem := m.connectionList.HighlightedConnection(); selectedItem != nil {
+						return m, m.confirmConnectionAction(components.ActionServices, *selectedItem)
+					}
+				case key.Matches(msg, connectionListKeys.Ports):
+					// Check remote ports
+					if selectedItem := m.connectionList.HighlightedConnection(); selectedItem != nil {
+						return m, m.confirmConnectionAction(components.ActionPorts, *selectedItem)
+					}
+				case key.Matches(msg, connectionListKeys.Run):
+					// Run a command on one or more hosts
+					m.commandRunner = components.NewCommandRunner(m.storageBackend.ListConnections(), m.connectionList.HighlightedConnection())
+					m.commandRunner.SetSize(m.width, max(m.height-headerHeight-footerHeight, 12))
+					m.state = StateCommandRunner
+					m.connectionList.Reset()
+					return m, m.commandRunner.Init()
+				case key.Matches(msg, connectionListKeys.Merge):
+					return m, m.startMerge()
+				case key.Matches(msg, connectionListKeys.Quick):
+					// Connect to a host that isn't saved
+					m.quickConnectForm = components.NewQuickConnectForm()
+					m.quickConnectForm.SetSize(m.width, m.height)
+					m.state = StateQuickConnect
+					m.connectionList.Reset()
+					return m, m.quickConnectForm.Init()
+				case key.Matches(msg, connectionListKeys.Local):
+					// Open the local shell in the built-in terminal
+					m.connectionList.Reset()
+					return m, m.startLocalShell()
+				case key.Matches(msg, connectionListKeys.Discover):
+					// Browse the local network for SSH servers
+					m.discovery = components.NewDiscoveryView()
+					m.discovery.SetSize(m.width, m.height)
+					m.state = StateDiscovery
+					m.connectionList.Reset()
+					return m, m.discovery.Init()
+				case key.Matches(msg, connectionListKeys.Settings):
+					// Edit the global settings
+					m.settingsForm = components.NewSettingsForm(config.CurrentSettings())
+					m.settingsForm.SetSize(m.width, m.height)
+					m.state = StateSettings
+					m.connectionList.Reset()
+					return m, m.settingsForm.Init()
+				case key.Matches(msg, connectionListKeys.DeployKey):
+					// Deploy a public key to the host (ssh-copy-id)
+					if conn := m.connectionList.HighlightedConnection(); conn != nil {
+						if m.refuseViewOnly(*conn) {
+							return m, nil
 						}
-						_, sizeCmd := m.scpManager.Update(sizeMsg)
-						return m, tea.Batch(initCmd, sizeCmd)
+						return m, m.confirmConnectionAction(components.ActionDeployKey, *conn)
 					}
-				case msg.String() == "o":
+				case key.Matches(msg, connectionListKeys.Import):
+					// Import sessions from PuTTY, WinSCP or Termius
+					m.importWizard = components.NewImportWizard(m.storageBackend.ListConnections())
+					m.importWizard.SetSize(m.width, m.height)
+					m.state = StateImport
+					return m, nil
+				case key.Matches(msg, connectionListKeys.CloudImport):
+					// Import or connect to running cloud instances
+					m.cloudImport = components.NewCloudImport(m.storageBackend.ListConnections())
+					m.cloudImport.SetSize(m.width, m.height)
+					m.state = StateCloudImport
+					return m, nil
+				case key.Matches(msg, connectionListKeys.Export):
+					// Export all or the highlighted connection
+					m.exportForm = components.NewExportForm(m.connectionList.HighlightedConnection(), len(m.storageBackend.ListConnections()))
+					m.exportForm.SetSize(m.width, m.height)
+					m.state = StateExport
+					return m, nil
+				case key.Matches(msg, connectionListKeys.Keys):
+					// Open local SSH key manager
+					m.keyManager = components.NewKeyManager()
+					m.keyManager.SetSize(m.width, max(m.height-headerHeight-footerHeight, 12))
+					m.state = StateKeyManager
+					m.connectionList.Reset()
+					return m, m.keyManager.Init()
+				case key.Matches(msg, connectionListKeys.NewTerminal):
 					if m.connectionList != nil {
 						m.connectionList.ToggleOpenInNewTerminal()
 						return m, nil
@@ -566,10 +1128,26 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					*listModel = newList
 					return m, cmd
 				}
+				if m.bitwardenCollectionList.IsShowingCollectionModal() {
+					break
+				}
 				switch {
 				case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
 					m.resetCollectionState()
 					return m, nil
+				case key.Matches(msg, key.NewBinding(key.WithKeys("n", "r"))):
+					org := m.bitwardenOrganizationList.SelectedOrganization()
+					if org == nil {
+						return m, nil
+					}
+					if !org.CanManageCollections() {
+						m.errorMessage = fmt.Sprintf("Your role in %s cannot manage collections", org.Name)
+						return m, nil
+					}
+					if msg.String() == "n" {
+						return m, m.bitwardenCollectionList.ShowCreate()
+					}
+					return m, m.bitwardenCollectionList.ShowRename()
 				}
 			}
 		case StateOrganizationSelect:
@@ -586,7 +1164,25 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.storageSelect.SetSize(m.width, m.height)
 					return m, m.storageSelect.Init()
 				case key.Matches(msg, key.NewBinding(key.WithKeys("o"))):
-					return m, m.loadPersonalVaultConnections()
+					m.loading = true
+					return m, tea.Batch(
+						loadBitwardenFoldersCmd(m.bitwardenManager),
+						m.spinner.Tick,
+					)
+				}
+			}
+		case StateFolderSelect:
+			if m.bitwardenFolderList != nil {
+				listModel := m.bitwardenFolderList.List()
+				if listModel != nil && listModel.FilterState() == list.Filtering {
+					newList, cmd := listModel.Update(msg)
+					*listModel = newList
+					return m, cmd
+				}
+				switch {
+				case key.Matches(msg, key.NewBinding(key.WithKeys("esc"))):
+					m.resetFolderState()
+					return m, nil
 				}
 			}
 		}
@@ -4,23 +4,23 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/config"
+	"github.com/eugeniofciuvasile/ssh-x-term/internal/ui/components"
 )
 
+// Colors are set by applyTheme
 var (
 	// Header style - always at top of screen
 	headerStyle = lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("170")).
-			Background(lipgloss.Color("235")).
 			Align(lipgloss.Center).
 			Padding(0, 2).
 			Width(0) // Will be set dynamically
 
 	// Footer style - always at bottom of screen
 	footerStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("241")).
-			Background(lipgloss.Color("235")).
 			Align(lipgloss.Center).
 			Padding(0, 2).
 			Width(0) // Will be set dynamically
@@ -32,11 +32,20 @@ var (
 
 	errorStyle = lipgloss.NewStyle().
 			Bold(true).
-			Foreground(lipgloss.Color("9")).
 			Align(lipgloss.Center).
 			Padding(0, 2)
 )
 
+// applyTheme switches the components and the header and footer bars to the
+// named theme
+func applyTheme(name string) {
+	components.ApplyTheme(name)
+	theme := components.CurrentTheme()
+	headerStyle = headerStyle.Foreground(theme.HeaderText).Background(theme.Bar)
+	footerStyle = footerStyle.Foreground(theme.Muted).Background(theme.Bar)
+	errorStyle = errorStyle.Foreground(theme.Error)
+}
+
 // View renders the UI model with full-screen layout
 func (m *Model) View() string {
 	// Calculate available space
@@ -67,13 +76,25 @@ func (m *Model) View() string {
 		title = "Select Organization"
 	case StateCollectionSelect:
 		title = "Select Collection"
+	case StateFolderSelect:
+		title = "Select Folder"
 	case StateConnectionList:
 		if m.connectionList != nil {
 			checkboxStr := "( )"
 			if m.connectionList.OpenInNewTerminal() {
 				checkboxStr = "(✓)"
 			}
-			title = fmt.Sprintf("SSH Connections - Open in New Terminal %s", checkboxStr)
+			target := "New Terminal"
+			if config.ActiveMultiplexer != "" {
+				target = config.ActiveMultiplexer + " " + config.CurrentSettings().MultiplexerPlacement
+			}
+			title = fmt.Sprintf("SSH Connections - Open in %s %s", target, checkboxStr)
+			if crumb := m.bitwardenBreadcrumb(); crumb != "" {
+				title = crumb + " - " + title
+			}
+			if n := m.connectionList.MarkedCount(); n > 0 {
+				title += fmt.Sprintf(" - %d selected (B to edit, esc to clear)", n)
+			}
 		} else {
 			title = "SSH Connections"
 		}
@@ -83,10 +104,53 @@ func (m *Model) View() string {
 		title = "Edit Connection"
 	case StateSSHTerminal:
 		title = "Terminal Session"
+		if m.terminal != nil && m.terminal.IsLocal() {
+			title = "Local Shell"
+		}
 	case StateSCPFileManager:
 		title = "SCP File Manager"
 	case StateSSHPassphrase:
 		title = "SSH Authentication Required"
+	case StateVaultConfig:
+		title = "HashiCorp Vault Configuration"
+	case StateKeePassXCConfig:
+		title = "KeePassXC Configuration"
+	case StateProcessManager:
+		title = "Remote Processes"
+	case StateSystemdBrowser:
+		title = "Systemd Services"
+	case StatePortChecker:
+		title = "Port Check"
+	case StateCommandRunner:
+		title = "Run Command"
+	case StateSettings:
+		title = "Settings"
+	case StateQuickConnect:
+		title = "Quick Connect"
+	case StateReconcile:
+		title = "Declarative Connections"
+	case StateEditConflict:
+		title = "Edit Conflict"
+	case StateKeyManager:
+		title = "SSH Key Manager"
+	case StateKeyDeploy:
+		title = "Deploy Public Key"
+	case StateImport:
+		title = "Import Connections"
+	case StateExport:
+		title = "Export Connections"
+	case StateChallenge:
+		title = "Server Authentication"
+	case StateBulkEdit:
+		title = "Bulk Edit"
+	case StateCloudImport:
+		title = "Import from Cloud"
+	case StateDiscovery:
+		title = "Discover LAN Hosts"
+	case StateError:
+		title = "Error"
+	case StateCollectionPicker:
+		title = "Switch Collection"
 	}
 
 	// Note: We removed the spinner from the header here
@@ -136,7 +200,7 @@ func (m *Model) View() string {
 
 		// For specific states, ensure content fills the space manually
 		// (Lipgloss styles inside the component usually handle this, but this is a safety net)
-		if m.state == StateSSHTerminal || m.state == StateSCPFileManager {
+		if m.state == StateSSHTerminal || m.state == StateSCPFileManager || m.state == StateProcessManager || m.state == StateSystemdBrowser || m.state == StatePortChecker || m.state == StateKeyManager || m.state == StateCommandRunner {
 			content = lipgloss.NewStyle().
 				Height(contentHeight).
 				Width(m.width).
@@ -144,6 +208,10 @@ func (m *Model) View() string {
 		}
 	}
 
+	if m.showHelp {
+		content = m.helpOverlay(title, contentHeight)
+	}
+
 	// Render footer with help text
 	footerText := m.getHelpText()
 	footer := footerStyle.Render(footerText)
@@ -152,45 +220,47 @@ func (m *Model) View() string {
 	return lipgloss.JoinVertical(lipgloss.Left, header, content, footer)
 }
 
-// getHelpText returns context-appropriate help text
+// getHelpText returns the footer hints for the current screen
 func (m *Model) getHelpText() string {
 	if m.loading && m.state != StateSSHTerminal && m.state != StateSCPFileManager {
 		return "Please wait... (ctrl+c to cancel)"
 	}
-
-	switch m.state {
-	case StateConnectionList:
-		return "a: add | e: edit | d: delete | f: pin | K/J: move | r: rename | p: pass | c: copy | s: scp | / filter | o: toggle new terminal | enter: connect | ctrl+c: quit"
-	case StateSSHTerminal:
-		if m.terminal != nil {
-			if m.terminal.IsSessionClosed() {
-				return "Session closed - Press ESC to return"
-			}
-			if m.terminal.GetWidth() < 80 || m.width < 80 {
-				return "ESC: Exit | CTRL+D: EOF | Scroll: PgUp/PgDn"
-			}
-			return "ESC: Exit | CTRL+D: EOF | PgUp/PgDn: Scroll Vertically | Tab: Complete Command | Mouse: Copy Text"
-		}
-		return "esc: disconnect"
-	case StateSCPFileManager:
-		return "↑/↓: navigate | enter: open | backspace: parent | tab: switch | g: get | u: upload | d: delete | n: create | r: rename | c: cd | /: search | esc: exit"
-	case StateSelectStorage:
-		return "↑/↓: navigate | enter: select | ctrl+c: quit"
-	case StateBitwardenConfig:
-		return "tab: next field | enter: confirm | esc: back"
-	case StateBitwardenLogin:
-		return "tab: next field | enter: login | esc: back"
-	case StateBitwardenUnlock:
-		return "enter: unlock | esc: back"
-	case StateOrganizationSelect:
-		return "↑/↓: navigate | o: personal vault | enter: select | esc: back"
-	case StateCollectionSelect:
-		return "↑/↓: navigate | enter: select | esc: back"
-	case StateAddConnection, StateEditConnection:
-		return "tab: next field | ctrl+p: toggle auth | enter: save | esc: cancel"
-	case StateSSHPassphrase:
-		return "enter: submit | esc: cancel"
-	default:
-		return "ctrl+c: quit"
+	if m.showHelp {
+		return "press any key to close"
 	}
+	// Hints that don't fit are cut off; the overlay lists all of them
+	m.help.Width = max(m.width-4, 0)
+	return m.help.ShortHelpView(m.keyMap().ShortHelp())
+}
+
+// helpOverlay renders every key of the current screen in a centered box
+func (m *Model) helpOverlay(title string, height int) string {
+	theme := components.CurrentTheme()
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(theme.Primary).
+		Padding(1, 3).
+		Render(lipgloss.JoinVertical(lipgloss.Left,
+			lipgloss.NewStyle().Bold(true).Foreground(theme.Primary).Render("Keys: "+title),
+			"",
+			m.help.FullHelpView(m.keyMap().FullHelp()),
+		))
+	return lipgloss.Place(m.width, height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// newHelpModel creates the footer and overlay help in the current theme.
+// Footer hints are left unstyled so the footer bar's colors apply.
+func newHelpModel() help.Model {
+	theme := components.CurrentTheme()
+	h := help.New()
+	h.ShortSeparator = " | "
+	h.Styles.ShortKey = lipgloss.NewStyle()
+	h.Styles.ShortDesc = lipgloss.NewStyle()
+	h.Styles.ShortSeparator = lipgloss.NewStyle()
+	h.Styles.Ellipsis = lipgloss.NewStyle()
+	h.Styles.FullKey = lipgloss.NewStyle().Foreground(theme.Primary).Bold(true)
+	h.Styles.FullDesc = lipgloss.NewStyle().Foreground(theme.SubText)
+	h.Styles.FullSeparator = lipgloss.NewStyle()
+	h.FullSeparator = "    "
+	return h
 }
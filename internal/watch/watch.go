@@ -0,0 +1,194 @@
+// Package watch reports the files created, changed or removed under a
+// directory tree. It polls their sizes and modification times, which
+// behaves the same on every platform and filesystem, network mounts
+// included, and needs no file descriptor per directory.
+package watch
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultInterval is how often the tree is scanned
+const DefaultInterval = time.Second
+
+// Change is a file created, modified or removed under the watched root
+type Change struct {
+	Path    string // Relative to the root, with / separators
+	Removed bool
+}
+
+// Watcher scans a directory tree at an interval and reports its changes
+type Watcher struct {
+	root     string
+	ignore   []string
+	interval time.Duration
+	last     map[string]fileState
+	changes  chan []Change
+	done     chan struct{}
+}
+
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// New starts watching root, skipping the files and directories whose name
+// or relative path matches one of the ignore patterns. Files already there
+// are not reported.
+func New(root string, ignore []string, interval time.Duration) (*Watcher, error) {
+	w := &Watcher{
+		root:     root,
+		ignore:   ignore,
+		interval: interval,
+		changes:  make(chan []Change, 1),
+		done:     make(chan struct{}),
+	}
+	last, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+	w.last = last
+	go w.run()
+	return w, nil
+}
+
+// Root returns the watched directory
+func (w *Watcher) Root() string {
+	return w.root
+}
+
+// Changes delivers the changes found by each scan that found some. It is
+// closed by Close, or when the root can no longer be read.
+func (w *Watcher) Changes() <-chan []Change {
+	return w.changes
+}
+
+// Close stops watching
+func (w *Watcher) Close() {
+	select {
+	case <-w.done:
+	default:
+		close(w.done)
+	}
+}
+
+func (w *Watcher) run() {
+	defer close(w.changes)
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	// Files changed by the last scan are reported once a scan finds them
+	// unchanged, so a file being written is not sent half done
+	unsettled := make(map[string]bool)
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+		current, err := w.scan()
+		if err != nil {
+			return
+		}
+
+		var changes []Change
+		changed := make(map[string]bool)
+		for p, st := range current {
+			if prev, ok := w.last[p]; !ok || prev != st {
+				changed[p] = true
+			}
+		}
+		for p := range unsettled {
+			if _, ok := current[p]; ok && !changed[p] {
+				changes = append(changes, Change{Path: p})
+			}
+		}
+		for p := range w.last {
+			if _, ok := current[p]; !ok {
+				changes = append(changes, Change{Path: p, Removed: true})
+			}
+		}
+		w.last, unsettled = current, changed
+
+		if len(changes) == 0 {
+			continue
+		}
+		select {
+		case w.changes <- changes:
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// scan records the size and modification time of every file under the
+// root that is not ignored
+func (w *Watcher) scan() (map[string]fileState, error) {
+	files := make(map[string]fileState)
+	err := filepath.WalkDir(w.root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// A file removed during the walk is seen as removed next time
+			if p != w.root && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if p == w.root {
+			return nil
+		}
+		rel, _ := filepath.Rel(w.root, p)
+		rel = filepath.ToSlash(rel)
+		if Ignored(rel, w.ignore) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files[rel] = fileState{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	return files, err
+}
+
+// Ignored reports whether the relative path rel, or one of the directories
+// it is in, matches one of patterns. Patterns without a / match a single
+// name, like ".git" or "*.swp"; the others match the whole path.
+func Ignored(rel string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.Contains(pattern, "/") {
+			if ok, _ := path.Match(strings.Trim(pattern, "/"), rel); ok {
+				return true
+			}
+			continue
+		}
+		for _, name := range strings.Split(rel, "/") {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ParsePatterns splits a comma-separated list of ignore patterns
+func ParsePatterns(list string) []string {
+	var patterns []string
+	for _, p := range strings.Split(list, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
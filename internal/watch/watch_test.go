@@ -0,0 +1,84 @@
+package watch
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestIgnored(t *testing.T) {
+	patterns := ParsePatterns(".git, node_modules,*.swp , build/out")
+	if !reflect.DeepEqual(patterns, []string{".git", "node_modules", "*.swp", "build/out"}) {
+		t.Fatalf("ParsePatterns = %q", patterns)
+	}
+	for rel, want := range map[string]bool{
+		".git":                     true,
+		".git/HEAD":                true,
+		"web/node_modules/x/y.js":  true,
+		"src/.main.go.swp":         true,
+		"build/out":                true,
+		"build/output.txt":         false,
+		"src/main.go":              false,
+		"docs/git.md":              false,
+		"node_modules_backup/a.js": false,
+	} {
+		if got := Ignored(rel, patterns); got != want {
+			t.Errorf("Ignored(%q) = %t, want %t", rel, got, want)
+		}
+	}
+}
+
+func TestWatcherReportsSettledChanges(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("keep.txt", "a")
+	write("gone.txt", "b")
+
+	w, err := New(root, []string{".git"}, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	write("src/new.go", "package main")
+	write(".git/index", "ignored")
+	os.Remove(filepath.Join(root, "gone.txt"))
+
+	var got []Change
+	deadline := time.After(5 * time.Second)
+	for len(got) < 2 {
+		select {
+		case changes := <-w.Changes():
+			got = append(got, changes...)
+		case <-deadline:
+			t.Fatalf("Timed out, got %+v", got)
+		}
+	}
+	sort.Slice(got, func(i, j int) bool { return got[i].Path < got[j].Path })
+	want := []Change{{Path: "gone.txt", Removed: true}, {Path: "src/new.go"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Changes = %+v, want %+v", got, want)
+	}
+
+	w.Close()
+	select {
+	case _, ok := <-w.Changes():
+		for ok {
+			_, ok = <-w.Changes()
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Close to close Changes")
+	}
+}
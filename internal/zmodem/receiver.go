@@ -0,0 +1,339 @@
+package zmodem
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTimeout is how long the receiver waits for the sender before
+// asking again
+const defaultTimeout = 10 * time.Second
+
+// maxRetries is how many times in a row the receiver asks again before
+// giving up on a silent sender
+const maxRetries = 4
+
+// errTimeout is a read that waited longer than the receiver's timeout
+var errTimeout = errors.New("timed out waiting for the sender")
+
+// Progress is how far the file being received is
+type Progress struct {
+	Name     string
+	Received int64
+	Size     int64 // 0 when the sender didn't tell
+}
+
+// Receiver receives the files a sender offers into Dir. The sender's
+// output is passed in with Feed, and the replies are written to the
+// writer given to NewReceiver.
+type Receiver struct {
+	Dir     string
+	Timeout time.Duration
+
+	out      io.Writer
+	progress chan Progress
+
+	mu     sync.Mutex
+	buf    []byte
+	ready  chan struct{}
+	closed bool
+}
+
+// NewReceiver creates a receiver saving files into dir and replying to
+// the sender through out
+func NewReceiver(dir string, out io.Writer) *Receiver {
+	return &Receiver{
+		Dir:      dir,
+		Timeout:  defaultTimeout,
+		out:      out,
+		progress: make(chan Progress, 1),
+		ready:    make(chan struct{}, 1),
+	}
+}
+
+// Feed passes output of the sender to the receiver. It never blocks.
+func (r *Receiver) Feed(data []byte) {
+	r.mu.Lock()
+	r.buf = append(r.buf, data...)
+	r.mu.Unlock()
+	select {
+	case r.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Cancel stops the transfer, making Run abort it
+func (r *Receiver) Cancel() {
+	r.mu.Lock()
+	r.closed = true
+	r.mu.Unlock()
+	select {
+	case r.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Rest returns the output fed after the end of the transfer, such as the
+// shell prompt, once Run has returned
+func (r *Receiver) Rest() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rest := r.buf
+	r.buf = nil
+	return rest
+}
+
+// Progress delivers the progress of the file being received, skipping
+// updates nobody took in time. It is closed when Run returns.
+func (r *Receiver) Progress() <-chan Progress {
+	return r.progress
+}
+
+// ReadByte reads the next byte of the sender's output, waiting up to the
+// timeout for it
+func (r *Receiver) ReadByte() (byte, error) {
+	deadline := time.NewTimer(r.Timeout)
+	defer deadline.Stop()
+	for {
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			return 0, ErrCancelled
+		}
+		if len(r.buf) > 0 {
+			b := r.buf[0]
+			r.buf = r.buf[1:]
+			r.mu.Unlock()
+			return b, nil
+		}
+		r.mu.Unlock()
+		select {
+		case <-r.ready:
+		case <-deadline.C:
+			return 0, errTimeout
+		}
+	}
+}
+
+// report sends the progress unless the last update is still unread
+func (r *Receiver) report(p Progress) {
+	select {
+	case r.progress <- p:
+	default:
+	}
+}
+
+// Run receives files until the sender is done, returning the paths they
+// were saved at. On failure the file being received is removed and the
+// sender is told to stop.
+func (r *Receiver) Run() (files []string, err error) {
+	defer close(r.progress)
+	d := &decoder{r: r}
+	var file *os.File
+	var current Progress
+	var pos int64
+	defer func() {
+		if err != nil {
+			r.out.Write(Abort)
+			if file != nil {
+				file.Close()
+				os.Remove(file.Name())
+			}
+		}
+	}()
+
+	ask := header{kind: zrinit, args: [4]byte{0, 0, 0, canfdx | canovio | canfc32}}
+	if err := writeHeader(r.out, ask); err != nil {
+		return files, err
+	}
+	retries := 0
+	for {
+		h, err := d.readHeader()
+		if err != nil {
+			if !errors.Is(err, errTimeout) && !errors.Is(err, errCorrupt) {
+				return files, err
+			}
+			if retries++; retries > maxRetries {
+				return files, err
+			}
+			// Ask again for what was expected
+			if file != nil {
+				ask = positionHeader(zrpos, pos)
+			}
+			if err := writeHeader(r.out, ask); err != nil {
+				return files, err
+			}
+			continue
+		}
+		retries = 0
+
+		switch h.kind {
+		case zrqinit:
+			err = writeHeader(r.out, ask)
+
+		case zsinit:
+			// The attention string is for interrupting the sender, which
+			// this receiver never needs
+			if _, _, err = d.readSubpacket(h.crc32); err == nil {
+				err = writeHeader(r.out, header{kind: zack})
+			}
+
+		case zfile:
+			var info []byte
+			if info, _, err = d.readSubpacket(h.crc32); err != nil {
+				break
+			}
+			name, size := parseFileInfo(info)
+			if file != nil {
+				// The sender gave up on the last file
+				file.Close()
+				os.Remove(file.Name())
+			}
+			if file, err = createUnique(r.Dir, name); err != nil {
+				return files, err
+			}
+			current = Progress{Name: filepath.Base(file.Name()), Size: size}
+			pos = 0
+			r.report(current)
+			ask = positionHeader(zrpos, 0)
+			err = writeHeader(r.out, ask)
+
+		case zdata:
+			if file == nil {
+				err = writeHeader(r.out, ask)
+				break
+			}
+			if h.position() != pos {
+				// Data we didn't ask for: ask again for where we are
+				err = writeHeader(r.out, positionHeader(zrpos, pos))
+				break
+			}
+			err = r.receiveData(d, h, file, &pos, current)
+
+		case zeof:
+			if file == nil || h.position() != pos {
+				break
+			}
+			if err = file.Close(); err != nil {
+				return files, err
+			}
+			files = append(files, file.Name())
+			file = nil
+			ask = header{kind: zrinit, args: [4]byte{0, 0, 0, canfdx | canovio | canfc32}}
+			err = writeHeader(r.out, ask)
+
+		case zfin:
+			writeHeader(r.out, header{kind: zfin})
+			// The sender ends with "OO", which isn't the shell's output
+			for _, want := range []byte("OO") {
+				b, err := d.readByte()
+				if err != nil {
+					break
+				}
+				if b != want {
+					d.unread = append(d.unread, b)
+					break
+				}
+			}
+			r.keep(d.unread)
+			return files, nil
+
+		case zcan, zabort:
+			return files, ErrCancelled
+		}
+
+		if err != nil {
+			if errors.Is(err, errCorrupt) || errors.Is(err, errTimeout) {
+				// Ask for the data again from where it went wrong
+				err = writeHeader(r.out, positionHeader(zrpos, pos))
+			}
+			if err != nil {
+				return files, err
+			}
+		}
+	}
+}
+
+// receiveData writes the subpackets after a ZDATA header to file until
+// the frame ends
+func (r *Receiver) receiveData(d *decoder, h header, file *os.File, pos *int64, current Progress) error {
+	for {
+		data, end, err := d.readSubpacket(h.crc32)
+		if err != nil {
+			return err
+		}
+		if _, err := file.Write(data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.Name(), err)
+		}
+		*pos += int64(len(data))
+		current.Received = *pos
+		r.report(current)
+
+		switch end {
+		case zcrcw:
+			return writeHeader(r.out, positionHeader(zack, *pos))
+		case zcrcq:
+			if err := writeHeader(r.out, positionHeader(zack, *pos)); err != nil {
+				return err
+			}
+		case zcrce:
+			return nil
+		}
+	}
+}
+
+// keep puts bytes the decoder read ahead back in front of the output
+// left for Rest
+func (r *Receiver) keep(unread []byte) {
+	if len(unread) == 0 {
+		return
+	}
+	reversed := make([]byte, len(unread))
+	for i, b := range unread {
+		reversed[len(unread)-1-i] = b
+	}
+	r.mu.Lock()
+	r.buf = append(reversed, r.buf...)
+	r.mu.Unlock()
+}
+
+// parseFileInfo reads the name and size from the ZFILE subpacket:
+// "name\0size mtime mode ...\0"
+func parseFileInfo(info []byte) (string, int64) {
+	name, rest, _ := bytes.Cut(info, []byte{0})
+	var size int64
+	if fields := strings.Fields(string(bytes.TrimRight(rest, "\x00"))); len(fields) > 0 {
+		size, _ = strconv.ParseInt(fields[0], 10, 64)
+	}
+	return string(name), size
+}
+
+// createUnique creates a file in dir named after the base of name,
+// numbering it when the name is taken. The sender can't pick a path
+// outside dir.
+func createUnique(dir, name string) (*os.File, error) {
+	name = filepath.Base(filepath.FromSlash(strings.ReplaceAll(name, "\\", "/")))
+	if name == "." || name == ".." || name == string(filepath.Separator) {
+		name = "zmodem-download"
+	}
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 0; ; i++ {
+		candidate := name
+		if i > 0 {
+			candidate = fmt.Sprintf("%s-%d%s", stem, i, ext)
+		}
+		file, err := os.OpenFile(filepath.Join(dir, candidate), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if !errors.Is(err, os.ErrExist) {
+			return file, err
+		}
+	}
+}
@@ -0,0 +1,382 @@
+// Package zmodem receives the files a remote shell sends with ZMODEM, as
+// `sz` does, over the input and output of a terminal session
+package zmodem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// Framing bytes
+const (
+	zpad   = '*'
+	zdle   = 0x18 // Also CAN, five in a row cancel the transfer
+	zbin   = 'A'
+	zhex   = 'B'
+	zbin32 = 'C'
+	zrub0  = 'l' // Escaped 0x7f
+	zrub1  = 'm' // Escaped 0xff
+
+	// Ends of a data subpacket
+	zcrce = 'h' // End of frame, a header follows
+	zcrcg = 'i' // More data follows
+	zcrcq = 'j' // More data follows, acknowledge
+	zcrcw = 'k' // End of frame, acknowledge
+)
+
+// Frame types
+const (
+	zrqinit = iota
+	zrinit
+	zsinit
+	zack
+	zfile
+	zskip
+	znak
+	zabort
+	zfin
+	zrpos
+	zdata
+	zeof
+	zferr
+	zcrc
+	zchallenge
+	zcompl
+	zcan
+)
+
+// Capabilities a receiver announces in ZRINIT
+const (
+	canfdx  = 0x01 // Full duplex
+	canovio = 0x02 // Receives while writing to disk
+	canfc32 = 0x20 // Checks 32-bit CRCs
+)
+
+// Kind is which side of a transfer the remote started
+type Kind int
+
+const (
+	None    Kind = iota
+	Send         // The remote sends files, as `sz` does
+	Receive      // The remote waits for files, as `rz` does
+)
+
+var (
+	// sendStart is the ZRQINIT header `sz` starts with
+	sendStart = []byte("**\x18B00")
+	// receiveStart is the ZRINIT header `rz` starts with
+	receiveStart = []byte("**\x18B01")
+)
+
+// Abort cancels the transfer on the other side: eight CANs, then
+// backspaces to erase them from the line
+var Abort = []byte("\x18\x18\x18\x18\x18\x18\x18\x18\x08\x08\x08\x08\x08\x08\x08\x08\x08\x08")
+
+// ErrCancelled is returned when either side cancels the transfer
+var ErrCancelled = errors.New("transfer cancelled")
+
+// errCorrupt is a frame damaged on the way, which the receiver recovers
+// from by asking for it again
+var errCorrupt = errors.New("corrupt frame")
+
+// Detect finds where the remote starts a transfer in data, returning its
+// offset and kind, or -1 and None
+func Detect(data []byte) (int, Kind) {
+	send := bytes.Index(data, sendStart)
+	receive := bytes.Index(data, receiveStart)
+	switch {
+	case send >= 0 && (receive < 0 || send < receive):
+		return send, Send
+	case receive >= 0:
+		return receive, Receive
+	}
+	return -1, None
+}
+
+// PartialStart returns how many bytes at the end of data may be the start
+// of a transfer cut off by the end of a read. Only a cut after the ZDLE
+// counts, so a prompt ending in "*" isn't held back.
+func PartialStart(data []byte) int {
+	for n := min(len(sendStart)-1, len(data)); n >= 3; n-- {
+		if bytes.HasPrefix(sendStart, data[len(data)-n:]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// header is a frame header: its type, four argument bytes and whether
+// the data after it is checked with CRC-32
+type header struct {
+	kind  byte
+	args  [4]byte
+	crc32 bool
+}
+
+// position returns the file offset a position header carries
+func (h header) position() int64 {
+	return int64(binary.LittleEndian.Uint32(h.args[:]))
+}
+
+// positionHeader returns a header carrying file offset pos
+func positionHeader(kind byte, pos int64) header {
+	h := header{kind: kind}
+	binary.LittleEndian.PutUint32(h.args[:], uint32(pos))
+	return h
+}
+
+// crc16 is the CRC-16/XMODEM of data
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// hexHeader encodes h as a hex header, the form receivers send
+func hexHeader(h header) []byte {
+	raw := append([]byte{h.kind}, h.args[:]...)
+	raw = binary.BigEndian.AppendUint16(raw, crc16(raw))
+	out := append([]byte{zpad, zpad, zdle, zhex}, hex.EncodeToString(raw)...)
+	out = append(out, '\r', 0x8a)
+	// lrzsz follows all but these with an XON in case flow control is on
+	if h.kind != zfin && h.kind != zack {
+		out = append(out, 0x11)
+	}
+	return out
+}
+
+// decoder reads frames from the sender's output
+type decoder struct {
+	r      byteReader
+	unread []byte
+}
+
+// byteReader reads the next byte of the sender's output
+type byteReader interface {
+	ReadByte() (byte, error)
+}
+
+// frameEnd marks a ZDLE sequence ending a subpacket in what readZDLE
+// returns, above any byte value
+const frameEnd = 0x100
+
+func (d *decoder) readByte() (byte, error) {
+	if n := len(d.unread); n > 0 {
+		b := d.unread[n-1]
+		d.unread = d.unread[:n-1]
+		return b, nil
+	}
+	return d.r.ReadByte()
+}
+
+// readZDLE reads a byte undoing ZDLE escaping, or frameEnd|type for the
+// end of a subpacket. Unescaped XON and XOFF are flow control noise.
+func (d *decoder) readZDLE() (int, error) {
+	for {
+		b, err := d.readByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case 0x11, 0x13, 0x91, 0x93:
+			continue
+		case zdle:
+		default:
+			return int(b), nil
+		}
+
+		cans := 1
+		for {
+			b, err = d.readByte()
+			if err != nil {
+				return 0, err
+			}
+			if b != zdle {
+				break
+			}
+			if cans++; cans == 5 {
+				return 0, ErrCancelled
+			}
+		}
+		switch {
+		case b >= zcrce && b <= zcrcw:
+			return frameEnd | int(b), nil
+		case b == zrub0:
+			return 0x7f, nil
+		case b == zrub1:
+			return 0xff, nil
+		case b&0x60 == 0x40:
+			return int(b ^ 0x40), nil
+		case b == 0x11 || b == 0x13 || b == 0x91 || b == 0x93:
+			continue
+		}
+		return 0, fmt.Errorf("%w: bad escape %#x", errCorrupt, b)
+	}
+}
+
+// readHeader skips to the next header and decodes it
+func (d *decoder) readHeader() (header, error) {
+	cans := 0
+	for {
+		b, err := d.readByte()
+		if err != nil {
+			return header{}, err
+		}
+		if b == zdle {
+			if cans++; cans == 5 {
+				return header{}, ErrCancelled
+			}
+			continue
+		}
+		cans = 0
+		if b != zpad {
+			continue
+		}
+		for b == zpad {
+			if b, err = d.readByte(); err != nil {
+				return header{}, err
+			}
+		}
+		if b != zdle {
+			continue
+		}
+		if b, err = d.readByte(); err != nil {
+			return header{}, err
+		}
+		switch b {
+		case zhex:
+			return d.readHexHeader()
+		case zbin:
+			return d.readBinaryHeader(false)
+		case zbin32:
+			return d.readBinaryHeader(true)
+		}
+	}
+}
+
+func (d *decoder) readHexHeader() (header, error) {
+	digits := make([]byte, 14)
+	for i := range digits {
+		b, err := d.readByte()
+		if err != nil {
+			return header{}, err
+		}
+		digits[i] = b
+	}
+	raw := make([]byte, 7)
+	if _, err := hex.Decode(raw, digits); err != nil {
+		return header{}, fmt.Errorf("%w: bad hex header", errCorrupt)
+	}
+	if crc16(raw[:5]) != binary.BigEndian.Uint16(raw[5:]) {
+		return header{}, fmt.Errorf("%w: bad header CRC", errCorrupt)
+	}
+	// Drop the CR and LF after it, leaving anything else for the next read
+	for range 2 {
+		b, err := d.readByte()
+		if err != nil {
+			break
+		}
+		if b&0x7f != '\r' && b&0x7f != '\n' {
+			d.unread = append(d.unread, b)
+			break
+		}
+	}
+	h := header{kind: raw[0]}
+	copy(h.args[:], raw[1:5])
+	return h, nil
+}
+
+func (d *decoder) readBinaryHeader(wide bool) (header, error) {
+	n := 7
+	if wide {
+		n = 9
+	}
+	raw := make([]byte, n)
+	for i := range raw {
+		c, err := d.readZDLE()
+		if err != nil {
+			return header{}, err
+		}
+		if c&frameEnd != 0 {
+			return header{}, fmt.Errorf("%w: bad binary header", errCorrupt)
+		}
+		raw[i] = byte(c)
+	}
+	if wide {
+		if crc32.ChecksumIEEE(raw[:5]) != binary.LittleEndian.Uint32(raw[5:]) {
+			return header{}, fmt.Errorf("%w: bad header CRC", errCorrupt)
+		}
+	} else if crc16(raw[:5]) != binary.BigEndian.Uint16(raw[5:]) {
+		return header{}, fmt.Errorf("%w: bad header CRC", errCorrupt)
+	}
+	h := header{kind: raw[0], crc32: wide}
+	copy(h.args[:], raw[1:5])
+	return h, nil
+}
+
+// maxSubpacket bounds a data subpacket; senders use at most 8 KB
+const maxSubpacket = 16 * 1024
+
+// errBadSubpacket is a subpacket that failed its CRC check
+var errBadSubpacket = fmt.Errorf("%w: bad data CRC", errCorrupt)
+
+// readSubpacket reads a data subpacket and how it ends
+func (d *decoder) readSubpacket(wide bool) ([]byte, byte, error) {
+	var data []byte
+	for {
+		c, err := d.readZDLE()
+		if err != nil {
+			return nil, 0, err
+		}
+		if c&frameEnd == 0 {
+			if len(data) == maxSubpacket {
+				return nil, 0, errBadSubpacket
+			}
+			data = append(data, byte(c))
+			continue
+		}
+
+		end := byte(c)
+		n := 2
+		if wide {
+			n = 4
+		}
+		sum := make([]byte, n)
+		for i := range sum {
+			c, err := d.readZDLE()
+			if err != nil {
+				return nil, 0, err
+			}
+			sum[i] = byte(c)
+		}
+		checked := append(data, end)
+		if wide {
+			if crc32.ChecksumIEEE(checked) != binary.LittleEndian.Uint32(sum) {
+				return nil, 0, errBadSubpacket
+			}
+		} else if crc16(checked) != binary.BigEndian.Uint16(sum) {
+			return nil, 0, errBadSubpacket
+		}
+		return data, end, nil
+	}
+}
+
+// writeHeader sends h to the sender
+func writeHeader(w io.Writer, h header) error {
+	_, err := w.Write(hexHeader(h))
+	return err
+}
@@ -0,0 +1,134 @@
+package zmodem
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// escape ZDLE-escapes data the way sz does
+func escape(data []byte) []byte {
+	var out []byte
+	for _, b := range data {
+		switch b {
+		case zdle, 0x10, 0x11, 0x13, 0x90, 0x91, 0x93:
+			out = append(out, zdle, b^0x40)
+		default:
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// binary32Header encodes h as sz does once the receiver checks CRC-32
+func binary32Header(h header) []byte {
+	raw := append([]byte{h.kind}, h.args[:]...)
+	raw = binary.LittleEndian.AppendUint32(raw, crc32.ChecksumIEEE(raw))
+	return append([]byte{zpad, zdle, zbin32}, escape(raw)...)
+}
+
+// subpacket32 encodes data as a subpacket ending with end
+func subpacket32(data []byte, end byte) []byte {
+	sum := crc32.ChecksumIEEE(append(append([]byte{}, data...), end))
+	out := append(escape(data), zdle, end)
+	return append(out, escape(binary.LittleEndian.AppendUint32(nil, sum))...)
+}
+
+func TestDetect(t *testing.T) {
+	at, kind := Detect([]byte("$ sz notes.txt\r\n**\x18B00000000000000\r\x8a\x11"))
+	if at != 16 || kind != Send {
+		t.Errorf("Expected sz at 16, got %d %v", at, kind)
+	}
+	if _, kind := Detect([]byte("rz waiting to receive.**\x18B0100000023be50\r\x8a\x11")); kind != Receive {
+		t.Errorf("Expected rz, got %v", kind)
+	}
+	if at, kind := Detect([]byte("**bold** text")); at != -1 || kind != None {
+		t.Errorf("Expected no transfer, got %d %v", at, kind)
+	}
+	if n := PartialStart([]byte("$ sz notes.txt\r\n**\x18B")); n != 4 {
+		t.Errorf("Expected the 4 bytes of a cut start to be held back, got %d", n)
+	}
+	if n := PartialStart([]byte("prompt **")); n != 0 {
+		t.Errorf("Expected stars alone not to be held back, got %d", n)
+	}
+}
+
+func TestReceiver(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("taken"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("hello \x18\x11 world")
+
+	var stream []byte
+	stream = append(stream, "**\x18B00000000000000\r\x8a\x11"...)
+	stream = append(stream, binary32Header(header{kind: zfile})...)
+	stream = append(stream, subpacket32([]byte("../notes.txt\x0015 14000000000 100644 0 1 15\x00"), zcrcw)...)
+	stream = append(stream, binary32Header(positionHeader(zdata, 0))...)
+	stream = append(stream, subpacket32(content[:6], zcrcg)...)
+	stream = append(stream, subpacket32(content[6:], zcrce)...)
+	stream = append(stream, binary32Header(positionHeader(zeof, int64(len(content))))...)
+	stream = append(stream, hexHeader(header{kind: zfin})...)
+	stream = append(stream, "OO$ "...)
+
+	var replies bytes.Buffer
+	r := NewReceiver(dir, &replies)
+	r.Timeout = time.Second
+	r.Feed(stream)
+	files, err := r.Run()
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	// The name is confined to dir and doesn't overwrite the file there
+	want := filepath.Join(dir, "notes-1.txt")
+	if len(files) != 1 || files[0] != want {
+		t.Fatalf("Expected %s, got %v", want, files)
+	}
+	if got, _ := os.ReadFile(want); !bytes.Equal(got, content) {
+		t.Errorf("Expected %q, got %q", content, got)
+	}
+	if rest := string(r.Rest()); rest != "$ " {
+		t.Errorf("Expected the prompt after the transfer to be left, got %q", rest)
+	}
+
+	sent := replies.Bytes()
+	if !bytes.HasPrefix(sent, hexHeader(header{kind: zrinit, args: [4]byte{0, 0, 0, canfdx | canovio | canfc32}})) {
+		t.Errorf("Expected the receiver to start with ZRINIT, got %q", sent)
+	}
+	if !bytes.Contains(sent, hexHeader(positionHeader(zrpos, 0))) || !bytes.HasSuffix(sent, hexHeader(header{kind: zfin})) {
+		t.Errorf("Expected ZRPOS and a closing ZFIN, got %q", sent)
+	}
+}
+
+func TestReceiverCancel(t *testing.T) {
+	dir := t.TempDir()
+	var replies bytes.Buffer
+	r := NewReceiver(dir, &replies)
+	r.Feed([]byte("**\x18B00000000000000\r\x8a\x11"))
+	r.Feed(binary32Header(header{kind: zfile}))
+	r.Feed(subpacket32([]byte("big.bin\x00100\x00"), zcrcw))
+	r.Feed(binary32Header(positionHeader(zdata, 0)))
+	r.Feed(subpacket32([]byte("partial"), zcrcg))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := r.Run()
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	r.Cancel()
+	if err := <-done; err != ErrCancelled {
+		t.Fatalf("Expected the transfer to be cancelled, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected the partial file to be removed, found %d files", len(entries))
+	}
+	if !bytes.HasSuffix(replies.Bytes(), Abort) {
+		t.Error("Expected the sender to be told to stop")
+	}
+}
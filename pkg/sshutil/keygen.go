@@ -0,0 +1,157 @@
+package sshutil
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// KeyType is the algorithm of a generated key pair
+type KeyType int
+
+const (
+	KeyTypeEd25519 KeyType = iota
+	KeyTypeRSA
+)
+
+// rsaKeyBits is the modulus size used for generated RSA keys
+const rsaKeyBits = 4096
+
+func (t KeyType) String() string {
+	if t == KeyTypeRSA {
+		return "rsa"
+	}
+	return "ed25519"
+}
+
+// DefaultKeyName is the file name ssh-keygen would use for the key type
+func (t KeyType) DefaultKeyName() string {
+	return "id_" + t.String()
+}
+
+// GeneratedKey describes a key pair written to disk
+type GeneratedKey struct {
+	PrivateKeyPath string
+	PublicKeyPath  string
+	AuthorizedKey  string // public key in authorized_keys format, without trailing newline
+	Fingerprint    string
+}
+
+// GenerateKeyPair creates a new key pair and writes it to path (mode 0600)
+// and path.pub (mode 0644), creating the parent directory with mode 0700.
+// Existing files are never overwritten. A non-empty passphrase encrypts the
+// private key in OpenSSH format.
+func GenerateKeyPair(keyType KeyType, path, comment, passphrase string) (*GeneratedKey, error) {
+	if path == "" {
+		return nil, errors.New("key path is required")
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("%s already exists", path)
+	}
+	if _, err := os.Stat(path + ".pub"); err == nil {
+		return nil, fmt.Errorf("%s.pub already exists", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory: %w", err)
+	}
+
+	var privateKey crypto.PrivateKey
+	var publicKey crypto.PublicKey
+	switch keyType {
+	case KeyTypeRSA:
+		key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate rsa key: %w", err)
+		}
+		privateKey, publicKey = key, &key.PublicKey
+	default:
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ed25519 key: %w", err)
+		}
+		privateKey, publicKey = priv, pub
+	}
+
+	var block *pem.Block
+	var err error
+	if passphrase != "" {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(privateKey, comment, []byte(passphrase))
+	} else {
+		block, err = ssh.MarshalPrivateKey(privateKey, comment)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+
+	sshPub, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+	authorizedKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPub)))
+	if comment != "" {
+		authorizedKey += " " + comment
+	}
+
+	// O_EXCL guards against a file appearing between the Stat above and here
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create private key file: %w", err)
+	}
+	if err := pem.Encode(f, block); err != nil {
+		f.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to write private key: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to write private key: %w", err)
+	}
+	if err := os.WriteFile(path+".pub", []byte(authorizedKey+"\n"), 0644); err != nil {
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to write public key: %w", err)
+	}
+
+	log.Printf("Generated %s key pair at %s", keyType, path)
+	return &GeneratedKey{
+		PrivateKeyPath: path,
+		PublicKeyPath:  path + ".pub",
+		AuthorizedKey:  authorizedKey,
+		Fingerprint:    ssh.FingerprintSHA256(sshPub),
+	}, nil
+}
+
+// PublicKeyInfo summarizes the .pub file next to a private key
+type PublicKeyInfo struct {
+	Type          string
+	Comment       string
+	Fingerprint   string
+	AuthorizedKey string
+}
+
+// ReadPublicKeyInfo parses privateKeyPath + ".pub"
+func ReadPublicKeyInfo(privateKeyPath string) (*PublicKeyInfo, error) {
+	data, err := os.ReadFile(privateKeyPath + ".pub")
+	if err != nil {
+		return nil, err
+	}
+	pub, comment, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return &PublicKeyInfo{
+		Type:          pub.Type(),
+		Comment:       comment,
+		Fingerprint:   ssh.FingerprintSHA256(pub),
+		AuthorizedKey: strings.TrimSpace(string(data)),
+	}, nil
+}
@@ -0,0 +1,95 @@
+package sshutil
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestGenerateKeyPair(t *testing.T) {
+	for _, tt := range []struct {
+		keyType    KeyType
+		passphrase string
+		wantType   string
+	}{
+		{KeyTypeEd25519, "", ssh.KeyAlgoED25519},
+		{KeyTypeEd25519, "secret", ssh.KeyAlgoED25519},
+		{KeyTypeRSA, "", ssh.KeyAlgoRSA},
+		{KeyTypeRSA, "secret", ssh.KeyAlgoRSA},
+	} {
+		name := tt.keyType.String()
+		if tt.passphrase != "" {
+			name += " with passphrase"
+		}
+		path := filepath.Join(t.TempDir(), "keys", tt.keyType.DefaultKeyName())
+		key, err := GenerateKeyPair(tt.keyType, path, "me@host", tt.passphrase)
+		if err != nil {
+			t.Fatalf("%s: GenerateKeyPair: %v", name, err)
+		}
+
+		for file, want := range map[string]os.FileMode{path: 0600, path + ".pub": 0644, filepath.Dir(path): 0700 | os.ModeDir} {
+			info, err := os.Stat(file)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if info.Mode() != want {
+				t.Errorf("%s: %s has mode %v, want %v", name, file, info.Mode(), want)
+			}
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var signer ssh.Signer
+		if tt.passphrase == "" {
+			signer, err = ssh.ParsePrivateKey(data)
+		} else {
+			if _, err := ssh.ParsePrivateKey(data); err == nil {
+				t.Errorf("%s: the private key is not encrypted", name)
+			}
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(data, []byte(tt.passphrase))
+		}
+		if err != nil {
+			t.Fatalf("%s: parsing the private key: %v", name, err)
+		}
+		if got := signer.PublicKey().Type(); got != tt.wantType {
+			t.Errorf("%s: key type %s, want %s", name, got, tt.wantType)
+		}
+		if got := ssh.FingerprintSHA256(signer.PublicKey()); got != key.Fingerprint {
+			t.Errorf("%s: private key fingerprint %s, want %s", name, got, key.Fingerprint)
+		}
+
+		info, err := ReadPublicKeyInfo(path)
+		if err != nil {
+			t.Fatalf("%s: ReadPublicKeyInfo: %v", name, err)
+		}
+		if info.Comment != "me@host" || info.Fingerprint != key.Fingerprint || info.AuthorizedKey != key.AuthorizedKey {
+			t.Errorf("%s: public key %+v does not match %+v", name, info, key)
+		}
+	}
+}
+
+func TestGenerateKeyPairKeepsExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, existing := range []string{"id_ed25519", "id_ed25519.pub"} {
+		path := filepath.Join(dir, existing)
+		if err := os.WriteFile(path, []byte("keep me\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := GenerateKeyPair(KeyTypeEd25519, filepath.Join(dir, "id_ed25519"), "", "")
+		if err == nil || !strings.Contains(err.Error(), "already exists") {
+			t.Errorf("Expected an existing %s to be refused, got %v", existing, err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != "keep me\n" {
+			t.Errorf("Expected %s left as it was, got %q", existing, data)
+		}
+		os.Remove(path)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected nothing written, found %d files", len(entries))
+	}
+}